@@ -0,0 +1,296 @@
+// Package accesslog records the bodies of write requests and their responses,
+// for debugging reports of edits that went missing. Only a sample of requests
+// is kept, secrets such as passwords and tokens are redacted before anything
+// is written, and entries go to a rotating file or the standard logger as one
+// JSON object per line.
+package accesslog
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Redacted replaces the values of secret fields
+const Redacted = "[REDACTED]"
+
+// DefaultMaxBody caps the bytes of each body recorded when
+// ACCESS_LOG_MAX_BODY_BYTES is not set
+const DefaultMaxBody = 64 << 10
+
+// DefaultRedact are the field names whose values are never recorded; a
+// field is redacted when its lowercased name contains one of them
+var DefaultRedact = []string{"password", "passwd", "token", "secret", "api_key", "apikey", "authorization", "credential"}
+
+// DefaultRouteRedact lists, per route, the fields holding secrets whose names
+// do not say so, such as the key a new API key is answered with. They are
+// redacted by exact name at any depth of that route's bodies. Routes are gin
+// paths without their /api/v<version> prefix.
+var DefaultRouteRedact = map[string][]string{
+	"/admin/api-keys":          {"key"},
+	"/admin/chat-channels":     {"webhook_url"},
+	"/admin/chat-channels/:id": {"webhook_url"},
+	"/admin/integrations":      {"headers"},
+	"/admin/integrations/:id":  {"headers"},
+}
+
+// versionPrefix matches the /api/v<version> prefix of routes
+var versionPrefix = regexp.MustCompile(`^/api/v[0-9]+`)
+
+// Logger records sampled write requests
+type Logger struct {
+	// SampleRate is the share of write requests recorded, from 0 to 1
+	SampleRate float64
+	// MaxBody caps the bytes of each body recorded; longer bodies are cut off
+	MaxBody int
+	// Redact lists the field names whose values are replaced with Redacted
+	Redact []string
+	// RouteRedact lists further fields redacted on particular routes, as
+	// DefaultRouteRedact does
+	RouteRedact map[string][]string
+
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// Entry is one recorded request
+type Entry struct {
+	Time         time.Time       `json:"time"`
+	Method       string          `json:"method"`
+	Path         string          `json:"path"`
+	Query        string          `json:"query,omitempty"`
+	Status       int             `json:"status"`
+	DurationMS   int64           `json:"duration_ms"`
+	Caller       string          `json:"caller,omitempty"`
+	KeyID        uint            `json:"key_id,omitempty"`
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+}
+
+// New returns a logger writing entries to out
+func New(out io.Writer, sampleRate float64) *Logger {
+	return &Logger{SampleRate: sampleRate, MaxBody: DefaultMaxBody, Redact: DefaultRedact, RouteRedact: DefaultRouteRedact, out: out}
+}
+
+// FromEnv returns the logger ACCESS_LOG=true enables, or nil. Entries are
+// written to ACCESS_LOG_FILE, rotated once it reaches
+// ACCESS_LOG_MAX_FILE_BYTES keeping ACCESS_LOG_BACKUPS old files, or to the
+// standard logger when no file is named. ACCESS_LOG_SAMPLE_RATE (default 1)
+// sets the share of requests recorded, ACCESS_LOG_MAX_BODY_BYTES the size
+// kept of each body and ACCESS_LOG_REDACT adds comma-separated field names
+// to redact.
+func FromEnv() (*Logger, error) {
+	if os.Getenv("ACCESS_LOG") != "true" {
+		return nil, nil
+	}
+
+	rate := 1.0
+	if value := os.Getenv("ACCESS_LOG_SAMPLE_RATE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return nil, fmt.Errorf("ACCESS_LOG_SAMPLE_RATE must be between 0 and 1, got %q", value)
+		}
+		rate = parsed
+	}
+
+	var out io.Writer = log.Writer()
+	if path := os.Getenv("ACCESS_LOG_FILE"); path != "" {
+		maxBytes, err := envInt("ACCESS_LOG_MAX_FILE_BYTES", DefaultMaxFileBytes)
+		if err != nil {
+			return nil, err
+		}
+		backups, err := envInt("ACCESS_LOG_BACKUPS", DefaultBackups)
+		if err != nil {
+			return nil, err
+		}
+		file, err := OpenRotating(path, int64(maxBytes), backups)
+		if err != nil {
+			return nil, err
+		}
+		out = file
+	}
+
+	logger := New(out, rate)
+	maxBody, err := envInt("ACCESS_LOG_MAX_BODY_BYTES", DefaultMaxBody)
+	if err != nil {
+		return nil, err
+	}
+	logger.MaxBody = maxBody
+	for _, name := range strings.Split(os.Getenv("ACCESS_LOG_REDACT"), ",") {
+		if name = strings.ToLower(strings.TrimSpace(name)); name != "" {
+			logger.Redact = append(logger.Redact, name)
+		}
+	}
+	return logger, nil
+}
+
+// Handler records a sample of POST, PUT, PATCH and DELETE requests with
+// their request and response bodies. It reads the request body before the
+// handlers do, so it must run after anything limiting the body's size.
+func (l *Logger) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isWrite(c.Request.Method) || rand.Float64() >= l.SampleRate {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var request []byte
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				log.Printf("Access log could not read the request body: %v", err)
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			request = body
+		}
+		writer := &captureWriter{ResponseWriter: c.Writer, max: l.MaxBody}
+		c.Writer = writer
+
+		c.Next()
+
+		caller := auth.CallerFrom(c)
+		fields := l.RouteRedact[versionPrefix.ReplaceAllString(c.FullPath(), "")]
+		entry := Entry{
+			Time:         start.UTC(),
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.Path,
+			Query:        l.redactQuery(c.Request.URL.RawQuery),
+			Status:       writer.Status(),
+			DurationMS:   time.Since(start).Milliseconds(),
+			Caller:       caller.Name,
+			KeyID:        caller.KeyID,
+			RequestBody:  l.body(request, c.ContentType(), fields),
+			ResponseBody: l.body(writer.body.Bytes(), writer.Header().Get("Content-Type"), fields),
+		}
+		l.write(entry)
+	}
+}
+
+func (l *Logger) write(entry Entry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Access log could not encode an entry: %v", err)
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.out.Write(append(line, '\n')); err != nil {
+		log.Printf("Access log could not be written: %v", err)
+	}
+}
+
+// body returns a recorded body: JSON with its secrets and fields redacted, or
+// a note of its size and type for anything else or JSON cut off at MaxBody
+func (l *Logger) body(data []byte, contentType string, fields []string) json.RawMessage {
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) <= l.MaxBody && strings.Contains(contentType, "json") {
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err == nil {
+			redacted, err := json.Marshal(l.redact(value, fields))
+			if err == nil {
+				return redacted
+			}
+		}
+	}
+	note, _ := json.Marshal(fmt.Sprintf("[%d bytes of %s]", len(data), contentType))
+	return note
+}
+
+// redact replaces the values of secret fields and of fields at any depth
+func (l *Logger) redact(value interface{}, fields []string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if l.secret(key) || slices.Contains(fields, strings.ToLower(key)) {
+				v[key] = Redacted
+			} else {
+				v[key] = l.redact(field, fields)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = l.redact(item, fields)
+		}
+	}
+	return value
+}
+
+func (l *Logger) redactQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+	for key := range values {
+		if l.secret(key) {
+			values[key] = []string{Redacted}
+		}
+	}
+	return values.Encode()
+}
+
+func (l *Logger) secret(name string) bool {
+	name = strings.ToLower(name)
+	for _, rule := range l.Redact {
+		if strings.Contains(name, rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWrite(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// captureWriter keeps a copy of up to max+1 bytes of the response, one more
+// than is recorded so a cut off body is told from one of exactly max bytes
+type captureWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	max  int
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	if room := w.max + 1 - w.body.Len(); room > 0 {
+		w.body.Write(data[:min(room, len(data))])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func envInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 {
+		return 0, fmt.Errorf("%s must be a positive integer, got %q", name, value)
+	}
+	return parsed, nil
+}
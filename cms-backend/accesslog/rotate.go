@@ -0,0 +1,95 @@
+package accesslog
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Defaults for rotating log files
+const (
+	DefaultMaxFileBytes = 10 << 20
+	DefaultBackups      = 3
+)
+
+// Rotating is a log file that is moved aside once it reaches MaxBytes:
+// path becomes path.1, path.1 becomes path.2 and so on, keeping Backups old
+// files, and writing continues in a new file at path
+type Rotating struct {
+	Path     string
+	MaxBytes int64
+	Backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotating opens path for appending, creating it if needed
+func OpenRotating(path string, maxBytes int64, backups int) (*Rotating, error) {
+	r := &Rotating{Path: path, MaxBytes: maxBytes, Backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p, rotating the file first when p would take it past MaxBytes
+func (r *Rotating) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size > 0 && r.size+int64(len(p)) > r.MaxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file
+func (r *Rotating) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+func (r *Rotating) open() error {
+	file, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	r.file, r.size = file, info.Size()
+	return nil
+}
+
+func (r *Rotating) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if r.Backups < 1 {
+		if err := os.Remove(r.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+	for i := r.Backups - 1; i >= 1; i-- {
+		if err := os.Rename(backupName(r.Path, i), backupName(r.Path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(r.Path, backupName(r.Path, 1)); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
@@ -0,0 +1,183 @@
+// Package acl checks per-post and per-page grants. Roles decide what a caller
+// may do in general; an entity with ACL entries is further restricted to admins
+// and the principals granted access to it. Entities without entries are
+// unaffected, so ACLs are opt-in per post or page.
+package acl
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entity types that can carry ACL entries
+const (
+	EntityPost = "post"
+	EntityPage = "page"
+)
+
+// Principal types a grant can name
+const (
+	// PrincipalKey grants access to a single API key
+	PrincipalKey = "key"
+
+	// PrincipalGroup grants access to every member of a group
+	PrincipalGroup = "group"
+)
+
+// Permissions, from least to most powerful; each includes the ones before it
+const (
+	Read    = "read"
+	Write   = "write"
+	Publish = "publish"
+)
+
+// ValidPermission reports whether p is a known permission
+func ValidPermission(p string) bool {
+	return p == Read || p == Write || p == Publish
+}
+
+// granting lists the permissions that include p
+func granting(p string) []string {
+	switch p {
+	case Read:
+		return []string{Read, Write, Publish}
+	case Write:
+		return []string{Write, Publish}
+	}
+	return []string{Publish}
+}
+
+// Bypass reports whether the caller ignores ACLs altogether
+func Bypass(caller auth.Caller) bool {
+	return caller.Role == auth.RoleAdmin
+}
+
+// Manages reports whether the caller's role takes part in managing content,
+// and so may see drafts, embargoed and members-only content at all
+func Manages(caller auth.Caller) bool {
+	switch caller.Role {
+	case auth.RoleAdmin, auth.RoleEditor, auth.RoleContributor:
+		return true
+	}
+	return false
+}
+
+// Published returns a scope limiting a query on table to content readers may
+// see: published, out of any embargo, and members-only content only when
+// members is set
+func Published(table string, members bool) func(*gorm.DB) *gorm.DB {
+	now := time.Now()
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where(table+".status = ?", models.StatusPublished).
+			Where(table+".embargoed_until IS NULL OR "+table+".embargoed_until <= ?", now)
+		if !members {
+			db = db.Where(table+".visibility <> ?", models.VisibilityMembers)
+		}
+		return db
+	}
+}
+
+// Readable returns a scope limiting a query on table, the entity's table such
+// as "posts", to rows the caller may read. Callers without a management role
+// read content as the delivery API shows it; for the others published content
+// stays readable. The check runs inside the list query so pages of results
+// need no further lookups.
+func Readable(caller auth.Caller, entity, table string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if Bypass(caller) {
+			return db
+		}
+		if !Manages(caller) {
+			return db.Scopes(Published(table, caller.Role == auth.RoleMember))
+		}
+		restricted := db.Session(&gorm.Session{NewDB: true}).Model(&models.ACLEntry{}).
+			Select("1").
+			Where("acl_entries.entity_type = ? AND acl_entries.entity_id = "+table+".id", entity)
+		groups := db.Session(&gorm.Session{NewDB: true}).Model(&models.GroupMember{}).
+			Select("group_id").
+			Where("api_key_id = ?", caller.KeyID)
+		granted := db.Session(&gorm.Session{NewDB: true}).Model(&models.ACLEntry{}).
+			Select("1").
+			Where("acl_entries.entity_type = ? AND acl_entries.entity_id = "+table+".id", entity).
+			Where("(acl_entries.principal_type = ? AND acl_entries.principal_id = ?) OR (acl_entries.principal_type = ? AND acl_entries.principal_id IN (?))",
+				PrincipalKey, caller.KeyID, PrincipalGroup, groups).
+			Where("acl_entries.permission IN ?", granting(Read))
+		return db.Where(table+".status = ? OR NOT EXISTS (?) OR EXISTS (?)", models.StatusPublished, restricted, granted)
+	}
+}
+
+// Denied returns the IDs among ids the caller lacks permission on, loading
+// the entries for all of them in one query
+func Denied(db *gorm.DB, caller auth.Caller, entity string, ids []uint, permission string) (map[uint]bool, error) {
+	denied := make(map[uint]bool)
+	if Bypass(caller) || len(ids) == 0 {
+		return denied, nil
+	}
+
+	var entries []models.ACLEntry
+	if err := db.Where("entity_type = ? AND entity_id IN ?", entity, ids).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	// Group memberships are only looked up when a group grant could apply
+	var groups map[uint]bool
+	for _, entry := range entries {
+		if entry.PrincipalType == PrincipalGroup && !caller.Anonymous() && includes(entry.Permission, permission) {
+			var err error
+			if groups, err = memberships(db, caller.KeyID); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	allowed := make(map[uint]bool)
+	for _, entry := range entries {
+		denied[entry.EntityID] = true
+		if caller.Anonymous() || !includes(entry.Permission, permission) {
+			continue
+		}
+		if (entry.PrincipalType == PrincipalKey && entry.PrincipalID == caller.KeyID) ||
+			(entry.PrincipalType == PrincipalGroup && groups[entry.PrincipalID]) {
+			allowed[entry.EntityID] = true
+		}
+	}
+	for id := range allowed {
+		delete(denied, id)
+	}
+	return denied, nil
+}
+
+// Allowed reports whether the caller holds permission on one entity
+func Allowed(db *gorm.DB, caller auth.Caller, entity string, id uint, permission string) (bool, error) {
+	denied, err := Denied(db, caller, entity, []uint{id}, permission)
+	if err != nil {
+		return false, err
+	}
+	return !denied[id], nil
+}
+
+// memberships returns the IDs of the groups an API key belongs to
+func memberships(db *gorm.DB, keyID uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := db.Model(&models.GroupMember{}).Where("api_key_id = ?", keyID).Pluck("group_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	groups := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		groups[id] = true
+	}
+	return groups, nil
+}
+
+// includes reports whether a grant of held covers permission
+func includes(held, permission string) bool {
+	for _, p := range granting(permission) {
+		if p == held {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+// Package adminui serves the single-page admin app from files embedded in
+// the binary, so the API and its admin UI ship together. The app's build
+// output goes in adminui/dist before the server is built; requests for paths
+// that are not files get index.html, so the app's own routes load directly.
+package adminui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultPath is where the admin UI is served when ADMIN_UI_PATH is not set
+const DefaultPath = "/admin"
+
+//go:embed all:dist
+var embedded embed.FS
+
+// UI serves an admin app's files under Path
+type UI struct {
+	Path  string
+	Files fs.FS
+}
+
+// New returns a UI serving files at urlPath
+func New(urlPath string, files fs.FS) *UI {
+	return &UI{Path: "/" + strings.Trim(urlPath, "/"), Files: files}
+}
+
+// FromEnv returns the UI ADMIN_UI=true enables, or nil. It is served at
+// ADMIN_UI_PATH from the embedded build, or from ADMIN_UI_DIR on disk while
+// developing the app.
+func FromEnv() *UI {
+	if os.Getenv("ADMIN_UI") != "true" {
+		return nil
+	}
+	urlPath := os.Getenv("ADMIN_UI_PATH")
+	if urlPath == "" {
+		urlPath = DefaultPath
+	}
+	if dir := os.Getenv("ADMIN_UI_DIR"); dir != "" {
+		return New(urlPath, os.DirFS(dir))
+	}
+	files, _ := fs.Sub(embedded, "dist")
+	return New(urlPath, files)
+}
+
+// Register adds the UI's routes to router
+func (ui *UI) Register(router *gin.Engine) {
+	router.GET(ui.Path+"/*filepath", ui.serve)
+	router.HEAD(ui.Path+"/*filepath", ui.serve)
+}
+
+// serve answers with the named file, or index.html for paths that look like
+// app routes. Missing files with an extension are 404s, so a stale asset
+// link does not load the app as a script.
+func (ui *UI) serve(c *gin.Context) {
+	name := strings.TrimPrefix(path.Clean("/"+c.Param("filepath")), "/")
+	if name != "" && name != "index.html" {
+		if info, err := fs.Stat(ui.Files, name); err == nil && !info.IsDir() {
+			// Bundlers put a content hash in asset names, so they never change
+			if strings.HasPrefix(name, "assets/") {
+				c.Header("Cache-Control", "public, max-age=31536000, immutable")
+			}
+			http.ServeFileFS(c.Writer, c.Request, ui.Files, name)
+			return
+		}
+		if path.Ext(name) != "" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+	}
+
+	index, err := fs.ReadFile(ui.Files, "index.html")
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	// The index names the current assets, so it is always revalidated
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+}
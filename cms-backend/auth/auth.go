@@ -0,0 +1,164 @@
+// Package auth identifies the caller of a request and the role it acts with.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Roles an API key can carry, from most to least privileged
+const (
+	RoleAdmin       = "admin"
+	RoleEditor      = "editor"
+	RoleContributor = "contributor"
+	RoleDelivery    = "delivery"
+
+	// RoleMember is for delivery tokens issued to members, which may also
+	// read members-only content
+	RoleMember = "member"
+
+	// RoleSubmitter is for external contributors, who may only use the
+	// submission portal
+	RoleSubmitter = "submitter"
+)
+
+// Scopes split API keys into two token types. Delivery tokens may only read
+// published content from the delivery API; management tokens use the rest of
+// the API and are kept off the delivery API, so they never need to ship in
+// front-end code.
+const (
+	ScopeDelivery   = "delivery"
+	ScopeManagement = "management"
+)
+
+// Audiences of the delivery API: members see members-only content as well
+// as public content
+const (
+	AudiencePublic  = "public"
+	AudienceMembers = "members"
+)
+
+// KeyPrefix starts every generated API key so leaked keys are easy to recognise
+const KeyPrefix = "cms_"
+
+// scopePrefixes follow KeyPrefix and tell the token type apart at a glance
+var scopePrefixes = map[string]string{
+	ScopeDelivery:   KeyPrefix + "dlv_",
+	ScopeManagement: KeyPrefix + "mgt_",
+}
+
+// callerKey is the gin context key holding the Caller
+const callerKey = "caller"
+
+// audienceKey is the gin context key holding the delivery audience
+const audienceKey = "audience"
+
+// Caller is the identity a request acts as
+type Caller struct {
+	// Name identifies the caller; contributors are matched to posts by author name
+	Name string
+
+	// Role is one of the Role constants, empty for anonymous callers
+	Role string
+
+	// KeyID is the API key used, zero for anonymous callers
+	KeyID uint
+
+	// Scopes narrow what the key may do within its role, e.g. "posts:read";
+	// nil when the key has none and its role alone applies
+	Scopes []string
+}
+
+// Anonymous reports whether the request carried no API key
+func (c Caller) Anonymous() bool {
+	return c.KeyID == 0
+}
+
+// Scope returns the scope of the caller's key, empty for anonymous callers
+func (c Caller) Scope() string {
+	if c.Anonymous() {
+		return ""
+	}
+	return ScopeOf(c.Role)
+}
+
+// ScopeOf returns the scope of keys issued for role; delivery and member keys
+// get delivery tokens and every other role a management token
+func ScopeOf(role string) string {
+	if role == RoleDelivery || role == RoleMember {
+		return ScopeDelivery
+	}
+	return ScopeManagement
+}
+
+// ValidRole reports whether role is a known role
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleEditor, RoleContributor, RoleDelivery, RoleMember, RoleSubmitter:
+		return true
+	}
+	return false
+}
+
+// SetCaller stores the caller on the request context
+func SetCaller(c *gin.Context, caller Caller) {
+	c.Set(callerKey, caller)
+}
+
+// CallerFrom returns the caller of a request, or an anonymous caller when none was set
+func CallerFrom(c *gin.Context) Caller {
+	if value, ok := c.Get(callerKey); ok {
+		if caller, ok := value.(Caller); ok {
+			return caller
+		}
+	}
+	return Caller{}
+}
+
+// SetAudience stores the delivery audience of the request, one of the
+// Audience constants
+func SetAudience(c *gin.Context, audience string) {
+	c.Set(audienceKey, audience)
+}
+
+// AudienceFrom returns the delivery audience of a request, AudiencePublic
+// when none was set
+func AudienceFrom(c *gin.Context) string {
+	if audience := c.GetString(audienceKey); audience != "" {
+		return audience
+	}
+	return AudiencePublic
+}
+
+// KeyPrefixFor returns the prefix of generated keys with scope
+func KeyPrefixFor(scope string) string {
+	return scopePrefixes[scope]
+}
+
+// GenerateKey returns a new random API key of scope
+func GenerateKey(scope string) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return KeyPrefixFor(scope) + hex.EncodeToString(buf), nil
+}
+
+// prefixLength is how much of a key APIKey.Prefix keeps: the scope prefix
+// and eight random characters
+const prefixLength = 16
+
+// PrefixOf returns the start of key kept in APIKey.Prefix, which tells keys
+// apart without revealing them
+func PrefixOf(key string) string {
+	return key[:min(len(key), prefixLength)]
+}
+
+// HashKey returns the digest stored in place of an API key
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
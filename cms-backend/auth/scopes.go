@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Actions a fine-grained scope grants on a resource, each including the ones
+// before it: "posts:write" also reads posts, and "settings:admin" also
+// writes settings
+const (
+	ActionRead  = "read"
+	ActionWrite = "write"
+	ActionAdmin = "admin"
+)
+
+// AnyResource in place of a resource grants the action on every resource, as in "*:read"
+const AnyResource = "*"
+
+// actionRanks orders the actions by what they grant
+var actionRanks = map[string]int{
+	ActionRead:  1,
+	ActionWrite: 2,
+	ActionAdmin: 3,
+}
+
+// resourcePattern matches resource names, which are the first segment of
+// the routes they cover, e.g. posts, media or api-keys
+var resourcePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// ParseScopes splits a comma-separated list of scopes such as
+// "posts:read,media:write". An empty list returns nil, which leaves a key
+// every route its role allows.
+func ParseScopes(raw string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(raw, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		resource, action, ok := strings.Cut(scope, ":")
+		if !ok || (resource != AnyResource && !resourcePattern.MatchString(resource)) || actionRanks[action] == 0 {
+			return nil, fmt.Errorf("invalid scope %q, expected <resource>:read, <resource>:write or <resource>:admin", scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	return scopes, nil
+}
+
+// ActionFor returns the action a request with method needs: reading for
+// GET, HEAD and OPTIONS and writing for everything else
+func ActionFor(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ActionRead
+	}
+	return ActionWrite
+}
+
+// Granted reports whether scopes grant action on resource
+func Granted(scopes []string, resource, action string) bool {
+	for _, scope := range scopes {
+		r, a, _ := strings.Cut(scope, ":")
+		if (r == resource || r == AnyResource) && actionRanks[a] >= actionRanks[action] {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows reports whether the caller's key grants action on resource. Keys
+// without scopes, and anonymous callers, are limited by their role alone.
+func (c Caller) Allows(resource, action string) bool {
+	return c.Scopes == nil || Granted(c.Scopes, resource, action)
+}
@@ -0,0 +1,502 @@
+// Package backup writes logical backups of the site's content and restores
+// them into an empty database. A backup is a gzipped JSON Lines file: a
+// header naming the schema version, then one line per row of each content
+// table. Media files stay where they are stored; the media rows in a backup
+// are the manifest of them. Credentials, logs and caches are left out.
+package backup
+
+import (
+	"bufio"
+	"cms-backend/jobs"
+	"cms-backend/migrations"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job types for backups run through the jobs API
+const (
+	JobTypeCreate  = "backup_create"
+	JobTypeRestore = "backup_restore"
+)
+
+// format is the version of the backup file layout
+const format = 1
+
+// defaultKeep is how many backups are kept when BACKUP_KEEP is not set
+const defaultKeep = 7
+
+// restoreBatchSize is how many rows are inserted per statement on restore
+const restoreBatchSize = 500
+
+// nameFormat is the timestamp in backup file names
+const nameFormat = "20060102T150405Z"
+
+// namePattern matches the names of backup files
+var namePattern = regexp.MustCompile(`^backup-\d{8}T\d{6}Z\.jsonl\.gz$`)
+
+// ErrNotFound is returned for a backup that does not exist
+var ErrNotFound = errors.New("backup not found")
+
+// ErrNotEmpty is returned when restoring into a database that has content
+var ErrNotEmpty = errors.New("the database already has content; backups are only restored into an empty database")
+
+// table is a content table a backup holds. Deferred columns reference rows
+// of the same or a later table, so they are filled in once every row exists.
+// Seeded tables get rows from the migrations, which a restore replaces.
+type table struct {
+	name     string
+	hasID    bool
+	deferred []string
+	seeded   bool
+}
+
+// tables are backed up and restored in this order, so each row is inserted
+// after the rows it references
+var tables = []table{
+	{name: "groups", hasID: true},
+	{name: "media", hasID: true, deferred: []string{"uploaded_by_id"}},
+	{name: "external_assets", hasID: true},
+	{name: "users", hasID: true},
+	{name: "tags", hasID: true, deferred: []string{"parent_id"}},
+	{name: "pages", hasID: true, deferred: []string{"parent_id"}},
+	{name: "posts", hasID: true},
+	{name: "post_media"},
+	{name: "post_tags"},
+	{name: "post_relations"},
+	{name: "series", hasID: true},
+	{name: "series_posts"},
+	{name: "playlists", hasID: true},
+	{name: "playlist_items", hasID: true},
+	{name: "collections", hasID: true},
+	{name: "redirects", hasID: true},
+	{name: "settings", hasID: true, seeded: true},
+	{name: "forms", hasID: true},
+	{name: "form_fields", hasID: true},
+	{name: "environments", hasID: true},
+	{name: "environment_entries", hasID: true},
+	{name: "acl_entries", hasID: true},
+}
+
+// header is the first line of a backup
+type header struct {
+	Format        int       `json:"format"`
+	SchemaVersion uint      `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// line is a row of a table in a backup
+type line struct {
+	Table string                 `json:"table"`
+	Row   map[string]interface{} `json:"row"`
+}
+
+// Info describes a stored backup
+type Info struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Policy keeps backups in Dir, creating one every Interval when it is set
+// and keeping the newest Keep
+type Policy struct {
+	Dir      string
+	Interval time.Duration
+	Keep     int
+}
+
+// Default is the policy the endpoints and the scheduler use. It is set once
+// at startup.
+var Default = &Policy{Dir: "backups", Keep: defaultKeep}
+
+// FromEnv returns a policy keeping backups in BACKUP_DIR (default
+// "backups"), creating one every BACKUP_INTERVAL when it is set and keeping
+// the newest BACKUP_KEEP (default 7). The directory is not served, since
+// backups hold unpublished content.
+func FromEnv() *Policy {
+	policy := &Policy{Dir: os.Getenv("BACKUP_DIR"), Keep: defaultKeep}
+	if policy.Dir == "" {
+		policy.Dir = "backups"
+	}
+	if value := os.Getenv("BACKUP_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			log.Printf("Ignoring invalid BACKUP_INTERVAL %q", value)
+		} else {
+			policy.Interval = interval
+		}
+	}
+	if value := os.Getenv("BACKUP_KEEP"); value != "" {
+		keep, err := strconv.Atoi(value)
+		if err != nil || keep < 1 {
+			log.Printf("Ignoring invalid BACKUP_KEEP %q", value)
+		} else {
+			policy.Keep = keep
+		}
+	}
+	return policy
+}
+
+// List returns the stored backups, newest first
+func (p *Policy) List() ([]Info, error) {
+	entries, err := os.ReadDir(p.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	backups := []Info{}
+	for _, entry := range entries {
+		if !namePattern.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		created, _ := time.Parse(nameFormat, entry.Name()[len("backup-"):len("backup-")+len(nameFormat)])
+		backups = append(backups, Info{Name: entry.Name(), Size: info.Size(), CreatedAt: created})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+// Find returns the stored backup called name
+func (p *Policy) Find(name string) (Info, error) {
+	if !namePattern.MatchString(name) {
+		return Info{}, ErrNotFound
+	}
+	backups, err := p.List()
+	if err != nil {
+		return Info{}, err
+	}
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, nil
+		}
+	}
+	return Info{}, ErrNotFound
+}
+
+// Due reports whether a scheduled backup should be made at now: the
+// interval is set and the newest backup is older than it
+func (p *Policy) Due(now time.Time) (bool, error) {
+	if p.Interval <= 0 {
+		return false, nil
+	}
+	backups, err := p.List()
+	if err != nil {
+		return false, err
+	}
+	return len(backups) == 0 || !backups[0].CreatedAt.After(now.Add(-p.Interval)), nil
+}
+
+// StartCreate starts a job creating a backup for the API key requestedBy
+func (p *Policy) StartCreate(db *gorm.DB, requestedBy uint) (*models.Job, error) {
+	return jobs.Start(db, requestedBy, JobTypeCreate, struct{}{}, func(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+		_, err := p.Create(ctx, db, time.Now(), progress)
+		return "", err
+	})
+}
+
+// StartRestore starts a job restoring the backup called name for the API key
+// requestedBy
+func (p *Policy) StartRestore(db *gorm.DB, requestedBy uint, name string) (*models.Job, error) {
+	payload := map[string]string{"name": name}
+	return jobs.Start(db, requestedBy, JobTypeRestore, payload, func(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+		return "", p.Restore(ctx, db, name, progress)
+	})
+}
+
+// Create writes a backup of the content at now, then deletes the backups
+// past the newest Keep
+func (p *Policy) Create(ctx context.Context, db *gorm.DB, now time.Time, progress func(int)) (Info, error) {
+	db = db.WithContext(ctx)
+	status, err := migrations.StatusFromDB(db)
+	if err != nil {
+		return Info{}, err
+	}
+	if err := os.MkdirAll(p.Dir, 0o700); err != nil {
+		return Info{}, err
+	}
+
+	// The file is written under a temporary name and moved into place once
+	// complete, so a failed backup is never listed
+	name := "backup-" + now.UTC().Format(nameFormat) + ".jsonl.gz"
+	tmp, err := os.CreateTemp(p.Dir, ".backup-*")
+	if err != nil {
+		return Info{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := p.write(db, tmp, header{Format: format, SchemaVersion: status.Version, CreatedAt: now.UTC()}, progress); err != nil {
+		tmp.Close()
+		return Info{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return Info{}, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(p.Dir, name)); err != nil {
+		return Info{}, err
+	}
+
+	if err := p.prune(); err != nil {
+		log.Printf("Pruning old backups failed: %v", err)
+	}
+	return p.Find(name)
+}
+
+// write encodes every content table into file
+func (p *Policy) write(db *gorm.DB, file *os.File, head header, progress func(int)) error {
+	compressed := gzip.NewWriter(file)
+	buffered := bufio.NewWriter(compressed)
+	encoder := json.NewEncoder(buffered)
+	if err := encoder.Encode(head); err != nil {
+		return err
+	}
+
+	for i, t := range tables {
+		rows, err := db.Table(t.name).Rows()
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			row := map[string]interface{}{}
+			if err := db.ScanRows(rows, &row); err != nil {
+				rows.Close()
+				return err
+			}
+			// JSON columns are scanned as bytes; they are kept as text
+			for column, value := range row {
+				if data, ok := value.([]byte); ok {
+					row[column] = string(data)
+				}
+			}
+			if err := encoder.Encode(line{Table: t.name, Row: row}); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		progress((i + 1) * 95 / len(tables))
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
+
+// prune deletes the backups past the newest Keep
+func (p *Policy) prune() error {
+	backups, err := p.List()
+	if err != nil || len(backups) <= p.Keep {
+		return err
+	}
+	for _, backup := range backups[p.Keep:] {
+		if err := os.Remove(filepath.Join(p.Dir, backup.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckEmpty returns ErrNotEmpty unless every content table is empty, apart
+// from the rows the migrations seed
+func CheckEmpty(ctx context.Context, db *gorm.DB) error {
+	for _, t := range tables {
+		if t.seeded {
+			continue
+		}
+		var count int64
+		if err := db.WithContext(ctx).Table(t.name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrNotEmpty
+		}
+	}
+	return nil
+}
+
+// Restore loads the backup called name into the database in one
+// transaction. The database must be empty and migrated to the schema
+// version the backup was made at.
+func (p *Policy) Restore(ctx context.Context, db *gorm.DB, name string, progress func(int)) error {
+	if _, err := p.Find(name); err != nil {
+		return err
+	}
+	file, err := os.Open(filepath.Join(p.Dir, name))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	compressed, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bufio.NewReader(compressed))
+	decoder.UseNumber()
+
+	var head header
+	if err := decoder.Decode(&head); err != nil {
+		return fmt.Errorf("reading the backup header: %w", err)
+	}
+	if head.Format != format {
+		return fmt.Errorf("unsupported backup format %d", head.Format)
+	}
+
+	return utils.WithTransaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+		status, err := migrations.StatusFromDB(tx)
+		if err != nil {
+			return err
+		}
+		if status.Version != head.SchemaVersion {
+			return fmt.Errorf("the backup was made at schema version %d but the database is at %d", head.SchemaVersion, status.Version)
+		}
+		if err := CheckEmpty(ctx, tx); err != nil {
+			return err
+		}
+		for _, t := range tables {
+			if t.seeded {
+				if err := tx.Exec("DELETE FROM " + t.name).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		r := restorer{tx: tx, known: make(map[string]table, len(tables))}
+		for _, t := range tables {
+			r.known[t.name] = t
+		}
+		for decoder.More() {
+			var l line
+			if err := decoder.Decode(&l); err != nil {
+				return fmt.Errorf("reading the backup: %w", err)
+			}
+			if err := r.add(l); err != nil {
+				return err
+			}
+			if r.done > 0 {
+				progress(r.done * 90 / len(tables))
+			}
+		}
+		return r.finish()
+	})
+}
+
+// restorer inserts the rows of a backup in batches
+type restorer struct {
+	tx    *gorm.DB
+	known map[string]table
+
+	// current is the table rows are being collected for
+	current table
+	batch   []map[string]interface{}
+	done    int
+
+	// deferredRows are the deferred columns of inserted rows, by table
+	deferredRows map[string][]map[string]interface{}
+}
+
+// add queues a row, inserting the batch when it is full or the table changes
+func (r *restorer) add(l line) error {
+	t, ok := r.known[l.Table]
+	if !ok {
+		return fmt.Errorf("the backup holds unknown table %q", l.Table)
+	}
+	if t.name != r.current.name {
+		if err := r.flush(); err != nil {
+			return err
+		}
+		if r.current.name != "" {
+			r.done++
+		}
+		r.current = t
+	}
+
+	row := make(map[string]interface{}, len(l.Row))
+	for column, value := range l.Row {
+		// Numbers are passed as text, so Postgres parses them for the column's type
+		if number, ok := value.(json.Number); ok {
+			value = string(number)
+		}
+		row[column] = value
+	}
+	if len(t.deferred) > 0 {
+		later := map[string]interface{}{"id": row["id"]}
+		for _, column := range t.deferred {
+			if row[column] != nil {
+				later[column] = row[column]
+			}
+			row[column] = nil
+		}
+		if len(later) > 1 {
+			if r.deferredRows == nil {
+				r.deferredRows = make(map[string][]map[string]interface{})
+			}
+			r.deferredRows[t.name] = append(r.deferredRows[t.name], later)
+		}
+	}
+
+	r.batch = append(r.batch, row)
+	if len(r.batch) >= restoreBatchSize {
+		return r.flush()
+	}
+	return nil
+}
+
+// flush inserts the queued rows
+func (r *restorer) flush() error {
+	if len(r.batch) == 0 {
+		return nil
+	}
+	err := r.tx.Table(r.current.name).Create(r.batch).Error
+	r.batch = nil
+	return err
+}
+
+// finish inserts the last rows, fills in the deferred columns and moves
+// the ID sequences past the restored IDs
+func (r *restorer) finish() error {
+	if err := r.flush(); err != nil {
+		return err
+	}
+	for _, t := range tables {
+		for _, later := range r.deferredRows[t.name] {
+			id := later["id"]
+			delete(later, "id")
+			if err := r.tx.Table(t.name).Where("id = ?", id).Updates(later).Error; err != nil {
+				return err
+			}
+		}
+		if t.hasID {
+			if err := r.tx.Exec(fmt.Sprintf(
+				"SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM %s",
+				t.name, t.name)).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+// Package bucket reads an existing asset library so its files can be
+// registered as media without copying them: a directory on disk or a prefix
+// of an S3 bucket. MEDIA_BULK_IMPORT_SOURCE picks it.
+package bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Object is a file in a source
+type Object struct {
+	Key  string `json:"key"`
+	Size int64  `json:"size"`
+}
+
+// Source is an asset library files are imported from
+type Source interface {
+	// List returns every file in the source, in key order
+	List(ctx context.Context) ([]Object, error)
+
+	// Head returns up to n bytes from the start of the file at key
+	Head(ctx context.Context, key string, n int) ([]byte, error)
+
+	// URL is where the file at key is served from
+	URL(key string) string
+
+	// String names the source, for job payloads and logs
+	String() string
+}
+
+// Default is the source bulk imports read from; nil disables them. It is set
+// once at startup.
+var Default Source
+
+// FromEnv returns the source named by MEDIA_BULK_IMPORT_SOURCE, or nil when
+// it is not set. An s3://bucket/prefix source is read with the AWS_REGION,
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN credentials;
+// anything else is a directory. Files are linked at MEDIA_BULK_IMPORT_BASE_URL,
+// which directories require and S3 sources default to the bucket's own URL.
+func FromEnv() (Source, error) {
+	value := os.Getenv("MEDIA_BULK_IMPORT_SOURCE")
+	if value == "" {
+		return nil, nil
+	}
+	baseURL := os.Getenv("MEDIA_BULK_IMPORT_BASE_URL")
+
+	if rest, ok := strings.CutPrefix(value, "s3://"); ok {
+		name, prefix, _ := strings.Cut(rest, "/")
+		s3 := &S3{
+			Bucket:          name,
+			Prefix:          prefix,
+			Region:          os.Getenv("AWS_REGION"),
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			Endpoint:        os.Getenv("AWS_S3_ENDPOINT"),
+			BaseURL:         baseURL,
+		}
+		if s3.Bucket == "" {
+			return nil, fmt.Errorf("MEDIA_BULK_IMPORT_SOURCE %q names no bucket", value)
+		}
+		if s3.Region == "" || s3.AccessKeyID == "" || s3.SecretAccessKey == "" {
+			return nil, errors.New("AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for an s3:// MEDIA_BULK_IMPORT_SOURCE")
+		}
+		return s3, nil
+	}
+
+	if baseURL == "" {
+		return nil, errors.New("MEDIA_BULK_IMPORT_BASE_URL is required when MEDIA_BULK_IMPORT_SOURCE is a directory")
+	}
+	return &Dir{Root: value, BaseURL: baseURL}, nil
+}
+
+// Dir is a directory of files served at BaseURL. Hidden files and
+// directories are left out.
+type Dir struct {
+	Root    string
+	BaseURL string
+}
+
+// List walks the directory
+func (d *Dir) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(d.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if path != d.Root && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(d.Root, path)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: filepath.ToSlash(rel), Size: info.Size()})
+		return nil
+	})
+	return objects, err
+}
+
+// Head reads the start of a file
+func (d *Dir) Head(ctx context.Context, key string, n int) ([]byte, error) {
+	file, err := os.Open(filepath.Join(d.Root, filepath.FromSlash(key)))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, int64(n)))
+}
+
+// URL links a file under BaseURL
+func (d *Dir) URL(key string) string {
+	return joinURL(d.BaseURL, key)
+}
+
+func (d *Dir) String() string {
+	return d.Root
+}
+
+// joinURL appends key, escaped segment by segment, to base
+func joinURL(base, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,213 @@
+package bucket
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// emptyHash is the SHA-256 of an empty request body
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3 is the part of a bucket under Prefix, read through the S3 REST API with
+// requests signed by AWS Signature Version 4
+type S3 struct {
+	Bucket string
+	Prefix string
+	Region string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is set for temporary credentials
+	SessionToken string
+
+	// Endpoint overrides https://<bucket>.s3.<region>.amazonaws.com with an
+	// S3-compatible service addressed by path, as in <endpoint>/<bucket>/<key>
+	Endpoint string
+
+	// BaseURL is where files are served from; by default the bucket's URL
+	BaseURL string
+
+	Client *http.Client
+}
+
+// listResult is the part of a ListObjectsV2 response read
+type listResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key  string `xml:"Key"`
+		Size int64  `xml:"Size"`
+	} `xml:"Contents"`
+}
+
+// List pages through ListObjectsV2. Keys ending in "/" are folder markers,
+// not files, and are left out.
+func (s *S3) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.Prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var page listResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading the S3 object list: %w", err)
+		}
+
+		for _, content := range page.Contents {
+			if !strings.HasSuffix(content.Key, "/") {
+				objects = append(objects, Object{Key: content.Key, Size: content.Size})
+			}
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Head fetches the start of an object with a range request
+func (s *S3) Head(ctx context.Context, key string, n int) ([]byte, error) {
+	resp, err := s.do(ctx, key, nil, http.Header{"Range": {fmt.Sprintf("bytes=0-%d", n-1)}})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, int64(n)))
+}
+
+// URL links an object under BaseURL or the bucket's URL
+func (s *S3) URL(key string) string {
+	if s.BaseURL != "" {
+		return joinURL(s.BaseURL, key)
+	}
+	return joinURL(s.bucketURL(), key)
+}
+
+func (s *S3) String() string {
+	return "s3://" + s.Bucket + "/" + s.Prefix
+}
+
+// bucketURL is the URL objects are requested under
+func (s *S3) bucketURL() string {
+	if s.Endpoint != "" {
+		return strings.TrimSuffix(s.Endpoint, "/") + "/" + s.Bucket
+	}
+	return "https://" + s.Bucket + ".s3." + s.Region + ".amazonaws.com"
+}
+
+// do sends a signed GET request for key, or for the bucket when key is
+// empty, and fails unless it succeeds
+func (s *S3) do(ctx context.Context, key string, query url.Values, header http.Header) (*http.Response, error) {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	target, err := url.Parse(s.bucketURL() + "/" + strings.Join(segments, "/"))
+	if err != nil {
+		return nil, err
+	}
+	target.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, time.Now())
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, fmt.Errorf("S3 responded %d: %s", resp.StatusCode, detail)
+	}
+	return resp, nil
+}
+
+// sign adds the Signature Version 4 headers for the "s3" service
+func (s *S3) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", emptyHash)
+
+	headers := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + emptyHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signed := "host;x-amz-content-sha256;x-amz-date"
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+		headers += "x-amz-security-token:" + s.SessionToken + "\n"
+		signed += ";x-amz-security-token"
+	}
+
+	canonical := req.Method + "\n" + req.URL.EscapedPath() + "\n" + req.URL.RawQuery + "\n" +
+		headers + "\n" + signed + "\n" + emptyHash
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	sum := sha256.Sum256([]byte(canonical))
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+}
+
+// canonicalQuery encodes query sorted by name, as Signature Version 4 requires
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var pairs []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			pairs = append(pairs, awsEscape(name)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsEscape percent-encodes everything but the unreserved characters
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
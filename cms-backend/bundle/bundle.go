@@ -0,0 +1,84 @@
+package bundle
+
+import (
+	"cms-backend/models"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Format is the bundle layout version; apps refuse bundles with a newer format
+const Format = 1
+
+// Bundle is the offline content for one channel. A full bundle (Since zero)
+// holds everything published in the channel; a delta bundle holds what changed
+// after Since, with Removed listing content the app should drop.
+type Bundle struct {
+	Format      int       `json:"format"`
+	Channel     string    `json:"channel"`
+	Version     int64     `json:"version"`
+	Since       int64     `json:"since"`
+	GeneratedAt time.Time `json:"generated_at"`
+
+	Posts []models.Post `json:"posts"`
+	Pages []models.Page `json:"pages"`
+
+	// Media lists the files referenced by the bundled posts for apps to download
+	Media   []MediaItem `json:"media"`
+	Removed Removed     `json:"removed"`
+
+	seenMedia map[uint]bool
+}
+
+// MediaItem is one entry of the media manifest
+type MediaItem struct {
+	ID   uint   `json:"id"`
+	URL  string `json:"url"`
+	Type string `json:"type"`
+	Size int64  `json:"size"`
+}
+
+// Removed lists content deleted or unpublished since the base version
+type Removed struct {
+	Posts []uint `json:"posts"`
+	Pages []uint `json:"pages"`
+}
+
+// New creates an empty bundle
+func New(channel string, since, version int64, now time.Time) *Bundle {
+	return &Bundle{
+		Format:      Format,
+		Channel:     channel,
+		Version:     version,
+		Since:       since,
+		GeneratedAt: now.UTC(),
+		Posts:       []models.Post{},
+		Pages:       []models.Page{},
+		Media:       []MediaItem{},
+		Removed:     Removed{Posts: []uint{}, Pages: []uint{}},
+		seenMedia:   make(map[uint]bool),
+	}
+}
+
+// AddPost adds a post and its media to the manifest, each media file once
+func (b *Bundle) AddPost(post models.Post) {
+	b.Posts = append(b.Posts, post)
+	for _, media := range post.Media {
+		if b.seenMedia[media.ID] {
+			continue
+		}
+		b.seenMedia[media.ID] = true
+		b.Media = append(b.Media, MediaItem{ID: media.ID, URL: media.URL, Type: media.Type, Size: media.Size})
+	}
+}
+
+// Write encodes the bundle as gzip-compressed JSON
+func Write(w io.Writer, b *Bundle) error {
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(b); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
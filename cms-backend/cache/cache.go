@@ -0,0 +1,313 @@
+// Package cache is an in-memory response cache for read endpoints with
+// stale-while-revalidate semantics: fresh entries are served directly, entries
+// within their stale window are served immediately while a background request
+// refreshes them, and anything older is fetched synchronously.
+//
+// The windows of each content type can be changed at runtime through string
+// settings in the "cache" namespace, e.g. cache/posts = "30s:5m".
+package cache
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/settings"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Policy sets how long responses of one content type stay fresh and how much
+// longer they may be served stale while being revalidated
+type Policy struct {
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+}
+
+// SettingsNamespace is the settings namespace holding policy overrides, one
+// string setting per content type in the form ParsePolicy reads
+const SettingsNamespace = "cache"
+
+// DefaultPolicies apply when no setting configures a content type
+var DefaultPolicies = map[string]Policy{
+	"posts":  {TTL: 30 * time.Second, StaleWhileRevalidate: 5 * time.Minute},
+	"pages":  {TTL: 5 * time.Minute, StaleWhileRevalidate: time.Hour},
+	"media":  {TTL: 5 * time.Minute, StaleWhileRevalidate: time.Hour},
+	"tags":   {TTL: 5 * time.Minute, StaleWhileRevalidate: time.Hour},
+	"series": {TTL: time.Minute, StaleWhileRevalidate: 10 * time.Minute},
+	"stats":  {TTL: time.Minute, StaleWhileRevalidate: 10 * time.Minute},
+
+	// Frontends resolve paths and redirects for every route they render
+	"redirects": {TTL: 5 * time.Minute, StaleWhileRevalidate: time.Hour},
+
+	// The delivery API is meant to sit behind a CDN
+	"delivery": {TTL: 10 * time.Minute, StaleWhileRevalidate: 24 * time.Hour},
+}
+
+// maxEntries bounds memory use; the oldest entry is evicted when full
+const maxEntries = 1000
+
+// Cache status values reported in the X-Cache response header
+const (
+	StatusHit   = "HIT"
+	StatusStale = "STALE"
+	StatusMiss  = "MISS"
+)
+
+// revalidateKey marks internal background requests so they bypass lookups
+type revalidateKey struct{}
+
+type entry struct {
+	status   int
+	header   http.Header
+	body     []byte
+	storedAt time.Time
+}
+
+// Cache stores GET responses per content type, caller role, time zone and URL
+type Cache struct {
+	// Now is the clock used for freshness; tests replace it
+	Now func() time.Time
+
+	// Settings holds policy overrides, read from the database of each request
+	Settings *settings.Store
+
+	mu         sync.Mutex
+	policies   map[string]Policy
+	entries    map[string]*entry
+	refreshing map[string]bool
+	handler    http.Handler
+
+	// generation changes on every purge so responses computed before a write are not stored after it
+	generation uint64
+}
+
+// New creates a cache. handler receives replayed requests when stale entries are
+// revalidated and is normally the router the cache middleware is mounted on.
+func New(policies map[string]Policy, handler http.Handler) *Cache {
+	return &Cache{
+		Now:        time.Now,
+		Settings:   settings.Default,
+		policies:   policies,
+		entries:    make(map[string]*entry),
+		refreshing: make(map[string]bool),
+		handler:    handler,
+	}
+}
+
+// ParsePolicy reads a policy written as "TTL:stale-while-revalidate", e.g.
+// "30s:5m". A TTL of 0 disables caching for the content type.
+func ParsePolicy(value string) (Policy, error) {
+	ttl, swr, ok := strings.Cut(strings.TrimSpace(value), ":")
+	if !ok {
+		return Policy{}, fmt.Errorf("cache policy %q must be TTL:stale-while-revalidate", value)
+	}
+	var policy Policy
+	var err error
+	if policy.TTL, err = time.ParseDuration(ttl); err != nil || policy.TTL < 0 {
+		return Policy{}, fmt.Errorf("invalid TTL in cache policy %q", value)
+	}
+	if policy.StaleWhileRevalidate, err = time.ParseDuration(swr); err != nil || policy.StaleWhileRevalidate < 0 {
+		return Policy{}, fmt.Errorf("invalid stale window in cache policy %q", value)
+	}
+	return policy, nil
+}
+
+// policy returns the policy for a content type: its setting when one is set
+// and valid, otherwise the one the cache was created with
+func (c *Cache) policy(ctx *gin.Context, contentType string) (Policy, bool) {
+	policy, ok := c.policies[contentType]
+	db, hasDB := ctx.Get("db")
+	if c.Settings == nil || !hasDB {
+		return policy, ok
+	}
+	value := c.Settings.String(db.(*gorm.DB), SettingsNamespace, contentType, "")
+	if value == "" {
+		return policy, ok
+	}
+	override, err := ParsePolicy(value)
+	if err != nil {
+		return policy, ok
+	}
+	return override, true
+}
+
+// Handler caches successful GET responses for a content type. Only anonymous and
+// delivery callers share cached responses, since other roles see unredacted fields.
+func (c *Cache) Handler(contentType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		policy, ok := c.policy(ctx, contentType)
+		caller := auth.CallerFrom(ctx)
+		if !ok || policy.TTL <= 0 || ctx.Request.Method != http.MethodGet ||
+			!(caller.Anonymous() || caller.Role == auth.RoleDelivery) {
+			ctx.Next()
+			return
+		}
+
+		// Accept-Timezone localizes date groupings just as ?tz= does
+		key := contentType + "|" + caller.Role + "|" + ctx.GetHeader("Accept-Timezone") + "|" +
+			ctx.Request.URL.Path + "?" + ctx.Request.URL.Query().Encode()
+		revalidating := ctx.Request.Context().Value(revalidateKey{}) != nil
+
+		if !revalidating {
+			if cached, age, found := c.lookup(key); found {
+				switch {
+				case age < policy.TTL:
+					c.serve(ctx, cached, StatusHit, policy)
+					return
+				case age < policy.TTL+policy.StaleWhileRevalidate:
+					c.serve(ctx, cached, StatusStale, policy)
+					c.revalidate(key, ctx.Request)
+					return
+				}
+			}
+		}
+
+		// Miss: run the handler and keep a copy of what it writes
+		generation := c.currentGeneration()
+		recorder := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Header("X-Cache", StatusMiss)
+		ctx.Header("Cache-Control", cacheControl(policy))
+		ctx.Next()
+
+		if ctx.Writer.Status() == http.StatusOK {
+			c.store(key, generation, &entry{
+				status:   http.StatusOK,
+				header:   ctx.Writer.Header().Clone(),
+				body:     recorder.body.Bytes(),
+				storedAt: c.Now(),
+			})
+		}
+	}
+}
+
+// InvalidateOnWrite purges the cache after every successful non-GET request, so
+// editors never wait out a TTL to see their own changes on cached endpoints
+func (c *Cache) InvalidateOnWrite() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Next()
+
+		method := ctx.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			return
+		}
+		if status := ctx.Writer.Status(); status >= 200 && status < 300 {
+			c.Purge()
+		}
+	}
+}
+
+// Purge drops every cached response
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*entry)
+	c.generation++
+}
+
+func (c *Cache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+func (c *Cache) lookup(key string) (*entry, time.Duration, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.entries[key]
+	if !ok {
+		return nil, 0, false
+	}
+	return cached, c.Now().Sub(cached.storedAt), true
+}
+
+func (c *Cache) store(key string, generation uint64, e *entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= maxEntries {
+		var oldestKey string
+		var oldest time.Time
+		for k, v := range c.entries {
+			if oldestKey == "" || v.storedAt.Before(oldest) {
+				oldestKey, oldest = k, v.storedAt
+			}
+		}
+		delete(c.entries, oldestKey)
+	}
+	c.entries[key] = e
+}
+
+// revalidate replays the request in the background; the Handler middleware on
+// that request stores the fresh response. Only one refresh per key runs at a time.
+func (c *Cache) revalidate(key string, original *http.Request) {
+	c.mu.Lock()
+	if c.refreshing[key] || c.handler == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.refreshing[key] = true
+	c.mu.Unlock()
+
+	req := original.Clone(context.WithValue(context.Background(), revalidateKey{}, true))
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.refreshing, key)
+			c.mu.Unlock()
+		}()
+		c.handler.ServeHTTP(&discardWriter{header: make(http.Header)}, req)
+	}()
+}
+
+func (c *Cache) serve(ctx *gin.Context, cached *entry, status string, policy Policy) {
+	// Cached headers replace any set by earlier middleware for this request
+	for name, values := range cached.header {
+		ctx.Writer.Header()[name] = append([]string(nil), values...)
+	}
+	ctx.Header("X-Cache", status)
+	ctx.Header("Cache-Control", cacheControl(policy))
+	ctx.Status(cached.status)
+	ctx.Writer.Write(cached.body)
+	ctx.Abort()
+}
+
+func cacheControl(policy Policy) string {
+	return fmt.Sprintf("public, max-age=%d, stale-while-revalidate=%d",
+		int(policy.TTL.Seconds()), int(policy.StaleWhileRevalidate.Seconds()))
+}
+
+// recordingWriter copies the response body while passing it through
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// discardWriter receives responses to background revalidation requests
+type discardWriter struct {
+	header http.Header
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(int)             {}
@@ -0,0 +1,122 @@
+// Package captcha verifies the challenge tokens that anti-spam widgets add to
+// public form submissions. FORM_CAPTCHA picks the provider: "recaptcha",
+// "hcaptcha" and "turnstile" check tokens with the provider's siteverify API
+// using FORM_CAPTCHA_SECRET; unset or "none" accepts every submission.
+package captcha
+
+import (
+	"cms-backend/egress"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TokenField is the submission field clients may always send the token in,
+// whichever provider is configured
+const TokenField = "captcha_token"
+
+// Verifier checks the token a challenge widget issued to a visitor
+type Verifier interface {
+	// Field is the form field the provider's widget writes its token to
+	Field() string
+
+	// Verify reports whether token proves a human solved the challenge. An
+	// error means the provider could not be asked, not that the token is bad.
+	Verify(ctx context.Context, token, remoteIP string) (bool, error)
+}
+
+// Noop accepts every submission without a challenge
+type Noop struct{}
+
+// Field returns TokenField
+func (Noop) Field() string { return TokenField }
+
+// Verify accepts any token, including none
+func (Noop) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return true, nil
+}
+
+// Default is the verifier form submissions go through. It is set once at startup.
+var Default Verifier = Noop{}
+
+// Enabled reports whether v actually checks tokens, so callers can skip
+// requiring one for the no-op verifier
+func Enabled(v Verifier) bool {
+	_, noop := v.(Noop)
+	return v != nil && !noop
+}
+
+// SiteVerify checks tokens with the siteverify protocol reCAPTCHA, hCaptcha
+// and Turnstile share: secret, response and remoteip are posted as a form and
+// the JSON answer says whether the token was valid.
+type SiteVerify struct {
+	URL        string
+	Secret     string
+	TokenField string
+	Client     *http.Client
+}
+
+// Field returns the provider's token field
+func (s *SiteVerify) Field() string { return s.TokenField }
+
+// Verify posts token to the provider
+func (s *SiteVerify) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	if token == "" {
+		return false, nil
+	}
+
+	form := url.Values{"secret": {s.Secret}, "response": {token}}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("captcha provider answered %s", resp.Status)
+	}
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("decoding captcha provider response: %w", err)
+	}
+	return result.Success, nil
+}
+
+// providers maps FORM_CAPTCHA values to their siteverify endpoint and token field
+var providers = map[string]struct{ url, field string }{
+	"recaptcha": {"https://www.google.com/recaptcha/api/siteverify", "g-recaptcha-response"},
+	"hcaptcha":  {"https://api.hcaptcha.com/siteverify", "h-captcha-response"},
+	"turnstile": {"https://challenges.cloudflare.com/turnstile/v0/siteverify", "cf-turnstile-response"},
+}
+
+// FromEnv returns the verifier selected by FORM_CAPTCHA
+func FromEnv() (Verifier, error) {
+	kind := os.Getenv("FORM_CAPTCHA")
+	if kind == "" || kind == "none" {
+		return Noop{}, nil
+	}
+	provider, ok := providers[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown FORM_CAPTCHA %q (expected recaptcha, hcaptcha, turnstile or none)", kind)
+	}
+	secret := os.Getenv("FORM_CAPTCHA_SECRET")
+	if secret == "" {
+		return nil, fmt.Errorf("FORM_CAPTCHA_SECRET is required for FORM_CAPTCHA=%s", kind)
+	}
+	return &SiteVerify{URL: provider.url, Secret: secret, TokenField: provider.field, Client: egress.Default()}, nil
+}
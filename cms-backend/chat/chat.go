@@ -0,0 +1,248 @@
+// Package chat announces editorial events in Slack and Discord channels. Events
+// are read from the activity trail and the submission queue, so announcing
+// never slows down or fails the request that caused the event; each channel
+// keeps its own position and retries from there when its webhook fails.
+package chat
+
+import (
+	"bytes"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/webhooks"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Providers
+const (
+	ProviderSlack   = "slack"
+	ProviderDiscord = "discord"
+)
+
+// Event kinds channels can follow. They are also the action filters test.
+const (
+	// EventPublished is a post or page going live
+	EventPublished = "published"
+
+	// EventReviewRequested is a submission entering the review queue
+	EventReviewRequested = "review_requested"
+)
+
+// EventKinds lists every event kind
+var EventKinds = []string{EventPublished, EventReviewRequested}
+
+// batchSize caps the activities and submissions one channel reads per dispatch
+const batchSize = 100
+
+// maxResponseBody caps how much of a webhook's response is kept for reporting
+const maxResponseBody = 1024
+
+// Event is an announcement
+type Event struct {
+	// Kind is one of the Event* constants
+	Kind string
+
+	// EntityType is "post", "page" or "submission"
+	EntityType string
+	EntityID   uint
+	Title      string
+
+	// Actor is who caused the event; empty for anonymous callers and the scheduler
+	Actor  string
+	Status string
+	Tags   []string
+}
+
+// SampleEvent is announced by test deliveries
+func SampleEvent() Event {
+	return Event{
+		Kind:       EventPublished,
+		EntityType: "post",
+		EntityID:   1,
+		Title:      "Hello world",
+		Actor:      "admin",
+		Status:     models.StatusPublished,
+	}
+}
+
+// Text is the message announcing the event
+func (e Event) Text() string {
+	switch e.Kind {
+	case EventReviewRequested:
+		return fmt.Sprintf("%s submitted %q for review", e.Actor, e.Title)
+	case EventPublished:
+		if e.Actor == "" {
+			return fmt.Sprintf("The %s %q was published", e.EntityType, e.Title)
+		}
+		return fmt.Sprintf("%s published the %s %q", e.Actor, e.EntityType, e.Title)
+	}
+	return e.Title
+}
+
+// filterEvent describes the event in the terms channel filters test
+func (e Event) filterEvent() webhooks.Event {
+	return webhooks.Event{Entity: e.EntityType, Action: e.Kind, ID: e.EntityID, Status: e.Status, Tags: e.Tags}
+}
+
+// ValidProvider reports whether provider is supported
+func ValidProvider(provider string) bool {
+	return provider == ProviderSlack || provider == ProviderDiscord
+}
+
+// ParseEvents splits a comma-separated list of event kinds, rejecting unknown ones
+func ParseEvents(raw string) ([]string, error) {
+	var kinds []string
+	for _, kind := range strings.Split(raw, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "" {
+			continue
+		}
+		known := false
+		for _, k := range EventKinds {
+			known = known || k == kind
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown event %q, expected one of %s", kind, strings.Join(EventKinds, ", "))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// Body renders the webhook body announcing event in the provider's format
+func Body(provider string, event Event) ([]byte, error) {
+	switch provider {
+	case ProviderSlack:
+		// Slack reads &, < and > as control characters in message text
+		text := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(event.Text())
+		return json.Marshal(map[string]interface{}{"text": text})
+	case ProviderDiscord:
+		// Titles must not ping @everyone or anyone else
+		return json.Marshal(map[string]interface{}{
+			"content":          event.Text(),
+			"allowed_mentions": map[string]interface{}{"parse": []string{}},
+		})
+	}
+	return nil, fmt.Errorf("unsupported chat provider %q", provider)
+}
+
+// Deliver posts an announcement to the channel's webhook through the egress
+// policy. Any 2xx response counts as success.
+func Deliver(ctx context.Context, channel models.ChatChannel, event Event) webhooks.Result {
+	body, err := Body(channel.Provider, event)
+	if err != nil {
+		return webhooks.Result{Error: err.Error()}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return webhooks.Result{Error: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := egress.Default().Do(req)
+	result := webhooks.Result{DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(excerpt)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Success {
+		result.Error = fmt.Sprintf("%s responded %d", channel.Provider, resp.StatusCode)
+	}
+	return result
+}
+
+// Dispatch announces the events each active channel has not yet announced.
+// A channel stops at its first failed delivery and retries from there on the
+// next dispatch.
+func Dispatch(ctx context.Context, db *gorm.DB) error {
+	var channels []models.ChatChannel
+	if err := db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&channels).Error; err != nil {
+		return err
+	}
+
+	// Events are loaded once for every channel reading them
+	cache := newEventCache(db.WithContext(ctx))
+	for _, channel := range channels {
+		if err := dispatchChannel(ctx, db, channel, cache); err != nil {
+			log.Printf("Chat channel %d dispatch failed: %v", channel.ID, err)
+		}
+	}
+	return nil
+}
+
+func dispatchChannel(ctx context.Context, db *gorm.DB, channel models.ChatChannel, cache *eventCache) error {
+	kinds, err := ParseEvents(channel.Events)
+	if err != nil {
+		return err
+	}
+	filter, err := webhooks.ParseFilter(channel.Filter)
+	if err != nil {
+		return err
+	}
+	follows := func(event Event) bool {
+		if len(kinds) > 0 {
+			followed := false
+			for _, kind := range kinds {
+				followed = followed || kind == event.Kind
+			}
+			if !followed {
+				return false
+			}
+		}
+		return filter.Match(event.filterEvent())
+	}
+
+	updates := map[string]interface{}{}
+	announce := func(event Event) bool {
+		result := Deliver(ctx, channel, event)
+		now := time.Now()
+		updates["last_status"] = result.StatusCode
+		updates["last_error"] = result.Error
+		updates["last_attempt_at"] = &now
+		return result.Success
+	}
+	save := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		return db.WithContext(ctx).Model(&models.ChatChannel{}).Where("id = ?", channel.ID).Updates(updates).Error
+	}
+
+	published, err := cache.published(channel.LastActivityID)
+	if err != nil {
+		return err
+	}
+	for _, item := range published {
+		if item.event != nil && follows(*item.event) && !announce(*item.event) {
+			return save()
+		}
+		updates["last_activity_id"] = item.id
+	}
+
+	requested, err := cache.requested(channel.LastSubmissionID)
+	if err != nil {
+		return err
+	}
+	for _, item := range requested {
+		if follows(item.event) && !announce(item.event) {
+			return save()
+		}
+		updates["last_submission_id"] = item.id
+	}
+	return save()
+}
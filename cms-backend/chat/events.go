@@ -0,0 +1,138 @@
+package chat
+
+import (
+	"cms-backend/models"
+
+	"gorm.io/gorm"
+)
+
+// activityItem is an activity read for announcement; event is nil when the
+// activity announces nothing
+type activityItem struct {
+	id    uint
+	event *Event
+}
+
+// submissionItem is a submission read for announcement
+type submissionItem struct {
+	id    uint
+	event Event
+}
+
+// eventCache resolves activities and submissions into events once per
+// dispatch, however many channels read them
+type eventCache struct {
+	db          *gorm.DB
+	activities  map[uint]*Event
+	submissions map[uint]Event
+}
+
+func newEventCache(db *gorm.DB) *eventCache {
+	return &eventCache{db: db, activities: make(map[uint]*Event), submissions: make(map[uint]Event)}
+}
+
+// published returns the activities after the given ID that may announce a
+// post or page going live: status changes to published, and creations, which
+// publish when the content was created published
+func (c *eventCache) published(after uint) ([]activityItem, error) {
+	var activities []models.Activity
+	if err := c.db.Where("id > ? AND (action = ? OR (action = ? AND to_status = ?))",
+		after, models.ActivityCreated, models.ActivityStatusChanged, models.StatusPublished).
+		Order("id").Limit(batchSize).Find(&activities).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]activityItem, len(activities))
+	for i, activity := range activities {
+		event, ok := c.activities[activity.ID]
+		if !ok {
+			var err error
+			if event, err = c.publishedEvent(activity); err != nil {
+				return nil, err
+			}
+			c.activities[activity.ID] = event
+		}
+		items[i] = activityItem{id: activity.ID, event: event}
+	}
+	return items, nil
+}
+
+// publishedEvent returns the event an activity announces, or nil. Content
+// unpublished or deleted since is not announced, and neither is content
+// created as a draft: its later status change announces it.
+func (c *eventCache) publishedEvent(activity models.Activity) (*Event, error) {
+	event := Event{Kind: EventPublished, EntityType: activity.EntityType, EntityID: activity.EntityID, Actor: activity.ActorName}
+	switch activity.EntityType {
+	case "post":
+		var post models.Post
+		err := c.db.Preload("Tags").First(&post, activity.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		event.Title = post.Title
+		event.Status = post.Status
+		for _, tag := range post.Tags {
+			event.Tags = append(event.Tags, tag.Name)
+		}
+	case "page":
+		var page models.Page
+		err := c.db.First(&page, activity.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		event.Title = page.Title
+		event.Status = page.Status
+	default:
+		return nil, nil
+	}
+	if event.Status != models.StatusPublished {
+		return nil, nil
+	}
+
+	if activity.Action == models.ActivityCreated {
+		var later int64
+		if err := c.db.Model(&models.Activity{}).
+			Where("entity_type = ? AND entity_id = ? AND action = ? AND id > ?",
+				activity.EntityType, activity.EntityID, models.ActivityStatusChanged, activity.ID).
+			Count(&later).Error; err != nil {
+			return nil, err
+		}
+		if later > 0 {
+			return nil, nil
+		}
+	}
+	return &event, nil
+}
+
+// requested returns the submissions sent in after the given ID
+func (c *eventCache) requested(after uint) ([]submissionItem, error) {
+	var submissions []models.Submission
+	if err := c.db.Select("id", "submitter", "title", "status").
+		Where("id > ?", after).Order("id").Limit(batchSize).Find(&submissions).Error; err != nil {
+		return nil, err
+	}
+
+	items := make([]submissionItem, len(submissions))
+	for i, submission := range submissions {
+		event, ok := c.submissions[submission.ID]
+		if !ok {
+			event = Event{
+				Kind:       EventReviewRequested,
+				EntityType: "submission",
+				EntityID:   submission.ID,
+				Title:      submission.Title,
+				Actor:      submission.Submitter,
+				Status:     submission.Status,
+			}
+			c.submissions[submission.ID] = event
+		}
+		items[i] = submissionItem{id: submission.ID, event: event}
+	}
+	return items, nil
+}
@@ -0,0 +1,207 @@
+// Package checklist decides whether a post is ready to publish, so editing
+// UIs can hold back the publish button until it is. A checklist is a list of
+// Checks: the built-in ones look for an image, search-friendly fields, a
+// minimum length and broken links, RegisterFromEnv adds the optional ones the
+// environment enables, and Register adds or replaces checks.
+package checklist
+
+import (
+	"cms-backend/linkcheck"
+	"cms-backend/models"
+	"cms-backend/readability"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// DefaultMinWords is the length a post needs when PUBLISH_MIN_WORDS is not set
+const DefaultMinWords = 150
+
+// MaxTitleLength is the longest title search results show in full
+const MaxTitleLength = 60
+
+// Check is one item of the checklist. Run inspects a post, loaded with its
+// media, and explains what to fix when it does not pass.
+type Check struct {
+	Name string
+
+	// Blocking checks must pass for the post to be ready; the others are advice
+	Blocking bool
+
+	Run func(ctx context.Context, db *gorm.DB, post models.Post) (passed bool, message string, err error)
+}
+
+// Item is the outcome of one check
+type Item struct {
+	Name     string `json:"name"`
+	Passed   bool   `json:"passed"`
+	Blocking bool   `json:"blocking"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Report is a post's checklist. Ready is set when every blocking check passed.
+type Report struct {
+	Ready bool   `json:"ready"`
+	Items []Item `json:"items"`
+}
+
+var (
+	mu     sync.RWMutex
+	checks = []Check{FeaturedImage, SEOFields, MinimumLength, BrokenLinks}
+)
+
+// Register adds a check to the checklist, replacing any check of the same name
+func Register(check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, existing := range checks {
+		if existing.Name == check.Name {
+			checks[i] = check
+			return
+		}
+	}
+	checks = append(checks, check)
+}
+
+// Unregister removes the check called name, if there is one
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, existing := range checks {
+		if existing.Name == name {
+			checks = append(checks[:i], checks[i+1:]...)
+			return
+		}
+	}
+}
+
+// Evaluate runs every check against a post
+func Evaluate(ctx context.Context, db *gorm.DB, post models.Post) (Report, error) {
+	mu.RLock()
+	current := append([]Check(nil), checks...)
+	mu.RUnlock()
+
+	report := Report{Ready: true, Items: make([]Item, 0, len(current))}
+	for _, check := range current {
+		passed, message, err := check.Run(ctx, db, post)
+		if err != nil {
+			return Report{}, fmt.Errorf("publish check %s: %w", check.Name, err)
+		}
+		if passed {
+			message = ""
+		} else if check.Blocking {
+			report.Ready = false
+		}
+		report.Items = append(report.Items, Item{Name: check.Name, Passed: passed, Blocking: check.Blocking, Message: message})
+	}
+	return report, nil
+}
+
+// RegisterFromEnv adds the optional checks the environment enables:
+// MEDIA_ALT_TEXT=warn advises alt text on attached images and
+// MEDIA_ALT_TEXT=block requires it
+func RegisterFromEnv() error {
+	switch mode := os.Getenv("MEDIA_ALT_TEXT"); mode {
+	case "", "off":
+	case "warn", "block":
+		Register(AltText(mode == "block"))
+	default:
+		return fmt.Errorf("MEDIA_ALT_TEXT must be off, warn or block, not %q", mode)
+	}
+	return nil
+}
+
+// AltText checks that every image attached to a post has alt text, so
+// screen readers can describe it
+func AltText(blocking bool) Check {
+	return Check{
+		Name:     "alt_text",
+		Blocking: blocking,
+		Run: func(ctx context.Context, db *gorm.DB, post models.Post) (bool, string, error) {
+			var missing []string
+			for _, media := range post.Media {
+				if strings.HasPrefix(media.Type, "image") && media.ScanStatus != models.ScanQuarantined &&
+					strings.TrimSpace(media.AltText) == "" {
+					missing = append(missing, media.URL)
+				}
+			}
+			if len(missing) > 0 {
+				return false, "Add alt text to the images: " + strings.Join(missing, ", "), nil
+			}
+			return true, "", nil
+		},
+	}
+}
+
+// FeaturedImage advises attaching an image, which listings and link
+// previews show beside the post
+var FeaturedImage = Check{
+	Name: "featured_image",
+	Run: func(ctx context.Context, db *gorm.DB, post models.Post) (bool, string, error) {
+		for _, media := range post.Media {
+			if strings.HasPrefix(media.Type, "image") && media.ScanStatus != models.ScanQuarantined {
+				return true, "", nil
+			}
+		}
+		return false, "Attach an image to show as the featured image", nil
+	},
+}
+
+// SEOFields advises a title short enough for search results and a slug
+var SEOFields = Check{
+	Name: "seo_fields",
+	Run: func(ctx context.Context, db *gorm.DB, post models.Post) (bool, string, error) {
+		if strings.TrimSpace(post.Title) == "" || post.Slug == "" {
+			return false, "Give the post a title and a slug", nil
+		}
+		if length := utf8.RuneCountInString(post.Title); length > MaxTitleLength {
+			return false, fmt.Sprintf("Shorten the title to %d characters or fewer; it has %d", MaxTitleLength, length), nil
+		}
+		return true, "", nil
+	},
+}
+
+// MinimumLength requires PUBLISH_MIN_WORDS words of content
+var MinimumLength = Check{
+	Name:     "minimum_length",
+	Blocking: true,
+	Run: func(ctx context.Context, db *gorm.DB, post models.Post) (bool, string, error) {
+		minWords := DefaultMinWords
+		if value, err := strconv.Atoi(os.Getenv("PUBLISH_MIN_WORDS")); err == nil && value >= 0 {
+			minWords = value
+		}
+		if words := readability.Analyze(post.Content).WordCount; words < minWords {
+			return false, fmt.Sprintf("Write at least %d words; the post has %d", minWords, words), nil
+		}
+		return true, "", nil
+	},
+}
+
+// BrokenLinks requires that none of the post's links failed their latest
+// check. Links are checked in the background, so links no content has
+// published yet are not known to be broken and pass.
+var BrokenLinks = Check{
+	Name:     "broken_links",
+	Blocking: true,
+	Run: func(ctx context.Context, db *gorm.DB, post models.Post) (bool, string, error) {
+		urls := linkcheck.ExtractURLs(post.Content)
+		if len(urls) == 0 {
+			return true, "", nil
+		}
+		var broken []string
+		if err := db.WithContext(ctx).Model(&models.Link{}).Distinct("url").
+			Where("url IN ? AND broken = ?", urls, true).Order("url").Pluck("url", &broken).Error; err != nil {
+			return false, "", err
+		}
+		if len(broken) > 0 {
+			return false, "Fix the broken links: " + strings.Join(broken, ", "), nil
+		}
+		return true, "", nil
+	},
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"cms-backend/migrations"
+	"cms-backend/seed"
+	"cms-backend/utils"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const usage = `usage:
+  main                                  start the API server
+  main migrate status                   show the schema version, dirty flag and pending migrations
+  main migrate up                       apply pending migrations
+  main migrate force <version>          set the version and clear the dirty flag
+  main migrate rerun <version> [up|down] re-run one migration in a transaction
+  main seed [--count N]                 add demo content (N posts, default 20)`
+
+// runCommand executes a CLI subcommand and returns the process exit code
+func runCommand(args []string) int {
+	switch args[0] {
+	case "migrate":
+		if len(args) >= 2 {
+			return runMigrate(args)
+		}
+	case "seed":
+		return runSeed(args[1:])
+	}
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// runMigrate executes a migrate subcommand
+func runMigrate(args []string) int {
+	switch args[1] {
+	case "status":
+		status, err := migrations.CurrentStatus()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read migration status: %v\n", err)
+			return 1
+		}
+		fmt.Printf("version: %d\ndirty: %t\nlatest: %d\npending: %v\n", status.Version, status.Dirty, status.Latest, status.Pending)
+		return 0
+
+	case "up":
+		if err := migrations.Up(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println("Migrations completed successfully")
+		return 0
+
+	case "force":
+		if len(args) != 3 {
+			fmt.Fprintln(os.Stderr, usage)
+			return 2
+		}
+		version, err := strconv.Atoi(args[2])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid version %q\n", args[2])
+			return 2
+		}
+		if err := migrations.Force(version); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to force version: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Forced version %d\n", version)
+		return 0
+
+	case "rerun":
+		if len(args) < 3 || len(args) > 4 {
+			fmt.Fprintln(os.Stderr, usage)
+			return 2
+		}
+		version, err := strconv.ParseUint(args[2], 10, 64)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid version %q\n", args[2])
+			return 2
+		}
+		direction := "up"
+		if len(args) == 4 {
+			direction = args[3]
+		}
+
+		db, err := utils.ConnectDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not connect to the database: %v\n", err)
+			return 1
+		}
+
+		result := migrations.Rerun(db, uint(version), direction)
+		encoded, _ := json.MarshalIndent(result, "", "  ")
+		fmt.Println(string(encoded))
+		if !result.Success {
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Fprintln(os.Stderr, usage)
+	return 2
+}
+
+// runSeed adds demo content to the database. It refuses to run when
+// ENV=production, where demo posts and API keys must never appear.
+func runSeed(args []string) int {
+	count, err := seed.CountFromArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n%s\n", err, usage)
+		return 2
+	}
+	if os.Getenv("ENV") == "production" {
+		fmt.Fprintln(os.Stderr, "Refusing to seed demo content with ENV=production")
+		return 1
+	}
+
+	db, err := utils.ConnectDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not connect to the database: %v\n", err)
+		return 1
+	}
+	summary, err := seed.Run(db, count)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to seed: %v\n", err)
+		return 1
+	}
+	encoded, _ := json.MarshalIndent(summary, "", "  ")
+	fmt.Println(string(encoded))
+	return 0
+}
@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/notifications"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ACLGrant gives one principal a permission
+type ACLGrant struct {
+	// PrincipalType is "key" (the default) or "group"
+	PrincipalType string `json:"principal_type"`
+	PrincipalID   uint   `json:"principal_id" binding:"required"`
+	Permission    string `json:"permission" binding:"required"`
+}
+
+// ACLRequest is the body for replacing an entity's grants; an empty list
+// lifts the restriction
+type ACLRequest struct {
+	Grants []ACLGrant `json:"grants"`
+}
+
+// ACLResponse lists an entity's grants
+type ACLResponse struct {
+	// Restricted is set when the grants limit access beyond roles
+	Restricted bool              `json:"restricted"`
+	Grants     []models.ACLEntry `json:"grants"`
+}
+
+// GetPostACL lists the grants on a post
+func GetPostACL(c *gin.Context) {
+	getACL(c, acl.EntityPost)
+}
+
+// PutPostACL replaces the grants on a post
+func PutPostACL(c *gin.Context) {
+	putACL(c, acl.EntityPost)
+}
+
+// GetPageACL lists the grants on a page
+func GetPageACL(c *gin.Context) {
+	getACL(c, acl.EntityPage)
+}
+
+// PutPageACL replaces the grants on a page
+func PutPageACL(c *gin.Context) {
+	putACL(c, acl.EntityPage)
+}
+
+func getACL(c *gin.Context, entity string) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	if !requireACLManager(c) {
+		return
+	}
+	id, ok := findACLEntity(c, db, entity)
+	if !ok {
+		return
+	}
+
+	var grants []models.ACLEntry
+	if err := db.Where("entity_type = ? AND entity_id = ?", entity, id).Order("id").Find(&grants).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ACLResponse{Restricted: len(grants) > 0, Grants: grants})
+}
+
+// putACL replaces an entity's grants. Admins manage every ACL; editors manage
+// those of content they may publish.
+func putACL(c *gin.Context, entity string) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	if !requireACLManager(c) {
+		return
+	}
+
+	var req ACLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	id, ok := findACLEntity(c, db, entity)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, entity, id, acl.Publish) {
+		return
+	}
+
+	grants := make([]models.ACLEntry, 0, len(req.Grants))
+	var keyIDs, groupIDs []uint
+	seen := make(map[ACLGrant]bool, len(req.Grants))
+	for _, grant := range req.Grants {
+		if grant.PrincipalType == "" {
+			grant.PrincipalType = acl.PrincipalKey
+		}
+		if grant.PrincipalType != acl.PrincipalKey && grant.PrincipalType != acl.PrincipalGroup {
+			utils.Fail(c, utils.Invalid("Unknown principal type "+grant.PrincipalType))
+			return
+		}
+		if !acl.ValidPermission(grant.Permission) {
+			utils.Fail(c, utils.Invalid("Permission must be 'read', 'write' or 'publish'"))
+			return
+		}
+		principal := ACLGrant{PrincipalType: grant.PrincipalType, PrincipalID: grant.PrincipalID}
+		if seen[principal] {
+			utils.Fail(c, utils.Invalid("Each principal may be granted only one permission"))
+			return
+		}
+		seen[principal] = true
+		if grant.PrincipalType == acl.PrincipalGroup {
+			groupIDs = append(groupIDs, grant.PrincipalID)
+		} else {
+			keyIDs = append(keyIDs, grant.PrincipalID)
+		}
+		grants = append(grants, models.ACLEntry{
+			EntityType:    entity,
+			EntityID:      id,
+			PrincipalType: grant.PrincipalType,
+			PrincipalID:   grant.PrincipalID,
+			Permission:    grant.Permission,
+		})
+	}
+
+	// Grants only matter to the editorial roles; admins bypass ACLs and the
+	// other roles cannot write content at all
+	if len(keyIDs) > 0 {
+		var keys []models.APIKey
+		if err := db.Where("id IN ?", keyIDs).Find(&keys).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		roles := make(map[uint]string, len(keys))
+		for _, key := range keys {
+			roles[key.ID] = key.Role
+		}
+		for _, keyID := range keyIDs {
+			if role := roles[keyID]; role != auth.RoleEditor && role != auth.RoleContributor {
+				utils.Fail(c, utils.Invalid("Grants must name API keys with the editor or contributor role"))
+				return
+			}
+		}
+	}
+
+	if len(groupIDs) > 0 {
+		var count int64
+		if err := db.Model(&models.Group{}).Where("id IN ?", groupIDs).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count != int64(len(groupIDs)) {
+			utils.Fail(c, utils.Invalid("Group not found"))
+			return
+		}
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		granted, err := grantNotifications(tx, auth.CallerFrom(c), entity, id, grants)
+		if err != nil {
+			return err
+		}
+
+		if err := deleteACL(tx, entity, id); err != nil {
+			return err
+		}
+		if len(grants) > 0 {
+			if err := tx.Create(&grants).Error; err != nil {
+				return err
+			}
+		}
+
+		return notifications.Notify(tx, granted...)
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ACLResponse{Restricted: len(grants) > 0, Grants: grants})
+}
+
+// grantNotifications tells the principals newly granted access to an entity,
+// expanding group grants to their members. Principals that already held a
+// grant, and the caller, are not notified.
+func grantNotifications(tx *gorm.DB, caller auth.Caller, entity string, id uint, grants []models.ACLEntry) ([]models.Notification, error) {
+	if len(grants) == 0 {
+		return nil, nil
+	}
+	var previous []models.ACLEntry
+	if err := tx.Where("entity_type = ? AND entity_id = ?", entity, id).Find(&previous).Error; err != nil {
+		return nil, err
+	}
+	held := make(map[ACLGrant]bool, len(previous))
+	for _, entry := range previous {
+		held[ACLGrant{PrincipalType: entry.PrincipalType, PrincipalID: entry.PrincipalID}] = true
+	}
+
+	permissions := make(map[uint]string)
+	var newGroups []uint
+	groupPermissions := make(map[uint]string)
+	for _, grant := range grants {
+		if held[ACLGrant{PrincipalType: grant.PrincipalType, PrincipalID: grant.PrincipalID}] {
+			continue
+		}
+		if grant.PrincipalType == acl.PrincipalGroup {
+			newGroups = append(newGroups, grant.PrincipalID)
+			groupPermissions[grant.PrincipalID] = grant.Permission
+			continue
+		}
+		permissions[grant.PrincipalID] = grant.Permission
+	}
+	if len(newGroups) > 0 {
+		var members []models.GroupMember
+		if err := tx.Where("group_id IN ?", newGroups).Find(&members).Error; err != nil {
+			return nil, err
+		}
+		// A direct grant to the key takes precedence over its groups'
+		for _, member := range members {
+			if _, ok := permissions[member.APIKeyID]; !ok {
+				permissions[member.APIKeyID] = groupPermissions[member.GroupID]
+			}
+		}
+	}
+	delete(permissions, caller.KeyID)
+
+	recipients := make([]uint, 0, len(permissions))
+	for keyID := range permissions {
+		recipients = append(recipients, keyID)
+	}
+	sort.Slice(recipients, func(i, j int) bool { return recipients[i] < recipients[j] })
+
+	granted := make([]models.Notification, len(recipients))
+	for i, keyID := range recipients {
+		granted[i] = models.Notification{
+			APIKeyID:   keyID,
+			Kind:       models.NotificationAccessGranted,
+			Message:    fmt.Sprintf("You were granted %s access to %s %d", permissions[keyID], entity, id),
+			EntityType: entity,
+			EntityID:   id,
+		}
+	}
+	return granted, nil
+}
+
+// requireACLManager writes a 403 response unless the caller is an admin or editor
+func requireACLManager(c *gin.Context) bool {
+	caller := auth.CallerFrom(c)
+	if caller.Role != auth.RoleAdmin && caller.Role != auth.RoleEditor {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Only admins and editors can manage access grants",
+		})
+		return false
+	}
+	return true
+}
+
+// findACLEntity returns the ID of the post or page named by the :id
+// parameter, writing a 404 response when it does not exist
+func findACLEntity(c *gin.Context, db *gorm.DB, entity string) (uint, bool) {
+	var (
+		model   interface{}
+		message string
+	)
+	switch entity {
+	case acl.EntityPage:
+		model, message = &models.Page{}, "Page not found"
+	default:
+		model, message = &models.Post{}, "Post not found"
+	}
+
+	var row struct{ ID uint }
+	if err := db.Model(model).Select("id").Where("id = ?", c.Param("id")).Take(&row).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound(message))
+			return 0, false
+		}
+		utils.Fail(c, err)
+		return 0, false
+	}
+	return row.ID, true
+}
+
+// deleteACL removes every grant on an entity
+func deleteACL(tx *gorm.DB, entity string, id uint) error {
+	return tx.Where("entity_type = ? AND entity_id = ?", entity, id).Delete(&models.ACLEntry{}).Error
+}
+
+// checkACL writes a 403 response unless the caller holds permission on the
+// entity; content without grants is left to the role checks
+func checkACL(c *gin.Context, db *gorm.DB, entity string, id uint, permission string) bool {
+	allowed, err := acl.Allowed(db, auth.CallerFrom(c), entity, id, permission)
+	if err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "You do not have " + permission + " access to this " + entity,
+		})
+		return false
+	}
+	return true
+}
+
+// checkACLAll is checkACL for several entities, writing a 403 if the caller
+// lacks permission on any of them
+func checkACLAll(c *gin.Context, db *gorm.DB, entity string, ids []uint, permission string) bool {
+	denied, err := acl.Denied(db, auth.CallerFrom(c), entity, ids, permission)
+	if err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if len(denied) > 0 {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "You do not have " + permission + " access to one or more of these " + entity + "s",
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPostActivity lists the history of a post, newest first, in pages of
+// ?limit= entries (default 20) continued with ?after=
+func GetPostActivity(c *gin.Context) {
+	getEntityActivity(c, acl.EntityPost)
+}
+
+// GetPageActivity lists the history of a page like GetPostActivity
+func GetPageActivity(c *gin.Context) {
+	getEntityActivity(c, acl.EntityPage)
+}
+
+// GetUserActivity lists the changes made with an API key, newest first and
+// paginated like GetPostActivity. Callers may read their own activity;
+// admins and editors may read anyone's.
+func GetUserActivity(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Fail(c, utils.NotFound("User not found"))
+		return
+	}
+	if uint(keyID) != caller.KeyID && caller.Role != auth.RoleAdmin && caller.Role != auth.RoleEditor {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Only admins and editors can read other users' activity",
+		})
+		return
+	}
+
+	writeActivityPage(c, db.Where("actor_key_id = ?", keyID))
+}
+
+func getEntityActivity(c *gin.Context, entity string) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	if !canSeeActivity(auth.CallerFrom(c)) {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Activity is only available to editorial roles",
+		})
+		return
+	}
+
+	// History survives deletion, so the entity itself is not required to exist
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.Fail(c, utils.NotFound("Not found"))
+		return
+	}
+
+	writeActivityPage(c, db.Where("entity_type = ? AND entity_id = ?", entity, id))
+}
+
+// writeActivityPage responds with one page of the activities matched by query
+func writeActivityPage(c *gin.Context, query *gorm.DB) {
+	query, limit, ok := paginate(c, query, "activities")
+	if !ok {
+		return
+	}
+	if limit == 0 {
+		// Feeds are always paginated
+		limit = defaultPageLimit
+		query = query.Order("activities.created_at DESC").Order("activities.id DESC").Limit(limit + 1)
+	}
+
+	var activities []models.Activity
+	if err := query.Find(&activities).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(activities) > limit {
+		activities = activities[:limit]
+		last := activities[limit-1]
+		c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	c.JSON(http.StatusOK, activities)
+}
+
+// canSeeActivity reports whether the caller takes part in the editorial workflow
+func canSeeActivity(caller auth.Caller) bool {
+	switch caller.Role {
+	case auth.RoleAdmin, auth.RoleEditor, auth.RoleContributor:
+		return true
+	}
+	return false
+}
+
+// recordActivity adds entries to the audit trail, attributed to the caller
+func recordActivity(tx *gorm.DB, c *gin.Context, activities ...models.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	caller := auth.CallerFrom(c)
+	for i := range activities {
+		if !caller.Anonymous() {
+			keyID := caller.KeyID
+			activities[i].ActorKeyID = &keyID
+			activities[i].ActorName = caller.Name
+		}
+	}
+	return tx.Create(&activities).Error
+}
+
+// editActivities describes an update as an edit of the changed fields and,
+// when the status moved, a separate status change
+func editActivities(entity string, id uint, fields []string, fromStatus, toStatus string) []models.Activity {
+	var activities []models.Activity
+	if len(fields) > 0 {
+		activities = append(activities, models.Activity{
+			EntityType: entity,
+			EntityID:   id,
+			Action:     models.ActivityEdited,
+			Fields:     strings.Join(fields, ","),
+		})
+	}
+	if fromStatus != toStatus {
+		activities = append(activities, models.Activity{
+			EntityType: entity,
+			EntityID:   id,
+			Action:     models.ActivityStatusChanged,
+			FromStatus: fromStatus,
+			ToStatus:   toStatus,
+		})
+	}
+	return activities
+}
+
+// changedPostFields lists the JSON names of the fields an update changed,
+// apart from the status, which is reported separately
+func changedPostFields(before, after models.Post, tagsChanged bool) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("title", before.Title != after.Title)
+	add("content", before.Content != after.Content)
+	add("author", before.Author != after.Author)
+	add("author_email", before.AuthorEmail != after.AuthorEmail)
+	add("canonical_url", before.CanonicalURL != after.CanonicalURL)
+	add("syndication_source", before.SyndicationSource != after.SyndicationSource)
+	add("slug", before.Slug != after.Slug)
+	add("locale", before.Locale != after.Locale)
+	add("expires_at", !sameTime(before.ExpiresAt, after.ExpiresAt))
+	add("visibility", before.Visibility != after.Visibility)
+	add("embargoed_until", !sameTime(before.EmbargoedUntil, after.EmbargoedUntil))
+	add("notes", before.Notes != after.Notes)
+	add("owner_group_id", !sameID(before.OwnerGroupID, after.OwnerGroupID))
+	add("tags", tagsChanged)
+	return fields
+}
+
+// changedPageFields is changedPostFields for pages
+func changedPageFields(before, after models.Page) []string {
+	var fields []string
+	add := func(name string, changed bool) {
+		if changed {
+			fields = append(fields, name)
+		}
+	}
+	add("title", before.Title != after.Title)
+	add("content", before.Content != after.Content)
+	add("slug", before.Slug != after.Slug)
+	add("locale", before.Locale != after.Locale)
+	add("expires_at", !sameTime(before.ExpiresAt, after.ExpiresAt))
+	add("visibility", before.Visibility != after.Visibility)
+	add("embargoed_until", !sameTime(before.EmbargoedUntil, after.EmbargoedUntil))
+	add("notes", before.Notes != after.Notes)
+	add("owner_group_id", !sameID(before.OwnerGroupID, after.OwnerGroupID))
+	return fields
+}
+
+func sameID(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"cms-backend/readability"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyzeRequest is Markdown content to score before it is saved
+type AnalyzeRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// Analyze scores content for readability: word count, Flesch scores,
+// estimated reading time and warnings about the heading outline. Nothing is
+// stored, so editors can check drafts as they write.
+func Analyze(c *gin.Context) {
+	var req AnalyzeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, readability.Analyze(req.Content))
+}
@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// APIKeyRequest is the body for issuing an API key
+type APIKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role" binding:"required"`
+
+	// Email, when set, receives notification digests
+	Email string `json:"email" binding:"omitempty,email"`
+
+	// Scopes, when set, limit the key to the routes they grant, e.g.
+	// "posts:read,media:write"
+	Scopes string `json:"scopes"`
+}
+
+// APIKeyResponse returns a newly issued key. The key is not stored and cannot be shown again.
+type APIKeyResponse struct {
+	APIKey models.APIKey `json:"api_key"`
+	Key    string        `json:"key"`
+}
+
+// GetAPIKeys lists issued API keys without their secrets
+func GetAPIKeys(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var keys []models.APIKey
+	if err := db.Order("id").Find(&keys).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// CreateAPIKey issues an API key for a role
+func CreateAPIKey(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if !auth.ValidRole(req.Role) {
+		utils.Fail(c, utils.Invalid("Role must be 'admin', 'editor', 'contributor', 'delivery', 'member' or 'submitter'"))
+		return
+	}
+
+	scopes, err := auth.ParseScopes(req.Scopes)
+	if err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	scope := auth.ScopeOf(req.Role)
+	key, err := auth.GenerateKey(scope)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	apiKey := models.APIKey{
+		Name:    req.Name,
+		Role:    req.Role,
+		Email:   req.Email,
+		Scopes:  strings.Join(scopes, ","),
+		Prefix:  auth.PrefixOf(key),
+		KeyHash: auth.HashKey(key),
+	}
+	if err := db.Create(&apiKey).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, APIKeyResponse{APIKey: apiKey, Key: key})
+}
+
+// DeleteAPIKey revokes an API key
+func DeleteAPIKey(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	result := db.Delete(&models.APIKey{}, c.Param("id"))
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("API key not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked successfully",
+	})
+}
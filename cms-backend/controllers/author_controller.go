@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// AuthorProfile is an author's public page: their profile and a page of
+// their published posts, newest first
+type AuthorProfile struct {
+	Username    string                     `json:"username"`
+	DisplayName string                     `json:"display_name"`
+	Bio         string                     `json:"bio"`
+	Avatar      *models.Media              `json:"avatar"`
+	Posts       []serializers.PostResponse `json:"posts"`
+}
+
+// AuthorRequest is the body for creating or updating an author profile
+type AuthorRequest struct {
+	DisplayName *string `json:"display_name"`
+	Bio         *string `json:"bio"`
+	AvatarID    *uint   `json:"avatar_id"`
+}
+
+// GetAuthor returns the public profile of :username with their published
+// posts in pages of ?limit= (default 20) continued with ?after=. Like the
+// delivery API it only lists what the request's audience may read.
+func GetAuthor(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	// A quarantined avatar is left out like any other quarantined media
+	var user models.User
+	err := db.Preload("Avatar", "scan_status <> ?", models.ScanQuarantined).
+		Where("username = ?", c.Param("username")).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Author not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	query, limit, ok := deliveryPage(c, db.Scopes(deliverable(c, "posts")).Where("posts.author = ?", user.Username), "posts")
+	if !ok {
+		return
+	}
+	query, ok = preloadPostRelations(c, query)
+	if !ok {
+		return
+	}
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	posts = trimPostsPage(c, posts, limit)
+
+	c.JSON(http.StatusOK, AuthorProfile{
+		Username:    user.Username,
+		DisplayName: user.DisplayName,
+		Bio:         user.Bio,
+		Avatar:      user.Avatar,
+		Posts:       serializers.Posts(auth.Caller{}, posts),
+	})
+}
+
+// PutAuthor creates or updates the profile of :username. Authors may edit
+// their own profile; admins and editors may edit anyone's.
+func PutAuthor(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+	username := c.Param("username")
+	if username != caller.Name && caller.Role != auth.RoleAdmin && caller.Role != auth.RoleEditor {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Only admins and editors can edit other authors' profiles",
+		})
+		return
+	}
+
+	var req AuthorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	var user models.User
+	err := db.Where("username = ?", username).First(&user).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.Fail(c, err)
+		return
+	}
+	status := http.StatusOK
+	if err == gorm.ErrRecordNotFound {
+		status = http.StatusCreated
+		user = models.User{Username: username}
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+	if req.Bio != nil {
+		user.Bio = *req.Bio
+	}
+	if req.AvatarID != nil {
+		var media models.Media
+		if err := db.First(&media, *req.AvatarID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				utils.Fail(c, utils.Invalid("Avatar media not found"))
+				return
+			}
+			utils.Fail(c, err)
+			return
+		}
+		user.AvatarID = req.AvatarID
+	}
+
+	if err := db.Save(&user).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(status, user)
+}
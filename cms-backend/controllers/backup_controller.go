@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/backup"
+	"cms-backend/utils"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetBackups lists the stored content backups, newest first
+func GetBackups(c *gin.Context) {
+	backups, err := backup.Default.List()
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, backups)
+}
+
+// CreateBackup starts a job writing a backup of all content to BACKUP_DIR.
+// Poll GET /jobs/:id for its progress.
+func CreateBackup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	job, err := backup.Default.StartCreate(db, auth.CallerFrom(c).KeyID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// RestoreBackup starts a job restoring the backup :name. The database must
+// have no content yet and be migrated to the schema version the backup was
+// made at. Poll GET /jobs/:id for its progress.
+func RestoreBackup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	name := c.Param("name")
+	if _, err := backup.Default.Find(name); err != nil {
+		if errors.Is(err, backup.ErrNotFound) {
+			utils.Fail(c, utils.NotFound("Backup not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	// The job checks again inside its transaction; this answers early
+	if err := backup.CheckEmpty(c.Request.Context(), db); err != nil {
+		if errors.Is(err, backup.ErrNotEmpty) {
+			utils.Fail(c, utils.Conflict(err.Error()))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	job, err := backup.Default.StartRestore(db, auth.CallerFrom(c).KeyID, name)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/bundle"
+	"cms-backend/jobs"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobTypeBundleBuild is the job type for offline bundle builds
+const JobTypeBundleBuild = "bundle_build"
+
+// AllChannel is the channel holding every published post; other channels are tag names
+const AllChannel = "all"
+
+// BundleVersionHeader reports the version a bundle is current to; apps send it
+// back as ?since= to download only what changed
+const BundleVersionHeader = "X-Bundle-Version"
+
+// unsafeFilename matches characters replaced when a channel names a file
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// bundleBuildPayload is stored on the job so the build can be traced
+type bundleBuildPayload struct {
+	Channel string `json:"channel"`
+	Since   int64  `json:"since"`
+	Version int64  `json:"version"`
+}
+
+// GetBundle serves the offline bundle for a channel: "all" or a tag name. Pass
+// the version of the bundle an app already holds as ?since= to get a delta.
+// Bundles are built by the job queue: while one is building the response is
+// 202 with the job, and once built the gzip-compressed JSON bundle is sent
+// with its version in X-Bundle-Version.
+func GetBundle(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	channel := c.Param("channel")
+	if channel != AllChannel {
+		var count int64
+		if err := db.Model(&models.Tag{}).Where("name = ?", channel).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count == 0 {
+			utils.Fail(c, utils.NotFound("Channel not found"))
+			return
+		}
+	}
+
+	var version int64
+	if err := db.Model(&models.Change{}).Select("COALESCE(MAX(seq), 0)").Scan(&version).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	var since int64
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 || parsed > version {
+			utils.Fail(c, utils.Invalid("since must be the version of a previously downloaded bundle"))
+			return
+		}
+		since = parsed
+	}
+
+	// Serve the bundle for the current version, or wait for one being built
+	var existing models.Bundle
+	err := db.Preload("Job").
+		Where("channel = ? AND since = ? AND version = ?", channel, since, version).
+		First(&existing).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.Fail(c, err)
+		return
+	}
+	if err == nil && existing.Job.Status != models.JobFailed {
+		writeBundle(c, existing)
+		return
+	}
+
+	// A build for an older version still in progress is waited for rather
+	// than starting another while content is being edited
+	var building models.Bundle
+	err = db.Joins("Job").
+		Where("bundles.channel = ? AND bundles.since = ?", channel, since).
+		Where(`"Job".status IN ?`, []string{models.JobQueued, models.JobRunning}).
+		Order("bundles.version DESC").
+		First(&building).Error
+	if err == nil {
+		writeBundle(c, building)
+		return
+	}
+	if err != gorm.ErrRecordNotFound {
+		utils.Fail(c, err)
+		return
+	}
+
+	payload := bundleBuildPayload{Channel: channel, Since: since, Version: version}
+	job, err := jobs.Start(db, auth.CallerFrom(c).KeyID, JobTypeBundleBuild, payload, buildBundle)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	// A failed build for this version is replaced by the new one
+	record := models.Bundle{Channel: channel, Since: since, Version: version, JobID: job.ID, Job: *job}
+	if err := db.Omit("Job").Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel"}, {Name: "since"}, {Name: "version"}},
+		DoUpdates: clause.AssignmentColumns([]string{"job_id"}),
+	}).Create(&record).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	writeBundle(c, record)
+}
+
+// writeBundle sends a built bundle, or 202 with its job while it is building
+func writeBundle(c *gin.Context, record models.Bundle) {
+	job := record.Job
+	switch job.Status {
+	case models.JobCompleted:
+		c.Header(BundleVersionHeader, strconv.FormatInt(record.Version, 10))
+		c.FileAttachment(job.ResultPath, filepath.Base(job.ResultPath))
+	case models.JobFailed:
+		c.JSON(http.StatusInternalServerError, utils.HTTPError{
+			Code:    http.StatusInternalServerError,
+			Message: "Bundle build failed: " + job.Error,
+		})
+	default:
+		c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+		c.JSON(http.StatusAccepted, job)
+	}
+}
+
+// buildBundle is the job function behind GetBundle
+func buildBundle(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+	var payload bundleBuildPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return "", err
+	}
+	db = db.WithContext(ctx)
+	b := bundle.New(payload.Channel, payload.Since, payload.Version, time.Now())
+
+	// A delta covers the entities changed after the base version
+	var postIDs, pageIDs []uint
+	if payload.Since > 0 {
+		var err error
+		if postIDs, err = changedIDs(db, "post", payload.Since, payload.Version); err != nil {
+			return "", err
+		}
+		if pageIDs, err = changedIDs(db, "page", payload.Since, payload.Version); err != nil {
+			return "", err
+		}
+	}
+	progress(10)
+
+	posts := db.Preload("Media").Preload("Tags").Where("posts.status = ?", models.StatusPublished)
+	if payload.Channel != AllChannel {
+		posts = posts.Where("posts.id IN (?)", db.Table("post_tags").
+			Select("post_tags.post_id").
+			Joins("JOIN tags ON tags.id = post_tags.tag_id").
+			Where("tags.name = ?", payload.Channel))
+	}
+	pages := db.Where("status = ?", models.StatusPublished)
+	if payload.Since > 0 {
+		posts = posts.Where("posts.id IN ?", postIDs)
+		pages = pages.Where("id IN ?", pageIDs)
+	}
+
+	var postList []models.Post
+	if err := posts.Order("posts.id").Find(&postList).Error; err != nil {
+		return "", err
+	}
+	included := make(map[uint]bool, len(postList))
+	for _, post := range serializers.RedactPosts(auth.Caller{}, postList) {
+		included[post.ID] = true
+		b.AddPost(post)
+	}
+	progress(50)
+
+	var pageList []models.Page
+	if err := pages.Order("id").Find(&pageList).Error; err != nil {
+		return "", err
+	}
+	b.Pages = append(b.Pages, serializers.RedactPages(auth.Caller{}, pageList)...)
+	progress(70)
+
+	// Changed content missing from the delta was deleted, unpublished or left the channel
+	for _, id := range postIDs {
+		if !included[id] {
+			b.Removed.Posts = append(b.Removed.Posts, id)
+		}
+	}
+	includedPages := make(map[uint]bool, len(pageList))
+	for _, page := range pageList {
+		includedPages[page.ID] = true
+	}
+	for _, id := range pageIDs {
+		if !includedPages[id] {
+			b.Removed.Pages = append(b.Removed.Pages, id)
+		}
+	}
+
+	dir, err := jobs.Dir()
+	if err != nil {
+		return "", err
+	}
+	name := unsafeFilename.ReplaceAllString(payload.Channel, "_")
+	path := filepath.Join(dir, fmt.Sprintf("bundle-%s-%d-%d-job-%d.json.gz", name, payload.Since, payload.Version, job.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := bundle.Write(file, b); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// changedIDs returns the IDs of entities of a type changed after since, up to version
+func changedIDs(db *gorm.DB, entityType string, since, version int64) ([]uint, error) {
+	var ids []uint
+	err := db.Model(&models.Change{}).
+		Where("entity_type = ? AND seq > ? AND seq <= ?", entityType, since, version).
+		Distinct().Pluck("entity_id", &ids).Error
+	return ids, err
+}
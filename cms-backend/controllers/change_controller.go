@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultChangesLimit is how many changes GetChanges returns without ?limit=
+const defaultChangesLimit = 100
+
+// ChangesResponse is one page of the change feed
+type ChangesResponse struct {
+	Changes []models.Change `json:"changes"`
+
+	// NextSince is the since value for the next request; it equals the
+	// request's since when there were no new changes
+	NextSince int64 `json:"next_since"`
+
+	// HasMore is set when more changes are waiting past this page
+	HasMore bool `json:"has_more"`
+}
+
+// GetChanges lists content changes with a sequence number above ?since= (default 0),
+// oldest first, up to ?limit= (default 100, up to 1000). Consumers store
+// next_since and pass it back to catch up without a full re-sync; a since
+// older than the retention period gets 410.
+func GetChanges(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var since int64
+	if value := c.Query("since"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			utils.Fail(c, utils.Invalid("since must be a non-negative sequence number"))
+			return
+		}
+		since = parsed
+	}
+	if since > 0 && !checkChangesRetained(c, db, since) {
+		return
+	}
+	limit, ok := intQuery(c, "limit", defaultChangesLimit, 1000)
+	if !ok {
+		return
+	}
+
+	// Fetch one extra row to learn whether another page follows
+	var changes []models.Change
+	if err := db.Where("seq > ?", since).Order("seq").Limit(limit + 1).Find(&changes).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	response := ChangesResponse{Changes: changes, NextSince: since}
+	if len(changes) > limit {
+		response.Changes = changes[:limit]
+		response.HasMore = true
+	}
+	if len(response.Changes) > 0 {
+		response.NextSince = response.Changes[len(response.Changes)-1].Seq
+	} else {
+		response.Changes = []models.Change{}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"cms-backend/chat"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ChatChannelRequest is the body for adding or updating a chat channel
+type ChatChannelRequest struct {
+	Name string `json:"name" binding:"required"`
+
+	// Provider is "slack" or "discord"
+	Provider string `json:"provider" binding:"required"`
+
+	// WebhookURL is the channel's incoming webhook; required for new channels
+	// and unchanged when omitted from an update
+	WebhookURL string `json:"webhook_url"`
+
+	// Events lists the announced event kinds, e.g. ["published"]; empty announces all
+	Events []string `json:"events"`
+
+	// Filter routes events by entity, action, status, tag or id, e.g. `entity=post AND tag=press`
+	Filter string `json:"filter"`
+
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// GetChatChannels lists every chat channel
+func GetChatChannels(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var channels []models.ChatChannel
+	if err := db.Order("id").Find(&channels).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+// CreateChatChannel adds a chat channel. It announces events that happen after it is added.
+func CreateChatChannel(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req ChatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.WebhookURL == "" {
+		utils.Fail(c, utils.Invalid("webhook_url is required"))
+		return
+	}
+	if !validateChatChannelRequest(c, req) {
+		return
+	}
+
+	channel := models.ChatChannel{
+		Name:       req.Name,
+		Provider:   req.Provider,
+		WebhookURL: req.WebhookURL,
+		Events:     strings.Join(req.Events, ","),
+		Filter:     req.Filter,
+		Active:     req.Active == nil || *req.Active,
+	}
+
+	// Start from the current end of the activity trail and submission queue
+	if err := db.Model(&models.Activity{}).Select("COALESCE(MAX(id), 0)").Scan(&channel.LastActivityID).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := db.Model(&models.Submission{}).Select("COALESCE(MAX(id), 0)").Scan(&channel.LastSubmissionID).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := db.Create(&channel).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, channel)
+}
+
+// UpdateChatChannel changes a chat channel's name, provider, webhook, routing
+// rules or active state
+func UpdateChatChannel(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	channel, ok := findChatChannel(c, db)
+	if !ok {
+		return
+	}
+
+	var req ChatChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !validateChatChannelRequest(c, req) {
+		return
+	}
+
+	channel.Name = req.Name
+	channel.Provider = req.Provider
+	channel.Events = strings.Join(req.Events, ",")
+	channel.Filter = req.Filter
+	if req.WebhookURL != "" {
+		channel.WebhookURL = req.WebhookURL
+	}
+	if req.Active != nil {
+		channel.Active = *req.Active
+	}
+	if err := db.Save(&channel).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChatChannel removes a chat channel
+func DeleteChatChannel(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	channel, ok := findChatChannel(c, db)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&channel).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Chat channel deleted successfully",
+	})
+}
+
+// TestChatChannel posts a sample announcement to a chat channel and reports
+// how the provider responded. The channel's position is unaffected.
+func TestChatChannel(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	channel, ok := findChatChannel(c, db)
+	if !ok {
+		return
+	}
+
+	result := chat.Deliver(c.Request.Context(), channel, chat.SampleEvent())
+	c.JSON(http.StatusOK, result)
+}
+
+// findChatChannel loads the chat channel named by the :id parameter, writing
+// a 404 response when it does not exist
+func findChatChannel(c *gin.Context, db *gorm.DB) (models.ChatChannel, bool) {
+	var channel models.ChatChannel
+	if err := db.First(&channel, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Chat channel not found"))
+			return channel, false
+		}
+		utils.Fail(c, err)
+		return channel, false
+	}
+	return channel, true
+}
+
+// validateChatChannelRequest checks the provider, the webhook URL against the
+// egress policy, the events and the filter, writing an error response when any is invalid
+func validateChatChannelRequest(c *gin.Context, req ChatChannelRequest) bool {
+	if !chat.ValidProvider(req.Provider) {
+		utils.Fail(c, utils.Invalid("provider must be slack or discord"))
+		return false
+	}
+	if req.WebhookURL != "" {
+		if err := egress.PolicyFromEnv().ValidateURL(c.Request.Context(), req.WebhookURL); err != nil {
+			writeURLError(c, err)
+			return false
+		}
+	}
+	if _, err := chat.ParseEvents(strings.Join(req.Events, ",")); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return false
+	}
+	if _, err := webhooks.ParseFilter(req.Filter); err != nil {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   err.Error(),
+			ErrorCode: utils.ErrorCodeInvalidFilter,
+		})
+		return false
+	}
+	return true
+}
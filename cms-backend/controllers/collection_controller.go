@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// collectionResources are the listings a collection can search
+var collectionResources = []string{"posts", "pages", "media"}
+
+// CollectionRequest is the body for creating or updating a collection. Query
+// takes the listing's query parameters as they would appear in its URL; omit
+// it on update to keep the current filters.
+type CollectionRequest struct {
+	Name     string  `json:"name"`
+	Resource string  `json:"resource"`
+	Query    *string `json:"query"`
+}
+
+// GetCollections retrieves all saved collections
+func GetCollections(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var collections []models.Collection
+	if err := db.Order("name").Find(&collections).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collections)
+}
+
+// GetCollection retrieves a collection's definition
+func GetCollection(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	collection, ok := findCollection(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// CreateCollection saves a named search over posts, pages or media
+func CreateCollection(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return
+	}
+	collection := models.Collection{Name: req.Name, Resource: req.Resource, CreatedBy: auth.CallerFrom(c).Name}
+	if req.Query != nil {
+		collection.Query = *req.Query
+	}
+	if !normalizeCollection(c, &collection) {
+		return
+	}
+
+	if err := db.Create(&collection).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, collection)
+}
+
+// UpdateCollection renames a collection or changes what it searches
+func UpdateCollection(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	collection, ok := findCollection(c, db)
+	if !ok {
+		return
+	}
+
+	var req CollectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.Name != "" {
+		collection.Name = req.Name
+	}
+	if req.Resource != "" {
+		collection.Resource = req.Resource
+	}
+	if req.Query != nil {
+		collection.Query = *req.Query
+	}
+	if !normalizeCollection(c, collection) {
+		return
+	}
+
+	if err := db.Save(collection).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, collection)
+}
+
+// DeleteCollection deletes a collection; the records it lists are not affected
+func DeleteCollection(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	collection, ok := findCollection(c, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(collection).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Collection deleted successfully",
+	})
+}
+
+// GetCollectionItems returns a handler listing the records a collection
+// matches right now, by running the listing handler of its resource with the
+// saved query parameters. Parameters on the request itself, such as ?limit=,
+// ?after= or ?fields=, are added and take precedence.
+func GetCollectionItems(lists map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Get database instance from context
+		db := c.MustGet("db").(*gorm.DB)
+
+		collection, ok := findCollection(c, db)
+		if !ok {
+			return
+		}
+		list, ok := lists[collection.Resource]
+		if !ok {
+			utils.Fail(c, fmt.Errorf("no listing for collection resource %q", collection.Resource))
+			return
+		}
+		query, err := url.ParseQuery(collection.Query)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+
+		// gin caches the query the first time it is read, so it is rewritten
+		// before the listing handler reads it
+		for key, values := range c.Request.URL.Query() {
+			query[key] = values
+		}
+		c.Request.URL.RawQuery = query.Encode()
+		list(c)
+	}
+}
+
+// findCollection loads the collection named by the :id parameter, writing a
+// 404 response when it does not exist
+func findCollection(c *gin.Context, db *gorm.DB) (*models.Collection, bool) {
+	var collection models.Collection
+	if err := db.First(&collection, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Collection not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &collection, true
+}
+
+// normalizeCollection checks a collection's resource and query, storing the
+// query in canonical form, and writes a 400 response when either is invalid.
+// A cursor is meaningless once saved, so ?after= is refused.
+func normalizeCollection(c *gin.Context, collection *models.Collection) bool {
+	if !slices.Contains(collectionResources, collection.Resource) {
+		utils.Fail(c, utils.Invalid("resource must be one of: "+strings.Join(collectionResources, ", ")))
+		return false
+	}
+	query, err := url.ParseQuery(strings.TrimPrefix(collection.Query, "?"))
+	if err != nil {
+		utils.Fail(c, utils.Invalid("query must be URL-encoded query parameters"))
+		return false
+	}
+	if query.Has("after") {
+		utils.Fail(c, utils.Invalid("query cannot contain after"))
+		return false
+	}
+	collection.Query = query.Encode()
+	return true
+}
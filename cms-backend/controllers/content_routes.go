@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"net/url"
+	"os"
+	"strings"
+)
+
+// contentRoute is where a content type lives on the public site: the path in
+// pathEnv (or defaultPath) with {slug} replaced. Pages are hierarchical, so
+// their {slug} is the slugs of their ancestors and their own joined by "/".
+// Sitemaps, rename redirects and path resolution all use these routes.
+type contentRoute struct {
+	table       string
+	pathEnv     string
+	defaultPath string
+}
+
+var contentRoutes = []contentRoute{
+	{table: "posts", pathEnv: "SITEMAP_POST_PATH", defaultPath: "/posts/{slug}"},
+	{table: "pages", pathEnv: "SITEMAP_PAGE_PATH", defaultPath: "/{slug}"},
+}
+
+// pattern returns the configured path pattern
+func (r contentRoute) pattern() string {
+	if pattern := os.Getenv(r.pathEnv); pattern != "" {
+		return pattern
+	}
+	return r.defaultPath
+}
+
+// path is where the content with slug lives on the public site
+func (r contentRoute) path(slug string) string {
+	segments := strings.Split(slug, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.ReplaceAll(r.pattern(), "{slug}", strings.Join(segments, "/"))
+}
+
+// match returns the slug in path when path follows the route's pattern
+func (r contentRoute) match(path string) (string, bool) {
+	prefix, suffix, ok := strings.Cut(r.pattern(), "{slug}")
+	if !ok || !strings.HasPrefix(path, prefix) || !strings.HasSuffix(path, suffix) || len(path) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	slug, err := url.PathUnescape(path[len(prefix) : len(path)-len(suffix)])
+	if err != nil {
+		return "", false
+	}
+	return slug, true
+}
+
+// contentPath is where the post or page with slug, from table, lives on the public site
+func contentPath(table, slug string) string {
+	for _, route := range contentRoutes {
+		if route.table == table {
+			return route.path(slug)
+		}
+	}
+	return "/" + url.PathEscape(slug)
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CountResponse is the number of records matching a filter
+type CountResponse struct {
+	Count int64 `json:"count"`
+}
+
+// setListTotal reports the number of items a list has over all its pages in
+// utils.TotalCountHeader, and the items a response holds in Content-Range as
+// "<unit> <first>-<last>/<total>". first is -1 when the position of the page
+// is unknown, as after a cursor, and the range is then given as "*".
+func setListTotal(c *gin.Context, unit string, first, count int, total int64) {
+	c.Header(utils.TotalCountHeader, strconv.FormatInt(total, 10))
+	span := "*"
+	if first >= 0 && count > 0 {
+		span = fmt.Sprintf("%d-%d", first, first+count-1)
+	}
+	c.Header("Content-Range", fmt.Sprintf("%s %s/%d", unit, span, total))
+}
+
+// headList answers a HEAD request for a list with just its total
+func headList(c *gin.Context, unit string, total int64) {
+	setListTotal(c, unit, -1, 0, total)
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"cms-backend/dam"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DAMSyncResult counts the media a DAM webhook created, updated and deleted
+type DAMSyncResult struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Deleted int `json:"deleted"`
+}
+
+// DAMHandler receives the webhooks of external asset managers
+type DAMHandler struct {
+	Deps
+}
+
+// NewDAMHandler returns a DAM handler using deps, with defaults for any it
+// leaves unset
+func NewDAMHandler(deps Deps) *DAMHandler {
+	return &DAMHandler{Deps: deps.withDefaults()}
+}
+
+// Receive mirrors asset changes reported by the external asset
+// manager named by :provider into the media library. Webhooks are
+// authenticated by the asset manager's signature rather than an API key.
+// Assets are matched to media by their ID in the asset manager, so updates
+// change the same media row and deletions remove it.
+func (h *DAMHandler) Receive(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	source := c.Param("provider")
+	adapter, ok := dam.Default[source]
+	if !ok {
+		utils.Fail(c, utils.NotFound("Unknown asset manager"))
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.Fail(c, utils.Invalid("Could not read request body"))
+		return
+	}
+	if err := adapter.Verify(c.Request.Header, body, h.Clock()); err != nil {
+		h.Logger.Printf("Refusing %s webhook: %v", source, err)
+		c.JSON(http.StatusUnauthorized, utils.HTTPError{
+			Code:    http.StatusUnauthorized,
+			Message: "Invalid webhook signature",
+		})
+		return
+	}
+	events, err := adapter.Events(body)
+	if err != nil {
+		utils.Fail(c, utils.Invalid("Invalid webhook payload"))
+		return
+	}
+	for _, event := range events {
+		if len(event.Asset.URL) > mediaURLMaxLength {
+			utils.Fail(c, utils.Invalid(fmt.Sprintf("Asset URL is longer than %d characters", mediaURLMaxLength)))
+			return
+		}
+	}
+
+	var result DAMSyncResult
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		for _, event := range events {
+			if err := applyAssetEvent(tx, source, event, &result); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// applyAssetEvent creates, updates or deletes the media mirroring an asset
+func applyAssetEvent(tx *gorm.DB, source string, event dam.Event, result *DAMSyncResult) error {
+	var link models.ExternalAsset
+	err := tx.Where("source = ? AND external_id = ?", source, event.Asset.ID).First(&link).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	found := err == nil
+
+	if event.Deleted {
+		if !found {
+			return nil
+		}
+		// The link goes with the media
+		if err := tx.Delete(&models.Media{}, link.MediaID).Error; err != nil {
+			return err
+		}
+		result.Deleted++
+		return nil
+	}
+
+	asset := event.Asset
+	if found {
+		if err := tx.Model(&models.Media{ID: link.MediaID}).Updates(map[string]interface{}{
+			"url":    asset.URL,
+			"type":   asset.Type,
+			"size":   asset.Size,
+			"width":  asset.Width,
+			"height": asset.Height,
+		}).Error; err != nil {
+			return err
+		}
+		result.Updated++
+		return nil
+	}
+
+	media := models.Media{
+		URL:        asset.URL,
+		Type:       asset.Type,
+		Size:       asset.Size,
+		Width:      asset.Width,
+		Height:     asset.Height,
+		ScanStatus: models.ScanUnscanned,
+	}
+	if err := tx.Create(&media).Error; err != nil {
+		return err
+	}
+	if err := tx.Create(&models.ExternalAsset{Source: source, ExternalID: asset.ID, MediaID: media.ID}).Error; err != nil {
+		return err
+	}
+	result.Created++
+	return nil
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// The delivery handlers serve published content to readers. They serialize
+// for an anonymous caller whatever delivery token was used, so every response
+// is the same for everyone in an audience; only public responses are cached.
+
+// GetDeliveryPosts lists published posts newest first, in pages of ?limit=
+// posts (default 20) continued with ?after=
+func GetDeliveryPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query, limit, ok := deliveryPage(c, db.Scopes(deliverable(c, "posts")), "posts")
+	if !ok {
+		return
+	}
+	query, ok = preloadPostRelations(c, query)
+	if !ok {
+		return
+	}
+
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	posts = trimPostsPage(c, posts, limit)
+
+	writeFields(c, http.StatusOK, serializers.Posts(auth.Caller{}, posts))
+}
+
+// GetDeliveryPost returns a published post by slug; ?format=html, ?format=text
+// or ?format=amp adds that rendering of the content for web pages, email and
+// AMP consumers
+func GetDeliveryPost(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query, ok := preloadPostRelations(c, db.Scopes(deliverable(c, "posts")).Where("posts.slug = ?", c.Param("slug")))
+	if !ok {
+		return
+	}
+
+	var post models.Post
+	if err := query.First(&post).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	if !renderPostFormat(c, db, &post) {
+		return
+	}
+
+	// Republished posts point crawlers and consumers at the original
+	if post.CanonicalURL != "" {
+		c.Header("Link", "<"+post.CanonicalURL+`>; rel="canonical"`)
+	}
+	writeFields(c, http.StatusOK, serializers.Post(auth.Caller{}, post))
+}
+
+// GetDeliveryPages lists published pages like GetDeliveryPosts
+func GetDeliveryPages(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query, limit, ok := deliveryPage(c, db.Scopes(deliverable(c, "pages")), "pages")
+	if !ok {
+		return
+	}
+
+	var pages []models.Page
+	if err := query.Find(&pages).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(pages) > limit {
+		pages = pages[:limit]
+		last := pages[limit-1]
+		c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	writeFields(c, http.StatusOK, serializers.Pages(auth.Caller{}, pages))
+}
+
+// GetDeliveryPage returns a published page by slug
+func GetDeliveryPage(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var page models.Page
+	if err := db.Scopes(deliverable(c, "pages")).Where("pages.slug = ?", c.Param("slug")).First(&page).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Page not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	writeFields(c, http.StatusOK, serializers.Page(auth.Caller{}, page))
+}
+
+// deliverable returns a scope limiting a query on table to the content the
+// request's delivery audience may read: published, out of any embargo, and
+// members-only content for members alone
+func deliverable(c *gin.Context, table string) func(*gorm.DB) *gorm.DB {
+	return acl.Published(table, auth.AudienceFrom(c) == auth.AudienceMembers)
+}
+
+// deliveryPage paginates a delivery listing, which is always paginated so a
+// single cached response stays small
+func deliveryPage(c *gin.Context, query *gorm.DB, table string) (*gorm.DB, int, bool) {
+	query, limit, ok := paginate(c, query, table)
+	if !ok {
+		return nil, 0, false
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+		query = query.Order(table + ".created_at DESC").Order(table + ".id DESC").Limit(limit + 1)
+	}
+	return query, limit, true
+}
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DistributionListRequest is the body for creating or updating a distribution
+// list. Members sets the full membership; omit it on update to keep it.
+type DistributionListRequest struct {
+	Name        string                      `json:"name"`
+	Description string                      `json:"description"`
+	Members     []DistributionMemberRequest `json:"members"`
+}
+
+// DistributionMemberRequest is one contact, identified by email address
+type DistributionMemberRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// GetDistributionLists retrieves all distribution lists with their members
+func GetDistributionLists(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var lists []models.DistributionList
+	if err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("name").Find(&lists).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+// GetDistributionList retrieves a distribution list with its members
+func GetDistributionList(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	list, ok := findDistributionList(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// CreateDistributionList creates a distribution list
+func CreateDistributionList(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req DistributionListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return
+	}
+	if req.Members == nil {
+		req.Members = []DistributionMemberRequest{}
+	}
+
+	list := models.DistributionList{Name: req.Name, Description: req.Description}
+	saveDistributionList(c, db, &list, req.Members, http.StatusCreated)
+}
+
+// UpdateDistributionList updates a distribution list. Members that keep their
+// email address keep their embargo links; removed members lose them.
+func UpdateDistributionList(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	list, ok := findDistributionList(c, db)
+	if !ok {
+		return
+	}
+
+	var req DistributionListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.Name != "" {
+		list.Name = req.Name
+	}
+	if req.Description != "" {
+		list.Description = req.Description
+	}
+
+	saveDistributionList(c, db, list, req.Members, http.StatusOK)
+}
+
+// DeleteDistributionList deletes a distribution list, revoking its members' embargo links
+func DeleteDistributionList(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	list, ok := findDistributionList(c, db)
+	if !ok {
+		return
+	}
+
+	// Members and their links are removed by ON DELETE CASCADE foreign keys
+	if err := db.Delete(list).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Distribution list deleted successfully",
+	})
+}
+
+// saveDistributionList writes the list and (when members is non-nil) reconciles
+// its membership by email address in one transaction
+func saveDistributionList(c *gin.Context, db *gorm.DB, list *models.DistributionList, members []DistributionMemberRequest, status int) {
+	if members != nil && !validateDistributionMembers(c, members) {
+		return
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Save(list).Error; err != nil {
+			return err
+		}
+		if members == nil {
+			return nil
+		}
+
+		existing := make(map[string]models.DistributionMember, len(list.Members))
+		for _, member := range list.Members {
+			existing[strings.ToLower(member.Email)] = member
+		}
+
+		for _, req := range members {
+			key := strings.ToLower(req.Email)
+			member, found := existing[key]
+			delete(existing, key)
+			if found && member.Name == req.Name {
+				continue
+			}
+			member.ListID = list.ID
+			member.Name = req.Name
+			member.Email = req.Email
+			if err := tx.Save(&member).Error; err != nil {
+				return err
+			}
+		}
+
+		// Whoever is left was removed from the list
+		var removed []uint
+		for _, member := range existing {
+			removed = append(removed, member.ID)
+		}
+		if len(removed) == 0 {
+			return nil
+		}
+		return tx.Where("id IN ?", removed).Delete(&models.DistributionMember{}).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	list.Members = []models.DistributionMember{}
+	if err := db.Where("list_id = ?", list.ID).Order("id").Find(&list.Members).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(status, list)
+}
+
+// validateDistributionMembers checks every member has a distinct valid email
+// address, writing a 400 response when one does not
+func validateDistributionMembers(c *gin.Context, members []DistributionMemberRequest) bool {
+	seen := make(map[string]bool, len(members))
+	for i, member := range members {
+		var message string
+		address, err := mail.ParseAddress(member.Email)
+		switch {
+		case err != nil || address.Address != member.Email:
+			message = "email must be a valid email address"
+		case seen[strings.ToLower(member.Email)]:
+			message = "email is already on the list"
+		}
+		if message != "" {
+			utils.Fail(c, utils.Invalid(fmt.Sprintf("members[%d] %s", i, message)))
+			return false
+		}
+		seen[strings.ToLower(member.Email)] = true
+	}
+	return true
+}
+
+// findDistributionList loads the list named by the :id parameter with its
+// members, writing a 404/500 response on failure
+func findDistributionList(c *gin.Context, db *gorm.DB) (*models.DistributionList, bool) {
+	var list models.DistributionList
+	if err := db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&list, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Distribution list not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &list, true
+}
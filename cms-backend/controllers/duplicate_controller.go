@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DuplicateRequest optionally overrides the title of a copy.
+// Without a title the copy is named "<original title> (copy)".
+type DuplicateRequest struct {
+	Title string `json:"title"`
+}
+
+// DuplicatePost creates a draft copy of a post with the same media and tags.
+// The copy gets a new slug and fresh timestamps.
+func DuplicatePost(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	req, ok := bindDuplicateRequest(c)
+	if !ok {
+		return
+	}
+
+	var original models.Post
+	if err := db.Preload("Media").Preload("Tags").First(&original, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	post := models.Post{
+		Title:        copyTitle(original.Title, req.Title),
+		Content:      original.Content,
+		ReadingTime:  original.ReadingTime,
+		Author:       original.Author,
+		AuthorEmail:  original.AuthorEmail,
+		Locale:       original.Locale,
+		Status:       models.StatusDraft,
+		Visibility:   original.Visibility,
+		Notes:        original.Notes,
+		OwnerGroupID: original.OwnerGroupID,
+		Media:        original.Media,
+		Tags:         original.Tags,
+
+		// A copy republishes the same original
+		CanonicalURL:      original.CanonicalURL,
+		SyndicationSource: original.SyndicationSource,
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		slug, err := uniqueSlug(tx, &models.Post{}, utils.Slugify(post.Title), 0)
+		if err != nil {
+			return err
+		}
+		post.Slug = slug
+
+		// Media and tags already exist, so only the junction rows are written
+		if err := tx.Omit("Media.*", "Tags.*").Create(&post).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPost, EntityID: post.ID, Action: models.ActivityCreated})
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/posts/%d", post.ID))
+	c.JSON(http.StatusCreated, serializers.Post(auth.CallerFrom(c), post))
+}
+
+// DuplicatePage creates a draft copy of a page with a new slug and fresh timestamps
+func DuplicatePage(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	req, ok := bindDuplicateRequest(c)
+	if !ok {
+		return
+	}
+
+	var original models.Page
+	if err := db.First(&original, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Page not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	page := models.Page{
+		Title:        copyTitle(original.Title, req.Title),
+		Content:      original.Content,
+		Locale:       original.Locale,
+		Status:       models.StatusDraft,
+		Visibility:   original.Visibility,
+		Notes:        original.Notes,
+		OwnerGroupID: original.OwnerGroupID,
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		slug, err := uniqueSlug(tx, &models.Page{}, utils.Slugify(page.Title), 0)
+		if err != nil {
+			return err
+		}
+		page.Slug = slug
+
+		if err := tx.Create(&page).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPage, EntityID: page.ID, Action: models.ActivityCreated})
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/pages/%d", page.ID))
+	c.JSON(http.StatusCreated, serializers.Page(auth.CallerFrom(c), page))
+}
+
+// bindDuplicateRequest reads the optional request body, writing a 400 on malformed JSON
+func bindDuplicateRequest(c *gin.Context) (DuplicateRequest, bool) {
+	var req DuplicateRequest
+	if c.Request.ContentLength == 0 {
+		return req, true
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return req, false
+	}
+	return req, true
+}
+
+// copyTitle returns the requested title, or the original marked as a copy
+func copyTitle(original, requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return original + " (copy)"
+}
@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EmbargoTokenPrefix starts every embargo link token
+const EmbargoTokenPrefix = "emb_"
+
+// EmbargoRequest embargoes a draft post until PublishAt and shares it with the
+// members of the given distribution lists
+type EmbargoRequest struct {
+	PublishAt *time.Time `json:"publish_at" binding:"required"`
+	ListIDs   []uint     `json:"list_ids"`
+}
+
+// EmbargoShare is a link issued to one member. The token is not stored and cannot be shown again.
+type EmbargoShare struct {
+	MemberID uint   `json:"member_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Token    string `json:"token"`
+}
+
+// EmbargoResponse returns an embargo with the links issued by this request
+type EmbargoResponse struct {
+	Embargo models.Embargo `json:"embargo"`
+	Shares  []EmbargoShare `json:"shares"`
+}
+
+// EmbargoedPost is what a list member sees through their link
+type EmbargoedPost struct {
+	PublishAt time.Time                `json:"publish_at"`
+	Post      serializers.PostResponse `json:"post"`
+}
+
+// EmbargoPost embargoes a draft post and issues links to the members of the
+// given lists. Repeating the request moves the embargo time and issues links
+// only to members not yet holding one; each email address gets one link.
+func EmbargoPost(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req EmbargoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !req.PublishAt.After(time.Now()) {
+		utils.Fail(c, utils.Invalid("publish_at must be in the future"))
+		return
+	}
+
+	var post models.Post
+	if err := db.First(&post, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+	if post.Status != models.StatusDraft {
+		utils.Fail(c, utils.Conflict("Only draft posts can be embargoed"))
+		return
+	}
+	// An embargo schedules the post's release, so it takes publish access
+	if !checkACL(c, db, acl.EntityPost, post.ID, acl.Publish) {
+		return
+	}
+
+	var members []models.DistributionMember
+	if len(req.ListIDs) > 0 {
+		var count int64
+		if err := db.Model(&models.DistributionList{}).Where("id IN ?", req.ListIDs).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count != int64(len(uniqueIDs(req.ListIDs))) {
+			utils.Fail(c, utils.Invalid("One or more distribution lists do not exist"))
+			return
+		}
+		if err := db.Where("list_id IN ?", req.ListIDs).Order("id").Find(&members).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+	}
+
+	shares := []EmbargoShare{}
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		embargo := models.Embargo{PostID: post.ID, PublishAt: *req.PublishAt}
+		if err := tx.Omit("Links").Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"publish_at", "published_at", "updated_at"}),
+		}).Create(&embargo).Error; err != nil {
+			return err
+		}
+
+		// Skip addresses that already hold a link, whichever list they came from
+		var linked []models.EmbargoLink
+		if err := tx.Preload("Member").Where("embargo_id = ?", embargo.ID).Find(&linked).Error; err != nil {
+			return err
+		}
+		shared := make(map[string]bool, len(linked))
+		for _, link := range linked {
+			shared[strings.ToLower(link.Member.Email)] = true
+		}
+
+		for _, member := range members {
+			email := strings.ToLower(member.Email)
+			if shared[email] {
+				continue
+			}
+			shared[email] = true
+
+			token, err := generateEmbargoToken()
+			if err != nil {
+				return err
+			}
+			link := models.EmbargoLink{EmbargoID: embargo.ID, MemberID: member.ID, TokenHash: auth.HashKey(token)}
+			if err := tx.Omit("Member").Create(&link).Error; err != nil {
+				return err
+			}
+			shares = append(shares, EmbargoShare{MemberID: member.ID, Name: member.Name, Email: member.Email, Token: token})
+		}
+		return nil
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	found, ok := findEmbargo(c, db)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, EmbargoResponse{Embargo: found, Shares: shares})
+}
+
+// GetEmbargo retrieves a post's embargo with each link's access history
+func GetEmbargo(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	embargo, ok := findEmbargo(c, db)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, acl.EntityPost, embargo.PostID, acl.Read) {
+		return
+	}
+
+	c.JSON(http.StatusOK, embargo)
+}
+
+// DeleteEmbargo lifts a post's embargo and revokes its links. The post stays a draft.
+func DeleteEmbargo(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	embargo, ok := findEmbargo(c, db)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, acl.EntityPost, embargo.PostID, acl.Publish) {
+		return
+	}
+
+	// Links are removed by the ON DELETE CASCADE foreign key
+	if err := db.Delete(&embargo).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Embargo lifted successfully",
+	})
+}
+
+// GetEmbargoedPost serves an embargoed post to the list member holding the
+// link token and records the access. No API key is needed; the token is the credential.
+func GetEmbargoedPost(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var link models.EmbargoLink
+	if err := db.Where("token_hash = ?", auth.HashKey(c.Param("token"))).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Link not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	var embargo models.Embargo
+	if err := db.First(&embargo, link.EmbargoID).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	var post models.Post
+	if err := db.Preload("Media").Preload("Tags").First(&post, embargo.PostID).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	now := time.Now()
+	if err := db.Model(&link).UpdateColumns(map[string]interface{}{
+		"access_count":      gorm.Expr("access_count + 1"),
+		"first_accessed_at": gorm.Expr("COALESCE(first_accessed_at, ?)", now),
+		"last_accessed_at":  now,
+	}).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	// Embargoed content must not be kept by shared caches
+	c.Header("Cache-Control", "private, no-store")
+	c.JSON(http.StatusOK, EmbargoedPost{PublishAt: embargo.PublishAt, Post: serializers.Post(auth.Caller{}, post)})
+}
+
+// findEmbargo loads the embargo of the post named by the :id parameter with
+// its links, writing a 404/500 response on failure
+func findEmbargo(c *gin.Context, db *gorm.DB) (models.Embargo, bool) {
+	var embargo models.Embargo
+	if err := db.Preload("Links", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Links.Member").Where("post_id = ?", c.Param("id")).First(&embargo).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post is not embargoed"))
+			return embargo, false
+		}
+		utils.Fail(c, err)
+		return embargo, false
+	}
+	return embargo, true
+}
+
+// generateEmbargoToken returns a new random link token
+func generateEmbargoToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return EmbargoTokenPrefix + hex.EncodeToString(buf), nil
+}
+
+// uniqueIDs returns ids without duplicates
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]bool, len(ids))
+	var unique []uint
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
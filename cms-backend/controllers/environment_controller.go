@@ -0,0 +1,519 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/readability"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Changes reported by DiffEnvironment
+const (
+	EnvironmentAdded     = "added"
+	EnvironmentChanged   = "changed"
+	EnvironmentUnchanged = "unchanged"
+)
+
+// EnvironmentRequest is the body for creating an environment
+type EnvironmentRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// EnvironmentEntryRequest is the body for staging a post or page in an
+// environment. Status defaults to published, the status it gets on promotion.
+type EnvironmentEntryRequest struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Author  string `json:"author"`
+	Status  string `json:"status"`
+}
+
+// EnvironmentEntryKey names an entry by its type and slug
+type EnvironmentEntryKey struct {
+	EntityType string `json:"entity_type"`
+	Slug       string `json:"slug"`
+}
+
+// EnvironmentChange is how an entry differs from the same content in the
+// target environment. Fields lists what a promotion would change.
+type EnvironmentChange struct {
+	EntityType string   `json:"entity_type"`
+	Slug       string   `json:"slug"`
+	Change     string   `json:"change"`
+	Fields     []string `json:"fields,omitempty"`
+}
+
+// PromoteRequest is the body for promoting entries. To defaults to
+// production and Entries to every entry of the environment.
+type PromoteRequest struct {
+	To      string                `json:"to"`
+	Entries []EnvironmentEntryKey `json:"entries"`
+}
+
+// PromoteResponse lists what a promotion changed in the target
+type PromoteResponse struct {
+	To       string              `json:"to"`
+	Promoted []EnvironmentChange `json:"promoted"`
+}
+
+// GetEnvironments retrieves all environments apart from production
+func GetEnvironments(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var environments []models.Environment
+	if err := db.Order("name").Find(&environments).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, environments)
+}
+
+// CreateEnvironment creates an empty environment. Its name is used in URLs,
+// so it takes the same form as a slug, and "production" is reserved for the
+// live content.
+func CreateEnvironment(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req EnvironmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !utils.IsValidSlug(req.Name) || req.Name == models.ProductionEnvironment {
+		utils.Fail(c, utils.Invalid("Name must be lowercase letters, numbers and hyphens, and not 'production'"))
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.Environment{}).Where("name = ?", req.Name).Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if count > 0 {
+		utils.Fail(c, utils.Conflict("An environment with this name already exists"))
+		return
+	}
+
+	environment := models.Environment{Name: req.Name, Description: req.Description, CreatedBy: auth.CallerFrom(c).Name}
+	if err := db.Create(&environment).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, environment)
+}
+
+// DeleteEnvironment deletes an environment and everything staged in it
+func DeleteEnvironment(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+
+	// Entries are removed by the foreign key
+	if err := db.Delete(&environment).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Environment deleted successfully",
+	})
+}
+
+// GetEnvironmentEntries lists the posts and pages staged in an environment
+func GetEnvironmentEntries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+	var entries []models.EnvironmentEntry
+	if err := db.Where("environment_id = ?", environment.ID).Order("entity_type, slug").Find(&entries).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// PutEnvironmentEntry stages the post or page :type/:slug in an environment,
+// replacing any version already staged there
+func PutEnvironmentEntry(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+	key, ok := environmentEntryKey(c, EnvironmentEntryKey{EntityType: c.Param("type"), Slug: c.Param("slug")})
+	if !ok {
+		return
+	}
+
+	var req EnvironmentEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.Title == "" || req.Content == "" {
+		utils.Fail(c, utils.Invalid("Title and content are required"))
+		return
+	}
+	if req.Status == "" {
+		req.Status = models.StatusPublished
+	}
+	if !checkPublishing(c, req.Status, nil) {
+		return
+	}
+
+	entry := models.EnvironmentEntry{
+		EnvironmentID: environment.ID,
+		EntityType:    key.EntityType,
+		Slug:          key.Slug,
+		Title:         req.Title,
+		Content:       req.Content,
+		Author:        req.Author,
+		Status:        req.Status,
+		UpdatedBy:     auth.CallerFrom(c).Name,
+	}
+	staged := []models.EnvironmentEntry{entry}
+	if err := stageEntries(db, staged); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, staged[0])
+}
+
+// DeleteEnvironmentEntry drops :type/:slug from an environment
+func DeleteEnvironmentEntry(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+	result := db.Where("environment_id = ? AND entity_type = ? AND slug = ?", environment.ID, c.Param("type"), c.Param("slug")).
+		Delete(&models.EnvironmentEntry{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Entry not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Entry deleted successfully",
+	})
+}
+
+// DiffEnvironment compares each entry of an environment with the same post
+// or page in ?to= (default production): added when the target has none,
+// otherwise changed with the fields that differ, or unchanged. Content only
+// in the target is not listed, since promoting never removes it.
+func DiffEnvironment(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+	to := c.DefaultQuery("to", models.ProductionEnvironment)
+	var entries []models.EnvironmentEntry
+	if err := db.Where("environment_id = ?", environment.ID).Order("entity_type, slug").Find(&entries).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	changes, ok := diffEntries(c, db, entries, to)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// PromoteEnvironment copies entries of an environment to another one, or
+// applies them to the live posts and pages when promoting to production. It
+// promotes the listed entries, or all of them, in one transaction, so a
+// launch goes out together or not at all. Promoting to production needs the
+// same grants as editing the content directly and is recorded in its history.
+func PromoteEnvironment(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	environment, ok := findEnvironment(c, db, c.Param("name"))
+	if !ok {
+		return
+	}
+	var req PromoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.To == "" {
+		req.To = models.ProductionEnvironment
+	}
+	if req.To == environment.Name {
+		utils.Fail(c, utils.Invalid("Cannot promote an environment to itself"))
+		return
+	}
+
+	query := db.Where("environment_id = ?", environment.ID)
+	if len(req.Entries) > 0 {
+		keys := make([][]interface{}, len(req.Entries))
+		for i, key := range req.Entries {
+			if _, ok := environmentEntryKey(c, key); !ok {
+				return
+			}
+			keys[i] = []interface{}{key.EntityType, key.Slug}
+		}
+		query = query.Where("(entity_type, slug) IN ?", keys)
+	}
+	var entries []models.EnvironmentEntry
+	if err := query.Order("entity_type, slug").Find(&entries).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(entries) < len(req.Entries) {
+		utils.Fail(c, utils.NotFound("Some of the entries are not staged in "+environment.Name))
+		return
+	}
+
+	response := PromoteResponse{To: req.To, Promoted: []EnvironmentChange{}}
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		changes, ok := diffEntries(c, tx, entries, req.To)
+		if !ok {
+			return errResponded
+		}
+		var promote []models.EnvironmentEntry
+		for i, change := range changes {
+			if change.Change != EnvironmentUnchanged {
+				promote = append(promote, entries[i])
+				response.Promoted = append(response.Promoted, change)
+			}
+		}
+		if len(promote) == 0 {
+			return nil
+		}
+
+		if req.To != models.ProductionEnvironment {
+			target, ok := findEnvironment(c, tx, req.To)
+			if !ok {
+				return errResponded
+			}
+			for i := range promote {
+				promote[i].ID, promote[i].EnvironmentID = 0, target.ID
+			}
+			return stageEntries(tx, promote)
+		}
+		for _, entry := range promote {
+			if err := promoteToProduction(c, tx, entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// promoteToProduction creates or updates the live post or page an entry names
+func promoteToProduction(c *gin.Context, tx *gorm.DB, entry models.EnvironmentEntry) error {
+	if entry.EntityType == acl.EntityPage {
+		var page models.Page
+		err := tx.Where("slug = ?", entry.Slug).First(&page).Error
+		if err == gorm.ErrRecordNotFound {
+			page = models.Page{Slug: entry.Slug, Title: entry.Title, Content: entry.Content, Status: entry.Status, Visibility: models.VisibilityPublic}
+			if err := tx.Create(&page).Error; err != nil {
+				return err
+			}
+			return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPage, EntityID: page.ID, Action: models.ActivityCreated})
+		}
+		if err != nil {
+			return err
+		}
+		before := page
+		if !checkACL(c, tx, acl.EntityPage, page.ID, promotionPermission(before.Status, entry.Status)) {
+			return errResponded
+		}
+		page.Title, page.Content, page.Status = entry.Title, entry.Content, entry.Status
+		page.ExpiresAt = republishClearsExpiry(page.Status, page.ExpiresAt)
+		if err := tx.Save(&page).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, c, editActivities(acl.EntityPage, page.ID, changedPageFields(before, page), before.Status, page.Status)...)
+	}
+
+	var post models.Post
+	err := tx.Where("slug = ?", entry.Slug).First(&post).Error
+	if err == gorm.ErrRecordNotFound {
+		post = models.Post{
+			Slug:        entry.Slug,
+			Title:       entry.Title,
+			Content:     entry.Content,
+			Author:      entry.Author,
+			Status:      entry.Status,
+			Visibility:  models.VisibilityPublic,
+			ReadingTime: readability.ReadingTime(entry.Content),
+		}
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPost, EntityID: post.ID, Action: models.ActivityCreated})
+	}
+	if err != nil {
+		return err
+	}
+	before := post
+	if !checkACL(c, tx, acl.EntityPost, post.ID, promotionPermission(before.Status, entry.Status)) {
+		return errResponded
+	}
+	post.Title, post.Content, post.Status = entry.Title, entry.Content, entry.Status
+	if entry.Author != "" {
+		post.Author = entry.Author
+	}
+	post.ExpiresAt = republishClearsExpiry(post.Status, post.ExpiresAt)
+	post.ReadingTime = readability.ReadingTime(post.Content)
+	if err := tx.Save(&post).Error; err != nil {
+		return err
+	}
+	return recordActivity(tx, c, editActivities(acl.EntityPost, post.ID, changedPostFields(before, post, false), before.Status, post.Status)...)
+}
+
+// promotionPermission is the grant needed to promote over content: publish
+// when the promotion publishes it, write otherwise
+func promotionPermission(from, to string) string {
+	if to == models.StatusPublished && from != models.StatusPublished {
+		return acl.Publish
+	}
+	return acl.Write
+}
+
+// diffEntries compares entries with the same content in the environment
+// named to, returning one change per entry in order
+func diffEntries(c *gin.Context, db *gorm.DB, entries []models.EnvironmentEntry, to string) ([]EnvironmentChange, bool) {
+	target := make(map[EnvironmentEntryKey]models.EnvironmentEntry)
+	if to == models.ProductionEnvironment {
+		// Pages have no author, so none is ever reported changed on them
+		for _, table := range []struct {
+			entity string
+			model  interface{}
+			author string
+		}{{acl.EntityPost, &models.Post{}, "author"}, {acl.EntityPage, &models.Page{}, "'' AS author"}} {
+			var slugs []string
+			for _, entry := range entries {
+				if entry.EntityType == table.entity {
+					slugs = append(slugs, entry.Slug)
+				}
+			}
+			if len(slugs) == 0 {
+				continue
+			}
+			var rows []models.EnvironmentEntry
+			if err := db.Model(table.model).Select("slug", "title", "content", "status", table.author).
+				Where("slug IN ?", slugs).Find(&rows).Error; err != nil {
+				utils.Fail(c, err)
+				return nil, false
+			}
+			for _, row := range rows {
+				target[EnvironmentEntryKey{EntityType: table.entity, Slug: row.Slug}] = row
+			}
+		}
+	} else {
+		environment, ok := findEnvironment(c, db, to)
+		if !ok {
+			return nil, false
+		}
+		var rows []models.EnvironmentEntry
+		if err := db.Where("environment_id = ?", environment.ID).Find(&rows).Error; err != nil {
+			utils.Fail(c, err)
+			return nil, false
+		}
+		for _, row := range rows {
+			target[EnvironmentEntryKey{EntityType: row.EntityType, Slug: row.Slug}] = row
+		}
+	}
+
+	changes := make([]EnvironmentChange, len(entries))
+	for i, entry := range entries {
+		change := EnvironmentChange{EntityType: entry.EntityType, Slug: entry.Slug, Change: EnvironmentAdded}
+		if current, ok := target[EnvironmentEntryKey{EntityType: entry.EntityType, Slug: entry.Slug}]; ok {
+			change.Change = EnvironmentUnchanged
+			add := func(name string, changed bool) {
+				if changed {
+					change.Fields = append(change.Fields, name)
+					change.Change = EnvironmentChanged
+				}
+			}
+			add("title", entry.Title != current.Title)
+			add("content", entry.Content != current.Content)
+			add("author", entry.EntityType == acl.EntityPost && entry.Author != "" && entry.Author != current.Author)
+			add("status", entry.Status != current.Status)
+		}
+		changes[i] = change
+	}
+	return changes, true
+}
+
+// stageEntries saves entries, replacing the versions their environments
+// already hold
+func stageEntries(db *gorm.DB, entries []models.EnvironmentEntry) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "environment_id"}, {Name: "entity_type"}, {Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"title", "content", "author", "status", "updated_by", "updated_at"}),
+	}).Create(entries).Error
+}
+
+// environmentEntryKey validates the type and slug naming an entry, writing a
+// 400 response when either is invalid
+func environmentEntryKey(c *gin.Context, key EnvironmentEntryKey) (EnvironmentEntryKey, bool) {
+	if key.EntityType != acl.EntityPost && key.EntityType != acl.EntityPage {
+		utils.Fail(c, utils.Invalid("Entity type must be 'post' or 'page'"))
+		return key, false
+	}
+	if !utils.IsValidSlug(key.Slug) {
+		utils.Fail(c, utils.Invalid(errInvalidSlug.Error()))
+		return key, false
+	}
+	return key, true
+}
+
+// findEnvironment loads the environment called name, writing a 404 response
+// when there is none
+func findEnvironment(c *gin.Context, db *gorm.DB, name string) (models.Environment, bool) {
+	var environment models.Environment
+	if err := db.Where("name = ?", name).First(&environment).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Environment not found"))
+			return environment, false
+		}
+		utils.Fail(c, err)
+		return environment, false
+	}
+	return environment, true
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/pdf"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ExportPostPDF renders a post to PDF using the site export template
+func ExportPostPDF(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+	if post.Status != models.StatusPublished && !checkACL(c, db, acl.EntityPost, post.ID, acl.Read) {
+		return
+	}
+
+	writePDF(c, fmt.Sprintf("post-%d.pdf", post.ID), pdf.Data{
+		Title:     post.Title,
+		Author:    post.Author,
+		Content:   post.Content,
+		CreatedAt: post.CreatedAt,
+		UpdatedAt: post.UpdatedAt,
+	})
+}
+
+// ExportPagePDF renders a page to PDF using the site export template
+func ExportPagePDF(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var page models.Page
+	if err := db.Scopes(acl.Readable(auth.CallerFrom(c), acl.EntityPage, "pages")).First(&page, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Page not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	writePDF(c, fmt.Sprintf("page-%d.pdf", page.ID), pdf.Data{
+		Title:     page.Title,
+		Content:   page.Content,
+		CreatedAt: page.CreatedAt,
+		UpdatedAt: page.UpdatedAt,
+	})
+}
+
+// writePDF generates the document and sends it as a download
+func writePDF(c *gin.Context, filename string, data pdf.Data) {
+	document, err := pdf.Generate(data)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", document)
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ReorderRequest is the ordered list of featured post IDs
+type ReorderRequest struct {
+	PostIDs []uint `json:"post_ids"`
+}
+
+// ReorderFeaturedPosts replaces the featured set with the given posts, in order.
+// Posts missing from the list stop being featured; an empty list clears the set.
+func ReorderFeaturedPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req ReorderRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.PostIDs == nil {
+		utils.Fail(c, utils.Invalid("post_ids is required"))
+		return
+	}
+
+	seen := make(map[uint]bool, len(req.PostIDs))
+	for _, id := range req.PostIDs {
+		if seen[id] {
+			utils.Fail(c, utils.Invalid("post_ids must not contain duplicates"))
+			return
+		}
+		seen[id] = true
+	}
+
+	if len(req.PostIDs) > 0 {
+		var count int64
+		if err := db.Model(&models.Post{}).Where("id IN ?", req.PostIDs).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count != int64(len(req.PostIDs)) {
+			utils.Fail(c, utils.Invalid("One or more posts do not exist"))
+			return
+		}
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Unfeature posts dropped from the list
+		unfeature := tx.Model(&models.Post{}).Where("featured = ?", true)
+		if len(req.PostIDs) > 0 {
+			unfeature = unfeature.Where("id NOT IN ?", req.PostIDs)
+		}
+		if err := unfeature.Updates(map[string]interface{}{"featured": false, "position": 0}).Error; err != nil {
+			return err
+		}
+
+		for i, id := range req.PostIDs {
+			if err := tx.Model(&models.Post{}).Where("id = ?", id).
+				Updates(map[string]interface{}{"featured": true, "position": i + 1}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	// Return the featured posts in their new order
+	var posts []models.Post
+	if err := db.Preload("Media").Preload("Tags").
+		Where("featured = ?", true).Order("position").Order("id").
+		Find(&posts).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, serializers.Posts(auth.CallerFrom(c), posts))
+}
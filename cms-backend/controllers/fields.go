@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"cms-backend/serializers"
+	"cms-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeFields responds with value reduced to the fields named in ?fields=, so
+// clients rendering lists can skip content bodies and relations
+func writeFields(c *gin.Context, status int, value interface{}) {
+	selected, err := serializers.SelectFields(value, serializers.ParseFields(c.Query("fields")))
+	if err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	c.JSON(status, selected)
+}
+
+// wantsField reports whether ?fields= selects field, or selects everything
+func wantsField(c *gin.Context, field string) bool {
+	fields := serializers.ParseFields(c.Query("fields"))
+	if len(fields) == 0 {
+		return true
+	}
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/flags"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// flagName matches valid flag names, e.g. "graphql" or "block_editor"
+var flagName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// FlagRequest is the body for writing a feature flag
+type FlagRequest struct {
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Percentage  int      `json:"percentage"`
+	Roles       []string `json:"roles"`
+}
+
+// GetFlags lists every feature flag with its rollout rules
+func GetFlags(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	all, err := flags.Default.All(db)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	list := make([]models.FeatureFlag, 0, len(all))
+	for _, flag := range all {
+		list = append(list, flag)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	c.JSON(http.StatusOK, list)
+}
+
+// GetEvaluatedFlags reports which flags are on for the caller, so clients can
+// show or hide features consistently with the API
+func GetEvaluatedFlags(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	all, err := flags.Default.All(db)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	evaluated := make(map[string]bool, len(all))
+	for name := range all {
+		evaluated[name] = flags.Enabled(c, name)
+	}
+	c.JSON(http.StatusOK, evaluated)
+}
+
+// PutFlag creates or replaces a feature flag
+func PutFlag(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	name := c.Param("name")
+	if !flagName.MatchString(name) {
+		utils.Fail(c, utils.Invalid("Flag names must be lowercase letters, digits and underscores"))
+		return
+	}
+
+	var req FlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.Percentage < 0 || req.Percentage > 100 {
+		utils.Fail(c, utils.Invalid("percentage must be between 0 and 100"))
+		return
+	}
+	for _, role := range req.Roles {
+		if !auth.ValidRole(role) {
+			utils.Fail(c, utils.Invalid("Unknown role "+role))
+			return
+		}
+	}
+
+	flag := models.FeatureFlag{
+		Name:        name,
+		Description: req.Description,
+		Enabled:     req.Enabled,
+		Percentage:  req.Percentage,
+		Roles:       strings.Join(req.Roles, ","),
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "enabled", "percentage", "roles", "updated_at"}),
+	}).Create(&flag).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	flags.Default.Invalidate()
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag removes a feature flag; code checking it sees it as off
+func DeleteFlag(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	result := db.Where("name = ?", c.Param("name")).Delete(&models.FeatureFlag{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Flag not found"))
+		return
+	}
+	flags.Default.Invalidate()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Flag deleted successfully",
+	})
+}
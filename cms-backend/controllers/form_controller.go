@@ -0,0 +1,685 @@
+package controllers
+
+import (
+	"cms-backend/captcha"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultFormFieldLength bounds values of fields without a MaxLength
+const defaultFormFieldLength = 5000
+
+// formFieldName is the shape of field names, which become JSON keys and CSV columns
+var formFieldName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,99}$`)
+
+// formFieldTypes are the field types a form may use
+var formFieldTypes = map[string]bool{
+	models.FormFieldText:     true,
+	models.FormFieldTextarea: true,
+	models.FormFieldEmail:    true,
+	models.FormFieldNumber:   true,
+	models.FormFieldURL:      true,
+	models.FormFieldSelect:   true,
+	models.FormFieldCheckbox: true,
+}
+
+// FormRequest is the body for creating or updating a form. Slug defaults to
+// one derived from Name and Active to true on create. Fields sets every field
+// of the form in order; omit it on update to keep them.
+type FormRequest struct {
+	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
+	Description string             `json:"description"`
+	Honeypot    *string            `json:"honeypot"`
+	Active      *bool              `json:"active"`
+	Fields      []models.FormField `json:"fields"`
+}
+
+// FormSubmissionError is the 400 response to a submission with invalid
+// values; Fields maps each invalid field to what is wrong with it
+type FormSubmissionError struct {
+	utils.HTTPError
+	Fields map[string]string `json:"fields"`
+}
+
+// GetForms retrieves all forms with their fields
+func GetForms(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var forms []models.Form
+	if err := db.Preload("Fields", orderFormFields).Order("name").Find(&forms).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, forms)
+}
+
+// GetForm retrieves a form with its fields
+func GetForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// GetDeliveryForm retrieves an active form by slug, so a frontend can render it
+func GetDeliveryForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findActiveForm(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// CreateForm creates a form
+func CreateForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req FormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return
+	}
+	if len(req.Fields) == 0 {
+		utils.Fail(c, utils.Invalid("A form needs at least one field"))
+		return
+	}
+	if req.Slug == "" {
+		req.Slug = utils.Slugify(req.Name)
+	}
+
+	form := models.Form{Name: req.Name, Slug: req.Slug, Description: req.Description, Active: true}
+	if req.Honeypot != nil {
+		form.Honeypot = *req.Honeypot
+	}
+	if req.Active != nil {
+		form.Active = *req.Active
+	}
+	saveForm(c, db, &form, req.Fields, http.StatusCreated)
+}
+
+// UpdateForm updates the fields of a form given in the request. Submissions
+// already received keep the values of fields that are removed.
+func UpdateForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+
+	var req FormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.Name != "" {
+		form.Name = req.Name
+	}
+	if req.Slug != "" {
+		form.Slug = req.Slug
+	}
+	if req.Description != "" {
+		form.Description = req.Description
+	}
+	if req.Honeypot != nil {
+		form.Honeypot = *req.Honeypot
+	}
+	if req.Active != nil {
+		form.Active = *req.Active
+	}
+	if req.Fields != nil && len(req.Fields) == 0 {
+		utils.Fail(c, utils.Invalid("A form needs at least one field"))
+		return
+	}
+
+	saveForm(c, db, form, req.Fields, http.StatusOK)
+}
+
+// DeleteForm deletes a form along with its submissions
+func DeleteForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+
+	// Fields and submissions are removed by ON DELETE CASCADE foreign keys
+	if err := db.Delete(form).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Form deleted successfully",
+	})
+}
+
+// SubmitForm accepts a visitor's submission of an active form, sent as a JSON
+// object or as an HTML form post. Values are validated against the form's
+// fields and only defined fields are stored. A filled-in honeypot field drops
+// the submission while answering as if it was accepted, so bots learn
+// nothing; when a captcha provider is configured its token must verify. With
+// a spam filter configured, suspicious submissions are held for moderation.
+func SubmitForm(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findActiveForm(c, db)
+	if !ok {
+		return
+	}
+
+	values, err := bindFormValues(c)
+	if err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	accepted := gin.H{"message": "Submission received"}
+	if form.Honeypot != "" && values[form.Honeypot] != "" {
+		c.JSON(http.StatusCreated, accepted)
+		return
+	}
+
+	data, invalid := validateFormValues(form.Fields, values)
+	if len(invalid) > 0 {
+		c.JSON(http.StatusBadRequest, FormSubmissionError{
+			HTTPError: utils.HTTPError{
+				Code:      http.StatusBadRequest,
+				Message:   "Some fields are invalid",
+				ErrorCode: utils.ErrorCodeInvalidSubmission,
+			},
+			Fields: invalid,
+		})
+		return
+	}
+
+	// Tokens are single-use, so they are only spent on otherwise valid submissions
+	token := values[captcha.TokenField]
+	if token == "" {
+		token = values[captcha.Default.Field()]
+	}
+	if !verifyCaptcha(c, token) {
+		return
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	submission := models.FormSubmission{
+		FormID:    form.ID,
+		Data:      string(encoded),
+		IP:        c.ClientIP(),
+		UserAgent: truncateString(c.GetHeader("User-Agent"), 500),
+	}
+
+	// Suspected spam is held for moderation, answered like any other submission
+	screenSubmission(c, db, form, data, &submission)
+	if err := db.Create(&submission).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, accepted)
+}
+
+// GetFormSubmissions lists a form's submissions newest first, one page at a
+// time like other feeds; ?status=held lists those awaiting moderation
+func GetFormSubmissions(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+
+	scope := db.Where("form_id = ?", form.ID)
+	switch status := c.Query("status"); status {
+	case "":
+	case models.FormSubmissionAccepted, models.FormSubmissionHeld, models.FormSubmissionSpam:
+		scope = scope.Where("status = ?", status)
+	default:
+		utils.Fail(c, utils.Invalid("status must be accepted, held or spam"))
+		return
+	}
+
+	query, limit, ok := paginate(c, scope, "form_submissions")
+	if !ok {
+		return
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+		query = query.Order("form_submissions.created_at DESC").Order("form_submissions.id DESC").Limit(limit + 1)
+	}
+
+	var submissions []models.FormSubmission
+	if err := query.Find(&submissions).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(submissions) > limit {
+		submissions = submissions[:limit]
+		last := submissions[limit-1]
+		c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+// ExportFormSubmissions downloads every submission of a form but spam as
+// CSV, oldest first, with a column per current field of the form. Rows are
+// read in batches by ID so large exports need little memory.
+func ExportFormSubmissions(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+
+	header := []string{"id", "submitted_at"}
+	for _, field := range form.Fields {
+		header = append(header, field.Name)
+	}
+	header = append(header, "ip", "user_agent")
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-submissions.csv"`, form.Slug))
+	c.Status(http.StatusOK)
+
+	out := csv.NewWriter(c.Writer)
+	out.Write(header)
+
+	var batch []models.FormSubmission
+	err := db.Where("form_id = ? AND status <> ?", form.ID, models.FormSubmissionSpam).FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, submission := range batch {
+			var data map[string]string
+			if err := json.Unmarshal([]byte(submission.Data), &data); err != nil {
+				return err
+			}
+			row := []string{strconv.FormatUint(uint64(submission.ID), 10), submission.CreatedAt.UTC().Format(time.RFC3339)}
+			for _, field := range form.Fields {
+				row = append(row, csvSafe(data[field.Name]))
+			}
+			row = append(row, submission.IP, csvSafe(submission.UserAgent))
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	out.Flush()
+
+	// The status is already sent, so a failure can only cut the file short
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		log.Printf("Exporting submissions of form %d failed: %v", form.ID, err)
+	}
+}
+
+// DeleteFormSubmission deletes one submission, such as on a visitor's request
+// to erase their data
+func DeleteFormSubmission(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	result := db.Where("id = ? AND form_id = ?", c.Param("submissionId"), c.Param("id")).Delete(&models.FormSubmission{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Submission not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Submission deleted successfully",
+	})
+}
+
+// saveForm validates and writes a form and (when fields is non-nil) replaces
+// its fields in one transaction, answering 409 when another form has the slug
+func saveForm(c *gin.Context, db *gorm.DB, form *models.Form, fields []models.FormField, status int) {
+	message := "slug may only contain lowercase letters, numbers and hyphens"
+	if utils.IsValidSlug(form.Slug) {
+		message = validateFormFields(form, fields)
+	}
+	if message != "" {
+		utils.Fail(c, utils.Invalid(message))
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.Form{}).Where("slug = ? AND id <> ?", form.Slug, form.ID).Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if count > 0 {
+		utils.Fail(c, utils.Conflict("A form with this slug already exists"))
+		return
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Omit("Fields").Save(form).Error; err != nil {
+			return err
+		}
+		if fields == nil {
+			return nil
+		}
+
+		if err := tx.Where("form_id = ?", form.ID).Delete(&models.FormField{}).Error; err != nil {
+			return err
+		}
+		for i := range fields {
+			fields[i].ID = 0
+			fields[i].FormID = form.ID
+			fields[i].Position = i
+		}
+		if err := tx.Create(&fields).Error; err != nil {
+			return err
+		}
+		form.Fields = fields
+		return nil
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(status, form)
+}
+
+// verifyCaptcha checks a captcha token when a provider is configured, writing
+// a 400/502 response and returning false when the visitor is not let through
+func verifyCaptcha(c *gin.Context, token string) bool {
+	if !captcha.Enabled(captcha.Default) {
+		return true
+	}
+	human, err := captcha.Default.Verify(c.Request.Context(), token, c.ClientIP())
+	if err != nil {
+		log.Printf("Captcha verification failed: %v", err)
+		c.JSON(http.StatusBadGateway, utils.HTTPError{
+			Code:    http.StatusBadGateway,
+			Message: "Captcha could not be verified, retry later",
+		})
+		return false
+	}
+	if !human {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   "Captcha verification failed",
+			ErrorCode: utils.ErrorCodeCaptchaFailed,
+		})
+		return false
+	}
+	return true
+}
+
+// validateFormFields returns why the fields (or, when nil, the form's current
+// fields) cannot be saved with form, or "" when they can
+func validateFormFields(form *models.Form, fields []models.FormField) string {
+	if fields == nil {
+		fields = form.Fields
+	}
+	if form.Honeypot != "" && !formFieldName.MatchString(form.Honeypot) {
+		return "honeypot must start with a letter and contain only letters, numbers, _ and -"
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		var message string
+		switch {
+		case !formFieldName.MatchString(field.Name):
+			message = "name must start with a letter and contain only letters, numbers, _ and -"
+		case seen[field.Name]:
+			message = "name is used by another field"
+		case field.Name == form.Honeypot || field.Name == captcha.TokenField || field.Name == captcha.Default.Field():
+			message = "name is reserved for spam protection"
+		case !formFieldTypes[field.Type]:
+			message = "type must be one of text, textarea, email, number, url, select or checkbox"
+		case field.Type == models.FormFieldSelect && len(formFieldOptions(field)) == 0:
+			message = "options are required for a select field"
+		case field.MaxLength < 0:
+			message = "max_length must not be negative"
+		}
+		if message == "" && field.Pattern != "" {
+			if _, err := regexp.Compile(field.Pattern); err != nil {
+				message = "pattern is not a valid regular expression"
+			}
+		}
+		if message != "" {
+			return fmt.Sprintf("fields[%d] %s", i, message)
+		}
+		seen[field.Name] = true
+	}
+	return ""
+}
+
+// validateFormValues checks the submitted values against the form's fields,
+// returning the values to store and, for each invalid field, why it is invalid
+func validateFormValues(fields []models.FormField, values map[string]string) (map[string]string, map[string]string) {
+	data := make(map[string]string, len(fields))
+	invalid := make(map[string]string)
+	for _, field := range fields {
+		value := strings.TrimSpace(values[field.Name])
+
+		if field.Type == models.FormFieldCheckbox {
+			switch strings.ToLower(value) {
+			case "true", "on", "yes", "1":
+				value = "true"
+			case "", "false", "off", "no", "0":
+				value = "false"
+			default:
+				invalid[field.Name] = "must be checked or unchecked"
+				continue
+			}
+			if field.Required && value != "true" {
+				invalid[field.Name] = "must be checked"
+				continue
+			}
+			data[field.Name] = value
+			continue
+		}
+
+		if value == "" {
+			if field.Required {
+				invalid[field.Name] = "is required"
+			}
+			continue
+		}
+
+		maxLength := field.MaxLength
+		if maxLength == 0 {
+			maxLength = defaultFormFieldLength
+		}
+		if utf8.RuneCountInString(value) > maxLength {
+			invalid[field.Name] = fmt.Sprintf("must be at most %d characters", maxLength)
+			continue
+		}
+
+		var message string
+		switch field.Type {
+		case models.FormFieldEmail:
+			if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+				message = "must be a valid email address"
+			}
+		case models.FormFieldNumber:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				message = "must be a number"
+			}
+		case models.FormFieldURL:
+			if parsed, err := url.Parse(value); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				message = "must be an http(s) URL"
+			}
+		case models.FormFieldSelect:
+			message = "must be one of the options"
+			for _, option := range formFieldOptions(field) {
+				if value == option {
+					message = ""
+					break
+				}
+			}
+		}
+		if message == "" && field.Pattern != "" {
+			// Patterns were checked when the form was saved
+			if pattern, err := regexp.Compile(`^(?:` + field.Pattern + `)$`); err == nil && !pattern.MatchString(value) {
+				message = "is not in the expected format"
+			}
+		}
+		if message != "" {
+			invalid[field.Name] = message
+			continue
+		}
+		data[field.Name] = value
+	}
+	return data, invalid
+}
+
+// bindFormValues reads the submitted values from a JSON object of strings,
+// numbers and booleans or from a URL-encoded or multipart form
+func bindFormValues(c *gin.Context) (map[string]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType == "application/json" {
+		var raw map[string]interface{}
+		if err := c.ShouldBindJSON(&raw); err != nil {
+			return nil, err
+		}
+		values := make(map[string]string, len(raw))
+		for name, value := range raw {
+			switch v := value.(type) {
+			case nil:
+				values[name] = ""
+			case string:
+				values[name] = v
+			case bool:
+				values[name] = strconv.FormatBool(v)
+			case float64:
+				values[name] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return nil, fmt.Errorf("%s must be a string, number or boolean", name)
+			}
+		}
+		return values, nil
+	}
+
+	if err := c.Request.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		return nil, err
+	}
+	values := make(map[string]string, len(c.Request.PostForm))
+	for name := range c.Request.PostForm {
+		values[name] = c.Request.PostForm.Get(name)
+	}
+	return values, nil
+}
+
+// formFieldOptions splits the comma-separated options of a select field
+func formFieldOptions(field models.FormField) []string {
+	var options []string
+	for _, option := range strings.Split(field.Options, ",") {
+		if option = strings.TrimSpace(option); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// csvSafe stops spreadsheet applications from running a visitor's value as a
+// formula when the export is opened
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// truncateString cuts s to at most n bytes without splitting a character
+func truncateString(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// orderFormFields preloads form fields in the order they were defined
+func orderFormFields(db *gorm.DB) *gorm.DB {
+	return db.Order("position")
+}
+
+// findForm loads the form named by the :id parameter with its fields, writing
+// a 404/500 response on failure
+func findForm(c *gin.Context, db *gorm.DB) (*models.Form, bool) {
+	var form models.Form
+	if err := db.Preload("Fields", orderFormFields).First(&form, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Form not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &form, true
+}
+
+// findActiveForm loads the active form named by the :slug parameter with its
+// fields, writing a 404/500 response on failure
+func findActiveForm(c *gin.Context, db *gorm.DB) (*models.Form, bool) {
+	var form models.Form
+	if err := db.Preload("Fields", orderFormFields).Where("slug = ? AND active = ?", c.Param("slug"), true).
+		First(&form).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Form not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &form, true
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/spam"
+	"cms-backend/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// FormSubmissionModeration is the body for a moderator's verdict on a submission
+type FormSubmissionModeration struct {
+	// Status is "accepted" or "spam"
+	Status string `json:"status" binding:"required,oneof=accepted spam"`
+}
+
+// ModerateFormSubmission accepts a submission or marks it as spam, such as
+// one the spam filter held. The verdict trains the configured spam filter.
+func ModerateFormSubmission(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req FormSubmissionModeration
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	form, ok := findForm(c, db)
+	if !ok {
+		return
+	}
+	var submission models.FormSubmission
+	if err := db.Where("id = ? AND form_id = ?", c.Param("submissionId"), form.ID).First(&submission).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Submission not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+	if submission.Status == req.Status {
+		c.JSON(http.StatusOK, submission)
+		return
+	}
+
+	if err := db.Model(&submission).Update("status", req.Status).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	// The verdict is recorded even when the filter cannot learn from it
+	if spam.Default != nil {
+		var data map[string]string
+		if err := json.Unmarshal([]byte(submission.Data), &data); err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		content := submissionContent(form, data, submission.IP, submission.UserAgent)
+		if err := spam.Default.Checker.Learn(c.Request.Context(), db, content, req.Status == models.FormSubmissionSpam); err != nil {
+			log.Printf("Teaching the spam filter about submission %d failed: %v", submission.ID, err)
+		}
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// screenSubmission scores a submission with the configured spam filter and
+// holds it for moderation when it looks like spam. Submissions that cannot
+// be checked are held too, so nothing is lost or let through unchecked.
+func screenSubmission(c *gin.Context, db *gorm.DB, form *models.Form, data map[string]string, submission *models.FormSubmission) {
+	submission.Status = models.FormSubmissionAccepted
+	if spam.Default == nil {
+		return
+	}
+	content := submissionContent(form, data, submission.IP, submission.UserAgent)
+	score, err := spam.Default.Checker.Score(c.Request.Context(), db, content)
+	if err != nil {
+		log.Printf("Spam check of a submission to form %d failed: %v", form.ID, err)
+		submission.Status = models.FormSubmissionHeld
+		return
+	}
+	submission.SpamScore = &score
+	if spam.Default.Hold(score) {
+		submission.Status = models.FormSubmissionHeld
+	}
+}
+
+// submissionContent describes a submission to the spam filter: the first
+// email field is the sender's address, a field called name their name, and
+// every other value the body
+func submissionContent(form *models.Form, data map[string]string, ip, userAgent string) spam.Content {
+	content := spam.Content{Type: "contact-form", IP: ip, UserAgent: userAgent}
+	var body []string
+	for _, field := range form.Fields {
+		value := data[field.Name]
+		switch {
+		case value == "":
+		case field.Type == models.FormFieldEmail && content.Email == "":
+			content.Email = value
+		case strings.EqualFold(field.Name, "name") && content.Author == "":
+			content.Author = value
+		default:
+			body = append(body, value)
+		}
+	}
+	content.Body = strings.Join(body, "\n")
+	return content
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GroupRequest is the body for creating or renaming a group
+type GroupRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// GroupMemberRequest adds an API key to a group
+type GroupMemberRequest struct {
+	APIKeyID uint `json:"api_key_id" binding:"required"`
+}
+
+// GetGroups retrieves all groups with their members
+func GetGroups(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var groups []models.Group
+	if err := preloadGroupMembers(db).Order("name").Find(&groups).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, groups)
+}
+
+// GetGroup retrieves a group with its members
+func GetGroup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, ok := findGroup(c, preloadGroupMembers(db))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// CreateGroup creates an empty group
+func CreateGroup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	if !checkGroupName(c, db, req.Name, 0) {
+		return
+	}
+
+	group := models.Group{Name: req.Name, Description: req.Description, Members: []models.GroupMember{}}
+	if err := db.Create(&group).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// UpdateGroup renames a group or changes its description
+func UpdateGroup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, ok := findGroup(c, preloadGroupMembers(db))
+	if !ok {
+		return
+	}
+	req, ok := bindGroupRequest(c)
+	if !ok {
+		return
+	}
+	if !checkGroupName(c, db, req.Name, group.ID) {
+		return
+	}
+
+	if err := db.Model(&group).Updates(map[string]interface{}{
+		"name":        req.Name,
+		"description": req.Description,
+	}).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup deletes a group. Content it owned is left without an owner and
+// the grants it held are revoked.
+func DeleteGroup(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, ok := findGroup(c, db)
+	if !ok {
+		return
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Where("principal_type = ? AND principal_id = ?", acl.PrincipalGroup, group.ID).Delete(&models.ACLEntry{}).Error; err != nil {
+			return err
+		}
+
+		// Members and content ownership are released by the foreign keys
+		return tx.Delete(&group).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Group deleted successfully",
+	})
+}
+
+// AddGroupMember adds an API key to a group; adding an existing member is a no-op
+func AddGroupMember(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, ok := findGroup(c, db)
+	if !ok {
+		return
+	}
+
+	var req GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	var key models.APIKey
+	if err := db.First(&key, req.APIKeyID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.Invalid("API key not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	member := models.GroupMember{GroupID: group.ID, APIKeyID: key.ID}
+	if err := db.Where(member).FirstOrCreate(&member).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	member.APIKey = key
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveGroupMember removes an API key from a group
+func RemoveGroupMember(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	group, ok := findGroup(c, db)
+	if !ok {
+		return
+	}
+
+	result := db.Where("group_id = ? AND api_key_id = ?", group.ID, c.Param("keyId")).Delete(&models.GroupMember{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Member not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Member removed successfully",
+	})
+}
+
+// preloadGroupMembers loads group members with their API keys, oldest first
+func preloadGroupMembers(db *gorm.DB) *gorm.DB {
+	return db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at").Order("api_key_id")
+	}).Preload("Members.APIKey")
+}
+
+func bindGroupRequest(c *gin.Context) (GroupRequest, bool) {
+	var req GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return req, false
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return req, false
+	}
+	return req, true
+}
+
+// checkGroupName writes a 409 response when another group already uses name
+func checkGroupName(c *gin.Context, db *gorm.DB, name string, excludeID uint) bool {
+	var count int64
+	if err := db.Model(&models.Group{}).Where("name = ? AND id <> ?", name, excludeID).Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if count > 0 {
+		utils.Fail(c, utils.Conflict("Group already exists"))
+		return false
+	}
+	return true
+}
+
+// findGroup loads the group named by the :id parameter, writing a 404
+// response when it does not exist
+func findGroup(c *gin.Context, db *gorm.DB) (models.Group, bool) {
+	var group models.Group
+	if err := db.First(&group, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Group not found"))
+			return group, false
+		}
+		utils.Fail(c, err)
+		return group, false
+	}
+	return group, true
+}
+
+// checkOwnerGroup writes a 400 response unless id, when set, names an
+// existing group. Zero is allowed and clears the owner.
+func checkOwnerGroup(c *gin.Context, db *gorm.DB, id *uint) bool {
+	if id == nil || *id == 0 {
+		return true
+	}
+	var count int64
+	if err := db.Model(&models.Group{}).Where("id = ?", *id).Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if count == 0 {
+		utils.Fail(c, utils.Invalid("Owner group not found"))
+		return false
+	}
+	return true
+}
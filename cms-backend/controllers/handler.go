@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"cms-backend/cache"
+	"cms-backend/presence"
+	"cms-backend/repository"
+	"cms-backend/storage"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Clock returns the current time. Handlers read the time through one so
+// tests can fix it.
+type Clock func() time.Time
+
+// Deps are the services handler structs are constructed with. Zero fields
+// are filled with the defaults by the handler constructors.
+//
+// The post, page, media, presence and DAM webhook handlers are handler
+// structs so far. The other controllers are still free functions reading the
+// database from the gin context, and UseRepositories exists for them until
+// they are converted; new handlers are written as handler structs.
+type Deps struct {
+	// Repositories builds the repositories for a request from its database,
+	// which middleware may have swapped for a replica; GORM by default
+	Repositories repository.Factory
+
+	// Cache is the response cache, nil when responses are not cached
+	Cache *cache.Cache
+
+	// Logger defaults to the standard logger
+	Logger *log.Logger
+
+	// Clock defaults to time.Now
+	Clock Clock
+
+	// Storage keeps the files the server holds itself; storage.Default by default
+	Storage storage.Store
+
+	// Presence fans out presence and lock events; presence.Default by default
+	Presence *presence.Hub
+}
+
+// withDefaults returns deps with its zero fields filled in
+func (d Deps) withDefaults() Deps {
+	if d.Repositories == nil {
+		d.Repositories = repository.NewGorm
+	}
+	if d.Logger == nil {
+		d.Logger = log.Default()
+	}
+	if d.Clock == nil {
+		d.Clock = time.Now
+	}
+	if d.Storage == nil {
+		d.Storage = storage.Default
+	}
+	if d.Presence == nil {
+		d.Presence = presence.Default
+	}
+	return d
+}
+
+// repositories returns the repositories for a request. The database is nil
+// when the request has none, which only factories of fakes accept.
+func (d Deps) repositories(c *gin.Context) *repository.Repositories {
+	db, _ := c.Get("db")
+	gormDB, _ := db.(*gorm.DB)
+	return d.Repositories(gormDB)
+}
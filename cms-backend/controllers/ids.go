@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxBatchIDs caps how many records one ?ids= request may resolve
+const maxBatchIDs = 100
+
+// idsQuery parses ?ids=1,5,9, dropping repeats but keeping the requested order.
+// With ID_FORMAT=uuid the list holds UUIDs of records in table instead, which
+// are resolved to their IDs; UUIDs naming no record are left out like unknown
+// IDs. It returns nil when the parameter is absent and writes a 400 response
+// when it is invalid.
+func idsQuery(c *gin.Context, table string) ([]uint, bool) {
+	value, present := c.GetQuery("ids")
+	if !present {
+		return nil, true
+	}
+
+	var refs []string
+	for _, part := range strings.Split(value, ",") {
+		refs = append(refs, strings.TrimSpace(part))
+	}
+	if len(refs) > maxBatchIDs {
+		utils.Fail(c, utils.Invalid("ids may list at most "+strconv.Itoa(maxBatchIDs)+" records"))
+		return nil, false
+	}
+	ids, err := resolveRefs(c.MustGet("db").(*gorm.DB), table, refs)
+	if err != nil {
+		if errors.Is(err, utils.ErrValidation) {
+			err = utils.Invalid("ids must be a comma-separated list of " + refKind())
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return ids, true
+}
+
+// RecordRef names a record in a request body: by its integer ID, or with
+// ID_FORMAT=uuid by its UUID. Both JSON numbers and strings are accepted.
+type RecordRef string
+
+// UnmarshalJSON accepts a JSON number or string
+func (r *RecordRef) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		var number json.Number
+		if err := json.Unmarshal(data, &number); err != nil {
+			return err
+		}
+		value = number.String()
+	}
+	*r = RecordRef(value)
+	return nil
+}
+
+// refKind describes the references records are named by
+func refKind() string {
+	if models.UUIDIDs() {
+		return "UUIDs"
+	}
+	return "positive integers"
+}
+
+// resolveRefs returns the IDs of the records in table that refs name,
+// dropping repeats but keeping their order. With ID_FORMAT=uuid refs must be
+// UUIDs, so integer IDs cannot be probed, and UUIDs naming no record are left
+// out; otherwise they must be integer IDs. Malformed refs are an
+// ErrValidation error.
+func resolveRefs(db *gorm.DB, table string, refs []string) ([]uint, error) {
+	if !models.UUIDIDs() {
+		var ids []uint
+		seen := make(map[uint]bool)
+		for _, ref := range refs {
+			id, err := utils.ParseID(ref)
+			if err != nil {
+				return nil, err
+			}
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		return ids, nil
+	}
+
+	lowered := make([]string, len(refs))
+	for i, ref := range refs {
+		if !utils.IsUUID(ref) {
+			return nil, utils.Invalid("IDs must be UUIDs")
+		}
+		lowered[i] = strings.ToLower(ref)
+	}
+	refs = lowered
+	resolved, err := utils.ResolveUUIDs(db, table, refs)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, ref := range refs {
+		if id, ok := resolved[ref]; ok && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// idPositions maps each requested ID to its position, for restoring request
+// order after an IN query
+func idPositions(ids []uint) map[uint]int {
+	positions := make(map[uint]int, len(ids))
+	for i, id := range ids {
+		positions[id] = i
+	}
+	return positions
+}
+
+// paramID parses the :id route parameter, reporting a 400 when it is not a
+// valid ID
+func paramID(c *gin.Context) (uint, bool) {
+	id, err := utils.ParseID(c.Param("id"))
+	if err != nil {
+		utils.Fail(c, err)
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"cms-backend/serializers"
+	"cms-backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// postRelations maps the ?include= names of post relations to their associations
+var postRelations = []struct {
+	name        string
+	association string
+}{
+	{"media", "Media"},
+	{"tags", "Tags"},
+}
+
+// preloadPostRelations preloads the post relations named in ?include=, e.g.
+// "media,tags". Without ?include= every relation is preloaded; an empty value
+// preloads none. Relations left out of ?fields= are skipped either way. An
+// unknown name writes a 400 response and returns false.
+func preloadPostRelations(c *gin.Context, query *gorm.DB) (*gorm.DB, bool) {
+	associations, ok := postIncludes(c)
+	if !ok {
+		return nil, false
+	}
+	for _, association := range associations {
+		query = query.Preload(association)
+	}
+	return query, true
+}
+
+// postIncludes returns the associations preloadPostRelations would preload
+func postIncludes(c *gin.Context) ([]string, bool) {
+	raw, present := c.GetQuery("include")
+	requested := make(map[string]bool)
+	for _, name := range serializers.ParseFields(raw) {
+		requested[name] = true
+	}
+
+	associations := []string{}
+	for _, relation := range postRelations {
+		if !present || requested[relation.name] {
+			if wantsField(c, relation.name) {
+				associations = append(associations, relation.association)
+			}
+			delete(requested, relation.name)
+		}
+	}
+	for name := range requested {
+		utils.Fail(c, utils.Invalid("unknown include \""+name+"\"; posts support media and tags"))
+		return nil, false
+	}
+	return associations, true
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"cms-backend/egress"
+	"cms-backend/integrations"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// IntegrationRequest is the body for creating or updating an integration
+type IntegrationRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+
+	// Filter selects delivered events, e.g. `entity=post AND status=published`
+	Filter string `json:"filter"`
+
+	// Preset names an integrations.Presets template used when Template is empty
+	Preset string `json:"preset"`
+
+	// Template renders the JSON body with Go template syntax, e.g.
+	// `{"title": {{json (index .Entity "title")}}}`
+	Template string `json:"template"`
+
+	// Headers are sent with every delivery; omitted from an update, the saved
+	// headers are kept
+	Headers map[string]string `json:"headers"`
+
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// IntegrationResponse is an integration with the names of its headers. Header
+// values may hold credentials and are never returned.
+type IntegrationResponse struct {
+	models.Integration
+	Headers []string `json:"headers"`
+}
+
+// GetIntegrations lists every integration
+func GetIntegrations(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var found []models.Integration
+	if err := db.Order("id").Find(&found).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	responses := make([]IntegrationResponse, len(found))
+	for i, integration := range found {
+		responses[i] = newIntegrationResponse(integration)
+	}
+	c.JSON(http.StatusOK, responses)
+}
+
+// GetIntegrationPresets lists the templates integrations can start from
+func GetIntegrationPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, integrations.Presets)
+}
+
+// CreateIntegration adds an integration. It receives changes made after it is created.
+func CreateIntegration(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req IntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !resolveIntegrationTemplate(c, &req) || !validateIntegrationRequest(c, req) {
+		return
+	}
+
+	headers := req.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	encoded, err := json.Marshal(headers)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	integration := models.Integration{
+		Name:     req.Name,
+		URL:      req.URL,
+		Filter:   req.Filter,
+		Template: req.Template,
+		Headers:  string(encoded),
+		Active:   req.Active == nil || *req.Active,
+	}
+
+	// Start from the current end of the change feed
+	if err := db.Model(&models.Change{}).Select("COALESCE(MAX(seq), 0)").Scan(&integration.LastSeq).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := db.Create(&integration).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, newIntegrationResponse(integration))
+}
+
+// UpdateIntegration changes an integration's name, URL, filter, template,
+// headers or active state
+func UpdateIntegration(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	integration, ok := findIntegration(c, db)
+	if !ok {
+		return
+	}
+
+	var req IntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !resolveIntegrationTemplate(c, &req) || !validateIntegrationRequest(c, req) {
+		return
+	}
+
+	integration.Name = req.Name
+	integration.URL = req.URL
+	integration.Filter = req.Filter
+	integration.Template = req.Template
+	if req.Headers != nil {
+		encoded, err := json.Marshal(req.Headers)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		integration.Headers = string(encoded)
+	}
+	if req.Active != nil {
+		integration.Active = *req.Active
+	}
+	if err := db.Save(&integration).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newIntegrationResponse(integration))
+}
+
+// DeleteIntegration removes an integration
+func DeleteIntegration(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	integration, ok := findIntegration(c, db)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&integration).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Integration deleted successfully",
+	})
+}
+
+// TestIntegration sends the sample data through an integration's template and
+// reports how the service responded. Its position in the change feed is unaffected.
+func TestIntegration(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	integration, ok := findIntegration(c, db)
+	if !ok {
+		return
+	}
+
+	result := integrations.Deliver(c.Request.Context(), integration, integrations.SampleData(time.Now()))
+	c.JSON(http.StatusOK, result)
+}
+
+// newIntegrationResponse lists an integration's header names in sorted order
+func newIntegrationResponse(integration models.Integration) IntegrationResponse {
+	names := []string{}
+	headers, _ := integrations.ParseHeaders(integration.Headers)
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return IntegrationResponse{Integration: integration, Headers: names}
+}
+
+// findIntegration loads the integration named by the :id parameter, writing a
+// 404 response when it does not exist
+func findIntegration(c *gin.Context, db *gorm.DB) (models.Integration, bool) {
+	var integration models.Integration
+	if err := db.First(&integration, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Integration not found"))
+			return integration, false
+		}
+		utils.Fail(c, err)
+		return integration, false
+	}
+	return integration, true
+}
+
+// resolveIntegrationTemplate fills in the template of the named preset when no
+// template is given, writing a 400 response for an unknown preset
+func resolveIntegrationTemplate(c *gin.Context, req *IntegrationRequest) bool {
+	if req.Preset == "" || req.Template != "" {
+		return true
+	}
+	preset, ok := integrations.FindPreset(req.Preset)
+	if !ok {
+		utils.Fail(c, utils.Invalid(fmt.Sprintf("Unknown preset %q", req.Preset)))
+		return false
+	}
+	req.Template = preset.Template
+	return true
+}
+
+// validateIntegrationRequest checks the URL against the egress policy, the
+// filter, the template and the headers, writing an error response when any is invalid
+func validateIntegrationRequest(c *gin.Context, req IntegrationRequest) bool {
+	if err := egress.PolicyFromEnv().ValidateURL(c.Request.Context(), req.URL); err != nil {
+		writeURLError(c, err)
+		return false
+	}
+	if _, err := webhooks.ParseFilter(req.Filter); err != nil {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   err.Error(),
+			ErrorCode: utils.ErrorCodeInvalidFilter,
+		})
+		return false
+	}
+	if err := integrations.ValidateTemplate(req.Template); err != nil {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   err.Error(),
+			ErrorCode: utils.ErrorCodeInvalidTemplate,
+		})
+		return false
+	}
+	if err := integrations.ValidateHeaders(req.Headers); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetJob returns the status of a background job
+func GetJob(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	job, ok := findJob(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// DownloadJobResult sends the file produced by a completed job to the key
+// that started it, or to an admin; to anyone else the job is not found
+func DownloadJobResult(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	job, ok := findJob(c, db)
+	if !ok {
+		return
+	}
+	if !ownsJob(auth.CallerFrom(c), job) {
+		utils.Fail(c, utils.NotFound("Job not found"))
+		return
+	}
+
+	if job.Status != models.JobCompleted || job.ResultPath == "" {
+		utils.Fail(c, utils.Conflict("Job has no downloadable result"))
+		return
+	}
+
+	c.FileAttachment(job.ResultPath, filepath.Base(job.ResultPath))
+}
+
+// findJob loads the job named by the :id parameter, writing a 404/500 response on failure
+func findJob(c *gin.Context, db *gorm.DB) (*models.Job, bool) {
+	var job models.Job
+	if err := db.First(&job, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Job not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+
+	if job.Status == models.JobCompleted && job.ResultPath != "" && ownsJob(auth.CallerFrom(c), &job) {
+		job.DownloadURL = fmt.Sprintf("/api/v1/jobs/%d/download", job.ID)
+	}
+	return &job, true
+}
+
+// ownsJob reports whether the caller may download the result of job: the key
+// that started it and admins may
+func ownsJob(caller auth.Caller, job *models.Job) bool {
+	if caller.Role == auth.RoleAdmin {
+		return true
+	}
+	return job.RequestedByKeyID != 0 && job.RequestedByKeyID == caller.KeyID
+}
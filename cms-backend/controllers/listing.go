@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"cms-backend/repository"
+	"cms-backend/search"
+	"cms-backend/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Columns each listing can be ordered by with ?sort=
+var (
+	postSortColumns  = []string{"id", "title", "author", "slug", "status", "position", "created_at", "updated_at"}
+	pageSortColumns  = []string{"id", "title", "author", "slug", "status", "created_at", "updated_at"}
+	mediaSortColumns = []string{"id", "type", "size", "created_at", "updated_at"}
+)
+
+// sortQuery parses ?sort=, a comma-separated list of columns from allowed,
+// each prefixed with "-" to sort descending, as in ?sort=author,-created_at.
+// Ties are broken by id. It returns nil without ?sort= and writes a 400
+// response when a column is not allowed or repeated.
+func sortQuery(c *gin.Context, allowed []string) ([]repository.SortField, bool) {
+	value := c.Query("sort")
+	if value == "" {
+		return nil, true
+	}
+
+	var fields []repository.SortField
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		field := repository.SortField{Column: strings.TrimSpace(part)}
+		if strings.HasPrefix(field.Column, "-") {
+			field.Column, field.Desc = field.Column[1:], true
+		}
+		if !slices.Contains(allowed, field.Column) || seen[field.Column] {
+			utils.Fail(c, utils.Invalid("sort must list distinct columns from: "+strings.Join(allowed, ", ")))
+			return nil, false
+		}
+		seen[field.Column] = true
+		fields = append(fields, field)
+	}
+	return fields, true
+}
+
+// olderThanQuery parses ?older_than_days=, returning the time listed records
+// must have been created before, or nil without it. Saved collections use it
+// to stay relative to the day they are fetched.
+func olderThanQuery(c *gin.Context) (*time.Time, bool) {
+	if c.Query("older_than_days") == "" {
+		return nil, true
+	}
+	days, ok := intQuery(c, "older_than_days", 0, 36500)
+	if !ok {
+		return nil, false
+	}
+	before := time.Now().AddDate(0, 0, -days)
+	return &before, true
+}
+
+// searchQuery parses ?q=, full-text search terms, and ?search_config=, which
+// stems every row with one of search.Configs instead of its own locale's. It
+// writes a 400 response for an unknown configuration.
+func searchQuery(c *gin.Context) (string, string, bool) {
+	config := c.Query("search_config")
+	if config != "" && !search.ValidConfig(config) {
+		utils.Fail(c, utils.Invalid("search_config must be one of: "+strings.Join(search.Configs, ", ")))
+		return "", "", false
+	}
+	return strings.TrimSpace(c.Query("q")), config, true
+}
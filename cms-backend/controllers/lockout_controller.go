@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"cms-backend/lockout"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLockouts lists the client addresses and key prefixes that recently saw
+// invalid API keys, with any lockout in force. Failures are tracked per
+// server instance, so the list covers the instance that answers.
+func GetLockouts(c *gin.Context) {
+	if lockout.Default == nil {
+		c.JSON(http.StatusOK, []lockout.Lockout{})
+		return
+	}
+	c.JSON(http.StatusOK, lockout.Default.Lockouts())
+}
+
+// DeleteLockout unlocks a client address or key prefix and forgets its failed attempts
+func DeleteLockout(c *gin.Context) {
+	if lockout.Default == nil || !lockout.Default.Unlock(c.Param("client")) {
+		utils.Fail(c, utils.NotFound("No failed attempts recorded for this address or key"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Unlocked successfully",
+	})
+}
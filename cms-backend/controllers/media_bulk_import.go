@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/bucket"
+	"cms-backend/imageinfo"
+	"cms-backend/jobs"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobTypeMediaBulkImport is the job type for bulk media imports
+const JobTypeMediaBulkImport = "media_bulk_import"
+
+// mediaURLMaxLength is the longest URL the media table holds
+const mediaURLMaxLength = 255
+
+// bulkImportPayload is stored on the job so the import can be traced
+type bulkImportPayload struct {
+	Source string `json:"source"`
+}
+
+// BulkImportFailure is a file a bulk import could not register
+type BulkImportFailure struct {
+	Key   string `json:"key"`
+	Error string `json:"error"`
+}
+
+// BulkImportSummary reports what a bulk import did with each file: files
+// already registered under the same URL and files of types MEDIA_IMPORT_TYPES
+// does not allow are skipped
+type BulkImportSummary struct {
+	Source      string              `json:"source"`
+	Imported    int                 `json:"imported"`
+	Existing    int                 `json:"existing"`
+	Unsupported int                 `json:"unsupported"`
+	Failed      []BulkImportFailure `json:"failed"`
+}
+
+// BulkImportMedia starts a job registering every file in the configured
+// asset library (MEDIA_BULK_IMPORT_SOURCE) as media, with its type, size and,
+// for images, dimensions. The files stay where they are and are not scanned.
+// Poll GET /jobs/:id for progress and download the summary from the job's
+// download_url; running the import again only adds files it has not seen.
+func BulkImportMedia(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	source := bucket.Default
+	if source == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.HTTPError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "No bulk import source is configured; set MEDIA_BULK_IMPORT_SOURCE",
+		})
+		return
+	}
+
+	job, err := jobs.Start(db, auth.CallerFrom(c).KeyID, JobTypeMediaBulkImport, bulkImportPayload{Source: source.String()}, func(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+		return bulkImportMedia(ctx, db, job, source, progress)
+	})
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// bulkImportMedia is the job function behind BulkImportMedia
+func bulkImportMedia(ctx context.Context, db *gorm.DB, job *models.Job, source bucket.Source, progress func(int)) (string, error) {
+	db = db.WithContext(ctx)
+	objects, err := source.List(ctx)
+	if err != nil {
+		return "", err
+	}
+	progress(5)
+
+	summary := BulkImportSummary{Source: source.String(), Failed: []BulkImportFailure{}}
+	for i, object := range objects {
+		if err := importObject(ctx, db, source, object, &summary); err != nil {
+			return "", err
+		}
+		progress(5 + (i+1)*90/len(objects))
+	}
+
+	dir, err := jobs.Dir()
+	if err != nil {
+		return "", err
+	}
+	resultPath := filepath.Join(dir, fmt.Sprintf("media-import-job-%d.json", job.ID))
+	file, err := os.Create(resultPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(summary); err != nil {
+		os.Remove(resultPath)
+		return "", err
+	}
+	return resultPath, nil
+}
+
+// importObject registers one file, counting the outcome in summary. Files
+// that cannot be read are recorded as failed; database errors end the import.
+func importObject(ctx context.Context, db *gorm.DB, source bucket.Source, object bucket.Object, summary *BulkImportSummary) error {
+	fail := func(message string) {
+		summary.Failed = append(summary.Failed, BulkImportFailure{Key: object.Key, Error: message})
+	}
+
+	url := source.URL(object.Key)
+	if len(url) > mediaURLMaxLength {
+		fail(fmt.Sprintf("URL is longer than %d characters", mediaURLMaxLength))
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Media{}).Where("url = ?", url).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		summary.Existing++
+		return nil
+	}
+
+	// The type comes from the extension; files without a known one, and
+	// images for their dimensions, are read
+	declared := mime.TypeByExtension(strings.ToLower(path.Ext(object.Key)))
+	var head []byte
+	if declared == "" || strings.HasPrefix(declared, "image/") {
+		var err error
+		if head, err = source.Head(ctx, object.Key, imageinfo.HeadSize); err != nil {
+			fail(err.Error())
+			return nil
+		}
+	}
+	contentType := importContentType(declared, head)
+	if !importTypeAllowed(contentType) {
+		summary.Unsupported++
+		return nil
+	}
+
+	media := models.Media{URL: url, Type: contentType, Size: object.Size, ScanStatus: models.ScanUnscanned}
+	if strings.HasPrefix(contentType, "image/") {
+		// Formats the server cannot decode are stored without dimensions
+		if width, height, err := imageinfo.Dimensions(head); err == nil {
+			media.Width, media.Height = width, height
+		}
+	}
+	if err := db.Create(&media).Error; err != nil {
+		return err
+	}
+	summary.Imported++
+	return nil
+}
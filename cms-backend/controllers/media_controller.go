@@ -1,176 +1,248 @@
 package controllers
 
 import (
+	"cms-backend/auth"
+	"cms-backend/egress"
+	"cms-backend/imageinfo"
 	"cms-backend/models"
+	"cms-backend/repository"
+	"cms-backend/scanner"
+	"cms-backend/serializers"
 	"cms-backend/utils"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func GetMedia(c *gin.Context) {
-	// Get database instance from context
-	db := c.MustGet("db").(*gorm.DB)
-	var media []models.Media
+// defaultMediaScanMaxBytes caps the files fetched for scanning when
+// MEDIA_SCAN_MAX_BYTES is not set
+const defaultMediaScanMaxBytes = 100 << 20
 
-	// Support filtering by type
-	mediaType := c.Query("type")
+// maxMediaCrops caps the named crops of one image
+const maxMediaCrops = 20
 
-	query := db
-	if mediaType != "" {
-		query = query.Where("type = ?", mediaType)
+// cropName matches the names of media crops, e.g. "square" or "og_image"
+var cropName = regexp.MustCompile(`^[a-z][a-z0-9_-]{0,49}$`)
+
+// MediaHandler serves the media endpoints
+type MediaHandler struct {
+	Deps
+}
+
+// NewMediaHandler returns a media handler using deps, with defaults for
+// any left unset
+func NewMediaHandler(deps Deps) *MediaHandler {
+	return &MediaHandler{Deps: deps.withDefaults()}
+}
+
+// List retrieves media, optionally filtered by ?type= and ordered by ?sort=
+func (h *MediaHandler) List(c *gin.Context) {
+	// ?ids= resolves several media items in one round trip, in the requested order
+	ids, ok := idsQuery(c, "media")
+	if !ok {
+		return
+	}
+	order, ok := sortQuery(c, mediaSortColumns)
+	if !ok {
+		return
 	}
 
-	// Retrieve all media with optional filtering
-	if err := query.Find(&media).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Retrieve all media with optional filtering by type; HEAD requests only count them
+	filter := repository.MediaFilter{
+		Type:               c.Query("type"),
+		IDs:                ids,
+		IncludeQuarantined: seesQuarantined(c),
+		Sort:               order,
+	}
+	repos := h.repositories(c)
+	if c.Request.Method == http.MethodHead {
+		total, err := repos.Media.Count(c.Request.Context(), filter)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		headList(c, "media", total)
 		return
 	}
+	media, err := repos.Media.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if ids != nil && order == nil {
+		positions := idPositions(ids)
+		sort.Slice(media, func(i, j int) bool { return positions[media[i].ID] < positions[media[j].ID] })
+	}
+	setListTotal(c, "media", 0, len(media), int64(len(media)))
 
-	c.JSON(http.StatusOK, media)
+	writeFields(c, http.StatusOK, serializers.MediaList(media))
 }
 
-func GetMediaByID(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *MediaHandler) Get(c *gin.Context) {
 	// Get ID parameter from URL
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 
 	// Find media by ID
-	var media models.Media
-	if err := db.First(&media, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Media not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	media, err := h.repositories(c).Media.Get(c.Request.Context(), id, seesQuarantined(c))
+	if err != nil {
+		writeRepositoryError(c, err, "Media not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, media)
+	writeFields(c, http.StatusOK, serializers.Media(*media))
 }
 
-func CreateMedia(c *gin.Context) {
-	// Get database instance from context
-	db := c.MustGet("db").(*gorm.DB)
+// CreateMediaRequest is the body of POST /media. The server records the
+// scan verdict, dimensions and uploader itself, so clients cannot set them.
+type CreateMediaRequest struct {
+	URL  string `json:"url" binding:"required,max=255"`
+	Type string `json:"type" binding:"required,max=50"`
 
-	// Define media variable to store incoming data
-	var media models.Media
+	// Size is the file size in bytes, 0 when unknown
+	Size int64 `json:"size"`
 
-	// Parse JSON request body into media struct
-	if err := c.ShouldBindJSON(&media); err != nil {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
+	AltText string       `json:"alt_text" binding:"max=1000"`
+	FocalX  *float64     `json:"focal_x"`
+	FocalY  *float64     `json:"focal_y"`
+	Crops   models.Crops `json:"crops"`
+}
+
+// model returns the media the request describes
+func (r CreateMediaRequest) model() models.Media {
+	return models.Media{
+		URL:     r.URL,
+		Type:    r.Type,
+		Size:    r.Size,
+		AltText: r.AltText,
+		FocalX:  r.FocalX,
+		FocalY:  r.FocalY,
+		Crops:   r.Crops,
+	}
+}
+
+func (h *MediaHandler) Create(c *gin.Context) {
+	// Parse JSON request body and map it onto new media
+	var request CreateMediaRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
 		return
 	}
+	media := request.model()
 
 	// Validate required fields
 	if media.URL == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "URL is required",
-		})
+		utils.Fail(c, utils.Invalid("URL is required"))
 		return
 	}
 	if media.Type == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Type is required",
-		})
+		utils.Fail(c, utils.Invalid("Type is required"))
+		return
+	}
+	if media.Size < 0 {
+		utils.Fail(c, utils.Invalid("Size must not be negative"))
 		return
 	}
 
-	// Start database transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	// Reject URLs the server could be tricked into fetching from internal hosts
+	if err := egress.PolicyFromEnv().ValidateURL(c.Request.Context(), media.URL); err != nil {
+		writeURLError(c, err)
+		return
+	}
 
-	// Create the media
-	if err := tx.Create(&media).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Scan and measure the file before accepting it
+	if !h.inspect(c, &media) {
+		return
+	}
+	if !validateFraming(c, &media) {
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Create the media
+	if err := h.repositories(c).Media.Create(c.Request.Context(), &media); err != nil {
+		utils.Fail(c, err)
 		return
 	}
 
 	// Return created media
-	c.JSON(http.StatusCreated, media)
+	c.JSON(http.StatusCreated, serializers.Media(media))
 }
 
-func DeleteMedia(c *gin.Context) {
-	// Get database instance from context
-	db := c.MustGet("db").(*gorm.DB)
+// MediaUpdateRequest is the body for updating how an image is described and
+// framed
+type MediaUpdateRequest struct {
+	AltText string `json:"alt_text" binding:"max=1000"`
 
+	// FocalX and FocalY are fractions of the width and height from the top
+	// left; omit both to clear the focal point
+	FocalX *float64 `json:"focal_x"`
+	FocalY *float64 `json:"focal_y"`
+
+	// Crops replaces every named crop; omit to remove them all
+	Crops models.Crops `json:"crops"`
+}
+
+// Update replaces the alt text, focal point and named crops of a media item
+func (h *MediaHandler) Update(c *gin.Context) {
 	// Get ID parameter from URL
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	var req MediaUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
 
 	// Check if media exists
-	var media models.Media
-	if err := db.First(&media, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Media not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	repos := h.repositories(c)
+	media, err := repos.Media.Get(c.Request.Context(), id, seesQuarantined(c))
+	if err != nil {
+		writeRepositoryError(c, err, "Media not found")
 		return
 	}
 
-	// Start transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
+	media.AltText = req.AltText
+	media.FocalX, media.FocalY, media.Crops = req.FocalX, req.FocalY, req.Crops
+	if !validateFraming(c, media) {
+		return
+	}
+	if err := repos.Media.Update(c.Request.Context(), media); err != nil {
+		utils.Fail(c, err)
+		return
+	}
 
-	// Delete the media
-	if err := tx.Delete(&media).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	c.JSON(http.StatusOK, serializers.Media(*media))
+}
+
+func (h *MediaHandler) Delete(c *gin.Context) {
+	// Get ID parameter from URL
+	id, ok := paramID(c)
+	if !ok {
 		return
 	}
 
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Check if media exists
+	repos := h.repositories(c)
+	media, err := repos.Media.Get(c.Request.Context(), id, true)
+	if err != nil {
+		writeRepositoryError(c, err, "Media not found")
+		return
+	}
+
+	// Delete the media
+	if err := repos.Media.Delete(c.Request.Context(), media); err != nil {
+		utils.Fail(c, err)
 		return
 	}
 
@@ -179,3 +251,174 @@ func DeleteMedia(c *gin.Context) {
 		"message": "Media deleted successfully",
 	})
 }
+
+// validateFraming checks the focal point and crops of media, writing a 400
+// response when they are invalid. Crops must lie within the image when its
+// size has been measured.
+func validateFraming(c *gin.Context, media *models.Media) bool {
+	fail := func(message string) bool {
+		utils.Fail(c, utils.Invalid(message))
+		return false
+	}
+
+	if (media.FocalX == nil) != (media.FocalY == nil) {
+		return fail("focal_x and focal_y must be set together")
+	}
+	if media.FocalX != nil && (*media.FocalX < 0 || *media.FocalX > 1 || *media.FocalY < 0 || *media.FocalY > 1) {
+		return fail("focal_x and focal_y must be between 0 and 1")
+	}
+
+	if len(media.Crops) > maxMediaCrops {
+		return fail(fmt.Sprintf("Media may have at most %d crops", maxMediaCrops))
+	}
+	for name, crop := range media.Crops {
+		if !cropName.MatchString(name) {
+			return fail(fmt.Sprintf("Invalid crop name %q", name))
+		}
+		if crop.X < 0 || crop.Y < 0 || crop.Width <= 0 || crop.Height <= 0 {
+			return fail(fmt.Sprintf("Crop %q must have a positive size and a non-negative offset", name))
+		}
+		if media.Width > 0 && media.Height > 0 && (crop.X+crop.Width > media.Width || crop.Y+crop.Height > media.Height) {
+			return fail(fmt.Sprintf("Crop %q extends past the %dx%d image", name, media.Width, media.Height))
+		}
+	}
+	return true
+}
+
+// inspect fetches the file at media.URL when it has to be scanned or
+// measured. With a scanner configured the file is scanned and the verdict
+// recorded on media: infected files are rejected with a 422 response, or kept
+// as quarantined when MEDIA_SCAN_ACTION=quarantine, and files that cannot be
+// scanned are refused rather than accepted unchecked. With
+// MEDIA_IMAGE_DIMENSIONS=true the width and height of images are recorded.
+func (h *MediaHandler) inspect(c *gin.Context, media *models.Media) bool {
+	media.ScanStatus, media.ScanSignature, media.ScannedAt = models.ScanUnscanned, "", nil
+	media.Width, media.Height = 0, 0
+	scan := scanner.Enabled(scanner.Default)
+	measure := strings.HasPrefix(media.Type, "image") && os.Getenv("MEDIA_IMAGE_DIMENSIONS") == "true"
+	if !scan && !measure {
+		return true
+	}
+	ctx := c.Request.Context()
+
+	resp, err := egress.Get(ctx, media.URL)
+	if err != nil {
+		writeURLError(c, err)
+		return false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Media could not be fetched: status %d", resp.StatusCode),
+		})
+		return false
+	}
+
+	return h.examine(c, media, resp.Body)
+}
+
+// examine scans and measures a file as inspect describes, writing the error
+// response when the file is refused
+func (h *MediaHandler) examine(c *gin.Context, media *models.Media, file io.Reader) bool {
+	scan := scanner.Enabled(scanner.Default)
+	measure := strings.HasPrefix(media.Type, "image") && os.Getenv("MEDIA_IMAGE_DIMENSIONS") == "true"
+
+	// The start of the file is kept for reading image dimensions while the
+	// whole of it streams through the scanner
+	head := &headBuffer{max: imageinfo.HeadSize}
+	if scan && !h.scanFile(c, media, io.TeeReader(file, head)) {
+		return false
+	}
+	if !scan {
+		if _, err := io.Copy(head, io.LimitReader(file, imageinfo.HeadSize)); err != nil {
+			c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Media could not be fetched: " + err.Error(),
+			})
+			return false
+		}
+	}
+
+	if measure {
+		// Formats the server cannot decode are stored without dimensions
+		if width, height, err := imageinfo.Dimensions(head.data); err == nil {
+			media.Width, media.Height = width, height
+		}
+	}
+	return true
+}
+
+// scanFile runs a file through the configured scanner and records the
+// verdict on media, writing the error response when the file is refused
+func (h *MediaHandler) scanFile(c *gin.Context, media *models.Media, file io.Reader) bool {
+	limit := int64(envLimit("MEDIA_SCAN_MAX_BYTES", defaultMediaScanMaxBytes))
+	body := &countingReader{r: io.LimitReader(file, limit+1)}
+	result, err := scanner.Default.Scan(c.Request.Context(), body)
+	if err != nil {
+		h.Logger.Printf("Scanning media %s failed: %v", media.URL, err)
+		c.JSON(http.StatusServiceUnavailable, utils.HTTPError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Media could not be scanned, try again later",
+		})
+		return false
+	}
+	if body.n > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, utils.HTTPError{
+			Code:    http.StatusRequestEntityTooLarge,
+			Message: fmt.Sprintf("Media exceeds the %d byte scanning limit", limit),
+		})
+		return false
+	}
+
+	now := h.Clock()
+	media.ScannedAt = &now
+	if !result.Infected {
+		media.ScanStatus = models.ScanClean
+		return true
+	}
+	if os.Getenv("MEDIA_SCAN_ACTION") == "quarantine" {
+		media.ScanStatus, media.ScanSignature = models.ScanQuarantined, result.Signature
+		return true
+	}
+	c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+		Code:      http.StatusUnprocessableEntity,
+		Message:   "Media failed the malware scan: " + result.Signature,
+		ErrorCode: utils.ErrorCodeMediaInfected,
+	})
+	return false
+}
+
+// seesQuarantined reports whether the caller may see quarantined media;
+// only admins can
+func seesQuarantined(c *gin.Context) bool {
+	return auth.CallerFrom(c).Role == auth.RoleAdmin
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// headBuffer keeps the first max bytes written to it and discards the rest
+type headBuffer struct {
+	data []byte
+	max  int
+}
+
+func (b *headBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.data); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.data = append(b.data, p[:room]...)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"archive/zip"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/repository"
+	"cms-backend/storage"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxMediaExport caps the media in one export archive
+const maxMediaExport = 1000
+
+// exportNameUnsafe matches the characters replaced in archived file names
+var exportNameUnsafe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// MediaExportRequest selects the media to export: the media in IDs, or all
+// media of Type, or all media when neither is given
+type MediaExportRequest struct {
+	IDs  []uint `json:"ids"`
+	Type string `json:"type"`
+}
+
+// MediaExportEntry describes one media item in an export's manifest. File is
+// its path in the archive; Error says why it is missing or incomplete.
+type MediaExportEntry struct {
+	ID    uint   `json:"id"`
+	URL   string `json:"url"`
+	Type  string `json:"type"`
+	Size  int64  `json:"size"`
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// Export streams a ZIP archive of the files behind the selected media, with
+// a manifest.json describing each of them, so assets can be handed off
+// without access to where they are stored. Quarantined media is never
+// exported. The archive is written as the files are fetched, so a file that
+// cannot be fetched is reported in the manifest rather than failing the export.
+func (h *MediaHandler) Export(c *gin.Context) {
+	var req MediaExportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	repos := h.repositories(c)
+	filter := repository.MediaFilter{Type: req.Type, IDs: req.IDs}
+	total, err := repos.Media.Count(ctx, filter)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if total == 0 {
+		utils.Fail(c, utils.NotFound("No media matches the selection"))
+		return
+	}
+	if total > maxMediaExport {
+		utils.Fail(c, utils.Invalid(fmt.Sprintf("%d media match the selection; an export holds at most %d", total, maxMediaExport)))
+		return
+	}
+	media, err := repos.Media.List(ctx, filter)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if req.IDs != nil {
+		positions := idPositions(req.IDs)
+		sort.Slice(media, func(i, j int) bool { return positions[media[i].ID] < positions[media[j].ID] })
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", `attachment; filename="media-export.zip"`)
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	manifest := make([]MediaExportEntry, len(media))
+	for i, item := range media {
+		manifest[i] = h.exportFile(ctx, archive, item)
+	}
+	file, err := archive.Create("manifest.json")
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(manifest)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		h.Logger.Printf("Writing media export failed: %v", err)
+	}
+}
+
+// exportFile copies the file behind media into archive and describes it
+func (h *MediaHandler) exportFile(ctx context.Context, archive *zip.Writer, media models.Media) MediaExportEntry {
+	entry := MediaExportEntry{ID: media.ID, URL: media.URL, Type: media.Type}
+	body, err := h.openMedia(ctx, media.URL)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer body.Close()
+
+	entry.File = exportFileName(media)
+	// Media formats are compressed already
+	file, err := archive.CreateHeader(&zip.FileHeader{Name: entry.File, Method: zip.Store, Modified: media.CreatedAt})
+	if err != nil {
+		entry.File, entry.Error = "", err.Error()
+		return entry
+	}
+	if entry.Size, err = io.Copy(file, body); err != nil {
+		entry.Error = "incomplete: " + err.Error()
+	}
+	return entry
+}
+
+// openMedia reads the file at url from storage when the server holds it,
+// and downloads it otherwise
+func (h *MediaHandler) openMedia(ctx context.Context, raw string) (io.ReadCloser, error) {
+	if opener, ok := h.Storage.(storage.Opener); ok {
+		body, err := opener.Open(ctx, raw)
+		if !errors.Is(err, storage.ErrNotHeld) {
+			return body, err
+		}
+	}
+
+	resp, err := egress.Fetch(ctx, raw, egress.MaxResponseBytes())
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// exportFileName is where media is kept in an export archive: under files/,
+// named by its ID and the last segment of its URL
+func exportFileName(media models.Media) string {
+	name := ""
+	if u, err := url.Parse(media.URL); err == nil {
+		name = exportNameUnsafe.ReplaceAllString(path.Base(u.Path), "_")
+	}
+	if name == "" || name == "." || name == "_" {
+		name = "file"
+	}
+	return "files/" + strconv.FormatUint(uint64(media.ID), 10) + "-" + name
+}
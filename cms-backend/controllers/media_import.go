@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"cms-backend/egress"
+	"cms-backend/imageinfo"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultMediaImportMaxBytes caps imported files when MEDIA_IMPORT_MAX_BYTES
+// is not set
+const defaultMediaImportMaxBytes = 25 << 20
+
+// defaultMediaImportTypes are the content types accepted when
+// MEDIA_IMPORT_TYPES is not set; entries ending in "/" match a whole family
+var defaultMediaImportTypes = []string{"image/", "video/", "audio/", "application/pdf"}
+
+// importExtension matches the file extensions kept on stored file names
+var importExtension = regexp.MustCompile(`^\.[a-z0-9]{1,8}$`)
+
+// MediaImportRequest names a remote file to copy into the media library
+type MediaImportRequest struct {
+	URL string `json:"url" binding:"required"`
+}
+
+// Import downloads the file at a remote URL, checks its size and type,
+// scans it like any other media and stores a copy through the storage
+// backend, so the media no longer depends on the remote host. The size is
+// capped by MEDIA_IMPORT_MAX_BYTES and the types by MEDIA_IMPORT_TYPES, and
+// the file counts against the caller's storage quota. With
+// MEDIA_STRIP_METADATA=true the EXIF and other metadata of JPEG and PNG
+// images, GPS positions included, is removed before the copy is stored, and
+// JPEGs are turned upright.
+func (h *MediaHandler) Import(c *gin.Context) {
+	var req MediaImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if h.Storage == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.HTTPError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Media storage is not configured",
+		})
+		return
+	}
+
+	// The shared fetcher rejects URLs the server could be tricked into
+	// fetching from internal hosts and cuts off files over the limit
+	ctx := c.Request.Context()
+	limit := int64(envLimit("MEDIA_IMPORT_MAX_BYTES", defaultMediaImportMaxBytes))
+	resp, err := egress.Fetch(ctx, req.URL, limit)
+	if errors.Is(err, egress.ErrTooLarge) {
+		writeImportTooLarge(c, limit)
+		return
+	}
+	if err != nil {
+		writeURLError(c, err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: fmt.Sprintf("Media could not be fetched: status %d", resp.StatusCode),
+		})
+		return
+	}
+
+	// The file is kept in a temporary file while it is checked, so it can be
+	// read again to store it
+	tmp, err := os.CreateTemp("", "media-import-*")
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	size, err := io.Copy(tmp, resp.Body)
+	if errors.Is(err, egress.ErrTooLarge) {
+		writeImportTooLarge(c, limit)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Media could not be fetched: " + err.Error(),
+		})
+		return
+	}
+
+	head := make([]byte, 512)
+	n, _ := tmp.ReadAt(head, 0)
+	contentType := importContentType(resp.Header.Get("Content-Type"), head[:n])
+	if !importTypeAllowed(contentType) {
+		c.JSON(http.StatusUnsupportedMediaType, utils.HTTPError{
+			Code:    http.StatusUnsupportedMediaType,
+			Message: "Media type " + contentType + " cannot be imported",
+		})
+		return
+	}
+
+	if strings.HasPrefix(contentType, "image/") && os.Getenv("MEDIA_STRIP_METADATA") == "true" {
+		if size, err = stripImportMetadata(tmp); err != nil {
+			h.Logger.Printf("Stripping metadata from %s failed: %v", req.URL, err)
+			c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+				Code:    http.StatusUnprocessableEntity,
+				Message: "Image metadata could not be removed",
+			})
+			return
+		}
+	}
+
+	uploadedBy, ok := checkStorageQuota(c, size)
+	if !ok {
+		return
+	}
+
+	media := models.Media{Type: contentType, Size: size, ScanStatus: models.ScanUnscanned, UploadedByID: uploadedBy}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if !h.examine(c, &media, tmp) {
+		return
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	key, err := importKey(req.URL, contentType)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if media.URL, err = h.Storage.Put(ctx, key, contentType, tmp); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if err := h.repositories(c).Media.Create(ctx, &media); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, serializers.Media(media))
+}
+
+// stripImportMetadata rewrites the image in tmp without its metadata and
+// returns its new size
+func stripImportMetadata(tmp *os.File) (int64, error) {
+	data, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	if data, err = imageinfo.Normalize(data); err != nil {
+		return 0, err
+	}
+	if err := tmp.Truncate(0); err != nil {
+		return 0, err
+	}
+	if _, err := tmp.WriteAt(data, 0); err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+func writeImportTooLarge(c *gin.Context, limit int64) {
+	c.JSON(http.StatusRequestEntityTooLarge, utils.HTTPError{
+		Code:    http.StatusRequestEntityTooLarge,
+		Message: fmt.Sprintf("Media exceeds the %d byte import limit", limit),
+	})
+}
+
+// importContentType is the declared type of a fetched file, or the type
+// sniffed from its first bytes when the server declared none
+func importContentType(declared string, head []byte) string {
+	if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+		return mediaType
+	}
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	return mediaType
+}
+
+// importTypeAllowed reports whether contentType matches MEDIA_IMPORT_TYPES,
+// a comma-separated list of types and type families such as "image/"
+func importTypeAllowed(contentType string) bool {
+	allowed := defaultMediaImportTypes
+	if value := os.Getenv("MEDIA_IMPORT_TYPES"); value != "" {
+		allowed = strings.Split(value, ",")
+	}
+	for _, rule := range allowed {
+		rule = strings.TrimSpace(rule)
+		if rule == contentType || (strings.HasSuffix(rule, "/") && strings.HasPrefix(contentType, rule)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importKey returns a random file name for an imported file, keeping the
+// extension of the remote file or, failing that, one for its type
+func importKey(raw, contentType string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	ext := ""
+	if u, err := url.Parse(raw); err == nil {
+		ext = strings.ToLower(path.Ext(u.Path))
+	}
+	if !importExtension.MatchString(ext) {
+		ext = ""
+		if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return hex.EncodeToString(buf) + ext, nil
+}
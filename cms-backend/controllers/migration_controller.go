@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"cms-backend/migrations"
+	"cms-backend/utils"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ForceMigrationRequest is the body for forcing the schema version
+type ForceMigrationRequest struct {
+	Version *int `json:"version" binding:"required"`
+}
+
+// RerunMigrationRequest is the body for re-running a single migration
+type RerunMigrationRequest struct {
+	Direction string `json:"direction"`
+}
+
+// GetMigrationStatus reports the current schema version, the dirty flag and
+// the migrations not applied yet
+func GetMigrationStatus(c *gin.Context) {
+	status, err := migrations.CurrentStatus()
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// migrating keeps this process from running two migrations at once. Other
+// replicas are held off by the advisory lock golang-migrate takes.
+var migrating sync.Mutex
+
+// RunMigrations applies pending migrations and reports the resulting status,
+// so deploys can migrate once before rolling out replicas started with
+// SKIP_MIGRATIONS=true
+func RunMigrations(c *gin.Context) {
+	if !migrating.TryLock() {
+		utils.Fail(c, utils.Conflict("Migrations are already running"))
+		return
+	}
+	defer migrating.Unlock()
+
+	if err := migrations.Up(); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	GetMigrationStatus(c)
+}
+
+// ForceMigrationVersion sets the schema version and clears the dirty flag
+func ForceMigrationVersion(c *gin.Context) {
+	var req ForceMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// -1 is migrate's "no version" marker
+	if *req.Version < -1 {
+		utils.Fail(c, utils.Invalid("version must be -1 or greater"))
+		return
+	}
+
+	if err := migrations.Force(*req.Version); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	GetMigrationStatus(c)
+}
+
+// RerunMigration re-runs one migration file in a transaction and returns the captured log
+func RerunMigration(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	version, err := strconv.ParseUint(c.Param("version"), 10, 64)
+	if err != nil {
+		utils.Fail(c, utils.Invalid("Invalid migration version"))
+		return
+	}
+
+	// The body is optional; direction defaults to "up"
+	var req RerunMigrationRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.Fail(c, utils.Invalid(err.Error()))
+			return
+		}
+	}
+	if req.Direction == "" {
+		req.Direction = "up"
+	}
+
+	result := migrations.Rerun(db, uint(version), req.Direction)
+	if !result.Success {
+		status := http.StatusUnprocessableEntity
+		if result.File == "" {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, result)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetNotifications lists the caller's notifications, newest first, in pages
+// of ?limit= entries (default 20) continued with ?after=. ?unread=true skips
+// the ones already read.
+func GetNotifications(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	query := db.Where("api_key_id = ?", caller.KeyID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+	query, limit, ok := paginate(c, query, "notifications")
+	if !ok {
+		return
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+		query = query.Order("notifications.created_at DESC").Order("notifications.id DESC").Limit(limit + 1)
+	}
+
+	var notifications []models.Notification
+	if err := query.Find(&notifications).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		last := notifications[limit-1]
+		c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	c.JSON(http.StatusOK, notifications)
+}
+
+// MarkNotificationRead marks one of the caller's notifications read
+func MarkNotificationRead(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	var notification models.Notification
+	if err := db.Where("api_key_id = ?", caller.KeyID).First(&notification, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Notification not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if err := db.Model(&notification).Update("read_at", now).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		notification.ReadAt = &now
+	}
+
+	c.JSON(http.StatusOK, notification)
+}
+
+// MarkAllNotificationsRead marks every unread notification of the caller read
+func MarkAllNotificationsRead(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	result := db.Model(&models.Notification{}).
+		Where("api_key_id = ? AND read_at IS NULL", caller.KeyID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"marked": result.RowsAffected,
+	})
+}
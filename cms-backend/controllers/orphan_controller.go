@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/jobs"
+	"cms-backend/models"
+	"cms-backend/orphans"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobTypeOrphanCleanup is the job type for orphaned record cleanups
+const JobTypeOrphanCleanup = "orphan_cleanup"
+
+// OrphanCleanupRequest is the body of POST /admin/orphans/clean
+type OrphanCleanupRequest struct {
+	// ReassignAuthor is given the posts whose author no longer exists; when
+	// empty their author is cleared
+	ReassignAuthor string `json:"reassign_author"`
+}
+
+// GetOrphanReport lists orphaned post_media rows, media nothing refers to
+// and posts whose author no longer exists, without changing anything
+func GetOrphanReport(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	report, err := orphans.Find(c.Request.Context(), db, time.Now())
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CleanOrphans starts a job fixing what GetOrphanReport lists: dangling
+// post_media rows and unattached media are deleted, and authorless posts are
+// reassigned. Poll GET /jobs/:id and download the report of what was fixed
+// from the job's download_url.
+func CleanOrphans(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req OrphanCleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Posts are only reassigned to an author with a profile, or they would
+	// be orphaned again
+	req.ReassignAuthor = strings.TrimSpace(req.ReassignAuthor)
+	if req.ReassignAuthor != "" {
+		var count int64
+		if err := db.Model(&models.User{}).Where("username = ?", req.ReassignAuthor).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count == 0 {
+			utils.Fail(c, utils.Invalid("reassign_author must be an existing author"))
+			return
+		}
+	}
+
+	job, err := jobs.Start(db, auth.CallerFrom(c).KeyID, JobTypeOrphanCleanup, req, cleanOrphans)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// cleanOrphans is the job function behind CleanOrphans
+func cleanOrphans(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+	var payload OrphanCleanupRequest
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return "", err
+	}
+
+	report, err := orphans.Clean(ctx, db, time.Now(), payload.ReassignAuthor)
+	if err != nil {
+		return "", err
+	}
+	progress(90)
+
+	dir, err := jobs.Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("orphans-job-%d.json", job.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := json.NewEncoder(file).Encode(report); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
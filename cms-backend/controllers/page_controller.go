@@ -1,174 +1,262 @@
 package controllers
 
 import (
+	"cms-backend/acl"
+	"cms-backend/auth"
 	"cms-backend/models"
+	"cms-backend/repository"
+	"cms-backend/serializers"
 	"cms-backend/utils"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// GetPages retrieves all pages
-func GetPages(c *gin.Context) {
-	// Get database instance from context
-	db := c.MustGet("db").(*gorm.DB)
+// CreatePageRequest is the body of POST /pages
+type CreatePageRequest struct {
+	Title   string `json:"title" binding:"required,max=255"`
+	Content string `json:"content" binding:"required"`
+
+	// Slug is generated from the title when omitted
+	Slug     string `json:"slug" binding:"max=255"`
+	ParentID *uint  `json:"parent_id"`
+
+	// Locale is a BCP 47 language tag such as "en" or "de-AT"
+	Locale string `json:"locale" binding:"omitempty,max=35,bcp47_language_tag"`
+
+	// Status defaults to published and Visibility to public
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Visibility     string     `json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+
+	Notes        string `json:"notes"`
+	OwnerGroupID *uint  `json:"owner_group_id"`
+}
+
+// UpdatePageRequest is the body of PUT /pages/:id. Empty fields are left
+// unchanged; a ParentID of 0 moves the page to the top level and an
+// OwnerGroupID of 0 clears the owning team.
+type UpdatePageRequest struct {
+	Title          string     `json:"title" binding:"max=255"`
+	Content        string     `json:"content"`
+	Slug           string     `json:"slug" binding:"max=255"`
+	ParentID       *uint      `json:"parent_id"`
+	Locale         string     `json:"locale" binding:"omitempty,max=35,bcp47_language_tag"`
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Visibility     string     `json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+	Notes          string     `json:"notes"`
+	OwnerGroupID   *uint      `json:"owner_group_id"`
+}
+
+// model maps the request onto a new page
+func (r CreatePageRequest) model() models.Page {
+	return models.Page{
+		Title:          r.Title,
+		Content:        r.Content,
+		Slug:           r.Slug,
+		ParentID:       r.ParentID,
+		Locale:         r.Locale,
+		Status:         r.Status,
+		ExpiresAt:      r.ExpiresAt,
+		Visibility:     r.Visibility,
+		EmbargoedUntil: r.EmbargoedUntil,
+		Notes:          r.Notes,
+		OwnerGroupID:   r.OwnerGroupID,
+	}
+}
 
-	// Declare pages slice variable
-	var pages []models.Page
+// PageHandler serves the page endpoints
+type PageHandler struct {
+	Deps
+}
 
-	// Query all pages from database
-	title := c.Query("title")
-	author := c.Query("author")
+// NewPageHandler returns a page handler using deps, with defaults for any
+// left unset
+func NewPageHandler(deps Deps) *PageHandler {
+	return &PageHandler{Deps: deps.withDefaults()}
+}
 
-	query := db
-	if title != "" {
-		query = query.Where("title ILIKE ?", "%"+title+"%")
+// List retrieves all pages; ?owner_group= lists a team's pages,
+// ?status=, ?older_than_days= and ?q= narrow them and ?sort= orders them. A HEAD
+// request reports only their number in X-Total-Count.
+func (h *PageHandler) List(c *gin.Context) {
+	order, ok := sortQuery(c, pageSortColumns)
+	if !ok {
+		return
+	}
+	createdBefore, ok := olderThanQuery(c)
+	if !ok {
+		return
 	}
-	if author != "" {
-		query = query.Where("author = ?", author)
+	terms, searchConfig, ok := searchQuery(c)
+	if !ok {
+		return
+	}
+
+	// Restricted drafts are left out unless the caller has been granted access
+	filter := repository.PageFilter{
+		Caller:        auth.CallerFrom(c),
+		Title:         c.Query("title"),
+		Author:        c.Query("author"),
+		OwnerGroup:    c.Query("owner_group"),
+		Status:        c.Query("status"),
+		CreatedBefore: createdBefore,
+		Search:        terms,
+		SearchConfig:  searchConfig,
+		Sort:          order,
+	}
+	repos := h.repositories(c)
+	if c.Request.Method == http.MethodHead {
+		total, err := repos.Pages.Count(c.Request.Context(), filter)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		headList(c, "pages", total)
+		return
 	}
+	pages, err := repos.Pages.List(c.Request.Context(), filter)
 
 	// Handle potential database errors
-	if err := query.Find(&pages).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err != nil {
+		utils.Fail(c, err)
 		return
 	}
 
 	// Return success response with pages
-	c.JSON(http.StatusOK, pages)
+	setListTotal(c, "pages", 0, len(pages), int64(len(pages)))
+	writeFields(c, http.StatusOK, serializers.Pages(auth.CallerFrom(c), pages))
 }
 
-// GetPage retrieves a specific page by ID
-func GetPage(c *gin.Context) {
-	// Get database instance from context
-	db := c.MustGet("db").(*gorm.DB)
-
+// Get retrieves a specific page by ID
+func (h *PageHandler) Get(c *gin.Context) {
 	// Get ID parameter and convert to uint
-	id := c.Param("id")
-
-	// Declare page variable
-	var page models.Page
-
-	// Query page from database
-	if err := db.First(&page, id).Error; err != nil {
-		// Handle potential database errors
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Page not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
+
+	// Query page from database; restricted drafts the caller has no grant on
+	// are reported as not found
+	page, err := h.repositories(c).Pages.GetReadable(c.Request.Context(), auth.CallerFrom(c), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Page not found")
 		return
 	}
 
 	// Return success response with page
-	c.JSON(http.StatusOK, page)
+	writeFields(c, http.StatusOK, serializers.Page(auth.CallerFrom(c), *page))
 }
 
-// CreatePage creates a new page
-func CreatePage(c *gin.Context) {
+// Create creates a new page
+func (h *PageHandler) Create(c *gin.Context) {
 	// Get database instance from context
 	db := c.MustGet("db").(*gorm.DB)
 
-	// Declare page variable
-	var page models.Page
-
-	// Bind JSON request body
-	if err := c.ShouldBindJSON(&page); err != nil {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
+	// Bind JSON request body and map it onto a new page
+	var request CreatePageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
 		return
 	}
+	page := request.model()
 
 	// Validate required fields
 	if page.Title == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Title is required",
-		})
+		utils.Fail(c, utils.Invalid("Title is required"))
 		return
 	}
 	if page.Content == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Content is required",
-		})
+		utils.Fail(c, utils.Invalid("Content is required"))
 		return
 	}
 
-	// Start transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Create page in database
-	if err := tx.Create(&page).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Default to published and reject unknown states or elapsed expiry times
+	if page.Status == "" {
+		page.Status = models.StatusPublished
+	}
+	if !checkPublishing(c, page.Status, page.ExpiresAt) {
+		return
+	}
+	if page.Visibility == "" {
+		page.Visibility = models.VisibilityPublic
+	}
+	if !checkVisibility(c, page.Visibility) {
 		return
 	}
+	if !checkOwnerGroup(c, db, page.OwnerGroupID) {
+		return
+	}
+	if page.OwnerGroupID != nil && *page.OwnerGroupID == 0 {
+		page.OwnerGroupID = nil
+	}
+	if !checkPageParent(c, db, 0, page.ParentID) {
+		return
+	}
+	if page.ParentID != nil && *page.ParentID == 0 {
+		page.ParentID = nil
+	}
 
-	// Commit transaction and return response
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Use the requested slug or derive a unique one from the title
+		slug, err := assignSlug(tx, &models.Page{}, page.Slug, page.Title, 0)
+		if err != nil {
+			writeSlugError(c, err)
+			return errResponded
+		}
+		page.Slug = slug
+
+		// Create page in database
+		if err := h.Repositories(tx).Pages.Create(c.Request.Context(), &page); err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPage, EntityID: page.ID, Action: models.ActivityCreated})
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, page)
+	c.JSON(http.StatusCreated, serializers.Page(auth.CallerFrom(c), page))
 }
 
-// UpdatePage updates an existing page by ID
-func UpdatePage(c *gin.Context) {
+// Update updates an existing page by ID
+func (h *PageHandler) Update(c *gin.Context) {
 	// Get database instance from context
 	db := c.MustGet("db").(*gorm.DB)
 
 	// Convert string ID to uint
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 
 	// Find existing page
-	var existingPage models.Page
-	if err := db.First(&existingPage, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Page not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	found, err := h.repositories(c).Pages.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Page not found")
 		return
 	}
+	existingPage := *found
+	before := existingPage
 
 	// Bind JSON update data
-	var updateData models.Page
+	var updateData UpdatePageRequest
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Publishing needs the publish grant on restricted pages, other edits write
+	permission := acl.Write
+	if updateData.Status == models.StatusPublished && existingPage.Status != models.StatusPublished {
+		permission = acl.Publish
+	}
+	if !checkACL(c, db, acl.EntityPage, existingPage.ID, permission) {
 		return
 	}
 
@@ -179,83 +267,118 @@ func UpdatePage(c *gin.Context) {
 	if updateData.Content != "" {
 		existingPage.Content = updateData.Content
 	}
-
-	// Start transaction and save
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.Save(&existingPage).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if updateData.Notes != "" {
+		existingPage.Notes = updateData.Notes
+	}
+	if updateData.Locale != "" {
+		existingPage.Locale = updateData.Locale
+	}
+	if !checkPublishing(c, updateData.Status, updateData.ExpiresAt) {
 		return
 	}
+	if updateData.Status != "" {
+		existingPage.Status = updateData.Status
+		existingPage.ExpiresAt = republishClearsExpiry(existingPage.Status, existingPage.ExpiresAt)
+	}
+	if updateData.ExpiresAt != nil {
+		existingPage.ExpiresAt = updateData.ExpiresAt
+	}
+	if !checkVisibility(c, updateData.Visibility) {
+		return
+	}
+	if updateData.Visibility != "" {
+		existingPage.Visibility = updateData.Visibility
+	}
+	if updateData.EmbargoedUntil != nil {
+		existingPage.EmbargoedUntil = updateData.EmbargoedUntil
+	}
+	if updateData.OwnerGroupID != nil {
+		if !checkOwnerGroup(c, db, updateData.OwnerGroupID) {
+			return
+		}
+		existingPage.OwnerGroupID = updateData.OwnerGroupID
+		if *updateData.OwnerGroupID == 0 {
+			existingPage.OwnerGroupID = nil
+		}
+	}
+	if updateData.ParentID != nil {
+		if !checkPageParent(c, db, existingPage.ID, updateData.ParentID) {
+			return
+		}
+		existingPage.ParentID = updateData.ParentID
+		if *updateData.ParentID == 0 {
+			existingPage.ParentID = nil
+		}
+	}
+
+	// Save the page in a transaction
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Slugs only change when explicitly requested so existing links keep working
+		if updateData.Slug != "" {
+			slug, err := assignSlug(tx, &models.Page{}, updateData.Slug, "", existingPage.ID)
+			if err != nil {
+				writeSlugError(c, err)
+				return errResponded
+			}
+			existingPage.Slug = slug
+		}
+
+		// Renaming or moving a published page leaves a redirect from its old path
+		if before.Status == models.StatusPublished && (existingPage.Slug != before.Slug || !sameParent(existingPage.ParentID, before.ParentID)) {
+			if err := redirectMovedPage(tx, before, existingPage); err != nil {
+				return err
+			}
+		}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		if err := h.Repositories(tx).Pages.Update(c.Request.Context(), &existingPage); err != nil {
+			return err
+		}
+
+		// Record the edit and any status change in the page's history
+		fields := changedPageFields(before, existingPage)
+		return recordActivity(tx, c, editActivities(acl.EntityPage, existingPage.ID, fields, before.Status, existingPage.Status)...)
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 
 	// Return success response
-	c.JSON(http.StatusOK, existingPage)
+	c.JSON(http.StatusOK, serializers.Page(auth.CallerFrom(c), existingPage))
 }
 
-// DeletePage deletes a page by ID
-func DeletePage(c *gin.Context) {
+// Delete deletes a page by ID
+func (h *PageHandler) Delete(c *gin.Context) {
 	// Get database instance from context
 	db := c.MustGet("db").(*gorm.DB)
 
 	// Convert string ID to uint
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 
 	// Check if page exists
-	var page models.Page
-	if err := db.First(&page, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Page not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	found, err := h.repositories(c).Pages.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Page not found")
 		return
 	}
-
-	// Start transaction and delete
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.Delete(&page).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	page := *found
+	if !checkACL(c, db, acl.EntityPage, page.ID, acl.Write) {
 		return
 	}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Delete the page, its grants and record it in one transaction
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := h.Repositories(tx).Pages.Delete(c.Request.Context(), &page); err != nil {
+			return err
+		}
+		if err := deleteACL(tx, acl.EntityPage, page.ID); err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPage, EntityID: page.ID, Action: models.ActivityDeleted})
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 
@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxPageDepth bounds how deeply pages may be nested
+const maxPageDepth = 10
+
+// pageNode is the part of a page its path is built from
+type pageNode struct {
+	ID       uint
+	ParentID *uint
+	Slug     string
+}
+
+// pagePaths returns the hierarchical slug of each page, such as "about/team",
+// keyed by page ID. Ancestors are loaded one level at a time, so the cost is
+// one query per level of nesting rather than one per page.
+func pagePaths(db *gorm.DB, pages []models.Page) (map[uint]string, error) {
+	nodes := make(map[uint]pageNode, len(pages))
+	for _, page := range pages {
+		nodes[page.ID] = pageNode{ID: page.ID, ParentID: page.ParentID, Slug: page.Slug}
+	}
+
+	for depth := 0; depth < maxPageDepth; depth++ {
+		var missing []uint
+		for _, node := range nodes {
+			if node.ParentID != nil {
+				if _, ok := nodes[*node.ParentID]; !ok {
+					missing = append(missing, *node.ParentID)
+				}
+			}
+		}
+		if len(missing) == 0 {
+			break
+		}
+		var parents []pageNode
+		if err := db.Model(&models.Page{}).Select("id", "parent_id", "slug").Where("id IN ?", missing).
+			Scan(&parents).Error; err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			nodes[parent.ID] = parent
+		}
+		// Parents that no longer exist end the path
+		for _, id := range missing {
+			if _, ok := nodes[id]; !ok {
+				nodes[id] = pageNode{ID: id}
+			}
+		}
+	}
+
+	paths := make(map[uint]string, len(pages))
+	for _, page := range pages {
+		var segments []string
+		node := nodes[page.ID]
+		for depth := 0; depth < maxPageDepth && node.Slug != ""; depth++ {
+			segments = append([]string{node.Slug}, segments...)
+			if node.ParentID == nil {
+				break
+			}
+			node = nodes[*node.ParentID]
+		}
+		paths[page.ID] = strings.Join(segments, "/")
+	}
+	return paths, nil
+}
+
+// checkPageParent validates the parent requested for page id (0 for a new
+// page): it must exist, must not be the page itself or one of its descendants,
+// and must leave the page within maxPageDepth. It writes a 400/500 response and
+// returns false when the parent is rejected. A nil or 0 parent is always valid.
+func checkPageParent(c *gin.Context, db *gorm.DB, id uint, parentID *uint) bool {
+	if parentID == nil || *parentID == 0 {
+		return true
+	}
+
+	message := ""
+	current := *parentID
+	for depth := 1; ; depth++ {
+		if current == id {
+			message = "A page cannot be nested under itself or its own subpages"
+			break
+		}
+		if depth >= maxPageDepth {
+			message = fmt.Sprintf("Pages cannot be nested more than %d levels deep", maxPageDepth)
+			break
+		}
+		var node pageNode
+		if err := db.Model(&models.Page{}).Select("id", "parent_id", "slug").Where("id = ?", current).
+			Take(&node).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				message = "Parent page not found"
+				break
+			}
+			utils.Fail(c, err)
+			return false
+		}
+		if node.ParentID == nil {
+			return true
+		}
+		current = *node.ParentID
+	}
+
+	utils.Fail(c, utils.Invalid(message))
+	return false
+}
+
+// redirectMovedPage leaves a redirect from the path page had before to the one
+// it has after being renamed or moved. Its subpages need none: resolving their
+// old paths finds them by slug and redirects to where they live now.
+func redirectMovedPage(tx *gorm.DB, before, after models.Page) error {
+	from, err := pagePaths(tx, []models.Page{before})
+	if err != nil {
+		return err
+	}
+	to, err := pagePaths(tx, []models.Page{after})
+	if err != nil {
+		return err
+	}
+	return redirectRenamed(tx, contentPath("pages", from[before.ID]), contentPath("pages", to[after.ID]))
+}
+
+// sameParent reports whether two parent IDs name the same page, or both none
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/repository"
+	"cms-backend/utils"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultPageLimit and maxPageLimit bound keyset pages
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// cursor is the position after the last item of a page. It is sent to clients
+// base64-encoded so they treat it as opaque.
+type cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uint      `json:"id"`
+}
+
+func encodeCursor(createdAt time.Time, id uint) string {
+	encoded, _ := json.Marshal(cursor{CreatedAt: createdAt, ID: id})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(value string) (cursor, bool) {
+	var cur cursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(raw, &cur) != nil || cur.ID == 0 {
+		return cur, false
+	}
+	return cur, true
+}
+
+// paginate applies keyset pagination when ?after= or ?limit= is given: rows
+// are ordered newest first by (created_at, id) and start after the cursor, so
+// deep pages cost an index seek instead of scanning skipped rows. It returns
+// the page size, or zero when the request is not paginated, and writes a 400
+// response for invalid parameters. One extra row is fetched to detect a next page.
+func paginate(c *gin.Context, query *gorm.DB, table string) (*gorm.DB, int, bool) {
+	limit, after, ok := pageQuery(c)
+	if !ok {
+		return nil, 0, false
+	}
+	if limit > 0 {
+		query = query.Scopes(repository.Keyset(table, after, limit+1))
+	}
+	return query, limit, true
+}
+
+// pageQuery parses ?limit= and ?after= for paginate and repository listings,
+// returning zero when the request is not paginated
+func pageQuery(c *gin.Context) (int, *repository.Cursor, bool) {
+	after := c.Query("after")
+	if after == "" && c.Query("limit") == "" {
+		return 0, nil, true
+	}
+
+	limit, ok := intQuery(c, "limit", defaultPageLimit, maxPageLimit)
+	if !ok {
+		return 0, nil, false
+	}
+	if after == "" {
+		return limit, nil, true
+	}
+	cur, ok := decodeCursor(after)
+	if !ok {
+		utils.Fail(c, utils.Invalid("after must be a cursor returned by a previous page"))
+		return 0, nil, false
+	}
+	return limit, &repository.Cursor{CreatedAt: cur.CreatedAt, ID: cur.ID}, true
+}
+
+// trimPostsPage drops the extra post fetched by paginate and, when there was
+// one, reports the cursor for the next page in utils.NextCursorHeader
+func trimPostsPage(c *gin.Context, posts []models.Post, limit int) []models.Post {
+	if limit == 0 || len(posts) <= limit {
+		return posts
+	}
+	posts = posts[:limit]
+	last := posts[limit-1]
+	c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	return posts
+}
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxItemDuration caps how long one playlist item is shown
+const maxItemDuration = 24 * 60 * 60
+
+// PlaylistRequest is the body for creating or updating a playlist.
+// Items sets the play order; omit it on update to keep the current items.
+type PlaylistRequest struct {
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Items       []PlaylistItemRequest `json:"items"`
+}
+
+// PlaylistItemRequest places a media file or page in a playlist
+type PlaylistItemRequest struct {
+	MediaID         *uint      `json:"media_id"`
+	PageID          *uint      `json:"page_id"`
+	DurationSeconds int        `json:"duration_seconds"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+}
+
+// PlaylistPlayback is what a signage player shows right now
+type PlaylistPlayback struct {
+	PlaylistID uint           `json:"playlist_id"`
+	Name       string         `json:"name"`
+	Items      []PlaybackItem `json:"items"`
+
+	// Hash changes whenever the items or their content change; it is also the ETag
+	Hash string `json:"hash"`
+
+	// ValidUntil is when an item's validity window next opens or closes, so
+	// players know when to fetch again even if nothing is edited
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// PlaybackItem is one entry of a playback loop
+type PlaybackItem struct {
+	// Type is "media" or "page"
+	Type            string                    `json:"type"`
+	DurationSeconds int                       `json:"duration_seconds"`
+	Media           *models.Media             `json:"media,omitempty"`
+	Page            *serializers.PageResponse `json:"page,omitempty"`
+}
+
+// GetPlaylists retrieves all playlists without their items
+func GetPlaylists(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var playlists []models.Playlist
+	if err := db.Order("name").Find(&playlists).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, playlists)
+}
+
+// GetPlaylist retrieves a playlist with all its items, including inactive ones
+func GetPlaylist(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	playlist, ok := findPlaylist(c, db)
+	if !ok {
+		return
+	}
+
+	redactPlaylistPages(auth.CallerFrom(c), playlist)
+	c.JSON(http.StatusOK, playlist)
+}
+
+// CreatePlaylist creates a playlist from an ordered list of items
+func CreatePlaylist(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	if req.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return
+	}
+	if req.Items == nil {
+		req.Items = []PlaylistItemRequest{}
+	}
+
+	playlist := models.Playlist{Name: req.Name, Description: req.Description}
+	savePlaylist(c, db, &playlist, req.Items, http.StatusCreated)
+}
+
+// UpdatePlaylist updates a playlist's details and, if given, its items
+func UpdatePlaylist(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	playlist, ok := findPlaylist(c, db)
+	if !ok {
+		return
+	}
+
+	var req PlaylistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.Name != "" {
+		playlist.Name = req.Name
+	}
+	if req.Description != "" {
+		playlist.Description = req.Description
+	}
+
+	savePlaylist(c, db, playlist, req.Items, http.StatusOK)
+}
+
+// DeletePlaylist deletes a playlist; its media and pages are not affected
+func DeletePlaylist(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	playlist, ok := findPlaylist(c, db)
+	if !ok {
+		return
+	}
+
+	// playlist_items rows are removed by the ON DELETE CASCADE foreign key
+	if err := db.Delete(playlist).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Playlist deleted successfully",
+	})
+}
+
+// PlayPlaylist serves a playlist to signage players: only items inside their
+// validity window, and pages only while published. The response carries a
+// content hash as its ETag, so players polling with If-None-Match get 304
+// until something they show changes.
+func PlayPlaylist(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	playlist, ok := findPlaylist(c, db)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	playback := PlaylistPlayback{PlaylistID: playlist.ID, Name: playlist.Name, Items: []PlaybackItem{}}
+	caller := auth.CallerFrom(c)
+	for _, item := range playlist.Items {
+		// The next window boundary is when the loop changes on its own
+		for _, boundary := range []*time.Time{item.StartsAt, item.EndsAt} {
+			if boundary != nil && boundary.After(now) && (playback.ValidUntil == nil || boundary.Before(*playback.ValidUntil)) {
+				playback.ValidUntil = boundary
+			}
+		}
+		if !item.ActiveAt(now) {
+			continue
+		}
+
+		entry := PlaybackItem{DurationSeconds: item.DurationSeconds}
+		switch {
+		case item.Media != nil:
+			entry.Type = "media"
+			entry.Media = item.Media
+		case item.Page != nil && item.Page.Status == models.StatusPublished:
+			page := serializers.Page(caller, *item.Page)
+			entry.Type = "page"
+			entry.Page = &page
+		default:
+			continue
+		}
+		playback.Items = append(playback.Items, entry)
+	}
+
+	encoded, err := json.Marshal(playback.Items)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	sum := sha256.Sum256(encoded)
+	playback.Hash = hex.EncodeToString(sum[:])
+
+	etag := fmt.Sprintf(`"%s"`, playback.Hash)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, playback)
+}
+
+// savePlaylist writes the playlist and (when items is non-nil) replaces its items in one transaction
+func savePlaylist(c *gin.Context, db *gorm.DB, playlist *models.Playlist, items []PlaylistItemRequest, status int) {
+	if items != nil && !validatePlaylistItems(c, db, items) {
+		return
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Save(playlist).Error; err != nil {
+			return err
+		}
+		if items == nil {
+			return nil
+		}
+
+		if err := tx.Where("playlist_id = ?", playlist.ID).Delete(&models.PlaylistItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		entries := make([]models.PlaylistItem, len(items))
+		for i, item := range items {
+			entries[i] = models.PlaylistItem{
+				PlaylistID:      playlist.ID,
+				Position:        i + 1,
+				MediaID:         item.MediaID,
+				PageID:          item.PageID,
+				DurationSeconds: item.DurationSeconds,
+				StartsAt:        item.StartsAt,
+				EndsAt:          item.EndsAt,
+			}
+		}
+		return tx.Create(&entries).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	if err := loadPlaylistItems(db, playlist); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	redactPlaylistPages(auth.CallerFrom(c), playlist)
+	c.JSON(status, playlist)
+}
+
+// validatePlaylistItems checks each item shows exactly one existing media file
+// or page for a sensible duration, writing a 400 response when one does not
+func validatePlaylistItems(c *gin.Context, db *gorm.DB, items []PlaylistItemRequest) bool {
+	var mediaIDs, pageIDs []uint
+	for i, item := range items {
+		var message string
+		switch {
+		case (item.MediaID == nil) == (item.PageID == nil):
+			message = "must set exactly one of media_id and page_id"
+		case item.DurationSeconds < 1 || item.DurationSeconds > maxItemDuration:
+			message = fmt.Sprintf("duration_seconds must be between 1 and %d", maxItemDuration)
+		case item.StartsAt != nil && item.EndsAt != nil && !item.EndsAt.After(*item.StartsAt):
+			message = "ends_at must be after starts_at"
+		}
+		if message != "" {
+			utils.Fail(c, utils.Invalid(fmt.Sprintf("items[%d] %s", i, message)))
+			return false
+		}
+		if item.MediaID != nil {
+			mediaIDs = append(mediaIDs, *item.MediaID)
+		} else {
+			pageIDs = append(pageIDs, *item.PageID)
+		}
+	}
+
+	checks := []struct {
+		model interface{}
+		ids   []uint
+		field string
+	}{
+		{&models.Media{}, mediaIDs, "media_id"},
+		{&models.Page{}, pageIDs, "page_id"},
+	}
+	for _, check := range checks {
+		if len(check.ids) == 0 {
+			continue
+		}
+		distinct := make(map[uint]bool)
+		for _, id := range check.ids {
+			distinct[id] = true
+		}
+		var count int64
+		if err := db.Model(check.model).Where("id IN ?", check.ids).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return false
+		}
+		if count != int64(len(distinct)) {
+			utils.Fail(c, utils.Invalid("items must reference existing records by "+check.field))
+			return false
+		}
+	}
+	return true
+}
+
+// findPlaylist loads the playlist named by the :id parameter with its items,
+// writing a 404/500 response on failure
+func findPlaylist(c *gin.Context, db *gorm.DB) (*models.Playlist, bool) {
+	var playlist models.Playlist
+	if err := db.First(&playlist, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Playlist not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	if err := loadPlaylistItems(db, &playlist); err != nil {
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &playlist, true
+}
+
+// loadPlaylistItems fills playlist.Items in play order with their media and pages
+func loadPlaylistItems(db *gorm.DB, playlist *models.Playlist) error {
+	playlist.Items = []models.PlaylistItem{}
+	return db.Preload("Media").Preload("Page").
+		Where("playlist_id = ?", playlist.ID).
+		Order("position").
+		Find(&playlist.Items).Error
+}
+
+// redactPlaylistPages clears the page fields the caller may not see
+func redactPlaylistPages(caller auth.Caller, playlist *models.Playlist) {
+	for i, item := range playlist.Items {
+		if item.Page != nil {
+			page := serializers.RedactPage(caller, *item.Page)
+			playlist.Items[i].Page = &page
+		}
+	}
+}
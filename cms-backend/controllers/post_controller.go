@@ -1,166 +1,401 @@
 package controllers
 
 import (
+	"cms-backend/acl"
+	"cms-backend/auth"
 	"cms-backend/models"
+	"cms-backend/readability"
+	"cms-backend/repository"
+	"cms-backend/serializers"
 	"cms-backend/utils"
 	"net/http"
+	"sort"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-// GetPosts retrieves all posts with optional filtering
-func GetPosts(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-	var posts []models.Post
+// CreatePostRequest is the body of POST /posts. Featured posts and their
+// positions are set through PATCH /posts/reorder instead.
+type CreatePostRequest struct {
+	Title       string `json:"title" binding:"required,max=255"`
+	Content     string `json:"content" binding:"required"`
+	Author      string `json:"author" binding:"max=100"`
+	AuthorEmail string `json:"author_email" binding:"max=255"`
+
+	// Slug is generated from the title when omitted
+	Slug string `json:"slug" binding:"max=255"`
+
+	// Locale is a BCP 47 language tag such as "en" or "de-AT"
+	Locale string `json:"locale" binding:"omitempty,max=35,bcp47_language_tag"`
+
+	// CanonicalURL is where republished content first appeared and
+	// SyndicationSource the publication it appeared in
+	CanonicalURL      string `json:"canonical_url" binding:"max=2048"`
+	SyndicationSource string `json:"syndication_source" binding:"max=255"`
+
+	// Status defaults to published and Visibility to public
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Visibility     string     `json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+
+	Notes        string   `json:"notes"`
+	OwnerGroupID *uint    `json:"owner_group_id"`
+	Tags         []TagRef `json:"tags"`
+}
+
+// UpdatePostRequest is the body of PUT /posts/:id. Empty fields are left
+// unchanged; Tags, when present, replaces the post's tags and an
+// OwnerGroupID of 0 clears the owning team.
+type UpdatePostRequest struct {
+	Title          string     `json:"title" binding:"max=255"`
+	Content        string     `json:"content"`
+	Author         string     `json:"author" binding:"max=100"`
+	AuthorEmail    string     `json:"author_email" binding:"max=255"`
+	Slug           string     `json:"slug" binding:"max=255"`
+	Locale         string     `json:"locale" binding:"omitempty,max=35,bcp47_language_tag"`
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Visibility     string     `json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+	Notes          string     `json:"notes"`
+	OwnerGroupID   *uint      `json:"owner_group_id"`
+	Tags           []TagRef   `json:"tags"`
+
+	// CanonicalURL and SyndicationSource are left unchanged when omitted and
+	// cleared when empty
+	CanonicalURL      *string `json:"canonical_url" binding:"omitempty,max=2048"`
+	SyndicationSource *string `json:"syndication_source" binding:"omitempty,max=255"`
+}
+
+// model maps the request onto a new post
+func (r CreatePostRequest) model() models.Post {
+	return models.Post{
+		Title:          r.Title,
+		Content:        r.Content,
+		Author:         r.Author,
+		AuthorEmail:    r.AuthorEmail,
+		Slug:           r.Slug,
+		Locale:         r.Locale,
+		Status:         r.Status,
+		ExpiresAt:      r.ExpiresAt,
+		Visibility:     r.Visibility,
+		EmbargoedUntil: r.EmbargoedUntil,
+		Notes:          r.Notes,
+		OwnerGroupID:   r.OwnerGroupID,
+
+		CanonicalURL:      r.CanonicalURL,
+		SyndicationSource: r.SyndicationSource,
+	}
+}
+
+// PostHandler serves the post endpoints
+type PostHandler struct {
+	Deps
+}
+
+// NewPostHandler returns a post handler using deps, with defaults for any
+// left unset
+func NewPostHandler(deps Deps) *PostHandler {
+	return &PostHandler{Deps: deps.withDefaults()}
+}
+
+// List retrieves all posts with optional filtering. ?include=media,tags
+// selects the relations loaded; all of them are loaded when it is omitted.
+// ?limit= returns one page newest first, with X-Next-Cursor holding the ?after=
+// value for the next page. ?ids=1,5,9 returns those posts in that order,
+// omitting any that do not exist. ?owner_group= lists a team's posts.
+// ?sort=author,-created_at orders them by whitelisted columns, ties by id.
+// X-Total-Count holds the number of matching posts over all pages; a HEAD
+// request reports only that.
+func (h *PostHandler) List(c *gin.Context) {
+	filter, ok := postFilter(c)
+	if !ok {
+		return
+	}
+	repos := h.repositories(c)
+	if c.Request.Method == http.MethodHead {
+		total, err := repos.Posts.Count(c.Request.Context(), filter)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		headList(c, "posts", total)
+		return
+	}
+	order, ok := sortQuery(c, postSortColumns)
+	if !ok {
+		return
+	}
+
+	// ?after= and ?limit= page through posts newest first
+	limit, after, ok := pageQuery(c)
+	if !ok {
+		return
+	}
+	if order != nil && limit > 0 {
+		utils.Fail(c, utils.Invalid("sort cannot be combined with after or limit"))
+		return
+	}
+	if filter.IDs != nil && limit > 0 {
+		utils.Fail(c, utils.Invalid("ids cannot be combined with after or limit"))
+		return
+	}
+
+	// ?featured=true returns curated posts in their manual order
+	if c.Query("featured") == "true" {
+		if limit > 0 {
+			utils.Fail(c, utils.Invalid("featured=true cannot be combined with after or limit"))
+			return
+		}
+		if order != nil {
+			utils.Fail(c, utils.Invalid("featured=true cannot be combined with sort"))
+			return
+		}
+		filter.ByPosition = true
+	}
+	filter.Sort = order
 
-	title := c.Query("title")
-	author := c.Query("author")
+	// Preload the media and tag relationships selected by ?include= and ?fields=
+	if filter.Preload, ok = postIncludes(c); !ok {
+		return
+	}
+
+	// One extra post is read to detect a next page
+	if limit > 0 {
+		filter.Limit, filter.After = limit+1, after
+	}
+	posts, err := repos.Posts.List(c.Request.Context(), filter)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
 
-	query := db
-	if title != "" {
-		query = query.Where("title ILIKE ?", "%"+title+"%")
+	// Only a page of a paginated list is read, so its total is counted apart
+	total := int64(len(posts))
+	if limit > 0 {
+		if total, err = repos.Posts.Count(c.Request.Context(), filter); err != nil {
+			utils.Fail(c, err)
+			return
+		}
 	}
-	if author != "" {
-		query = query.Where("author = ?", author)
+	first := 0
+	if c.Query("after") != "" {
+		first = -1
 	}
+	posts = trimPostsPage(c, posts, limit)
+	setListTotal(c, "posts", first, len(posts), total)
 
-	// Use proper preloading for media relationships
-	if err := query.Preload("Media").Find(&posts).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if filter.IDs != nil && order == nil {
+		positions := idPositions(filter.IDs)
+		sort.Slice(posts, func(i, j int) bool { return positions[posts[i].ID] < positions[posts[j].ID] })
+	}
+	writeFields(c, http.StatusOK, serializers.Posts(auth.CallerFrom(c), posts))
+}
+
+// Count counts the posts List would list with the same filters
+func (h *PostHandler) Count(c *gin.Context) {
+	filter, ok := postFilter(c)
+	if !ok {
+		return
+	}
+	count, err := h.repositories(c).Posts.Count(c.Request.Context(), filter)
+	if err != nil {
+		utils.Fail(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, posts)
+	c.JSON(http.StatusOK, CountResponse{Count: count})
 }
 
-// GetPost retrieves a specific post by ID
-func GetPost(c *gin.Context) {
-	// Get database instance from Gin context
-	db := c.MustGet("db").(*gorm.DB)
-	
+// postFilter reads the filters of a post listing: ?title=, ?author=,
+// ?owner_group=, ?featured=, ?status=, ?older_than_days=, ?q= with
+// ?search_config= and ?ids=. Restricted drafts are left out unless the
+// caller has been granted access.
+func postFilter(c *gin.Context) (repository.PostFilter, bool) {
+	// ?ids= resolves several posts in one round trip, in the requested order
+	ids, ok := idsQuery(c, "posts")
+	if !ok {
+		return repository.PostFilter{}, false
+	}
+	createdBefore, ok := olderThanQuery(c)
+	if !ok {
+		return repository.PostFilter{}, false
+	}
+	terms, searchConfig, ok := searchQuery(c)
+	if !ok {
+		return repository.PostFilter{}, false
+	}
+
+	filter := repository.PostFilter{
+		Caller:        auth.CallerFrom(c),
+		Title:         c.Query("title"),
+		Author:        c.Query("author"),
+		OwnerGroup:    c.Query("owner_group"),
+		Status:        c.Query("status"),
+		CreatedBefore: createdBefore,
+		IDs:           ids,
+		Search:        terms,
+		SearchConfig:  searchConfig,
+	}
+	switch c.Query("featured") {
+	case "true", "false":
+		featured := c.Query("featured") == "true"
+		filter.Featured = &featured
+	}
+	return filter, true
+}
+
+// Get retrieves a specific post by ID
+func (h *PostHandler) Get(c *gin.Context) {
 	// Get the ID from URL parameter
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 	
-	// Define post variable and query database
-	var post models.Post
-	if err := db.Preload("Media").First(&post, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Post not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	// Preload the relationships selected by ?include= and ?fields=
+	associations, ok := postIncludes(c)
+	if !ok {
+		return
+	}
+	
+	// Restricted drafts the caller has no grant on are reported as not found
+	post, err := h.repositories(c).Posts.GetReadable(c.Request.Context(), auth.CallerFrom(c), id, associations)
+	if err != nil {
+		writeRepositoryError(c, err, "Post not found")
+		return
+	}
+	
+	// Render Markdown content to sanitized HTML, plaintext or AMP when requested
+	if !renderPostFormat(c, c.MustGet("db").(*gorm.DB), post) {
 		return
 	}
 	
 	// Return the post
-	c.JSON(http.StatusOK, post)
+	writeFields(c, http.StatusOK, serializers.Post(auth.CallerFrom(c), *post))
 }
 
-// CreatePost creates a new post
-func CreatePost(c *gin.Context) {
+// Create creates a new post. With ?check_duplicates=true a post whose
+// title or content closely resembles an existing post is refused with a 409
+// listing the similar posts, so the request can be repeated without the
+// check if it is not a duplicate after all.
+func (h *PostHandler) Create(c *gin.Context) {
 	// Get database instance from Gin context
 	db := c.MustGet("db").(*gorm.DB)
 	
-	// Define post variable to store incoming data
-	var post models.Post
-	
-	// Parse JSON request body into post struct
-	if err := c.ShouldBindJSON(&post); err != nil {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
+	// Parse JSON request body and map it onto a new post
+	var request CreatePostRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
 		return
 	}
+	post := request.model()
 	
 	// Validate required fields
 	if post.Title == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Title is required",
-		})
+		utils.Fail(c, utils.Invalid("Title is required"))
 		return
 	}
 	if post.Content == "" {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: "Content is required",
-		})
+		utils.Fail(c, utils.Invalid("Content is required"))
 		return
 	}
-	
-	// Start database transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-	
-	// Create the post
-	if err := tx.Create(&post).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+
+	// Default to published and reject unknown states or elapsed expiry times
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
+	if !checkPublishing(c, post.Status, post.ExpiresAt) {
+		return
+	}
+	if post.Visibility == "" {
+		post.Visibility = models.VisibilityPublic
+	}
+	if !checkVisibility(c, post.Visibility) {
+		return
+	}
+	if !checkCanonicalURL(c, post.CanonicalURL) {
+		return
+	}
+	if !checkOwnerGroup(c, db, post.OwnerGroupID) {
+		return
+	}
+	if post.OwnerGroupID != nil && *post.OwnerGroupID == 0 {
+		post.OwnerGroupID = nil
+	}
+	if !checkDuplicates(c, db, &post) {
 		return
 	}
 	
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Resolve tags given by ID or name
+		if len(request.Tags) > 0 {
+			tags, err := resolveTags(tx, request.Tags)
+			if err != nil {
+				writeTagError(c, err)
+				return errResponded
+			}
+			post.Tags = tags
+		}
+
+		// Use the requested slug or derive a unique one from the title
+		slug, err := assignSlug(tx, &models.Post{}, post.Slug, post.Title, 0)
+		if err != nil {
+			writeSlugError(c, err)
+			return errResponded
+		}
+		post.Slug = slug
+		post.ReadingTime = readability.ReadingTime(post.Content)
+
+		// Create the post
+		if err := h.Repositories(tx).Posts.Create(c.Request.Context(), &post); err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPost, EntityID: post.ID, Action: models.ActivityCreated})
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 	
 	// Return created post
-	c.JSON(http.StatusCreated, post)
+	c.JSON(http.StatusCreated, serializers.Post(auth.CallerFrom(c), post))
 }
 
-// UpdatePost updates an existing post
-func UpdatePost(c *gin.Context) {
+// Update updates an existing post
+func (h *PostHandler) Update(c *gin.Context) {
 	// Get database instance from Gin context
 	db := c.MustGet("db").(*gorm.DB)
 	
 	// Get ID from URL parameter
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 	
 	// Find existing post
-	var existingPost models.Post
-	if err := db.First(&existingPost, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Post not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	found, err := h.repositories(c).Posts.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Post not found")
 		return
 	}
+	existingPost := *found
+	before := existingPost
 	
 	// Define variable for update input
-	var updateData models.Post
+	var updateData UpdatePostRequest
 	if err := c.ShouldBindJSON(&updateData); err != nil {
-		c.JSON(http.StatusBadRequest, utils.HTTPError{
-			Code:    http.StatusBadRequest,
-			Message: err.Error(),
-		})
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	
+	// Publishing needs the publish grant on restricted posts, other edits write
+	permission := acl.Write
+	if updateData.Status == models.StatusPublished && existingPost.Status != models.StatusPublished {
+		permission = acl.Publish
+	}
+	if !checkACL(c, db, acl.EntityPost, existingPost.ID, permission) {
 		return
 	}
 	
@@ -174,87 +409,143 @@ func UpdatePost(c *gin.Context) {
 	if updateData.Author != "" {
 		existingPost.Author = updateData.Author
 	}
-	
-	// Start transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	if updateData.AuthorEmail != "" {
+		existingPost.AuthorEmail = updateData.AuthorEmail
+	}
+	if updateData.Notes != "" {
+		existingPost.Notes = updateData.Notes
+	}
+	if updateData.Locale != "" {
+		existingPost.Locale = updateData.Locale
+	}
+	if updateData.CanonicalURL != nil {
+		if !checkCanonicalURL(c, *updateData.CanonicalURL) {
+			return
 		}
-	}()
-	
-	// Save the updated post
-	if err := tx.Save(&existingPost).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+		existingPost.CanonicalURL = *updateData.CanonicalURL
+	}
+	if updateData.SyndicationSource != nil {
+		existingPost.SyndicationSource = *updateData.SyndicationSource
+	}
+	if !checkPublishing(c, updateData.Status, updateData.ExpiresAt) {
+		return
+	}
+	if updateData.Status != "" {
+		existingPost.Status = updateData.Status
+		existingPost.ExpiresAt = republishClearsExpiry(existingPost.Status, existingPost.ExpiresAt)
+	}
+	if updateData.ExpiresAt != nil {
+		existingPost.ExpiresAt = updateData.ExpiresAt
+	}
+	if !checkVisibility(c, updateData.Visibility) {
 		return
 	}
+	if updateData.Visibility != "" {
+		existingPost.Visibility = updateData.Visibility
+	}
+	if updateData.EmbargoedUntil != nil {
+		existingPost.EmbargoedUntil = updateData.EmbargoedUntil
+	}
+	if updateData.OwnerGroupID != nil {
+		if !checkOwnerGroup(c, db, updateData.OwnerGroupID) {
+			return
+		}
+		existingPost.OwnerGroupID = updateData.OwnerGroupID
+		if *updateData.OwnerGroupID == 0 {
+			existingPost.OwnerGroupID = nil
+		}
+	}
 	
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		posts := h.Repositories(tx).Posts
+
+		// Slugs only change when explicitly requested so existing links keep working
+		if updateData.Slug != "" {
+			slug, err := assignSlug(tx, &models.Post{}, updateData.Slug, "", existingPost.ID)
+			if err != nil {
+				writeSlugError(c, err)
+				return errResponded
+			}
+
+			// Renaming a published post leaves a redirect from its old path
+			if slug != before.Slug && before.Status == models.StatusPublished {
+				if err := redirectRenamed(tx, contentPath("posts", before.Slug), contentPath("posts", slug)); err != nil {
+					return err
+				}
+			}
+			existingPost.Slug = slug
+		}
+
+		// Save the updated post
+		existingPost.ReadingTime = readability.ReadingTime(existingPost.Content)
+		if err := posts.Update(c.Request.Context(), &existingPost); err != nil {
+			return err
+		}
+
+		// Drop the cached HTML rendering when the content changes
+		if updateData.Content != "" {
+			if err := invalidatePostRender(tx, existingPost.ID); err != nil {
+				return err
+			}
+		}
+
+		// Replace tags only when the request includes them
+		if updateData.Tags != nil {
+			tags, err := resolveTags(tx, updateData.Tags)
+			if err != nil {
+				writeTagError(c, err)
+				return errResponded
+			}
+			if err := posts.SetTags(c.Request.Context(), &existingPost, tags); err != nil {
+				return err
+			}
+		}
+
+		// Record the edit and any status change in the post's history
+		fields := changedPostFields(before, existingPost, updateData.Tags != nil)
+		return recordActivity(tx, c, editActivities(acl.EntityPost, existingPost.ID, fields, before.Status, existingPost.Status)...)
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 	
 	// Return updated post
-	c.JSON(http.StatusOK, existingPost)
+	c.JSON(http.StatusOK, serializers.Post(auth.CallerFrom(c), existingPost))
 }
 
-// DeletePost deletes a post
-func DeletePost(c *gin.Context) {
+// Delete deletes a post
+func (h *PostHandler) Delete(c *gin.Context) {
 	// Get database instance from Gin context
 	db := c.MustGet("db").(*gorm.DB)
 	
 	// Get ID from URL parameter
-	id := c.Param("id")
+	id, ok := paramID(c)
+	if !ok {
+		return
+	}
 	
 	// Find existing post
-	var post models.Post
-	if err := db.First(&post, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, utils.HTTPError{
-				Code:    http.StatusNotFound,
-				Message: "Post not found",
-			})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	found, err := h.repositories(c).Posts.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Post not found")
 		return
 	}
-	
-	// Start transaction
-	tx := db.Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-	
-	// Delete the post (soft delete if GORM's DeletedAt is configured, otherwise hard delete)
-	if err := tx.Delete(&post).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	post := *found
+	if !checkACL(c, db, acl.EntityPost, post.ID, acl.Write) {
 		return
 	}
 	
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, utils.HTTPError{
-			Code:    http.StatusInternalServerError,
-			Message: err.Error(),
-		})
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Delete the post (soft delete if GORM's DeletedAt is configured, otherwise hard delete)
+		if err := h.Repositories(tx).Posts.Delete(c.Request.Context(), &post); err != nil {
+			return err
+		}
+		if err := deleteACL(tx, acl.EntityPost, post.ID); err != nil {
+			return err
+		}
+		return recordActivity(tx, c, models.Activity{EntityType: acl.EntityPost, EntityID: post.ID, Action: models.ActivityDeleted})
+	}); err != nil {
+		writeTransactionError(c, err)
 		return
 	}
 	
@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"cms-backend/markdown"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// renderPost returns the sanitized HTML, plaintext and AMP renderings of a post's
+// Markdown content, serving them from post_renders when the cached copy matches
+// the current content
+func renderPost(db *gorm.DB, post *models.Post) (models.PostRender, error) {
+	sum := sha256.Sum256([]byte(post.Content))
+	hash := hex.EncodeToString(sum[:])
+
+	var cached models.PostRender
+	err := db.Where("post_id = ?", post.ID).First(&cached).Error
+	if err == nil && cached.ContentHash == hash {
+		return cached, nil
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return models.PostRender{}, err
+	}
+
+	render := models.PostRender{
+		PostID:      post.ID,
+		ContentHash: hash,
+		HTML:        markdown.ToHTML(post.Content),
+		Text:        markdown.ToText(post.Content),
+		AMP:         markdown.ToAMP(post.Content),
+		RenderedAt:  time.Now(),
+	}
+	if err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content_hash", "html", "text", "amp", "rendered_at"}),
+	}).Create(&render).Error; err != nil {
+		return models.PostRender{}, err
+	}
+
+	return render, nil
+}
+
+// renderPostFormat fills the rendering of a post's content named by ?format=:
+// html, text or amp. It writes an error response and returns false when the
+// format is unknown or rendering fails.
+func renderPostFormat(c *gin.Context, db *gorm.DB, post *models.Post) bool {
+	format := c.Query("format")
+	switch format {
+	case "":
+		return true
+	case "html", "text", "amp":
+	default:
+		utils.Fail(c, utils.Invalid("Unsupported format"))
+		return false
+	}
+
+	render, err := renderPost(db, post)
+	if err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	switch format {
+	case "html":
+		post.ContentHTML = render.HTML
+	case "text":
+		post.ContentText = render.Text
+	case "amp":
+		post.ContentAMP = render.AMP
+	}
+	return true
+}
+
+// invalidatePostRender drops the cached rendering of a post
+func invalidatePostRender(db *gorm.DB, postID uint) error {
+	return db.Where("post_id = ?", postID).Delete(&models.PostRender{}).Error
+}
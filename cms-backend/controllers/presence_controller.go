@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/presence"
+	"cms-backend/repository"
+	"cms-backend/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockTTL is how long a soft lock lasts before it must be re-acquired
+const lockTTL = 5 * time.Minute
+
+// PresenceHandler serves the presence stream and soft locks of posts being
+// edited
+type PresenceHandler struct {
+	Deps
+}
+
+// NewPresenceHandler returns a presence handler using deps, with defaults for
+// any it leaves unset
+func NewPresenceHandler(deps Deps) *PresenceHandler {
+	return &PresenceHandler{Deps: deps.withDefaults()}
+}
+
+// Connect upgrades to a WebSocket and streams presence/lock events for a post
+func (h *PresenceHandler) Connect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	// Other editors see the caller under their API key's name
+	caller, ok := editorFrom(c)
+	if !ok {
+		return
+	}
+
+	post, ok := loadPost(c, h.repositories(c).Posts)
+	if !ok {
+		return
+	}
+	if post.Status != models.StatusPublished && !checkACL(c, db, acl.EntityPost, post.ID, acl.Read) {
+		return
+	}
+
+	conn, err := presence.Upgrade(c.Writer, c.Request)
+	if errors.Is(err, presence.ErrOriginNotAllowed) {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "WebSocket connections are not allowed from this origin",
+		})
+		return
+	}
+	if err != nil {
+		utils.Fail(c, utils.Invalid("Could not upgrade to a WebSocket connection"))
+		return
+	}
+
+	client := h.Presence.Join(post.ID, caller.Name, conn)
+	defer h.Presence.Leave(post.ID, client)
+
+	// Send the current lock state so new editors know immediately
+	var lock models.PostLock
+	if err := db.Where("post_id = ?", post.ID).First(&lock).Error; err == nil && lock.IsActive(h.Clock()) {
+		h.Presence.Broadcast(presence.Event{
+			Type:   presence.EventLockAcquired,
+			PostID: post.ID,
+			User:   lock.Holder,
+			Lock:   lock,
+		})
+	}
+
+	// Keep reading until the client disconnects or stops heartbeating for
+	// presence.ReadTimeout; messages are heartbeats only
+	for {
+		if _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// GetLock returns the active lock on a post
+func (h *PresenceHandler) GetLock(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	post, ok := loadPost(c, h.repositories(c).Posts)
+	if !ok {
+		return
+	}
+	if post.Status != models.StatusPublished && !checkACL(c, db, acl.EntityPost, post.ID, acl.Read) {
+		return
+	}
+
+	var lock models.PostLock
+	if err := db.Where("post_id = ?", post.ID).First(&lock).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post is not locked"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	if !lock.IsActive(h.Clock()) {
+		utils.Fail(c, utils.NotFound("Post is not locked"))
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+// AcquireLock takes or refreshes the soft lock on a post.
+// Returns 409 with the current lock if another editor holds it.
+func (h *PresenceHandler) AcquireLock(c *gin.Context) {
+	h.changeLock(c, false)
+}
+
+// StealLock takes the soft lock even if another editor holds it
+func (h *PresenceHandler) StealLock(c *gin.Context) {
+	h.changeLock(c, true)
+}
+
+// ReleaseLock releases a lock held by the requesting editor
+func (h *PresenceHandler) ReleaseLock(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := editorFrom(c)
+	if !ok {
+		return
+	}
+
+	post, ok := loadPost(c, h.repositories(c).Posts)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, acl.EntityPost, post.ID, acl.Write) {
+		return
+	}
+
+	var lock models.PostLock
+	if err := db.Where("post_id = ?", post.ID).First(&lock).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post is not locked"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	// Only the holder may release an active lock
+	if lock.HolderKeyID != caller.KeyID && lock.IsActive(h.Clock()) {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Lock is held by another user",
+		})
+		return
+	}
+
+	if err := db.Delete(&lock).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	h.Presence.Broadcast(presence.Event{
+		Type:   presence.EventLockReleased,
+		PostID: post.ID,
+		User:   caller.Name,
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lock released successfully",
+	})
+}
+
+// changeLock acquires (or with steal, forcibly takes) the lock for the requesting editor
+func (h *PresenceHandler) changeLock(c *gin.Context, steal bool) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := editorFrom(c)
+	if !ok {
+		return
+	}
+
+	post, ok := loadPost(c, h.repositories(c).Posts)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, acl.EntityPost, post.ID, acl.Write) {
+		return
+	}
+
+	now := h.Clock()
+	lock := models.PostLock{PostID: post.ID, Holder: caller.Name, HolderKeyID: caller.KeyID, AcquiredAt: now, ExpiresAt: now.Add(lockTTL)}
+	var exists bool
+	var previousHolder uint
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Take a free post in one statement, so concurrent requests cannot
+		// both create the lock
+		result := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "post_id"}},
+			DoNothing: true,
+		}).Create(&lock)
+		if result.Error != nil || result.RowsAffected == 1 {
+			return result.Error
+		}
+
+		// Otherwise hold the existing lock row until the transaction ends
+		exists = true
+		lock = models.PostLock{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("post_id = ?", post.ID).First(&lock).Error; err != nil {
+			return err
+		}
+		previousHolder = lock.HolderKeyID
+
+		// Refuse to take over someone else's active lock unless stealing
+		if !steal && lock.HolderKeyID != caller.KeyID && lock.IsActive(now) {
+			c.JSON(http.StatusConflict, lock)
+			return errResponded
+		}
+
+		if lock.HolderKeyID != caller.KeyID || !lock.IsActive(now) {
+			lock.AcquiredAt = now
+		}
+		lock.Holder = caller.Name
+		lock.HolderKeyID = caller.KeyID
+		lock.ExpiresAt = now.Add(lockTTL)
+		return tx.Save(&lock).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	eventType := presence.EventLockAcquired
+	if exists && previousHolder != caller.KeyID && steal {
+		eventType = presence.EventLockStolen
+	}
+	h.Presence.Broadcast(presence.Event{
+		Type:   eventType,
+		PostID: post.ID,
+		User:   caller.Name,
+		Lock:   lock,
+	})
+
+	c.JSON(http.StatusOK, lock)
+}
+
+// editorFrom returns the caller a lock or presence belongs to, writing a 401
+// for anonymous callers, who have no identity to hold a lock under
+func editorFrom(c *gin.Context) (auth.Caller, bool) {
+	caller := auth.CallerFrom(c)
+	if caller.Anonymous() {
+		c.JSON(http.StatusUnauthorized, utils.HTTPError{
+			Code:      http.StatusUnauthorized,
+			Message:   "An API key is required to edit a post",
+			ErrorCode: utils.ErrorCodeAuthRequired,
+		})
+		return caller, false
+	}
+	return caller, true
+}
+
+// findPost loads the post named by the :id parameter, writing a 404/500 response on failure
+func findPost(c *gin.Context) (*models.Post, bool) {
+	return loadPost(c, repositoriesFor(c).Posts)
+}
+
+// loadPost loads the post named by the :id parameter from posts, writing a
+// 404/500 response on failure
+func loadPost(c *gin.Context, posts repository.PostRepository) (*models.Post, bool) {
+	id, ok := paramID(c)
+	if !ok {
+		return nil, false
+	}
+	post, err := posts.Get(c.Request.Context(), id)
+	if err != nil {
+		writeRepositoryError(c, err, "Post not found")
+		return nil, false
+	}
+	return post, true
+}
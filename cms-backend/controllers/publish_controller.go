@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/checklist"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Bulk publish outcomes reported per post
+const (
+	PublishResultPublished = "published"
+	PublishResultSkipped   = "skipped"
+	PublishResultNotFound  = "not_found"
+)
+
+// BulkPublishRequest selects the posts to publish. Filters combine with AND
+// and at least one is required, so an empty body cannot publish every draft.
+type BulkPublishRequest struct {
+	IDs    []uint `json:"ids"`
+	Tag    string `json:"tag"`
+	Author string `json:"author"`
+
+	// CreatedAfter and CreatedBefore bound the posts' creation time (inclusive)
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+}
+
+// BulkPublishResult reports what happened to one post
+type BulkPublishResult struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title,omitempty"`
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkPublishResponse summarises a bulk publish
+type BulkPublishResponse struct {
+	Published int                 `json:"published"`
+	Skipped   int                 `json:"skipped"`
+	Results   []BulkPublishResult `json:"results"`
+}
+
+// BulkPublishPosts publishes every draft matching the filter in one
+// transaction, so a campaign goes live all at once or not at all. Matching
+// posts that are not drafts, or whose ACL withholds publish access from the
+// caller, are skipped, and requested IDs that do not exist are reported as
+// not_found. Drafts whose expiry has passed have it cleared.
+func BulkPublishPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req BulkPublishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if len(req.IDs) == 0 && req.Tag == "" && req.Author == "" && req.CreatedAfter == nil && req.CreatedBefore == nil {
+		utils.Fail(c, utils.Invalid("At least one of ids, tag, author, created_after and created_before is required"))
+		return
+	}
+	if req.CreatedAfter != nil && req.CreatedBefore != nil && req.CreatedBefore.Before(*req.CreatedAfter) {
+		utils.Fail(c, utils.Invalid("created_before must not be before created_after"))
+		return
+	}
+
+	response := BulkPublishResponse{Results: []BulkPublishResult{}}
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Lock the matching rows so concurrent edits cannot change them mid-publish
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "title", "status")
+		if len(req.IDs) > 0 {
+			query = query.Where("id IN ?", req.IDs)
+		}
+		if req.Tag != "" {
+			query = query.Where("id IN (?)", tx.Table("post_tags").
+				Select("post_tags.post_id").
+				Joins("JOIN tags ON tags.id = post_tags.tag_id").
+				Where("tags.name = ?", req.Tag))
+		}
+		if req.Author != "" {
+			query = query.Where("author = ?", req.Author)
+		}
+		if req.CreatedAfter != nil {
+			query = query.Where("created_at >= ?", *req.CreatedAfter)
+		}
+		if req.CreatedBefore != nil {
+			query = query.Where("created_at <= ?", *req.CreatedBefore)
+		}
+
+		var posts []models.Post
+		if err := query.Order("id").Find(&posts).Error; err != nil {
+			return err
+		}
+
+		// Check publish grants for every matched draft at once
+		var ids []uint
+		for _, post := range posts {
+			if post.Status == models.StatusDraft {
+				ids = append(ids, post.ID)
+			}
+		}
+		denied, err := acl.Denied(tx, auth.CallerFrom(c), acl.EntityPost, ids, acl.Publish)
+		if err != nil {
+			return err
+		}
+
+		found := make(map[uint]bool, len(posts))
+		var drafts []uint
+		for _, post := range posts {
+			found[post.ID] = true
+			result := BulkPublishResult{ID: post.ID, Title: post.Title, Result: PublishResultPublished}
+			switch {
+			case post.Status != models.StatusDraft:
+				result.Result = PublishResultSkipped
+				result.Reason = fmt.Sprintf("status is %s", post.Status)
+				response.Skipped++
+			case denied[post.ID]:
+				result.Result = PublishResultSkipped
+				result.Reason = "no publish access"
+				response.Skipped++
+			default:
+				drafts = append(drafts, post.ID)
+				response.Published++
+			}
+			response.Results = append(response.Results, result)
+		}
+		for _, id := range req.IDs {
+			if !found[id] {
+				found[id] = true
+				response.Results = append(response.Results, BulkPublishResult{ID: id, Result: PublishResultNotFound})
+			}
+		}
+		if len(drafts) == 0 {
+			return nil
+		}
+
+		// Like republishClearsExpiry, drop expiry times that have already passed
+		if err := tx.Model(&models.Post{}).Where("id IN ?", drafts).Updates(map[string]interface{}{
+			"status":     models.StatusPublished,
+			"expires_at": gorm.Expr("CASE WHEN expires_at <= ? THEN NULL ELSE expires_at END", time.Now()),
+		}).Error; err != nil {
+			return err
+		}
+
+		activities := make([]models.Activity, len(drafts))
+		for i, id := range drafts {
+			activities[i] = models.Activity{
+				EntityType: acl.EntityPost,
+				EntityID:   id,
+				Action:     models.ActivityStatusChanged,
+				FromStatus: models.StatusDraft,
+				ToStatus:   models.StatusPublished,
+			}
+		}
+		return recordActivity(tx, c, activities...)
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPublishReadiness runs the publish checklist against a post and reports
+// each check's outcome, and whether every blocking check passed, so editing
+// UIs can hold back publishing until the post is ready
+func GetPublishReadiness(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	// Restricted drafts the caller has no grant on are reported as not found
+	var post models.Post
+	err := db.Scopes(acl.Readable(auth.CallerFrom(c), acl.EntityPost, "posts")).Preload("Media").First(&post, c.Param("id")).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Post not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	report, err := checklist.Evaluate(c.Request.Context(), db, post)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
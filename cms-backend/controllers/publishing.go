@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkPublishing validates the status and expiry given in a create or update
+// request, writing a 400 response when either is invalid. Empty values pass.
+func checkPublishing(c *gin.Context, status string, expiresAt *time.Time) bool {
+	if status != "" && !models.ValidStatus(status) {
+		utils.Fail(c, utils.Invalid("Status must be 'draft', 'published' or 'archived'"))
+		return false
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		utils.Fail(c, utils.Invalid("expires_at must be in the future"))
+		return false
+	}
+	return true
+}
+
+// checkVisibility validates the visibility given in a create or update
+// request, writing a 400 response when it is unknown. An empty value passes.
+func checkVisibility(c *gin.Context, visibility string) bool {
+	if visibility != "" && !models.ValidVisibility(visibility) {
+		utils.Fail(c, utils.Invalid("Visibility must be 'public' or 'members'"))
+		return false
+	}
+	return true
+}
+
+// checkCanonicalURL validates the canonical URL given in a create or update
+// request, writing a 400 response unless it is an absolute http(s) URL. An
+// empty value passes.
+func checkCanonicalURL(c *gin.Context, canonical string) bool {
+	if canonical == "" {
+		return true
+	}
+	parsed, err := url.Parse(canonical)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		utils.Fail(c, utils.Invalid("canonical_url must be an absolute http or https URL"))
+		return false
+	}
+	return true
+}
+
+// republishClearsExpiry drops an expiry that has already passed when content is
+// set back to published, so the scheduler does not immediately archive it again
+func republishClearsExpiry(status string, expiresAt *time.Time) *time.Time {
+	if status == models.StatusPublished && expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil
+	}
+	return expiresAt
+}
@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RedirectRequest is the body for creating or updating a redirect.
+// StatusCode defaults to 301 on create.
+type RedirectRequest struct {
+	FromPath   string `json:"from_path"`
+	ToPath     string `json:"to_path"`
+	StatusCode int    `json:"status_code"`
+}
+
+// redirectStatuses are the status codes a redirect may use
+var redirectStatuses = map[int]bool{
+	http.StatusMovedPermanently:  true,
+	http.StatusFound:             true,
+	http.StatusTemporaryRedirect: true,
+	http.StatusPermanentRedirect: true,
+}
+
+// GetRedirects retrieves all redirects ordered by path
+func GetRedirects(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var redirects []models.Redirect
+	if err := db.Order("from_path").Find(&redirects).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, redirects)
+}
+
+// GetRedirect retrieves a redirect by ID
+func GetRedirect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	redirect, ok := findRedirect(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, redirect)
+}
+
+// ResolveRedirect looks up the redirect for ?path=, so a frontend can send the
+// visitor on instead of answering 404. Trailing slashes are ignored.
+func ResolveRedirect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	path := c.Query("path")
+	if path == "" {
+		utils.Fail(c, utils.Invalid("path is required"))
+		return
+	}
+
+	var redirect models.Redirect
+	if err := db.Where("from_path = ?", normalizeRedirectPath(path)).First(&redirect).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Redirect not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, redirect)
+}
+
+// CreateRedirect creates a redirect
+func CreateRedirect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req RedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.StatusCode == 0 {
+		req.StatusCode = http.StatusMovedPermanently
+	}
+
+	redirect := models.Redirect{FromPath: req.FromPath, ToPath: req.ToPath, StatusCode: req.StatusCode}
+	saveRedirect(c, db, &redirect, http.StatusCreated)
+}
+
+// UpdateRedirect updates the fields of a redirect given in the request
+func UpdateRedirect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	redirect, ok := findRedirect(c, db)
+	if !ok {
+		return
+	}
+
+	var req RedirectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.FromPath != "" {
+		redirect.FromPath = req.FromPath
+	}
+	if req.ToPath != "" {
+		redirect.ToPath = req.ToPath
+	}
+	if req.StatusCode != 0 {
+		redirect.StatusCode = req.StatusCode
+	}
+
+	saveRedirect(c, db, redirect, http.StatusOK)
+}
+
+// DeleteRedirect deletes a redirect
+func DeleteRedirect(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	redirect, ok := findRedirect(c, db)
+	if !ok {
+		return
+	}
+
+	if err := db.Delete(redirect).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Redirect deleted successfully",
+	})
+}
+
+// saveRedirect validates and writes a redirect, answering 409 when another
+// redirect already starts from the same path
+func saveRedirect(c *gin.Context, db *gorm.DB, redirect *models.Redirect, status int) {
+	redirect.FromPath = normalizeRedirectPath(redirect.FromPath)
+	if message := validateRedirect(redirect); message != "" {
+		utils.Fail(c, utils.Invalid(message))
+		return
+	}
+
+	var count int64
+	if err := db.Model(&models.Redirect{}).Where("from_path = ? AND id <> ?", redirect.FromPath, redirect.ID).
+		Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if count > 0 {
+		utils.Fail(c, utils.Conflict("A redirect from this path already exists"))
+		return
+	}
+
+	if err := db.Save(redirect).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(status, redirect)
+}
+
+// validateRedirect returns why a redirect is invalid, or "" when it is valid
+func validateRedirect(redirect *models.Redirect) string {
+	switch {
+	case !strings.HasPrefix(redirect.FromPath, "/") || strings.HasPrefix(redirect.FromPath, "//"):
+		return "from_path must be a path starting with /"
+	case !validRedirectTarget(redirect.ToPath):
+		return "to_path must be a path starting with / or an http(s) URL"
+	case normalizeRedirectPath(redirect.ToPath) == redirect.FromPath:
+		return "to_path must differ from from_path"
+	case !redirectStatuses[redirect.StatusCode]:
+		return "status_code must be 301, 302, 307 or 308"
+	}
+	return ""
+}
+
+// validRedirectTarget reports whether target is a site path or an absolute http(s) URL
+func validRedirectTarget(target string) bool {
+	if strings.HasPrefix(target, "/") {
+		return !strings.HasPrefix(target, "//")
+	}
+	parsed, err := url.Parse(target)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// normalizeRedirectPath drops a trailing slash so /about and /about/ match
+func normalizeRedirectPath(path string) string {
+	if len(path) > 1 {
+		return strings.TrimRight(path, "/")
+	}
+	return path
+}
+
+// redirectRenamed leaves a permanent redirect from the old path of a renamed
+// or moved post or page to its new one. Redirects that pointed at the old path
+// are moved to the new one, so visitors never follow a chain, and a redirect
+// away from the new path is dropped since the content lives there now.
+func redirectRenamed(tx *gorm.DB, from, to string) error {
+	if err := tx.Where("from_path = ?", to).Delete(&models.Redirect{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Redirect{}).Where("to_path = ?", from).Update("to_path", to).Error; err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "from_path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"to_path", "status_code", "updated_at"}),
+	}).Create(&models.Redirect{FromPath: from, ToPath: to, StatusCode: http.StatusMovedPermanently}).Error
+}
+
+// findRedirect loads the redirect named by the :id parameter, writing a
+// 404/500 response on failure
+func findRedirect(c *gin.Context, db *gorm.DB) (*models.Redirect, bool) {
+	var redirect models.Redirect
+	if err := db.First(&redirect, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Redirect not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &redirect, true
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// defaultRelatedLimit is how many posts GetRelatedPosts returns when suggesting
+const defaultRelatedLimit = 5
+
+// RelatedPostsRequest lists the posts to attach as related, by ID or, with
+// ID_FORMAT=uuid, by UUID
+type RelatedPostsRequest struct {
+	PostIDs []RecordRef `json:"post_ids" binding:"required"`
+}
+
+// RelatedPostsResponse separates editor-curated relations from automatic suggestions
+type RelatedPostsResponse struct {
+	Related   []serializers.PostResponse `json:"related"`
+	Suggested []serializers.PostResponse `json:"suggested"`
+}
+
+// GetRelatedPosts lists the posts related to a post. With ?suggest=true the list is
+// topped up (up to ?limit=, default 5) with posts sharing the most tags.
+func GetRelatedPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+	if post.Status != models.StatusPublished && !checkACL(c, db, acl.EntityPost, post.ID, acl.Read) {
+		return
+	}
+
+	limit := defaultRelatedLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 50 {
+			utils.Fail(c, utils.Invalid("limit must be between 1 and 50"))
+			return
+		}
+		limit = parsed
+	}
+
+	var related, suggested []models.Post
+	caller := auth.CallerFrom(c)
+	readable := acl.Readable(caller, acl.EntityPost, "posts")
+
+	// Curated relations the caller may read
+	if err := db.Scopes(readable).Preload("Media").
+		Joins("JOIN post_relations ON post_relations.related_post_id = posts.id").
+		Where("post_relations.post_id = ?", post.ID).
+		Order("posts.created_at DESC").
+		Find(&related).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	// Suggestions by shared tags, excluding the post itself and curated relations
+	if c.Query("suggest") == "true" && len(related) < limit {
+		exclude := []uint{post.ID}
+		for _, post := range related {
+			exclude = append(exclude, post.ID)
+		}
+
+		sharedTags := db.Table("post_tags").Select("tag_id").Where("post_id = ?", post.ID)
+		if err := db.Scopes(readable).Preload("Media").
+			Select("posts.*").
+			Joins("JOIN post_tags ON post_tags.post_id = posts.id").
+			Where("post_tags.tag_id IN (?)", sharedTags).
+			Where("posts.id NOT IN ?", exclude).
+			Group("posts.id").
+			Order("COUNT(post_tags.tag_id) DESC, posts.created_at DESC").
+			Limit(limit - len(related)).
+			Find(&suggested).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, RelatedPostsResponse{
+		Related:   serializers.Posts(caller, related),
+		Suggested: serializers.Posts(caller, suggested),
+	})
+}
+
+// AddRelatedPosts attaches posts as related to a post (in both directions)
+func AddRelatedPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req RelatedPostsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+	if !checkACL(c, db, acl.EntityPost, post.ID, acl.Write) {
+		return
+	}
+
+	// Validate related IDs; UUIDs naming no post are caught by the count below
+	refs := make([]string, len(req.PostIDs))
+	for i, ref := range req.PostIDs {
+		refs[i] = string(ref)
+	}
+	ids, err := resolveRefs(db, "posts", refs)
+	if err != nil {
+		if errors.Is(err, utils.ErrValidation) {
+			err = utils.Invalid("post_ids must list " + refKind())
+		}
+		utils.Fail(c, err)
+		return
+	}
+	for _, id := range ids {
+		if id == post.ID {
+			utils.Fail(c, utils.Invalid("A post cannot be related to itself"))
+			return
+		}
+	}
+	if len(refs) == 0 {
+		utils.Fail(c, utils.Invalid("post_ids is required"))
+		return
+	}
+
+	// UUIDs naming no post were dropped when resolving; IDs are counted
+	unique := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		unique[strings.ToLower(ref)] = true
+	}
+	var count int64
+	if len(ids) == len(unique) {
+		if err := db.Model(&models.Post{}).Where("id IN ?", ids).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+	}
+	if count != int64(len(unique)) {
+		utils.Fail(c, utils.Invalid("One or more related posts do not exist"))
+		return
+	}
+	// Relations are two-way, so the caller must be able to edit both ends
+	if !checkACLAll(c, db, acl.EntityPost, ids, acl.Write) {
+		return
+	}
+
+	relations := make([]models.PostRelation, 0, len(ids)*2)
+	for _, id := range ids {
+		relations = append(relations,
+			models.PostRelation{PostID: post.ID, RelatedPostID: id},
+			models.PostRelation{PostID: id, RelatedPostID: post.ID},
+		)
+	}
+
+	// Existing relations are left untouched
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&relations).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Related posts added successfully",
+	})
+}
+
+// RemoveRelatedPost detaches a related post (in both directions)
+func RemoveRelatedPost(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+
+	relatedID, err := strconv.ParseUint(c.Param("relatedId"), 10, 64)
+	if err != nil {
+		utils.Fail(c, utils.Invalid("Invalid related post ID"))
+		return
+	}
+	if !checkACLAll(c, db, acl.EntityPost, []uint{post.ID, uint(relatedID)}, acl.Write) {
+		return
+	}
+
+	result := db.Where("(post_id = ? AND related_post_id = ?) OR (post_id = ? AND related_post_id = ?)",
+		post.ID, relatedID, relatedID, post.ID).
+		Delete(&models.PostRelation{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Related post not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Related post removed successfully",
+	})
+}
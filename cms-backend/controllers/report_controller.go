@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/trash"
+	"cms-backend/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BrokenLink is a link that failed its latest check, with the title of the
+// post or page it appears in
+type BrokenLink struct {
+	models.Link
+	Title string `json:"title"`
+}
+
+// GetBrokenLinks reports the external links in published content that failed
+// their latest check, grouped by the post or page they appear in. Links are
+// checked in the background every LINK_CHECK_INTERVAL; ?entity_type= limits
+// the report to posts or pages.
+func GetBrokenLinks(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query := db.Where("broken = ?", true)
+	switch entity := c.Query("entity_type"); entity {
+	case "":
+	case "post", "page":
+		query = query.Where("entity_type = ?", entity)
+	default:
+		utils.Fail(c, utils.Invalid("entity_type must be post or page"))
+		return
+	}
+
+	var links []models.Link
+	if err := query.Order("entity_type").Order("entity_id").Order("url").Find(&links).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	titles, err := linkTitles(db, links)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	report := make([]BrokenLink, len(links))
+	for i, link := range links {
+		report[i] = BrokenLink{Link: link, Title: titles[link.EntityType][link.EntityID]}
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// MissingAltText is an image attached to a published post without alt text
+type MissingAltText struct {
+	PostID    uint   `json:"post_id"`
+	PostTitle string `json:"post_title"`
+	MediaID   uint   `json:"media_id"`
+	MediaURL  string `json:"media_url"`
+}
+
+// GetAccessibilityReport lists the images attached to published posts that
+// have no alt text, by post. MEDIA_ALT_TEXT decides whether the publish
+// checklist warns about them or blocks publishing; the report lists them either way.
+func GetAccessibilityReport(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	report := []MissingAltText{}
+	if err := db.Table("post_media").
+		Select("posts.id AS post_id, posts.title AS post_title, media.id AS media_id, media.url AS media_url").
+		Joins("JOIN posts ON posts.id = post_media.post_id").
+		Joins("JOIN media ON media.id = post_media.media_id").
+		Where("posts.status = ? AND media.type LIKE ? AND media.scan_status <> ? AND TRIM(media.alt_text) = ''",
+			models.StatusPublished, "image%", models.ScanQuarantined).
+		Order("posts.id").Order("media.id").
+		Scan(&report).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTrashReport lists the unused media the purge policy would delete now,
+// without deleting anything. ?days= previews a retention period other than
+// TRASH_RETENTION_DAYS, and is required when purging is disabled.
+func GetTrashReport(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	policy := trash.Default
+	if c.Query("days") != "" || policy == nil {
+		days, ok := intQuery(c, "days", 0, 36500)
+		if !ok {
+			return
+		}
+		if days == 0 {
+			utils.Fail(c, utils.Invalid("TRASH_RETENTION_DAYS is not set; pass days to preview a retention period"))
+			return
+		}
+		policy = &trash.Policy{RetentionDays: days}
+	}
+
+	report, err := policy.Report(c.Request.Context(), db, time.Now())
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// linkTitles looks up the titles of the posts and pages links appear in,
+// keyed by entity type and ID
+func linkTitles(db *gorm.DB, links []models.Link) (map[string]map[uint]string, error) {
+	ids := make(map[string][]uint)
+	for _, link := range links {
+		ids[link.EntityType] = append(ids[link.EntityType], link.EntityID)
+	}
+
+	titles := make(map[string]map[uint]string, len(ids))
+	for entity, entityIDs := range ids {
+		var rows []struct {
+			ID    uint
+			Title string
+		}
+		model := interface{}(&models.Post{})
+		if entity == "page" {
+			model = &models.Page{}
+		}
+		if err := db.Model(model).Select("id", "title").Where("id IN ?", uniqueIDs(entityIDs)).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		titles[entity] = make(map[uint]string, len(rows))
+		for _, row := range rows {
+			titles[entity][row.ID] = row.Title
+		}
+	}
+	return titles, nil
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"cms-backend/repository"
+	"cms-backend/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// repositoriesKey is the gin context key holding injected repositories
+const repositoriesKey = "repositories"
+
+// UseRepositories is middleware making the free-function handlers after it
+// store records through repos instead of the request's database, for tests
+// and other storage backends. Handler structs take theirs from Deps.
+func UseRepositories(repos *repository.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(repositoriesKey, repos)
+		c.Next()
+	}
+}
+
+// repositoriesFor returns the repositories injected with UseRepositories, or
+// GORM repositories over the request's database
+func repositoriesFor(c *gin.Context) *repository.Repositories {
+	if repos, ok := c.Get(repositoriesKey); ok {
+		return repos.(*repository.Repositories)
+	}
+	return repository.NewGorm(c.MustGet("db").(*gorm.DB))
+}
+
+// writeRepositoryError reports a repository error, naming the missing record
+// for repository.ErrNotFound
+func writeRepositoryError(c *gin.Context, err error, notFound string) {
+	if errors.Is(err, repository.ErrNotFound) {
+		err = utils.NotFound(notFound)
+	}
+	utils.Fail(c, err)
+}
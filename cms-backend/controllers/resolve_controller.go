@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Types of what a path resolves to
+const (
+	ResolvedPost     = "post"
+	ResolvedPage     = "page"
+	ResolvedRedirect = "redirect"
+)
+
+// Resolution tells a frontend what to render for a path: a post, a page, or a
+// redirect to follow. Only the field named by Type is set.
+type Resolution struct {
+	Type     string                    `json:"type"`
+	Post     *serializers.PostResponse `json:"post,omitempty"`
+	Page     *serializers.PageResponse `json:"page,omitempty"`
+	Redirect *models.Redirect          `json:"redirect,omitempty"`
+}
+
+// Resolve answers what a frontend should render for ?path= on the public site.
+// Published posts and pages are matched by their routes (SITEMAP_POST_PATH and
+// SITEMAP_PAGE_PATH), pages under the full path of their parents, then the
+// redirects table is checked. A page reached by an outdated path, such as one
+// whose parent was renamed, resolves to a redirect to its current path.
+func Resolve(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	path, _, _ := strings.Cut(c.Query("path"), "?")
+	if !strings.HasPrefix(path, "/") {
+		utils.Fail(c, utils.Invalid("path must start with /"))
+		return
+	}
+	path = normalizeRedirectPath(path)
+
+	// A page found by slug under another path is redirected to, unless a
+	// redirect in the table says otherwise
+	var moved *models.Redirect
+	for _, route := range contentRoutes {
+		slug, ok := route.match(path)
+		if !ok {
+			continue
+		}
+
+		switch route.table {
+		case "posts":
+			if strings.Contains(slug, "/") {
+				continue
+			}
+			query, ok := preloadPostRelations(c, db.Where("posts.status = ? AND posts.slug = ?", models.StatusPublished, slug))
+			if !ok {
+				return
+			}
+			var post models.Post
+			if err := query.First(&post).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					continue
+				}
+				writeResolveError(c, err)
+				return
+			}
+			response := serializers.Post(auth.Caller{}, post)
+			c.JSON(http.StatusOK, Resolution{Type: ResolvedPost, Post: &response})
+			return
+
+		case "pages":
+			segments := strings.Split(slug, "/")
+			var page models.Page
+			if err := db.Where("pages.status = ? AND pages.slug = ?", models.StatusPublished, segments[len(segments)-1]).
+				First(&page).Error; err != nil {
+				if err == gorm.ErrRecordNotFound {
+					continue
+				}
+				writeResolveError(c, err)
+				return
+			}
+			paths, err := pagePaths(db, []models.Page{page})
+			if err != nil {
+				writeResolveError(c, err)
+				return
+			}
+			if paths[page.ID] == slug {
+				response := serializers.Page(auth.Caller{}, page)
+				c.JSON(http.StatusOK, Resolution{Type: ResolvedPage, Page: &response})
+				return
+			}
+			moved = &models.Redirect{FromPath: path, ToPath: route.path(paths[page.ID]), StatusCode: http.StatusMovedPermanently}
+		}
+	}
+
+	var redirect models.Redirect
+	if err := db.Where("from_path = ?", path).First(&redirect).Error; err == nil {
+		c.JSON(http.StatusOK, Resolution{Type: ResolvedRedirect, Redirect: &redirect})
+		return
+	} else if err != gorm.ErrRecordNotFound {
+		writeResolveError(c, err)
+		return
+	}
+	if moved != nil {
+		c.JSON(http.StatusOK, Resolution{Type: ResolvedRedirect, Redirect: moved})
+		return
+	}
+
+	utils.Fail(c, utils.NotFound("Nothing found at this path"))
+}
+
+// writeResolveError responds to a failed lookup while resolving a path
+func writeResolveError(c *gin.Context, err error) {
+	utils.Fail(c, err)
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/schema"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusChoices are the publication states accepted for posts and pages
+var statusChoices = []interface{}{models.StatusDraft, models.StatusPublished, models.StatusArchived}
+
+// contentSchemas maps each content type to the body its create endpoint binds
+// and the rules its handler checks beyond the struct tags
+var contentSchemas = map[string]struct {
+	body  interface{}
+	rules schema.Rules
+}{
+	"post": {CreatePostRequest{}, schema.Rules{
+		Enums: map[string][]interface{}{"status": statusChoices},
+	}},
+	"page": {CreatePageRequest{}, schema.Rules{
+		Enums: map[string][]interface{}{"status": statusChoices},
+	}},
+	"media": {models.Media{}, schema.Rules{}},
+	"tag":   {models.Tag{}, schema.Rules{}},
+	"series": {SeriesRequest{}, schema.Rules{
+		Required: []string{"title"},
+	}},
+	"playlist": {PlaylistRequest{}, schema.Rules{
+		Required: []string{"name"},
+	}},
+	"distribution_list": {DistributionListRequest{}, schema.Rules{
+		Required: []string{"name"},
+	}},
+	"submission": {SubmissionRequest{}, schema.Rules{}},
+	"webhook": {WebhookRequest{}, schema.Rules{
+		Minimum: map[string]float64{"payload_version": webhooks.PayloadV1},
+		Maximum: map[string]float64{"payload_version": webhooks.LatestPayloadVersion},
+	}},
+}
+
+// GetSchemaTypes lists the content types with a schema
+func GetSchemaTypes(c *gin.Context) {
+	types := make([]string, 0, len(contentSchemas))
+	for name := range contentSchemas {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	c.JSON(http.StatusOK, types)
+}
+
+// GetSchema returns the JSON Schema of a content type's create body, for
+// generating edit forms and client-side validation. It is derived from the
+// structs the server binds, so it follows any change to them.
+func GetSchema(c *gin.Context) {
+	entry, ok := contentSchemas[c.Param("type")]
+	if !ok {
+		utils.Fail(c, utils.NotFound("Unknown content type"))
+		return
+	}
+
+	c.JSON(http.StatusOK, schema.Generate(c.Param("type"), entry.body, entry.rules))
+}
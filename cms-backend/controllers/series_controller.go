@@ -0,0 +1,366 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/egress"
+	"cms-backend/epub"
+	"cms-backend/jobs"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JobTypeEPUBExport is the job type for series EPUB exports
+const JobTypeEPUBExport = "epub_export"
+
+// maxEmbeddedImageSize caps each image downloaded into an EPUB
+const maxEmbeddedImageSize = 10 << 20
+
+// SeriesRequest is the body for creating or updating a series.
+// PostIDs sets the reading order; omit it on update to keep the current posts.
+type SeriesRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	PostIDs     []uint `json:"post_ids"`
+}
+
+// epubExportPayload is stored on the job so the export can be traced
+type epubExportPayload struct {
+	SeriesID uint `json:"series_id"`
+}
+
+// GetSeriesList retrieves all series
+func GetSeriesList(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var series []models.Series
+	if err := db.Order("title").Find(&series).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// GetSeries retrieves a series with its posts in reading order
+func GetSeries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	series, ok := findSeries(c, db)
+	if !ok {
+		return
+	}
+
+	// Posts the caller may not read are left out of the listing
+	caller := auth.CallerFrom(c)
+	if err := loadSeriesPosts(db.Scopes(acl.Readable(caller, acl.EntityPost, "posts")), series); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	series.Posts = serializers.RedactPosts(caller, series.Posts)
+	c.JSON(http.StatusOK, series)
+}
+
+// CreateSeries creates a series from an ordered list of post IDs
+func CreateSeries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	if req.Title == "" {
+		utils.Fail(c, utils.Invalid("Title is required"))
+		return
+	}
+
+	series := models.Series{Title: req.Title, Description: req.Description}
+	saveSeries(c, db, &series, req.PostIDs, http.StatusCreated)
+}
+
+// UpdateSeries updates a series' details and, if given, its posts
+func UpdateSeries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	series, ok := findSeries(c, db)
+	if !ok {
+		return
+	}
+
+	var req SeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	if req.Title != "" {
+		series.Title = req.Title
+	}
+	if req.Description != "" {
+		series.Description = req.Description
+	}
+
+	saveSeries(c, db, series, req.PostIDs, http.StatusOK)
+}
+
+// DeleteSeries deletes a series; its posts are not affected
+func DeleteSeries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	series, ok := findSeries(c, db)
+	if !ok {
+		return
+	}
+
+	// series_posts rows are removed by the ON DELETE CASCADE foreign key
+	if err := db.Delete(series).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Series deleted successfully",
+	})
+}
+
+// ExportSeriesEPUB starts a background job compiling the series into an EPUB.
+// Poll GET /jobs/:id and download the book from the job's download_url.
+func ExportSeriesEPUB(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	series, ok := findSeries(c, db)
+	if !ok {
+		return
+	}
+
+	// The job runs without a caller, so every post in the book is checked now
+	var total, readable int64
+	seriesPosts := func() *gorm.DB {
+		return db.Model(&models.Post{}).
+			Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+			Where("series_posts.series_id = ?", series.ID)
+	}
+	if err := seriesPosts().Count(&total).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := seriesPosts().Scopes(acl.Readable(auth.CallerFrom(c), acl.EntityPost, "posts")).Count(&readable).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if readable != total {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "You do not have read access to every post in this series",
+		})
+		return
+	}
+
+	job, err := jobs.Start(db, auth.CallerFrom(c).KeyID, JobTypeEPUBExport, epubExportPayload{SeriesID: series.ID}, buildSeriesEPUB)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.Header("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	c.JSON(http.StatusAccepted, job)
+}
+
+// saveSeries writes the series and (when postIDs is non-nil) replaces its posts in one transaction
+func saveSeries(c *gin.Context, db *gorm.DB, series *models.Series, postIDs []uint, status int) {
+	caller := auth.CallerFrom(c)
+	readable := acl.Readable(caller, acl.EntityPost, "posts")
+	if postIDs != nil {
+		// Posts the caller may not read count as missing
+		var count int64
+		if err := db.Model(&models.Post{}).Scopes(readable).Where("id IN ?", postIDs).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count != int64(len(postIDs)) {
+			utils.Fail(c, utils.Invalid("post_ids must reference distinct existing posts"))
+			return
+		}
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Save(series).Error; err != nil {
+			return err
+		}
+		if postIDs == nil {
+			return nil
+		}
+
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesPost{}).Error; err != nil {
+			return err
+		}
+		if len(postIDs) == 0 {
+			return nil
+		}
+		entries := make([]models.SeriesPost, len(postIDs))
+		for i, postID := range postIDs {
+			entries[i] = models.SeriesPost{SeriesID: series.ID, PostID: postID, Position: i + 1}
+		}
+		return tx.Create(&entries).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	if err := loadSeriesPosts(db.Scopes(readable), series); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	series.Posts = serializers.RedactPosts(caller, series.Posts)
+	c.JSON(status, series)
+}
+
+// findSeries loads the series named by the :id parameter, writing a 404/500 response on failure
+func findSeries(c *gin.Context, db *gorm.DB) (*models.Series, bool) {
+	var series models.Series
+	if err := db.First(&series, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Series not found"))
+			return nil, false
+		}
+		utils.Fail(c, err)
+		return nil, false
+	}
+	return &series, true
+}
+
+// loadSeriesPosts fills series.Posts in reading order
+func loadSeriesPosts(db *gorm.DB, series *models.Series) error {
+	series.Posts = []models.Post{}
+	return db.Preload("Media").
+		Joins("JOIN series_posts ON series_posts.post_id = posts.id").
+		Where("series_posts.series_id = ?", series.ID).
+		Order("series_posts.position").
+		Find(&series.Posts).Error
+}
+
+// buildSeriesEPUB is the job function behind ExportSeriesEPUB
+func buildSeriesEPUB(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error) {
+	var payload epubExportPayload
+	if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+		return "", err
+	}
+
+	var series models.Series
+	if err := db.First(&series, payload.SeriesID).Error; err != nil {
+		return "", err
+	}
+	// Only published posts out of any embargo go into the book
+	if err := loadSeriesPosts(db.Scopes(acl.Published("posts", true)), &series); err != nil {
+		return "", err
+	}
+	if len(series.Posts) == 0 {
+		return "", fmt.Errorf("series %d has no published posts", series.ID)
+	}
+
+	book := epub.Book{
+		Identifier: fmt.Sprintf("urn:cms:series:%d:%d", series.ID, series.UpdatedAt.Unix()),
+		Title:      series.Title,
+		Modified:   series.UpdatedAt,
+	}
+
+	authors := []string{}
+	seenAuthors := make(map[string]bool)
+	for i, post := range series.Posts {
+		if post.Author != "" && !seenAuthors[post.Author] {
+			seenAuthors[post.Author] = true
+			authors = append(authors, post.Author)
+		}
+
+		chapter := epub.Chapter{Title: post.Title, Body: post.Content}
+		for _, media := range post.Media {
+			if !strings.HasPrefix(media.Type, "image") {
+				continue
+			}
+			// Images that cannot be fetched are left out rather than failing the export
+			image, err := fetchEPUBImage(ctx, media)
+			if err != nil {
+				continue
+			}
+			chapter.Images = append(chapter.Images, image)
+		}
+		book.Chapters = append(book.Chapters, chapter)
+
+		progress((i + 1) * 90 / len(series.Posts))
+	}
+	book.Author = strings.Join(authors, ", ")
+
+	dir, err := jobs.Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("series-%d-job-%d.epub", series.ID, job.ID))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if err := epub.Write(file, book); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	return path, nil
+}
+
+// fetchEPUBImage downloads a media item for embedding in an EPUB
+func fetchEPUBImage(ctx context.Context, media models.Media) (epub.Image, error) {
+	resp, err := egress.Fetch(ctx, media.URL, maxEmbeddedImageSize)
+	if err != nil {
+		return epub.Image{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return epub.Image{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return epub.Image{}, err
+	}
+
+	mediaType := http.DetectContentType(data)
+	if !strings.HasPrefix(mediaType, "image/") {
+		return epub.Image{}, fmt.Errorf("unsupported content type %s", mediaType)
+	}
+	ext := ".img"
+	if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+		ext = extensions[0]
+	}
+
+	return epub.Image{
+		Name:      fmt.Sprintf("media-%d%s", media.ID, ext),
+		MediaType: mediaType,
+		Data:      data,
+	}, nil
+}
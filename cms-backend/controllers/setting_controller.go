@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"cms-backend/cache"
+	"cms-backend/models"
+	"cms-backend/settings"
+	"cms-backend/utils"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// settingName matches valid namespaces and keys, e.g. "site" and "default_locale"
+var settingName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,99}$`)
+
+// SettingRequest is the body for writing a setting
+type SettingRequest struct {
+	// Type is fixed when the setting is created; later writes may omit it
+	Type        string          `json:"type"`
+	Value       json.RawMessage `json:"value" binding:"required"`
+	Description string          `json:"description"`
+}
+
+// GetSettings lists all settings, or those of ?namespace=
+func GetSettings(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	list, err := settings.Default.All(db, c.Query("namespace"))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetSetting retrieves one setting
+func GetSetting(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	setting, ok, err := settings.Default.Get(db, c.Param("namespace"), c.Param("key"))
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if !ok {
+		utils.Fail(c, utils.NotFound("Setting not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, setting)
+}
+
+// PutSetting creates or replaces a setting. A setting keeps the type it was
+// created with, so readers can rely on it.
+func PutSetting(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	namespace, key := c.Param("namespace"), c.Param("key")
+	if !settingName.MatchString(namespace) || !settingName.MatchString(key) {
+		utils.Fail(c, utils.Invalid("Namespace and key must be lowercase letters, digits and underscores"))
+		return
+	}
+
+	var req SettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	var setting models.Setting
+	err := db.Where("namespace = ? AND key = ?", namespace, key).First(&setting).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		utils.Fail(c, err)
+		return
+	}
+	status := http.StatusOK
+	if err == gorm.ErrRecordNotFound {
+		status = http.StatusCreated
+		setting = models.Setting{Namespace: namespace, Key: key, Type: req.Type}
+	}
+
+	switch {
+	case req.Type != "" && !settings.ValidType(req.Type):
+		utils.Fail(c, utils.Invalid("Type must be 'string', 'int', 'float', 'bool' or 'json'"))
+		return
+	case setting.Type == "":
+		utils.Fail(c, utils.Invalid("Type is required for a new setting"))
+		return
+	case req.Type != "" && req.Type != setting.Type:
+		utils.Fail(c, utils.Conflict("Setting is of type "+setting.Type+"; delete it to change its type"))
+		return
+	}
+	if err := settings.Validate(setting.Type, req.Value); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if err := validateCachePolicy(setting, req.Value); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	setting.Value = string(req.Value)
+	if req.Description != "" {
+		setting.Description = req.Description
+	}
+	var write *gorm.DB
+	if status == http.StatusCreated {
+		// A concurrent create of the same setting becomes an update
+		write = db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "namespace"}, {Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value", "description", "updated_at"}),
+		}).Create(&setting)
+	} else {
+		write = db.Save(&setting)
+	}
+	if err := write.Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	settings.Default.Invalidate()
+
+	c.JSON(status, setting)
+}
+
+// validateCachePolicy checks that a setting in the cache namespace is a string
+// the response cache can read, so a typo is refused rather than ignored
+func validateCachePolicy(setting models.Setting, value json.RawMessage) error {
+	if setting.Namespace != cache.SettingsNamespace {
+		return nil
+	}
+	var policy string
+	if setting.Type != settings.TypeString || json.Unmarshal(value, &policy) != nil {
+		return errors.New("cache settings must be strings like \"30s:5m\"")
+	}
+	_, err := cache.ParsePolicy(policy)
+	return err
+}
+
+// DeleteSetting removes a setting; readers fall back to their defaults
+func DeleteSetting(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	result := db.Where("namespace = ? AND key = ?", c.Param("namespace"), c.Param("key")).Delete(&models.Setting{})
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Setting not found"))
+		return
+	}
+	settings.Default.Invalidate()
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Setting deleted successfully",
+	})
+}
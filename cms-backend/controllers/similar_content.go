@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Similarity is measured with pg_trgm trigrams. A title or the start of the
+// content at least similarThreshold alike (1 is identical) makes a post a
+// possible duplicate; only the first similarContentLength characters of
+// content are compared to keep the check quick.
+const (
+	similarThreshold     = 0.5
+	similarContentLength = 2000
+	similarLimit         = 5
+)
+
+// SimilarPost is an existing post that resembles a new one
+type SimilarPost struct {
+	ID         uint    `json:"id"`
+	Title      string  `json:"title"`
+	Slug       string  `json:"slug"`
+	Status     string  `json:"status"`
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicatePostError is the 409 response to a new post that resembles
+// existing ones, listing the closest first
+type DuplicatePostError struct {
+	utils.HTTPError
+	Candidates []SimilarPost `json:"candidates"`
+}
+
+// checkDuplicates writes a 409 response listing similar posts when the
+// request asks for ?check_duplicates=true and any exist
+func checkDuplicates(c *gin.Context, db *gorm.DB, post *models.Post) bool {
+	if c.Query("check_duplicates") != "true" {
+		return true
+	}
+
+	content := truncateString(post.Content, similarContentLength)
+	var candidates []SimilarPost
+	if err := db.Model(&models.Post{}).
+		Select("id, title, slug, status, GREATEST(similarity(title, ?), similarity(left(content, ?), ?)) AS similarity",
+			post.Title, similarContentLength, content).
+		Where("similarity(title, ?) >= ? OR similarity(left(content, ?), ?) >= ?",
+			post.Title, similarThreshold, similarContentLength, content, similarThreshold).
+		Order("similarity DESC").
+		Limit(similarLimit).
+		Scan(&candidates).Error; err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if len(candidates) > 0 {
+		c.JSON(http.StatusConflict, DuplicatePostError{
+			HTTPError: utils.HTTPError{
+				Code:      http.StatusConflict,
+				Message:   "Similar posts already exist",
+				ErrorCode: utils.ErrorCodePossibleDuplicate,
+			},
+			Candidates: candidates,
+		})
+		return false
+	}
+	return true
+}
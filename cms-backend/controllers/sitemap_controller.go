@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/models"
+	"cms-backend/settings"
+	"cms-backend/sitemap"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Settings that override the generated robots.txt
+const (
+	RobotsNamespace = "seo"
+	RobotsKey       = "robots_txt"
+)
+
+// sitemapPartName matches the file names of the sitemaps listed in the index
+var sitemapPartName = regexp.MustCompile(`^(posts|pages)-([1-9][0-9]*)\.xml$`)
+
+// sitemapEntry is the part of a post or page a sitemap needs
+type sitemapEntry struct {
+	ID           uint
+	ParentID     *uint
+	Slug         string
+	CanonicalURL string
+	UpdatedAt    time.Time
+}
+
+// GetSitemap serves /sitemap.xml listing published posts and pages with their
+// last update; posts with a canonical URL on another site are left out. When they do not fit in one sitemap of SITEMAP_PAGE_SIZE URLs
+// (at most 50,000) it is an index of /sitemaps/posts-1.xml, /sitemaps/pages-1.xml
+// and so on instead.
+func GetSitemap(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	size := sitemapPageSize()
+	type sectionStats struct {
+		Count  int64
+		Latest *time.Time
+	}
+	stats := make([]sectionStats, len(contentRoutes))
+	var total int64
+	for i, section := range contentRoutes {
+		if err := db.Table(section.table).Select("COUNT(*) AS count, MAX(updated_at) AS latest").
+			Where("status = ?", models.StatusPublished).Scan(&stats[i]).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		total += stats[i].Count
+	}
+
+	if total <= int64(size) {
+		var urls []sitemap.URL
+		for _, section := range contentRoutes {
+			sectionURLs, err := sitemapURLs(c, db, section, 1, size)
+			if err != nil {
+				utils.Fail(c, err)
+				return
+			}
+			urls = append(urls, sectionURLs...)
+		}
+		writeXML(c, func(buf *bytes.Buffer) error { return sitemap.WriteURLSet(buf, urls) })
+		return
+	}
+
+	// Each listed sitemap is dated by the latest update in its section
+	var sitemaps []sitemap.URL
+	base := requestBaseURL(c)
+	for i, section := range contentRoutes {
+		parts := (stats[i].Count + int64(size) - 1) / int64(size)
+		for part := int64(1); part <= parts; part++ {
+			entry := sitemap.URL{Loc: fmt.Sprintf("%s/sitemaps/%s-%d.xml", base, section.table, part)}
+			if stats[i].Latest != nil {
+				entry.LastMod = *stats[i].Latest
+			}
+			sitemaps = append(sitemaps, entry)
+		}
+	}
+	writeXML(c, func(buf *bytes.Buffer) error { return sitemap.WriteIndex(buf, sitemaps) })
+}
+
+// GetSitemapPart serves one sitemap listed in the sitemap index, such as
+// /sitemaps/posts-2.xml
+func GetSitemapPart(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	match := sitemapPartName.FindStringSubmatch(c.Param("name"))
+	if match == nil {
+		utils.Fail(c, utils.NotFound("Sitemap not found"))
+		return
+	}
+	part, err := strconv.Atoi(match[2])
+	if err != nil {
+		utils.Fail(c, utils.NotFound("Sitemap not found"))
+		return
+	}
+	var section contentRoute
+	for _, s := range contentRoutes {
+		if s.table == match[1] {
+			section = s
+		}
+	}
+
+	urls, err := sitemapURLs(c, db, section, part, sitemapPageSize())
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(urls) == 0 && part > 1 {
+		utils.Fail(c, utils.NotFound("Sitemap not found"))
+		return
+	}
+	writeXML(c, func(buf *bytes.Buffer) error { return sitemap.WriteURLSet(buf, urls) })
+}
+
+// GetRobots serves /robots.txt. The "seo/robots_txt" string setting replaces
+// the default, which keeps crawlers out of the API and points them at the sitemap.
+func GetRobots(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	body := settings.Default.String(db, RobotsNamespace, RobotsKey, "")
+	if body == "" {
+		body = "User-agent: *\nDisallow: /api/\n\nSitemap: " + requestBaseURL(c) + "/sitemap.xml\n"
+	} else if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(body))
+}
+
+// sitemapURLs returns the URLs of one page (1-based) of published content in
+// section, oldest first so existing URLs keep their sitemap as content is added
+func sitemapURLs(c *gin.Context, db *gorm.DB, section contentRoute, part, size int) ([]sitemap.URL, error) {
+	columns := []string{"id", "slug", "updated_at"}
+	if section.table == "pages" {
+		columns = append(columns, "parent_id")
+	} else {
+		columns = append(columns, "canonical_url")
+	}
+	var entries []sitemapEntry
+	if err := db.Table(section.table).Select(columns).
+		Where("status = ?", models.StatusPublished).
+		Order("id").Limit(size).Offset((part - 1) * size).
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	// Pages are listed under the paths of their ancestors
+	slugs := make(map[uint]string, len(entries))
+	if section.table == "pages" {
+		pages := make([]models.Page, len(entries))
+		for i, entry := range entries {
+			pages[i] = models.Page{ID: entry.ID, ParentID: entry.ParentID, Slug: entry.Slug}
+		}
+		var err error
+		if slugs, err = pagePaths(db, pages); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, entry := range entries {
+			slugs[entry.ID] = entry.Slug
+		}
+	}
+
+	base := os.Getenv("SITE_URL")
+	if base == "" {
+		base = requestBaseURL(c)
+	}
+	base = strings.TrimRight(base, "/")
+
+	urls := make([]sitemap.URL, 0, len(entries))
+	for _, entry := range entries {
+		loc := base + section.path(slugs[entry.ID])
+
+		// Republished posts are listed at their canonical URL when it is on
+		// this site, and left to the original site's sitemap otherwise
+		if entry.CanonicalURL != "" {
+			if !strings.HasPrefix(entry.CanonicalURL, base+"/") {
+				continue
+			}
+			loc = entry.CanonicalURL
+		}
+		urls = append(urls, sitemap.URL{Loc: loc, LastMod: entry.UpdatedAt})
+	}
+	return urls, nil
+}
+
+// sitemapPageSize is how many URLs go in one sitemap
+func sitemapPageSize() int {
+	size := envLimit("SITEMAP_PAGE_SIZE", sitemap.MaxURLs)
+	if size > sitemap.MaxURLs {
+		size = sitemap.MaxURLs
+	}
+	return size
+}
+
+// requestBaseURL is the scheme and host the request was made to, honouring
+// X-Forwarded-Proto from a TLS-terminating proxy
+func requestBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// writeXML sends the document written by encode, or a 500 when encoding fails
+func writeXML(c *gin.Context, encode func(buf *bytes.Buffer) error) {
+	var buf bytes.Buffer
+	if err := encode(&buf); err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", buf.Bytes())
+}
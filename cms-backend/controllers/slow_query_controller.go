@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SlowQuery is a statement recorded by pg_stat_statements, with its times in
+// milliseconds
+type SlowQuery struct {
+	Query     string  `json:"query"`
+	Calls     int64   `json:"calls"`
+	TotalTime float64 `json:"total_time_ms"`
+	MeanTime  float64 `json:"mean_time_ms"`
+	Rows      int64   `json:"rows"`
+}
+
+// GetSlowQueries reports the statements run against this database with the
+// highest mean execution time, ?limit= of them (default 20). It needs the
+// pg_stat_statements extension, which Postgres only loads when it is listed
+// in shared_preload_libraries.
+func GetSlowQueries(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	limit, ok := intQuery(c, "limit", 20, 100)
+	if !ok {
+		return
+	}
+
+	var enabled int64
+	if err := db.Raw("SELECT count(*) FROM pg_extension WHERE extname = 'pg_stat_statements'").
+		Scan(&enabled).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if enabled == 0 {
+		c.JSON(http.StatusNotImplemented, utils.HTTPError{
+			Code:    http.StatusNotImplemented,
+			Message: "pg_stat_statements is not enabled; add it to shared_preload_libraries and run CREATE EXTENSION pg_stat_statements",
+		})
+		return
+	}
+
+	queries := []SlowQuery{}
+	if err := db.Raw(`SELECT query, calls, total_exec_time AS total_time, mean_exec_time AS mean_time, rows
+		FROM pg_stat_statements
+		WHERE dbid = (SELECT oid FROM pg_database WHERE datname = current_database())
+		ORDER BY mean_exec_time DESC
+		LIMIT ?`, limit).Scan(&queries).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, queries)
+}
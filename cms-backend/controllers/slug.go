@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	// errInvalidSlug is returned by assignSlug when a requested slug is malformed
+	errInvalidSlug = errors.New("slug may only contain lowercase letters, numbers and single hyphens")
+
+	// errSlugTaken is returned by assignSlug when a requested slug is already used
+	errSlugTaken = errors.New("slug is already in use")
+)
+
+// assignSlug validates an explicitly requested slug, or derives a unique one from
+// the title when none is given. model selects the table; id excludes the record itself.
+func assignSlug(db *gorm.DB, model interface{}, requested, title string, id uint) (string, error) {
+	if requested != "" {
+		if !utils.IsValidSlug(requested) {
+			return "", errInvalidSlug
+		}
+		var count int64
+		query := db.Model(model).Where("slug = ?", requested)
+		if id != 0 {
+			query = query.Where("id <> ?", id)
+		}
+		if err := query.Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count > 0 {
+			return "", errSlugTaken
+		}
+		return requested, nil
+	}
+	return uniqueSlug(db, model, utils.Slugify(title), id)
+}
+
+// uniqueSlug returns base, or base-2, base-3... when base is already taken
+func uniqueSlug(db *gorm.DB, model interface{}, base string, id uint) (string, error) {
+	var taken []string
+	query := db.Model(model).Where("slug = ? OR slug LIKE ?", base, base+"-%")
+	if id != 0 {
+		query = query.Where("id <> ?", id)
+	}
+	if err := query.Pluck("slug", &taken).Error; err != nil {
+		return "", err
+	}
+
+	used := make(map[string]bool, len(taken))
+	for _, slug := range taken {
+		used[slug] = true
+	}
+	if !used[base] {
+		return base, nil
+	}
+	for n := 2; ; n++ {
+		if candidate := fmt.Sprintf("%s-%d", base, n); !used[candidate] {
+			return candidate, nil
+		}
+	}
+}
+
+// writeSlugError responds to an assignSlug failure
+func writeSlugError(c *gin.Context, err error) {
+	switch err {
+	case errInvalidSlug:
+		utils.Fail(c, utils.Invalid(err.Error()))
+	case errSlugTaken:
+		utils.Fail(c, utils.Conflict(err.Error()))
+	default:
+		utils.Fail(c, err)
+	}
+}
@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Stats aggregates content counts for admin dashboards
+type Stats struct {
+	// Posts and Pages count content by status
+	Posts map[string]int64 `json:"posts"`
+	Pages map[string]int64 `json:"pages"`
+
+	PostsPerAuthor  []AuthorStats   `json:"posts_per_author"`
+	Media           MediaStats      `json:"media"`
+	RecentActivity  []ActivityItem  `json:"recent_activity"`
+	CreatedOverTime []CreatedBucket `json:"created_over_time"`
+}
+
+// AuthorStats is the number of posts written by one author
+type AuthorStats struct {
+	Author string `json:"author"`
+	Posts  int64  `json:"posts"`
+}
+
+// MediaStats summarises the media library
+type MediaStats struct {
+	Count      int64            `json:"count"`
+	TotalBytes int64            `json:"total_bytes"`
+	ByType     map[string]int64 `json:"by_type"`
+}
+
+// ActivityItem is a recently created or updated piece of content
+type ActivityItem struct {
+	Type   string    `json:"type"`
+	ID     uint      `json:"id"`
+	Title  string    `json:"title"`
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+// CreatedBucket counts content created in the interval starting at Start
+type CreatedBucket struct {
+	Start time.Time `json:"start"`
+	Posts int64     `json:"posts"`
+	Pages int64     `json:"pages"`
+}
+
+// recentActivityLimit is how many items recent_activity returns
+const recentActivityLimit = 10
+
+// maxStatsBuckets bounds created_over_time so a distant ?since stays cheap
+const maxStatsBuckets = 366
+
+// GetStats returns content statistics. created_over_time covers ?since (RFC 3339,
+// default 30 days ago) to now in ?interval buckets of "day" (default), "week" or "month".
+// Buckets start at midnight in the ?tz= or Accept-Timezone zone, UTC by default.
+func GetStats(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	interval := c.DefaultQuery("interval", "day")
+	if interval != "day" && interval != "week" && interval != "month" {
+		utils.Fail(c, utils.Invalid("interval must be 'day', 'week' or 'month'"))
+		return
+	}
+	location, ok := requestLocation(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now().In(location)
+	since := now.AddDate(0, 0, -30)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil || parsed.After(now) {
+			utils.Fail(c, utils.Invalid("since must be a past RFC 3339 timestamp"))
+			return
+		}
+		since = parsed.In(location)
+	}
+	buckets := statsBuckets(since, now, interval)
+	if len(buckets) > maxStatsBuckets {
+		utils.Fail(c, utils.Invalid("since is too far back for this interval"))
+		return
+	}
+
+	stats, err := collectStats(db, interval, location, buckets)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func collectStats(db *gorm.DB, interval string, location *time.Location, buckets []CreatedBucket) (*Stats, error) {
+	stats := &Stats{PostsPerAuthor: []AuthorStats{}, CreatedOverTime: buckets}
+	var err error
+
+	if stats.Posts, err = countBy(db, &models.Post{}, "status"); err != nil {
+		return nil, err
+	}
+	if stats.Pages, err = countBy(db, &models.Page{}, "status"); err != nil {
+		return nil, err
+	}
+
+	if err := db.Model(&models.Post{}).Select("author, COUNT(*) AS posts").
+		Group("author").Order("posts DESC").Order("author").
+		Scan(&stats.PostsPerAuthor).Error; err != nil {
+		return nil, err
+	}
+
+	var media struct {
+		Count      int64
+		TotalBytes int64
+	}
+	if err := db.Model(&models.Media{}).Select("COUNT(*) AS count, COALESCE(SUM(size), 0) AS total_bytes").
+		Scan(&media).Error; err != nil {
+		return nil, err
+	}
+	stats.Media.Count, stats.Media.TotalBytes = media.Count, media.TotalBytes
+	if stats.Media.ByType, err = countBy(db, &models.Media{}, "type"); err != nil {
+		return nil, err
+	}
+
+	if stats.RecentActivity, err = recentActivity(db); err != nil {
+		return nil, err
+	}
+
+	// Count creations per bucket; the buckets are already in order and gap-free
+	index := make(map[int64]int, len(buckets))
+	for i, bucket := range buckets {
+		index[bucket.Start.Unix()] = i
+	}
+	for _, model := range []any{&models.Post{}, &models.Page{}} {
+		var rows []struct {
+			Bucket time.Time
+			Count  int64
+		}
+		// Truncate the local wall-clock time, then turn it back into an instant
+		if err := db.Model(model).
+			Select("date_trunc(?, created_at AT TIME ZONE ?) AT TIME ZONE ? AS bucket, COUNT(*) AS count",
+				interval, location.String(), location.String()).
+			Where("created_at >= ?", buckets[0].Start).
+			Group("bucket").
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			i, ok := index[row.Bucket.Unix()]
+			if !ok {
+				continue
+			}
+			if _, isPost := model.(*models.Post); isPost {
+				buckets[i].Posts = row.Count
+			} else {
+				buckets[i].Pages = row.Count
+			}
+		}
+	}
+
+	return stats, nil
+}
+
+// countBy returns the number of rows of model per value of column
+func countBy(db *gorm.DB, model any, column string) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	if err := db.Model(model).Select(column + " AS value, COUNT(*) AS count").
+		Group(column).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Value] = row.Count
+	}
+	return counts, nil
+}
+
+// recentActivity merges the latest changes to posts, pages and media
+func recentActivity(db *gorm.DB) ([]ActivityItem, error) {
+	sources := []struct {
+		contentType string
+		model       any
+		title       string
+	}{
+		{"post", &models.Post{}, "title"},
+		{"page", &models.Page{}, "title"},
+		{"media", &models.Media{}, "url"},
+	}
+
+	items := []ActivityItem{}
+	for _, source := range sources {
+		var rows []struct {
+			ID        uint
+			Title     string
+			CreatedAt time.Time
+			UpdatedAt time.Time
+		}
+		if err := db.Model(source.model).Select("id, " + source.title + " AS title, created_at, updated_at").
+			Order("updated_at DESC").Limit(recentActivityLimit).
+			Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			action := "updated"
+			if row.UpdatedAt.Equal(row.CreatedAt) {
+				action = "created"
+			}
+			items = append(items, ActivityItem{
+				Type:   source.contentType,
+				ID:     row.ID,
+				Title:  row.Title,
+				Action: action,
+				At:     row.UpdatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].At.After(items[j].At) })
+	if len(items) > recentActivityLimit {
+		items = items[:recentActivityLimit]
+	}
+	return items, nil
+}
+
+// statsBuckets returns empty buckets from the one containing since up to the one
+// containing now, truncated the way Postgres date_trunc does in the zone of since
+func statsBuckets(since, now time.Time, interval string) []CreatedBucket {
+	start := truncateToInterval(since, interval)
+	var buckets []CreatedBucket
+	for t := start; !t.After(now); t = nextInterval(t, interval) {
+		buckets = append(buckets, CreatedBucket{Start: t})
+		if len(buckets) > maxStatsBuckets {
+			break
+		}
+	}
+	return buckets
+}
+
+func truncateToInterval(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case "week":
+		// Weeks start on Monday
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day
+}
+
+func nextInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "month":
+		return t.AddDate(0, 1, 0)
+	}
+	return t.AddDate(0, 0, 1)
+}
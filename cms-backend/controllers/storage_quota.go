@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StorageUsage is how much of a user's storage quota their imported media
+// takes up. QuotaBytes is nil when the user has no limit.
+type StorageUsage struct {
+	Username   string `json:"username"`
+	UsedBytes  int64  `json:"used_bytes"`
+	QuotaBytes *int64 `json:"quota_bytes"`
+}
+
+// StorageQuotaRequest sets a user's quota; a null quota returns the user to
+// MEDIA_STORAGE_QUOTA_BYTES
+type StorageQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes"`
+}
+
+// GetStorageUsage reports the storage used by the media :username imported
+// and the quota it counts against
+func GetStorageUsage(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var user models.User
+	if err := db.Where("username = ?", c.Param("username")).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("User not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+	used, err := storageUsed(db, user.ID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StorageUsage{Username: user.Username, UsedBytes: used, QuotaBytes: storageQuota(user)})
+}
+
+// PutStorageQuota sets the storage quota of :username, creating the user
+// when they have not imported anything yet. Lowering a quota below what the
+// user already uses keeps their media but refuses further imports.
+func PutStorageQuota(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req StorageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.QuotaBytes != nil && *req.QuotaBytes < 0 {
+		utils.Fail(c, utils.Invalid("quota_bytes cannot be negative"))
+		return
+	}
+
+	user := models.User{Username: c.Param("username")}
+	if err := db.Where("username = ?", user.Username).FirstOrCreate(&user).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	user.StorageQuota = req.QuotaBytes
+	if err := db.Model(&user).Update("storage_quota", user.StorageQuota).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	used, err := storageUsed(db, user.ID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, StorageUsage{Username: user.Username, UsedBytes: used, QuotaBytes: storageQuota(user)})
+}
+
+// checkStorageQuota makes sure size more bytes fit in the caller's quota,
+// answering 413 when they do not, and returns the user the stored file is
+// counted against. Callers without an API key have no quota to count
+// against, so they are refused with a 401.
+func checkStorageQuota(c *gin.Context, size int64) (*uint, bool) {
+	caller := auth.CallerFrom(c)
+	if caller.Anonymous() {
+		c.JSON(http.StatusUnauthorized, utils.HTTPError{
+			Code:      http.StatusUnauthorized,
+			Message:   "An API key is required to store media",
+			ErrorCode: utils.ErrorCodeAuthRequired,
+		})
+		return nil, false
+	}
+	db := c.MustGet("db").(*gorm.DB)
+
+	user := models.User{Username: caller.Name}
+	if err := db.Where("username = ?", user.Username).FirstOrCreate(&user).Error; err != nil {
+		utils.Fail(c, err)
+		return nil, false
+	}
+	quota := storageQuota(user)
+	if quota == nil {
+		return &user.ID, true
+	}
+	used, err := storageUsed(db, user.ID)
+	if err != nil {
+		utils.Fail(c, err)
+		return nil, false
+	}
+	if used+size > *quota {
+		c.JSON(http.StatusRequestEntityTooLarge, utils.HTTPError{
+			Code:      http.StatusRequestEntityTooLarge,
+			Message:   fmt.Sprintf("Storage quota exceeded: %d of %d bytes used, the file needs %d", used, *quota, size),
+			ErrorCode: utils.ErrorCodeQuotaExceeded,
+		})
+		return nil, false
+	}
+	return &user.ID, true
+}
+
+// storageQuota is the user's own quota or MEDIA_STORAGE_QUOTA_BYTES, nil
+// when neither sets one
+func storageQuota(user models.User) *int64 {
+	if user.StorageQuota != nil {
+		return user.StorageQuota
+	}
+	if quota := int64(envLimit("MEDIA_STORAGE_QUOTA_BYTES", 0)); quota > 0 {
+		return &quota
+	}
+	return nil
+}
+
+// storageUsed sums the size of the media a user imported
+func storageUsed(db *gorm.DB, userID uint) (int64, error) {
+	var used int64
+	err := db.Model(&models.Media{}).Where("uploaded_by_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").Scan(&used).Error
+	return used, err
+}
@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/notifications"
+	"cms-backend/readability"
+	"cms-backend/utils"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Submission limits applied per API key when the env vars are not set
+const (
+	defaultSubmissionMaxPending = 3
+	defaultSubmissionDailyLimit = 10
+)
+
+// SubmissionRequest is the body for sending in a submission
+type SubmissionRequest struct {
+	Title   string `json:"title" binding:"required"`
+	Content string `json:"content" binding:"required"`
+
+	// NotifyURL optionally receives a POST when the submission is accepted or rejected
+	NotifyURL string `json:"notify_url"`
+}
+
+// ReviewRequest is the body for accepting or rejecting a submission
+type ReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// GetSubmissions lists the caller's submissions, newest first; reviewers see
+// every submission. ?status= filters by review state.
+func GetSubmissions(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	query := scopeSubmissions(db, caller)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var submissions []models.Submission
+	if err := query.Order("created_at DESC").Order("id DESC").Find(&submissions).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+// GetSubmission retrieves one submission and its review status
+func GetSubmission(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	submission, ok := findSubmission(c, db)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, submission)
+}
+
+// CreateSubmission queues a draft for review. Each API key may have
+// SUBMISSION_MAX_PENDING submissions awaiting review and send
+// SUBMISSION_DAILY_LIMIT per 24 hours; beyond that the response is 429.
+func CreateSubmission(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	var req SubmissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.NotifyURL != "" {
+		if err := egress.PolicyFromEnv().ValidateURL(c.Request.Context(), req.NotifyURL); err != nil {
+			writeURLError(c, err)
+			return
+		}
+	}
+
+	limits := []struct {
+		query   *gorm.DB
+		limit   int
+		message string
+	}{
+		{
+			db.Model(&models.Submission{}).Where("api_key_id = ? AND status = ?", caller.KeyID, models.SubmissionPending),
+			envLimit("SUBMISSION_MAX_PENDING", defaultSubmissionMaxPending),
+			"Too many submissions are awaiting review",
+		},
+		{
+			db.Model(&models.Submission{}).Where("api_key_id = ? AND created_at > ?", caller.KeyID, time.Now().Add(-24*time.Hour)),
+			envLimit("SUBMISSION_DAILY_LIMIT", defaultSubmissionDailyLimit),
+			"Daily submission limit reached",
+		},
+	}
+	for _, limit := range limits {
+		var count int64
+		if err := limit.query.Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count >= int64(limit.limit) {
+			c.JSON(http.StatusTooManyRequests, utils.HTTPError{
+				Code:      http.StatusTooManyRequests,
+				Message:   fmt.Sprintf("%s (limit %d)", limit.message, limit.limit),
+				ErrorCode: utils.ErrorCodeSubmissionLimit,
+			})
+			return
+		}
+	}
+
+	submission := models.Submission{
+		APIKeyID:  caller.KeyID,
+		Submitter: caller.Name,
+		Title:     req.Title,
+		Content:   req.Content,
+		Status:    models.SubmissionPending,
+		NotifyURL: req.NotifyURL,
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if err := tx.Create(&submission).Error; err != nil {
+			return err
+		}
+
+		// Let the reviewers know there is something in the queue
+		reviewers, err := notifications.Reviewers(tx)
+		if err != nil {
+			return err
+		}
+		pending := make([]models.Notification, len(reviewers))
+		for i, reviewer := range reviewers {
+			pending[i] = models.Notification{
+				APIKeyID:   reviewer,
+				Kind:       models.NotificationSubmissionPending,
+				Message:    fmt.Sprintf("%s submitted %q for review", submission.Submitter, submission.Title),
+				EntityType: "submission",
+				EntityID:   submission.ID,
+			}
+		}
+		return notifications.Notify(tx, pending...)
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, submission)
+}
+
+// WithdrawSubmission deletes a submission that has not been reviewed yet
+func WithdrawSubmission(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	submission, ok := findSubmission(c, db)
+	if !ok {
+		return
+	}
+	if submission.Status != models.SubmissionPending {
+		utils.Fail(c, utils.Conflict("Only pending submissions can be withdrawn"))
+		return
+	}
+	if err := db.Delete(&submission).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Submission withdrawn successfully",
+	})
+}
+
+// AcceptSubmission creates a draft post from a pending submission, credited
+// to the submitter, and queues the acceptance notification
+func AcceptSubmission(c *gin.Context) {
+	reviewSubmission(c, models.SubmissionAccepted)
+}
+
+// RejectSubmission rejects a pending submission with a note for the submitter
+func RejectSubmission(c *gin.Context) {
+	reviewSubmission(c, models.SubmissionRejected)
+}
+
+// reviewSubmission moves a pending submission to status; the scheduler sends
+// the notification afterwards
+func reviewSubmission(c *gin.Context, status string) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller := auth.CallerFrom(c)
+	if caller.Role != auth.RoleAdmin && caller.Role != auth.RoleEditor {
+		c.JSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "Only admins and editors can review submissions",
+		})
+		return
+	}
+
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if status == models.SubmissionRejected && req.Note == "" {
+		utils.Fail(c, utils.Invalid("A note explaining the rejection is required"))
+		return
+	}
+
+	submission, ok := findSubmission(c, db)
+	if !ok {
+		return
+	}
+	if submission.Status != models.SubmissionPending {
+		utils.Fail(c, utils.Conflict("Submission has already been "+submission.Status))
+		return
+	}
+
+	now := time.Now()
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if status == models.SubmissionAccepted {
+			post := models.Post{
+				Title:       submission.Title,
+				Content:     submission.Content,
+				ReadingTime: readability.ReadingTime(submission.Content),
+				Author:      submission.Submitter,
+				Status:      models.StatusDraft,
+				Notes:       fmt.Sprintf("From submission %d", submission.ID),
+			}
+			slug, err := assignSlug(tx, &models.Post{}, "", post.Title, 0)
+			if err != nil {
+				writeSlugError(c, err)
+				return errResponded
+			}
+			post.Slug = slug
+			if err := tx.Create(&post).Error; err != nil {
+				return err
+			}
+			if err := recordActivity(tx, c, models.Activity{EntityType: acl.EntityPost, EntityID: post.ID, Action: models.ActivityCreated}); err != nil {
+				return err
+			}
+			submission.PostID = &post.ID
+		}
+
+		// Guard against a concurrent review of the same submission
+		result := tx.Model(&submission).Where("status = ?", models.SubmissionPending).Updates(map[string]interface{}{
+			"status":      status,
+			"review_note": req.Note,
+			"reviewed_at": now,
+			"post_id":     submission.PostID,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			utils.Fail(c, utils.Conflict("Submission has already been reviewed"))
+			return errResponded
+		}
+
+		return notifications.Notify(tx, models.Notification{
+			APIKeyID:   submission.APIKeyID,
+			Kind:       models.NotificationSubmissionReviewed,
+			Message:    fmt.Sprintf("Your submission %q was %s", submission.Title, status),
+			EntityType: "submission",
+			EntityID:   submission.ID,
+		})
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	submission.Status = status
+	submission.ReviewNote = req.Note
+	submission.ReviewedAt = &now
+	c.JSON(http.StatusOK, submission)
+}
+
+// scopeSubmissions limits a query to the submissions the caller may see
+func scopeSubmissions(db *gorm.DB, caller auth.Caller) *gorm.DB {
+	if caller.Role == auth.RoleAdmin || caller.Role == auth.RoleEditor {
+		return db
+	}
+	return db.Where("api_key_id = ?", caller.KeyID)
+}
+
+// findSubmission loads the submission named by the :id parameter, writing a
+// 404 response when it does not exist or belongs to another API key
+func findSubmission(c *gin.Context, db *gorm.DB) (models.Submission, bool) {
+	var submission models.Submission
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return submission, false
+	}
+	if err := scopeSubmissions(db, caller).First(&submission, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Submission not found"))
+			return submission, false
+		}
+		utils.Fail(c, err)
+		return submission, false
+	}
+	return submission, true
+}
+
+// envLimit reads a positive integer limit from the environment
+func envLimit(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		log.Printf("Ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return limit
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/newsletter"
+	"cms-backend/utils"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// confirmResendInterval is how long after a confirmation email another
+// signup for the same address sends none, so signups cannot flood an inbox
+const confirmResendInterval = 10 * time.Minute
+
+// SubscribeRequest is the body for signing up to the newsletter.
+// CaptchaToken is checked when a captcha provider is configured.
+type SubscribeRequest struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	CaptchaToken string `json:"captcha_token"`
+}
+
+// ConfirmSubscriptionRequest is the body for confirming a subscription with
+// the token from the confirmation email
+type ConfirmSubscriptionRequest struct {
+	Token string `json:"token"`
+}
+
+// UnsubscribeRequest is the body for unsubscribing; Token is the address's
+// newsletter.UnsubscribeToken
+type UnsubscribeRequest struct {
+	Email string `json:"email"`
+	Token string `json:"token"`
+}
+
+// Subscribe signs an address up to the newsletter and emails it a link to
+// confirm. The answer is the same whether or not the address was already
+// subscribed, so signups reveal nothing about who is on the list.
+func Subscribe(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req SubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	email := newsletter.NormalizeEmail(req.Email)
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		utils.Fail(c, utils.Invalid("email must be a valid email address"))
+		return
+	}
+	if newsletter.Mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, utils.HTTPError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Newsletter signups are not available",
+		})
+		return
+	}
+	if !verifyCaptcha(c, req.CaptchaToken) {
+		return
+	}
+
+	accepted := gin.H{"message": "Check your inbox to confirm your subscription"}
+	var subscriber models.Subscriber
+	if err := db.Where("email = ?", email).First(&subscriber).Error; err != nil && err != gorm.ErrRecordNotFound {
+		utils.Fail(c, err)
+		return
+	}
+	now := time.Now()
+	if subscriber.Status == models.SubscriberSubscribed ||
+		(subscriber.Status == models.SubscriberPending && subscriber.ConfirmSentAt != nil &&
+			now.Sub(*subscriber.ConfirmSentAt) < confirmResendInterval) {
+		c.JSON(http.StatusAccepted, accepted)
+		return
+	}
+
+	token, err := generateConfirmToken()
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	subscriber.Email = email
+	if req.Name != "" {
+		subscriber.Name = truncateString(req.Name, 255)
+	}
+	subscriber.Status = models.SubscriberPending
+	subscriber.ConfirmTokenHash = auth.HashKey(token)
+	subscriber.ConfirmSentAt = &now
+	if err := db.Save(&subscriber).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	if err := newsletter.SendConfirmation(c.Request.Context(), subscriber, token); err != nil {
+		log.Printf("Sending the confirmation email to subscriber %d failed: %v", subscriber.ID, err)
+		// Let the visitor try again straight away
+		db.Model(&subscriber).UpdateColumn("confirm_sent_at", nil)
+		c.JSON(http.StatusBadGateway, utils.HTTPError{
+			Code:    http.StatusBadGateway,
+			Message: "The confirmation email could not be sent, retry later",
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// ConfirmSubscription completes a signup with the token from its
+// confirmation email, which is valid for newsletter.ConfirmTTL
+func ConfirmSubscription(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req ConfirmSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.Fail(c, utils.Invalid("token is required"))
+		return
+	}
+
+	var subscriber models.Subscriber
+	if err := db.Where("confirm_token_hash = ? AND status = ?", auth.HashKey(req.Token), models.SubscriberPending).
+		First(&subscriber).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Confirmation link is invalid or was already used"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+	now := time.Now()
+	if subscriber.ConfirmSentAt == nil || now.Sub(*subscriber.ConfirmSentAt) > newsletter.ConfirmTTL {
+		c.JSON(http.StatusGone, utils.HTTPError{
+			Code:    http.StatusGone,
+			Message: "Confirmation link has expired, subscribe again",
+		})
+		return
+	}
+
+	if err := db.Model(&subscriber).Updates(map[string]interface{}{
+		"status":             models.SubscriberSubscribed,
+		"confirm_token_hash": "",
+		"confirmed_at":       now,
+		"unsubscribed_at":    nil,
+	}).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subscription confirmed",
+	})
+}
+
+// Unsubscribe removes an address from the newsletter. Unknown addresses
+// succeed too, so a valid link always leads to the same answer.
+func Unsubscribe(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var req UnsubscribeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if _, err := newsletter.UnsubscribeToken(req.Email); err != nil {
+		c.JSON(http.StatusServiceUnavailable, utils.HTTPError{
+			Code:    http.StatusServiceUnavailable,
+			Message: "Unsubscribe links are not available",
+		})
+		return
+	}
+	if !newsletter.CheckUnsubscribeToken(req.Email, req.Token) {
+		utils.Fail(c, utils.Invalid("Unsubscribe link is invalid"))
+		return
+	}
+
+	if err := db.Model(&models.Subscriber{}).
+		Where("email = ? AND status <> ?", newsletter.NormalizeEmail(req.Email), models.SubscriberUnsubscribed).
+		Updates(map[string]interface{}{
+			"status":             models.SubscriberUnsubscribed,
+			"confirm_token_hash": "",
+			"unsubscribed_at":    time.Now(),
+		}).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "You have been unsubscribed",
+	})
+}
+
+// GetSubscribers lists subscribers newest first, optionally only those with
+// ?status=, one page at a time like other feeds
+func GetSubscribers(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query, ok := subscriberQuery(c, db, "")
+	if !ok {
+		return
+	}
+	query, limit, ok := paginate(c, query, "subscribers")
+	if !ok {
+		return
+	}
+	if limit == 0 {
+		limit = defaultPageLimit
+		query = query.Order("subscribers.created_at DESC").Order("subscribers.id DESC").Limit(limit + 1)
+	}
+
+	var subscribers []models.Subscriber
+	if err := query.Find(&subscribers).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(subscribers) > limit {
+		subscribers = subscribers[:limit]
+		last := subscribers[limit-1]
+		c.Header(utils.NextCursorHeader, encodeCursor(last.CreatedAt, last.ID))
+	}
+
+	c.JSON(http.StatusOK, subscribers)
+}
+
+// ExportSubscribers downloads subscribers as CSV, by default only confirmed
+// ones (?status= picks another status). Each row carries the address's
+// unsubscribe token when NEWSLETTER_SECRET is set, for building unsubscribe
+// links in newsletters sent elsewhere.
+func ExportSubscribers(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	query, ok := subscriberQuery(c, db, models.SubscriberSubscribed)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="subscribers.csv"`)
+	c.Status(http.StatusOK)
+
+	out := csv.NewWriter(c.Writer)
+	out.Write([]string{"email", "name", "status", "confirmed_at", "unsubscribed_at", "created_at", "unsubscribe_token"})
+
+	var batch []models.Subscriber
+	err := query.FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+		for _, subscriber := range batch {
+			token, _ := newsletter.UnsubscribeToken(subscriber.Email)
+			row := []string{
+				csvSafe(subscriber.Email),
+				csvSafe(subscriber.Name),
+				subscriber.Status,
+				csvTime(subscriber.ConfirmedAt),
+				csvTime(subscriber.UnsubscribedAt),
+				subscriber.CreatedAt.UTC().Format(time.RFC3339),
+				token,
+			}
+			if err := out.Write(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+	out.Flush()
+
+	// The status is already sent, so a failure can only cut the file short
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		log.Printf("Exporting subscribers failed: %v", err)
+	}
+}
+
+// DeleteSubscriber erases a subscriber, such as on request under data
+// protection law. An address already pushed to the mailing list provider has
+// to be removed there as well.
+func DeleteSubscriber(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	result := db.Delete(&models.Subscriber{}, c.Param("id"))
+	if result.Error != nil {
+		utils.Fail(c, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		utils.Fail(c, utils.NotFound("Subscriber not found"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Subscriber deleted successfully",
+	})
+}
+
+// subscriberQuery filters subscribers by ?status=, or by fallback when it is
+// absent (empty for all), writing a 400 response for an unknown status
+func subscriberQuery(c *gin.Context, db *gorm.DB, fallback string) (*gorm.DB, bool) {
+	status := c.DefaultQuery("status", fallback)
+	switch status {
+	case "":
+		return db, true
+	case models.SubscriberPending, models.SubscriberSubscribed, models.SubscriberUnsubscribed:
+		return db.Where("status = ?", status), true
+	}
+	utils.Fail(c, utils.Invalid("status must be pending, subscribed or unsubscribed"))
+	return nil, false
+}
+
+// csvTime formats an optional time for a CSV cell
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// generateConfirmToken returns a new random confirmation token
+func generateConfirmToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
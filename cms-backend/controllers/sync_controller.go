@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSyncLimit is how many changes GetSync covers without ?limit=
+const defaultSyncLimit = 100
+
+// syncEntityTypes are the entity types synced, in snapshot order
+var syncEntityTypes = []string{"post", "page", "media", "tag", "series"}
+
+// SyncEntity is the current state of a created or updated entity
+type SyncEntity struct {
+	EntityType string      `json:"entity_type"`
+	ID         uint        `json:"id"`
+	Data       interface{} `json:"data"`
+}
+
+// Tombstone marks an entity the client should drop: it was deleted, or posts
+// and pages were unpublished
+type Tombstone struct {
+	EntityType string    `json:"entity_type"`
+	ID         uint      `json:"id"`
+	DeletedAt  time.Time `json:"deleted_at"`
+}
+
+// SyncResponse is one batch of deltas
+type SyncResponse struct {
+	Created []SyncEntity `json:"created"`
+	Updated []SyncEntity `json:"updated"`
+	Deleted []Tombstone  `json:"deleted"`
+
+	// Cursor is presented on the next request
+	Cursor string `json:"cursor"`
+
+	// HasMore is set when further changes are waiting past this batch
+	HasMore bool `json:"has_more"`
+}
+
+// syncCursor is the change feed position a client has synced to, sent
+// base64-encoded so clients treat it as opaque
+type syncCursor struct {
+	Seq int64 `json:"seq"`
+}
+
+func encodeSyncCursor(seq int64) string {
+	encoded, _ := json.Marshal(syncCursor{Seq: seq})
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+func decodeSyncCursor(value string) (int64, bool) {
+	var cur syncCursor
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || json.Unmarshal(raw, &cur) != nil || cur.Seq < 0 {
+		return 0, false
+	}
+	return cur.Seq, true
+}
+
+// GetSync returns what changed since ?cursor=: entities created or updated, with
+// their current state, and tombstones for deleted or unpublished ones. Each
+// entity appears once per batch however often it changed. Without a cursor
+// the response is a snapshot of all content, listed as created. A batch covers
+// up to ?limit= changes (default 100, up to 1000); clients repeat with the
+// returned cursor while has_more is set. Tombstones are kept for the change
+// feed retention period, after which old cursors get 410 and must re-sync.
+func GetSync(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+	caller := auth.CallerFrom(c)
+
+	limit, ok := intQuery(c, "limit", defaultSyncLimit, 1000)
+	if !ok {
+		return
+	}
+
+	value := c.Query("cursor")
+	if value == "" {
+		writeSyncSnapshot(c, db, caller)
+		return
+	}
+	since, ok := decodeSyncCursor(value)
+	if !ok {
+		utils.Fail(c, utils.Invalid("cursor must be a value returned by a previous sync"))
+		return
+	}
+	if !checkChangesRetained(c, db, since) {
+		return
+	}
+
+	// Fetch one extra row to learn whether another batch follows
+	var changes []models.Change
+	if err := db.Where("seq > ?", since).Order("seq").Limit(limit + 1).Find(&changes).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	response := SyncResponse{
+		Created: []SyncEntity{},
+		Updated: []SyncEntity{},
+		Deleted: []Tombstone{},
+		Cursor:  encodeSyncCursor(since),
+	}
+	if len(changes) > limit {
+		changes = changes[:limit]
+		response.HasMore = true
+	}
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, response)
+		return
+	}
+	response.Cursor = encodeSyncCursor(changes[len(changes)-1].Seq)
+
+	// Collapse the changes to one delta per entity
+	type entityKey struct {
+		entityType string
+		id         uint
+	}
+	type delta struct {
+		created bool
+		deleted bool
+		at      time.Time
+	}
+	var order []entityKey
+	deltas := make(map[entityKey]*delta)
+	for _, change := range changes {
+		key := entityKey{change.EntityType, change.EntityID}
+		d, seen := deltas[key]
+		if !seen {
+			d = &delta{created: change.Action == models.ChangeInsert}
+			deltas[key] = d
+			order = append(order, key)
+		}
+		d.deleted = change.Action == models.ChangeDelete
+		d.at = change.ChangedAt
+	}
+
+	ids := make(map[string][]uint)
+	for _, key := range order {
+		if !deltas[key].deleted {
+			ids[key.entityType] = append(ids[key.entityType], key.id)
+		}
+	}
+	current := make(map[entityKey]SyncEntity)
+	for entityType, typeIDs := range ids {
+		entities, err := loadSyncEntities(db, caller, entityType, typeIDs)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		for _, entity := range entities {
+			current[entityKey{entity.EntityType, entity.ID}] = entity
+		}
+	}
+
+	for _, key := range order {
+		d := deltas[key]
+		entity, ok := current[key]
+		switch {
+		case d.deleted || !ok:
+			response.Deleted = append(response.Deleted, Tombstone{EntityType: key.entityType, ID: key.id, DeletedAt: d.at})
+		case d.created:
+			response.Created = append(response.Created, entity)
+		default:
+			response.Updated = append(response.Updated, entity)
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// writeSyncSnapshot responds with every synced entity as created and a cursor
+// at the change feed position read before the snapshot, so changes racing with
+// it are delivered again on the next sync rather than lost
+func writeSyncSnapshot(c *gin.Context, db *gorm.DB, caller auth.Caller) {
+	var seq int64
+	if err := db.Model(&models.Change{}).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	response := SyncResponse{Created: []SyncEntity{}, Updated: []SyncEntity{}, Deleted: []Tombstone{}, Cursor: encodeSyncCursor(seq)}
+	for _, entityType := range syncEntityTypes {
+		entities, err := loadSyncEntities(db, caller, entityType, nil)
+		if err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		response.Created = append(response.Created, entities...)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loadSyncEntities loads the synced entities of a type, limited to ids unless
+// ids is nil. Posts and pages are only synced while published.
+func loadSyncEntities(db *gorm.DB, caller auth.Caller, entityType string, ids []uint) ([]SyncEntity, error) {
+	query := db.Order("id")
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+
+	var entities []SyncEntity
+	switch entityType {
+	case "post":
+		var posts []models.Post
+		if err := query.Preload("Media").Preload("Tags").Where("status = ?", models.StatusPublished).Find(&posts).Error; err != nil {
+			return nil, err
+		}
+		for _, post := range serializers.Posts(caller, posts) {
+			entities = append(entities, SyncEntity{EntityType: entityType, ID: post.ID, Data: post})
+		}
+	case "page":
+		var pages []models.Page
+		if err := query.Where("status = ?", models.StatusPublished).Find(&pages).Error; err != nil {
+			return nil, err
+		}
+		for _, page := range serializers.Pages(caller, pages) {
+			entities = append(entities, SyncEntity{EntityType: entityType, ID: page.ID, Data: page})
+		}
+	case "media":
+		var media []models.Media
+		if err := query.Find(&media).Error; err != nil {
+			return nil, err
+		}
+		for _, item := range media {
+			entities = append(entities, SyncEntity{EntityType: entityType, ID: item.ID, Data: item})
+		}
+	case "tag":
+		var tags []models.Tag
+		if err := query.Find(&tags).Error; err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			entities = append(entities, SyncEntity{EntityType: entityType, ID: tag.ID, Data: tag})
+		}
+	case "series":
+		var series []models.Series
+		if err := query.Find(&series).Error; err != nil {
+			return nil, err
+		}
+		for _, s := range series {
+			entities = append(entities, SyncEntity{EntityType: entityType, ID: s.ID, Data: s})
+		}
+	}
+	return entities, nil
+}
+
+// checkChangesRetained writes a 410 response when changes after since have been
+// pruned by the retention policy, so the client cannot catch up incrementally
+func checkChangesRetained(c *gin.Context, db *gorm.DB, since int64) bool {
+	var pruned int64
+	if err := db.Model(&models.ChangePrune{}).Select("COALESCE(MAX(pruned_through), 0)").Scan(&pruned).Error; err != nil {
+		utils.Fail(c, err)
+		return false
+	}
+	if since < pruned {
+		c.JSON(http.StatusGone, utils.HTTPError{
+			Code:      http.StatusGone,
+			Message:   "Changes since this position are no longer retained; sync again from the start",
+			ErrorCode: utils.ErrorCodeCursorExpired,
+		})
+		return false
+	}
+	return true
+}
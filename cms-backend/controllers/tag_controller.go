@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetTags retrieves all tags ordered by name
+func GetTags(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var tags []models.Tag
+	if err := db.Order("name").Find(&tags).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tags)
+}
+
+// CreateTag creates a new tag
+func CreateTag(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	// Validate required fields
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		utils.Fail(c, utils.Invalid("Name is required"))
+		return
+	}
+
+	if !checkTagParent(c, db, 0, tag.ParentID) {
+		return
+	}
+	if tag.ParentID != nil && *tag.ParentID == 0 {
+		tag.ParentID = nil
+	}
+
+	// Tag names are unique; report a conflict instead of a raw constraint error
+	var count int64
+	if err := db.Model(&models.Tag{}).Where("name = ?", tag.Name).Count(&count).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if count > 0 {
+		utils.Fail(c, utils.Conflict("Tag already exists"))
+		return
+	}
+
+	if err := db.Create(&tag).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, tag)
+}
+
+// TagUpdateRequest is the body of PUT /tags/:id; fields left out are unchanged
+type TagUpdateRequest struct {
+	Name string `json:"name"`
+
+	// ParentID moves the tag under another; 0 moves it to the top level
+	ParentID *uint `json:"parent_id"`
+}
+
+// UpdateTag renames a tag or moves it in the hierarchy. Renaming leaves a
+// redirect from the tag's old path on the public site (TAG_PATH) to its new one.
+func UpdateTag(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	tag, ok := findTag(c, db, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var updateData TagUpdateRequest
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+
+	before := tag
+	if name := strings.TrimSpace(updateData.Name); name != "" && name != tag.Name {
+		var count int64
+		if err := db.Model(&models.Tag{}).Where("name = ? AND id <> ?", name, tag.ID).Count(&count).Error; err != nil {
+			utils.Fail(c, err)
+			return
+		}
+		if count > 0 {
+			utils.Fail(c, utils.Conflict("Tag already exists"))
+			return
+		}
+		tag.Name = name
+	}
+	if updateData.ParentID != nil {
+		if !checkTagParent(c, db, tag.ID, updateData.ParentID) {
+			return
+		}
+		tag.ParentID = updateData.ParentID
+		if *updateData.ParentID == 0 {
+			tag.ParentID = nil
+		}
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		if tag.Name != before.Name {
+			if err := redirectRenamed(tx, tagRoute.path(before.Name), tagRoute.path(tag.Name)); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&tag).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// TagMergeRequest is the body of POST /tags/:id/merge
+type TagMergeRequest struct {
+	// Into is the tag that replaces the merged one
+	Into uint `json:"into" binding:"required"`
+}
+
+// MergeTags merges a tag into another: its posts are tagged with the other
+// tag instead, its subtags move under the other tag, its path on the public
+// site redirects to the other tag's, and the merged tag is deleted.
+func MergeTags(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	source, ok := findTag(c, db, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var req TagMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if req.Into == source.ID {
+		utils.Fail(c, utils.Invalid("A tag cannot be merged into itself"))
+		return
+	}
+
+	var target models.Tag
+	if err := db.First(&target, req.Into).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.Invalid("Tag to merge into not found"))
+			return
+		}
+		utils.Fail(c, err)
+		return
+	}
+
+	// The subtags of the merged tag move under the target, which cannot be one of them
+	nested, err := isSubtag(db, target.ID, source.ID)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if nested {
+		utils.Fail(c, utils.Invalid("A tag cannot be merged into one of its own subtags"))
+		return
+	}
+
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error {
+		// Posts already carrying both tags keep a single one
+		if err := tx.Exec(`INSERT INTO post_tags (post_id, tag_id)
+			SELECT post_id, ? FROM post_tags WHERE tag_id = ?
+			ON CONFLICT DO NOTHING`, target.ID, source.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Tag{}).Where("parent_id = ?", source.ID).Update("parent_id", target.ID).Error; err != nil {
+			return err
+		}
+		if err := redirectRenamed(tx, tagRoute.path(source.Name), tagRoute.path(target.Name)); err != nil {
+			return err
+		}
+		// The merged tag's post_tags rows are removed by the ON DELETE CASCADE foreign key
+		return tx.Delete(&source).Error
+	}); err != nil {
+		writeTransactionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, target)
+}
+
+// DeleteTag deletes a tag and detaches it from all posts. Its subtags move to
+// the top level.
+func DeleteTag(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	tag, ok := findTag(c, db, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	// post_tags rows are removed by the ON DELETE CASCADE foreign key
+	if err := db.Delete(&tag).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag deleted successfully",
+	})
+}
+
+// findTag loads the tag with id, writing a 404/500 response on failure
+func findTag(c *gin.Context, db *gorm.DB, id string) (models.Tag, bool) {
+	var tag models.Tag
+	if err := db.First(&tag, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Tag not found"))
+			return tag, false
+		}
+		utils.Fail(c, err)
+		return tag, false
+	}
+	return tag, true
+}
+
+// errTagNotFound is returned by resolveTags when a referenced tag ID does not exist
+var errTagNotFound = errors.New("one or more tags do not exist")
+
+// TagRef names a tag in a request body: by ID, or by name to find or create it
+type TagRef struct {
+	ID   uint   `json:"id"`
+	Name string `json:"name"`
+}
+
+// resolveTags maps tags from a request body to stored tags. Tags given by ID must
+// exist and are loaded in one query; tags given only by name are looked up and
+// created when missing.
+func resolveTags(db *gorm.DB, input []TagRef) ([]models.Tag, error) {
+	var ids []uint
+	for _, t := range input {
+		if t.ID != 0 {
+			ids = append(ids, t.ID)
+		}
+	}
+	byID := make(map[uint]models.Tag, len(ids))
+	if len(ids) > 0 {
+		var found []models.Tag
+		if err := db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		for _, tag := range found {
+			byID[tag.ID] = tag
+		}
+	}
+
+	tags := make([]models.Tag, 0, len(input))
+	for _, t := range input {
+		var tag models.Tag
+		if t.ID != 0 {
+			var ok bool
+			if tag, ok = byID[t.ID]; !ok {
+				return nil, errTagNotFound
+			}
+		} else {
+			name := strings.TrimSpace(t.Name)
+			if name == "" {
+				continue
+			}
+			if err := db.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// writeTagError responds to a resolveTags failure
+func writeTagError(c *gin.Context, err error) {
+	if err == errTagNotFound {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	utils.Fail(c, err)
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"cms-backend/keywords"
+	"cms-backend/markdown"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSuggestionLimit is how many tags SuggestTags returns without ?limit=
+const defaultSuggestionLimit = 10
+
+// minKeywordCount is how often a term must occur in a post before it is
+// suggested as a new tag
+const minKeywordCount = 2
+
+// TagSuggestion is a candidate tag for a post. TagID is set when the tag
+// already exists. Scores are relative to the best candidate, which scores 1.
+type TagSuggestion struct {
+	Name  string  `json:"name"`
+	TagID *uint   `json:"tag_id"`
+	Score float64 `json:"score"`
+}
+
+// SuggestTags ranks candidate tags for a post by TF-IDF over all posts:
+// existing tags whose words all occur in the post, and frequent distinctive
+// terms that no tag covers yet. Tags already on the post are left out.
+// The title counts twice. ?limit= caps the list (default 10).
+func SuggestTags(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+	limit, ok := intQuery(c, "limit", defaultSuggestionLimit, 50)
+	if !ok {
+		return
+	}
+
+	counts := keywords.Count(suggestionText(post.Title, post.Content, 2))
+	suggestions, err := rankTags(db, post.ID, counts)
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// suggestionText is the text of a post that keywords are taken from, with
+// the title repeated to weigh it
+func suggestionText(title, content string, titleWeight int) string {
+	text := markdown.ToText(content)
+	for i := 0; i < titleWeight; i++ {
+		text = title + "\n" + text
+	}
+	return text
+}
+
+// rankTags scores the candidate tags of a post with the given term counts
+func rankTags(db *gorm.DB, postID uint, counts map[string]int) ([]TagSuggestion, error) {
+	suggestions := []TagSuggestion{}
+	if len(counts) == 0 {
+		return suggestions, nil
+	}
+
+	// Document frequencies of the post's terms across all posts
+	corpus := keywords.NewCorpus(counts)
+	var batch []struct {
+		ID      uint
+		Title   string
+		Content string
+	}
+	if err := db.Model(&models.Post{}).Select("id", "title", "content").
+		FindInBatches(&batch, 200, func(tx *gorm.DB, _ int) error {
+			for _, other := range batch {
+				corpus.Add(suggestionText(other.Title, other.Content, 1))
+			}
+			return nil
+		}).Error; err != nil {
+		return nil, err
+	}
+	scores := corpus.Score(counts)
+
+	var tags []models.Tag
+	if err := db.Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	var attached []uint
+	if err := db.Table("post_tags").Where("post_id = ?", postID).Pluck("tag_id", &attached).Error; err != nil {
+		return nil, err
+	}
+	isAttached := make(map[uint]bool)
+	for _, id := range attached {
+		isAttached[id] = true
+	}
+
+	// Existing tags score the mean of their words; a term a tag covers is
+	// not suggested again as a new tag
+	covered := make(map[string]bool)
+	for i := range tags {
+		terms := keywords.Terms(tags[i].Name)
+		if len(terms) == 0 {
+			continue
+		}
+		total, matched := 0.0, true
+		for _, term := range terms {
+			covered[term] = true
+			score, ok := scores[term]
+			if !ok {
+				matched = false
+			}
+			total += score
+		}
+		if matched && !isAttached[tags[i].ID] {
+			suggestions = append(suggestions, TagSuggestion{Name: tags[i].Name, TagID: &tags[i].ID, Score: total / float64(len(terms))})
+		}
+	}
+	for term, count := range counts {
+		if count >= minKeywordCount && !covered[term] {
+			suggestions = append(suggestions, TagSuggestion{Name: term, Score: scores[term]})
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Name < suggestions[j].Name
+	})
+	if len(suggestions) > 0 {
+		best := suggestions[0].Score
+		for i := range suggestions {
+			suggestions[i].Score = math.Round(suggestions[i].Score/best*1000) / 1000
+		}
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxTagDepth bounds how deeply tags may be nested
+const maxTagDepth = 10
+
+// tagRoute is where a tag's listing lives on the public site, with the tag's
+// name as its {slug}. Renaming or merging tags redirects from its old path.
+var tagRoute = contentRoute{table: "tags", pathEnv: "TAG_PATH", defaultPath: "/tags/{slug}"}
+
+// tagNode is the part of a tag its place in the hierarchy is read from
+type tagNode struct {
+	ID       uint
+	ParentID *uint
+}
+
+// parentTag loads the parent of the tag with id
+func parentTag(db *gorm.DB, id uint) (tagNode, error) {
+	var node tagNode
+	err := db.Model(&models.Tag{}).Select("id", "parent_id").Where("id = ?", id).Take(&node).Error
+	return node, err
+}
+
+// checkTagParent validates the parent requested for tag id (0 for a new tag):
+// it must exist, must not be the tag itself or one of its subtags, and must
+// leave the tag within maxTagDepth. It writes a 400/500 response and returns
+// false when the parent is rejected. A nil or 0 parent is always valid.
+func checkTagParent(c *gin.Context, db *gorm.DB, id uint, parentID *uint) bool {
+	if parentID == nil || *parentID == 0 {
+		return true
+	}
+
+	message := ""
+	current := *parentID
+	for depth := 1; ; depth++ {
+		if current == id {
+			message = "A tag cannot be nested under itself or its own subtags"
+			break
+		}
+		if depth >= maxTagDepth {
+			message = fmt.Sprintf("Tags cannot be nested more than %d levels deep", maxTagDepth)
+			break
+		}
+		node, err := parentTag(db, current)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				message = "Parent tag not found"
+				break
+			}
+			utils.Fail(c, err)
+			return false
+		}
+		if node.ParentID == nil {
+			return true
+		}
+		current = *node.ParentID
+	}
+
+	utils.Fail(c, utils.Invalid(message))
+	return false
+}
+
+// isSubtag reports whether the tag with id is nested, at any depth, under ancestor
+func isSubtag(db *gorm.DB, id, ancestor uint) (bool, error) {
+	current := id
+	for depth := 0; depth < maxTagDepth; depth++ {
+		node, err := parentTag(db, current)
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if node.ParentID == nil {
+			return false, nil
+		}
+		if *node.ParentID == ancestor {
+			return true, nil
+		}
+		current = *node.ParentID
+	}
+	return false, nil
+}
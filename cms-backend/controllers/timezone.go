@@ -0,0 +1,29 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLocation returns the time zone date groupings are localized to: ?tz=
+// or else the Accept-Timezone header, as an IANA name such as Europe/Berlin.
+// Without either it is UTC. It writes a 400 response for unknown zones.
+func requestLocation(c *gin.Context) (*time.Location, bool) {
+	c.Header("Vary", "Accept-Timezone")
+	name := c.Query("tz")
+	if name == "" {
+		name = c.GetHeader("Accept-Timezone")
+	}
+	if name == "" {
+		return time.UTC, true
+	}
+	// "Local" would be the server's own zone, which is what ?tz= avoids
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		utils.Fail(c, utils.Invalid("tz must be an IANA time zone such as Europe/Berlin"))
+		return nil, false
+	}
+	return location, true
+}
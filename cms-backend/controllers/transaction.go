@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errResponded is returned from a transaction that wrote its own error
+// response, rolling it back without another one being written
+var errResponded = errors.New("response already written")
+
+// writeTransactionError reports an error from utils.WithTransaction, unless
+// the transaction already responded
+func writeTransactionError(c *gin.Context, err error) {
+	if errors.Is(err, errResponded) {
+		return
+	}
+	utils.Fail(c, err)
+}
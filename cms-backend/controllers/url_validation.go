@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"cms-backend/egress"
+	"cms-backend/utils"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeURLError responds to a failed egress.ValidateURL check with 422 and a
+// machine-readable error code distinguishing unsafe destinations from bad input
+func writeURLError(c *gin.Context, err error) {
+	code := utils.ErrorCodeInvalidURL
+	if errors.Is(err, egress.ErrBlocked) || errors.Is(err, egress.ErrUnsafeAddress) {
+		code = utils.ErrorCodeUnsafeURL
+	}
+	c.JSON(http.StatusUnprocessableEntity, utils.HTTPError{
+		Code:      http.StatusUnprocessableEntity,
+		Message:   err.Error(),
+		ErrorCode: code,
+	})
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"cms-backend/views"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultPopularDays and defaultPopularLimit apply when GetPopularPosts is not given ?days= or ?limit=
+const (
+	defaultPopularDays  = 7
+	defaultPopularLimit = 10
+)
+
+// PopularPost is a post with its views over the requested window
+type PopularPost struct {
+	Post  serializers.PostResponse `json:"post"`
+	Views int64                    `json:"views"`
+}
+
+// RecordPostView counts a view of a published post. Views are buffered and
+// written in batches, and repeat views by the same client are debounced.
+func RecordPostView(c *gin.Context) {
+	post, ok := findPost(c)
+	if !ok {
+		return
+	}
+	if post.Status != models.StatusPublished {
+		utils.Fail(c, utils.NotFound("Post not found"))
+		return
+	}
+
+	views.Default.Record(post.ID, c.ClientIP()+"|"+c.GetHeader("User-Agent"))
+	c.Status(http.StatusNoContent)
+}
+
+// GetPopularPosts lists published posts by views over the last ?days= (default 7,
+// up to 365), most viewed first, up to ?limit= (default 10, up to 50). Counts
+// lag behind by up to one flush interval. ?include= selects the relations loaded.
+func GetPopularPosts(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	days, ok := intQuery(c, "days", defaultPopularDays, 365)
+	if !ok {
+		return
+	}
+	limit, ok := intQuery(c, "limit", defaultPopularLimit, 50)
+	if !ok {
+		return
+	}
+	query, ok := preloadPostRelations(c, db)
+	if !ok {
+		return
+	}
+
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1-days)
+
+	var counts []struct {
+		PostID uint
+		Views  int64
+	}
+	if err := db.Model(&models.PostView{}).
+		Select("post_views.post_id, SUM(post_views.views) AS views").
+		Joins("JOIN posts ON posts.id = post_views.post_id").
+		Where("post_views.day >= ? AND posts.status = ?", since, models.StatusPublished).
+		Group("post_views.post_id").
+		Order("views DESC").Order("post_views.post_id").
+		Limit(limit).
+		Scan(&counts).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	popular := []PopularPost{}
+	if len(counts) == 0 {
+		c.JSON(http.StatusOK, popular)
+		return
+	}
+
+	ids := make([]uint, len(counts))
+	for i, count := range counts {
+		ids[i] = count.PostID
+	}
+	var posts []models.Post
+	if err := query.Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	byID := make(map[uint]models.Post, len(posts))
+	for _, post := range posts {
+		byID[post.ID] = post
+	}
+
+	caller := auth.CallerFrom(c)
+	for _, count := range counts {
+		if post, ok := byID[count.PostID]; ok {
+			popular = append(popular, PopularPost{Post: serializers.Post(caller, post), Views: count.Views})
+		}
+	}
+	c.JSON(http.StatusOK, popular)
+}
+
+// intQuery parses an optional positive integer query parameter up to max,
+// writing a 400 response when it is invalid
+func intQuery(c *gin.Context, name string, fallback, max int) (int, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 1 || parsed > max {
+		utils.Fail(c, utils.Invalid(name+" must be between 1 and "+strconv.Itoa(max)))
+		return 0, false
+	}
+	return parsed, true
+}
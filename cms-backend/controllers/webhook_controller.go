@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// WebhookRequest is the body for registering or updating a webhook
+type WebhookRequest struct {
+	Name string `json:"name" binding:"required"`
+	URL  string `json:"url" binding:"required"`
+
+	// Filter selects delivered events, e.g. `entity=post AND status=published AND tag=press`
+	Filter string `json:"filter"`
+
+	// PayloadVersion defaults to the latest version for new webhooks and is
+	// unchanged when omitted from an update
+	PayloadVersion int `json:"payload_version"`
+
+	// Template optionally renders a custom JSON body with Go template syntax,
+	// e.g. `{"text": {{json .Event}}}`
+	Template string `json:"template"`
+
+	// Active defaults to true
+	Active *bool `json:"active"`
+}
+
+// WebhookResponse returns a newly registered webhook. The secret is not shown again.
+type WebhookResponse struct {
+	Webhook models.Webhook `json:"webhook"`
+	Secret  string         `json:"secret"`
+}
+
+// GetWebhooks lists the caller's webhooks; admins see every webhook
+func GetWebhooks(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := scopeWebhooks(db, caller).Order("id").Find(&hooks).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hooks)
+}
+
+// CreateWebhook registers a webhook for the caller's API key. It receives
+// changes made after registration.
+func CreateWebhook(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !validateWebhookRequest(c, req) {
+		return
+	}
+
+	secret, err := webhooks.GenerateSecret()
+	if err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	version := req.PayloadVersion
+	if version == 0 {
+		version = webhooks.LatestPayloadVersion
+	}
+	hook := models.Webhook{
+		APIKeyID:       caller.KeyID,
+		Name:           req.Name,
+		URL:            req.URL,
+		Filter:         req.Filter,
+		PayloadVersion: version,
+		Template:       req.Template,
+		Secret:         secret,
+		Active:         req.Active == nil || *req.Active,
+	}
+
+	// Start from the current end of the change feed
+	if err := db.Model(&models.Change{}).Select("COALESCE(MAX(seq), 0)").Scan(&hook.LastSeq).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+	if err := db.Create(&hook).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, WebhookResponse{Webhook: hook, Secret: secret})
+}
+
+// UpdateWebhook changes a webhook's name, URL, filter, payload format or active state
+func UpdateWebhook(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	hook, ok := findWebhook(c, db)
+	if !ok {
+		return
+	}
+
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Fail(c, utils.Invalid(err.Error()))
+		return
+	}
+	if !validateWebhookRequest(c, req) {
+		return
+	}
+
+	hook.Name = req.Name
+	hook.URL = req.URL
+	hook.Filter = req.Filter
+	hook.Template = req.Template
+	if req.PayloadVersion != 0 {
+		hook.PayloadVersion = req.PayloadVersion
+	}
+	if req.Active != nil {
+		hook.Active = *req.Active
+	}
+	if err := db.Save(&hook).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, hook)
+}
+
+// DeleteWebhook removes a webhook
+func DeleteWebhook(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	hook, ok := findWebhook(c, db)
+	if !ok {
+		return
+	}
+	if err := db.Delete(&hook).Error; err != nil {
+		utils.Fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+	})
+}
+
+// TestWebhook sends a signed sample payload to a webhook and reports how the
+// endpoint responded. The webhook's position in the change feed is unaffected.
+func TestWebhook(c *gin.Context) {
+	// Get database instance from context
+	db := c.MustGet("db").(*gorm.DB)
+
+	hook, ok := findWebhook(c, db)
+	if !ok {
+		return
+	}
+
+	result := webhooks.Deliver(c.Request.Context(), hook, webhooks.SamplePayload(time.Now()))
+	c.JSON(http.StatusOK, result)
+}
+
+// requireAPIKey returns the caller, writing a 401 response for anonymous callers
+func requireAPIKey(c *gin.Context) (auth.Caller, bool) {
+	caller := auth.CallerFrom(c)
+	if caller.Anonymous() {
+		c.JSON(http.StatusUnauthorized, utils.HTTPError{
+			Code:    http.StatusUnauthorized,
+			Message: "An API key is required",
+		})
+		return caller, false
+	}
+	return caller, true
+}
+
+// scopeWebhooks limits a query to the webhooks the caller manages
+func scopeWebhooks(db *gorm.DB, caller auth.Caller) *gorm.DB {
+	if caller.Role == auth.RoleAdmin {
+		return db
+	}
+	return db.Where("api_key_id = ?", caller.KeyID)
+}
+
+// findWebhook loads the webhook named by the :id parameter, writing a 404
+// response when it does not exist or belongs to another API key
+func findWebhook(c *gin.Context, db *gorm.DB) (models.Webhook, bool) {
+	var hook models.Webhook
+	caller, ok := requireAPIKey(c)
+	if !ok {
+		return hook, false
+	}
+	if err := scopeWebhooks(db, caller).First(&hook, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			utils.Fail(c, utils.NotFound("Webhook not found"))
+			return hook, false
+		}
+		utils.Fail(c, err)
+		return hook, false
+	}
+	return hook, true
+}
+
+// validateWebhookRequest checks the URL against the egress policy, the filter,
+// the payload version and the template, writing an error response when any is invalid
+func validateWebhookRequest(c *gin.Context, req WebhookRequest) bool {
+	if err := egress.PolicyFromEnv().ValidateURL(c.Request.Context(), req.URL); err != nil {
+		writeURLError(c, err)
+		return false
+	}
+	if _, err := webhooks.ParseFilter(req.Filter); err != nil {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   err.Error(),
+			ErrorCode: utils.ErrorCodeInvalidFilter,
+		})
+		return false
+	}
+	if req.PayloadVersion != 0 && !webhooks.ValidVersion(req.PayloadVersion) {
+		utils.Fail(c, utils.Invalid(fmt.Sprintf("payload_version must be between 1 and %d", webhooks.LatestPayloadVersion)))
+		return false
+	}
+	if err := webhooks.ValidateTemplate(req.Template); err != nil {
+		c.JSON(http.StatusBadRequest, utils.HTTPError{
+			Code:      http.StatusBadRequest,
+			Message:   err.Error(),
+			ErrorCode: utils.ErrorCodeInvalidTemplate,
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package dam
+
+import (
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// cloudinaryMaxAge is how old a Cloudinary notification may be, which bounds
+// how long a captured one can be replayed
+const cloudinaryMaxAge = time.Hour
+
+// Cloudinary handles Cloudinary upload and delete notifications. They are
+// signed with the account's API secret.
+type Cloudinary struct {
+	Secret string
+}
+
+// cloudinaryNotification holds the fields used from upload and delete notifications
+type cloudinaryNotification struct {
+	NotificationType string `json:"notification_type"`
+	PublicID         string `json:"public_id"`
+	SecureURL        string `json:"secure_url"`
+	ResourceType     string `json:"resource_type"`
+	Format           string `json:"format"`
+	Bytes            int64  `json:"bytes"`
+	Width            int    `json:"width"`
+	Height           int    `json:"height"`
+	Resources        []struct {
+		PublicID string `json:"public_id"`
+	} `json:"resources"`
+}
+
+// Verify checks X-Cld-Signature, the hex SHA-1 of the body, X-Cld-Timestamp
+// and the API secret, and that the timestamp is recent
+func (a *Cloudinary) Verify(header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Cld-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignature
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > cloudinaryMaxAge || age < -cloudinaryMaxAge {
+		return ErrSignature
+	}
+
+	sum := sha1.Sum([]byte(string(body) + timestamp + a.Secret))
+	expected := hex.EncodeToString(sum[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(header.Get("X-Cld-Signature"))) != 1 {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Events maps "upload" notifications to an added or replaced asset and
+// "delete" notifications to deletions, keyed by public ID
+func (a *Cloudinary) Events(body []byte) ([]Event, error) {
+	var notification cloudinaryNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, err
+	}
+
+	switch notification.NotificationType {
+	case "upload":
+		if notification.PublicID == "" || notification.SecureURL == "" {
+			return nil, errors.New("upload notification without public_id or secure_url")
+		}
+		return []Event{{Asset: Asset{
+			ID:     notification.PublicID,
+			URL:    notification.SecureURL,
+			Type:   cloudinaryType(notification.ResourceType, notification.Format),
+			Size:   notification.Bytes,
+			Width:  notification.Width,
+			Height: notification.Height,
+		}}}, nil
+	case "delete":
+		var events []Event
+		for _, resource := range notification.Resources {
+			if resource.PublicID != "" {
+				events = append(events, Event{Asset: Asset{ID: resource.PublicID}, Deleted: true})
+			}
+		}
+		return events, nil
+	}
+	return nil, nil
+}
+
+// cloudinaryType derives a MIME type from a resource type, such as "image",
+// and a format, such as "png"
+func cloudinaryType(resourceType, format string) string {
+	if format != "" {
+		if mediaType, _, err := mime.ParseMediaType(mime.TypeByExtension("." + format)); err == nil {
+			return mediaType
+		}
+	}
+	if (resourceType == "image" || resourceType == "video") && format != "" {
+		return resourceType + "/" + format
+	}
+	return "application/octet-stream"
+}
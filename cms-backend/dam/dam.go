@@ -0,0 +1,60 @@
+// Package dam receives webhooks from external digital asset managers so media
+// managed there is mirrored in the media library. Each asset manager has an
+// Adapter that checks a webhook's signature and maps its payload to asset
+// changes; they are enabled by configuring their secrets.
+package dam
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+)
+
+// ErrSignature is returned by Verify for webhooks the asset manager did not send
+var ErrSignature = errors.New("invalid webhook signature")
+
+// Asset is a file held by an asset manager. Type is its MIME type.
+type Asset struct {
+	ID     string
+	URL    string
+	Type   string
+	Size   int64
+	Width  int
+	Height int
+}
+
+// Event is a change to an asset: it was added or updated, or with Deleted
+// removed. Deletions only carry the asset's ID.
+type Event struct {
+	Asset   Asset
+	Deleted bool
+}
+
+// Adapter understands the webhooks of one asset manager
+type Adapter interface {
+	// Verify checks that a webhook was sent by the asset manager
+	Verify(header http.Header, body []byte, now time.Time) error
+
+	// Events maps a webhook's payload to asset changes. Notifications about
+	// anything else give no events.
+	Events(body []byte) ([]Event, error)
+}
+
+// Default holds the configured adapters by the name used in the webhook URL.
+// It is set once at startup.
+var Default = map[string]Adapter{}
+
+// FromEnv returns the adapters whose secrets are configured:
+// CLOUDINARY_API_SECRET enables "cloudinary", and DAM_WEBHOOK_SECRET enables
+// "generic" for any other asset manager that can post the Generic format.
+func FromEnv() map[string]Adapter {
+	adapters := map[string]Adapter{}
+	if secret := os.Getenv("CLOUDINARY_API_SECRET"); secret != "" {
+		adapters["cloudinary"] = &Cloudinary{Secret: secret}
+	}
+	if secret := os.Getenv("DAM_WEBHOOK_SECRET"); secret != "" {
+		adapters["generic"] = &Generic{Secret: secret}
+	}
+	return adapters
+}
@@ -0,0 +1,75 @@
+package dam
+
+import (
+	"cms-backend/webhooks"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Generic handles webhooks in this server's own format, for asset managers,
+// such as Bynder, whose webhooks can be relayed or reshaped into it:
+//
+//	{"event": "asset.updated", "asset": {"id": "...", "url": "...", "type": "image/png",
+//	 "size": 1024, "width": 800, "height": 600}}
+//
+// "asset.created" is treated like "asset.updated", and "asset.deleted" only
+// needs the asset's id. Bodies are signed like the server's own outgoing
+// webhooks: X-Webhook-Signature is "sha256=" and the hex HMAC-SHA256 of the
+// body keyed with the secret.
+type Generic struct {
+	Secret string
+}
+
+// genericPayload is the body of a Generic webhook
+type genericPayload struct {
+	Event string `json:"event"`
+	Asset struct {
+		ID     string `json:"id"`
+		URL    string `json:"url"`
+		Type   string `json:"type"`
+		Size   int64  `json:"size"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	} `json:"asset"`
+}
+
+// Verify checks X-Webhook-Signature
+func (a *Generic) Verify(header http.Header, body []byte, now time.Time) error {
+	expected := webhooks.Sign(a.Secret, body)
+	if !hmac.Equal([]byte(expected), []byte(header.Get(webhooks.SignatureHeader))) {
+		return ErrSignature
+	}
+	return nil
+}
+
+// Events maps the payload's event to one asset change
+func (a *Generic) Events(body []byte) ([]Event, error) {
+	var payload genericPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Asset.ID == "" {
+		return nil, errors.New("asset.id is required")
+	}
+
+	switch payload.Event {
+	case "asset.created", "asset.updated":
+		if payload.Asset.URL == "" || payload.Asset.Type == "" {
+			return nil, errors.New("asset.url and asset.type are required")
+		}
+		return []Event{{Asset: Asset{
+			ID:     payload.Asset.ID,
+			URL:    payload.Asset.URL,
+			Type:   payload.Asset.Type,
+			Size:   payload.Asset.Size,
+			Width:  payload.Asset.Width,
+			Height: payload.Asset.Height,
+		}}}, nil
+	case "asset.deleted":
+		return []Event{{Asset: Asset{ID: payload.Asset.ID}, Deleted: true}}, nil
+	}
+	return nil, nil
+}
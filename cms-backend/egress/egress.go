@@ -0,0 +1,391 @@
+// Package egress provides the HTTP client for all outbound calls. It honours
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY and enforces an allow/deny policy so user
+// supplied URLs cannot be used to reach hosts the operator has not permitted.
+// Responses are capped in size as well as time, so a remote host cannot tie
+// up the server by streaming an endless body.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrBlocked is returned when a destination is rejected by the egress policy
+	ErrBlocked = errors.New("destination blocked by egress policy")
+
+	// ErrUnsafeAddress is returned when a destination resolves to a private,
+	// loopback, link-local or otherwise reserved address
+	ErrUnsafeAddress = errors.New("destination resolves to a non-public address")
+
+	// ErrTooLarge is returned when reading a response body past its size cap
+	ErrTooLarge = errors.New("response exceeds the size limit")
+)
+
+// LookupIP resolves hostnames for ValidateURL; tests replace it to avoid DNS
+var LookupIP = net.DefaultResolver.LookupIPAddr
+
+// reservedRanges are non-public ranges not covered by the net.IP helpers
+var reservedRanges = parseCIDRs(
+	"0.0.0.0/8",       // "this" network
+	"100.64.0.0/10",   // carrier-grade NAT
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // TEST-NET-1
+	"198.18.0.0/15",   // benchmarking
+	"198.51.100.0/24", // TEST-NET-2
+	"203.0.113.0/24",  // TEST-NET-3
+	"240.0.0.0/4",     // reserved
+	"64:ff9b::/96",    // NAT64, may map to internal IPv4
+	"2001:db8::/32",   // documentation
+)
+
+// maxRedirects caps redirect chains followed by the client
+const maxRedirects = 5
+
+// defaultMaxResponseBytes caps response bodies when EGRESS_MAX_RESPONSE_BYTES
+// is not set
+const defaultMaxResponseBytes = 50 << 20
+
+// Policy decides which destinations outbound requests may reach.
+// Rules are hostnames (matching the host and its subdomains), IPs or CIDR ranges.
+type Policy struct {
+	Allow []string
+	Deny  []string
+
+	// AllowPrivate permits non-public addresses, e.g. for local development
+	AllowPrivate bool
+}
+
+// PolicyFromEnv reads comma-separated rules from EGRESS_ALLOWLIST and EGRESS_DENYLIST.
+// An empty allowlist permits any destination not denied. Private addresses are
+// refused unless EGRESS_ALLOW_PRIVATE=true.
+func PolicyFromEnv() Policy {
+	return Policy{
+		Allow:        splitRules(os.Getenv("EGRESS_ALLOWLIST")),
+		Deny:         splitRules(os.Getenv("EGRESS_DENYLIST")),
+		AllowPrivate: os.Getenv("EGRESS_ALLOW_PRIVATE") == "true",
+	}
+}
+
+// ValidateURL checks a user-supplied URL before it is stored or fetched: the
+// scheme and host must pass the policy and every address the host resolves to
+// must be public.
+func (p Policy) ValidateURL(ctx context.Context, raw string) error {
+	if err := p.CheckURL(raw); err != nil {
+		return err
+	}
+	u, _ := url.Parse(raw)
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in URL", ErrBlocked)
+	}
+	if p.AllowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip)
+	}
+	addrs, err := LookupIP(ctx, host)
+	if err != nil {
+		return fmt.Errorf("cannot resolve %s: %v", host, err)
+	}
+	for _, addr := range addrs {
+		if err := p.checkIP(addr.IP); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckURL validates the scheme and host of a URL against the policy
+func (p Policy) CheckURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: unsupported scheme %q", ErrBlocked, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("invalid URL: missing host")
+	}
+	return p.CheckHost(u.Hostname())
+}
+
+// CheckHost validates a hostname or IP literal against the policy. Deny rules win.
+func (p Policy) CheckHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, rule := range p.Deny {
+		if matches(rule, host) {
+			return fmt.Errorf("%w: %s", ErrBlocked, host)
+		}
+	}
+	if len(p.Allow) == 0 {
+		return nil
+	}
+	for _, rule := range p.Allow {
+		if matches(rule, host) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not allowlisted", ErrBlocked, host)
+}
+
+// checkIP applies the IP and CIDR deny rules and the private address check to a
+// resolved address. Hostname rules were already enforced on the request.
+func (p Policy) checkIP(ip net.IP) error {
+	for _, rule := range p.Deny {
+		if matchesIP(rule, ip) {
+			return fmt.Errorf("%w: %s", ErrBlocked, ip)
+		}
+	}
+	if !p.AllowPrivate && !IsPublicIP(ip) {
+		return fmt.Errorf("%w: %s", ErrUnsafeAddress, ip)
+	}
+	return nil
+}
+
+// IsPublicIP reports whether ip is a globally routable unicast address
+func IsPublicIP(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, network := range reservedRanges {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxResponseBytes is the largest response body the client reads, set by
+// EGRESS_MAX_RESPONSE_BYTES
+func MaxResponseBytes() int64 {
+	if value, err := strconv.ParseInt(os.Getenv("EGRESS_MAX_RESPONSE_BYTES"), 10, 64); err == nil && value > 0 {
+		return value
+	}
+	return defaultMaxResponseBytes
+}
+
+// NewClient returns an HTTP client that uses the proxy environment variables and
+// enforces the policy on the initial request, every redirect and every dialled
+// address. Response bodies are cut off with ErrTooLarge past
+// MaxResponseBytes. Connections to the configured proxy itself are not checked, since
+// operators commonly run it on a private network.
+func NewClient(policy Policy, timeout time.Duration) *http.Client {
+	proxies := proxyAddresses()
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	dialer.Control = func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return policy.checkIP(ip)
+		}
+		return nil
+	}
+	dial := func(ctx context.Context, network, address string) (net.Conn, error) {
+		if proxies[address] {
+			return (&net.Dialer{Timeout: dialer.Timeout}).DialContext(ctx, network, address)
+		}
+		return dialer.DialContext(ctx, network, address)
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dial,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          20,
+		IdleConnTimeout:       90 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &policyTransport{policy: policy, maxBytes: MaxResponseBytes(), next: transport},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			// Each hop is resolved and checked so redirects cannot reach internal hosts
+			return policy.ValidateURL(req.Context(), req.URL.String())
+		},
+	}
+}
+
+// Default returns a client using the environment policy and a 10 second timeout
+func Default() *http.Client {
+	return NewClient(PolicyFromEnv(), 10*time.Second)
+}
+
+// policyTransport checks each request's URL before it is sent and caps the
+// size of each response
+type policyTransport struct {
+	policy   Policy
+	maxBytes int64
+	next     http.RoundTripper
+}
+
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.policy.CheckURL(req.URL.String()); err != nil {
+		return nil, err
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := limitBody(resp, t.maxBytes); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Get is a convenience wrapper performing a GET with the default client
+func Get(ctx context.Context, raw string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	return Default().Do(req)
+}
+
+// Fetch validates a user-supplied URL and GETs it with the default client,
+// for features that download what users point them at. Reading more than
+// maxBytes of the body fails with ErrTooLarge, as does a declared length
+// over it; maxBytes of 0 leaves only the client's own cap.
+func Fetch(ctx context.Context, raw string, maxBytes int64) (*http.Response, error) {
+	if err := PolicyFromEnv().ValidateURL(ctx, raw); err != nil {
+		return nil, err
+	}
+	resp, err := Get(ctx, raw)
+	if err != nil {
+		return nil, err
+	}
+	if err := limitBody(resp, maxBytes); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// limitBody makes reads of resp's body fail with ErrTooLarge past maxBytes,
+// closing it and returning ErrTooLarge at once when the declared length is
+// already over. A maxBytes of 0 or less leaves the body as it is.
+func limitBody(resp *http.Response, maxBytes int64) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+	if resp.ContentLength > maxBytes {
+		resp.Body.Close()
+		return fmt.Errorf("%w: %d bytes declared, limit %d", ErrTooLarge, resp.ContentLength, maxBytes)
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: maxBytes}
+	return nil
+}
+
+// limitedBody is a response body that errors once more than its limit is read
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	// One byte past the limit is read to tell a body of exactly the limit
+	// from a longer one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), ErrTooLarge
+	}
+	return n, err
+}
+
+// proxyAddresses returns the host:port of each proxy named in the environment
+func proxyAddresses() map[string]bool {
+	addresses := make(map[string]bool)
+	for _, key := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "://") {
+			value = "http://" + value
+		}
+		u, err := url.Parse(value)
+		if err != nil || u.Hostname() == "" {
+			continue
+		}
+		port := u.Port()
+		if port == "" {
+			port = "80"
+			if u.Scheme == "https" {
+				port = "443"
+			}
+		}
+		addresses[net.JoinHostPort(u.Hostname(), port)] = true
+	}
+	return addresses
+}
+
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+func splitRules(value string) []string {
+	var rules []string
+	for _, rule := range strings.Split(value, ",") {
+		if rule = strings.ToLower(strings.TrimSpace(rule)); rule != "" {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matches reports whether a host matches a hostname, IP or CIDR rule
+func matches(rule, host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return matchesIP(rule, ip)
+	}
+	rule = strings.TrimPrefix(rule, "*.")
+	return host == rule || strings.HasSuffix(host, "."+rule)
+}
+
+// matchesIP reports whether an address matches an IP or CIDR rule
+func matchesIP(rule string, ip net.IP) bool {
+	if _, network, err := net.ParseCIDR(rule); err == nil {
+		return network.Contains(ip)
+	}
+	if ruleIP := net.ParseIP(rule); ruleIP != nil {
+		return ruleIP.Equal(ip)
+	}
+	return false
+}
@@ -0,0 +1,183 @@
+package epub
+
+import (
+	"archive/zip"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// Book is an EPUB 3 document made of ordered chapters
+type Book struct {
+	Identifier string
+	Title      string
+	Author     string
+	Language   string
+	Modified   time.Time
+	Chapters   []Chapter
+}
+
+// Chapter is one XHTML document in the book's spine
+type Chapter struct {
+	Title  string
+	Body   string
+	Images []Image
+}
+
+// Image is a file embedded in the book and shown at the end of its chapter
+type Image struct {
+	Name      string
+	MediaType string
+	Data      []byte
+}
+
+// Write encodes the book as an EPUB 3 container
+func Write(w io.Writer, book Book) error {
+	if book.Language == "" {
+		book.Language = "en"
+	}
+	if book.Modified.IsZero() {
+		book.Modified = time.Now()
+	}
+
+	zw := zip.NewWriter(w)
+
+	// The mimetype entry must come first and be stored uncompressed
+	mimetype, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, "application/epub+zip"); err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"META-INF/container.xml", []byte(containerXML)},
+		{"OEBPS/content.opf", []byte(packageDocument(book))},
+		{"OEBPS/nav.xhtml", []byte(navDocument(book))},
+	}
+	for i, chapter := range book.Chapters {
+		files = append(files, struct {
+			name    string
+			content []byte
+		}{fmt.Sprintf("OEBPS/chapter-%d.xhtml", i+1), []byte(chapterDocument(chapter))})
+		for _, image := range chapter.Images {
+			files = append(files, struct {
+				name    string
+				content []byte
+			}{"OEBPS/images/" + image.Name, image.Data})
+		}
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(file.content); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+const containerXML = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+func packageDocument(book Book) string {
+	var manifest, spine strings.Builder
+	manifest.WriteString(`    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>` + "\n")
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&manifest, `    <item id="chapter-%d" href="chapter-%d.xhtml" media-type="application/xhtml+xml"/>`+"\n", i+1, i+1)
+		fmt.Fprintf(&spine, `    <itemref idref="chapter-%d"/>`+"\n", i+1)
+		for j, image := range chapter.Images {
+			fmt.Fprintf(&manifest, `    <item id="image-%d-%d" href="images/%s" media-type="%s"/>`+"\n",
+				i+1, j+1, html.EscapeString(image.Name), html.EscapeString(image.MediaType))
+		}
+	}
+
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="book-id">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+    <dc:identifier id="book-id">%s</dc:identifier>
+    <dc:title>%s</dc:title>
+    <dc:creator>%s</dc:creator>
+    <dc:language>%s</dc:language>
+    <meta property="dcterms:modified">%s</meta>
+  </metadata>
+  <manifest>
+%s  </manifest>
+  <spine>
+%s  </spine>
+</package>
+`,
+		html.EscapeString(book.Identifier),
+		html.EscapeString(book.Title),
+		html.EscapeString(book.Author),
+		html.EscapeString(book.Language),
+		book.Modified.UTC().Format("2006-01-02T15:04:05Z"),
+		manifest.String(),
+		spine.String(),
+	)
+}
+
+func navDocument(book Book) string {
+	var items strings.Builder
+	for i, chapter := range book.Chapters {
+		fmt.Fprintf(&items, `      <li><a href="chapter-%d.xhtml">%s</a></li>`+"\n", i+1, html.EscapeString(chapter.Title))
+	}
+	return xhtml(book.Title, fmt.Sprintf(`  <nav epub:type="toc" id="toc">
+    <h1>%s</h1>
+    <ol>
+%s    </ol>
+  </nav>
+`, html.EscapeString(book.Title), items.String()))
+}
+
+func chapterDocument(chapter Chapter) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "  <h1>%s</h1>\n", html.EscapeString(chapter.Title))
+
+	// Blank lines separate paragraphs; single newlines become line breaks
+	for _, paragraph := range strings.Split(strings.ReplaceAll(chapter.Body, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		fmt.Fprintf(&body, "  <p>%s</p>\n", strings.Join(lines, "<br/>"))
+	}
+
+	for _, image := range chapter.Images {
+		fmt.Fprintf(&body, "  <figure><img src=\"images/%s\" alt=\"\"/></figure>\n", html.EscapeString(image.Name))
+	}
+
+	return xhtml(chapter.Title, body.String())
+}
+
+func xhtml(title, body string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops">
+<head>
+  <title>%s</title>
+</head>
+<body>
+%s</body>
+</html>
+`, html.EscapeString(title), body)
+}
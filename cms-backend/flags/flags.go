@@ -0,0 +1,146 @@
+// Package flags evaluates feature flags stored in the feature_flags table.
+// Flags are cached in memory like settings, and each request evaluates a flag
+// once so a feature cannot flicker on and off halfway through it.
+package flags
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DefaultTTL is how long a loaded copy of the flags is used
+const DefaultTTL = 30 * time.Second
+
+// evaluatedKey is the gin context key holding a request's evaluated flags
+const evaluatedKey = "feature_flags"
+
+// Store caches every flag in memory
+type Store struct {
+	// Now is the clock used to age the cache; tests replace it
+	Now func() time.Time
+
+	ttl      time.Duration
+	mu       sync.Mutex
+	flags    map[string]models.FeatureFlag
+	loadedAt time.Time
+}
+
+// Default is the process-wide store used by the API
+var Default = NewStore(DefaultTTL)
+
+// NewStore creates an empty store that reloads after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{Now: time.Now, ttl: ttl}
+}
+
+// All returns every flag keyed by name
+func (s *Store) All(db *gorm.DB) (map[string]models.FeatureFlag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	if s.flags != nil && now.Sub(s.loadedAt) < s.ttl {
+		return s.flags, nil
+	}
+
+	var rows []models.FeatureFlag
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	flags := make(map[string]models.FeatureFlag, len(rows))
+	for _, row := range rows {
+		flags[row.Name] = row
+	}
+	s.flags = flags
+	s.loadedAt = now
+	return flags, nil
+}
+
+// Invalidate drops the cached flags so the next read reloads them
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flags = nil
+}
+
+// Evaluate reports whether flag is on for a caller identified by subject, a
+// stable identifier such as the API key ID or client address
+func Evaluate(flag models.FeatureFlag, caller auth.Caller, subject string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if caller.Role != "" {
+		for _, role := range strings.Split(flag.Roles, ",") {
+			if strings.TrimSpace(role) == caller.Role {
+				return true
+			}
+		}
+	}
+	if flag.Percentage >= 100 {
+		return true
+	}
+	if flag.Percentage <= 0 {
+		return false
+	}
+
+	// Hash the flag name too so each flag picks a different set of callers
+	h := fnv.New32a()
+	h.Write([]byte(flag.Name + "\x00" + subject))
+	return int(h.Sum32()%100) < flag.Percentage
+}
+
+// Enabled reports whether a flag is on for the current request. Unknown flags
+// and flags that cannot be loaded are off.
+func Enabled(c *gin.Context, name string) bool {
+	evaluated, _ := c.Get(evaluatedKey)
+	results, ok := evaluated.(map[string]bool)
+	if !ok {
+		results = make(map[string]bool)
+		c.Set(evaluatedKey, results)
+	}
+	if on, ok := results[name]; ok {
+		return on
+	}
+
+	on := false
+	all, err := Default.All(c.MustGet("db").(*gorm.DB))
+	if flag, ok := all[name]; err == nil && ok {
+		on = Evaluate(flag, auth.CallerFrom(c), Subject(c))
+	}
+	results[name] = on
+	return on
+}
+
+// Subject identifies the caller for percentage rollouts: the API key when one
+// is used, otherwise the client address
+func Subject(c *gin.Context) string {
+	if caller := auth.CallerFrom(c); !caller.Anonymous() {
+		return "key:" + strconv.FormatUint(uint64(caller.KeyID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Require serves a route only to callers the flag is on for; others get a 404
+// as if the route did not exist
+func Require(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled(c, name) {
+			c.AbortWithStatusJSON(http.StatusNotFound, utils.HTTPError{
+				Code:    http.StatusNotFound,
+				Message: "Not found",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,146 @@
+// Package health reports whether the server and the backends it depends on are
+// usable. A backend can be degraded, when the server still works through a
+// fallback, or down; only critical backends being down make the server unready.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Component and overall states
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// checkTimeout bounds each check so a hung backend cannot hang the probe
+const checkTimeout = 2 * time.Second
+
+// Check reports the state of one backend and an optional human-readable detail
+type Check func(ctx context.Context) (status string, detail string)
+
+// ComponentStatus is the reported state of one backend
+type ComponentStatus struct {
+	Status   string `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Critical bool   `json:"critical"`
+}
+
+// Stats returns usage figures reported alongside the checks, such as pool sizes
+type Stats func() interface{}
+
+// Report is the /readyz response body
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+	Stats      map[string]interface{}     `json:"stats,omitempty"`
+}
+
+type component struct {
+	check    Check
+	critical bool
+}
+
+// Registry holds the checks run by the readiness probe
+type Registry struct {
+	mu         sync.Mutex
+	components map[string]component
+	stats      map[string]Stats
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{components: make(map[string]component), stats: make(map[string]Stats)}
+}
+
+// Register adds a check. When a critical backend is down the server reports
+// itself unready; other backends only mark it degraded.
+func (r *Registry) Register(name string, critical bool, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.components[name] = component{check: check, critical: critical}
+}
+
+// AddStats adds a section of usage figures to every report
+func (r *Registry) AddStats(name string, stats Stats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stats[name] = stats
+}
+
+// Run executes every check concurrently and combines the results
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.components))
+	for name := range r.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	components := make([]component, len(names))
+	for i, name := range names {
+		components[i] = r.components[name]
+	}
+	stats := make(map[string]Stats, len(r.stats))
+	for name, s := range r.stats {
+		stats[name] = s
+	}
+	r.mu.Unlock()
+
+	results := make([]ComponentStatus, len(names))
+	var wg sync.WaitGroup
+	for i, c := range components {
+		wg.Add(1)
+		go func(i int, c component) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+			defer cancel()
+			status, detail := c.check(checkCtx)
+			results[i] = ComponentStatus{Status: status, Detail: detail, Critical: c.critical}
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Components: make(map[string]ComponentStatus, len(names))}
+	for i, name := range names {
+		result := results[i]
+		report.Components[name] = result
+		switch {
+		case result.Status == StatusDown && result.Critical:
+			report.Status = StatusDown
+		case result.Status != StatusOK && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	if len(stats) > 0 {
+		report.Stats = make(map[string]interface{}, len(stats))
+		for name, s := range stats {
+			report.Stats[name] = s()
+		}
+	}
+	return report
+}
+
+// Readiness serves the readiness probe: 200 while the server can serve traffic,
+// even in degraded mode, and 503 when a critical backend is down
+func (r *Registry) Readiness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := r.Run(c.Request.Context())
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
+// Liveness serves the liveness probe, which only shows the process is responding
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
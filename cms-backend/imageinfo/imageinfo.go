@@ -0,0 +1,97 @@
+// Package imageinfo reads the dimensions of JPEG, PNG and GIF images from the
+// start of the file, taking the EXIF orientation of JPEGs into account so the
+// size reported is the size the image is displayed at. It also strips the
+// metadata of images the server stores.
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	_ "image/gif"  // register the GIF decoder
+	_ "image/jpeg" // register the JPEG decoder
+	_ "image/png"  // register the PNG decoder
+)
+
+// HeadSize is how much of a file Dimensions needs; JPEG metadata segments
+// before the frame header rarely come close
+const HeadSize = 1 << 20
+
+// Dimensions returns the displayed width and height of the image whose first
+// bytes are head
+func Dimensions(head []byte) (width, height int, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(head))
+	if err != nil {
+		return 0, 0, err
+	}
+	width, height = config.Width, config.Height
+	// Orientations 5 to 8 rotate the image a quarter turn
+	if orientation := Orientation(head); orientation >= 5 && orientation <= 8 {
+		width, height = height, width
+	}
+	return width, height, nil
+}
+
+// Orientation returns the EXIF orientation of a JPEG, 1 (upright) when the
+// image carries none
+func Orientation(head []byte) int {
+	if len(head) < 4 || head[0] != 0xFF || head[1] != 0xD8 {
+		return 1
+	}
+	for pos := 2; pos+4 <= len(head); {
+		if head[pos] != 0xFF {
+			return 1
+		}
+		marker := head[pos+1]
+		// Image data starts at the start-of-scan marker; metadata comes before it
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(head[pos+2:]))
+		segment := pos + 4
+		end := pos + 2 + length
+		if length < 2 || end > len(head) {
+			return 1
+		}
+		if marker == 0xE1 && bytes.HasPrefix(head[segment:end], []byte("Exif\x00\x00")) {
+			return exifOrientation(head[segment+6 : end])
+		}
+		pos = end
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from the first IFD of a TIFF
+// structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
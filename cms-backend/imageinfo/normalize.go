@@ -0,0 +1,146 @@
+package imageinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+)
+
+// ErrMalformed is returned by Normalize when an image's metadata cannot be
+// told apart from its image data
+var ErrMalformed = errors.New("imageinfo: malformed image")
+
+// reencodeQuality is the JPEG quality images turned upright are saved at
+const reencodeQuality = 90
+
+var (
+	jpegSignature = []byte{0xFF, 0xD8}
+	pngSignature  = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// strippedJPEGMarkers are the JPEG segments Normalize drops: APP1 holds EXIF
+// and XMP, APP13 Photoshop's IPTC block and COM free-text comments. JFIF,
+// the ICC colour profile and Adobe's colour transform are kept.
+var strippedJPEGMarkers = map[byte]bool{0xE1: true, 0xED: true, 0xFE: true}
+
+// strippedPNGChunks are the PNG chunks Normalize drops: EXIF, text and the
+// modification time
+var strippedPNGChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// Normalize returns a JPEG or PNG without the metadata it carries, such as
+// the camera details and GPS position in EXIF. A JPEG whose orientation tag
+// turns it is decoded and saved upright, since the tag goes with the rest;
+// other images keep their image data byte for byte. Formats other than JPEG
+// and PNG are returned unchanged.
+func Normalize(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, jpegSignature):
+		if orientation := Orientation(data); orientation != 1 {
+			return upright(data, orientation)
+		}
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	}
+	return data, nil
+}
+
+// stripJPEG copies a JPEG leaving out strippedJPEGMarkers segments before
+// its image data
+func stripJPEG(data []byte) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), jpegSignature...)
+	for pos := 2; ; {
+		if pos+4 > len(data) || data[pos] != 0xFF {
+			return nil, ErrMalformed
+		}
+		marker := data[pos+1]
+		// Everything from the start of the image data on is copied as is
+		if marker == 0xDA || marker == 0xD9 {
+			return append(out, data[pos:]...), nil
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end < pos+4 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		if !strippedJPEGMarkers[marker] {
+			out = append(out, data[pos:end]...)
+		}
+		pos = end
+	}
+}
+
+// stripPNG copies a PNG leaving out strippedPNGChunks chunks
+func stripPNG(data []byte) ([]byte, error) {
+	out := append(make([]byte, 0, len(data)), pngSignature...)
+	for pos := len(pngSignature); ; {
+		if pos+12 > len(data) {
+			return nil, ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrMalformed
+		}
+		chunk := string(data[pos+4 : pos+8])
+		if !strippedPNGChunks[chunk] {
+			out = append(out, data[pos:end]...)
+		}
+		if chunk == "IEND" {
+			return out, nil
+		}
+		pos = end
+	}
+}
+
+// upright decodes a JPEG, applies its EXIF orientation and encodes it again.
+// The encoder writes no metadata, so the result carries none.
+func upright(data []byte, orientation int) ([]byte, error) {
+	decoded, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	bounds := decoded.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), decoded, bounds.Min, draw.Src)
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	// Orientations 5 to 8 rotate the image a quarter turn
+	if orientation >= 5 {
+		dst = image.NewRGBA(image.Rect(0, 0, h, w))
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// Where the pixel at (x, y) is displayed
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			default:
+				dx, dy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):][:4], src.Pix[src.PixOffset(x, y):][:4])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: reencodeQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
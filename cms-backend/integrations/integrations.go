@@ -0,0 +1,284 @@
+// Package integrations posts content events to third-party services, such as
+// Zapier or IFTTT, in the shape each service expects. An integration follows the
+// change feed like a webhook, but maps the event and the changed entity onto
+// its own JSON body with a Go template and authenticates with custom headers.
+package integrations
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/webhooks"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// batchSize caps the changes one integration processes per dispatch
+const batchSize = 100
+
+// maxResponseBody caps how much of a service's response is kept for reporting
+const maxResponseBody = 1024
+
+// ErrInvalidHeader is returned when an integration header cannot be sent
+var ErrInvalidHeader = errors.New("invalid integration header")
+
+// reservedHeaders are set by the HTTP client and cannot be overridden
+var reservedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+}
+
+// Data is given to integration templates: the webhook payload fields (.Event,
+// .Seq, .EntityType, .EntityID, .Action, .Status, .Tags, .ChangedAt) and
+// .Entity, the changed post, page, media or tag as the public API serializes
+// it. .Entity is empty for deleted entities and other entity types, so
+// templates should read it with index, e.g. `{{json (index .Entity "title")}}`.
+type Data struct {
+	webhooks.Payload
+	Entity map[string]interface{}
+}
+
+// SampleData is the data sent by test deliveries and used to validate templates
+func SampleData(now time.Time) Data {
+	return Data{
+		Payload: webhooks.SamplePayload(now),
+		Entity: map[string]interface{}{
+			"id":         1,
+			"title":      "Hello world",
+			"slug":       "hello-world",
+			"content":    "Welcome to the site.",
+			"author":     "admin",
+			"status":     models.StatusPublished,
+			"created_at": now.UTC(),
+			"updated_at": now.UTC(),
+		},
+	}
+}
+
+// ValidateTemplate checks that an integration template parses and renders
+// valid JSON for the sample data
+func ValidateTemplate(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("%w: template is required", webhooks.ErrInvalidTemplate)
+	}
+	_, err := webhooks.RenderTemplate(text, SampleData(time.Now()))
+	return err
+}
+
+// ParseHeaders decodes an integration's stored headers
+func ParseHeaders(raw string) (map[string]string, error) {
+	headers := map[string]string{}
+	if raw == "" {
+		return headers, nil
+	}
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// ValidateHeaders checks that every header has a valid name and a single-line
+// value, and that none overrides a header the HTTP client manages
+func ValidateHeaders(headers map[string]string) error {
+	for name, value := range headers {
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("%w: %q is not a header name", ErrInvalidHeader, name)
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return fmt.Errorf("%w: %s cannot be set", ErrInvalidHeader, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("%w: the value of %s must be a single line", ErrInvalidHeader, name)
+		}
+	}
+	return nil
+}
+
+// Deliver posts the integration's rendered template to its URL through the
+// egress policy. Any 2xx response counts as success.
+func Deliver(ctx context.Context, integration models.Integration, data Data) webhooks.Result {
+	body, err := webhooks.RenderTemplate(integration.Template, data)
+	if err != nil {
+		return webhooks.Result{Error: err.Error()}
+	}
+	headers, err := ParseHeaders(integration.Headers)
+	if err != nil {
+		return webhooks.Result{Error: err.Error()}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, integration.URL, bytes.NewReader(body))
+	if err != nil {
+		return webhooks.Result{Error: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := egress.Default().Do(req)
+	result := webhooks.Result{DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(excerpt)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Success {
+		result.Error = fmt.Sprintf("service responded %d", resp.StatusCode)
+	}
+	return result
+}
+
+// loaded is a change's filterable event and template data
+type loaded struct {
+	event webhooks.Event
+	data  Data
+}
+
+// Dispatch delivers the changes each active integration has not yet processed,
+// in feed order. An integration stops at its first failed delivery and retries
+// from there on the next dispatch.
+func Dispatch(ctx context.Context, db *gorm.DB) error {
+	var integrations []models.Integration
+	if err := db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&integrations).Error; err != nil {
+		return err
+	}
+
+	// Changes are loaded once for every integration processing them
+	cache := make(map[int64]loaded)
+	for _, integration := range integrations {
+		if err := dispatchIntegration(ctx, db, integration, cache); err != nil {
+			log.Printf("Integration %d dispatch failed: %v", integration.ID, err)
+		}
+	}
+	return nil
+}
+
+func dispatchIntegration(ctx context.Context, db *gorm.DB, integration models.Integration, cache map[int64]loaded) error {
+	filter, err := webhooks.ParseFilter(integration.Filter)
+	if err != nil {
+		return err
+	}
+
+	var changes []models.Change
+	if err := db.WithContext(ctx).Where("seq > ?", integration.LastSeq).Order("seq").Limit(batchSize).Find(&changes).Error; err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	for _, change := range changes {
+		item, ok := cache[change.Seq]
+		if !ok {
+			event, err := webhooks.LoadEvent(ctx, db, change)
+			if err != nil {
+				return err
+			}
+			entity, err := LoadEntity(ctx, db, change)
+			if err != nil {
+				return err
+			}
+			item = loaded{event: event, data: Data{Payload: webhooks.NewPayload(change, event), Entity: entity}}
+			cache[change.Seq] = item
+		}
+
+		if filter.Match(item.event) {
+			result := Deliver(ctx, integration, item.data)
+			now := time.Now()
+			updates["last_status"] = result.StatusCode
+			updates["last_error"] = result.Error
+			updates["last_attempt_at"] = &now
+			if !result.Success {
+				break
+			}
+		}
+		updates["last_seq"] = change.Seq
+	}
+	return db.WithContext(ctx).Model(&models.Integration{}).Where("id = ?", integration.ID).Updates(updates).Error
+}
+
+// LoadEntity returns the changed post, page, media or tag as the public API
+// serializes it, so notes and author emails never leave the CMS. It is empty
+// for deletes, entities that no longer exist and other entity types.
+func LoadEntity(ctx context.Context, db *gorm.DB, change models.Change) (map[string]interface{}, error) {
+	entity := map[string]interface{}{}
+	if change.Action == models.ChangeDelete {
+		return entity, nil
+	}
+
+	var value interface{}
+	switch change.EntityType {
+	case "post":
+		var post models.Post
+		err := db.WithContext(ctx).Preload("Tags").Preload("Media").First(&post, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return entity, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value = serializers.Post(auth.Caller{}, post)
+	case "page":
+		var page models.Page
+		err := db.WithContext(ctx).First(&page, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return entity, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value = serializers.Page(auth.Caller{}, page)
+	case "media":
+		var media models.Media
+		err := db.WithContext(ctx).First(&media, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return entity, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value = media
+	case "tag":
+		var tag models.Tag
+		err := db.WithContext(ctx).First(&tag, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return entity, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		value = tag
+	default:
+		return entity, nil
+	}
+
+	// Round trip through JSON so templates see the API's field names
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(encoded, &entity); err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
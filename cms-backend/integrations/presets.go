@@ -0,0 +1,56 @@
+package integrations
+
+// Preset is a ready-made template for a common service
+type Preset struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Template    string `json:"template"`
+}
+
+// Presets are the templates integrations can start from instead of writing their own
+var Presets = []Preset{
+	{
+		Name:        "zapier",
+		Description: "Flat fields for a Zapier catch hook, one per event and entity attribute",
+		Template: `{
+  "event": {{json .Event}},
+  "entity_type": {{json .EntityType}},
+  "entity_id": {{.EntityID}},
+  "action": {{json .Action}},
+  "status": {{json .Status}},
+  "tags": {{json .Tags}},
+  "title": {{json (index .Entity "title")}},
+  "slug": {{json (index .Entity "slug")}},
+  "changed_at": {{json .ChangedAt}}
+}`,
+	},
+	{
+		Name:        "ifttt",
+		Description: "The value1 to value3 ingredients of an IFTTT Webhooks applet: title, event and entity ID",
+		Template: `{
+  "value1": {{json (index .Entity "title")}},
+  "value2": {{json .Event}},
+  "value3": {{json (printf "%d" .EntityID)}}
+}`,
+	},
+	{
+		Name:        "generic",
+		Description: "The event fields and the full entity",
+		Template: `{
+  "event": {{json .Event}},
+  "seq": {{.Seq}},
+  "changed_at": {{json .ChangedAt}},
+  "entity": {{json .Entity}}
+}`,
+	},
+}
+
+// FindPreset returns the preset with the given name
+func FindPreset(name string) (Preset, bool) {
+	for _, preset := range Presets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"cms-backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxConcurrent limits how many jobs run at the same time in this process
+const maxConcurrent = 2
+
+var slots = make(chan struct{}, maxConcurrent)
+
+// Func performs the work of a job. It reports progress (0-100) through the
+// callback and returns the path of the file it produced, if any.
+type Func func(ctx context.Context, db *gorm.DB, job *models.Job, progress func(int)) (string, error)
+
+// Start records a queued job requested by the API key requestedBy, 0 for the
+// server itself, and runs fn in the background
+func Start(db *gorm.DB, requestedBy uint, jobType string, payload interface{}, fn Func) (*models.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	job := models.Job{
+		Type:             jobType,
+		Status:           models.JobQueued,
+		RequestedByKeyID: requestedBy,
+		Payload:          string(encoded),
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, err
+	}
+
+	go run(db, job, fn)
+	return &job, nil
+}
+
+// Dir returns the directory job results are written to (EXPORT_DIR, default "exports")
+func Dir() (string, error) {
+	dir := os.Getenv("EXPORT_DIR")
+	if dir == "" {
+		dir = "exports"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// RecoverInterrupted marks jobs left queued or running by a previous process as failed.
+// Jobs run in-process, so they cannot survive a restart.
+func RecoverInterrupted(db *gorm.DB) error {
+	now := time.Now()
+	return db.Model(&models.Job{}).
+		Where("status IN ?", []string{models.JobQueued, models.JobRunning}).
+		Updates(map[string]interface{}{
+			"status":      models.JobFailed,
+			"error":       "interrupted by server restart",
+			"finished_at": now,
+		}).Error
+}
+
+func run(db *gorm.DB, job models.Job, fn Func) {
+	slots <- struct{}{}
+	defer func() { <-slots }()
+
+	started := time.Now()
+	db.Model(&job).Updates(map[string]interface{}{
+		"status":     models.JobRunning,
+		"started_at": started,
+	})
+
+	progress := func(percent int) {
+		if percent < 0 {
+			percent = 0
+		}
+		if percent > 100 {
+			percent = 100
+		}
+		db.Model(&job).Update("progress", percent)
+	}
+
+	resultPath, err := safeRun(db, &job, fn, progress)
+
+	finished := time.Now()
+	if err != nil {
+		log.Printf("job %d (%s) failed: %v", job.ID, job.Type, err)
+		db.Model(&job).Updates(map[string]interface{}{
+			"status":      models.JobFailed,
+			"error":       err.Error(),
+			"finished_at": finished,
+		})
+		return
+	}
+
+	db.Model(&job).Updates(map[string]interface{}{
+		"status":      models.JobCompleted,
+		"progress":    100,
+		"result_path": resultPath,
+		"finished_at": finished,
+	})
+}
+
+// safeRun converts a panicking job into a failed one
+func safeRun(db *gorm.DB, job *models.Job, fn Func, progress func(int)) (path string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return fn(context.Background(), db, job, progress)
+}
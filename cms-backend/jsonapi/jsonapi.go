@@ -0,0 +1,243 @@
+// Package jsonapi converts the API's JSON responses to JSON:API documents
+// (https://jsonapi.org), for clients that ask for that format with
+// Accept: application/vnd.api+json. Resources become objects with their ID and
+// type at the top level, fields become attributes, and embedded media and tags
+// become relationships whose objects are listed once in included.
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"strings"
+)
+
+// MediaType is the JSON:API media type
+const MediaType = "application/vnd.api+json"
+
+// Relationship describes a field of a resource holding related resources
+type Relationship struct {
+	// Name is the relationship's name in the document
+	Name string
+	// Key is the JSON field it is read from. It holds the related objects, or
+	// with IDOnly just the ID of one related resource.
+	Key    string
+	Type   string
+	IDOnly bool
+}
+
+// Resource describes how one type of response object is converted
+type Resource struct {
+	Type          string
+	Relationships []Relationship
+	// Rename moves attributes JSON:API reserves, such as "type", to another name
+	Rename map[string]string
+	// Readable resources have a GET /<type>/:id route their self links point to
+	Readable bool
+}
+
+// Resources are the resource types the API serves as JSON:API, by type
+var Resources = map[string]Resource{
+	"posts": {
+		Type: "posts",
+		Relationships: []Relationship{
+			{Name: "media", Key: "media", Type: "media"},
+			{Name: "tags", Key: "tags", Type: "tags"},
+			{Name: "owner_group", Key: "owner_group_id", Type: "groups", IDOnly: true},
+		},
+		Readable: true,
+	},
+	"pages": {
+		Type: "pages",
+		Relationships: []Relationship{
+			{Name: "parent", Key: "parent_id", Type: "pages", IDOnly: true},
+			{Name: "owner_group", Key: "owner_group_id", Type: "groups", IDOnly: true},
+		},
+		Readable: true,
+	},
+	"media": {
+		Type: "media",
+		Relationships: []Relationship{
+			{Name: "uploaded_by", Key: "uploaded_by_id", Type: "users", IDOnly: true},
+		},
+		Rename:   map[string]string{"type": "media_type"},
+		Readable: true,
+	},
+	"tags": {
+		Type: "tags",
+		Relationships: []Relationship{
+			{Name: "parent", Key: "parent_id", Type: "tags", IDOnly: true},
+		},
+	},
+}
+
+// Document is a top-level JSON:API document. Data is an *Object or []Object.
+type Document struct {
+	Data     interface{}       `json:"data,omitempty"`
+	Included []Object          `json:"included,omitempty"`
+	Errors   []Error           `json:"errors,omitempty"`
+	Links    map[string]string `json:"links,omitempty"`
+}
+
+// Object is a resource object
+type Object struct {
+	Type          string                     `json:"type"`
+	ID            string                     `json:"id"`
+	Attributes    map[string]json.RawMessage `json:"attributes,omitempty"`
+	Relationships map[string]Relation        `json:"relationships,omitempty"`
+	Links         map[string]string          `json:"links,omitempty"`
+}
+
+// Relation is a relationship object. Data is nil (null), an *Identifier or
+// []Identifier.
+type Relation struct {
+	Data  interface{}       `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// Identifier names a related resource
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Error is an error object
+type Error struct {
+	Status string `json:"status"`
+	Title  string `json:"title"`
+}
+
+// errNotObject is returned for bodies that are not an object or a list of them
+var errNotObject = errors.New("response is not a resource object or list")
+
+// Accepts reports whether an Accept header lists the JSON:API media type
+func Accepts(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == MediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// Convert turns a JSON response body holding one resource or a list of them
+// into a document. Self links are rooted at base; with no base they are left
+// out, as for the delivery API where management routes do not apply.
+func (r Resource) Convert(body []byte, base string) (*Document, error) {
+	doc := &Document{}
+	included := &includedSet{seen: make(map[Identifier]bool)}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &items); err != nil {
+			return nil, errNotObject
+		}
+		objects := make([]Object, len(items))
+		for i, item := range items {
+			objects[i] = r.object(item, base, included)
+		}
+		doc.Data = objects
+	} else {
+		var item map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &item); err != nil {
+			return nil, errNotObject
+		}
+		object := r.object(item, base, included)
+		doc.Data = &object
+	}
+	doc.Included = included.objects
+	return doc, nil
+}
+
+// object converts one response object, collecting embedded related objects
+func (r Resource) object(item map[string]json.RawMessage, base string, included *includedSet) Object {
+	object := Object{Type: r.Type, ID: idString(item["id"]), Attributes: make(map[string]json.RawMessage)}
+	delete(item, "id")
+
+	for _, rel := range r.Relationships {
+		raw, ok := item[rel.Key]
+		if !ok {
+			continue
+		}
+		delete(item, rel.Key)
+		related := Resources[rel.Type]
+
+		var relation Relation
+		switch {
+		case rel.IDOnly:
+			if id := idString(raw); id != "" {
+				relation.Data = &Identifier{Type: rel.Type, ID: id}
+				relation.Links = related.links(base, id, "related")
+			}
+		default:
+			var items []map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil || items == nil {
+				// Relations that were not loaded are left out
+				continue
+			}
+			identifiers := make([]Identifier, len(items))
+			for i, relatedItem := range items {
+				child := related.object(relatedItem, base, included)
+				identifiers[i] = Identifier{Type: child.Type, ID: child.ID}
+				included.add(child)
+			}
+			relation.Data = identifiers
+		}
+		if object.Relationships == nil {
+			object.Relationships = make(map[string]Relation)
+		}
+		object.Relationships[rel.Name] = relation
+	}
+
+	for key, value := range item {
+		if renamed, ok := r.Rename[key]; ok {
+			key = renamed
+		}
+		object.Attributes[key] = value
+	}
+	object.Links = r.links(base, object.ID, "self")
+	return object
+}
+
+// links returns the link to a resource under name, if it has a route
+func (r Resource) links(base, id, name string) map[string]string {
+	if base == "" || !r.Readable || id == "" {
+		return nil
+	}
+	return map[string]string{name: strings.TrimSuffix(base, "/") + "/" + r.Type + "/" + id}
+}
+
+// idString returns a JSON ID, number or string, as the string JSON:API uses;
+// null and missing IDs give ""
+func idString(raw json.RawMessage) string {
+	var id interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if len(raw) == 0 || decoder.Decode(&id) != nil {
+		return ""
+	}
+	switch id := id.(type) {
+	case string:
+		return id
+	case json.Number:
+		return id.String()
+	}
+	return ""
+}
+
+// includedSet lists related objects once each, in the order first seen
+type includedSet struct {
+	seen    map[Identifier]bool
+	objects []Object
+}
+
+func (s *includedSet) add(object Object) {
+	key := Identifier{Type: object.Type, ID: object.ID}
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.objects = append(s.objects, object)
+}
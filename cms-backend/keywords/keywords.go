@@ -0,0 +1,111 @@
+// Package keywords extracts the terms that characterize a document within a
+// corpus using TF-IDF: a term scores highly when it is frequent in the
+// document but rare across the corpus. It works on plain text and needs no
+// external service.
+package keywords
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// minTermLength drops short tokens, which are almost never useful keywords
+const minTermLength = 3
+
+// stopwords are common English words that never make useful keywords
+var stopwords = toSet(`a about above after again against all also am an and any are as at be
+because been before being below between both but by can could did do does doing down during
+each even ever every few for from further get gets got had has have having he her here hers
+herself him himself his how however i if in into is it its itself just like made make makes
+many may me more most much must my myself new no nor not now of off often on once one only or
+other our ours ourselves out over own per put same see she should since so some still such
+than that the their theirs them themselves then there these they this those through to too
+two under until up upon us use used using very via was way we well were what when where
+whether which while who whom why will with within without would yet you your yours yourself
+yourselves`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// Terms splits text into lowercase terms, dropping numbers, stopwords and
+// very short words
+func Terms(text string) []string {
+	var terms []string
+	for _, token := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(token)) < minTermLength || stopwords[token] || !containsLetter(token) {
+			continue
+		}
+		terms = append(terms, token)
+	}
+	return terms
+}
+
+func containsLetter(token string) bool {
+	return strings.IndexFunc(token, unicode.IsLetter) >= 0
+}
+
+// Count returns how often each term occurs in text
+func Count(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, term := range Terms(text) {
+		counts[term]++
+	}
+	return counts
+}
+
+// Corpus counts the documents each tracked term appears in. Only tracked
+// terms are counted, so a large corpus can be streamed through it without
+// keeping its whole vocabulary.
+type Corpus struct {
+	Documents int
+	frequency map[string]int
+}
+
+// NewCorpus returns an empty corpus tracking the given terms
+func NewCorpus(terms map[string]int) *Corpus {
+	frequency := make(map[string]int, len(terms))
+	for term := range terms {
+		frequency[term] = 0
+	}
+	return &Corpus{frequency: frequency}
+}
+
+// Add counts one document
+func (c *Corpus) Add(text string) {
+	c.Documents++
+	seen := make(map[string]bool)
+	for _, term := range Terms(text) {
+		if _, tracked := c.frequency[term]; tracked && !seen[term] {
+			seen[term] = true
+			c.frequency[term]++
+		}
+	}
+}
+
+// Score returns the TF-IDF score of each term of a document given its term
+// counts. Inverse document frequency is smoothed, so terms the corpus has
+// never seen still score.
+func (c *Corpus) Score(counts map[string]int) map[string]float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	scores := make(map[string]float64, len(counts))
+	if total == 0 {
+		return scores
+	}
+	for term, count := range counts {
+		tf := float64(count) / float64(total)
+		idf := math.Log(float64(c.Documents+1)/float64(c.frequency[term]+1)) + 1
+		scores[term] = tf * idf
+	}
+	return scores
+}
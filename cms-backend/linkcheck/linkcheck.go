@@ -0,0 +1,259 @@
+// Package linkcheck finds the external links in published posts and pages
+// and periodically requests each one, recording those that no longer work.
+// Requests go through the egress policy, so content cannot make the server
+// probe internal hosts.
+package linkcheck
+
+import (
+	"cms-backend/egress"
+	"cms-backend/markdown"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"context"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultInterval is how often each link is rechecked when
+// LINK_CHECK_INTERVAL is not set
+const DefaultInterval = 24 * time.Hour
+
+// checkBatchSize caps the URLs requested per run and workers how many are
+// requested at once
+const (
+	checkBatchSize = 20
+	workers        = 4
+)
+
+// maxURLLength matches the url column; longer links are skipped
+const maxURLLength = 2048
+
+// linkAttr matches the targets of links and images in rendered Markdown
+var linkAttr = regexp.MustCompile(`(?:href|src)="([^"]*)"`)
+
+// contentTables are the entities links are collected from
+var contentTables = []struct {
+	entity string
+	model  interface{}
+}{
+	{"post", &models.Post{}},
+	{"page", &models.Page{}},
+}
+
+// Checker collects and checks links. It remembers when it last collected
+// links, so each run only rereads content changed since then.
+type Checker struct {
+	Client *http.Client
+
+	// Interval is how long a result stands before the link is checked again
+	Interval time.Duration
+
+	mu          sync.Mutex
+	collectedAt time.Time
+}
+
+// Default is the checker the scheduler runs; nil disables link checking. It
+// is set once at startup.
+var Default *Checker
+
+// FromEnv returns a checker rechecking links every LINK_CHECK_INTERVAL
+// (default 24h), or nil when the interval is 0
+func FromEnv() *Checker {
+	interval := DefaultInterval
+	if value := os.Getenv("LINK_CHECK_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Ignoring invalid LINK_CHECK_INTERVAL %q", value)
+		} else {
+			interval = parsed
+		}
+	}
+	if interval == 0 {
+		return nil
+	}
+	return &Checker{Client: egress.Default(), Interval: interval}
+}
+
+// Run collects links from content changed since the previous run and checks
+// the links that are due
+func (c *Checker) Run(ctx context.Context, db *gorm.DB, now time.Time) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := Collect(ctx, db, c.collectedAt); err != nil {
+		return err
+	}
+	c.collectedAt = now
+	return c.Check(ctx, db, now)
+}
+
+// Collect records the links of published content updated at or after since
+// (everything when since is zero) and forgets links that were removed from
+// it or whose content is no longer published
+func Collect(ctx context.Context, db *gorm.DB, since time.Time) error {
+	db = db.WithContext(ctx)
+	for _, table := range contentTables {
+		var batch []struct {
+			ID      uint
+			Content string
+		}
+		query := db.Model(table.model).Select("id", "content").Where("status = ?", models.StatusPublished)
+		if !since.IsZero() {
+			query = query.Where("updated_at >= ?", since)
+		}
+		if err := query.FindInBatches(&batch, 100, func(tx *gorm.DB, _ int) error {
+			for _, entity := range batch {
+				if err := syncLinks(db, table.entity, entity.ID, ExtractURLs(entity.Content)); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error; err != nil {
+			return err
+		}
+
+		published := db.Model(table.model).Select("id").Where("status = ?", models.StatusPublished)
+		if err := db.Where("entity_type = ? AND entity_id NOT IN (?)", table.entity, published).
+			Delete(&models.Link{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncLinks makes the recorded links of one entity match urls, keeping the
+// check results of links that are still there
+func syncLinks(db *gorm.DB, entity string, id uint, urls []string) error {
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		removed := tx.Where("entity_type = ? AND entity_id = ?", entity, id)
+		if len(urls) > 0 {
+			removed = removed.Where("url NOT IN ?", urls)
+		}
+		if err := removed.Delete(&models.Link{}).Error; err != nil {
+			return err
+		}
+		if len(urls) == 0 {
+			return nil
+		}
+
+		links := make([]models.Link, len(urls))
+		for i, url := range urls {
+			links[i] = models.Link{EntityType: entity, EntityID: id, URL: url}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+	})
+}
+
+// Check requests the links not checked within the interval, oldest first.
+// A URL used in several places is requested once and every link to it
+// gets the result.
+func (c *Checker) Check(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+	var urls []string
+	if err := db.Model(&models.Link{}).
+		Where("checked_at IS NULL OR checked_at < ?", now.Add(-c.Interval)).
+		Group("url").Order("MIN(COALESCE(checked_at, '-infinity'))").
+		Limit(checkBatchSize).Pluck("url", &urls).Error; err != nil {
+		return err
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	results := make([]models.Link, len(urls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = c.checkURL(ctx, urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	broken := 0
+	for i, result := range results {
+		if result.Broken {
+			broken++
+		}
+		if err := db.Model(&models.Link{}).Where("url = ?", urls[i]).Updates(map[string]interface{}{
+			"status_code": result.StatusCode,
+			"error":       result.Error,
+			"broken":      result.Broken,
+			"checked_at":  now,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	if broken > 0 {
+		log.Printf("Link check found %d broken links out of %d checked", broken, len(urls))
+	}
+	return nil
+}
+
+// checkURL requests url with HEAD, retrying with GET for servers that do not
+// support HEAD, and reports the outcome. Client errors and server errors both
+// count as broken, as do requests that fail outright.
+func (c *Checker) checkURL(ctx context.Context, url string) models.Link {
+	status, err := c.request(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented || status == http.StatusForbidden) {
+		status, err = c.request(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		return models.Link{Error: err.Error(), Broken: true}
+	}
+	return models.Link{StatusCode: status, Broken: status >= 400}
+}
+
+func (c *Checker) request(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "cms-link-checker/1.0")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// ExtractURLs returns the distinct absolute http(s) URLs that Markdown
+// content links to or embeds, in order of appearance
+func ExtractURLs(content string) []string {
+	seen := make(map[string]bool)
+	var urls []string
+	for _, match := range linkAttr.FindAllStringSubmatch(markdown.ToHTML(content), -1) {
+		url := html.UnescapeString(match[1])
+		lower := strings.ToLower(url)
+		if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+			continue
+		}
+		if len(url) > maxURLLength || seen[url] {
+			continue
+		}
+		seen[url] = true
+		urls = append(urls, url)
+	}
+	return urls
+}
@@ -0,0 +1,296 @@
+// Package lockout protects API key authentication from guessing. A client IP
+// that presents LOCKOUT_THRESHOLD unknown keys within LOCKOUT_WINDOW is locked
+// out for LOCKOUT_DURATION, doubled on every further lockout up to
+// LOCKOUT_MAX_DURATION. Failures are also counted per key prefix, so guesses
+// at one key spread over many addresses lock that key out too. Locked-out
+// clients get a 429 with Retry-After before any key is looked up. Failures
+// are counted per server instance, like the delivery rate limiter.
+package lockout
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config sets how many failures lock a client out and for how long
+type Config struct {
+	// Threshold is the failures within Window that lock a client out; 0 disables lockouts
+	Threshold int
+	Window    time.Duration
+
+	// Duration is the first lockout; each further one doubles up to MaxDuration
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// DefaultConfig applies when the environment does not override a setting
+var DefaultConfig = Config{
+	Threshold:   10,
+	Window:      15 * time.Minute,
+	Duration:    time.Minute,
+	MaxDuration: 24 * time.Hour,
+}
+
+// maxClients is how many addresses, and how many key prefixes, the tracker
+// holds at most. Clients with nothing left to remember are dropped first,
+// then those whose failures are oldest.
+const maxClients = 10000
+
+// ConfigFromEnv returns DefaultConfig overridden by LOCKOUT_THRESHOLD,
+// LOCKOUT_WINDOW, LOCKOUT_DURATION and LOCKOUT_MAX_DURATION
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	var err error
+	if value := os.Getenv("LOCKOUT_THRESHOLD"); value != "" {
+		if config.Threshold, err = strconv.Atoi(value); err != nil || config.Threshold < 0 {
+			return DefaultConfig, fmt.Errorf("invalid LOCKOUT_THRESHOLD %q", value)
+		}
+	}
+	durations := []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"LOCKOUT_WINDOW", &config.Window},
+		{"LOCKOUT_DURATION", &config.Duration},
+		{"LOCKOUT_MAX_DURATION", &config.MaxDuration},
+	}
+	for _, d := range durations {
+		if value := os.Getenv(d.name); value != "" {
+			if *d.value, err = time.ParseDuration(value); err != nil || *d.value <= 0 {
+				return DefaultConfig, fmt.Errorf("invalid %s %q", d.name, value)
+			}
+		}
+	}
+	if config.MaxDuration < config.Duration {
+		return DefaultConfig, fmt.Errorf("LOCKOUT_MAX_DURATION must not be shorter than LOCKOUT_DURATION")
+	}
+	return config, nil
+}
+
+// Lockout describes a client's recent failures and any lockout in force. The
+// client is an address or, for guesses at one key, the key's prefix.
+type Lockout struct {
+	IP        string `json:"ip,omitempty"`
+	KeyPrefix string `json:"key_prefix,omitempty"`
+	Failures  int    `json:"failures"`
+
+	// Lockouts counts the client's lockouts in a row; the next one lasts longer
+	Lockouts    int        `json:"lockouts"`
+	LockedUntil *time.Time `json:"locked_until"`
+}
+
+type client struct {
+	failures     int
+	firstFailure time.Time
+	lastFailure  time.Time
+	lockouts     int
+	lockedUntil  time.Time
+
+	// prefixes are the key prefixes an address failed with since it last
+	// authenticated
+	prefixes map[string]bool
+}
+
+// Tracker counts failed authentications per client IP and per key prefix
+type Tracker struct {
+	// Now is the clock used for windows and lockouts; tests replace it
+	Now func() time.Time
+
+	mu      sync.Mutex
+	config  Config
+	clients map[string]*client
+	keys    map[string]*client
+}
+
+// Default is the tracker API key authentication reports to, nil when lockouts
+// are disabled. It is set once at startup.
+var Default *Tracker
+
+// New creates a tracker, or returns nil when config disables lockouts
+func New(config Config) *Tracker {
+	if config.Threshold == 0 {
+		return nil
+	}
+	return &Tracker{Now: time.Now, config: config, clients: make(map[string]*client), keys: make(map[string]*client)}
+}
+
+// Locked reports whether ip, or the key with prefix, is locked out and for
+// how much longer
+func (t *Tracker) Locked(ip, prefix string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.Now()
+
+	var remaining time.Duration
+	for _, c := range []*client{t.clients[ip], t.keys[prefix]} {
+		if c != nil && c.lockedUntil.Sub(now) > remaining {
+			remaining = c.lockedUntil.Sub(now)
+		}
+	}
+	return remaining, remaining > 0
+}
+
+// Fail counts a failed authentication from ip with a key starting with
+// prefix. When either reaches the threshold it is locked out and Fail
+// returns the lockout's length.
+func (t *Tracker) Fail(ip, prefix string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.Now()
+
+	duration, locked := t.fail(t.clients, ip, prefix, now)
+	if keyDuration, keyLocked := t.fail(t.keys, prefix, "", now); keyLocked && keyDuration > duration {
+		duration, locked = keyDuration, true
+	}
+	return duration, locked
+}
+
+// fail counts a failure against id in clients, remembering the key prefix used
+func (t *Tracker) fail(clients map[string]*client, id, prefix string, now time.Time) (time.Duration, bool) {
+	c, ok := clients[id]
+	if !ok {
+		if len(clients) >= maxClients {
+			t.evict(clients, now)
+		}
+		c = &client{}
+		clients[id] = c
+	} else if t.stale(c, now) {
+		*c = client{}
+	}
+	if c.failures == 0 || now.Sub(c.firstFailure) > t.config.Window {
+		c.failures, c.firstFailure, c.prefixes = 0, now, nil
+	}
+	c.failures++
+	c.lastFailure = now
+	if prefix != "" {
+		if c.prefixes == nil {
+			c.prefixes = make(map[string]bool)
+		}
+		c.prefixes[prefix] = true
+	}
+	if c.failures < t.config.Threshold {
+		return 0, false
+	}
+
+	duration := t.config.Duration
+	for i := 0; i < c.lockouts && duration < t.config.MaxDuration; i++ {
+		duration *= 2
+	}
+	if duration > t.config.MaxDuration {
+		duration = t.config.MaxDuration
+	}
+	c.lockouts++
+	c.failures = 0
+	c.prefixes = nil
+	c.lockedUntil = now.Add(duration)
+	return duration, true
+}
+
+// Succeed records that ip authenticated with the key starting with prefix.
+// The key's failures are forgotten, and the address's too when they were all
+// with that key, i.e. its holder mistyped it; a valid key of a different
+// prefix, such as a public delivery token, does not clear guesses.
+func (t *Tracker) Succeed(ip, prefix string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.keys, prefix)
+	if c, ok := t.clients[ip]; ok && c.lockouts == 0 {
+		for p := range c.prefixes {
+			if p != prefix {
+				return
+			}
+		}
+		delete(t.clients, ip)
+	}
+}
+
+// Unlock lifts the lockout of an address or key prefix and forgets its
+// failures, reporting whether there was anything to forget
+func (t *Tracker) Unlock(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, isClient := t.clients[id]
+	_, isKey := t.keys[id]
+	delete(t.clients, id)
+	delete(t.keys, id)
+	return isClient || isKey
+}
+
+// Lockouts lists the addresses and key prefixes with recent failures or a
+// lockout in force, those locked out longest first
+func (t *Tracker) Lockouts() []Lockout {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.Now()
+
+	lockouts := []Lockout{}
+	for _, tracked := range []struct {
+		clients map[string]*client
+		byKey   bool
+	}{{t.clients, false}, {t.keys, true}} {
+		for id, c := range tracked.clients {
+			if t.stale(c, now) {
+				continue
+			}
+			lockout := Lockout{IP: id, Failures: c.failures, Lockouts: c.lockouts}
+			if tracked.byKey {
+				lockout = Lockout{KeyPrefix: id, Failures: c.failures, Lockouts: c.lockouts}
+			}
+			if c.lockedUntil.After(now) {
+				until := c.lockedUntil
+				lockout.LockedUntil = &until
+			}
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	sort.Slice(lockouts, func(i, j int) bool {
+		a, b := lockouts[i].LockedUntil, lockouts[j].LockedUntil
+		if (a == nil) != (b == nil) {
+			return a != nil
+		}
+		if a != nil && !a.Equal(*b) {
+			return a.After(*b)
+		}
+		return lockouts[i].IP+lockouts[i].KeyPrefix < lockouts[j].IP+lockouts[j].KeyPrefix
+	})
+	return lockouts
+}
+
+// stale reports whether a client has nothing left to remember: its lockout
+// is over and it has not failed for a window, so backoff starts afresh
+func (t *Tracker) stale(c *client, now time.Time) bool {
+	return !c.lockedUntil.After(now) && now.Sub(c.lastFailure) > t.config.Window &&
+		now.Sub(c.lockedUntil) > t.config.Window
+}
+
+// evict makes room in a full map: stale clients go first, then, so the map
+// stays within maxClients however many addresses are spoofed, the tenth of
+// clients whose lockouts end soonest and failures are oldest
+func (t *Tracker) evict(clients map[string]*client, now time.Time) {
+	for id, c := range clients {
+		if t.stale(c, now) {
+			delete(clients, id)
+		}
+	}
+	if len(clients) < maxClients {
+		return
+	}
+	ids := make([]string, 0, len(clients))
+	for id := range clients {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := clients[ids[i]], clients[ids[j]]
+		if !a.lockedUntil.Equal(b.lockedUntil) {
+			return a.lockedUntil.Before(b.lockedUntil)
+		}
+		return a.lastFailure.Before(b.lastFailure)
+	})
+	for _, id := range ids[:len(ids)-maxClients*9/10] {
+		delete(clients, id)
+	}
+}
@@ -0,0 +1,64 @@
+// Package mailer sends email through a transport chosen by MAILER: "smtp",
+// "ses" or "log". Messages are rendered from the HTML templates in this
+// package; when MAILER is unset no email is sent.
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Message is one email. HTML is the body; Text, when set, is sent alongside
+// it for clients that do not render HTML.
+type Message struct {
+	To      []string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// Mailer delivers messages from the configured sender address
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv returns the mailer selected by MAILER, or nil when MAILER is unset.
+// MAIL_FROM is the sender address for every transport.
+func FromEnv() (Mailer, error) {
+	transport := os.Getenv("MAILER")
+	if transport == "" {
+		return nil, nil
+	}
+	from := os.Getenv("MAIL_FROM")
+	if from == "" && transport != "log" {
+		return nil, errors.New("MAIL_FROM is required when MAILER is set")
+	}
+
+	switch transport {
+	case "smtp":
+		return SMTPFromEnv(from)
+	case "ses":
+		return SESFromEnv(from)
+	case "log":
+		return Log{}, nil
+	}
+	return nil, fmt.Errorf("unknown MAILER %q (expected smtp, ses or log)", transport)
+}
+
+// checkHeaders rejects line breaks in values that end up in headers, which
+// would let them add headers or recipients of their own
+func checkHeaders(from string, msg Message) error {
+	values := append([]string{from, msg.Subject}, msg.To...)
+	for _, value := range values {
+		if strings.ContainsAny(value, "\r\n") {
+			return errors.New("email headers must not contain line breaks")
+		}
+	}
+	if len(msg.To) == 0 {
+		return errors.New("email has no recipients")
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package mailer
+
+import (
+	"bytes"
+	"cms-backend/models"
+	"embed"
+	"fmt"
+	"html"
+	"html/template"
+	"os"
+	"strings"
+	"time"
+)
+
+// Template names
+const (
+	TemplatePasswordReset     = "password_reset"
+	TemplateReviewRequest     = "review_request"
+	TemplateDigest            = "digest"
+	TemplateNewsletterConfirm = "newsletter_confirm"
+)
+
+// PasswordResetData fills TemplatePasswordReset
+type PasswordResetData struct {
+	Name      string
+	ResetURL  string
+	ExpiresIn time.Duration
+}
+
+// ReviewRequestData fills TemplateReviewRequest
+type ReviewRequestData struct {
+	Name      string
+	Requester string
+	Title     string
+	ReviewURL string
+}
+
+// DigestData fills TemplateDigest
+type DigestData struct {
+	Name          string
+	Notifications []models.Notification
+}
+
+// NewsletterConfirmData fills TemplateNewsletterConfirm
+type NewsletterConfirmData struct {
+	Name       string
+	ConfirmURL string
+	ExpiresIn  time.Duration
+}
+
+//go:embed templates/*.html
+var templateFiles embed.FS
+
+// templates holds each template parsed together with the shared layout
+var templates = parseTemplates(TemplatePasswordReset, TemplateReviewRequest, TemplateDigest, TemplateNewsletterConfirm)
+
+func parseTemplates(names ...string) map[string]*template.Template {
+	parsed := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		parsed[name] = template.Must(template.ParseFS(templateFiles, "templates/layout.html", "templates/"+name+".html"))
+	}
+	return parsed
+}
+
+// Render builds the message for a template. Templates define a "subject"
+// and a "content" block and see the site name, from SITE_NAME, beside data.
+func Render(name string, data interface{}, to ...string) (Message, error) {
+	tmpl, ok := templates[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email template %q", name)
+	}
+	view := struct {
+		SiteName string
+		Data     interface{}
+	}{os.Getenv("SITE_NAME"), data}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", view); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.ExecuteTemplate(&body, "layout.html", view); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		To: to,
+		// The subject is a header, not HTML
+		Subject: strings.TrimSpace(html.UnescapeString(subject.String())),
+		HTML:    body.String(),
+	}, nil
+}
@@ -2,16 +2,35 @@
 package main
 
 import (
+	"cms-backend/backup"
+	"cms-backend/bucket"
+	"cms-backend/captcha"
+	"cms-backend/checklist"
+	"cms-backend/dam"
+	"cms-backend/jobs"
+	"cms-backend/linkcheck"
+	"cms-backend/lockout"
+	"cms-backend/mailer"
+	"cms-backend/migrations"
+	"cms-backend/newsletter"
+	"cms-backend/notifications"
+	"cms-backend/presence"
 	"cms-backend/routes"
+	"cms-backend/scanner"
+	"cms-backend/scheduler"
+	"cms-backend/secrets"
+	"cms-backend/spam"
+	"cms-backend/storage"
+	"cms-backend/trash"
 	"cms-backend/utils"
-	"fmt"
+	"context"
 	"log"
 	"os"
+	"strings"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/joho/godotenv/autoload"
 )
 
@@ -21,36 +40,26 @@ import (
 // @host localhost:8080
 // @BasePath /api/v1
 
-func runMigrations() error {
-	// Build database URL from environment variables
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbName := os.Getenv("DB_NAME")
-
-	databaseURL := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
-
-	// Create migrate instance with file source and postgres database
-	m, err := migrate.New(
-		"file://migrations",
-		databaseURL)
+func main() {
+	// Keep timestamps in UTC whatever the host's zone: the driver hands
+	// timestamptz values back in time.Local and GORM stamps rows with it
+	time.Local = time.UTC
+
+	// Fill secrets that are mounted as files or kept in Vault into the
+	// environment before anything, the CLI included, reads them
+	providers, err := secrets.ProvidersFromEnv()
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %v", err)
+		log.Fatalf("Invalid secrets settings: %v", err)
 	}
-	defer m.Close()
-
-	// Run migrations
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return fmt.Errorf("failed to run migrations: %v", err)
+	if err := secrets.Load(context.Background(), providers); err != nil {
+		log.Fatalf("Could not load secrets: %v", err)
 	}
 
-	log.Println("Migrations completed successfully")
-	return nil
-}
+	// Run a CLI subcommand instead of the server when one is given
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
 
-func main() {
 	// Initialize database connection
 	db, err := utils.ConnectDB()
 	if err != nil {
@@ -70,12 +79,91 @@ func main() {
 		env = "development" // default to development if ENV is not set
 	}
 
-	// Run database migrations
-	log.Println("Running database migrations...")
-	if err := runMigrations(); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// Run database migrations. SKIP_MIGRATIONS=true boots without them, so a
+	// broken migration can be repaired through the admin API and multi-replica
+	// deploys can migrate once with 'migrate up' or POST /admin/migrations/up.
+	if os.Getenv("SKIP_MIGRATIONS") == "true" {
+		log.Println("Skipping database migrations (SKIP_MIGRATIONS=true)")
+	} else {
+		log.Println("Running database migrations...")
+		if err := migrations.Up(); err != nil {
+			log.Fatalf("Failed to run migrations: %v (see 'migrate status' and 'migrate force')", err)
+		}
+		log.Println("Migrations completed successfully")
+	}
+
+	// Jobs run in-process, so any left running by a previous process are lost
+	if err := jobs.RecoverInterrupted(db); err != nil {
+		log.Printf("Failed to recover interrupted jobs: %v", err)
+	}
+
+	// Email digests of unread notifications when a mail transport is configured
+	mail, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Invalid mailer configuration: %v", err)
+	}
+	if mail != nil {
+		notifications.DigestSender = notifications.EmailSender(mail)
+		newsletter.Mailer = mail
+	}
+
+	// Push newsletter subscribers to a mailing list provider when one is configured
+	if newsletter.Default, err = newsletter.FromEnv(); err != nil {
+		log.Fatalf("Invalid newsletter configuration: %v", err)
+	}
+
+	// Scan media files for malware when a scanner is configured
+	if scanner.Default, err = scanner.FromEnv(); err != nil {
+		log.Fatalf("Invalid media scanner configuration: %v", err)
+	}
+
+	// Keep imported media files in the configured storage backend
+	if storage.Default, err = storage.FromEnv(); err != nil {
+		log.Fatalf("Invalid media storage configuration: %v", err)
+	}
+
+	// Register media from an existing asset library when one is configured
+	if bucket.Default, err = bucket.FromEnv(); err != nil {
+		log.Fatalf("Invalid media bulk import configuration: %v", err)
 	}
 
+	// Accept webhooks from the external asset managers whose secrets are set
+	dam.Default = dam.FromEnv()
+
+	// Check captcha tokens on public form submissions when a provider is configured
+	if captcha.Default, err = captcha.FromEnv(); err != nil {
+		log.Fatalf("Invalid form captcha configuration: %v", err)
+	}
+
+	// Lock out clients that keep presenting invalid API keys
+	lockoutConfig, err := lockout.ConfigFromEnv()
+	if err != nil {
+		log.Printf("Ignoring lockout settings: %v", err)
+	}
+	lockout.Default = lockout.New(lockoutConfig)
+
+	// Hold suspected spam among form submissions when a spam filter is configured
+	if spam.Default, err = spam.FromEnv(); err != nil {
+		log.Fatalf("Invalid spam filter configuration: %v", err)
+	}
+
+	// Check external links in published content unless LINK_CHECK_INTERVAL=0
+	linkcheck.Default = linkcheck.FromEnv()
+
+	// Keep content backups in BACKUP_DIR, made every BACKUP_INTERVAL if set
+	backup.Default = backup.FromEnv()
+
+	// Purge unused media once it is older than TRASH_RETENTION_DAYS, if set
+	trash.Default = trash.FromEnv()
+
+	// Add the optional publish checks, such as alt text on images, that are enabled
+	if err := checklist.RegisterFromEnv(); err != nil {
+		log.Fatalf("Invalid publish checklist configuration: %v", err)
+	}
+
+	// Start periodic tasks such as archiving expired content
+	scheduler.Start(context.Background(), db, scheduler.Default())
+
 	// Set Gin mode based on environment
 	if env == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -83,6 +171,27 @@ func main() {
 
 	router := gin.Default()
 
+	// Only proxies listed in TRUSTED_PROXIES may set the client address with
+	// X-Forwarded-For; otherwise any client could pick the address lockouts,
+	// rate limits and form submissions are keyed on
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	if err := router.SetTrustedProxies(proxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+
+	// Browsers may open presence WebSockets from the API's own host and the
+	// admin front ends listed in PRESENCE_ALLOWED_ORIGINS
+	for _, origin := range strings.Split(os.Getenv("PRESENCE_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			presence.AllowedOrigins = append(presence.AllowedOrigins, origin)
+		}
+	}
+
 	// Initialize routes
 	routes.InitializeRoutes(router, db)
 
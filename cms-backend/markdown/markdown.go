@@ -0,0 +1,124 @@
+// Package markdown renders CommonMark to safe HTML.
+//
+// Sources are rendered with goldmark, with raw HTML in the source escaped so
+// it shows as typed, and the output is sanitized with bluemonday, so only
+// formatting elements remain and link/image URLs are limited to http, https,
+// mailto and relative references. The output can be embedded in a page
+// without further sanitizing.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+var (
+	codeBlockHTML = regexp.MustCompile(`(?s)<pre>.*?</pre>`)
+	blockEndHTML  = regexp.MustCompile(`</(?:p|h[1-6]|li|blockquote)>|<br>|<hr>`)
+	tagHTML       = regexp.MustCompile(`<[^>]*>`)
+	imageHTML     = regexp.MustCompile(`<img src="([^"]*)" alt="([^"]*)"/?>`)
+)
+
+// converter renders CommonMark, escaping raw HTML instead of omitting it
+var converter = goldmark.New(goldmark.WithRendererOptions(
+	renderer.WithNodeRenderers(util.Prioritized(escapedHTML{}, 100)),
+))
+
+// policy keeps the elements Markdown produces and drops everything else,
+// including event handler and style attributes and unsafe URL schemes
+var policy = newPolicy()
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "h1", "h2", "h3", "h4", "h5", "h6",
+		"em", "strong", "del", "code", "pre", "blockquote", "ul", "ol", "li")
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowAttrs("src", "alt").OnElements("img")
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.AllowRelativeURLs(true)
+	p.RequireParseableURLs(true)
+	return p
+}
+
+// ToHTML renders Markdown source to sanitized HTML
+func ToHTML(source string) string {
+	var out bytes.Buffer
+	if err := converter.Convert([]byte(source), &out); err != nil {
+		// Rendering to memory only fails on broken writers; show the source
+		return "<p>" + html.EscapeString(source) + "</p>\n"
+	}
+	return policy.Sanitize(out.String())
+}
+
+// ToText renders Markdown source to plain text with one line per block.
+// Code blocks are left out, and links and images are reduced to their text.
+func ToText(source string) string {
+	rendered := codeBlockHTML.ReplaceAllString(ToHTML(source), "")
+	rendered = blockEndHTML.ReplaceAllString(rendered, "\n")
+	text := html.UnescapeString(tagHTML.ReplaceAllString(rendered, ""))
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ToAMP renders Markdown source to an AMP-valid HTML fragment. Images become
+// amp-img elements; Markdown carries no image dimensions, so they are laid out
+// responsively in a 16:9 box.
+func ToAMP(source string) string {
+	return imageHTML.ReplaceAllString(ToHTML(source),
+		`<amp-img src="$1" alt="$2" width="16" height="9" layout="responsive"></amp-img>`)
+}
+
+// escapedHTML renders raw HTML blocks and inline tags as escaped text, taking
+// precedence over goldmark's HTML renderer
+type escapedHTML struct{}
+
+func (escapedHTML) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHTMLBlock, renderHTMLBlock)
+	reg.Register(ast.KindRawHTML, renderRawHTML)
+}
+
+// renderHTMLBlock renders an HTML block as a paragraph of its escaped source
+func renderHTMLBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.HTMLBlock)
+	if entering {
+		w.WriteString("<p>")
+		writeEscaped(w, source, n.Lines())
+		return ast.WalkContinue, nil
+	}
+	if n.HasClosure() {
+		w.WriteString(html.EscapeString(string(n.ClosureLine.Value(source))))
+	}
+	w.WriteString("</p>\n")
+	return ast.WalkContinue, nil
+}
+
+// renderRawHTML renders an inline tag as its escaped source
+func renderRawHTML(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if entering {
+		writeEscaped(w, source, node.(*ast.RawHTML).Segments)
+	}
+	return ast.WalkSkipChildren, nil
+}
+
+func writeEscaped(w util.BufWriter, source []byte, segments *text.Segments) {
+	for i := 0; i < segments.Len(); i++ {
+		segment := segments.At(i)
+		w.WriteString(html.EscapeString(string(segment.Value(source))))
+	}
+}
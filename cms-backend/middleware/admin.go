@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"cms-backend/auth"
+	"cms-backend/utils"
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHeader carries the shared secret for admin endpoints
+const AdminTokenHeader = "X-Admin-Token"
+
+// RequireAdminToken guards admin routes with the ADMIN_TOKEN shared secret.
+// When ADMIN_TOKEN is unset the admin API is disabled entirely. API keys with
+// scopes also need <resource>:admin, e.g. settings:admin to change settings.
+func RequireAdminToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := os.Getenv("ADMIN_TOKEN")
+		if expected == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.HTTPError{
+				Code:    http.StatusForbidden,
+				Message: "Admin API is disabled",
+			})
+			return
+		}
+
+		provided := c.GetHeader(AdminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.HTTPError{
+				Code:    http.StatusUnauthorized,
+				Message: "Invalid admin token",
+			})
+			return
+		}
+
+		if denyScope(c, auth.ActionAdmin) {
+			return
+		}
+
+		c.Next()
+	}
+}
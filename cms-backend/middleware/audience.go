@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"cms-backend/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audience decides from the caller's token which content the delivery API
+// shows: member tokens also see members-only content, while anonymous callers
+// and other delivery tokens see public content only. It runs after
+// Authenticate.
+func Audience() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := auth.CallerFrom(c)
+		audience := auth.AudiencePublic
+		if caller.Scope() == auth.ScopeDelivery && caller.Role == auth.RoleMember {
+			audience = auth.AudienceMembers
+		}
+		auth.SetAudience(c, audience)
+		c.Next()
+	}
+}
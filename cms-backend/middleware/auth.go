@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"cms-backend/auth"
+	"cms-backend/lockout"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Authenticate resolves the API key in "Authorization: Bearer <key>" to a caller.
+// Requests without a key continue as anonymous callers; an unknown key gets a
+// 401, and clients that present too many get a 429 until their lockout ends.
+func Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		key, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.HTTPError{
+				Code:    http.StatusUnauthorized,
+				Message: "Authorization header must be 'Bearer <api key>'",
+			})
+			return
+		}
+
+		// Guesses are counted per address and per key prefix, so neither
+		// spreading them over addresses nor over keys escapes a lockout
+		tracker := lockout.Default
+		prefix := auth.PrefixOf(key)
+		if tracker != nil {
+			if remaining, locked := tracker.Locked(c.ClientIP(), prefix); locked {
+				abortLockedOut(c, remaining)
+				return
+			}
+		}
+
+		db := c.MustGet("db").(*gorm.DB)
+		var apiKey models.APIKey
+		if err := db.Where("key_hash = ?", auth.HashKey(key)).First(&apiKey).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if tracker != nil {
+					if duration, locked := tracker.Fail(c.ClientIP(), prefix); locked {
+						log.Printf("Locked out %s or key %s for %s after repeated invalid API keys", c.ClientIP(), prefix, duration)
+					}
+				}
+				c.AbortWithStatusJSON(http.StatusUnauthorized, utils.HTTPError{
+					Code:    http.StatusUnauthorized,
+					Message: "Invalid API key",
+				})
+				return
+			}
+			c.Abort()
+			utils.Fail(c, err)
+			return
+		}
+		if tracker != nil {
+			tracker.Succeed(c.ClientIP(), prefix)
+		}
+
+		// Scopes are checked when keys are issued; should a stored list still
+		// not parse, the key is granted nothing rather than everything
+		scopes, err := auth.ParseScopes(apiKey.Scopes)
+		if err != nil {
+			scopes = []string{}
+		}
+		auth.SetCaller(c, auth.Caller{Name: apiKey.Name, Role: apiKey.Role, KeyID: apiKey.ID, Scopes: scopes})
+		c.Next()
+	}
+}
+
+// abortLockedOut answers a locked-out client with a 429 saying when to retry
+func abortLockedOut(c *gin.Context, remaining time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(remaining.Seconds())))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, utils.HTTPError{
+		Code:      http.StatusTooManyRequests,
+		Message:   "Too many invalid API keys, retry later",
+		ErrorCode: utils.ErrorCodeLockedOut,
+	})
+}
+
+// DenyRole rejects callers acting with role with a 403, keeping restricted
+// keys such as submitters to the routes registered for them
+func DenyRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth.CallerFrom(c).Role == role {
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.HTTPError{
+				Code:    http.StatusForbidden,
+				Message: "This API key may not use this endpoint",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole lets only callers acting with one of roles through: anonymous
+// callers get a 401 and keys of any other role a 403
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := auth.CallerFrom(c)
+		if caller.Anonymous() {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.HTTPError{
+				Code:      http.StatusUnauthorized,
+				Message:   "An API key is required: send 'Authorization: Bearer <api key>'",
+				ErrorCode: utils.ErrorCodeAuthRequired,
+			})
+			return
+		}
+		for _, role := range roles {
+			if caller.Role == role {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, utils.HTTPError{
+			Code:    http.StatusForbidden,
+			Message: "This API key may not use this endpoint",
+		})
+	}
+}
+
+// RequireAuth rejects anonymous callers with a 401 according to REQUIRE_AUTH:
+// "all", the default, refuses every request, leaving anonymous visitors the
+// delivery API; "writes" refuses every method but GET, HEAD and OPTIONS, so
+// anonymous callers read published public content only; "off" lets anonymous
+// callers through. Delivery tokens are kept off these routes by RequireScope,
+// so dropping the header must not be a way around it.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth.CallerFrom(c).Anonymous() && anonymousRefused(os.Getenv("REQUIRE_AUTH"), c.Request.Method) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.HTTPError{
+				Code:      http.StatusUnauthorized,
+				Message:   "An API key is required: send 'Authorization: Bearer <api key>'",
+				ErrorCode: utils.ErrorCodeAuthRequired,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// anonymousRefused reports whether the REQUIRE_AUTH policy refuses an
+// anonymous request with method; unknown policies refuse everything
+func anonymousRefused(policy, method string) bool {
+	switch policy {
+	case "off":
+		return false
+	case "writes":
+		return auth.ActionFor(method) != auth.ActionRead
+	}
+	return true
+}
+
+// RequireScope rejects API keys of any other scope with a 403, keeping
+// delivery tokens on the delivery API and management tokens off it.
+// Anonymous callers pass.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := auth.CallerFrom(c)
+		if !caller.Anonymous() && caller.Scope() != scope {
+			message := "Delivery tokens may only read published content from the delivery API"
+			if scope == auth.ScopeDelivery {
+				message = "Management tokens may not be used with the delivery API"
+			}
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.HTTPError{
+				Code:      http.StatusForbidden,
+				Message:   message,
+				ErrorCode: utils.ErrorCodeWrongTokenScope,
+			})
+			return
+		}
+		c.Next()
+	}
+}
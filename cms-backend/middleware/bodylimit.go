@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"bytes"
+	"cms-backend/utils"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxBodyBytes caps request bodies when MAX_BODY_BYTES is not set
+const DefaultMaxBodyBytes = 1 << 20
+
+// MaxBodyBytesFromEnv reads the request body cap from MAX_BODY_BYTES
+func MaxBodyBytesFromEnv() int64 {
+	value := os.Getenv("MAX_BODY_BYTES")
+	if value == "" {
+		return DefaultMaxBodyBytes
+	}
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || limit < 1 {
+		log.Printf("Ignoring invalid MAX_BODY_BYTES %q", value)
+		return DefaultMaxBodyBytes
+	}
+	return limit
+}
+
+// BodyLimit answers 413 when a request body is larger than limit bytes,
+// before any handler reads it. Bodies sent without a length are read up to
+// the limit first, so an oversized one gets the same answer rather than the
+// binding error a handler would report.
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength > limit {
+			abortTooLarge(c, limit)
+			return
+		}
+		if c.Request.ContentLength >= 0 {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+		if err != nil {
+			c.Abort()
+			c.JSON(http.StatusBadRequest, utils.HTTPError{
+				Code:    http.StatusBadRequest,
+				Message: "Request body could not be read: " + err.Error(),
+			})
+			return
+		}
+		if int64(len(body)) > limit {
+			abortTooLarge(c, limit)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+func abortTooLarge(c *gin.Context, limit int64) {
+	c.Abort()
+	c.JSON(http.StatusRequestEntityTooLarge, utils.HTTPError{
+		Code:      http.StatusRequestEntityTooLarge,
+		Message:   fmt.Sprintf("Request body exceeds the %d byte limit", limit),
+		ErrorCode: utils.ErrorCodeBodyTooLarge,
+	})
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// uuidTables maps the path segment before an ID parameter to the table whose
+// records are named by UUID with ID_FORMAT=uuid; related posts are posts
+var uuidTables = map[string]string{
+	"posts":   "posts",
+	"pages":   "pages",
+	"media":   "media",
+	"related": "posts",
+}
+
+// ValidateIDs answers 400 when a route parameter naming a record, :id or one
+// ending in Id such as :relatedId, is not a valid ID, so malformed IDs never
+// reach the database. With ID_FORMAT=uuid posts, pages and media are named
+// by their UUID instead, which is swapped for the record's ID here so
+// handlers look records up as usual; a UUID naming no record answers 404.
+func ValidateIDs() gin.HandlerFunc {
+	uuids := models.UUIDIDs()
+	return func(c *gin.Context) {
+		for i, param := range c.Params {
+			if param.Key != "id" && !strings.HasSuffix(param.Key, "Id") {
+				continue
+			}
+
+			if table := uuidTable(c.FullPath(), param.Key); uuids && table != "" {
+				id, err := resolveUUID(c, table, param.Value)
+				if err != nil {
+					c.Abort()
+					utils.Fail(c, err)
+					return
+				}
+				c.Params[i].Value = strconv.FormatUint(uint64(id), 10)
+				continue
+			}
+
+			if _, err := utils.ParseID(param.Value); err != nil {
+				c.Abort()
+				utils.Fail(c, utils.Invalid(param.Key+" must be a positive integer"))
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// uuidTable returns the table of the records a parameter of route names,
+// when they have UUIDs
+func uuidTable(route, key string) string {
+	segments := strings.Split(route, "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i] == ":"+key {
+			return uuidTables[segments[i-1]]
+		}
+	}
+	return ""
+}
+
+// resolveUUID returns the ID of the record in table with the given UUID
+func resolveUUID(c *gin.Context, table, value string) (uint, error) {
+	if !utils.IsUUID(value) {
+		return 0, utils.Invalid("ID must be a UUID")
+	}
+	var ids []uint
+	if err := c.MustGet("db").(*gorm.DB).Table(table).Where("uuid = ?", value).Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, utils.NotFound("Record not found")
+	}
+	return ids[0], nil
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"cms-backend/jsonapi"
+	"cms-backend/utils"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jsonAPIRoutes are the routes that answer JSON:API documents when asked to,
+// relative to their version prefix, with the resource type they return
+var jsonAPIRoutes = map[string]string{
+	"/posts":                "posts",
+	"/posts/:id":            "posts",
+	"/pages":                "pages",
+	"/pages/:id":            "pages",
+	"/media":                "media",
+	"/media/:id":            "media",
+	"/tags":                 "tags",
+	"/tags/:id":             "tags",
+	"/delivery/posts":       "posts",
+	"/delivery/posts/:slug": "posts",
+	"/delivery/pages":       "pages",
+	"/delivery/pages/:slug": "pages",
+}
+
+// JSONAPI answers requests with Accept: application/vnd.api+json as JSON:API
+// documents on the routes that serve posts, pages, media and tags; other
+// routes keep answering plain JSON. prefix is the version prefix of the
+// route group, such as /api/v1. Lists continue with a next link in place of
+// X-Next-Cursor. It replaces the version 2 envelope, so it must run after
+// APIVersion.
+func JSONAPI(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := strings.TrimPrefix(c.FullPath(), prefix)
+		resourceType, ok := jsonAPIRoutes[route]
+		if !ok {
+			c.Next()
+			return
+		}
+		// Shared caches must keep the two formats apart
+		c.Writer.Header().Add("Vary", "Accept")
+		if !jsonapi.Accepts(c.GetHeader("Accept")) {
+			c.Next()
+			return
+		}
+
+		writer := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		if len(c.Errors) > 0 && !writer.decided && !writer.Written() {
+			utils.WriteError(c, c.Errors.Last().Err)
+		}
+		c.Writer = writer.ResponseWriter
+
+		if !writer.buffering {
+			return
+		}
+		// Delivery responses link nowhere: management routes need other credentials
+		base := prefix
+		if strings.HasPrefix(route, "/delivery/") {
+			base = ""
+		}
+		doc, err := jsonAPIDocument(c, jsonapi.Resources[resourceType], base, writer.Status(), writer.body.Bytes())
+		if err != nil {
+			writer.ResponseWriter.Write(writer.body.Bytes())
+			return
+		}
+		writer.Header().Set("Content-Type", jsonapi.MediaType)
+		writer.ResponseWriter.Write(doc)
+	}
+}
+
+// jsonAPIDocument converts a buffered JSON response. utils.HTTPError bodies
+// become the single entry of errors.
+func jsonAPIDocument(c *gin.Context, resource jsonapi.Resource, base string, status int, body []byte) ([]byte, error) {
+	if status >= 400 {
+		var httpErr utils.HTTPError
+		if err := json.Unmarshal(body, &httpErr); err != nil || httpErr.Message == "" {
+			httpErr = utils.HTTPError{Code: status, Message: strings.TrimSpace(string(body))}
+		}
+		return json.Marshal(jsonapi.Document{Errors: []jsonapi.Error{{Status: strconv.Itoa(status), Title: httpErr.Message}}})
+	}
+
+	doc, err := resource.Convert(body, base)
+	if err != nil {
+		return nil, err
+	}
+	doc.Links = map[string]string{"self": c.Request.URL.RequestURI()}
+	if cursor := c.Writer.Header().Get(utils.NextCursorHeader); cursor != "" {
+		next := *c.Request.URL
+		query := next.Query()
+		query.Set("after", cursor)
+		next.RawQuery = query.Encode()
+		doc.Links["next"] = next.RequestURI()
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"cms-backend/utils"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VersionHeader reports the API version that produced a response
+const VersionHeader = "API-Version"
+
+// versionKey is the gin context key holding the API version
+const versionKey = "api_version"
+
+// LatestVersion is the newest API version served
+const LatestVersion = 2
+
+// APIVersion marks requests with the API version of their route group, so
+// controllers and serializers shared between versions can branch where shapes
+// differ. From version 2, JSON responses are wrapped in a utils.Envelope; it
+// must run before any middleware that may write an error response.
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(versionKey, version)
+		c.Header(VersionHeader, strconv.Itoa(version))
+		if version < 2 {
+			c.Next()
+			return
+		}
+
+		writer := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		// Errors reported with utils.Fail are answered here so they are
+		// enveloped too
+		if len(c.Errors) > 0 && !writer.decided && !writer.Written() {
+			utils.WriteError(c, c.Errors.Last().Err)
+		}
+		c.Writer = writer.ResponseWriter
+
+		if writer.buffering {
+			body := envelope(version, writer.Status(), writer.body.Bytes(), writer.Header().Get(utils.NextCursorHeader))
+			writer.ResponseWriter.Write(body)
+		}
+	}
+}
+
+// VersionFrom returns the API version of a request, 1 outside versioned groups
+func VersionFrom(c *gin.Context) int {
+	if version, ok := c.Get(versionKey); ok {
+		if v, ok := version.(int); ok {
+			return v
+		}
+	}
+	return 1
+}
+
+// envelope wraps a JSON response body: successful bodies become data, and
+// utils.HTTPError bodies become the single entry of errors
+func envelope(version, status int, body []byte, nextCursor string) []byte {
+	response := utils.Envelope{Meta: utils.EnvelopeMeta{APIVersion: version, NextCursor: nextCursor}}
+	if status >= 400 {
+		var httpErr utils.HTTPError
+		if err := json.Unmarshal(body, &httpErr); err != nil || httpErr.Message == "" {
+			httpErr = utils.HTTPError{Code: status, Message: strings.TrimSpace(string(body))}
+		}
+		response.Errors = []utils.HTTPError{httpErr}
+	} else {
+		response.Data = json.RawMessage(body)
+		var items []json.RawMessage
+		if json.Unmarshal(body, &items) == nil {
+			count := len(items)
+			response.Meta.Count = &count
+		}
+	}
+
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// envelopeWriter holds back JSON bodies so they can be wrapped once the handler
+// is done. Other content types, such as PDF downloads, pass straight through.
+type envelopeWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	decided   bool
+	buffering bool
+}
+
+func (w *envelopeWriter) decide() {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
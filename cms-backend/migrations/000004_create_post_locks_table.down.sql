@@ -0,0 +1,2 @@
+-- Drop post_locks table
+DROP TABLE IF EXISTS post_locks;
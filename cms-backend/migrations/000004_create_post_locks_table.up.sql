@@ -0,0 +1,9 @@
+-- Create post_locks table for soft edit locks (one lock per post)
+CREATE TABLE post_locks (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL UNIQUE,
+    holder VARCHAR(100) NOT NULL,
+    acquired_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,6 @@
+-- Drop junction tables before the tables they reference
+DROP TABLE IF EXISTS post_relations;
+DROP TABLE IF EXISTS post_tags;
+
+-- Drop tags table
+DROP TABLE IF EXISTS tags;
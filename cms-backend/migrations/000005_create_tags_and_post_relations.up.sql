@@ -0,0 +1,28 @@
+-- Create tags table
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create post_tags junction table
+CREATE TABLE post_tags (
+    post_id INTEGER NOT NULL,
+    tag_id INTEGER NOT NULL,
+    PRIMARY KEY (post_id, tag_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+-- Create post_relations junction table (self-referential, stored in both directions)
+CREATE TABLE post_relations (
+    post_id INTEGER NOT NULL,
+    related_post_id INTEGER NOT NULL,
+    PRIMARY KEY (post_id, related_post_id),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    FOREIGN KEY (related_post_id) REFERENCES posts(id) ON DELETE CASCADE,
+    CHECK (post_id <> related_post_id)
+);
+
+CREATE INDEX idx_post_tags_tag_id ON post_tags(tag_id);
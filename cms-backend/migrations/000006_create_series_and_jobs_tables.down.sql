@@ -0,0 +1,6 @@
+-- Drop jobs table
+DROP TABLE IF EXISTS jobs;
+
+-- Drop series_posts junction table before series
+DROP TABLE IF EXISTS series_posts;
+DROP TABLE IF EXISTS series;
@@ -0,0 +1,2 @@
+-- Drop post_renders table
+DROP TABLE IF EXISTS post_renders;
@@ -0,0 +1,8 @@
+-- Create post_renders table caching rendered Markdown per post
+CREATE TABLE post_renders (
+    post_id INTEGER PRIMARY KEY,
+    content_hash VARCHAR(64) NOT NULL,
+    html TEXT NOT NULL,
+    rendered_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
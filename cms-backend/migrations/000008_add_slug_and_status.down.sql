@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_pages_slug;
+DROP INDEX IF EXISTS idx_posts_slug;
+ALTER TABLE pages DROP COLUMN IF EXISTS status;
+ALTER TABLE pages DROP COLUMN IF EXISTS slug;
+ALTER TABLE posts DROP COLUMN IF EXISTS status;
+ALTER TABLE posts DROP COLUMN IF EXISTS slug;
@@ -0,0 +1,13 @@
+-- Add slugs and publication status to posts and pages.
+-- Existing content was publicly visible, so it is backfilled as published.
+ALTER TABLE posts ADD COLUMN slug VARCHAR(255);
+ALTER TABLE posts ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published';
+ALTER TABLE pages ADD COLUMN slug VARCHAR(255);
+ALTER TABLE pages ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'published';
+
+-- Backfill slugs from titles; the id suffix keeps them unique
+UPDATE posts SET slug = trim(both '-' from regexp_replace(lower(title), '[^a-z0-9]+', '-', 'g')) || '-' || id;
+UPDATE pages SET slug = trim(both '-' from regexp_replace(lower(title), '[^a-z0-9]+', '-', 'g')) || '-' || id;
+
+CREATE UNIQUE INDEX idx_posts_slug ON posts (slug);
+CREATE UNIQUE INDEX idx_pages_slug ON pages (slug);
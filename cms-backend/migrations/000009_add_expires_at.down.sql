@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_pages_expires_at;
+DROP INDEX IF EXISTS idx_posts_expires_at;
+ALTER TABLE pages DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,7 @@
+-- Add expiry times used by the scheduler to archive published content
+ALTER TABLE posts ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pages ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- The scheduler only looks at published content with an expiry
+CREATE INDEX idx_posts_expires_at ON posts (expires_at) WHERE status = 'published' AND expires_at IS NOT NULL;
+CREATE INDEX idx_pages_expires_at ON pages (expires_at) WHERE status = 'published' AND expires_at IS NOT NULL;
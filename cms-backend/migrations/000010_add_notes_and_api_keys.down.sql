@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_keys;
+ALTER TABLE pages DROP COLUMN IF EXISTS notes;
+ALTER TABLE posts DROP COLUMN IF EXISTS notes;
+ALTER TABLE posts DROP COLUMN IF EXISTS author_email;
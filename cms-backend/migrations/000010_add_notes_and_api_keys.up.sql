@@ -0,0 +1,14 @@
+-- Editorial fields that are redacted for delivery callers
+ALTER TABLE posts ADD COLUMN author_email VARCHAR(255);
+ALTER TABLE posts ADD COLUMN notes TEXT;
+ALTER TABLE pages ADD COLUMN notes TEXT;
+
+-- API keys identify callers and their role; only a SHA-256 hash of each key is stored
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    role VARCHAR(20) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_featured_position;
+ALTER TABLE posts DROP COLUMN IF EXISTS position;
+ALTER TABLE posts DROP COLUMN IF EXISTS featured;
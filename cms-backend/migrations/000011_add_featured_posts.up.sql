@@ -0,0 +1,5 @@
+-- Curated homepage ordering for posts
+ALTER TABLE posts ADD COLUMN featured BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE posts ADD COLUMN position INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_posts_featured_position ON posts (position, id) WHERE featured;
@@ -0,0 +1,2 @@
+-- File size in bytes, summed for storage statistics
+ALTER TABLE media ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,10 @@
+-- Daily view counts per post, used to rank popular posts over a time window
+CREATE TABLE post_views (
+    post_id INTEGER NOT NULL,
+    day DATE NOT NULL,
+    views BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (post_id, day),
+    FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_post_views_day ON post_views (day);
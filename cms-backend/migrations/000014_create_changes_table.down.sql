@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS series_record_change ON series;
+DROP TRIGGER IF EXISTS tags_record_change ON tags;
+DROP TRIGGER IF EXISTS media_record_change ON media;
+DROP TRIGGER IF EXISTS pages_record_change ON pages;
+DROP TRIGGER IF EXISTS posts_record_change ON posts;
+DROP FUNCTION IF EXISTS record_change();
+DROP TABLE IF EXISTS changes;
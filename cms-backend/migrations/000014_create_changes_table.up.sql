@@ -0,0 +1,34 @@
+-- Append-only feed of content changes for external caches, indexers and mirrors
+CREATE TABLE changes (
+    seq BIGSERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(10) NOT NULL,
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Records every row change in the same transaction as the change itself. The
+-- advisory lock serialises writers until commit, so sequence numbers become
+-- visible in order and a reader never skips a number committed after it read.
+CREATE FUNCTION record_change() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('changes'));
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO changes (entity_type, entity_id, action) VALUES (TG_ARGV[0], OLD.id, 'delete');
+        RETURN OLD;
+    END IF;
+    INSERT INTO changes (entity_type, entity_id, action) VALUES (TG_ARGV[0], NEW.id, lower(TG_OP));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER posts_record_change AFTER INSERT OR UPDATE OR DELETE ON posts
+    FOR EACH ROW EXECUTE FUNCTION record_change('post');
+CREATE TRIGGER pages_record_change AFTER INSERT OR UPDATE OR DELETE ON pages
+    FOR EACH ROW EXECUTE FUNCTION record_change('page');
+CREATE TRIGGER media_record_change AFTER INSERT OR UPDATE OR DELETE ON media
+    FOR EACH ROW EXECUTE FUNCTION record_change('media');
+CREATE TRIGGER tags_record_change AFTER INSERT OR UPDATE OR DELETE ON tags
+    FOR EACH ROW EXECUTE FUNCTION record_change('tag');
+CREATE TRIGGER series_record_change AFTER INSERT OR UPDATE OR DELETE ON series
+    FOR EACH ROW EXECUTE FUNCTION record_change('series');
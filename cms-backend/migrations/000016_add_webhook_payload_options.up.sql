@@ -0,0 +1,3 @@
+-- Existing webhooks keep the version 1 payload their receivers were built for
+ALTER TABLE webhooks ADD COLUMN payload_version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE webhooks ADD COLUMN template TEXT NOT NULL DEFAULT '';
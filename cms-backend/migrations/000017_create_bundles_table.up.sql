@@ -0,0 +1,11 @@
+-- Offline content bundles, one per channel, base version and target version
+CREATE TABLE bundles (
+    id SERIAL PRIMARY KEY,
+    channel VARCHAR(100) NOT NULL,
+    since BIGINT NOT NULL,
+    version BIGINT NOT NULL,
+    job_id INTEGER NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_bundles_key ON bundles(channel, since, version);
@@ -0,0 +1,2 @@
+-- Supports keyset pagination over posts by (created_at, id)
+CREATE INDEX idx_posts_created_at_id ON posts(created_at, id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_changes_changed_at;
+DROP TABLE IF EXISTS change_prunes;
@@ -0,0 +1,9 @@
+-- Watermarks left by change feed retention; cursors below the latest are expired
+CREATE TABLE change_prunes (
+    id SERIAL PRIMARY KEY,
+    pruned_through BIGINT NOT NULL,
+    pruned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Retention deletes by age
+CREATE INDEX idx_changes_changed_at ON changes(changed_at);
@@ -0,0 +1,23 @@
+-- Signage playlists: ordered media and pages with display durations
+CREATE TABLE playlists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Items disappear with the media or page they show
+CREATE TABLE playlist_items (
+    id SERIAL PRIMARY KEY,
+    playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    media_id INTEGER REFERENCES media(id) ON DELETE CASCADE,
+    page_id INTEGER REFERENCES pages(id) ON DELETE CASCADE,
+    duration_seconds INTEGER NOT NULL CHECK (duration_seconds > 0),
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    CHECK ((media_id IS NULL) <> (page_id IS NULL))
+);
+
+CREATE INDEX idx_playlist_items_playlist_id ON playlist_items(playlist_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS embargo_links;
+DROP TABLE IF EXISTS embargoes;
+DROP TABLE IF EXISTS distribution_members;
+DROP TABLE IF EXISTS distribution_lists;
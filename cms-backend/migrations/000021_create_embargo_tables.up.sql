@@ -0,0 +1,42 @@
+-- Press contacts that embargoed posts are shared with
+CREATE TABLE distribution_lists (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE distribution_members (
+    id SERIAL PRIMARY KEY,
+    list_id INTEGER NOT NULL REFERENCES distribution_lists(id) ON DELETE CASCADE,
+    name VARCHAR(255),
+    email VARCHAR(255) NOT NULL
+);
+
+CREATE INDEX idx_distribution_members_list_id ON distribution_members(list_id);
+
+-- One embargo per draft post, published by the scheduler at publish_at
+CREATE TABLE embargoes (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL UNIQUE REFERENCES posts(id) ON DELETE CASCADE,
+    publish_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    published_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_embargoes_publish_at ON embargoes(publish_at);
+
+-- Tokenized links handed to list members; lifting the embargo revokes them
+CREATE TABLE embargo_links (
+    id SERIAL PRIMARY KEY,
+    embargo_id INTEGER NOT NULL REFERENCES embargoes(id) ON DELETE CASCADE,
+    member_id INTEGER NOT NULL REFERENCES distribution_members(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    first_accessed_at TIMESTAMP WITH TIME ZONE,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (embargo_id, member_id)
+);
@@ -0,0 +1,16 @@
+-- Site-level configuration managed through the API instead of env vars
+CREATE TABLE settings (
+    id SERIAL PRIMARY KEY,
+    namespace VARCHAR(100) NOT NULL,
+    key VARCHAR(100) NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    value JSONB NOT NULL,
+    description TEXT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_settings_namespace_key ON settings(namespace, key);
+
+INSERT INTO settings (namespace, key, type, value, description) VALUES
+    ('site', 'title', 'string', '""', 'Site title shown by front ends'),
+    ('site', 'default_locale', 'string', '"en"', 'Locale used when a request does not ask for one');
@@ -0,0 +1,21 @@
+-- Drafts sent in by external contributors for review
+CREATE TABLE submissions (
+    id SERIAL PRIMARY KEY,
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    submitter VARCHAR(100) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    review_note TEXT,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL,
+    notify_url VARCHAR(2048),
+    notified_at TIMESTAMP WITH TIME ZONE,
+    notify_attempts INTEGER NOT NULL DEFAULT 0,
+    notify_last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_submissions_api_key_id ON submissions(api_key_id);
+CREATE INDEX idx_submissions_status ON submissions(status);
@@ -0,0 +1,11 @@
+-- Per-post and per-page grants that narrow what roles allow
+CREATE TABLE acl_entries (
+    id SERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL CHECK (entity_type IN ('post', 'page')),
+    entity_id INTEGER NOT NULL,
+    principal_type VARCHAR(20) NOT NULL,
+    principal_id INTEGER NOT NULL,
+    permission VARCHAR(20) NOT NULL CHECK (permission IN ('read', 'write', 'publish')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (entity_type, entity_id, principal_type, principal_id)
+);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_pages_owner_group_id;
+DROP INDEX IF EXISTS idx_posts_owner_group_id;
+ALTER TABLE pages DROP COLUMN IF EXISTS owner_group_id;
+ALTER TABLE posts DROP COLUMN IF EXISTS owner_group_id;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
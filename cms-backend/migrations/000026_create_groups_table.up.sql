@@ -0,0 +1,24 @@
+-- Teams of API keys that can own content and receive ACL grants
+CREATE TABLE groups (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE group_members (
+    group_id INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (group_id, api_key_id)
+);
+
+CREATE INDEX idx_group_members_api_key_id ON group_members(api_key_id);
+
+-- The team responsible for each post and page
+ALTER TABLE posts ADD COLUMN owner_group_id INTEGER REFERENCES groups(id) ON DELETE SET NULL;
+ALTER TABLE pages ADD COLUMN owner_group_id INTEGER REFERENCES groups(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_posts_owner_group_id ON posts(owner_group_id);
+CREATE INDEX idx_pages_owner_group_id ON pages(owner_group_id);
@@ -0,0 +1,16 @@
+-- Audit trail of changes to posts and pages, read by the activity feeds
+CREATE TABLE activities (
+    id SERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    actor_key_id INTEGER REFERENCES api_keys(id) ON DELETE SET NULL,
+    actor_name VARCHAR(100),
+    fields VARCHAR(255),
+    from_status VARCHAR(20),
+    to_status VARCHAR(20),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_activities_entity ON activities(entity_type, entity_id, created_at DESC, id DESC);
+CREATE INDEX idx_activities_actor_key_id ON activities(actor_key_id, created_at DESC, id DESC);
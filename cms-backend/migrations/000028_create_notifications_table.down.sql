@@ -0,0 +1,2 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS email;
+DROP TABLE IF EXISTS notifications;
@@ -0,0 +1,20 @@
+-- In-app notifications raised by workflow events
+CREATE TABLE notifications (
+    id SERIAL PRIMARY KEY,
+    api_key_id INTEGER NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    message VARCHAR(500) NOT NULL,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    read_at TIMESTAMP WITH TIME ZONE,
+    emailed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notifications_api_key_id ON notifications(api_key_id, created_at DESC, id DESC);
+
+-- Unread notifications awaiting the email digest
+CREATE INDEX idx_notifications_digest ON notifications(created_at) WHERE read_at IS NULL AND emailed_at IS NULL;
+
+-- Where digests are sent; keys without an address only see notifications in-app
+ALTER TABLE api_keys ADD COLUMN email VARCHAR(255);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_media_quarantined;
+ALTER TABLE media DROP COLUMN IF EXISTS scanned_at;
+ALTER TABLE media DROP COLUMN IF EXISTS scan_signature;
+ALTER TABLE media DROP COLUMN IF EXISTS scan_status;
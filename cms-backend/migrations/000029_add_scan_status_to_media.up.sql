@@ -0,0 +1,6 @@
+-- Malware scan verdicts; media registered before scanning existed is unscanned
+ALTER TABLE media ADD COLUMN scan_status VARCHAR(20) NOT NULL DEFAULT 'unscanned';
+ALTER TABLE media ADD COLUMN scan_signature VARCHAR(255);
+ALTER TABLE media ADD COLUMN scanned_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_media_quarantined ON media(id) WHERE scan_status = 'quarantined';
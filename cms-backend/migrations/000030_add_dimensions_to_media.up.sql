@@ -0,0 +1,3 @@
+-- Pixel size of images, measured on registration when MEDIA_IMAGE_DIMENSIONS is on
+ALTER TABLE media ADD COLUMN width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE media ADD COLUMN height INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,11 @@
+-- Redirects from old paths on the public site, looked up by frontends before they 404
+CREATE TABLE redirects (
+    id SERIAL PRIMARY KEY,
+    from_path VARCHAR(500) NOT NULL UNIQUE,
+    to_path VARCHAR(500) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 301,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_redirects_to_path ON redirects(to_path);
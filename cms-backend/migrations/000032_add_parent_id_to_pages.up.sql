@@ -0,0 +1,4 @@
+-- Pages nest under a parent, whose path prefixes theirs on the public site
+ALTER TABLE pages ADD COLUMN parent_id INTEGER REFERENCES pages(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_pages_parent_id ON pages(parent_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS form_submissions;
+DROP TABLE IF EXISTS form_fields;
+DROP TABLE IF EXISTS forms;
@@ -0,0 +1,18 @@
+-- Newsletter subscribers, confirmed by double opt-in
+CREATE TABLE subscribers (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL UNIQUE,
+    name VARCHAR(255),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    confirm_token_hash VARCHAR(64),
+    confirm_sent_at TIMESTAMP WITH TIME ZONE,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    unsubscribed_at TIMESTAMP WITH TIME ZONE,
+    synced_status VARCHAR(20) NOT NULL DEFAULT '',
+    sync_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_subscribers_status ON subscribers(status);
+CREATE INDEX idx_subscribers_confirm_token_hash ON subscribers(confirm_token_hash);
@@ -0,0 +1,16 @@
+-- External links in published content and whether they still work
+CREATE TABLE links (
+    id SERIAL PRIMARY KEY,
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id INTEGER NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT,
+    broken BOOLEAN NOT NULL DEFAULT FALSE,
+    checked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (entity_type, entity_id, url)
+);
+
+CREATE INDEX idx_links_checked_at ON links(checked_at);
+CREATE INDEX idx_links_broken ON links(broken) WHERE broken;
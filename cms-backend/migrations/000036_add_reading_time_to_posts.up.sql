@@ -0,0 +1,7 @@
+-- Estimated reading time in minutes, kept up to date whenever a post is saved.
+-- The backfill counts whitespace-separated words at 200 per minute; the
+-- application's own estimate replaces it the next time a post is saved.
+ALTER TABLE posts ADD COLUMN reading_time INTEGER NOT NULL DEFAULT 0;
+
+UPDATE posts SET reading_time = CEIL(array_length(regexp_split_to_array(trim(content), '\s+'), 1) / 200.0)
+WHERE trim(content) <> '';
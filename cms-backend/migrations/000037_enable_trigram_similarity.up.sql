@@ -0,0 +1,2 @@
+-- Trigram similarity powers the duplicate check on new posts
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
@@ -0,0 +1,3 @@
+ALTER TABLE media DROP COLUMN IF EXISTS uuid;
+ALTER TABLE pages DROP COLUMN IF EXISTS uuid;
+ALTER TABLE posts DROP COLUMN IF EXISTS uuid;
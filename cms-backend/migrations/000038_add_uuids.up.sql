@@ -0,0 +1,11 @@
+-- Non-sequential IDs for posts, pages and media, so records cannot be
+-- enumerated when the API is run with ID_FORMAT=uuid. gen_random_uuid() is
+-- built into PostgreSQL 13 and later.
+ALTER TABLE posts ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX idx_posts_uuid ON posts (uuid);
+
+ALTER TABLE pages ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX idx_pages_uuid ON pages (uuid);
+
+ALTER TABLE media ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid();
+CREATE UNIQUE INDEX idx_media_uuid ON media (uuid);
@@ -0,0 +1,10 @@
+-- Saved searches over the posts, pages and media listings
+CREATE TABLE collections (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    resource VARCHAR(20) NOT NULL CHECK (resource IN ('posts', 'pages', 'media')),
+    query TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_posts_author;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,13 @@
+-- Author profiles, linked to posts by the author name they carry. Profiles
+-- keep their text when the avatar is deleted from the media library.
+CREATE TABLE users (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(100) NOT NULL UNIQUE,
+    display_name VARCHAR(255),
+    bio TEXT,
+    avatar_id INTEGER REFERENCES media(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_posts_author ON posts(author);
@@ -0,0 +1,4 @@
+ALTER TABLE pages DROP COLUMN IF EXISTS embargoed_until;
+ALTER TABLE pages DROP COLUMN IF EXISTS visibility;
+ALTER TABLE posts DROP COLUMN IF EXISTS embargoed_until;
+ALTER TABLE posts DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,6 @@
+-- Delivery visibility of posts and pages: public or members-only, optionally
+-- held back from everyone until embargoed_until
+ALTER TABLE posts ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+ALTER TABLE posts ADD COLUMN embargoed_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pages ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public';
+ALTER TABLE pages ADD COLUMN embargoed_until TIMESTAMP WITH TIME ZONE;
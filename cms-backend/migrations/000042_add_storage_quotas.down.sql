@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_media_uploaded_by;
+ALTER TABLE media DROP COLUMN IF EXISTS uploaded_by_id;
+ALTER TABLE users DROP COLUMN IF EXISTS storage_quota;
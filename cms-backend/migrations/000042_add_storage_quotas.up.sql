@@ -0,0 +1,7 @@
+-- Storage quotas for media imported into local storage. A user's usage is
+-- the size of the media they imported; a NULL quota falls back to
+-- MEDIA_STORAGE_QUOTA_BYTES.
+ALTER TABLE users ADD COLUMN storage_quota BIGINT;
+ALTER TABLE media ADD COLUMN uploaded_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_media_uploaded_by ON media(uploaded_by_id);
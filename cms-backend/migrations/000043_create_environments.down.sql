@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS environment_entries;
+DROP TABLE IF EXISTS environments;
@@ -0,0 +1,4 @@
+-- Tags nest under a parent tag, so they can be used as hierarchical categories
+ALTER TABLE tags ADD COLUMN parent_id INTEGER REFERENCES tags(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_tags_parent_id ON tags(parent_id);
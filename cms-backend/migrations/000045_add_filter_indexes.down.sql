@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_pages_title_trgm;
+DROP INDEX IF EXISTS idx_posts_title_trgm;
+DROP INDEX IF EXISTS idx_media_created_at_id;
+DROP INDEX IF EXISTS idx_media_type;
+DROP INDEX IF EXISTS idx_pages_created_at_id;
+DROP INDEX IF EXISTS idx_pages_status_created_at;
+DROP INDEX IF EXISTS idx_posts_status_created_at;
@@ -0,0 +1,15 @@
+-- Indexes for the columns lists filter and sort by. Slugs are already unique
+-- (000008) and posts.author is indexed (000040).
+
+-- Listings of one status, newest first, as the delivery API serves them
+CREATE INDEX idx_posts_status_created_at ON posts(status, created_at DESC, id DESC);
+CREATE INDEX idx_pages_status_created_at ON pages(status, created_at DESC, id DESC);
+
+CREATE INDEX idx_pages_created_at_id ON pages(created_at, id);
+
+CREATE INDEX idx_media_type ON media(type);
+CREATE INDEX idx_media_created_at_id ON media(created_at, id);
+
+-- Trigram indexes serve ?title= searches, which match with ILIKE '%...%'
+CREATE INDEX idx_posts_title_trgm ON posts USING gin (title gin_trgm_ops);
+CREATE INDEX idx_pages_title_trgm ON pages USING gin (title gin_trgm_ops);
@@ -0,0 +1,13 @@
+-- Media mirrored from external asset managers, keyed by the manager's own
+-- asset ID so its webhooks can update or remove the media later
+CREATE TABLE external_assets (
+    id SERIAL PRIMARY KEY,
+    source VARCHAR(50) NOT NULL,
+    external_id VARCHAR(255) NOT NULL,
+    media_id INTEGER NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX idx_external_assets_key ON external_assets(source, external_id);
+CREATE INDEX idx_external_assets_media_id ON external_assets(media_id);
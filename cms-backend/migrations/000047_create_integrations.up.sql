@@ -0,0 +1,17 @@
+-- Outbound integrations: templated POSTs to services such as Zapier on content
+-- events, each tracking its own position in the change feed like a webhook
+CREATE TABLE integrations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    filter TEXT NOT NULL DEFAULT '',
+    template TEXT NOT NULL,
+    headers JSONB NOT NULL DEFAULT '{}',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_seq BIGINT NOT NULL DEFAULT 0,
+    last_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+-- Slack and Discord channels announcing editorial events; each tracks how far
+-- it has read the activity trail and the submission queue
+CREATE TABLE chat_channels (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    provider VARCHAR(20) NOT NULL,
+    webhook_url VARCHAR(2048) NOT NULL,
+    events VARCHAR(255) NOT NULL DEFAULT '',
+    filter TEXT NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    last_activity_id INTEGER NOT NULL DEFAULT 0,
+    last_submission_id INTEGER NOT NULL DEFAULT 0,
+    last_status INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    last_attempt_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_pages_search;
+DROP INDEX IF EXISTS idx_posts_search;
+DROP FUNCTION IF EXISTS content_search_config(TEXT);
+ALTER TABLE pages DROP COLUMN IF EXISTS locale;
+ALTER TABLE posts DROP COLUMN IF EXISTS locale;
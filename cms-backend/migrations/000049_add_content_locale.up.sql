@@ -0,0 +1,16 @@
+-- The language content is written in, e.g. "en" or "de-AT"; empty when unknown
+ALTER TABLE posts ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
+ALTER TABLE pages ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
+
+-- content_search_config picks the text search configuration for a locale. It
+-- mirrors search.ConfigFor and is IMMUTABLE so the search indexes can use it.
+CREATE FUNCTION content_search_config(locale TEXT) RETURNS regconfig AS $$
+    SELECT CASE lower(split_part(replace(locale, '_', '-'), '-', 1))
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'de' THEN 'german'::regconfig
+        ELSE 'simple'::regconfig
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX idx_posts_search ON posts USING GIN (to_tsvector(content_search_config(locale), title || ' ' || content));
+CREATE INDEX idx_pages_search ON pages USING GIN (to_tsvector(content_search_config(locale), title || ' ' || content));
@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS syndication_source;
+ALTER TABLE posts DROP COLUMN IF EXISTS canonical_url;
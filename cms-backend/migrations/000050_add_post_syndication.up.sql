@@ -0,0 +1,4 @@
+-- Where a republished post was first published, so search engines credit the
+-- original and readers know the source of truth
+ALTER TABLE posts ADD COLUMN canonical_url VARCHAR(2048) NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN syndication_source VARCHAR(255) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+ALTER TABLE post_renders DROP COLUMN IF EXISTS amp;
+ALTER TABLE post_renders DROP COLUMN IF EXISTS text;
@@ -0,0 +1,5 @@
+-- Plaintext and AMP renderings are cached alongside the HTML; existing rows
+-- are dropped so every post is rendered again with all three
+DELETE FROM post_renders;
+ALTER TABLE post_renders ADD COLUMN text TEXT NOT NULL DEFAULT '';
+ALTER TABLE post_renders ADD COLUMN amp TEXT NOT NULL DEFAULT '';
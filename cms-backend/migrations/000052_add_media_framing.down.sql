@@ -0,0 +1,3 @@
+ALTER TABLE media DROP COLUMN IF EXISTS crops;
+ALTER TABLE media DROP COLUMN IF EXISTS focal_y;
+ALTER TABLE media DROP COLUMN IF EXISTS focal_x;
@@ -0,0 +1,4 @@
+-- Where the subject of an image is, and the crops editors have chosen
+ALTER TABLE media ADD COLUMN focal_x DOUBLE PRECISION CHECK (focal_x BETWEEN 0 AND 1);
+ALTER TABLE media ADD COLUMN focal_y DOUBLE PRECISION CHECK (focal_y BETWEEN 0 AND 1);
+ALTER TABLE media ADD COLUMN crops JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,2 @@
+-- Text alternatives read out by screen readers in place of images
+ALTER TABLE media ADD COLUMN alt_text VARCHAR(1000) NOT NULL DEFAULT '';
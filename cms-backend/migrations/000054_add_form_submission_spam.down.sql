@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS spam_tokens;
+DROP INDEX IF EXISTS idx_form_submissions_form_id_status;
+ALTER TABLE form_submissions DROP COLUMN IF EXISTS spam_score;
+ALTER TABLE form_submissions DROP COLUMN IF EXISTS status;
@@ -0,0 +1,11 @@
+-- Spam scores and moderation of form submissions
+ALTER TABLE form_submissions ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'accepted';
+ALTER TABLE form_submissions ADD COLUMN spam_score DOUBLE PRECISION;
+CREATE INDEX idx_form_submissions_form_id_status ON form_submissions(form_id, status);
+
+-- Word counts of the Bayesian spam filter; the '' row counts entries
+CREATE TABLE spam_tokens (
+    token VARCHAR(64) PRIMARY KEY,
+    spam_count INTEGER NOT NULL DEFAULT 0,
+    ham_count INTEGER NOT NULL DEFAULT 0
+);
@@ -0,0 +1,2 @@
+-- Fine-grained scopes narrowing what an API key may do within its role
+ALTER TABLE api_keys ADD COLUMN scopes VARCHAR(1000) NOT NULL DEFAULT '';
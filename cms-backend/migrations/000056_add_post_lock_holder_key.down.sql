@@ -0,0 +1 @@
+ALTER TABLE post_locks DROP COLUMN IF EXISTS holder_key_id;
@@ -0,0 +1,3 @@
+-- Post locks belong to the API key that took them; locks taken before this
+-- migration belong to no key and simply expire
+ALTER TABLE post_locks ADD COLUMN holder_key_id INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE jobs DROP COLUMN IF EXISTS requested_by_key_id;
@@ -0,0 +1,3 @@
+-- Job results belong to the API key that started the job; jobs started before
+-- this migration belong to no key, so only admins download their results
+ALTER TABLE jobs ADD COLUMN requested_by_key_id INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,251 @@
+// Package migrations runs and repairs the SQL migrations stored alongside it.
+// The files are embedded in the binary, so migrations run the same from any
+// working directory, including containers that ship nothing but the binary.
+package migrations
+
+import (
+	"cms-backend/utils"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"gorm.io/gorm"
+)
+
+// Files holds the migration files shipped with this build
+//
+//go:embed *.sql
+var Files embed.FS
+
+// Status describes the schema version recorded by golang-migrate
+type Status struct {
+	// Version is the last applied migration (0 when none has run)
+	Version uint `json:"version"`
+
+	// Dirty is set when a migration failed part-way; migrate refuses to run until it is cleared
+	Dirty bool `json:"dirty"`
+
+	// Latest is the newest migration shipped with this build
+	Latest uint `json:"latest"`
+
+	// Pending lists the shipped migrations newer than Version, oldest first
+	Pending []uint `json:"pending"`
+}
+
+// RunResult reports the outcome of re-running a single migration file
+type RunResult struct {
+	Version   uint     `json:"version"`
+	Direction string   `json:"direction"`
+	File      string   `json:"file"`
+	Success   bool     `json:"success"`
+	Log       []string `json:"log"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ErrMigrationNotFound is returned when no file exists for a version/direction
+var ErrMigrationNotFound = errors.New("migration file not found")
+
+// DatabaseURL builds the migrate connection URL from the DB_* environment variables
+func DatabaseURL() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
+		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+}
+
+// Up applies all pending migrations
+func Up() error {
+	m, err := open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to run migrations: %v", err)
+	}
+	return nil
+}
+
+// CurrentStatus returns the recorded schema version and dirty flag
+func CurrentStatus() (Status, error) {
+	m, err := open()
+	if err != nil {
+		return Status{}, err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return Status{}, err
+	}
+	return withPending(Status{Version: version, Dirty: dirty})
+}
+
+// StatusFromDB reads the schema version through an existing connection
+// rather than opening a new one, for frequent checks such as the readiness
+// probe
+func StatusFromDB(db *gorm.DB) (Status, error) {
+	var rows []struct {
+		Version int64
+		Dirty   bool
+	}
+	if err := db.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&rows).Error; err != nil {
+		return Status{}, err
+	}
+	status := Status{}
+	if len(rows) > 0 && rows[0].Version > 0 {
+		status = Status{Version: uint(rows[0].Version), Dirty: rows[0].Dirty}
+	}
+	return withPending(status)
+}
+
+// withPending fills in the migrations status has yet to apply
+func withPending(status Status) (Status, error) {
+	versions, err := Versions()
+	if err != nil {
+		return Status{}, err
+	}
+	status.Pending = []uint{}
+	for _, version := range versions {
+		if version > status.Version {
+			status.Pending = append(status.Pending, version)
+		}
+		status.Latest = version
+	}
+	return status, nil
+}
+
+// Versions lists the versions of the shipped migrations in order
+func Versions() ([]uint, error) {
+	files, err := fs.Glob(Files, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	var versions []uint
+	for _, file := range files {
+		if version, ok := fileVersion(file); ok {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions, nil
+}
+
+// Force records version as applied and clears the dirty flag without running any SQL.
+// Use it after fixing a failed migration by hand.
+func Force(version int) error {
+	m, err := open()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return m.Force(version)
+}
+
+// Rerun executes one migration file inside a transaction and captures a log of the run.
+// When an "up" migration for the current dirty version succeeds, the dirty flag is cleared.
+func Rerun(db *gorm.DB, version uint, direction string) RunResult {
+	result := RunResult{Version: version, Direction: direction, Log: []string{}}
+	logf := func(format string, args ...interface{}) {
+		line := time.Now().UTC().Format(time.RFC3339) + " " + fmt.Sprintf(format, args...)
+		result.Log = append(result.Log, line)
+	}
+	fail := func(err error) RunResult {
+		logf("error: %v", err)
+		result.Error = err.Error()
+		return result
+	}
+
+	if direction != "up" && direction != "down" {
+		return fail(fmt.Errorf("direction must be 'up' or 'down'"))
+	}
+
+	file, err := findFile(version, direction)
+	if err != nil {
+		return fail(err)
+	}
+	result.File = file
+
+	contents, err := fs.ReadFile(Files, file)
+	if err != nil {
+		return fail(err)
+	}
+
+	logf("running %s in a transaction", result.File)
+	started := time.Now()
+	err = utils.WithTransaction(db, func(tx *gorm.DB) error {
+		return tx.Exec(string(contents)).Error
+	})
+	if err != nil {
+		logf("transaction rolled back after %s", time.Since(started))
+		return fail(err)
+	}
+	logf("transaction committed after %s", time.Since(started))
+
+	if direction == "up" {
+		status, err := CurrentStatus()
+		if err != nil {
+			return fail(err)
+		}
+		if status.Dirty && status.Version == version {
+			if err := Force(int(version)); err != nil {
+				return fail(err)
+			}
+			logf("cleared dirty flag at version %d", version)
+		}
+	}
+
+	result.Success = true
+	return result
+}
+
+// findFile locates the migration file for a version and direction
+func findFile(version uint, direction string) (string, error) {
+	files, err := fs.Glob(Files, "*."+direction+".sql")
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if v, ok := fileVersion(file); ok && v == version {
+			return file, nil
+		}
+	}
+	return "", ErrMigrationNotFound
+}
+
+// fileVersion parses the version a migration file name starts with
+func fileVersion(file string) (uint, bool) {
+	prefix, _, ok := strings.Cut(path.Base(file), "_")
+	if !ok {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(prefix, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(version), true
+}
+
+// open creates a migrate instance for the embedded files and configured database
+func open() (*migrate.Migrate, error) {
+	source, err := iofs.New(Files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded migrations: %v", err)
+	}
+	m, err := migrate.NewWithSourceInstance("iofs", source, DatabaseURL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %v", err)
+	}
+	return m, nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// ACLEntry grants one principal a permission on a single post or page.
+// Content without entries is governed by roles alone; once it has any, only
+// admins and the principals granted access may read it while unpublished,
+// edit it or publish it.
+type ACLEntry struct {
+	ID uint `gorm:"primaryKey" json:"-"`
+
+	// EntityType is "post" or "page"
+	EntityType string `gorm:"size:20;not null;uniqueIndex:idx_acl_entries_grant" json:"-"`
+	EntityID   uint   `gorm:"not null;uniqueIndex:idx_acl_entries_grant" json:"-"`
+
+	// PrincipalType is "key" for an API key or "group" for every member of a group
+	PrincipalType string `gorm:"size:20;not null;uniqueIndex:idx_acl_entries_grant" json:"principal_type"`
+	PrincipalID   uint   `gorm:"not null;uniqueIndex:idx_acl_entries_grant" json:"principal_id"`
+
+	// Permission is "read", "write" or "publish"; each includes the ones before it
+	Permission string `gorm:"size:20;not null" json:"permission"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
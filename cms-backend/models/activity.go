@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Activity actions
+const (
+	ActivityCreated       = "created"
+	ActivityEdited        = "edited"
+	ActivityStatusChanged = "status_changed"
+	ActivityDeleted       = "deleted"
+)
+
+// Activity is one entry of the audit trail behind the activity feeds, written
+// by the handlers that change posts and pages in the same transaction as the
+// change itself
+type Activity struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// EntityType is "post" or "page"
+	EntityType string `gorm:"size:20;not null;index:idx_activities_entity" json:"entity_type"`
+	EntityID   uint   `gorm:"not null;index:idx_activities_entity" json:"entity_id"`
+
+	// Action is one of the Activity constants
+	Action string `gorm:"size:20;not null" json:"action"`
+
+	// ActorKeyID is the API key that made the change, nil for anonymous callers
+	ActorKeyID *uint  `gorm:"index" json:"actor_key_id"`
+	ActorName  string `gorm:"size:100" json:"actor_name,omitempty"`
+
+	// Fields lists the fields an edit changed, comma-separated
+	Fields string `gorm:"size:255" json:"fields,omitempty"`
+
+	// FromStatus and ToStatus are set for status changes
+	FromStatus string `gorm:"size:20" json:"from_status,omitempty"`
+	ToStatus   string `gorm:"size:20" json:"to_status,omitempty"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
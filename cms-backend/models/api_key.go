@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// APIKey authenticates API callers and assigns them a role.
+// Only a hash of the key is stored; the key itself is shown once on creation.
+type APIKey struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Name identifies the holder; for contributors it must match their post author name
+	Name string `gorm:"size:100;not null" json:"name"`
+
+	// Role is one of the auth.Role constants
+	Role string `gorm:"size:20;not null" json:"role"`
+
+	// Email receives digests of unread notifications; optional
+	Email string `gorm:"size:255" json:"email,omitempty"`
+
+	// Scopes is a comma-separated list of auth scopes such as "posts:read";
+	// empty leaves the key every route its role allows
+	Scopes string `gorm:"size:1000;not null" json:"scopes"`
+
+	// Prefix is the start of the key, shown so keys can be told apart
+	Prefix string `gorm:"size:16;not null" json:"prefix"`
+
+	KeyHash   string    `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
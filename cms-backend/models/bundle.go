@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Bundle records an offline content bundle built for a channel. Version is the
+// change feed sequence number the bundle is current to; bundles with Since
+// above zero hold only what changed after that version.
+type Bundle struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Channel string `gorm:"size:100;not null;uniqueIndex:idx_bundles_key" json:"channel"`
+	Since   int64  `gorm:"not null;uniqueIndex:idx_bundles_key" json:"since"`
+	Version int64  `gorm:"not null;uniqueIndex:idx_bundles_key" json:"version"`
+
+	// JobID is the job building the bundle file
+	JobID uint `gorm:"not null" json:"job_id"`
+	Job   Job  `gorm:"foreignKey:JobID" json:"-"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
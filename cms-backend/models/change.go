@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Change actions
+const (
+	ChangeInsert = "insert"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+)
+
+// Change is one entry of the change feed. Rows are written by database triggers
+// on posts, pages, media, tags and series, never by the application, and Seq
+// increases in commit order.
+type Change struct {
+	Seq int64 `gorm:"primaryKey" json:"seq"`
+
+	// EntityType is "post", "page", "media", "tag" or "series"
+	EntityType string `gorm:"size:20;not null" json:"entity_type"`
+	EntityID   uint   `gorm:"not null" json:"entity_id"`
+
+	// Action is ChangeInsert, ChangeUpdate or ChangeDelete
+	Action string `gorm:"size:10;not null" json:"action"`
+
+	ChangedAt time.Time `gorm:"not null" json:"changed_at"`
+}
+
+// ChangePrune records that changes up to PrunedThrough were deleted by the
+// retention policy, so cursors from before it can no longer be caught up
+type ChangePrune struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	PrunedThrough int64     `gorm:"not null" json:"pruned_through"`
+	PrunedAt      time.Time `gorm:"autoCreateTime" json:"pruned_at"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// ChatChannel announces editorial events, such as a post being published or a
+// submission awaiting review, in a Slack or Discord channel through its
+// incoming webhook. Events and Filter route only the events the channel cares
+// about, so e.g. #press can follow published press releases while #editors
+// follows the review queue.
+type ChatChannel struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name string `gorm:"size:100;not null" json:"name"`
+
+	// Provider is chat.ProviderSlack or chat.ProviderDiscord
+	Provider string `gorm:"size:20;not null" json:"provider"`
+
+	// WebhookURL is the channel's incoming webhook. Anyone holding it can post
+	// to the channel, so it is never shown once saved.
+	WebhookURL string `gorm:"size:2048;not null" json:"-"`
+
+	// Events is a comma-separated list of chat.Event* kinds; empty announces every kind
+	Events string `gorm:"size:255;not null" json:"events"`
+
+	// Filter is a webhooks.ParseFilter expression over entity, action, status,
+	// tag and id; empty announces every event of the chosen kinds
+	Filter string `gorm:"type:text;not null" json:"filter"`
+
+	Active bool `gorm:"not null" json:"active"`
+
+	// LastActivityID and LastSubmissionID are the activity and submission IDs
+	// announced up to
+	LastActivityID   uint `gorm:"not null" json:"last_activity_id"`
+	LastSubmissionID uint `gorm:"not null" json:"last_submission_id"`
+
+	// LastStatus, LastError and LastAttemptAt describe the latest delivery attempt
+	LastStatus    int        `gorm:"not null" json:"last_status"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
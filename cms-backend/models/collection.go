@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Collection is a saved search: a named listing of posts, pages or media
+// whose filters are re-evaluated each time its items are fetched
+type Collection struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name string `gorm:"size:255;not null" json:"name"`
+
+	// Resource is the listing searched: posts, pages or media
+	Resource string `gorm:"size:20;not null" json:"resource"`
+
+	// Query holds the listing's query parameters, URL-encoded, as in
+	// "status=draft&older_than_days=30&sort=created_at"
+	Query string `gorm:"type:text;not null" json:"query"`
+
+	// CreatedBy is the name of the caller that saved the collection
+	CreatedBy string `gorm:"size:100" json:"created_by"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
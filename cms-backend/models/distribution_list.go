@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DistributionList is a named group of press contacts that embargoed content is shared with
+type DistributionList struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name        string `gorm:"size:255;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Members are replaced as a whole on update
+	Members []DistributionMember `gorm:"foreignKey:ListID" json:"members"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// DistributionMember is one contact on a distribution list
+type DistributionMember struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	ListID uint   `gorm:"not null;index" json:"list_id"`
+	Name   string `gorm:"size:255" json:"name"`
+	Email  string `gorm:"size:255;not null" json:"email"`
+}
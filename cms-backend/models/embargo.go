@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Embargo holds a draft post back until PublishAt, when the scheduler
+// publishes it. Until then it is shared through EmbargoLinks.
+type Embargo struct {
+	ID     uint `gorm:"primaryKey" json:"id"`
+	PostID uint `gorm:"not null;uniqueIndex" json:"post_id"`
+
+	PublishAt time.Time `gorm:"not null;index" json:"publish_at"`
+
+	// PublishedAt is set once the scheduler has published the post
+	PublishedAt *time.Time `json:"published_at"`
+
+	Links []EmbargoLink `gorm:"foreignKey:EmbargoID" json:"links"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// TableName overrides GORM's pluralisation ("embargos")
+func (Embargo) TableName() string {
+	return "embargoes"
+}
+
+// EmbargoLink gives one distribution list member access to an embargoed post.
+// Only a hash of the link token is stored; the token is shown once on creation.
+type EmbargoLink struct {
+	ID        uint               `gorm:"primaryKey" json:"id"`
+	EmbargoID uint               `gorm:"not null;index" json:"-"`
+	MemberID  uint               `gorm:"not null" json:"member_id"`
+	Member    DistributionMember `gorm:"foreignKey:MemberID" json:"member"`
+	TokenHash string             `gorm:"size:64;not null;uniqueIndex" json:"-"`
+
+	// Access tracking, updated each time the link is opened
+	AccessCount     int        `gorm:"not null" json:"access_count"`
+	FirstAccessedAt *time.Time `json:"first_accessed_at"`
+	LastAccessedAt  *time.Time `json:"last_accessed_at"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// ProductionEnvironment names the live content: the posts and pages tables
+// themselves rather than an Environment
+const ProductionEnvironment = "production"
+
+// Environment is a named content space, such as "staging", where editors
+// prepare versions of posts and pages before promoting them to production
+// or to another environment
+type Environment struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name        string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// CreatedBy is the name of the caller that created the environment
+	CreatedBy string `gorm:"size:100" json:"created_by"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// EnvironmentEntry is an environment's version of one post or page, matched
+// to the content of other environments by its type and slug
+type EnvironmentEntry struct {
+	ID            uint `gorm:"primaryKey" json:"id"`
+	EnvironmentID uint `gorm:"not null;uniqueIndex:idx_environment_entries_key" json:"environment_id"`
+
+	// EntityType is "post" or "page"
+	EntityType string `gorm:"size:20;not null;uniqueIndex:idx_environment_entries_key" json:"entity_type"`
+	Slug       string `gorm:"size:255;not null;uniqueIndex:idx_environment_entries_key" json:"slug"`
+
+	Title   string `gorm:"size:255;not null" json:"title"`
+	Content string `gorm:"type:text;not null" json:"content"`
+
+	// Author is only promoted to posts
+	Author string `gorm:"size:255" json:"author,omitempty"`
+	Status string `gorm:"size:20;not null" json:"status"`
+
+	// UpdatedBy is the name of the caller that last staged the entry
+	UpdatedBy string `gorm:"size:100" json:"updated_by"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ExternalAsset links media to the asset it mirrors in an external asset
+// manager, such as Cloudinary. Deleting the media removes the link.
+type ExternalAsset struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Source names the asset manager and ExternalID the asset within it
+	Source     string `gorm:"size:50;not null;uniqueIndex:idx_external_assets_key" json:"source"`
+	ExternalID string `gorm:"size:255;not null;uniqueIndex:idx_external_assets_key" json:"external_id"`
+
+	MediaID uint `gorm:"not null;index" json:"media_id"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
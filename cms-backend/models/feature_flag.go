@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// FeatureFlag gates a feature under staged rollout. A flag is on for a caller
+// when it is enabled and either the caller's role is listed in Roles or the
+// caller falls within Percentage of all callers.
+type FeatureFlag struct {
+	ID          uint   `gorm:"primaryKey" json:"-"`
+	Name        string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Enabled     bool   `gorm:"not null" json:"enabled"`
+
+	// Percentage of callers (0-100) the flag is on for, chosen by a stable hash
+	Percentage int `gorm:"not null" json:"percentage"`
+
+	// Roles is a comma-separated list of roles the flag is always on for
+	Roles string `gorm:"size:255" json:"roles"`
+
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Form field types
+const (
+	FormFieldText     = "text"
+	FormFieldTextarea = "textarea"
+	FormFieldEmail    = "email"
+	FormFieldNumber   = "number"
+	FormFieldURL      = "url"
+	FormFieldSelect   = "select"
+	FormFieldCheckbox = "checkbox"
+)
+
+// Form is a form visitors fill in on the public site, such as a contact or
+// signup form. Its fields say what a submission may contain and how each
+// value is validated.
+type Form struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name        string `gorm:"size:255;not null" json:"name"`
+	Slug        string `gorm:"size:255;not null;uniqueIndex" json:"slug"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Fields are replaced as a whole on update and kept in Position order
+	Fields []FormField `gorm:"foreignKey:FormID" json:"fields"`
+
+	// Honeypot names a field kept hidden from visitors; submissions that fill
+	// it in are bots and are silently dropped. Empty disables the check.
+	Honeypot string `gorm:"size:100" json:"honeypot"`
+
+	// Active forms accept submissions; inactive ones are hidden from the public
+	Active bool `gorm:"not null" json:"active"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// FormField is one input of a form
+type FormField struct {
+	ID       uint `gorm:"primaryKey" json:"-"`
+	FormID   uint `gorm:"not null;index" json:"-"`
+	Position int  `gorm:"not null" json:"-"`
+
+	// Name is the key the value is submitted and stored under
+	Name  string `gorm:"size:100;not null" json:"name"`
+	Label string `gorm:"size:255" json:"label"`
+
+	// Type is one of the FormField* constants
+	Type     string `gorm:"size:20;not null" json:"type"`
+	Required bool   `gorm:"not null" json:"required"`
+
+	// MaxLength bounds the length of the value; 0 uses the default limit
+	MaxLength int `gorm:"not null" json:"max_length"`
+
+	// Pattern is a regular expression text values must match in full
+	Pattern string `gorm:"size:500" json:"pattern"`
+
+	// Options is a comma-separated list of the values a select field allows
+	Options string `gorm:"type:text" json:"options"`
+}
+
+// Form submission moderation states
+const (
+	FormSubmissionAccepted = "accepted"
+
+	// FormSubmissionHeld marks a submission the spam filter found suspicious,
+	// awaiting a moderator's verdict
+	FormSubmissionHeld = "held"
+	FormSubmissionSpam = "spam"
+)
+
+// FormSubmission is one accepted submission of a form. Data holds the JSON
+// object of submitted values keyed by field name.
+type FormSubmission struct {
+	ID     uint   `gorm:"primaryKey" json:"id"`
+	FormID uint   `gorm:"not null;index" json:"form_id"`
+	Data   string `gorm:"type:jsonb;not null" json:"data"`
+
+	IP        string `gorm:"size:45" json:"ip"`
+	UserAgent string `gorm:"size:500" json:"user_agent"`
+
+	// Status is one of the FormSubmission states
+	Status string `gorm:"size:20;not null" json:"status"`
+
+	// SpamScore is how likely the spam filter found the submission to be spam,
+	// from 0 to 1; nil when no filter checked it
+	SpamScore *float64 `json:"spam_score"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
+
+// MarshalJSON writes Data as JSON rather than as a string holding JSON
+func (s FormSubmission) MarshalJSON() ([]byte, error) {
+	type submission FormSubmission
+	return json.Marshal(struct {
+		submission
+		Data json.RawMessage `json:"data"`
+	}{submission(s), json.RawMessage(s.Data)})
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// Group is a team of API keys, such as "Marketing". Groups can own posts and
+// pages and be granted access to them, so work is assigned to a team rather
+// than to individuals.
+type Group struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name        string `gorm:"size:100;not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Members are managed through the /groups/:id/members endpoints
+	Members []GroupMember `gorm:"foreignKey:GroupID" json:"members"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// GroupMember puts one API key in a group
+type GroupMember struct {
+	GroupID  uint   `gorm:"primaryKey" json:"-"`
+	APIKeyID uint   `gorm:"primaryKey" json:"api_key_id"`
+	APIKey   APIKey `gorm:"foreignKey:APIKeyID" json:"api_key"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
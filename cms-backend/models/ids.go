@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// UUIDIDs reports whether the API runs with ID_FORMAT=uuid. Posts, pages and
+// media are then named by their UUID everywhere: in paths, in ?ids= and
+// request bodies, and as the "id" of responses, so their sequential integer
+// IDs never reach clients.
+func UUIDIDs() bool {
+	return os.Getenv("ID_FORMAT") == "uuid"
+}
+
+// MarshalJSON writes the UUID as the post's id with ID_FORMAT=uuid
+func (p Post) MarshalJSON() ([]byte, error) {
+	type post Post
+	if !UUIDIDs() {
+		return json.Marshal(post(p))
+	}
+	return json.Marshal(struct {
+		post
+		ID string `json:"id"`
+	}{post(p), p.UUID})
+}
+
+// MarshalJSON writes the UUID as the page's id with ID_FORMAT=uuid
+func (p Page) MarshalJSON() ([]byte, error) {
+	type page Page
+	if !UUIDIDs() {
+		return json.Marshal(page(p))
+	}
+	return json.Marshal(struct {
+		page
+		ID string `json:"id"`
+	}{page(p), p.UUID})
+}
+
+// MarshalJSON writes the UUID as the media item's id with ID_FORMAT=uuid
+func (m Media) MarshalJSON() ([]byte, error) {
+	type media Media
+	if !UUIDIDs() {
+		return json.Marshal(media(m))
+	}
+	return json.Marshal(struct {
+		media
+		ID string `json:"id"`
+	}{media(m), m.UUID})
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Integration posts a templated JSON body to a third-party service, such as a
+// Zapier catch hook, for every content change matching Filter. Unlike webhooks,
+// integrations are managed by admins, carry the changed entity itself and
+// authenticate with custom headers rather than a signature.
+type Integration struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name string `gorm:"size:100;not null" json:"name"`
+	URL  string `gorm:"size:2048;not null" json:"url"`
+
+	// Filter is a webhooks.ParseFilter expression; empty delivers every event
+	Filter string `gorm:"type:text;not null" json:"filter"`
+
+	// Template is a Go text/template mapping the event and entity onto the
+	// JSON body the service expects
+	Template string `gorm:"type:text;not null" json:"template"`
+
+	// Headers is a JSON object of extra request headers, e.g. an API token.
+	// Values are never shown once saved.
+	Headers string `gorm:"type:jsonb;not null" json:"-"`
+
+	Active bool `gorm:"not null" json:"active"`
+
+	// LastSeq is the change feed sequence number processed up to
+	LastSeq int64 `gorm:"not null" json:"last_seq"`
+
+	// LastStatus, LastError and LastAttemptAt describe the latest delivery attempt
+	LastStatus    int        `gorm:"not null" json:"last_status"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+)
+
+// Job records a background task (exports, imports) and its progress
+type Job struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Type names the kind of work, e.g. "epub_export"
+	Type string `gorm:"size:50;not null" json:"type"`
+
+	// Status is one of queued, running, completed or failed
+	Status string `gorm:"size:20;not null;index" json:"status"`
+
+	// Progress is a percentage from 0 to 100
+	Progress int `gorm:"not null;default:0" json:"progress"`
+
+	// RequestedByKeyID is the API key that started the job, 0 when the server
+	// or an anonymous caller did; only that key and admins download the result
+	RequestedByKeyID uint `gorm:"not null;default:0" json:"-"`
+
+	// Payload holds the JSON-encoded job arguments
+	Payload string `gorm:"type:text" json:"payload,omitempty"`
+
+	// Error is set when the job fails
+	Error string `gorm:"type:text" json:"error,omitempty"`
+
+	// ResultPath is the file produced by the job, served by the download endpoint
+	ResultPath string `gorm:"size:255" json:"-"`
+
+	// DownloadURL is filled in by the controller when a result is available
+	DownloadURL string `gorm:"-" json:"download_url,omitempty"`
+
+	CreatedAt  time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
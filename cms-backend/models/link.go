@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Link is an external link found in a published post or page, with the
+// result of the latest check of it. Links are found and checked by the
+// linkcheck package.
+type Link struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// EntityType is "post" or "page"
+	EntityType string `gorm:"size:20;not null;uniqueIndex:idx_links_entity_url" json:"entity_type"`
+	EntityID   uint   `gorm:"not null;uniqueIndex:idx_links_entity_url" json:"entity_id"`
+	URL        string `gorm:"size:2048;not null;uniqueIndex:idx_links_entity_url" json:"url"`
+
+	// StatusCode is the HTTP status of the latest check, 0 when the request failed
+	StatusCode int    `gorm:"not null" json:"status_code"`
+	Error      string `gorm:"type:text" json:"error,omitempty"`
+	Broken     bool   `gorm:"not null;index" json:"broken"`
+
+	// CheckedAt is nil until the link is first checked
+	CheckedAt *time.Time `gorm:"index" json:"checked_at"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
@@ -2,6 +2,16 @@ package models
 
 import "time"
 
+// Malware scan verdicts recorded on media
+const (
+	// ScanUnscanned marks media accepted while no scanner was configured
+	ScanUnscanned = "unscanned"
+	ScanClean     = "clean"
+
+	// ScanQuarantined marks flagged media kept, hidden from everyone but admins
+	ScanQuarantined = "quarantined"
+)
+
 // This struct includes fields for:
 // - ID (unsigned integer, primary key)
 // - URL (string, required, with max length)
@@ -12,12 +22,47 @@ import "time"
 type Media struct {
 	//ID field as uint with gorm tag for primary key and json tag
     ID        uint      `gorm:"primaryKey" json:"id"`
+    // UUID is a non-sequential ID assigned by the database; with ID_FORMAT=uuid
+    // it is the only ID the API accepts in paths
+    UUID      string    `gorm:"type:uuid;default:gen_random_uuid();->" json:"uuid,omitempty"`
 
 	//URL field as string with gorm tag for size limit (255) and not null constraint and json tag and binding tag to make it required
     URL       string    `gorm:"size:255;not null" json:"url" binding:"required"`
 	
 	//Type field as string with gorm tag for size limit (50) and json tag and binding tag to make it required
     Type      string    `gorm:"size:50" json:"type" binding:"required"`
+
+	// Size is the file size in bytes as reported by the uploader, 0 when unknown
+    Size      int64     `gorm:"not null" json:"size"`
+
+	// Width and Height are the displayed pixel size of images, 0 when not measured
+    Width  int `gorm:"not null" json:"width,omitempty"`
+    Height int `gorm:"not null" json:"height,omitempty"`
+
+	// AltText describes an image for readers who cannot see it
+    AltText string `gorm:"size:1000;not null" json:"alt_text"`
+
+	// FocalX and FocalY locate the subject of an image as fractions of its width
+	// and height from the top left, so automated crops keep it in frame; nil
+	// when no focal point is set and crops center on the image
+    FocalX *float64 `json:"focal_x"`
+    FocalY *float64 `json:"focal_y"`
+
+	// Crops are named crops chosen by editors
+    Crops Crops `gorm:"type:jsonb;not null" json:"crops"`
+
+	// ScanStatus is the malware scan verdict, one of the Scan constants; set by the server
+    ScanStatus string `gorm:"size:20;not null" json:"scan_status"`
+
+	// ScanSignature names what the scanner found in a quarantined file
+    ScanSignature string `gorm:"size:255" json:"scan_signature,omitempty"`
+
+	// ScannedAt is when the file was scanned, nil when no scanner is configured
+    ScannedAt *time.Time `json:"scanned_at"`
+
+	// UploadedByID is the user who imported the file into storage, whose
+	// quota it counts against; nil for media hosted elsewhere
+    UploadedByID *uint `gorm:"index" json:"uploaded_by_id,omitempty"`
 	
 	//CreatedAt field as time.Time with gorm tag for automatic timestamp on creation and json tag
     CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Crop is a named rectangle of an image in source pixels
+type Crop struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Crops are the named crops of an image, e.g. "square" or "banner", stored
+// as a JSON object
+type Crops map[string]Crop
+
+// Value stores the crops as JSON; no crops are stored as an empty object
+func (c Crops) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(map[string]Crop(c))
+	return string(data), err
+}
+
+// Scan reads crops stored by Value
+func (c *Crops) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into Crops", value)
+	}
+	return json.Unmarshal(data, (*map[string]Crop)(c))
+}
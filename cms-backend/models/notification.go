@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Notification kinds
+const (
+	// NotificationSubmissionPending tells reviewers a submission awaits review
+	NotificationSubmissionPending = "submission_pending"
+
+	// NotificationSubmissionReviewed tells a submitter their submission was
+	// accepted or rejected
+	NotificationSubmissionReviewed = "submission_reviewed"
+
+	// NotificationAccessGranted tells a key it was granted access to a
+	// restricted post or page, such as a draft handed over for review
+	NotificationAccessGranted = "access_granted"
+)
+
+// Notification is an in-app message for one API key, raised by a workflow
+// event. Notifications still unread after a while are emailed in a digest.
+type Notification struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// APIKeyID is the recipient
+	APIKeyID uint `gorm:"not null;index" json:"api_key_id"`
+
+	// Kind is one of the Notification constants
+	Kind    string `gorm:"size:50;not null" json:"kind"`
+	Message string `gorm:"size:500;not null" json:"message"`
+
+	// EntityType and EntityID name what the notification is about, e.g.
+	// "submission" 4 or "post" 12
+	EntityType string `gorm:"size:20;not null" json:"entity_type"`
+	EntityID   uint   `gorm:"not null" json:"entity_id"`
+
+	ReadAt *time.Time `json:"read_at"`
+
+	// EmailedAt is set once the notification went out in a digest
+	EmailedAt *time.Time `json:"-"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+}
@@ -16,18 +16,48 @@ type Page struct {
 	// - gorm tag for primary key
 	// - json tag for serialization
 	ID uint `gorm:"primaryKey" json:"id"`
+	// UUID is a non-sequential ID assigned by the database; with ID_FORMAT=uuid
+	// it is the only ID the API accepts in paths
+	UUID string `gorm:"type:uuid;default:gen_random_uuid();->" json:"uuid,omitempty"`
 
 	// TODO: Add Title field as string with:
 	// - gorm tags for size limit (255) and not null constraint
 	// - json tag for serialization
-	// - binding tag to make it required
-	Title string `gorm:"size:255;not null" json:"title" binding:"required"`
+	Title string `gorm:"size:255;not null" json:"title"`
 
 	// TODO: Add Content field as string with:
 	// - gorm tag specifying text type and not null constraint
 	// - json tag for serialization
-	// - binding tag to make it required
-	Content string `gorm:"type:text;not null" json:"content" binding:"required"`
+	Content string `gorm:"type:text;not null" json:"content"`
+
+	// Slug is the URL-friendly identifier, generated from the title when omitted
+	Slug string `gorm:"size:255;uniqueIndex" json:"slug"`
+
+	// Locale is the language the content is written in, e.g. "en" or "de-AT";
+	// it selects the stemming full-text search applies. Empty when unknown.
+	Locale string `gorm:"size:35;not null" json:"locale"`
+
+	// ParentID nests the page under another, whose path comes before its slug
+	// in the page's path; 0 on update clears it
+	ParentID *uint `gorm:"index" json:"parent_id"`
+
+	// Status is StatusDraft, StatusPublished or StatusArchived
+	Status string `gorm:"size:20;not null" json:"status"`
+
+	// ExpiresAt, when set, is when the scheduler archives published content
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Visibility is who the delivery API shows published content to:
+	// VisibilityPublic or VisibilityMembers. Until EmbargoedUntil, when set,
+	// it is shown to no one.
+	Visibility     string     `gorm:"size:20;not null" json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+
+	// Notes are internal editorial workflow notes, never shown to delivery callers
+	Notes string `gorm:"type:text" json:"notes,omitempty"`
+
+	// OwnerGroupID is the team responsible for the page; 0 on update clears it
+	OwnerGroupID *uint `json:"owner_group_id"`
 
 	// TODO: Add CreatedAt field using time.Time with:
 	// - gorm tag for automatic timestamp on creation
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Playlist is an ordered loop of media and pages shown by signage players
+type Playlist struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name        string `gorm:"size:255;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+
+	// Items in play order, replaced as a whole on update
+	Items []PlaylistItem `gorm:"foreignKey:PlaylistID" json:"items"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// PlaylistItem shows one media file or page for DurationSeconds. Items with a
+// validity window are only played between StartsAt and EndsAt.
+type PlaylistItem struct {
+	ID         uint `gorm:"primaryKey" json:"id"`
+	PlaylistID uint `gorm:"not null;index" json:"-"`
+	Position   int  `gorm:"not null" json:"position"`
+
+	// Exactly one of MediaID and PageID is set
+	MediaID *uint  `json:"media_id,omitempty"`
+	Media   *Media `gorm:"foreignKey:MediaID" json:"media,omitempty"`
+	PageID  *uint  `json:"page_id,omitempty"`
+	Page    *Page  `gorm:"foreignKey:PageID" json:"page,omitempty"`
+
+	DurationSeconds int        `gorm:"not null" json:"duration_seconds"`
+	StartsAt        *time.Time `json:"starts_at"`
+	EndsAt          *time.Time `json:"ends_at"`
+}
+
+// ActiveAt reports whether the item's validity window includes t
+func (i PlaylistItem) ActiveAt(t time.Time) bool {
+	if i.StartsAt != nil && t.Before(*i.StartsAt) {
+		return false
+	}
+	return i.EndsAt == nil || t.Before(*i.EndsAt)
+}
@@ -18,23 +18,74 @@ type Post struct {
 	// - gorm tag for primary key
 	// - json tag for serialization
 	ID uint `gorm:"primaryKey" json:"id"`
+	// UUID is a non-sequential ID assigned by the database; with ID_FORMAT=uuid
+	// it is the only ID the API accepts in paths
+	UUID string `gorm:"type:uuid;default:gen_random_uuid();->" json:"uuid,omitempty"`
 	// TODO: Add Title field as string with:
 	// - gorm tags for size limit (255) and not null constraint
 	// - json tag for serialization
-	// - binding tag to make it required
-	Title string `gorm:"size:255" json:"title" binding:"required"`
+	Title string `gorm:"size:255" json:"title"`
 
 	// TODO: Add Content field as string with:
 	// - gorm tag specifying text type and not null constraint
 	// - json tag for serialization
-	// - binding tag to make it required
-	Content string `gorm:"type:text;not null" json:"content" binding:"required"`
+	Content string `gorm:"type:text;not null" json:"content"`
+
+	// ContentHTML is the rendered Markdown content, filled only for ?format=html
+	ContentHTML string `gorm:"-" json:"content_html,omitempty"`
+
+	// ContentText and ContentAMP are the plaintext and AMP renderings, filled
+	// only for ?format=text and ?format=amp
+	ContentText string `gorm:"-" json:"content_text,omitempty"`
+	ContentAMP  string `gorm:"-" json:"content_amp,omitempty"`
+
+	// ReadingTime is the estimated minutes to read Content, set on every save
+	ReadingTime int `gorm:"not null" json:"reading_time"`
 
 	// TODO: Add Author field as string with:
 	// - gorm tag for size limit (100)
 	// - json tag for serialization
 	Author string `gorm:"size:100" json:"author"`
 
+	// AuthorEmail is the author's contact address, redacted for most callers
+	AuthorEmail string `gorm:"size:255" json:"author_email,omitempty"`
+
+	// Slug is the URL-friendly identifier, generated from the title when omitted
+	Slug string `gorm:"size:255;uniqueIndex" json:"slug"`
+
+	// Locale is the language the content is written in, e.g. "en" or "de-AT";
+	// it selects the stemming full-text search applies. Empty when unknown.
+	Locale string `gorm:"size:35;not null" json:"locale"`
+
+	// CanonicalURL, when set, is where the post was first published; it is
+	// republished here and search engines should credit the original.
+	// SyndicationSource names the original publication, e.g. "The Guardian".
+	CanonicalURL      string `gorm:"size:2048;not null" json:"canonical_url"`
+	SyndicationSource string `gorm:"size:255;not null" json:"syndication_source"`
+
+	// Status is StatusDraft, StatusPublished or StatusArchived
+	Status string `gorm:"size:20;not null" json:"status"`
+
+	// ExpiresAt, when set, is when the scheduler archives published content
+	ExpiresAt *time.Time `json:"expires_at"`
+
+	// Visibility is who the delivery API shows published content to:
+	// VisibilityPublic or VisibilityMembers. Until EmbargoedUntil, when set,
+	// it is shown to no one.
+	Visibility     string     `gorm:"size:20;not null" json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+
+	// Notes are internal editorial workflow notes, never shown to delivery callers
+	Notes string `gorm:"type:text" json:"notes,omitempty"`
+
+	// Featured posts are curated for homepages in Position order (1-based).
+	// Both are managed through PATCH /posts/reorder.
+	Featured bool `gorm:"not null" json:"featured"`
+	Position int  `gorm:"not null" json:"position"`
+
+	// OwnerGroupID is the team responsible for the post; 0 on update clears it
+	OwnerGroupID *uint `json:"owner_group_id"`
+
 	// TODO: Add CreatedAt field using time.Time with:
 	// - gorm tag for automatic timestamp on creation
 	// - json tag for serialization
@@ -49,4 +100,11 @@ type Post struct {
 	// - gorm tag for many-to-many relationship (specify junction table name: post_media)
 	// - json tag for serialization
 	Media []Media `gorm:"many2many:post_media" json:"media"`
+
+	// Tags attached to the post (junction table: post_tags)
+	Tags []Tag `gorm:"many2many:post_tags" json:"tags"`
+
+	// Related posts curated by editors (junction table: post_relations).
+	// Managed through the /posts/:id/related endpoints, never bound from JSON.
+	Related []Post `gorm:"many2many:post_relations;joinForeignKey:PostID;joinReferences:RelatedPostID" json:"-"`
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// PostLock is a soft edit lock on a post. A lock is advisory: it warns other
+// editors that someone is working on the post and expires on its own if the
+// holder disappears without releasing it.
+type PostLock struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// PostID is unique so a post has at most one lock row
+	PostID uint `gorm:"not null;uniqueIndex" json:"post_id"`
+
+	// Holder is the name of the API key holding the lock, shown to other editors
+	Holder string `gorm:"size:100;not null" json:"holder"`
+
+	// HolderKeyID is the API key holding the lock; only it may refresh or release the lock
+	HolderKeyID uint `gorm:"not null" json:"holder_key_id"`
+
+	AcquiredAt time.Time `gorm:"not null" json:"acquired_at"`
+	ExpiresAt  time.Time `gorm:"not null" json:"expires_at"`
+}
+
+// IsActive reports whether the lock is still held at the given time
+func (l PostLock) IsActive(now time.Time) bool {
+	return l.ExpiresAt.After(now)
+}
@@ -0,0 +1,8 @@
+package models
+
+// PostRelation is a row of the post_relations junction table behind Post.Related.
+// Relations are stored in both directions so either post lists the other.
+type PostRelation struct {
+	PostID        uint `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	RelatedPostID uint `gorm:"primaryKey;autoIncrement:false" json:"related_post_id"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PostRender caches the sanitized HTML, plaintext and AMP renderings of a post's
+// Markdown content. ContentHash identifies the source they were rendered from,
+// so a stale row is never served.
+type PostRender struct {
+	PostID      uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	ContentHash string    `gorm:"size:64;not null" json:"content_hash"`
+	HTML        string    `gorm:"column:html;type:text;not null" json:"html"`
+	Text        string    `gorm:"type:text;not null" json:"text"`
+	AMP         string    `gorm:"column:amp;type:text;not null" json:"amp"`
+	RenderedAt  time.Time `gorm:"not null" json:"rendered_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// PostView counts the views of a post on one day (UTC). Views are buffered in
+// memory and added to these rows in batches.
+type PostView struct {
+	PostID uint      `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Day    time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views  int64     `gorm:"not null" json:"views"`
+}
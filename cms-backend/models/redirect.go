@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Redirect sends visitors of FromPath on the public site to ToPath, a path on
+// the site or an absolute URL, with StatusCode. Renaming a published post or
+// page creates one from its old path.
+type Redirect struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	FromPath   string `gorm:"size:500;not null;uniqueIndex" json:"from_path"`
+	ToPath     string `gorm:"size:500;not null" json:"to_path"`
+	StatusCode int    `gorm:"not null" json:"status_code"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
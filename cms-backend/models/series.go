@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Series is an ordered collection of posts, such as a multi-part tutorial
+type Series struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Title string `gorm:"size:255;not null" json:"title" binding:"required"`
+
+	Description string `gorm:"type:text" json:"description"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+
+	// Posts in reading order, loaded from series_posts by the controller
+	Posts []Post `gorm:"-" json:"posts,omitempty"`
+}
+
+// SeriesPost places a post at a position within a series
+type SeriesPost struct {
+	SeriesID uint `gorm:"primaryKey;autoIncrement:false" json:"series_id"`
+	PostID   uint `gorm:"primaryKey;autoIncrement:false" json:"post_id"`
+	Position int  `gorm:"not null" json:"position"`
+}
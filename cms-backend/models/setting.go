@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Setting is one site-level configuration value, addressed by namespace and
+// key (e.g. site/title). Value holds the JSON encoding of a value of Type.
+type Setting struct {
+	ID        uint   `gorm:"primaryKey" json:"-"`
+	Namespace string `gorm:"size:100;not null;uniqueIndex:idx_settings_namespace_key" json:"namespace"`
+	Key       string `gorm:"size:100;not null;uniqueIndex:idx_settings_namespace_key" json:"key"`
+
+	// Type is one of the settings.Type constants
+	Type  string `gorm:"size:10;not null" json:"type"`
+	Value string `gorm:"type:jsonb;not null" json:"value"`
+
+	Description string    `gorm:"type:text" json:"description"`
+	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
+
+// MarshalJSON writes Value as JSON rather than as a string holding JSON
+func (s Setting) MarshalJSON() ([]byte, error) {
+	type setting Setting
+	return json.Marshal(struct {
+		setting
+		Value json.RawMessage `json:"value"`
+	}{setting(s), json.RawMessage(s.Value)})
+}
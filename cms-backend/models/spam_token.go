@@ -0,0 +1,10 @@
+package models
+
+// SpamToken counts the spam and legitimate entries moderators have seen a word
+// in, for the Bayesian spam filter. The row with an empty Token counts the
+// entries themselves.
+type SpamToken struct {
+	Token     string `gorm:"primaryKey;size:64" json:"token"`
+	SpamCount int    `gorm:"not null" json:"spam_count"`
+	HamCount  int    `gorm:"not null" json:"ham_count"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// Publication states shared by posts and pages
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+
+	// StatusArchived marks content taken down when its ExpiresAt passed
+	StatusArchived = "archived"
+)
+
+// ValidStatus reports whether s is a known publication state
+func ValidStatus(s string) bool {
+	return s == StatusDraft || s == StatusPublished || s == StatusArchived
+}
+
+// Visibility levels of published posts and pages on the delivery API
+const (
+	VisibilityPublic = "public"
+
+	// VisibilityMembers content is only delivered to member tokens
+	VisibilityMembers = "members"
+)
+
+// ValidVisibility reports whether v is a known visibility level
+func ValidVisibility(v string) bool {
+	return v == VisibilityPublic || v == VisibilityMembers
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Submission review states
+const (
+	SubmissionPending  = "pending"
+	SubmissionAccepted = "accepted"
+	SubmissionRejected = "rejected"
+)
+
+// Submission is a draft sent in by an external contributor for review.
+// Accepting it creates a draft post; the submitter never sees other content.
+type Submission struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// APIKeyID is the submitter's key; submitters only see their own submissions
+	APIKeyID  uint   `gorm:"not null;index" json:"api_key_id"`
+	Submitter string `gorm:"size:100;not null" json:"submitter"`
+
+	Title   string `gorm:"size:255;not null" json:"title"`
+	Content string `gorm:"type:text;not null" json:"content"`
+
+	// Status is SubmissionPending, SubmissionAccepted or SubmissionRejected
+	Status string `gorm:"size:20;not null;index" json:"status"`
+
+	// ReviewNote is the reviewer's feedback, sent with the notification
+	ReviewNote string     `gorm:"type:text" json:"review_note"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+
+	// PostID is the draft post created on acceptance
+	PostID *uint `json:"post_id"`
+
+	// NotifyURL, when set, receives a POST once the submission is reviewed
+	NotifyURL       string     `gorm:"size:2048" json:"notify_url"`
+	NotifiedAt      *time.Time `json:"notified_at"`
+	NotifyAttempts  int        `gorm:"not null" json:"-"`
+	NotifyLastError string     `gorm:"type:text" json:"-"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Subscriber statuses. Subscribers start pending until they confirm their
+// address through the link emailed to them (double opt-in).
+const (
+	SubscriberPending      = "pending"
+	SubscriberSubscribed   = "subscribed"
+	SubscriberUnsubscribed = "unsubscribed"
+)
+
+// Subscriber is an email address signed up for the newsletter
+type Subscriber struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Email is stored lowercased so each address signs up once
+	Email string `gorm:"size:255;not null;uniqueIndex" json:"email"`
+	Name  string `gorm:"size:255" json:"name"`
+
+	// Status is one of the Subscriber* constants
+	Status string `gorm:"size:20;not null;index" json:"status"`
+
+	// ConfirmTokenHash is the digest of the token in the latest confirmation
+	// email, sent at ConfirmSentAt
+	ConfirmTokenHash string     `gorm:"size:64;index" json:"-"`
+	ConfirmSentAt    *time.Time `json:"-"`
+
+	ConfirmedAt    *time.Time `json:"confirmed_at"`
+	UnsubscribedAt *time.Time `json:"unsubscribed_at"`
+
+	// SyncedStatus is the status last pushed to the mailing list provider and
+	// SyncError why the latest push failed
+	SyncedStatus string `gorm:"size:20;not null" json:"synced_status"`
+	SyncError    string `gorm:"type:text" json:"sync_error,omitempty"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Tag is a free-form label used to group related posts
+type Tag struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// Name is unique so the same label is never stored twice
+	Name string `gorm:"size:100;not null;uniqueIndex" json:"name" binding:"required"`
+
+	// ParentID nests the tag under another, as a subcategory; 0 on update
+	// clears it
+	ParentID *uint `gorm:"index" json:"parent_id"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// User is an author's public profile. Username is the name posts carry in
+// their Author field, which links an author to their posts.
+type User struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Username    string `gorm:"size:100;not null;uniqueIndex" json:"username"`
+	DisplayName string `gorm:"size:255" json:"display_name"`
+	Bio         string `gorm:"type:text" json:"bio"`
+
+	// Avatar is an image from the media library
+	AvatarID *uint  `json:"avatar_id"`
+	Avatar   *Media `gorm:"foreignKey:AvatarID" json:"avatar,omitempty"`
+
+	// StorageQuota caps the bytes of media the user may import into storage;
+	// nil uses MEDIA_STORAGE_QUOTA_BYTES
+	StorageQuota *int64 `json:"storage_quota"`
+
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Webhook delivers content change events matching Filter to URL. Each webhook
+// belongs to the API key that registered it and keeps its own position in the
+// change feed, so a failing endpoint is retried without holding up others.
+type Webhook struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	// APIKeyID is the key that registered the webhook; only it and admins manage it
+	APIKeyID uint `gorm:"not null;index" json:"api_key_id"`
+
+	Name string `gorm:"size:100;not null" json:"name"`
+	URL  string `gorm:"size:2048;not null" json:"url"`
+
+	// Filter is a webhooks.ParseFilter expression; empty delivers every event
+	Filter string `gorm:"type:text;not null" json:"filter"`
+
+	// PayloadVersion is the webhooks.PayloadV* version deliveries are rendered in
+	PayloadVersion int `gorm:"not null" json:"payload_version"`
+
+	// Template, when set, is a Go text/template rendering a custom JSON body,
+	// e.g. a Slack message, in place of the versioned payload
+	Template string `gorm:"type:text;not null" json:"template"`
+
+	// Secret signs deliveries; it is shown once when the webhook is created
+	Secret string `gorm:"size:100;not null" json:"-"`
+
+	Active bool `gorm:"not null" json:"active"`
+
+	// LastSeq is the change feed sequence number processed up to
+	LastSeq int64 `gorm:"not null" json:"last_seq"`
+
+	// LastStatus, LastError and LastAttemptAt describe the latest delivery attempt
+	LastStatus    int        `gorm:"not null" json:"last_status"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
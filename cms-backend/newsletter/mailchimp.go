@@ -0,0 +1,88 @@
+package newsletter
+
+import (
+	"bytes"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Mailchimp pushes subscribers to an audience through the Mailchimp Marketing
+// API (v3) or a service compatible with its list members endpoint
+type Mailchimp struct {
+	// BaseURL is the API root, such as https://us21.api.mailchimp.com/3.0
+	BaseURL string
+	ListID  string
+	APIKey  string
+	Client  *http.Client
+}
+
+// MailchimpFromEnv configures Mailchimp from MAILCHIMP_API_KEY and
+// MAILCHIMP_LIST_ID. MAILCHIMP_API_URL overrides the API root, which is
+// otherwise taken from the data center suffix of the key (e.g. "-us21").
+func MailchimpFromEnv() (*Mailchimp, error) {
+	m := &Mailchimp{
+		BaseURL: os.Getenv("MAILCHIMP_API_URL"),
+		ListID:  os.Getenv("MAILCHIMP_LIST_ID"),
+		APIKey:  os.Getenv("MAILCHIMP_API_KEY"),
+		Client:  egress.NewClient(egress.PolicyFromEnv(), 30*time.Second),
+	}
+	if m.APIKey == "" || m.ListID == "" {
+		return nil, errors.New("MAILCHIMP_API_KEY and MAILCHIMP_LIST_ID are required when NEWSLETTER_SYNC=mailchimp")
+	}
+	if m.BaseURL == "" {
+		_, dc, ok := strings.Cut(m.APIKey, "-")
+		if !ok || dc == "" {
+			return nil, errors.New("MAILCHIMP_API_URL is required when MAILCHIMP_API_KEY has no data center suffix")
+		}
+		m.BaseURL = "https://" + dc + ".api.mailchimp.com/3.0"
+	}
+	return m, nil
+}
+
+// Push adds or updates the subscriber as a list member. Members are addressed
+// by the MD5 of their lowercased address, so pushing is idempotent.
+func (m *Mailchimp) Push(ctx context.Context, subscriber models.Subscriber) error {
+	member := map[string]interface{}{
+		"email_address": subscriber.Email,
+		"status":        subscriber.Status,
+		"status_if_new": subscriber.Status,
+	}
+	if subscriber.Name != "" {
+		member["merge_fields"] = map[string]string{"FNAME": subscriber.Name}
+	}
+	body, err := json.Marshal(member)
+	if err != nil {
+		return err
+	}
+
+	hash := md5.Sum([]byte(NormalizeEmail(subscriber.Email)))
+	endpoint := fmt.Sprintf("%s/lists/%s/members/%s", strings.TrimRight(m.BaseURL, "/"), m.ListID, hex.EncodeToString(hash[:]))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth("cms", m.APIKey)
+
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("mailing list provider answered %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
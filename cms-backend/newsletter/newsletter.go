@@ -0,0 +1,140 @@
+// Package newsletter confirms subscribers by email and keeps a mailing list
+// provider in step with them. Pushing subscribers is pluggable:
+// NEWSLETTER_SYNC=mailchimp pushes them to a Mailchimp-compatible audience;
+// unset, subscribers stay in this database only.
+package newsletter
+
+import (
+	"cms-backend/mailer"
+	"cms-backend/models"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ConfirmTTL is how long a confirmation link stays valid
+const ConfirmTTL = 48 * time.Hour
+
+// syncBatchSize caps the subscribers pushed per run
+const syncBatchSize = 100
+
+// ErrNoSecret is returned for unsubscribe tokens when NEWSLETTER_SECRET is unset
+var ErrNoSecret = errors.New("NEWSLETTER_SECRET is not set")
+
+// Mailer sends confirmation emails; nil refuses new signups. It is set once at startup.
+var Mailer mailer.Mailer
+
+// Sync pushes a subscriber's current status to a mailing list provider
+type Sync interface {
+	Push(ctx context.Context, subscriber models.Subscriber) error
+}
+
+// Default is the provider subscribers are pushed to; nil disables pushing.
+// It is set once at startup.
+var Default Sync
+
+// FromEnv returns the provider selected by NEWSLETTER_SYNC, or nil when it is unset
+func FromEnv() (Sync, error) {
+	switch kind := os.Getenv("NEWSLETTER_SYNC"); kind {
+	case "", "none":
+		return nil, nil
+	case "mailchimp":
+		return MailchimpFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown NEWSLETTER_SYNC %q (expected mailchimp or none)", kind)
+	}
+}
+
+// NormalizeEmail is the form addresses are stored and compared in
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// UnsubscribeToken returns the token that unsubscribes email. It is derived
+// from NEWSLETTER_SECRET rather than stored, so every newsletter can carry an
+// unsubscribe link without a lookup; rotating the secret invalidates old links.
+func UnsubscribeToken(email string) (string, error) {
+	secret := os.Getenv("NEWSLETTER_SECRET")
+	if secret == "" {
+		return "", ErrNoSecret
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("unsubscribe:" + NormalizeEmail(email)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// CheckUnsubscribeToken reports whether token unsubscribes email
+func CheckUnsubscribeToken(email, token string) bool {
+	expected, err := UnsubscribeToken(email)
+	return err == nil && hmac.Equal([]byte(expected), []byte(token))
+}
+
+// ConfirmURL is the link in confirmation emails: NEWSLETTER_CONFIRM_URL with
+// {token} replaced, by default a /newsletter/confirm page on SITE_URL. The
+// frontend page posts the token back to confirm, so link scanners that open
+// the email cannot confirm on the visitor's behalf.
+func ConfirmURL(token string) string {
+	pattern := os.Getenv("NEWSLETTER_CONFIRM_URL")
+	if pattern == "" {
+		pattern = strings.TrimRight(os.Getenv("SITE_URL"), "/") + "/newsletter/confirm?token={token}"
+	}
+	return strings.ReplaceAll(pattern, "{token}", url.QueryEscape(token))
+}
+
+// SendConfirmation emails subscriber the link confirming their subscription
+func SendConfirmation(ctx context.Context, subscriber models.Subscriber, token string) error {
+	if Mailer == nil {
+		return errors.New("no mailer is configured")
+	}
+	msg, err := mailer.Render(mailer.TemplateNewsletterConfirm, mailer.NewsletterConfirmData{
+		Name:       subscriber.Name,
+		ConfirmURL: ConfirmURL(token),
+		ExpiresIn:  ConfirmTTL,
+	}, subscriber.Email)
+	if err != nil {
+		return err
+	}
+	return Mailer.Send(ctx, msg)
+}
+
+// SyncPending pushes subscribers whose status the provider has not seen yet:
+// confirmed subscribers, and unsubscribes of previously pushed ones. A failed
+// push is recorded on the subscriber and retried on the next run.
+func SyncPending(ctx context.Context, db *gorm.DB, sync Sync) error {
+	if sync == nil {
+		return nil
+	}
+
+	db = db.WithContext(ctx)
+	var pending []models.Subscriber
+	if err := db.Where("(status = ? AND synced_status <> ?) OR (status = ? AND synced_status = ?)",
+		models.SubscriberSubscribed, models.SubscriberSubscribed,
+		models.SubscriberUnsubscribed, models.SubscriberSubscribed).
+		Order("id").Limit(syncBatchSize).Find(&pending).Error; err != nil {
+		return err
+	}
+
+	for _, subscriber := range pending {
+		updates := map[string]interface{}{"synced_status": subscriber.Status, "sync_error": ""}
+		if err := sync.Push(ctx, subscriber); err != nil {
+			log.Printf("Pushing subscriber %d failed: %v", subscriber.ID, err)
+			updates = map[string]interface{}{"sync_error": err.Error()}
+		}
+		// The status may have changed during the push; the next run catches up
+		if err := db.Model(&models.Subscriber{}).Where("id = ?", subscriber.ID).
+			UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"cms-backend/mailer"
+	"cms-backend/models"
+	"context"
+)
+
+// EmailSender sends digests as emails rendered from mailer.TemplateDigest
+func EmailSender(m mailer.Mailer) Sender {
+	return SenderFunc(func(ctx context.Context, recipient models.APIKey, notifications []models.Notification) error {
+		msg, err := mailer.Render(mailer.TemplateDigest, mailer.DigestData{
+			Name:          recipient.Name,
+			Notifications: notifications,
+		}, recipient.Email)
+		if err != nil {
+			return err
+		}
+		return m.Send(ctx, msg)
+	})
+}
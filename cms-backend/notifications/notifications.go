@@ -0,0 +1,109 @@
+// Package notifications raises in-app notifications for workflow events and
+// emails digests of the ones left unread. Digest delivery is pluggable:
+// nothing is emailed until DigestSender is set.
+package notifications
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultDigestDelay is how long a notification stays unread before it is
+// emailed when NOTIFICATION_DIGEST_DELAY is not set
+const DefaultDigestDelay = time.Hour
+
+// digestBatchSize caps the notifications gathered into digests per run
+const digestBatchSize = 500
+
+// Sender delivers one recipient's digest, e.g. by email. Notifications are
+// oldest first.
+type Sender interface {
+	SendDigest(ctx context.Context, recipient models.APIKey, notifications []models.Notification) error
+}
+
+// SenderFunc adapts a function to Sender
+type SenderFunc func(ctx context.Context, recipient models.APIKey, notifications []models.Notification) error
+
+// SendDigest calls f
+func (f SenderFunc) SendDigest(ctx context.Context, recipient models.APIKey, notifications []models.Notification) error {
+	return f(ctx, recipient, notifications)
+}
+
+// DigestSender sends the digests; nil disables them. It is set once at startup.
+var DigestSender Sender
+
+// Notify stores notifications, typically in the transaction of the event
+// that raised them
+func Notify(tx *gorm.DB, notifications ...models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return tx.Create(&notifications).Error
+}
+
+// Reviewers returns the IDs of the API keys that review the editorial
+// workflow: admins and editors
+func Reviewers(tx *gorm.DB) ([]uint, error) {
+	var ids []uint
+	err := tx.Model(&models.APIKey{}).
+		Where("role IN ?", []string{auth.RoleAdmin, auth.RoleEditor}).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// SendDigests passes sender the notifications unread for longer than delay,
+// one digest per recipient with an email address, and marks them emailed.
+// A failed digest is retried on the next run.
+func SendDigests(ctx context.Context, db *gorm.DB, sender Sender, delay time.Duration, now time.Time) error {
+	if sender == nil {
+		return nil
+	}
+
+	db = db.WithContext(ctx)
+	var pending []models.Notification
+	if err := db.Where("read_at IS NULL AND emailed_at IS NULL AND created_at <= ?", now.Add(-delay)).
+		Where("api_key_id IN (?)", db.Model(&models.APIKey{}).Select("id").Where("email <> ''")).
+		Order("api_key_id").Order("id").
+		Limit(digestBatchSize).
+		Find(&pending).Error; err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	var recipientIDs []uint
+	byRecipient := make(map[uint][]models.Notification)
+	for _, notification := range pending {
+		if _, ok := byRecipient[notification.APIKeyID]; !ok {
+			recipientIDs = append(recipientIDs, notification.APIKeyID)
+		}
+		byRecipient[notification.APIKeyID] = append(byRecipient[notification.APIKeyID], notification)
+	}
+	var recipients []models.APIKey
+	if err := db.Where("id IN ?", recipientIDs).Order("id").Find(&recipients).Error; err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		notifications := byRecipient[recipient.ID]
+		if err := sender.SendDigest(ctx, recipient, notifications); err != nil {
+			log.Printf("Sending notification digest to API key %d failed: %v", recipient.ID, err)
+			continue
+		}
+		ids := make([]uint, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+		}
+		if err := db.Model(&models.Notification{}).Where("id IN ?", ids).Update("emailed_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Package orphans finds the records long-lived databases accumulate when
+// deletes leave related rows behind: post_media rows whose post or media is
+// gone, media nothing refers to, and posts whose author's profile was
+// deleted. Clean removes or repairs them.
+package orphans
+
+import (
+	"cms-backend/models"
+	"cms-backend/trash"
+	"cms-backend/utils"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MinMediaAge is how old unattached media must be to be reported, so files
+// uploaded for a post that is still being written are left alone
+const MinMediaAge = 24 * time.Hour
+
+// PostMedia is a post_media row
+type PostMedia struct {
+	PostID  uint `json:"post_id"`
+	MediaID uint `json:"media_id"`
+}
+
+// AuthorlessPost is a post whose Author names no user. A deleted profile
+// leaves no trace, so authors who never had a profile are reported too.
+type AuthorlessPost struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+}
+
+// Report lists the orphaned records found, or fixed by Clean
+type Report struct {
+	PostMedia []PostMedia      `json:"post_media"`
+	Media     []models.Media   `json:"media"`
+	Posts     []AuthorlessPost `json:"posts"`
+}
+
+// danglingPostMedia matches the post_media rows whose post or media no longer exists
+const danglingPostMedia = "NOT EXISTS (SELECT 1 FROM posts WHERE posts.id = post_media.post_id) OR " +
+	"NOT EXISTS (SELECT 1 FROM media WHERE media.id = post_media.media_id)"
+
+// Find lists the orphaned records at now without changing anything
+func Find(ctx context.Context, db *gorm.DB, now time.Time) (*Report, error) {
+	db = db.WithContext(ctx)
+	report := &Report{PostMedia: []PostMedia{}, Media: []models.Media{}, Posts: []AuthorlessPost{}}
+
+	if err := db.Table("post_media").Select("post_id", "media_id").Where(danglingPostMedia).
+		Order("post_id").Order("media_id").Scan(&report.PostMedia).Error; err != nil {
+		return nil, err
+	}
+	if err := trash.Unused(db, now.Add(-MinMediaAge)).Order("media.id").Find(&report.Media).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&models.Post{}).Select("id", "title", "author").
+		Where("posts.author <> '' AND NOT EXISTS (SELECT 1 FROM users WHERE users.username = posts.author)").
+		Order("id").Scan(&report.Posts).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Clean fixes the orphaned records Find reports at now, in one transaction:
+// dangling post_media rows and unattached media are deleted, and authorless
+// posts are given reassignTo as their author, or none when it is empty. It
+// returns what was fixed.
+func Clean(ctx context.Context, db *gorm.DB, now time.Time, reassignTo string) (*Report, error) {
+	var report *Report
+	err := utils.WithTransaction(db.WithContext(ctx), func(tx *gorm.DB) error {
+		var err error
+		if report, err = Find(ctx, tx, now); err != nil {
+			return err
+		}
+
+		if len(report.PostMedia) > 0 {
+			if err := tx.Exec("DELETE FROM post_media WHERE " + danglingPostMedia).Error; err != nil {
+				return err
+			}
+		}
+		if len(report.Media) > 0 {
+			ids := make([]uint, len(report.Media))
+			for i, media := range report.Media {
+				ids[i] = media.ID
+			}
+			if err := tx.Where("id IN ?", ids).Delete(&models.Media{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(report.Posts) > 0 {
+			ids := make([]uint, len(report.Posts))
+			for i, post := range report.Posts {
+				ids[i] = post.ID
+			}
+			if err := tx.Model(&models.Post{}).Where("id IN ?", ids).Update("author", reassignTo).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
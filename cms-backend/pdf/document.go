@@ -0,0 +1,195 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry in PDF points (US Letter with one-inch margins)
+const (
+	pageWidth  = 612
+	pageHeight = 792
+	margin     = 72
+)
+
+// Font sizes and line heights used by the renderer
+const (
+	bodySize       = 11
+	bodyLeading    = 15
+	headingSize    = 18
+	headingLeading = 24
+	footerSize     = 9
+)
+
+// line is a single laid-out line of text
+type line struct {
+	text    string
+	heading bool
+}
+
+// Render lays out text as a paginated PDF using the built-in Helvetica fonts.
+// Lines starting with "# " are rendered as headings; footer, if set, is printed
+// at the bottom of every page followed by the page number.
+func Render(text, footer string) []byte {
+	pages := paginate(layout(text))
+
+	var buf bytes.Buffer
+	var offsets []int
+	writeObject := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Objects 1-4 are fixed: catalog, page tree, regular and bold fonts.
+	// Each page then takes two objects: the page and its content stream.
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		content := pageContent(page, footer, i+1, len(pages))
+		writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+i*2,
+		))
+		writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	// Cross-reference table and trailer
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// layout splits text into paragraphs and wraps them to the page width
+func layout(text string) []line {
+	var lines []line
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		heading := strings.HasPrefix(paragraph, "# ")
+		size := bodySize
+		if heading {
+			paragraph = strings.TrimPrefix(paragraph, "# ")
+			size = headingSize
+		}
+		for _, wrapped := range wrap(paragraph, size) {
+			lines = append(lines, line{text: wrapped, heading: heading})
+		}
+	}
+	return lines
+}
+
+// wrap breaks a paragraph into lines that fit between the margins.
+// Helvetica averages roughly half an em per character, which is close enough
+// for body copy without shipping font metrics.
+func wrap(paragraph string, size int) []string {
+	maxChars := (pageWidth - 2*margin) * 2 / size
+	words := strings.Fields(paragraph)
+	if len(words) == 0 {
+		return []string{""}
+	}
+
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for len(word) > maxChars {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, word[:maxChars])
+			word = word[maxChars:]
+		}
+		switch {
+		case current == "":
+			current = word
+		case len(current)+1+len(word) <= maxChars:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// paginate distributes lines over pages, leaving room for the footer
+func paginate(lines []line) [][]line {
+	var pages [][]line
+	var current []line
+	y := pageHeight - margin
+	for _, l := range lines {
+		leading := bodyLeading
+		if l.heading {
+			leading = headingLeading
+		}
+		if y-leading < margin && len(current) > 0 {
+			pages = append(pages, current)
+			current = nil
+			y = pageHeight - margin
+		}
+		current = append(current, l)
+		y -= leading
+	}
+	if len(current) > 0 || len(pages) == 0 {
+		pages = append(pages, current)
+	}
+	return pages
+}
+
+// pageContent builds the content stream for one page
+func pageContent(lines []line, footer string, number, total int) string {
+	var b strings.Builder
+	y := pageHeight - margin
+	for _, l := range lines {
+		font, size, leading := "F1", bodySize, bodyLeading
+		if l.heading {
+			font, size, leading = "F2", headingSize, headingLeading
+		}
+		y -= leading
+		fmt.Fprintf(&b, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, size, margin, y+leading-size, escape(l.text))
+	}
+
+	pageLabel := fmt.Sprintf("Page %d of %d", number, total)
+	if footer != "" {
+		pageLabel = footer + " - " + pageLabel
+	}
+	fmt.Fprintf(&b, "BT /F1 %d Tf %d %d Td (%s) Tj ET", footerSize, margin, margin/2, escape(pageLabel))
+	return b.String()
+}
+
+// escape encodes text as a PDF literal string in WinAnsi (Latin-1 subset)
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\t':
+			b.WriteString("    ")
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,60 @@
+package pdf
+
+import (
+	"bytes"
+	"os"
+	"text/template"
+	"time"
+)
+
+// DefaultTemplate lays out a document as a heading, byline and body
+const DefaultTemplate = `# {{.Title}}
+{{if .Author}}By {{.Author}}
+{{end}}{{.UpdatedAt.Format "January 2, 2006"}}
+
+{{.Content}}
+`
+
+// Data is the content made available to export templates
+type Data struct {
+	Title     string
+	Author    string
+	Content   string
+	SiteName  string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LoadTemplate returns the site's export template. PDF_TEMPLATE_PATH points at a
+// text/template file; when it is unset the built-in DefaultTemplate is used.
+func LoadTemplate() (*template.Template, error) {
+	source := DefaultTemplate
+	if path := os.Getenv("PDF_TEMPLATE_PATH"); path != "" {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		source = string(contents)
+	}
+	return template.New("export").Parse(source)
+}
+
+// Generate renders data through the site template and returns the PDF bytes.
+// SITE_NAME, when set, is printed in every page footer.
+func Generate(data Data) ([]byte, error) {
+	tmpl, err := LoadTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	if data.SiteName == "" {
+		data.SiteName = os.Getenv("SITE_NAME")
+	}
+
+	var text bytes.Buffer
+	if err := tmpl.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	return Render(text.String(), data.SiteName), nil
+}
@@ -0,0 +1,147 @@
+package presence
+
+import (
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// sendQueueSize is how many events may wait for a slow client before it is dropped
+const sendQueueSize = 32
+
+// writeTimeout bounds each write, so a client that stops reading is dropped
+// instead of holding its writer forever
+const writeTimeout = 10 * time.Second
+
+// Event types broadcast to everyone editing a post
+const (
+	EventJoin         = "join"
+	EventLeave        = "leave"
+	EventLockAcquired = "lock_acquired"
+	EventLockReleased = "lock_released"
+	EventLockStolen   = "lock_stolen"
+)
+
+// Event is the JSON message pushed to presence subscribers
+type Event struct {
+	Type   string      `json:"type"`
+	PostID uint        `json:"post_id"`
+	User   string      `json:"user,omitempty"`
+	Users  []string    `json:"users"`
+	Lock   interface{} `json:"lock,omitempty"`
+}
+
+// Client is a single WebSocket subscriber editing a post. Events are queued
+// on send and written by the client's own goroutine, so one slow connection
+// never delays the others.
+type Client struct {
+	User string
+	conn *Conn
+	send chan []byte
+}
+
+// Hub tracks which users have each post open and fans out events to them
+type Hub struct {
+	mu    sync.Mutex
+	rooms map[uint]map[*Client]struct{}
+}
+
+// Default is the process-wide hub used by the controllers
+var Default = NewHub()
+
+// NewHub creates an empty presence hub
+func NewHub() *Hub {
+	return &Hub{rooms: make(map[uint]map[*Client]struct{})}
+}
+
+// Join registers a connection for a post and announces it to the room
+func (h *Hub) Join(postID uint, user string, conn *Conn) *Client {
+	client := &Client{User: user, conn: conn, send: make(chan []byte, sendQueueSize)}
+	go client.writeLoop()
+
+	h.mu.Lock()
+	room, ok := h.rooms[postID]
+	if !ok {
+		room = make(map[*Client]struct{})
+		h.rooms[postID] = room
+	}
+	room[client] = struct{}{}
+	h.mu.Unlock()
+
+	h.Broadcast(Event{Type: EventJoin, PostID: postID, User: user})
+	return client
+}
+
+// Leave removes a connection from a post's room and announces it
+func (h *Hub) Leave(postID uint, client *Client) {
+	h.mu.Lock()
+	if room, ok := h.rooms[postID]; ok {
+		if _, joined := room[client]; joined {
+			delete(room, client)
+			close(client.send)
+		}
+		if len(room) == 0 {
+			delete(h.rooms, postID)
+		}
+	}
+	h.mu.Unlock()
+
+	client.conn.Close()
+	h.Broadcast(Event{Type: EventLeave, PostID: postID, User: client.User})
+}
+
+// Users returns the distinct users currently viewing a post, sorted by name
+func (h *Hub) Users(postID uint) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	users := []string{}
+	for client := range h.rooms[postID] {
+		if _, ok := seen[client.User]; ok {
+			continue
+		}
+		seen[client.User] = struct{}{}
+		users = append(users, client.User)
+	}
+	sort.Strings(users)
+	return users
+}
+
+// Broadcast sends an event to every connection in the post's room.
+// The current user list is always attached so clients can re-render from any event.
+func (h *Hub) Broadcast(event Event) {
+	event.Users = h.Users(event.PostID)
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("presence: failed to encode event: %v", err)
+		return
+	}
+
+	// Queues are only closed under the lock, and sends never block
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.rooms[event.PostID] {
+		select {
+		case client.send <- data:
+		default:
+			// The client is not keeping up; closing the connection ends its
+			// read loop, which calls Leave
+			client.conn.Close()
+		}
+	}
+}
+
+// writeLoop writes queued events until Leave closes the queue
+func (c *Client) writeLoop() {
+	for data := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if err := c.conn.WriteMessage(data); err != nil {
+			// The read loop notices the closed connection and calls Leave
+			c.conn.Close()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// Package querybudget counts the SQL statements each request runs and flags
+// handlers that exceed a budget, which is how N+1 query patterns show up.
+package querybudget
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CountHeader reports how many SQL statements a request ran
+const CountHeader = "X-Query-Count"
+
+// DefaultBudget applies when QUERY_BUDGET is not set
+const DefaultBudget = 20
+
+// callbackName identifies the counting callbacks registered on a database
+const callbackName = "querybudget:count"
+
+// counterKey is the context key holding a request's counter
+type counterKey struct{}
+
+// Register adds counting callbacks to db. Statements only count when they run
+// with a context prepared by Middleware. Registering twice is a no-op.
+func Register(db *gorm.DB) error {
+	if db.Callback().Query().Get(callbackName) != nil {
+		return nil
+	}
+	count := func(tx *gorm.DB) {
+		// Subqueries are built by a dry run of the callbacks and never reach the database
+		if tx.DryRun {
+			return
+		}
+		if counter, ok := tx.Statement.Context.Value(counterKey{}).(*atomic.Int64); ok {
+			counter.Add(1)
+		}
+	}
+	if err := db.Callback().Query().After("gorm:query").Register(callbackName, count); err != nil {
+		return err
+	}
+	if err := db.Callback().Create().After("gorm:create").Register(callbackName, count); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register(callbackName, count); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:delete").Register(callbackName, count); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().After("gorm:row").Register(callbackName, count); err != nil {
+		return err
+	}
+	return db.Callback().Raw().After("gorm:raw").Register(callbackName, count)
+}
+
+// BudgetFromEnv returns QUERY_BUDGET, or DefaultBudget when unset or invalid
+func BudgetFromEnv() int {
+	if budget, err := strconv.Atoi(os.Getenv("QUERY_BUDGET")); err == nil && budget > 0 {
+		return budget
+	}
+	return DefaultBudget
+}
+
+// Middleware counts the statements run through the request's "db", or any
+// handle given the request's context, and logs requests that exceed budget. It must run after anything that replaces "db",
+// such as read replica routing. The count is reported in CountHeader.
+func Middleware(budget int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		counter := new(atomic.Int64)
+		ctx := context.WithValue(c.Request.Context(), counterKey{}, counter)
+		c.Request = c.Request.WithContext(ctx)
+		if db, ok := c.Get("db"); ok {
+			c.Set("db", db.(*gorm.DB).WithContext(ctx))
+		}
+
+		c.Writer = &countingWriter{ResponseWriter: c.Writer, counter: counter}
+		c.Next()
+
+		n := counter.Load()
+		if !c.Writer.Written() {
+			c.Header(CountHeader, strconv.FormatInt(n, 10))
+		}
+		if n > int64(budget) {
+			log.Printf("Query budget exceeded: %s %s ran %d queries (budget %d)",
+				c.Request.Method, c.FullPath(), n, budget)
+		}
+	}
+}
+
+// countingWriter sets CountHeader just before the response is written, when
+// the handler has run its queries but headers can still change
+type countingWriter struct {
+	gin.ResponseWriter
+	counter *atomic.Int64
+}
+
+func (w *countingWriter) setHeader() {
+	if !w.ResponseWriter.Written() {
+		w.Header().Set(CountHeader, strconv.FormatInt(w.counter.Load(), 10))
+	}
+}
+
+func (w *countingWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *countingWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
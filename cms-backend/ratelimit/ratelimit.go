@@ -0,0 +1,216 @@
+// Package ratelimit sheds delivery API load when the database struggles. A
+// monitor samples connection pool stats and ping latency; while either crosses
+// its threshold the allowed request rate is halved on every sample, and it
+// recovers step by step once the database is healthy again. Requests over the
+// current rate get a 503 with Retry-After instead of queueing on the pool.
+package ratelimit
+
+import (
+	"cms-backend/auth"
+	"cms-backend/utils"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Config sets the base rate and the load thresholds that tighten it
+type Config struct {
+	// Rate is the requests per second allowed while the database is healthy
+	Rate float64
+	// Burst is how many requests may arrive at once on top of the rate
+	Burst int
+
+	// MaxLatency is the ping latency above which the database counts as overloaded
+	MaxLatency time.Duration
+	// MaxSaturation is the in-use share of MaxOpenConnections above which the
+	// pool counts as overloaded
+	MaxSaturation float64
+
+	// MinFactor is the lowest share of Rate the limiter tightens to
+	MinFactor float64
+	// SampleInterval is how often the monitor checks the database
+	SampleInterval time.Duration
+}
+
+// DefaultConfig applies when the environment does not override a setting
+var DefaultConfig = Config{
+	Rate:           100,
+	Burst:          200,
+	MaxLatency:     250 * time.Millisecond,
+	MaxSaturation:  0.8,
+	MinFactor:      0.05,
+	SampleInterval: 5 * time.Second,
+}
+
+// recoveryStep is how much of the base rate is restored per healthy sample
+const recoveryStep = 0.1
+
+// ConfigFromEnv returns DefaultConfig overridden by RATE_LIMIT_RPS,
+// RATE_LIMIT_BURST, DB_LATENCY_THRESHOLD and DB_SATURATION_THRESHOLD.
+// A rate of 0 disables the limiter.
+func ConfigFromEnv() (Config, error) {
+	config := DefaultConfig
+	var err error
+	if value := os.Getenv("RATE_LIMIT_RPS"); value != "" {
+		if config.Rate, err = strconv.ParseFloat(value, 64); err != nil || config.Rate < 0 {
+			return DefaultConfig, fmt.Errorf("invalid RATE_LIMIT_RPS %q", value)
+		}
+	}
+	if value := os.Getenv("RATE_LIMIT_BURST"); value != "" {
+		if config.Burst, err = strconv.Atoi(value); err != nil || config.Burst < 1 {
+			return DefaultConfig, fmt.Errorf("invalid RATE_LIMIT_BURST %q", value)
+		}
+	}
+	if value := os.Getenv("DB_LATENCY_THRESHOLD"); value != "" {
+		if config.MaxLatency, err = time.ParseDuration(value); err != nil || config.MaxLatency <= 0 {
+			return DefaultConfig, fmt.Errorf("invalid DB_LATENCY_THRESHOLD %q", value)
+		}
+	}
+	if value := os.Getenv("DB_SATURATION_THRESHOLD"); value != "" {
+		if config.MaxSaturation, err = strconv.ParseFloat(value, 64); err != nil || config.MaxSaturation <= 0 || config.MaxSaturation > 1 {
+			return DefaultConfig, fmt.Errorf("invalid DB_SATURATION_THRESHOLD %q", value)
+		}
+	}
+	return config, nil
+}
+
+// Limiter is a token bucket whose refill rate follows database load
+type Limiter struct {
+	// Now is the clock used for refilling tokens; tests replace it
+	Now func() time.Time
+
+	mu        sync.Mutex
+	config    Config
+	factor    float64
+	tokens    float64
+	updatedAt time.Time
+	waitCount int64
+}
+
+// New creates a limiter running at the full configured rate
+func New(config Config) *Limiter {
+	return &Limiter{
+		Now:    time.Now,
+		config: config,
+		factor: 1,
+		tokens: float64(config.Burst),
+	}
+}
+
+// Factor returns the share of the base rate currently allowed
+func (l *Limiter) Factor() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.factor
+}
+
+// Observe feeds one database sample into the limiter. The pool counts as
+// overloaded when ping latency or connection saturation crosses its threshold,
+// or when callers had to wait for a connection since the previous sample.
+func (l *Limiter) Observe(stats sql.DBStats, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	overloaded := latency > l.config.MaxLatency || stats.WaitCount > l.waitCount
+	if stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		overloaded = overloaded || saturation >= l.config.MaxSaturation
+	}
+	l.waitCount = stats.WaitCount
+
+	// Refill at the old rate before changing it
+	l.refill()
+	previous := l.factor
+	if overloaded {
+		l.factor = math.Max(l.config.MinFactor, l.factor/2)
+	} else {
+		l.factor = math.Min(1, l.factor+recoveryStep)
+	}
+	if l.factor != previous && (l.factor == 1 || previous == 1) {
+		log.Printf("Delivery rate limit now at %.0f%% of %.0f req/s", l.factor*100, l.config.Rate)
+	}
+}
+
+// Monitor samples db every SampleInterval until ctx is cancelled
+func (l *Limiter) Monitor(ctx context.Context, db *sql.DB) {
+	go func() {
+		ticker := time.NewTicker(l.config.SampleInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// A ping that times out counts as latency at the timeout
+			pingCtx, cancel := context.WithTimeout(ctx, 2*l.config.MaxLatency)
+			start := time.Now()
+			if err := db.PingContext(pingCtx); err != nil {
+				log.Printf("Database ping failed: %v", err)
+			}
+			latency := time.Since(start)
+			cancel()
+
+			l.Observe(db.Stats(), latency)
+		}
+	}()
+}
+
+// Allow takes a token if one is available. Otherwise it reports how long until
+// the next token at the current rate.
+func (l *Limiter) Allow() (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if l.tokens >= 1 {
+		l.tokens--
+		return true, 0
+	}
+	rate := l.config.Rate * l.factor
+	return false, time.Duration((1 - l.tokens) / rate * float64(time.Second))
+}
+
+// refill adds the tokens earned since the last update; callers hold l.mu
+func (l *Limiter) refill() {
+	now := l.Now()
+	if !l.updatedAt.IsZero() {
+		earned := now.Sub(l.updatedAt).Seconds() * l.config.Rate * l.factor
+		l.tokens = math.Min(float64(l.config.Burst), l.tokens+earned)
+	}
+	l.updatedAt = now
+}
+
+// Handler limits GET requests from anonymous and delivery callers. Editors and
+// other authenticated roles are never shed so the CMS stays usable under load.
+func (l *Limiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := auth.CallerFrom(c)
+		if l.config.Rate <= 0 || c.Request.Method != http.MethodGet ||
+			!(caller.Anonymous() || caller.Scope() == auth.ScopeDelivery) {
+			c.Next()
+			return
+		}
+
+		if ok, wait := l.Allow(); !ok {
+			c.Header("Retry-After", strconv.Itoa(max(1, int(math.Ceil(wait.Seconds())))))
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, utils.HTTPError{
+				Code:      http.StatusServiceUnavailable,
+				Message:   "Server is under heavy load, retry later",
+				ErrorCode: utils.ErrorCodeOverloaded,
+			})
+			return
+		}
+		c.Next()
+	}
+}
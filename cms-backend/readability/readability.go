@@ -0,0 +1,183 @@
+// Package readability scores Markdown content for editors: word count,
+// Flesch readability, estimated reading time and problems with the heading
+// outline. Scores assume English prose.
+package readability
+
+import (
+	"cms-backend/markdown"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// WordsPerMinute is the reading speed reading times are estimated at
+const WordsPerMinute = 200
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	sentenceEnd    = regexp.MustCompile(`[.!?]+(?:["')\]]*)(?:\s|$)`)
+)
+
+// Heading is one heading of the content's outline
+type Heading struct {
+	Level int    `json:"level"`
+	Text  string `json:"text"`
+}
+
+// Report describes how readable a piece of content is. FleschReadingEase
+// runs from about 0 (very hard) to 100 (very easy); FleschKincaidGrade is
+// the US school grade needed to follow the text.
+type Report struct {
+	WordCount          int       `json:"word_count"`
+	SentenceCount      int       `json:"sentence_count"`
+	FleschReadingEase  float64   `json:"flesch_reading_ease"`
+	FleschKincaidGrade float64   `json:"flesch_kincaid_grade"`
+	ReadingTime        int       `json:"reading_time"`
+	Headings           []Heading `json:"headings"`
+	Warnings           []string  `json:"warnings"`
+}
+
+// Analyze scores Markdown content. Code blocks are left out of the counts.
+func Analyze(source string) Report {
+	report := Report{Headings: outline(source), Warnings: []string{}}
+
+	syllables := 0
+	for _, block := range blocks(source) {
+		words := 0
+		for _, word := range strings.Fields(block) {
+			if isWord(word) {
+				words++
+				syllables += countSyllables(word)
+			}
+		}
+		if words == 0 {
+			continue
+		}
+		report.WordCount += words
+
+		// A block without closing punctuation, like a heading or list item,
+		// still reads as one sentence
+		ends := sentenceEnd.FindAllStringIndex(block, -1)
+		report.SentenceCount += len(ends)
+		if len(ends) == 0 || ends[len(ends)-1][1] != len(block) {
+			report.SentenceCount++
+		}
+	}
+
+	if report.WordCount > 0 {
+		wordsPerSentence := float64(report.WordCount) / float64(report.SentenceCount)
+		syllablesPerWord := float64(syllables) / float64(report.WordCount)
+		report.FleschReadingEase = round(206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord)
+		report.FleschKincaidGrade = round(0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59)
+	}
+	report.ReadingTime = minutes(report.WordCount)
+	report.Warnings = append(report.Warnings, headingWarnings(report.Headings)...)
+	return report
+}
+
+// ReadingTime estimates the minutes needed to read Markdown content,
+// rounded up; non-empty content takes at least a minute
+func ReadingTime(source string) int {
+	words := 0
+	for _, block := range blocks(source) {
+		for _, word := range strings.Fields(block) {
+			if isWord(word) {
+				words++
+			}
+		}
+	}
+	return minutes(words)
+}
+
+func minutes(words int) int {
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+// blocks returns the plain text of each block element, without code blocks
+func blocks(source string) []string {
+	text := markdown.ToText(source)
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// outline lists the ATX headings of the source outside fenced code
+func outline(source string) []Heading {
+	headings := []Heading{}
+	fenced := false
+	for _, line := range strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if m := headingPattern.FindStringSubmatch(trimmed); m != nil {
+			headings = append(headings, Heading{Level: len(m[1]), Text: m[2]})
+		}
+	}
+	return headings
+}
+
+// headingWarnings reports outline problems. The title is rendered as the
+// page's h1, so content headings are expected to start at h2 and go down
+// one level at a time.
+func headingWarnings(headings []Heading) []string {
+	var warnings []string
+	previous := 1
+	for _, heading := range headings {
+		switch {
+		case heading.Text == "":
+			warnings = append(warnings, fmt.Sprintf("Empty h%d heading", heading.Level))
+		case heading.Level == 1:
+			warnings = append(warnings, fmt.Sprintf("%q is an h1; the title is already the page's h1", heading.Text))
+		case heading.Level > previous+1:
+			warnings = append(warnings, fmt.Sprintf("%q skips from h%d to h%d", heading.Text, previous, heading.Level))
+		}
+		previous = heading.Level
+	}
+	return warnings
+}
+
+// isWord reports whether a token contains a letter or digit
+func isWord(token string) bool {
+	return strings.IndexFunc(token, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) >= 0
+}
+
+// countSyllables estimates the syllables of an English word by counting
+// vowel groups, not counting a silent final e. Tokens without letters count
+// as none.
+func countSyllables(word string) int {
+	word = strings.ToLower(strings.TrimFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }))
+	if word == "" {
+		return 0
+	}
+
+	count := 0
+	inVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !inVowel {
+			count++
+		}
+		inVowel = vowel
+	}
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+	if count == 0 {
+		count = 1
+	}
+	return count
+}
+
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,237 @@
+// Package replica routes delivery reads to region-local read replicas. Each
+// replica's replication lag is sampled periodically; a replica only serves
+// reads while its lag is within bounds and it has replayed every write this
+// server made, so content is never read back stale right after a publish.
+package replica
+
+import (
+	"cms-backend/auth"
+	"cms-backend/utils"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RegionHeader selects the replica region for a request
+const RegionHeader = "X-Region"
+
+// ServedByHeader reports which region, or "primary", served a read
+const ServedByHeader = "X-DB-Region"
+
+// Primary names the primary database in ServedByHeader
+const Primary = "primary"
+
+// DefaultMaxLag is the replication lag above which a replica stops serving reads
+const DefaultMaxLag = 5 * time.Second
+
+// SampleInterval is how often replica lag is measured
+const SampleInterval = 2 * time.Second
+
+// lagQuery returns replay lag in seconds; a replica that has replayed all WAL it
+// received is idle rather than behind, so it reports zero
+const lagQuery = `SELECT CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+
+type replica struct {
+	db *gorm.DB
+
+	// caughtUpTo is when the replica last had every earlier primary commit
+	caughtUpTo time.Time
+	lag        time.Duration
+	healthy    bool
+}
+
+// Set holds the primary database and its replicas by region
+type Set struct {
+	// Now is the clock used to record writes; tests replace it
+	Now func() time.Time
+
+	primary       *gorm.DB
+	defaultRegion string
+	maxLag        time.Duration
+
+	mu        sync.Mutex
+	replicas  map[string]*replica
+	lastWrite time.Time
+}
+
+// New creates a set without replicas; every read goes to primary until replicas
+// are added. Requests without RegionHeader use defaultRegion.
+func New(primary *gorm.DB, defaultRegion string, maxLag time.Duration) *Set {
+	return &Set{
+		Now:           time.Now,
+		primary:       primary,
+		defaultRegion: defaultRegion,
+		maxLag:        maxLag,
+		replicas:      make(map[string]*replica),
+	}
+}
+
+// Add registers the replica for a region. It serves no reads until its first
+// lag sample arrives through Observe.
+func (s *Set) Add(region string, db *gorm.DB) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replicas[region] = &replica{db: db}
+}
+
+// FromEnv connects the replicas listed in DB_REPLICAS, e.g.
+// "eu-west=replica-eu:5432,us-east=replica-us:5432", with the primary's
+// credentials. DB_REGION is the region used when requests do not name one and
+// DB_REPLICA_MAX_LAG overrides DefaultMaxLag. Replicas that fail to connect are
+// skipped so the server still starts on the primary alone.
+func FromEnv(primary *gorm.DB) (*Set, error) {
+	maxLag := DefaultMaxLag
+	if value := os.Getenv("DB_REPLICA_MAX_LAG"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid DB_REPLICA_MAX_LAG %q", value)
+		}
+		maxLag = parsed
+	}
+	set := New(primary, os.Getenv("DB_REGION"), maxLag)
+
+	value := strings.TrimSpace(os.Getenv("DB_REPLICAS"))
+	if value == "" {
+		return set, nil
+	}
+	for _, item := range strings.Split(value, ",") {
+		region, address, ok := strings.Cut(strings.TrimSpace(item), "=")
+		host, port, err := net.SplitHostPort(address)
+		if !ok || region == "" || err != nil {
+			return nil, fmt.Errorf("invalid DB_REPLICAS entry %q", item)
+		}
+		db, err := utils.ConnectDBHost(host, port)
+		if err != nil {
+			log.Printf("Skipping read replica %s: %v", region, err)
+			continue
+		}
+		set.Add(region, db)
+	}
+	return set, nil
+}
+
+// Observe records a lag sample for a region taken at the given time; err marks
+// the replica unreachable until the next successful sample
+func (s *Set) Observe(region string, lag time.Duration, at time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.replicas[region]
+	if !ok {
+		return
+	}
+	if err != nil {
+		if r.healthy {
+			log.Printf("Read replica %s unavailable: %v", region, err)
+		}
+		r.healthy = false
+		return
+	}
+	r.healthy = true
+	r.lag = lag
+	r.caughtUpTo = at.Add(-lag)
+}
+
+// Monitor samples every replica's lag until ctx is cancelled
+func (s *Set) Monitor(ctx context.Context) {
+	s.mu.Lock()
+	regions := make(map[string]*gorm.DB, len(s.replicas))
+	for region, r := range s.replicas {
+		regions[region] = r.db
+	}
+	s.mu.Unlock()
+	if len(regions) == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(SampleInterval)
+		defer ticker.Stop()
+		for {
+			for region, db := range regions {
+				at := time.Now()
+				var seconds float64
+				err := db.WithContext(ctx).Raw(lagQuery).Scan(&seconds).Error
+				s.Observe(region, time.Duration(seconds*float64(time.Second)), at, err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RecordWrite sends reads to the primary until replicas replay changes made now
+func (s *Set) RecordWrite() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastWrite = s.Now()
+}
+
+// ForRegion returns the database that should serve a read for region and the
+// name of what was chosen. Unknown, lagging or unreachable replicas, and replicas
+// that have not yet replayed the latest write, fall back to the primary.
+func (s *Set) ForRegion(region string) (*gorm.DB, string) {
+	if region == "" {
+		region = s.defaultRegion
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.replicas[region]
+	if !ok || !r.healthy || r.lag > s.maxLag || r.caughtUpTo.Before(s.lastWrite) {
+		return s.primary, Primary
+	}
+	return r.db, region
+}
+
+// Unavailable returns the regions whose replica cannot serve reads because it
+// is unreachable or lagging, sorted by name
+func (s *Set) Unavailable() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var regions []string
+	for region, r := range s.replicas {
+		if !r.healthy || r.lag > s.maxLag {
+			regions = append(regions, region)
+		}
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+// Handler sends GET requests from anonymous and delivery callers to a replica
+// and records successful writes so the reads that follow see them. Other roles
+// always read from the primary since they edit what they read.
+func (s *Set) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		caller := auth.CallerFrom(c)
+		if c.Request.Method == http.MethodGet && (caller.Anonymous() || caller.Scope() == auth.ScopeDelivery) {
+			db, servedBy := s.ForRegion(c.GetHeader(RegionHeader))
+			c.Set("db", db)
+			c.Header(ServedByHeader, servedBy)
+		}
+
+		c.Next()
+
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			return
+		}
+		if status := c.Writer.Status(); status >= 200 && status < 300 {
+			s.RecordWrite()
+		}
+	}
+}
@@ -0,0 +1,398 @@
+// Package repository hides how posts, pages and media are stored behind
+// interfaces, so handlers can be tested with in-memory fakes and storage can
+// be swapped without touching them. The GORM implementations are built per
+// request from the request's database handle, which may be a read replica or
+// carry a query budget.
+package repository
+
+import (
+	"cms-backend/acl"
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/search"
+	"cms-backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when the requested record does not exist or is
+// hidden from the caller. It is a utils.ErrNotFound.
+var ErrNotFound = fmt.Errorf("record %w", utils.ErrNotFound)
+
+// PostFilter narrows a post listing. Restricted drafts are left out unless
+// Caller has been granted access to them. Featured, when set, keeps featured
+// or other posts and IDs, when set, limits the listing to those posts;
+// CreatedBefore and Search work as in PageFilter.
+//
+// The rest shape List and is ignored when counting: Limit, when set, returns
+// at most that many posts newest first, starting after the After cursor.
+// ByPosition orders curated posts by their manual position, Sort by
+// whitelisted columns, and Preload names the associations loaded.
+type PostFilter struct {
+	Caller        auth.Caller
+	Title         string
+	Author        string
+	OwnerGroup    string
+	Featured      *bool
+	Status        string
+	CreatedBefore *time.Time
+	IDs           []uint
+	Search        string
+	SearchConfig  string
+
+	Limit      int
+	After      *Cursor
+	ByPosition bool
+	Sort       []SortField
+	Preload    []string
+}
+
+// PostRepository stores posts
+type PostRepository interface {
+	// Get returns a post without its relations, whatever its grants
+	Get(ctx context.Context, id uint) (*models.Post, error)
+
+	// GetReadable returns a post with the preload associations unless it is
+	// hidden from caller: a restricted draft it has no grant on or, for
+	// callers not managing content, a post that is not yet published
+	GetReadable(ctx context.Context, caller auth.Caller, id uint, preload []string) (*models.Post, error)
+
+	// List returns the posts matching filter
+	List(ctx context.Context, filter PostFilter) ([]models.Post, error)
+
+	// Count returns how many posts match filter
+	Count(ctx context.Context, filter PostFilter) (int64, error)
+
+	// Create inserts post with the tags set on it
+	Create(ctx context.Context, post *models.Post) error
+
+	// Update saves every field of post; its tags are changed with SetTags
+	Update(ctx context.Context, post *models.Post) error
+
+	// SetTags replaces the tags of post
+	SetTags(ctx context.Context, post *models.Post, tags []models.Tag) error
+
+	// Delete removes post
+	Delete(ctx context.Context, post *models.Post) error
+}
+
+// PageFilter narrows a page listing. Restricted drafts are left out unless
+// Caller has been granted access to them. CreatedBefore, when set, keeps
+// pages created before it. Search, when set, keeps pages matching the
+// full-text search terms, stemmed with SearchConfig or each page's locale.
+// Sort orders the listing and is ignored when counting.
+type PageFilter struct {
+	Caller        auth.Caller
+	Title         string
+	Author        string
+	OwnerGroup    string
+	Status        string
+	CreatedBefore *time.Time
+	Search        string
+	SearchConfig  string
+	Sort          []SortField
+}
+
+// PageRepository stores pages
+type PageRepository interface {
+	// List returns the pages matching filter
+	List(ctx context.Context, filter PageFilter) ([]models.Page, error)
+
+	// Count returns how many pages match filter
+	Count(ctx context.Context, filter PageFilter) (int64, error)
+
+	// Get returns a page whatever its grants
+	Get(ctx context.Context, id uint) (*models.Page, error)
+
+	// GetReadable returns a page unless it is hidden from caller: a
+	// restricted draft it has no grant on or, for callers not managing
+	// content, a page that is not yet published
+	GetReadable(ctx context.Context, caller auth.Caller, id uint) (*models.Page, error)
+
+	// Create inserts page
+	Create(ctx context.Context, page *models.Page) error
+
+	// Update saves every field of page
+	Update(ctx context.Context, page *models.Page) error
+
+	// Delete removes page
+	Delete(ctx context.Context, page *models.Page) error
+}
+
+// MediaFilter narrows a media listing. Quarantined media is left out unless
+// IncludeQuarantined is set; IDs, when set, limits the listing to those items.
+// Sort orders the listing and is ignored when counting.
+type MediaFilter struct {
+	Type               string
+	IDs                []uint
+	IncludeQuarantined bool
+	Sort               []SortField
+}
+
+// MediaRepository stores media
+type MediaRepository interface {
+	List(ctx context.Context, filter MediaFilter) ([]models.Media, error)
+	Count(ctx context.Context, filter MediaFilter) (int64, error)
+	Get(ctx context.Context, id uint, includeQuarantined bool) (*models.Media, error)
+	Create(ctx context.Context, media *models.Media) error
+
+	// Update saves the alt text, focal point and crops of media; the file it
+	// describes cannot change
+	Update(ctx context.Context, media *models.Media) error
+	Delete(ctx context.Context, media *models.Media) error
+}
+
+// Repositories groups the repositories a request uses
+type Repositories struct {
+	Posts PostRepository
+	Pages PageRepository
+	Media MediaRepository
+}
+
+// Factory builds the repositories for a request from its database handle
+type Factory func(db *gorm.DB) *Repositories
+
+// NewGorm returns repositories storing records through db. Built from a
+// transaction, they store records as part of it.
+func NewGorm(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Posts: gormPosts{db},
+		Pages: gormPages{db},
+		Media: gormMedia{db},
+	}
+}
+
+// first loads one record into dest, translating a missing record to ErrNotFound
+func first(query *gorm.DB, dest interface{}, id uint) error {
+	err := query.First(dest, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+type gormPosts struct {
+	db *gorm.DB
+}
+
+func (r gormPosts) Get(ctx context.Context, id uint) (*models.Post, error) {
+	var post models.Post
+	if err := first(r.db.WithContext(ctx), &post, id); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r gormPosts) GetReadable(ctx context.Context, caller auth.Caller, id uint, preload []string) (*models.Post, error) {
+	query := r.db.WithContext(ctx).Scopes(acl.Readable(caller, acl.EntityPost, "posts"))
+	for _, association := range preload {
+		query = query.Preload(association)
+	}
+
+	var post models.Post
+	if err := first(query, &post, id); err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r gormPosts) filtered(ctx context.Context, filter PostFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.Post{}).Scopes(acl.Readable(filter.Caller, acl.EntityPost, "posts"))
+	if filter.Title != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.Author != "" {
+		query = query.Where("author = ?", filter.Author)
+	}
+	if filter.OwnerGroup != "" {
+		query = query.Where("owner_group_id = ?", filter.OwnerGroup)
+	}
+	if filter.Featured != nil {
+		query = query.Where("featured = ?", *filter.Featured)
+	}
+	if filter.Status != "" {
+		query = query.Where("posts.status = ?", filter.Status)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("posts.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.IDs != nil {
+		query = query.Where("posts.id IN ?", filter.IDs)
+	}
+	if filter.Search != "" {
+		query = query.Scopes(search.Match("posts", filter.Search, filter.SearchConfig))
+	}
+	return query
+}
+
+func (r gormPosts) List(ctx context.Context, filter PostFilter) ([]models.Post, error) {
+	query := r.filtered(ctx, filter)
+	if filter.Limit > 0 {
+		query = query.Scopes(Keyset("posts", filter.After, filter.Limit))
+	}
+	if filter.ByPosition {
+		query = query.Order("position").Order("id")
+	}
+	query = query.Scopes(Sorted("posts", filter.Sort))
+	for _, association := range filter.Preload {
+		query = query.Preload(association)
+	}
+
+	var posts []models.Post
+	if err := query.Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+func (r gormPosts) Count(ctx context.Context, filter PostFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+func (r gormPosts) Create(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Create(post).Error
+}
+
+func (r gormPosts) Update(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Save(post).Error
+}
+
+func (r gormPosts) SetTags(ctx context.Context, post *models.Post, tags []models.Tag) error {
+	return r.db.WithContext(ctx).Model(post).Association("Tags").Replace(tags)
+}
+
+func (r gormPosts) Delete(ctx context.Context, post *models.Post) error {
+	return r.db.WithContext(ctx).Delete(post).Error
+}
+
+type gormPages struct {
+	db *gorm.DB
+}
+
+func (r gormPages) filtered(ctx context.Context, filter PageFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&models.Page{}).Scopes(acl.Readable(filter.Caller, acl.EntityPage, "pages"))
+	if filter.Title != "" {
+		query = query.Where("title ILIKE ?", "%"+filter.Title+"%")
+	}
+	if filter.Author != "" {
+		query = query.Where("author = ?", filter.Author)
+	}
+	if filter.OwnerGroup != "" {
+		query = query.Where("owner_group_id = ?", filter.OwnerGroup)
+	}
+	if filter.Status != "" {
+		query = query.Where("pages.status = ?", filter.Status)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("pages.created_at < ?", *filter.CreatedBefore)
+	}
+	if filter.Search != "" {
+		query = query.Scopes(search.Match("pages", filter.Search, filter.SearchConfig))
+	}
+	return query
+}
+
+func (r gormPages) List(ctx context.Context, filter PageFilter) ([]models.Page, error) {
+	var pages []models.Page
+	if err := r.filtered(ctx, filter).Scopes(Sorted("pages", filter.Sort)).Find(&pages).Error; err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+func (r gormPages) Count(ctx context.Context, filter PageFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+func (r gormPages) Get(ctx context.Context, id uint) (*models.Page, error) {
+	var page models.Page
+	if err := first(r.db.WithContext(ctx), &page, id); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r gormPages) GetReadable(ctx context.Context, caller auth.Caller, id uint) (*models.Page, error) {
+	var page models.Page
+	if err := first(r.db.WithContext(ctx).Scopes(acl.Readable(caller, acl.EntityPage, "pages")), &page, id); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (r gormPages) Create(ctx context.Context, page *models.Page) error {
+	return r.db.WithContext(ctx).Create(page).Error
+}
+
+func (r gormPages) Update(ctx context.Context, page *models.Page) error {
+	return r.db.WithContext(ctx).Save(page).Error
+}
+
+func (r gormPages) Delete(ctx context.Context, page *models.Page) error {
+	return r.db.WithContext(ctx).Delete(page).Error
+}
+
+type gormMedia struct {
+	db *gorm.DB
+}
+
+func (r gormMedia) visible(ctx context.Context, includeQuarantined bool) *gorm.DB {
+	query := r.db.WithContext(ctx)
+	if !includeQuarantined {
+		query = query.Where("scan_status <> ?", models.ScanQuarantined)
+	}
+	return query
+}
+
+func (r gormMedia) filtered(ctx context.Context, filter MediaFilter) *gorm.DB {
+	query := r.visible(ctx, filter.IncludeQuarantined).Model(&models.Media{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IDs != nil {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	return query
+}
+
+func (r gormMedia) List(ctx context.Context, filter MediaFilter) ([]models.Media, error) {
+	var media []models.Media
+	if err := r.filtered(ctx, filter).Scopes(Sorted("media", filter.Sort)).Find(&media).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+func (r gormMedia) Count(ctx context.Context, filter MediaFilter) (int64, error) {
+	var count int64
+	err := r.filtered(ctx, filter).Count(&count).Error
+	return count, err
+}
+
+func (r gormMedia) Get(ctx context.Context, id uint, includeQuarantined bool) (*models.Media, error) {
+	var media models.Media
+	if err := first(r.visible(ctx, includeQuarantined), &media, id); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+func (r gormMedia) Create(ctx context.Context, media *models.Media) error {
+	return r.db.WithContext(ctx).Create(media).Error
+}
+
+func (r gormMedia) Update(ctx context.Context, media *models.Media) error {
+	return r.db.WithContext(ctx).Model(media).Select("alt_text", "focal_x", "focal_y", "crops").Updates(media).Error
+}
+
+func (r gormMedia) Delete(ctx context.Context, media *models.Media) error {
+	return r.db.WithContext(ctx).Delete(media).Error
+}
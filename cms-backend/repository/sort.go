@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cursor is the position of the last row of a keyset page
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uint
+}
+
+// SortField orders a listing by one column
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Sorted returns a scope ordering rows of table by fields, then by id so that
+// rows with equal values keep a stable order across requests. Without fields
+// the query is left unordered. Columns are expected to come from a whitelist.
+func Sorted(table string, fields []SortField) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		if len(fields) == 0 {
+			return query
+		}
+		columns := make([]clause.OrderByColumn, 0, len(fields)+1)
+		byID := false
+		for _, field := range fields {
+			columns = append(columns, clause.OrderByColumn{
+				Column: clause.Column{Table: table, Name: field.Column},
+				Desc:   field.Desc,
+			})
+			byID = byID || field.Column == "id"
+		}
+		if !byID {
+			columns = append(columns, clause.OrderByColumn{Column: clause.Column{Table: table, Name: "id"}})
+		}
+		return query.Order(clause.OrderBy{Columns: columns})
+	}
+}
+
+// Keyset returns a scope reading at most limit rows of table newest first by
+// (created_at, id), starting after the cursor when one is given, so deep
+// pages cost an index seek instead of scanning skipped rows
+func Keyset(table string, after *Cursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(query *gorm.DB) *gorm.DB {
+		if after != nil {
+			query = query.Where("("+table+".created_at, "+table+".id) < (?, ?)", after.CreatedAt, after.ID)
+		}
+		return query.Order(table + ".created_at DESC").Order(table + ".id DESC").Limit(limit)
+	}
+}
@@ -1,7 +1,26 @@
 package routes
 
 import (
+	"cms-backend/accesslog"
+	"cms-backend/adminui"
+	"cms-backend/auth"
+	"cms-backend/cache"
 	"cms-backend/controllers"
+	"cms-backend/health"
+	"cms-backend/middleware"
+	"cms-backend/migrations"
+	"cms-backend/presence"
+	"cms-backend/querybudget"
+	"cms-backend/ratelimit"
+	"cms-backend/replica"
+	"cms-backend/repository"
+	"cms-backend/storage"
+	"cms-backend/utils"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -9,32 +28,455 @@ import (
 
 // InitializeRoutes sets up all API routes
 func InitializeRoutes(router *gin.Engine, db *gorm.DB) {
+	// Answer errors handlers report with utils.Fail
+	router.Use(utils.HandleErrors())
+
+	// Refuse request bodies over MAX_BODY_BYTES before handlers read them
+	router.Use(middleware.BodyLimit(middleware.MaxBodyBytesFromEnv()))
+
+	// Record sampled write requests with their bodies when ACCESS_LOG=true
+	accessLog, err := accesslog.FromEnv()
+	if err != nil {
+		log.Printf("Ignoring access log settings: %v", err)
+	}
+	if accessLog != nil {
+		router.Use(accessLog.Handler())
+	}
+
 	// Add database middleware
 	router.Use(func(c *gin.Context) {
 		c.Set("db", db)
 		c.Next()
 	})
 
-	// Create API version group
-	api := router.Group("/api/v1")
+	// Cache read endpoints for anonymous and delivery callers; writes purge the
+	// cache. Settings in the "cache" namespace override the default windows.
+	responseCache := cache.New(cache.DefaultPolicies, router)
+
+	// Handlers are constructed with the services they use
+	deps := controllers.Deps{
+		Repositories: repository.NewGorm,
+		Cache:        responseCache,
+		Logger:       log.Default(),
+		Clock:        time.Now,
+		Storage:      storage.Default,
+		Presence:     presence.Default,
+	}
+
+	// Serve media kept in local storage when no other host serves it
+	if local, ok := deps.Storage.(*storage.Local); ok && local.ServePath != "" {
+		router.Static(local.ServePath, local.Dir)
+	}
+
+	// Shed delivery reads that miss the cache when the database is overloaded
+	limitConfig, err := ratelimit.ConfigFromEnv()
+	if err != nil {
+		log.Printf("Ignoring rate limit settings: %v", err)
+	}
+	limiter := ratelimit.New(limitConfig)
+	if sqlDB, err := db.DB(); err == nil {
+		limiter.Monitor(context.Background(), sqlDB)
+	}
+
+	// Serve delivery reads from region-local replicas when DB_REPLICAS configures them
+	readReplicas, err := replica.FromEnv(db)
+	if err != nil {
+		log.Printf("Ignoring read replicas: %v", err)
+		readReplicas = replica.New(db, "", replica.DefaultMaxLag)
+	}
+	readReplicas.Monitor(context.Background())
+
+	// Probes report degraded mode when a backend is only reachable through a fallback
+	probes := health.NewRegistry()
+	probes.Register("database", true, func(ctx context.Context) (string, string) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return health.StatusDown, err.Error()
+		}
+		// A ping would queue behind the busy connections, which prove the database is reachable
+		if stats := sqlDB.Stats(); stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+			return health.StatusDegraded, "connection pool exhausted; requests are waiting for connections"
+		}
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return health.StatusDown, err.Error()
+		}
+		return health.StatusOK, ""
+	})
+	if sqlDB, err := db.DB(); err == nil {
+		probes.AddStats("database_pool", func() interface{} {
+			return utils.NewPoolStats(sqlDB.Stats())
+		})
+	}
+	probes.Register("read_replicas", false, func(ctx context.Context) (string, string) {
+		if regions := readReplicas.Unavailable(); len(regions) > 0 {
+			return health.StatusDegraded, "serving reads from primary for " + strings.Join(regions, ", ")
+		}
+		return health.StatusOK, ""
+	})
+	probes.Register("load_shedding", false, func(ctx context.Context) (string, string) {
+		if factor := limiter.Factor(); factor < 1 {
+			return health.StatusDegraded, fmt.Sprintf("delivery rate limited to %.0f%%", factor*100)
+		}
+		return health.StatusOK, ""
+	})
+	probes.Register("migrations", false, func(ctx context.Context) (string, string) {
+		status, err := migrations.StatusFromDB(db.WithContext(ctx))
+		if err != nil {
+			return health.StatusDegraded, err.Error()
+		}
+		if status.Dirty {
+			return health.StatusDegraded, fmt.Sprintf("migration %d failed part-way", status.Version)
+		}
+		if len(status.Pending) > 0 {
+			return health.StatusDegraded, fmt.Sprintf("schema at version %d, %d migrations pending", status.Version, len(status.Pending))
+		}
+		return health.StatusOK, ""
+	})
+	router.GET("/healthz", health.Liveness)
+	router.GET("/readyz", probes.Readiness())
+
+	// Requests running more than QUERY_BUDGET statements are logged
+	queryBudget := querybudget.BudgetFromEnv()
+
+	// Every version serves the same routes and controllers; from v2 responses
+	// are wrapped in an envelope
+	for version := 1; version <= middleware.LatestVersion; version++ {
+		registerVersion(router, version, deps, limiter, readReplicas, queryBudget)
+	}
+
+	// Sitemaps and robots.txt are read by crawlers, so like the delivery API
+	// they read from replicas and use the longer "delivery" cache policy
+	crawlers := router.Group("", readReplicas.Handler(), responseCache.Handler("delivery"), limiter.Handler())
+	crawlers.GET("/robots.txt", controllers.GetRobots)
+	crawlers.GET("/sitemap.xml", controllers.GetSitemap)
+	crawlers.GET("/sitemaps/:name", controllers.GetSitemapPart)
+
+	// Serve the bundled admin app when ADMIN_UI=true
+	if ui := adminui.FromEnv(); ui != nil {
+		ui.Register(router)
+	}
+}
+
+// registerVersion sets up the routes of one API version under /api/v<version>
+func registerVersion(router *gin.Engine, version int, deps controllers.Deps, limiter *ratelimit.Limiter, readReplicas *replica.Set, queryBudget int) {
+	prefix := fmt.Sprintf("/api/v%d", version)
+	responseCache := deps.Cache
+
+	// Malformed record IDs are refused, and UUIDs resolved, before anything
+	// else runs. An API key, when given, identifies the caller. Queries are
+	// counted on whichever database the request ends up reading from.
+	// Anonymous callers are refused as REQUIRE_AUTH says, by default on every
+	// route, so visitors without a key read through the delivery API and
+	// record views through tracking alone. Submitter keys are
+	// confined to the submission portal, delivery tokens to the delivery API
+	// and keys with scopes to the routes those grant. Content routes answer
+	// JSON:API documents to clients that ask for them.
+	api := router.Group(prefix, middleware.APIVersion(version), middleware.JSONAPI(prefix), middleware.ValidateIDs(), middleware.Authenticate(),
+		middleware.RequireAuth(), middleware.RequireScope(auth.ScopeManagement), middleware.DenyRole(auth.RoleSubmitter), middleware.RequireScopes(), readReplicas.Handler(),
+		querybudget.Middleware(queryBudget), responseCache.InvalidateOnWrite())
+
+	// The submission portal is never cached and always uses the primary, so
+	// submitters see their own submissions at once; accepting one creates a
+	// post, so writes still purge the cache. Notifications are personal and
+	// open to every key, submitters included, that its scopes allow.
+	portal := router.Group(prefix, middleware.APIVersion(version), middleware.ValidateIDs(), middleware.Authenticate(),
+		middleware.RequireAuth(), middleware.RequireScope(auth.ScopeManagement), middleware.RequireScopes(), querybudget.Middleware(queryBudget),
+		responseCache.InvalidateOnWrite())
+	portal.GET("/submissions", controllers.GetSubmissions)
+	portal.GET("/submissions/:id", controllers.GetSubmission)
+	portal.POST("/submissions", controllers.CreateSubmission)
+	portal.DELETE("/submissions/:id", controllers.WithdrawSubmission)
+	portal.POST("/submissions/:id/accept", controllers.AcceptSubmission)
+	portal.POST("/submissions/:id/reject", controllers.RejectSubmission)
+	portal.GET("/me/notifications", controllers.GetNotifications)
+	portal.POST("/me/notifications/read", controllers.MarkAllNotificationsRead)
+	portal.POST("/me/notifications/:id/read", controllers.MarkNotificationRead)
+
+	// View tracking changes no content, so it skips the cache purge and
+	// read-after-write routing that other writes trigger. Views are recorded
+	// by visitors' browsers, so anonymous callers are let through; a key with
+	// scopes needs only read access, since a view changes no content.
+	tracking := router.Group(prefix, middleware.APIVersion(version), middleware.ValidateIDs(), middleware.Authenticate(),
+		middleware.RequireScope(auth.ScopeManagement), middleware.DenyRole(auth.RoleSubmitter), middleware.RequireReadScopes())
+	tracking.POST("/posts/:id/view", controllers.RecordPostView)
+
+	// Embargo links are opened by press contacts without an API key; each open
+	// is recorded, so they also use the primary
+	tracking.GET("/embargoed/:token", limiter.Handler(), controllers.GetEmbargoedPost)
+
+	// The delivery API serves published content only and answers every caller
+	// alike, so its responses are safe for CDNs. Anonymous callers and delivery
+	// tokens may use it; management tokens are refused so they never end up in
+	// front-end code. Member tokens also see members-only content, so their
+	// responses are never cached. It reads from replicas and uses the longer
+	// "delivery" cache policy; management writes still purge it.
+	delivery := router.Group(prefix+"/delivery", middleware.APIVersion(version), middleware.JSONAPI(prefix), middleware.Authenticate(),
+		middleware.RequireScope(auth.ScopeDelivery), middleware.Audience(), readReplicas.Handler(),
+		querybudget.Middleware(queryBudget), responseCache.Handler("delivery"), limiter.Handler())
+	delivery.GET("/posts", controllers.GetDeliveryPosts)
+	delivery.GET("/posts/:slug", controllers.GetDeliveryPost)
+	delivery.GET("/pages", controllers.GetDeliveryPages)
+	delivery.GET("/pages/:slug", controllers.GetDeliveryPage)
+	delivery.GET("/forms/:slug", controllers.GetDeliveryForm)
+	delivery.GET("/authors/:username", controllers.GetAuthor)
+
+	// Visitors submit forms and manage newsletter subscriptions through the
+	// same tokens as the delivery API, but these writes change no content, so
+	// they skip the cache and always reach the primary
+	visitors := router.Group(prefix+"/delivery", middleware.APIVersion(version), middleware.Authenticate(),
+		middleware.RequireScope(auth.ScopeDelivery), querybudget.Middleware(queryBudget))
+	visitors.POST("/forms/:slug/submissions", controllers.SubmitForm)
+	visitors.POST("/newsletter/subscribe", controllers.Subscribe)
+	visitors.POST("/newsletter/confirm", controllers.ConfirmSubscription)
+	visitors.POST("/newsletter/unsubscribe", controllers.Unsubscribe)
+
+	// External asset managers post media changes signed with their own
+	// secrets instead of an API key; the changes purge the cache
+	integrations := router.Group(prefix+"/integrations", middleware.APIVersion(version),
+		querybudget.Middleware(queryBudget), responseCache.InvalidateOnWrite())
+	integrations.POST("/dam/:provider", controllers.NewDAMHandler(deps).Receive)
+
+	// Bundle downloads may queue a build, so they always use the primary. They
+	// are published content, so both token types may fetch them, given bundles:read.
+	bundles := router.Group(prefix, middleware.APIVersion(version), middleware.Authenticate(), middleware.DenyRole(auth.RoleSubmitter),
+		middleware.RequireScopes())
+	bundles.GET("/bundles/:channel", limiter.Handler(), controllers.GetBundle)
 
 	// Page Routes
-	api.GET("/pages", controllers.GetPages)
-	api.GET("/pages/:id", controllers.GetPage)
-	api.POST("/pages", controllers.CreatePage)
-	api.PUT("/pages/:id", controllers.UpdatePage)
-	api.DELETE("/pages/:id", controllers.DeletePage)
+	pages := controllers.NewPageHandler(deps)
+	api.GET("/pages", responseCache.Handler("pages"), limiter.Handler(), pages.List)
+	api.HEAD("/pages", pages.List)
+	api.GET("/pages/:id", responseCache.Handler("pages"), limiter.Handler(), pages.Get)
+	api.POST("/pages", pages.Create)
+	api.PUT("/pages/:id", pages.Update)
+	api.DELETE("/pages/:id", pages.Delete)
+	api.GET("/pages/:id/export.pdf", controllers.ExportPagePDF)
+	api.POST("/pages/:id/duplicate", controllers.DuplicatePage)
 
 	// Post Routes
-	api.GET("/posts", controllers.GetPosts)
-	api.GET("/posts/:id", controllers.GetPost)
-	api.POST("/posts", controllers.CreatePost)
-	api.PUT("/posts/:id", controllers.UpdatePost)
-	api.DELETE("/posts/:id", controllers.DeletePost)
+	posts := controllers.NewPostHandler(deps)
+	api.GET("/posts", responseCache.Handler("posts"), limiter.Handler(), posts.List)
+	api.HEAD("/posts", posts.List)
+	api.GET("/posts/count", responseCache.Handler("posts"), limiter.Handler(), posts.Count)
+	api.GET("/posts/popular", responseCache.Handler("posts"), limiter.Handler(), controllers.GetPopularPosts)
+	api.GET("/posts/:id", responseCache.Handler("posts"), limiter.Handler(), posts.Get)
+	api.POST("/posts", posts.Create)
+	api.PATCH("/posts/reorder", controllers.ReorderFeaturedPosts)
+	api.POST("/posts/publish", controllers.BulkPublishPosts)
+	api.PUT("/posts/:id", posts.Update)
+	api.DELETE("/posts/:id", posts.Delete)
+	api.GET("/posts/:id/export.pdf", controllers.ExportPostPDF)
+	api.POST("/posts/:id/duplicate", controllers.DuplicatePost)
+	api.POST("/posts/:id/suggest-tags", controllers.SuggestTags)
+	api.GET("/posts/:id/publish-readiness", controllers.GetPublishReadiness)
+
+	// Author Routes
+	api.GET("/authors/:username", responseCache.Handler("posts"), limiter.Handler(), controllers.GetAuthor)
+	api.PUT("/authors/:username", controllers.PutAuthor)
+
+	// Related Post Routes
+	api.GET("/posts/:id/related", responseCache.Handler("posts"), limiter.Handler(), controllers.GetRelatedPosts)
+	api.POST("/posts/:id/related", controllers.AddRelatedPosts)
+	api.DELETE("/posts/:id/related/:relatedId", controllers.RemoveRelatedPost)
+
+	// Collaborative Editing Routes
+	editing := controllers.NewPresenceHandler(deps)
+	api.GET("/posts/:id/presence", editing.Connect)
+	api.GET("/posts/:id/lock", editing.GetLock)
+	api.POST("/posts/:id/lock", editing.AcquireLock)
+	api.POST("/posts/:id/lock/steal", editing.StealLock)
+	api.DELETE("/posts/:id/lock", editing.ReleaseLock)
+
+	// Group Routes (managing groups requires the ADMIN_TOKEN shared secret)
+	api.GET("/groups", controllers.GetGroups)
+	api.GET("/groups/:id", controllers.GetGroup)
+	api.POST("/groups", middleware.RequireAdminToken(), controllers.CreateGroup)
+	api.PUT("/groups/:id", middleware.RequireAdminToken(), controllers.UpdateGroup)
+	api.DELETE("/groups/:id", middleware.RequireAdminToken(), controllers.DeleteGroup)
+	api.POST("/groups/:id/members", middleware.RequireAdminToken(), controllers.AddGroupMember)
+	api.DELETE("/groups/:id/members/:keyId", middleware.RequireAdminToken(), controllers.RemoveGroupMember)
+
+	// Activity Feed Routes
+	api.GET("/posts/:id/activity", controllers.GetPostActivity)
+	api.GET("/pages/:id/activity", controllers.GetPageActivity)
+	api.GET("/users/:id/activity", controllers.GetUserActivity)
+
+	// Access Control Routes
+	api.GET("/posts/:id/acl", controllers.GetPostACL)
+	api.PUT("/posts/:id/acl", controllers.PutPostACL)
+	api.GET("/pages/:id/acl", controllers.GetPageACL)
+	api.PUT("/pages/:id/acl", controllers.PutPageACL)
+
+	// Embargo Routes
+	api.GET("/posts/:id/embargo", controllers.GetEmbargo)
+	api.PUT("/posts/:id/embargo", controllers.EmbargoPost)
+	api.DELETE("/posts/:id/embargo", controllers.DeleteEmbargo)
+
+	// Distribution Routes (press contacts are personal data, so only admins
+	// and editors may read or change the lists)
+	distribution := api.Group("/distribution-lists", middleware.RequireRole(auth.RoleAdmin, auth.RoleEditor))
+	distribution.GET("", controllers.GetDistributionLists)
+	distribution.GET("/:id", controllers.GetDistributionList)
+	distribution.POST("", controllers.CreateDistributionList)
+	distribution.PUT("/:id", controllers.UpdateDistributionList)
+	distribution.DELETE("/:id", controllers.DeleteDistributionList)
+
+	// Redirect Routes (renaming published posts and pages adds redirects too)
+	api.GET("/redirects", controllers.GetRedirects)
+	api.GET("/redirects/resolve", responseCache.Handler("redirects"), limiter.Handler(), controllers.ResolveRedirect)
+	api.GET("/redirects/:id", controllers.GetRedirect)
+	api.POST("/redirects", controllers.CreateRedirect)
+	api.PUT("/redirects/:id", controllers.UpdateRedirect)
+	api.DELETE("/redirects/:id", controllers.DeleteRedirect)
+
+	// Form Routes (defining forms and reading submissions requires the
+	// ADMIN_TOKEN shared secret; visitors submit through the delivery API)
+	api.GET("/forms", controllers.GetForms)
+	api.GET("/forms/:id", controllers.GetForm)
+	api.POST("/forms", middleware.RequireAdminToken(), controllers.CreateForm)
+	api.PUT("/forms/:id", middleware.RequireAdminToken(), controllers.UpdateForm)
+	api.DELETE("/forms/:id", middleware.RequireAdminToken(), controllers.DeleteForm)
+	api.GET("/forms/:id/submissions", middleware.RequireAdminToken(), controllers.GetFormSubmissions)
+	api.GET("/forms/:id/submissions/export.csv", middleware.RequireAdminToken(), controllers.ExportFormSubmissions)
+	api.PUT("/forms/:id/submissions/:submissionId/status", middleware.RequireAdminToken(), controllers.ModerateFormSubmission)
+	api.DELETE("/forms/:id/submissions/:submissionId", middleware.RequireAdminToken(), controllers.DeleteFormSubmission)
+
+	// Newsletter Routes (subscribers are personal data, so reading them
+	// requires the ADMIN_TOKEN shared secret)
+	api.GET("/subscribers", middleware.RequireAdminToken(), controllers.GetSubscribers)
+	api.GET("/subscribers/export.csv", middleware.RequireAdminToken(), controllers.ExportSubscribers)
+	api.DELETE("/subscribers/:id", middleware.RequireAdminToken(), controllers.DeleteSubscriber)
+
+	// Path Resolution Routes
+	api.GET("/resolve", responseCache.Handler("redirects"), limiter.Handler(), controllers.Resolve)
+
+	// Series Routes
+	api.GET("/series", responseCache.Handler("series"), limiter.Handler(), controllers.GetSeriesList)
+	api.GET("/series/:id", responseCache.Handler("series"), limiter.Handler(), controllers.GetSeries)
+	api.POST("/series", controllers.CreateSeries)
+	api.PUT("/series/:id", controllers.UpdateSeries)
+	api.DELETE("/series/:id", controllers.DeleteSeries)
+	api.POST("/series/:id/export/epub", controllers.ExportSeriesEPUB)
+
+	// Playlist Routes
+	api.GET("/playlists", controllers.GetPlaylists)
+	api.GET("/playlists/:id", controllers.GetPlaylist)
+	api.GET("/playlists/:id/play", limiter.Handler(), controllers.PlayPlaylist)
+	api.POST("/playlists", controllers.CreatePlaylist)
+	api.PUT("/playlists/:id", controllers.UpdatePlaylist)
+	api.DELETE("/playlists/:id", controllers.DeletePlaylist)
+
+	// Job Routes
+	api.GET("/jobs/:id", controllers.GetJob)
+	api.GET("/jobs/:id/download", controllers.DownloadJobResult)
+
+	// Tag Routes
+	api.GET("/tags", responseCache.Handler("tags"), limiter.Handler(), controllers.GetTags)
+	api.POST("/tags", controllers.CreateTag)
+	api.PUT("/tags/:id", controllers.UpdateTag)
+	api.POST("/tags/:id/merge", controllers.MergeTags)
+	api.DELETE("/tags/:id", controllers.DeleteTag)
 
 	// Media Routes
-	api.GET("/media", controllers.GetMedia)
-	api.GET("/media/:id", controllers.GetMediaByID)
-	api.POST("/media", controllers.CreateMedia)
-	api.DELETE("/media/:id", controllers.DeleteMedia)
+	media := controllers.NewMediaHandler(deps)
+	api.GET("/media", responseCache.Handler("media"), limiter.Handler(), media.List)
+	api.HEAD("/media", media.List)
+	api.GET("/media/:id", responseCache.Handler("media"), limiter.Handler(), media.Get)
+	api.POST("/media", media.Create)
+	api.POST("/media/import", media.Import)
+	api.POST("/media/export", media.Export)
+	api.PUT("/media/:id", media.Update)
+	api.DELETE("/media/:id", media.Delete)
+
+	// Collection Routes (saved searches, evaluated when their items are fetched)
+	api.GET("/collections", controllers.GetCollections)
+	api.GET("/collections/:id", controllers.GetCollection)
+	api.GET("/collections/:id/items", limiter.Handler(), controllers.GetCollectionItems(map[string]gin.HandlerFunc{
+		"posts": posts.List,
+		"pages": pages.List,
+		"media": media.List,
+	}))
+	api.POST("/collections", controllers.CreateCollection)
+	api.PUT("/collections/:id", controllers.UpdateCollection)
+	api.DELETE("/collections/:id", controllers.DeleteCollection)
+
+	// Environment Routes
+	api.GET("/environments", controllers.GetEnvironments)
+	api.POST("/environments", controllers.CreateEnvironment)
+	api.DELETE("/environments/:name", controllers.DeleteEnvironment)
+	api.GET("/environments/:name/entries", controllers.GetEnvironmentEntries)
+	api.PUT("/environments/:name/entries/:type/:slug", controllers.PutEnvironmentEntry)
+	api.DELETE("/environments/:name/entries/:type/:slug", controllers.DeleteEnvironmentEntry)
+	api.GET("/environments/:name/diff", controllers.DiffEnvironment)
+	api.POST("/environments/:name/promote", controllers.PromoteEnvironment)
+
+	// Report Routes
+	api.GET("/reports/broken-links", controllers.GetBrokenLinks)
+	api.GET("/reports/accessibility", controllers.GetAccessibilityReport)
+	api.GET("/reports/trash", controllers.GetTrashReport)
+
+	// Readability Routes
+	api.POST("/analyze", controllers.Analyze)
+
+	// Statistics Routes
+	api.GET("/stats", responseCache.Handler("stats"), limiter.Handler(), controllers.GetStats)
+
+	// Change Feed Routes (never cached so consumers always see the latest sequence)
+	api.GET("/changes", controllers.GetChanges)
+	api.GET("/sync", limiter.Handler(), controllers.GetSync)
+
+	// Webhook Routes (each API key manages its own webhooks)
+	api.GET("/webhooks", controllers.GetWebhooks)
+	api.POST("/webhooks", controllers.CreateWebhook)
+	api.PUT("/webhooks/:id", controllers.UpdateWebhook)
+	api.DELETE("/webhooks/:id", controllers.DeleteWebhook)
+	api.POST("/webhooks/:id/test", controllers.TestWebhook)
+
+	// Settings Routes (writes require the ADMIN_TOKEN shared secret)
+	api.GET("/settings", controllers.GetSettings)
+	api.GET("/settings/:namespace/:key", controllers.GetSetting)
+	api.PUT("/settings/:namespace/:key", middleware.RequireAdminToken(), controllers.PutSetting)
+	api.DELETE("/settings/:namespace/:key", middleware.RequireAdminToken(), controllers.DeleteSetting)
+
+	// Schema Routes
+	api.GET("/schema", controllers.GetSchemaTypes)
+	api.GET("/schema/:type", controllers.GetSchema)
+
+	// Feature Flag Routes (managing flags requires the ADMIN_TOKEN shared secret)
+	api.GET("/flags", middleware.RequireAdminToken(), controllers.GetFlags)
+	api.GET("/flags/evaluated", controllers.GetEvaluatedFlags)
+	api.PUT("/flags/:name", middleware.RequireAdminToken(), controllers.PutFlag)
+	api.DELETE("/flags/:name", middleware.RequireAdminToken(), controllers.DeleteFlag)
+
+	// Admin Routes (require the ADMIN_TOKEN shared secret)
+	admin := api.Group("/admin", middleware.RequireAdminToken())
+	admin.GET("/migrations", controllers.GetMigrationStatus)
+	admin.POST("/migrations/up", controllers.RunMigrations)
+	admin.POST("/migrations/force", controllers.ForceMigrationVersion)
+	admin.POST("/migrations/:version/rerun", controllers.RerunMigration)
+	admin.GET("/api-keys", controllers.GetAPIKeys)
+	admin.POST("/api-keys", controllers.CreateAPIKey)
+	admin.DELETE("/api-keys/:id", controllers.DeleteAPIKey)
+	admin.GET("/lockouts", controllers.GetLockouts)
+	admin.DELETE("/lockouts/:client", controllers.DeleteLockout)
+	admin.GET("/users/:username/storage", controllers.GetStorageUsage)
+	admin.PUT("/users/:username/storage", controllers.PutStorageQuota)
+	admin.GET("/orphans", controllers.GetOrphanReport)
+	admin.POST("/orphans/clean", controllers.CleanOrphans)
+	admin.GET("/slow-queries", controllers.GetSlowQueries)
+	admin.POST("/media/bulk-import", controllers.BulkImportMedia)
+	admin.GET("/backups", controllers.GetBackups)
+	admin.POST("/backups", controllers.CreateBackup)
+	admin.POST("/backups/:name/restore", controllers.RestoreBackup)
+	admin.GET("/integrations", controllers.GetIntegrations)
+	admin.GET("/integrations/presets", controllers.GetIntegrationPresets)
+	admin.POST("/integrations", controllers.CreateIntegration)
+	admin.PUT("/integrations/:id", controllers.UpdateIntegration)
+	admin.DELETE("/integrations/:id", controllers.DeleteIntegration)
+	admin.POST("/integrations/:id/test", controllers.TestIntegration)
+	admin.GET("/chat-channels", controllers.GetChatChannels)
+	admin.POST("/chat-channels", controllers.CreateChatChannel)
+	admin.PUT("/chat-channels/:id", controllers.UpdateChatChannel)
+	admin.DELETE("/chat-channels/:id", controllers.DeleteChatChannel)
+	admin.POST("/chat-channels/:id/test", controllers.TestChatChannel)
 }
@@ -0,0 +1,31 @@
+package scheduler
+
+import (
+	"cms-backend/backup"
+	"cms-backend/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CreateBackup starts a backup job every BACKUP_INTERVAL, unless one is
+// still running
+func CreateBackup(ctx context.Context, db *gorm.DB, now time.Time) error {
+	due, err := backup.Default.Due(now)
+	if err != nil || !due {
+		return err
+	}
+
+	var running int64
+	if err := db.WithContext(ctx).Model(&models.Job{}).
+		Where("type = ? AND status IN ?", backup.JobTypeCreate, []string{models.JobQueued, models.JobRunning}).
+		Count(&running).Error; err != nil {
+		return err
+	}
+	if running > 0 {
+		return nil
+	}
+	_, err = backup.Default.StartCreate(db, 0)
+	return err
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultChangeRetention is how long change feed entries, including deletion
+// tombstones, are kept when CHANGE_RETENTION is not set
+const DefaultChangeRetention = 30 * 24 * time.Hour
+
+// PruneChanges deletes change feed entries older than CHANGE_RETENTION and
+// records how far it pruned. Consumers whose cursor falls behind must re-sync.
+func PruneChanges(ctx context.Context, db *gorm.DB, now time.Time) error {
+	retention := DefaultChangeRetention
+	if value := os.Getenv("CHANGE_RETENTION"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("Ignoring invalid CHANGE_RETENTION %q", value)
+		} else {
+			retention = parsed
+		}
+	}
+
+	db = db.WithContext(ctx)
+	var through int64
+	if err := db.Model(&models.Change{}).
+		Where("changed_at < ?", now.Add(-retention)).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&through).Error; err != nil {
+		return err
+	}
+	if through == 0 {
+		return nil
+	}
+
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		result := tx.Where("seq <= ?", through).Delete(&models.Change{})
+		if result.Error != nil {
+			return result.Error
+		}
+		log.Printf("Pruned %d change feed entries through %d", result.RowsAffected, through)
+
+		// Only the latest watermark matters
+		if err := tx.Where("pruned_through < ?", through).Delete(&models.ChangePrune{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ChangePrune{PrunedThrough: through}).Error
+	})
+}
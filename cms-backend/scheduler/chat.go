@@ -0,0 +1,14 @@
+package scheduler
+
+import (
+	"cms-backend/chat"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AnnounceChat posts new editorial events to Slack and Discord channels
+func AnnounceChat(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return chat.Dispatch(ctx, db)
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"cms-backend/models"
+	"cms-backend/utils"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PublishEmbargoed publishes draft posts whose embargo has lifted. Posts
+// published or archived by hand in the meantime are left alone.
+func PublishEmbargoed(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+	var embargoes []models.Embargo
+	if err := db.Where("published_at IS NULL AND publish_at <= ?", now).Find(&embargoes).Error; err != nil {
+		return err
+	}
+	if len(embargoes) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(embargoes))
+	postIDs := make([]uint, len(embargoes))
+	for i, embargo := range embargoes {
+		ids[i] = embargo.ID
+		postIDs[i] = embargo.PostID
+	}
+
+	return utils.WithTransaction(db, func(tx *gorm.DB) error {
+		var drafts []uint
+		if err := tx.Model(&models.Post{}).Where("id IN ? AND status = ?", postIDs, models.StatusDraft).
+			Pluck("id", &drafts).Error; err != nil {
+			return err
+		}
+		if len(drafts) > 0 {
+			result := tx.Model(&models.Post{}).
+				Where("id IN ? AND status = ?", drafts, models.StatusDraft).
+				Updates(map[string]interface{}{"status": models.StatusPublished, "updated_at": now})
+			if result.Error != nil {
+				return result.Error
+			}
+			log.Printf("Published %d embargoed posts", result.RowsAffected)
+
+			// Record the status changes like a publish by hand, so the activity
+			// trail and the chat announcements reading it include them
+			activities := make([]models.Activity, len(drafts))
+			for i, id := range drafts {
+				activities[i] = models.Activity{
+					EntityType: "post",
+					EntityID:   id,
+					Action:     models.ActivityStatusChanged,
+					FromStatus: models.StatusDraft,
+					ToStatus:   models.StatusPublished,
+				}
+			}
+			if err := tx.Create(&activities).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.Embargo{}).Where("id IN ?", ids).Update("published_at", now).Error
+	})
+}
@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"cms-backend/models"
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExpireContent archives published posts and pages whose expires_at has passed
+func ExpireContent(ctx context.Context, db *gorm.DB, now time.Time) error {
+	tables := []struct {
+		name  string
+		model interface{}
+	}{
+		{"posts", &models.Post{}},
+		{"pages", &models.Page{}},
+	}
+	for _, table := range tables {
+		result := db.WithContext(ctx).Model(table.model).
+			Where("status = ? AND expires_at <= ?", models.StatusPublished, now).
+			Updates(map[string]interface{}{"status": models.StatusArchived, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Archived %d expired %s", result.RowsAffected, table.name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package scheduler
+
+import (
+	"cms-backend/linkcheck"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CheckLinks collects external links from published content and checks those that are due
+func CheckLinks(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return linkcheck.Default.Run(ctx, db, now)
+}
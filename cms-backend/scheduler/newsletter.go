@@ -0,0 +1,14 @@
+package scheduler
+
+import (
+	"cms-backend/newsletter"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SyncSubscribers pushes subscriber changes to the configured mailing list provider
+func SyncSubscribers(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return newsletter.SyncPending(ctx, db, newsletter.Default)
+}
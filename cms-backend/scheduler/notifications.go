@@ -0,0 +1,26 @@
+package scheduler
+
+import (
+	"cms-backend/notifications"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SendNotificationDigests emails notifications left unread for
+// NOTIFICATION_DIGEST_DELAY through the configured digest sender
+func SendNotificationDigests(ctx context.Context, db *gorm.DB, now time.Time) error {
+	delay := notifications.DefaultDigestDelay
+	if value := os.Getenv("NOTIFICATION_DIGEST_DELAY"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Ignoring invalid NOTIFICATION_DIGEST_DELAY %q", value)
+		} else {
+			delay = parsed
+		}
+	}
+	return notifications.SendDigests(ctx, db, notifications.DigestSender, delay, now)
+}
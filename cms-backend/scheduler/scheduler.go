@@ -0,0 +1,58 @@
+// Package scheduler runs periodic maintenance tasks in-process.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Interval is how often scheduled tasks run
+const Interval = time.Minute
+
+// Task is a unit of periodic work; now is the time of the current tick
+type Task func(ctx context.Context, db *gorm.DB, now time.Time) error
+
+// Start runs every task once immediately and then on each tick until ctx is cancelled
+func Start(ctx context.Context, db *gorm.DB, tasks map[string]Task) {
+	go func() {
+		ticker := time.NewTicker(Interval)
+		defer ticker.Stop()
+
+		now := time.Now()
+		for {
+			for name, task := range tasks {
+				if err := task(ctx, db, now); err != nil {
+					log.Printf("Scheduled task %s failed: %v", name, err)
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case now = <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Default returns the tasks the server schedules at startup
+func Default() map[string]Task {
+	return map[string]Task{
+		"expire_content":       ExpireContent,
+		"flush_views":          FlushViews,
+		"deliver_webhooks":     DeliverWebhooks,
+		"deliver_integrations": DeliverIntegrations,
+		"announce_chat":        AnnounceChat,
+		"prune_changes":        PruneChanges,
+		"publish_embargoed":    PublishEmbargoed,
+		"notify_submissions":   NotifySubmissions,
+		"email_digests":        SendNotificationDigests,
+		"sync_subscribers":     SyncSubscribers,
+		"check_links":          CheckLinks,
+		"purge_trash":          PurgeTrash,
+		"create_backup":        CreateBackup,
+	}
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"bytes"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxNotifyAttempts is how often a review notification is tried before giving up
+const maxNotifyAttempts = 5
+
+// SubmissionNotification is posted to a submission's notify URL once it is reviewed
+type SubmissionNotification struct {
+	// Event is "submission.accepted" or "submission.rejected"
+	Event      string            `json:"event"`
+	Submission models.Submission `json:"submission"`
+}
+
+// NotifySubmissions tells submitters their submissions were accepted or
+// rejected, retrying failed notifications on later runs
+func NotifySubmissions(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+	var submissions []models.Submission
+	if err := db.Where("status <> ? AND notify_url <> '' AND notified_at IS NULL AND notify_attempts < ?",
+		models.SubmissionPending, maxNotifyAttempts).
+		Order("id").Find(&submissions).Error; err != nil {
+		return err
+	}
+
+	for _, submission := range submissions {
+		updates := map[string]interface{}{"notify_attempts": submission.NotifyAttempts + 1}
+		if err := notifySubmitter(ctx, submission); err != nil {
+			log.Printf("Notifying submitter of submission %d failed: %v", submission.ID, err)
+			updates["notify_last_error"] = err.Error()
+		} else {
+			updates["notified_at"] = now
+			updates["notify_last_error"] = ""
+		}
+		if err := db.Model(&submission).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifySubmitter posts the review outcome through the egress policy
+func notifySubmitter(ctx context.Context, submission models.Submission) error {
+	body, err := json.Marshal(SubmissionNotification{Event: "submission." + submission.Status, Submission: submission})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, submission.NotifyURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := egress.Default().Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return nil
+}
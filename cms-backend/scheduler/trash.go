@@ -0,0 +1,17 @@
+package scheduler
+
+import (
+	"cms-backend/trash"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PurgeTrash permanently deletes unused media past TRASH_RETENTION_DAYS
+func PurgeTrash(ctx context.Context, db *gorm.DB, now time.Time) error {
+	if trash.Default == nil {
+		return nil
+	}
+	return trash.Default.Purge(ctx, db, now)
+}
@@ -0,0 +1,14 @@
+package scheduler
+
+import (
+	"cms-backend/views"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// FlushViews writes buffered post view counts to the database
+func FlushViews(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return views.Default.Flush(ctx, db)
+}
@@ -0,0 +1,20 @@
+package scheduler
+
+import (
+	"cms-backend/integrations"
+	"cms-backend/webhooks"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DeliverWebhooks sends new change feed events to registered webhooks
+func DeliverWebhooks(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return webhooks.Dispatch(ctx, db)
+}
+
+// DeliverIntegrations sends new change feed events to outbound integrations
+func DeliverIntegrations(ctx context.Context, db *gorm.DB, now time.Time) error {
+	return integrations.Dispatch(ctx, db)
+}
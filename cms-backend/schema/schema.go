@@ -0,0 +1,198 @@
+// Package schema derives JSON Schemas from the structs the API binds request
+// bodies to, so admin frontends can build forms and validate input with the
+// same rules as the server. Rules come from the struct tags the server already
+// uses: json names, binding:"required" and max=, and gorm sizes and generated
+// columns.
+package schema
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Draft is the JSON Schema dialect produced
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema the generator emits
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        interface{}        `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	Items       *Schema            `json:"items,omitempty"`
+	Enum        []interface{}      `json:"enum,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	ReadOnly    bool               `json:"readOnly,omitempty"`
+}
+
+// Rules adds validation the struct tags cannot express, keyed by JSON property name
+type Rules struct {
+	Enums    map[string][]interface{}
+	Required []string
+	Minimum  map[string]float64
+	Maximum  map[string]float64
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate returns the schema of the struct value v. Nested structs are
+// described without required or read-only markers, since the server accepts
+// references (such as tags by ID or name) in their place.
+func Generate(title string, v interface{}, rules Rules) *Schema {
+	s := object(reflect.TypeOf(v), true)
+	s.Schema = Draft
+	s.Title = title
+
+	for name, values := range rules.Enums {
+		if prop, ok := s.Properties[name]; ok {
+			target := prop
+			if prop.Items != nil {
+				target = prop.Items
+			}
+			target.Enum = values
+		}
+	}
+	for name, min := range rules.Minimum {
+		if prop, ok := s.Properties[name]; ok {
+			value := min
+			prop.Minimum = &value
+		}
+	}
+	for name, max := range rules.Maximum {
+		if prop, ok := s.Properties[name]; ok {
+			value := max
+			prop.Maximum = &value
+		}
+	}
+	for _, name := range rules.Required {
+		if !contains(s.Required, name) {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// object describes a struct type; top is false for nested structs
+func object(t reflect.Type, top bool) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := property(field.Type, top)
+		if top {
+			gorm := field.Tag.Get("gorm")
+			if gorm == "-" || strings.Contains(gorm, "primaryKey") ||
+				strings.Contains(gorm, "autoCreateTime") || strings.Contains(gorm, "autoUpdateTime") {
+				prop.ReadOnly = true
+			}
+			if size := tagValue(gorm, "size"); size != "" && prop.Type == "string" {
+				if n, err := strconv.Atoi(size); err == nil {
+					prop.MaxLength = &n
+				}
+			}
+			binding := strings.Split(field.Tag.Get("binding"), ",")
+			if max, ok := bindingValue(binding, "max"); ok && prop.Type == "string" {
+				if n, err := strconv.Atoi(max); err == nil {
+					prop.MaxLength = &n
+				}
+			}
+			if contains(binding, "required") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		s.Properties[name] = prop
+	}
+	return s
+}
+
+// property describes a field type
+func property(t reflect.Type, top bool) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		nullable = true
+		t = t.Elem()
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		s = &Schema{Type: "integer"}
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		zero := 0.0
+		s = &Schema{Type: "integer", Minimum: &zero}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		// json.RawMessage and other raw JSON accept any value
+		s = &Schema{}
+	case t.Kind() == reflect.Slice:
+		s = &Schema{Type: "array", Items: property(t.Elem(), top)}
+	case t.Kind() == reflect.Struct && top:
+		s = object(t, false)
+	case t.Kind() == reflect.Struct:
+		// Deeper nesting is summarised to keep recursive models finite
+		s = &Schema{Type: "object"}
+	default:
+		s = &Schema{}
+	}
+	if nullable && s.Type != nil {
+		s.Type = []interface{}{s.Type, "null"}
+	}
+	return s
+}
+
+// bindingValue returns the parameter of a validator in a binding tag split on
+// commas, such as 255 for max=255
+func bindingValue(validators []string, key string) (string, bool) {
+	for _, validator := range validators {
+		if value, ok := strings.CutPrefix(validator, key+"="); ok {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// tagValue returns the value of key in a gorm tag such as "size:255;not null"
+func tagValue(tag, key string) string {
+	for _, part := range strings.Split(tag, ";") {
+		if value, ok := strings.CutPrefix(part, key+":"); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Package search matches posts and pages with Postgres full-text search in the
+// language of their content. Each row is stemmed with the configuration of its
+// locale, so "running" finds "runs" in English content and "Häuser" finds
+// "Haus" in German content; a search can override the configuration, e.g. to
+// look for German words in content with no locale.
+package search
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Text search configurations
+const (
+	ConfigEnglish = "english"
+	ConfigGerman  = "german"
+	ConfigSimple  = "simple"
+)
+
+// Configs lists the configurations a search can select. ConfigSimple only
+// lowercases words and is used for locales without a stemmer.
+var Configs = []string{ConfigEnglish, ConfigGerman, ConfigSimple}
+
+// languages maps a locale's primary language to its configuration. The
+// content_search_config SQL function holds the same mapping for indexing, so
+// the two must change together.
+var languages = map[string]string{
+	"en": ConfigEnglish,
+	"de": ConfigGerman,
+}
+
+// ConfigFor returns the configuration for a locale such as "de" or "en-GB"
+func ConfigFor(locale string) string {
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if config, ok := languages[strings.ToLower(language)]; ok {
+		return config
+	}
+	return ConfigSimple
+}
+
+// ValidConfig reports whether name is one of Configs
+func ValidConfig(name string) bool {
+	for _, config := range Configs {
+		if config == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns a scope keeping the rows of table, posts or pages, whose title
+// or content matches terms in web search syntax: words, "quoted phrases", OR
+// and -excluded words. config selects the configuration for every row; empty
+// uses each row's locale, which is what the search indexes cover.
+func Match(table, terms, config string) func(*gorm.DB) *gorm.DB {
+	document := fmt.Sprintf("%[1]s.title || ' ' || %[1]s.content", table)
+	return func(query *gorm.DB) *gorm.DB {
+		if config == "" {
+			rowConfig := fmt.Sprintf("content_search_config(%s.locale)", table)
+			return query.Where(fmt.Sprintf("to_tsvector(%[1]s, %[2]s) @@ websearch_to_tsquery(%[1]s, ?)", rowConfig, document), terms)
+		}
+		return query.Where(fmt.Sprintf("to_tsvector(?::regconfig, %s) @@ websearch_to_tsquery(?::regconfig, ?)", document), config, config, terms)
+	}
+}
@@ -0,0 +1,97 @@
+// Package secrets loads secret settings such as DB_PASSWORD from files or
+// from Vault at startup, as an alternative to plain environment variables.
+// Loaded values are set in the environment, the way .env files are, so the
+// rest of the code reads every setting the same way.
+//
+// For each name in Names a variable set directly wins. Otherwise NAME_FILE
+// names a file holding the value, as Docker and Kubernetes secrets are
+// mounted, and with SECRETS_PROVIDER=vault the remaining names are read from
+// the KV secret at VAULT_SECRET_PATH.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Names are the settings that may be loaded as secrets
+var Names = []string{
+	"DB_PASSWORD",
+	"ADMIN_TOKEN",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"SMTP_PASSWORD",
+	"FORM_CAPTCHA_SECRET",
+	"CLOUDINARY_API_SECRET",
+	"DAM_WEBHOOK_SECRET",
+	"NEWSLETTER_SECRET",
+	"MAILCHIMP_API_KEY",
+	"SPAM_AKISMET_KEY",
+}
+
+// Provider looks up secrets by setting name
+type Provider interface {
+	// Lookup returns the secret called name, and false when the provider has none
+	Lookup(ctx context.Context, name string) (string, bool, error)
+}
+
+// Files reads each secret from the file named by its _FILE variable, e.g.
+// DB_PASSWORD_FILE=/run/secrets/db_password
+type Files struct{}
+
+// Lookup reads the file named by name's _FILE variable, without its trailing newline
+func (Files) Lookup(ctx context.Context, name string) (string, bool, error) {
+	path := os.Getenv(name + "_FILE")
+	if path == "" {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("reading %s_FILE: %w", name, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// ProvidersFromEnv returns the providers secrets are loaded from: files, then
+// Vault when SECRETS_PROVIDER=vault
+func ProvidersFromEnv() ([]Provider, error) {
+	providers := []Provider{Files{}}
+	switch kind := os.Getenv("SECRETS_PROVIDER"); kind {
+	case "", "env":
+	case "vault":
+		vault, err := VaultFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, vault)
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_PROVIDER %q (expected vault or env)", kind)
+	}
+	return providers, nil
+}
+
+// Load sets each secret in Names that the environment does not set from the
+// first provider that has it
+func Load(ctx context.Context, providers []Provider) error {
+	for _, name := range Names {
+		if os.Getenv(name) != "" {
+			continue
+		}
+		for _, provider := range providers {
+			value, ok, err := provider.Lookup(ctx, name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				if err := os.Setenv(name, value); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+	return nil
+}
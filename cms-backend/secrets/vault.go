@@ -0,0 +1,107 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from one key/value secret in HashiCorp Vault whose keys
+// are setting names. The secret is fetched once, on the first lookup.
+type Vault struct {
+	// Addr is the server, e.g. https://vault.internal:8200
+	Addr string
+	// Path is the secret's API path under /v1/, e.g. secret/data/cms for the
+	// cms secret of a version 2 engine mounted at secret
+	Path      string
+	Token     string
+	Namespace string
+	Client    *http.Client
+
+	data map[string]string
+}
+
+// VaultFromEnv configures Vault from VAULT_ADDR, VAULT_SECRET_PATH,
+// VAULT_TOKEN (or VAULT_TOKEN_FILE) and VAULT_NAMESPACE. Vault usually runs
+// on a private network, so the egress policy for user-supplied URLs does not apply.
+func VaultFromEnv() (*Vault, error) {
+	token, _, err := Files{}.Lookup(context.Background(), "VAULT_TOKEN")
+	if err != nil {
+		return nil, err
+	}
+	if value := os.Getenv("VAULT_TOKEN"); value != "" {
+		token = value
+	}
+	vault := &Vault{
+		Addr:      os.Getenv("VAULT_ADDR"),
+		Path:      strings.Trim(os.Getenv("VAULT_SECRET_PATH"), "/"),
+		Token:     token,
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+	if vault.Addr == "" || vault.Path == "" || vault.Token == "" {
+		return nil, fmt.Errorf("VAULT_ADDR, VAULT_SECRET_PATH and VAULT_TOKEN are required for SECRETS_PROVIDER=vault")
+	}
+	return vault, nil
+}
+
+// Lookup returns the secret's value for name
+func (v *Vault) Lookup(ctx context.Context, name string) (string, bool, error) {
+	if v.data == nil {
+		data, err := v.fetch(ctx)
+		if err != nil {
+			return "", false, err
+		}
+		v.data = data
+	}
+	value, ok := v.data[name]
+	return value, ok, nil
+}
+
+// fetch reads the secret. Version 2 engines nest the values under data.data,
+// version 1 engines put them directly under data.
+func (v *Vault) fetch(ctx context.Context) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(v.Addr, "/")+"/v1/"+v.Path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.Token)
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	resp, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("reading Vault secret %s: %w", v.Path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reading Vault secret %s: Vault answered %s", v.Path, resp.Status)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decoding Vault secret %s: %w", v.Path, err)
+	}
+	values := body.Data
+	if nested, ok := body.Data["data"]; ok {
+		if err := json.Unmarshal(nested, &values); err != nil {
+			return nil, fmt.Errorf("decoding Vault secret %s: %w", v.Path, err)
+		}
+	}
+
+	data := make(map[string]string, len(values))
+	for key, raw := range values {
+		var value string
+		if err := json.Unmarshal(raw, &value); err == nil {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
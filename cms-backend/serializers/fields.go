@@ -0,0 +1,84 @@
+package serializers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ParseFields splits a ?fields= value such as "id,title,slug" into field names.
+// An empty value selects every field and returns nil.
+func ParseFields(raw string) []string {
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// SelectFields returns value, a struct or slice of structs, reduced to the named
+// JSON fields. Names that are not JSON fields of the struct are an error. With no
+// fields, value is returned unchanged.
+func SelectFields(value interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return value, nil
+	}
+
+	t := reflect.TypeOf(value)
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	known := jsonFields(t)
+	for _, field := range fields {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown field %q", field)
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.TypeOf(value).Kind() == reflect.Slice {
+		var items []map[string]json.RawMessage
+		if err := json.Unmarshal(encoded, &items); err != nil {
+			return nil, err
+		}
+		selected := make([]map[string]json.RawMessage, len(items))
+		for i, item := range items {
+			selected[i] = pick(item, fields)
+		}
+		return selected, nil
+	}
+	var item map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &item); err != nil {
+		return nil, err
+	}
+	return pick(item, fields), nil
+}
+
+// pick keeps the named keys; omitted empty fields stay omitted
+func pick(item map[string]json.RawMessage, fields []string) map[string]json.RawMessage {
+	selected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := item[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected
+}
+
+// jsonFields returns the names a struct type serializes its fields as
+func jsonFields(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
@@ -0,0 +1,187 @@
+package serializers
+
+import (
+	"cms-backend/models"
+	"encoding/json"
+	"time"
+)
+
+// PostResponse is the JSON form of a post. Fields are copied from the model
+// explicitly, so columns can be added or renamed without changing the API.
+type PostResponse struct {
+	ID                uint           `json:"id"`
+	UUID              string         `json:"uuid,omitempty"`
+	Title             string         `json:"title"`
+	Content           string         `json:"content"`
+	ContentHTML       string         `json:"content_html,omitempty"`
+	ContentText       string         `json:"content_text,omitempty"`
+	ContentAMP        string         `json:"content_amp,omitempty"`
+	ReadingTime       int            `json:"reading_time"`
+	Author            string         `json:"author"`
+	AuthorEmail       string         `json:"author_email,omitempty"`
+	Slug              string         `json:"slug"`
+	Locale            string         `json:"locale"`
+	CanonicalURL      string         `json:"canonical_url"`
+	SyndicationSource string         `json:"syndication_source"`
+	Status            string         `json:"status"`
+	ExpiresAt         *time.Time     `json:"expires_at"`
+	Visibility        string         `json:"visibility"`
+	EmbargoedUntil    *time.Time     `json:"embargoed_until"`
+	Notes             string         `json:"notes,omitempty"`
+	Featured          bool           `json:"featured"`
+	Position          int            `json:"position"`
+	OwnerGroupID      *uint          `json:"owner_group_id"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	Media             []models.Media `json:"media"`
+	Tags              []models.Tag   `json:"tags"`
+}
+
+// PageResponse is the JSON form of a page
+type PageResponse struct {
+	ID             uint       `json:"id"`
+	UUID           string     `json:"uuid,omitempty"`
+	Title          string     `json:"title"`
+	Content        string     `json:"content"`
+	Slug           string     `json:"slug"`
+	Locale         string     `json:"locale"`
+	ParentID       *uint      `json:"parent_id"`
+	Status         string     `json:"status"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	Visibility     string     `json:"visibility"`
+	EmbargoedUntil *time.Time `json:"embargoed_until"`
+	Notes          string     `json:"notes,omitempty"`
+	OwnerGroupID   *uint      `json:"owner_group_id"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MediaResponse is the JSON form of a media item
+type MediaResponse struct {
+	ID            uint         `json:"id"`
+	UUID          string       `json:"uuid,omitempty"`
+	URL           string       `json:"url"`
+	Type          string       `json:"type"`
+	Size          int64        `json:"size"`
+	Width         int          `json:"width,omitempty"`
+	Height        int          `json:"height,omitempty"`
+	AltText       string       `json:"alt_text"`
+	FocalX        *float64     `json:"focal_x"`
+	FocalY        *float64     `json:"focal_y"`
+	Crops         models.Crops `json:"crops"`
+	ScanStatus    string       `json:"scan_status"`
+	ScanSignature string       `json:"scan_signature,omitempty"`
+	ScannedAt     *time.Time   `json:"scanned_at"`
+	UploadedByID  *uint        `json:"uploaded_by_id,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+func newPostResponse(post models.Post) PostResponse {
+	return PostResponse{
+		ID:                post.ID,
+		UUID:              post.UUID,
+		Title:             post.Title,
+		Content:           post.Content,
+		ContentHTML:       post.ContentHTML,
+		ContentText:       post.ContentText,
+		ContentAMP:        post.ContentAMP,
+		ReadingTime:       post.ReadingTime,
+		Author:            post.Author,
+		AuthorEmail:       post.AuthorEmail,
+		Slug:              post.Slug,
+		Locale:            post.Locale,
+		CanonicalURL:      post.CanonicalURL,
+		SyndicationSource: post.SyndicationSource,
+		Status:            post.Status,
+		ExpiresAt:         post.ExpiresAt,
+		Visibility:        post.Visibility,
+		EmbargoedUntil:    post.EmbargoedUntil,
+		Notes:             post.Notes,
+		Featured:          post.Featured,
+		Position:          post.Position,
+		OwnerGroupID:      post.OwnerGroupID,
+		CreatedAt:         post.CreatedAt,
+		UpdatedAt:         post.UpdatedAt,
+		Media:             post.Media,
+		Tags:              post.Tags,
+	}
+}
+
+func newPageResponse(page models.Page) PageResponse {
+	return PageResponse{
+		ID:             page.ID,
+		UUID:           page.UUID,
+		Title:          page.Title,
+		Content:        page.Content,
+		Slug:           page.Slug,
+		Locale:         page.Locale,
+		ParentID:       page.ParentID,
+		Status:         page.Status,
+		ExpiresAt:      page.ExpiresAt,
+		Visibility:     page.Visibility,
+		EmbargoedUntil: page.EmbargoedUntil,
+		Notes:          page.Notes,
+		OwnerGroupID:   page.OwnerGroupID,
+		CreatedAt:      page.CreatedAt,
+		UpdatedAt:      page.UpdatedAt,
+	}
+}
+
+func newMediaResponse(media models.Media) MediaResponse {
+	return MediaResponse{
+		ID:            media.ID,
+		UUID:          media.UUID,
+		URL:           media.URL,
+		Type:          media.Type,
+		Size:          media.Size,
+		Width:         media.Width,
+		Height:        media.Height,
+		AltText:       media.AltText,
+		FocalX:        media.FocalX,
+		FocalY:        media.FocalY,
+		Crops:         media.Crops,
+		ScanStatus:    media.ScanStatus,
+		ScanSignature: media.ScanSignature,
+		ScannedAt:     media.ScannedAt,
+		UploadedByID:  media.UploadedByID,
+		CreatedAt:     media.CreatedAt,
+		UpdatedAt:     media.UpdatedAt,
+	}
+}
+
+// MarshalJSON writes the UUID as the post's id with ID_FORMAT=uuid
+func (r PostResponse) MarshalJSON() ([]byte, error) {
+	type response PostResponse
+	if !models.UUIDIDs() {
+		return json.Marshal(response(r))
+	}
+	return json.Marshal(struct {
+		response
+		ID string `json:"id"`
+	}{response(r), r.UUID})
+}
+
+// MarshalJSON writes the UUID as the page's id with ID_FORMAT=uuid
+func (r PageResponse) MarshalJSON() ([]byte, error) {
+	type response PageResponse
+	if !models.UUIDIDs() {
+		return json.Marshal(response(r))
+	}
+	return json.Marshal(struct {
+		response
+		ID string `json:"id"`
+	}{response(r), r.UUID})
+}
+
+// MarshalJSON writes the UUID as the media item's id with ID_FORMAT=uuid
+func (r MediaResponse) MarshalJSON() ([]byte, error) {
+	type response MediaResponse
+	if !models.UUIDIDs() {
+		return json.Marshal(response(r))
+	}
+	return json.Marshal(struct {
+		response
+		ID string `json:"id"`
+	}{response(r), r.UUID})
+}
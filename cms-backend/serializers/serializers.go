@@ -0,0 +1,113 @@
+// Package serializers turns models into API responses, clearing the fields the
+// caller's role may not see. Every handler returning posts, pages or media goes
+// through it so redaction rules live in one place, and so the JSON contract
+// does not follow the database schema.
+package serializers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+)
+
+// Post returns the response for post, redacted for the caller:
+//   - notes (editorial workflow notes) are hidden from delivery keys and anonymous callers
+//   - author_email is shown to admins and editors, and to contributors on their own posts
+func Post(caller auth.Caller, post models.Post) PostResponse {
+	return newPostResponse(RedactPost(caller, post))
+}
+
+// Posts returns the responses for a list of posts
+func Posts(caller auth.Caller, posts []models.Post) []PostResponse {
+	responses := make([]PostResponse, len(posts))
+	for i, post := range posts {
+		responses[i] = Post(caller, post)
+	}
+	return responses
+}
+
+// Page returns the response for page with notes hidden from delivery keys and
+// anonymous callers
+func Page(caller auth.Caller, page models.Page) PageResponse {
+	return newPageResponse(RedactPage(caller, page))
+}
+
+// Pages returns the responses for a list of pages
+func Pages(caller auth.Caller, pages []models.Page) []PageResponse {
+	responses := make([]PageResponse, len(pages))
+	for i, page := range pages {
+		responses[i] = Page(caller, page)
+	}
+	return responses
+}
+
+// Media returns the response for a media item
+func Media(media models.Media) MediaResponse {
+	return newMediaResponse(media)
+}
+
+// MediaList returns the responses for a list of media
+func MediaList(media []models.Media) []MediaResponse {
+	responses := make([]MediaResponse, len(media))
+	for i, item := range media {
+		responses[i] = Media(item)
+	}
+	return responses
+}
+
+// RedactPost returns a copy of post with the fields Post hides cleared, for
+// responses that still embed the model, such as series and offline bundles
+func RedactPost(caller auth.Caller, post models.Post) models.Post {
+	if !canSeeNotes(caller) {
+		post.Notes = ""
+	}
+	if !canSeeAuthorEmail(caller, post.Author) {
+		post.AuthorEmail = ""
+	}
+	return post
+}
+
+// RedactPosts redacts each post in a list
+func RedactPosts(caller auth.Caller, posts []models.Post) []models.Post {
+	redacted := make([]models.Post, len(posts))
+	for i, post := range posts {
+		redacted[i] = RedactPost(caller, post)
+	}
+	return redacted
+}
+
+// RedactPage returns a copy of page with the fields Page hides cleared
+func RedactPage(caller auth.Caller, page models.Page) models.Page {
+	if !canSeeNotes(caller) {
+		page.Notes = ""
+	}
+	return page
+}
+
+// RedactPages redacts each page in a list
+func RedactPages(caller auth.Caller, pages []models.Page) []models.Page {
+	redacted := make([]models.Page, len(pages))
+	for i, page := range pages {
+		redacted[i] = RedactPage(caller, page)
+	}
+	return redacted
+}
+
+// canSeeNotes reports whether the caller takes part in the editorial workflow
+func canSeeNotes(caller auth.Caller) bool {
+	switch caller.Role {
+	case auth.RoleAdmin, auth.RoleEditor, auth.RoleContributor:
+		return true
+	}
+	return false
+}
+
+// canSeeAuthorEmail reports whether the caller may see the email of a post's author
+func canSeeAuthorEmail(caller auth.Caller, author string) bool {
+	switch caller.Role {
+	case auth.RoleAdmin, auth.RoleEditor:
+		return true
+	case auth.RoleContributor:
+		return author != "" && author == caller.Name
+	}
+	return false
+}
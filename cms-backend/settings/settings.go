@@ -0,0 +1,185 @@
+// Package settings keeps site-level configuration stored in the settings table
+// in memory. Each process reloads the table when its copy is older than the
+// store's TTL and drops it when it writes a setting, so a change is seen at
+// once by the process that made it and within the TTL by the others.
+package settings
+
+import (
+	"bytes"
+	"cms-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Value types a setting can hold
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+
+	// TypeJSON holds any JSON object or array
+	TypeJSON = "json"
+)
+
+// DefaultTTL is how long a loaded copy of the settings is used
+const DefaultTTL = 30 * time.Second
+
+// ErrInvalidValue is returned when a value does not match its setting's type
+var ErrInvalidValue = errors.New("invalid setting value")
+
+// Store caches every setting in memory
+type Store struct {
+	// Now is the clock used to age the cache; tests replace it
+	Now func() time.Time
+
+	ttl      time.Duration
+	mu       sync.Mutex
+	settings map[string]models.Setting
+	loadedAt time.Time
+}
+
+// Default is the process-wide store used by the API
+var Default = NewStore(DefaultTTL)
+
+// NewStore creates an empty store that reloads after ttl
+func NewStore(ttl time.Duration) *Store {
+	return &Store{Now: time.Now, ttl: ttl}
+}
+
+// ValidType reports whether t is a known value type
+func ValidType(t string) bool {
+	switch t {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeJSON:
+		return true
+	}
+	return false
+}
+
+// Validate checks that value is the JSON encoding of a value of type t
+func Validate(t string, value json.RawMessage) error {
+	decoder := json.NewDecoder(bytes.NewReader(value))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+
+	ok := false
+	switch t {
+	case TypeString:
+		_, ok = decoded.(string)
+	case TypeBool:
+		_, ok = decoded.(bool)
+	case TypeInt:
+		if number, isNumber := decoded.(json.Number); isNumber {
+			_, err := number.Int64()
+			ok = err == nil
+		}
+	case TypeFloat:
+		_, ok = decoded.(json.Number)
+	case TypeJSON:
+		switch decoded.(type) {
+		case map[string]interface{}, []interface{}:
+			ok = true
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%w: value must be a %s", ErrInvalidValue, t)
+	}
+	return nil
+}
+
+// All returns every setting, optionally limited to a namespace, ordered by namespace and key
+func (s *Store) All(db *gorm.DB, namespace string) ([]models.Setting, error) {
+	settings, err := s.load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	list := []models.Setting{}
+	for _, setting := range settings {
+		if namespace == "" || setting.Namespace == namespace {
+			list = append(list, setting)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Namespace != list[j].Namespace {
+			return list[i].Namespace < list[j].Namespace
+		}
+		return list[i].Key < list[j].Key
+	})
+	return list, nil
+}
+
+// Get returns one setting and whether it exists
+func (s *Store) Get(db *gorm.DB, namespace, key string) (models.Setting, bool, error) {
+	settings, err := s.load(db)
+	if err != nil {
+		return models.Setting{}, false, err
+	}
+	setting, ok := settings[namespace+"/"+key]
+	return setting, ok, nil
+}
+
+// Bool returns a bool setting, or fallback when it is missing, of another type or unreadable
+func (s *Store) Bool(db *gorm.DB, namespace, key string, fallback bool) bool {
+	setting, ok, err := s.Get(db, namespace, key)
+	if err != nil || !ok || setting.Type != TypeBool {
+		return fallback
+	}
+	var value bool
+	if json.Unmarshal([]byte(setting.Value), &value) != nil {
+		return fallback
+	}
+	return value
+}
+
+// String returns a string setting, or fallback when it is missing, of another type or unreadable
+func (s *Store) String(db *gorm.DB, namespace, key, fallback string) string {
+	setting, ok, err := s.Get(db, namespace, key)
+	if err != nil || !ok || setting.Type != TypeString {
+		return fallback
+	}
+	var value string
+	if json.Unmarshal([]byte(setting.Value), &value) != nil {
+		return fallback
+	}
+	return value
+}
+
+// Invalidate drops the cached settings so the next read reloads them
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settings = nil
+}
+
+// load returns the cached settings keyed by "namespace/key", reloading them when stale
+func (s *Store) load(db *gorm.DB) (map[string]models.Setting, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.Now()
+	if s.settings != nil && now.Sub(s.loadedAt) < s.ttl {
+		return s.settings, nil
+	}
+
+	var rows []models.Setting
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	settings := make(map[string]models.Setting, len(rows))
+	for _, row := range rows {
+		settings[row.Namespace+"/"+row.Key] = row
+	}
+	s.settings = settings
+	s.loadedAt = now
+	return settings, nil
+}
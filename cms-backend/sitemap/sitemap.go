@@ -0,0 +1,74 @@
+// Package sitemap encodes sitemaps and sitemap indexes in the sitemaps.org
+// XML format.
+package sitemap
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// MaxURLs is the most URLs the protocol allows in one sitemap; larger sites
+// split their URLs across several sitemaps listed in an index
+const MaxURLs = 50000
+
+// namespace is the XML namespace of both sitemaps and sitemap indexes
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// URL is one location in a sitemap, or one sitemap in an index
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// entry is how a URL is encoded; lastmod is omitted when unknown
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	URLs    []entry  `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name `xml:"sitemapindex"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Sitemaps []entry  `xml:"sitemap"`
+}
+
+// WriteURLSet encodes urls as a sitemap
+func WriteURLSet(w io.Writer, urls []URL) error {
+	return write(w, urlSet{XMLNS: namespace, URLs: entries(urls)})
+}
+
+// WriteIndex encodes a sitemap index listing the sitemaps at urls
+func WriteIndex(w io.Writer, sitemaps []URL) error {
+	return write(w, sitemapIndex{XMLNS: namespace, Sitemaps: entries(sitemaps)})
+}
+
+func entries(urls []URL) []entry {
+	list := make([]entry, len(urls))
+	for i, url := range urls {
+		list[i] = entry{Loc: url.Loc}
+		if !url.LastMod.IsZero() {
+			list[i].LastMod = url.LastMod.UTC().Format(time.RFC3339)
+		}
+	}
+	return list
+}
+
+func write(w io.Writer, document interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
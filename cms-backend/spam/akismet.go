@@ -0,0 +1,83 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AkismetURL is the Akismet API used unless SPAM_AKISMET_URL names a compatible one
+const AkismetURL = "https://rest.akismet.com/1.1"
+
+// Akismet checks content with the Akismet API, which answers spam or not;
+// spam scores 1 and anything else 0. Verdicts are reported back so the
+// service learns from them.
+type Akismet struct {
+	URL    string
+	Key    string
+	Site   string
+	Client *http.Client
+}
+
+// Score asks the service whether content is spam
+func (a *Akismet) Score(ctx context.Context, db *gorm.DB, content Content) (float64, error) {
+	answer, err := a.post(ctx, "comment-check", content)
+	if err != nil {
+		return 0, err
+	}
+	switch answer {
+	case "true":
+		return 1, nil
+	case "false":
+		return 0, nil
+	}
+	return 0, fmt.Errorf("akismet answered %q", answer)
+}
+
+// Learn reports content as missed spam or as a false positive
+func (a *Akismet) Learn(ctx context.Context, db *gorm.DB, content Content, spam bool) error {
+	method := "submit-ham"
+	if spam {
+		method = "submit-spam"
+	}
+	_, err := a.post(ctx, method, content)
+	return err
+}
+
+// post calls an API method with content and returns the answer
+func (a *Akismet) post(ctx context.Context, method string, content Content) (string, error) {
+	form := url.Values{
+		"api_key":              {a.Key},
+		"blog":                 {a.Site},
+		"user_ip":              {content.IP},
+		"user_agent":           {content.UserAgent},
+		"comment_type":         {content.Type},
+		"comment_author":       {content.Author},
+		"comment_author_email": {content.Email},
+		"comment_content":      {content.Body},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(a.URL, "/")+"/"+method, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("akismet answered %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
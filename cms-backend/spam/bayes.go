@@ -0,0 +1,126 @@
+package spam
+
+import (
+	"cms-backend/models"
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MinTraining is how many spam and how many legitimate entries the Bayesian
+// filter must have learned before it scores anything above 0
+const MinTraining = 10
+
+// maxTokens caps the words of one entry the filter reads
+const maxTokens = 500
+
+// interesting is how many of an entry's most telling words decide its score
+const interesting = 15
+
+// Bayes is a naive Bayesian filter over the words of content, trained on
+// moderators' verdicts and stored in spam_tokens
+type Bayes struct{}
+
+// Score combines the spam probabilities of the entry's most telling words
+func (Bayes) Score(ctx context.Context, db *gorm.DB, content Content) (float64, error) {
+	tokens := Tokenize(content)
+	var rows []models.SpamToken
+	if err := db.WithContext(ctx).Where("token IN ?", append(tokens, "")).Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	var totals models.SpamToken
+	counts := make(map[string]models.SpamToken, len(rows))
+	for _, row := range rows {
+		if row.Token == "" {
+			totals = row
+		} else {
+			counts[row.Token] = row
+		}
+	}
+	if totals.SpamCount < MinTraining || totals.HamCount < MinTraining {
+		return 0, nil
+	}
+
+	var probabilities []float64
+	for _, token := range tokens {
+		row, ok := counts[token]
+		if !ok {
+			continue
+		}
+		spam := float64(row.SpamCount) / float64(totals.SpamCount)
+		ham := float64(row.HamCount) / float64(totals.HamCount)
+		// Words seen rarely lean towards neutral
+		seen := float64(row.SpamCount + row.HamCount)
+		p := (0.5 + seen*spam/(spam+ham)) / (1 + seen)
+		probabilities = append(probabilities, math.Min(math.Max(p, 0.01), 0.99))
+	}
+	if len(probabilities) == 0 {
+		return 0, nil
+	}
+	sort.Slice(probabilities, func(i, j int) bool {
+		return math.Abs(probabilities[i]-0.5) > math.Abs(probabilities[j]-0.5)
+	})
+	if len(probabilities) > interesting {
+		probabilities = probabilities[:interesting]
+	}
+
+	var eta float64
+	for _, p := range probabilities {
+		eta += math.Log(1-p) - math.Log(p)
+	}
+	return 1 / (1 + math.Exp(eta)), nil
+}
+
+// Learn counts the entry and its words as spam or legitimate
+func (Bayes) Learn(ctx context.Context, db *gorm.DB, content Content, spam bool) error {
+	tokens := append(Tokenize(content), "")
+	rows := make([]models.SpamToken, len(tokens))
+	for i, token := range tokens {
+		rows[i] = models.SpamToken{Token: token}
+		if spam {
+			rows[i].SpamCount = 1
+		} else {
+			rows[i].HamCount = 1
+		}
+	}
+	return db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"spam_count": gorm.Expr("spam_tokens.spam_count + excluded.spam_count"),
+			"ham_count":  gorm.Expr("spam_tokens.ham_count + excluded.ham_count"),
+		}),
+	}).Create(&rows).Error
+}
+
+// Tokenize returns the distinct lowercase words of an entry's author, email
+// domain and body, in order of first appearance
+func Tokenize(content Content) []string {
+	text := content.Author + " " + content.Body
+	if at := strings.LastIndexByte(content.Email, '@'); at >= 0 {
+		text += " " + content.Email[at+1:]
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	tokens := []string{}
+	for _, word := range words {
+		if length := utf8.RuneCountInString(word); length < 2 || len(word) > 64 || seen[word] {
+			continue
+		}
+		seen[word] = true
+		tokens = append(tokens, word)
+		if len(tokens) == maxTokens {
+			break
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,88 @@
+// Package spam scores content sent in by visitors so suspicious entries can
+// be held for moderation. SPAM_FILTER picks the checker: "akismet" asks an
+// Akismet-compatible service, "bayes" runs a naive Bayesian filter trained on
+// moderators' verdicts, and unset or "none" checks nothing. Entries scoring
+// SPAM_HOLD_SCORE or more are held.
+package spam
+
+import (
+	"cms-backend/egress"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// DefaultHoldScore is the score entries are held at when SPAM_HOLD_SCORE is not set
+const DefaultHoldScore = 0.5
+
+// Content is an entry to check: who sent it, from where, and what it says
+type Content struct {
+	// Type names the kind of entry, e.g. "contact-form"
+	Type      string
+	Author    string
+	Email     string
+	Body      string
+	IP        string
+	UserAgent string
+}
+
+// Checker scores content for spam and learns from moderators' verdicts
+type Checker interface {
+	// Score returns how likely content is spam, from 0 to 1. An error means
+	// the content could not be checked, not that it is spam.
+	Score(ctx context.Context, db *gorm.DB, content Content) (float64, error)
+
+	// Learn records a moderator's verdict on content
+	Learn(ctx context.Context, db *gorm.DB, content Content, spam bool) error
+}
+
+// Filter holds entries its Checker scores at HoldScore or more
+type Filter struct {
+	Checker   Checker
+	HoldScore float64
+}
+
+// Hold reports whether an entry with score is held for moderation
+func (f *Filter) Hold(score float64) bool {
+	return score >= f.HoldScore
+}
+
+// Default is the filter form submissions go through, nil when none is
+// configured. It is set once at startup.
+var Default *Filter
+
+// FromEnv returns the filter selected by SPAM_FILTER, or nil for none
+func FromEnv() (*Filter, error) {
+	var checker Checker
+	switch kind := os.Getenv("SPAM_FILTER"); kind {
+	case "", "none":
+		return nil, nil
+	case "akismet":
+		key, site := os.Getenv("SPAM_AKISMET_KEY"), os.Getenv("SPAM_AKISMET_SITE")
+		if key == "" || site == "" {
+			return nil, fmt.Errorf("SPAM_AKISMET_KEY and SPAM_AKISMET_SITE are required for SPAM_FILTER=akismet")
+		}
+		url := os.Getenv("SPAM_AKISMET_URL")
+		if url == "" {
+			url = AkismetURL
+		}
+		checker = &Akismet{URL: url, Key: key, Site: site, Client: egress.Default()}
+	case "bayes":
+		checker = Bayes{}
+	default:
+		return nil, fmt.Errorf("unknown SPAM_FILTER %q (expected akismet, bayes or none)", kind)
+	}
+
+	hold := DefaultHoldScore
+	if value := os.Getenv("SPAM_HOLD_SCORE"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return nil, fmt.Errorf("SPAM_HOLD_SCORE must be a number from 0 to 1, not %q", value)
+		}
+		hold = parsed
+	}
+	return &Filter{Checker: checker, HoldScore: hold}, nil
+}
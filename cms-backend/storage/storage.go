@@ -0,0 +1,98 @@
+// Package storage keeps the media files the server holds itself, such as
+// files imported from remote URLs. MEDIA_STORAGE picks the backend: unset or
+// "local" writes them to a directory the server serves them from.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Store saves files and reports where they are served from
+type Store interface {
+	// Put stores body under key and returns the URL the file is served at
+	Put(ctx context.Context, key, contentType string, body io.Reader) (string, error)
+}
+
+// Opener is a store that can read back the files it holds
+type Opener interface {
+	// Open returns the file served at url, or ErrNotHeld when the store does
+	// not hold it
+	Open(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// ErrNotHeld is returned by Open for URLs a store does not serve
+var ErrNotHeld = errors.New("file is not held by this store")
+
+// Local stores files in Dir. Files are served at BaseURL followed by their
+// key; when ServePath is set the server serves Dir there itself.
+type Local struct {
+	Dir       string
+	BaseURL   string
+	ServePath string
+}
+
+// Default is the store imported media is saved to. It is set once at startup.
+var Default Store
+
+// FromEnv returns the store selected by MEDIA_STORAGE. Local files go to
+// MEDIA_STORAGE_DIR (default "uploads") and are linked at MEDIA_BASE_URL; without
+// it the server serves them under /uploads on SITE_URL.
+func FromEnv() (Store, error) {
+	switch kind := os.Getenv("MEDIA_STORAGE"); kind {
+	case "", "local":
+		local := &Local{Dir: os.Getenv("MEDIA_STORAGE_DIR"), BaseURL: os.Getenv("MEDIA_BASE_URL")}
+		if local.Dir == "" {
+			local.Dir = "uploads"
+		}
+		if local.BaseURL == "" {
+			local.ServePath = "/uploads"
+			local.BaseURL = strings.TrimSuffix(os.Getenv("SITE_URL"), "/") + local.ServePath
+		}
+		return local, nil
+	default:
+		return nil, fmt.Errorf("unknown MEDIA_STORAGE %q (expected local)", kind)
+	}
+}
+
+// Put writes body to a temporary file and moves it into place once complete,
+// so a failed copy never leaves a partial file under key
+func (l *Local) Put(ctx context.Context, key, contentType string, body io.Reader) (string, error) {
+	if key == "" || filepath.Base(key) != key || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("invalid storage key %q", key)
+	}
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(l.Dir, ".upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(l.Dir, key)); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(l.BaseURL, "/") + "/" + key, nil
+}
+
+// Open reads the file served at url from Dir
+func (l *Local) Open(ctx context.Context, url string) (io.ReadCloser, error) {
+	key, ok := strings.CutPrefix(url, strings.TrimSuffix(l.BaseURL, "/")+"/")
+	if !ok || key == "" || filepath.Base(key) != key || strings.HasPrefix(key, ".") {
+		return nil, ErrNotHeld
+	}
+	return os.Open(filepath.Join(l.Dir, key))
+}
@@ -1,10 +1,14 @@
 package integration
 
 import (
+	"cms-backend/auth"
+	"cms-backend/egress"
 	"cms-backend/models"
 	"cms-backend/routes"
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"testing"
 
@@ -18,6 +22,9 @@ import (
 var (
 	testDB *gorm.DB
 	router *gin.Engine
+
+	// testKey is the editor API key requests send by default
+	testKey string
 )
 
 /*
@@ -51,6 +58,12 @@ func setup() {
 	// STEP 1: Configure Gin
 	gin.SetMode(gin.TestMode)
 
+	// Resolve every hostname to a public address so media URL validation
+	// does not depend on DNS being available
+	egress.LookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+
 	// STEP 2: Database Connection
 	// Define test database connection string
 	dbHost := getEnvOrDefault("TEST_DB_HOST", "localhost")
@@ -78,14 +91,60 @@ func setup() {
 	err = testDB.AutoMigrate(
 		&models.Media{},
 		&models.Page{},
+		&models.Tag{},
 		&models.Post{},
+		&models.PostLock{},
+		&models.Series{},
+		&models.SeriesPost{},
+		&models.Job{},
+		&models.PostRender{},
+		&models.APIKey{},
+		&models.PostView{},
+		&models.Change{},
+		&models.Webhook{},
+		&models.Bundle{},
+		&models.ChangePrune{},
+		&models.Playlist{},
+		&models.PlaylistItem{},
+		&models.DistributionList{},
+		&models.DistributionMember{},
+		&models.Embargo{},
+		&models.EmbargoLink{},
+		&models.Setting{},
+		&models.Submission{},
+		&models.FeatureFlag{},
+		&models.ACLEntry{},
+		&models.Group{},
+		&models.GroupMember{},
+		&models.Activity{},
+		&models.Notification{},
+		&models.Redirect{},
+		&models.Form{},
+		&models.FormField{},
+		&models.FormSubmission{},
+		&models.Subscriber{},
+		&models.Link{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to migrate database schemas: %v", err)
 	}
 
 	// STEP 4: Router Setup
+	// Anonymous writes are refused, so requests act as an editor unless they
+	// send their own API key
+	testKey, err = auth.GenerateKey(auth.ScopeManagement)
+	if err != nil {
+		log.Fatalf("Failed to generate a test API key: %v", err)
+	}
+	if err := testDB.Create(&models.APIKey{Name: "Test Author", Role: auth.RoleEditor, Prefix: testKey[:16], KeyHash: auth.HashKey(testKey)}).Error; err != nil {
+		log.Fatalf("Failed to create a test API key: %v", err)
+	}
 	router = gin.New()
+	router.Use(func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+testKey)
+		}
+	})
 	routes.InitializeRoutes(router, testDB)
 }
 
@@ -100,10 +159,38 @@ func cleanup() {
 	// Drop all tables in correct order (foreign key constraints)
 	// 1. Junction tables first
 	testDB.Exec("DROP TABLE IF EXISTS post_media CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS post_locks CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS post_tags CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS post_relations CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS series_posts CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS post_renders CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS post_views CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS playlist_items CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS embargo_links CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS group_members CASCADE")
 	// 2. Main tables next
 	testDB.Exec("DROP TABLE IF EXISTS posts CASCADE")
 	testDB.Exec("DROP TABLE IF EXISTS media CASCADE")
 	testDB.Exec("DROP TABLE IF EXISTS pages CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS tags CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS series CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS jobs CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS api_keys CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS changes CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS webhooks CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS bundles CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS change_prunes CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS playlists CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS embargoes CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS distribution_members CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS distribution_lists CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS settings CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS submissions CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS feature_flags CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS acl_entries CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS groups CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS activities CASCADE")
+	testDB.Exec("DROP TABLE IF EXISTS notifications CASCADE")
 
 	// STEP 2: Connection Cleanup
 	err = sqlDB.Close()
@@ -117,10 +204,38 @@ func clearTables() {
 	// Delete all data from tables in correct order to maintain referential integrity
 	// 1. Junction tables first
 	testDB.Exec("DELETE FROM post_media")
+	testDB.Exec("DELETE FROM post_locks")
+	testDB.Exec("DELETE FROM post_tags")
+	testDB.Exec("DELETE FROM post_relations")
+	testDB.Exec("DELETE FROM series_posts")
+	testDB.Exec("DELETE FROM post_renders")
+	testDB.Exec("DELETE FROM post_views")
+	testDB.Exec("DELETE FROM playlist_items")
+	testDB.Exec("DELETE FROM embargo_links")
+	testDB.Exec("DELETE FROM group_members")
 	// 2. Main tables next
 	testDB.Exec("DELETE FROM posts")
 	testDB.Exec("DELETE FROM media")
 	testDB.Exec("DELETE FROM pages")
+	testDB.Exec("DELETE FROM tags")
+	testDB.Exec("DELETE FROM series")
+	testDB.Exec("DELETE FROM jobs")
+	testDB.Exec("DELETE FROM api_keys WHERE key_hash <> ?", auth.HashKey(testKey))
+	testDB.Exec("DELETE FROM changes")
+	testDB.Exec("DELETE FROM webhooks")
+	testDB.Exec("DELETE FROM bundles")
+	testDB.Exec("DELETE FROM change_prunes")
+	testDB.Exec("DELETE FROM playlists")
+	testDB.Exec("DELETE FROM embargoes")
+	testDB.Exec("DELETE FROM distribution_members")
+	testDB.Exec("DELETE FROM distribution_lists")
+	testDB.Exec("DELETE FROM settings")
+	testDB.Exec("DELETE FROM submissions")
+	testDB.Exec("DELETE FROM feature_flags")
+	testDB.Exec("DELETE FROM acl_entries")
+	testDB.Exec("DELETE FROM groups")
+	testDB.Exec("DELETE FROM activities")
+	testDB.Exec("DELETE FROM notifications")
 }
 
 // getEnvOrDefault returns the environment variable value or a default value if not set
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"cms-backend/checklist"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAltTextCheck(t *testing.T) {
+	// STEP 1: Test Setup; MEDIA_ALT_TEXT=block makes alt text a blocking check
+	t.Setenv("MEDIA_ALT_TEXT", "block")
+	t.Setenv("PUBLISH_MIN_WORDS", "0")
+	if err := checklist.RegisterFromEnv(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { checklist.Unregister("alt_text") })
+	post := models.Post{Title: "Launch", Slug: "launch", Media: []models.Media{
+		{URL: "https://cdn.example.com/a.png", Type: "image/png", AltText: "The team on stage"},
+		{URL: "https://cdn.example.com/b.png", Type: "image/png", AltText: " "},
+		{URL: "https://cdn.example.com/c.pdf", Type: "application/pdf"},
+	}}
+
+	// STEP 2: The image without alt text holds the post back
+	report, err := checklist.Evaluate(context.Background(), nil, post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var item checklist.Item
+	for _, i := range report.Items {
+		if i.Name == "alt_text" {
+			item = i
+		}
+	}
+	if report.Ready || item.Passed || !item.Blocking || item.Message != "Add alt text to the images: https://cdn.example.com/b.png" {
+		t.Fatalf("Expected the alt text check to block, but got %+v", report)
+	}
+
+	// STEP 3: Unknown modes are rejected
+	t.Setenv("MEDIA_ALT_TEXT", "strict")
+	if err := checklist.RegisterFromEnv(); err == nil {
+		t.Fatalf("Expected an unknown MEDIA_ALT_TEXT mode to be rejected")
+	}
+}
+
+func TestGetAccessibilityReport(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/reports/accessibility", controllers.GetAccessibilityReport)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT posts\.id AS post_id, posts\.title AS post_title, media\.id AS media_id, media\.url AS media_url FROM "post_media" JOIN posts ON posts\.id = post_media\.post_id JOIN media ON media\.id = post_media\.media_id WHERE posts\.status = \$1 AND media\.type LIKE \$2 AND media\.scan_status <> \$3 AND TRIM\(media\.alt_text\) = '' ORDER BY posts\.id,media\.id`).
+		WithArgs("published", "image%", "quarantined").
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "post_title", "media_id", "media_url"}).
+			AddRow(3, "Launch", 5, "https://cdn.example.com/b.png"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/reports/accessibility", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var response []controllers.MissingAltText
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 1 || response[0].PostTitle != "Launch" || response[0].MediaID != 5 {
+		t.Fatalf("Unexpected report %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/accesslog"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAccessLogRecordsRedactedWrites(t *testing.T) {
+	// STEP 1: Test Setup; every write is sampled
+	var out bytes.Buffer
+	logger := accesslog.New(&out, 1)
+	router := gin.New()
+	router.Use(logger.Handler(), func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	})
+	router.POST("/posts", func(c *gin.Context) {
+		var body map[string]interface{}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			t.Errorf("Expected the handler to read the body, but got %v", err)
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 1, "title": body["title"], "api_token": "tok_123"})
+	})
+	router.GET("/posts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, []string{})
+	})
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"title": "Draft", "meta": {"password": "hunter2"}}`
+	req, _ := http.NewRequest(http.MethodPost, "/posts?access_token=abc&draft=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/posts", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Log Validation; reads are not recorded and secrets never are
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected one entry, but got %d: %s", len(lines), out.String())
+	}
+	for _, secret := range []string{"hunter2", "tok_123", "abc"} {
+		if strings.Contains(lines[0], secret) {
+			t.Errorf("Expected %q to be redacted, but got %s", secret, lines[0])
+		}
+	}
+	var entry accesslog.Entry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Error unmarshaling entry: %v", err)
+	}
+	if entry.Status != http.StatusCreated || entry.Caller != "Jane" || entry.KeyID != 7 {
+		t.Errorf("Expected the status and caller to be recorded, but got %+v", entry)
+	}
+	if !strings.Contains(string(entry.RequestBody), `"title":"Draft"`) || !strings.Contains(string(entry.ResponseBody), `"id":1`) {
+		t.Errorf("Expected both bodies to be recorded, but got %s", lines[0])
+	}
+}
+
+func TestAccessLogRedactsIssuedAPIKeys(t *testing.T) {
+	// STEP 1: Test Setup; every write is sampled
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	var out bytes.Buffer
+	router.Use(accesslog.New(&out, 1).Handler())
+	router.POST("/api/v1/admin/api-keys", controllers.CreateAPIKey)
+
+	// STEP 2: Database Expectations
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/api/v1/admin/api-keys", strings.NewReader(`{"name":"website","role":"editor"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.APIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+
+	// STEP 4: Log Validation; the raw key never reaches the log
+	if response.Key == "" || strings.Contains(out.String(), response.Key) {
+		t.Fatalf("Expected the issued key to be redacted, but got %s", out.String())
+	}
+	if !strings.Contains(out.String(), `"key":"`+accesslog.Redacted+`"`) {
+		t.Errorf("Expected the key field to be redacted, but got %s", out.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAccessLogRotatesFiles(t *testing.T) {
+	// STEP 1: Test Setup; each write fills the file
+	path := filepath.Join(t.TempDir(), "access.log")
+	file, err := accesslog.OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Expected the file to open, but got %v", err)
+	}
+	defer file.Close()
+
+	// STEP 2: Writes; the oldest of four falls off past two backups
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := file.Write([]byte(line)); err != nil {
+			t.Fatalf("Expected the write to succeed, but got %v", err)
+		}
+	}
+
+	// STEP 3: File Validation
+	expected := map[string]string{path: "fourth\n", path + ".1": "third\n", path + ".2": "second\n"}
+	for name, content := range expected {
+		data, err := os.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Errorf("Expected %s to hold %q, but got %q (%v)", name, content, data, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("Expected only two backups to be kept")
+	}
+}
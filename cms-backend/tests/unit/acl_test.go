@@ -0,0 +1,314 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetPostsChecksGrantsInListQuery(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.NewPostHandler(controllers.Deps{}).List)
+
+	// STEP 2: Database Expectations; the grant check is part of the one list query
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 OR NOT EXISTS \(SELECT 1 FROM "acl_entries" WHERE acl_entries\.entity_type = \$2 AND acl_entries\.entity_id = posts\.id\) OR EXISTS \(SELECT 1 FROM "acl_entries" WHERE \(acl_entries\.entity_type = \$3 AND acl_entries\.entity_id = posts\.id\) AND \(\(acl_entries\.principal_type = \$4 AND acl_entries\.principal_id = \$5\) OR \(acl_entries\.principal_type = \$6 AND acl_entries\.principal_id IN \(SELECT "group_id" FROM "group_members" WHERE api_key_id = \$7\)\)\) AND acl_entries\.permission IN \(\$8,\$9,\$10\)\)`).
+		WithArgs("published", "post", "post", "key", 7, "group", 7, "read", "write", "publish").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
+			AddRow(1, "Granted draft", "Content", now, now))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetPostHidesUnpublishedContentFromNonManagers(t *testing.T) {
+	// STEP 1: Test Setup; the caller is anonymous
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id", controllers.NewPostHandler(controllers.Deps{}).Get)
+
+	// STEP 2: Database Expectations; drafts, embargoed and members-only posts
+	// are left out as on the delivery API, so the members-only draft is not found
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4`).
+		WithArgs(7, "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/7?include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdatePostDeniedWithoutGrant(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/posts/:id", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.NewPostHandler(controllers.Deps{}).Update)
+
+	// STEP 2: Database Expectations; the post is restricted to key 3
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Restricted", "draft", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 1, "key", 3, "write").
+			AddRow(2, "post", 1, "key", 7, "read"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/posts/1", bytes.NewBufferString(`{"title": "Edited", "content": "Edited content"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPutPostACLRejectsNonEditorialKeys(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/posts/:id/acl", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "admin", Role: auth.RoleAdmin, KeyID: 1})
+	}, controllers.PutPostACL)
+
+	// STEP 2: Database Expectations; key 5 is a delivery key
+	mock.ExpectQuery(`SELECT "id" FROM "posts" WHERE id = \$1`).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(4, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "role"}).
+			AddRow(4, "editor").
+			AddRow(5, "delivery"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"grants": [{"principal_id": 4, "permission": "publish"}, {"principal_id": 5, "permission": "read"}]}`
+	req, _ := http.NewRequest(http.MethodPut, "/posts/1/acl", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRelatedPostsDeniedWithoutGrant(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id/related", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.GetRelatedPosts)
+
+	// STEP 2: Database Expectations; the draft is restricted to key 3
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Restricted", "draft", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 1, "key", 3, "read"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/related?suggest=true", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddRelatedPostsDeniedWithoutGrantOnRelatedPost(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts/:id/related", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	}, controllers.AddRelatedPosts)
+
+	// STEP 2: Database Expectations; post 2 only grants key 7 read access
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Open", "draft", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts" WHERE id IN \(\$1\)`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 2, "key", 7, "read"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/related", bytes.NewBufferString(`{"post_ids": [2]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAcquirePostLockDeniedWithoutGrant(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts/:id/lock", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.NewPresenceHandler(controllers.Deps{}).AcquireLock)
+
+	// STEP 2: Database Expectations; the post is restricted to key 3
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Restricted", "draft", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 1, "key", 3, "write"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/lock", bytes.NewBufferString(`{"user": "jane"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteEmbargoDeniedWithoutGrant(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.DELETE("/posts/:id/embargo", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	}, controllers.DeleteEmbargo)
+
+	// STEP 2: Database Expectations; key 7 may edit but not publish the post
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "embargoes" WHERE post_id = \$1`).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "publish_at"}).AddRow(1, 1, now.Add(time.Hour)))
+	mock.ExpectQuery(`SELECT \* FROM "embargo_links" WHERE "embargo_links"\."embargo_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embargo_id", "member_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 1, "key", 7, "write"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/posts/1/embargo", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExportSeriesEPUBDeniedWithUnreadablePosts(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/series/:id/export.epub", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.ExportSeriesEPUB)
+
+	// STEP 2: Database Expectations; one of the two posts is hidden from key 7
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "series" WHERE "series"\."id" = \$1`).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "updated_at"}).AddRow(1, "Go Basics", now, now))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts" JOIN series_posts ON series_posts\.post_id = posts\.id WHERE series_posts\.series_id = \$1$`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts" JOIN series_posts ON series_posts\.post_id = posts\.id WHERE series_posts\.series_id = \$1 AND \(posts\.status = \$2 OR .*\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/series/1/export.epub", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetPostActivityPaginatesNewestFirst(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id/activity", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.GetPostActivity)
+
+	// STEP 2: Database Expectations; one row more than the limit means another page follows
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "activities" WHERE entity_type = \$1 AND entity_id = \$2 ORDER BY activities\.created_at DESC,activities\.id DESC LIMIT \$3`).
+		WithArgs("post", 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "action", "created_at"}).
+			AddRow(3, "post", 1, "status_changed", now).
+			AddRow(2, "post", 1, "edited", now.Add(-time.Minute)).
+			AddRow(1, "post", 1, "created", now.Add(-time.Hour)))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/activity?limit=2", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get(utils.NextCursorHeader) == "" {
+		t.Errorf("Expected a %s header", utils.NextCursorHeader)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetPostActivityRequiresEditorialRole(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id/activity", controllers.GetPostActivity)
+
+	// STEP 2: HTTP Test Setup; anonymous callers see no history
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/activity", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetUserActivityForbidsOtherKeys(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/users/:id/activity", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleContributor, KeyID: 7})
+	}, controllers.GetUserActivity)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/users/8/activity", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
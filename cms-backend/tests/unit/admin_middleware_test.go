@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"cms-backend/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	router.GET("/admin/ping", middleware.RequireAdminToken(), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		name     string
+		env      string
+		token    string
+		expected int
+	}{
+		{"disabled without ADMIN_TOKEN", "", "anything", http.StatusForbidden},
+		{"wrong token", "secret", "guess", http.StatusUnauthorized},
+		{"correct token", "secret", "secret", http.StatusNoContent},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// STEP 2: Environment Setup
+			t.Setenv("ADMIN_TOKEN", tc.env)
+
+			// STEP 3: HTTP Test Setup
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/admin/ping", nil)
+			req.Header.Set(middleware.AdminTokenHeader, tc.token)
+			router.ServeHTTP(w, req)
+
+			// STEP 4: Response Validation
+			if w.Code != tc.expected {
+				t.Fatalf("Expected status %d, but got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"cms-backend/adminui"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAdminUIServesAppWithFallback(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	adminui.New("/admin", fstest.MapFS{
+		"index.html":    {Data: []byte("<html>app</html>")},
+		"assets/app.js": {Data: []byte("console.log('app')")},
+	}).Register(router)
+
+	// STEP 2: Requests and expected responses; app routes load the index,
+	// missing assets do not
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/admin/", http.StatusOK, "<html>app</html>"},
+		{"/admin/posts/12/edit", http.StatusOK, "<html>app</html>"},
+		{"/admin/assets/app.js", http.StatusOK, "console.log('app')"},
+		{"/admin/assets/old.js", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+		router.ServeHTTP(w, req)
+
+		// STEP 3: Response Validation
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: expected %d %q, but got %d %q", tt.path, tt.status, tt.body, w.Code, w.Body.String())
+		}
+	}
+}
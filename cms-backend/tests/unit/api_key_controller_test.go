@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys" \("name","role","email","scopes","prefix","key_hash","created_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\) RETURNING "id"`).
+		WithArgs("website", "delivery", "", "", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/admin/api-keys", controllers.CreateAPIKey)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(`{"name":"website","role":"delivery"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", w.Code)
+	}
+
+	var response controllers.APIKeyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if prefix := auth.KeyPrefixFor(auth.ScopeDelivery); !strings.HasPrefix(response.Key, prefix) {
+		t.Fatalf("Expected key to start with '%s', but got '%s'", prefix, response.Key)
+	}
+	if !strings.HasPrefix(response.Key, response.APIKey.Prefix) {
+		t.Fatalf("Expected prefix '%s' to match the key", response.APIKey.Prefix)
+	}
+	if strings.Contains(w.Body.String(), auth.HashKey(response.Key)) {
+		t.Fatalf("Expected the key hash not to be returned")
+	}
+}
+
+func TestAuthenticateRejectsUnknownKey(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_hash = \$1 ORDER BY "api_keys"\."id" LIMIT \$2`).
+		WithArgs(auth.HashKey("cms_unknown"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role", "prefix", "key_hash", "created_at"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/whoami", middleware.Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, auth.CallerFrom(c))
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer cms_unknown")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAPIVersionEnvelope(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	for version := 1; version <= 2; version++ {
+		group := router.Group(fmt.Sprintf("/v%d", version), middleware.APIVersion(version))
+		group.GET("/tags", func(c *gin.Context) {
+			c.JSON(http.StatusOK, []gin.H{{"id": 1, "name": "go"}, {"id": 2, "name": "cms"}})
+		})
+		group.GET("/tags/9", func(c *gin.Context) {
+			c.JSON(http.StatusNotFound, utils.HTTPError{Code: http.StatusNotFound, Message: "Tag not found"})
+		})
+		group.GET("/export.pdf", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/pdf", []byte("%PDF-1.4"))
+		})
+		group.GET("/version", func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"version": middleware.VersionFrom(c)})
+		})
+	}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: Version 1 responses keep their original shape
+	if w := get("/v1/tags"); w.Header().Get(middleware.VersionHeader) != "1" || w.Body.String()[0] != '[' {
+		t.Fatalf("Expected a bare v1 list, but got %s", w.Body.String())
+	}
+
+	// STEP 3: Version 2 wraps lists in data with a count
+	w := get("/v2/tags")
+	var list struct {
+		Data []map[string]interface{} `json:"data"`
+		Meta utils.EnvelopeMeta        `json:"meta"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(list.Data) != 2 || list.Meta.APIVersion != 2 || list.Meta.Count == nil || *list.Meta.Count != 2 {
+		t.Fatalf("Unexpected v2 list envelope: %s", w.Body.String())
+	}
+
+	// STEP 4: Version 2 errors move into errors with a null data
+	w = get("/v2/tags/9")
+	var failure utils.Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &failure); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if w.Code != http.StatusNotFound || failure.Data != nil || len(failure.Errors) != 1 || failure.Errors[0].Message != "Tag not found" {
+		t.Fatalf("Unexpected v2 error envelope: %d %s", w.Code, w.Body.String())
+	}
+
+	// STEP 5: Non-JSON responses pass through untouched
+	if w := get("/v2/export.pdf"); w.Body.String() != "%PDF-1.4" {
+		t.Fatalf("Expected the PDF body untouched, but got %q", w.Body.String())
+	}
+
+	// STEP 6: Shared controllers see the version of their route group
+	if w := get("/v2/version"); w.Body.String() != `{"data":{"version":2},"meta":{"api_version":2}}` {
+		t.Fatalf("Unexpected version response: %s", w.Body.String())
+	}
+}
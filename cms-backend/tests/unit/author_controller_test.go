@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetAuthor(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/authors/:username", controllers.GetAuthor)
+
+	// STEP 2: Database Expectations; the profile, its avatar, then a page of
+	// the author's published posts
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE username = \$1 ORDER BY "users"\."id" LIMIT \$2`).
+		WithArgs("ada", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "display_name", "bio", "avatar_id"}).
+			AddRow(1, "ada", "Ada Lovelace", "Writes about engines.", 7))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE "media"\."id" = \$1 AND scan_status <> \$2`).
+		WithArgs(7, "quarantined").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type"}).AddRow(7, "https://cdn.example.com/ada.png", "image/png"))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.author = \$1 AND posts\.status = \$2 AND .* AND posts\.visibility <> \$4 ORDER BY posts\.created_at DESC,posts\.id DESC LIMIT \$5`).
+		WithArgs("ada", "published", sqlmock.AnyArg(), "members", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author", "status"}).
+			AddRow(5, "Engines", "ada", "published").
+			AddRow(4, "Notes", "ada", "published").
+			AddRow(2, "Tables", "ada", "published"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/authors/ada?limit=2&include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var profile controllers.AuthorProfile
+	if err := json.Unmarshal(w.Body.Bytes(), &profile); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if profile.DisplayName != "Ada Lovelace" || profile.Avatar == nil || profile.Avatar.ID != 7 {
+		t.Errorf("Expected Ada's profile with her avatar, but got %+v", profile)
+	}
+	if len(profile.Posts) != 2 || w.Header().Get(utils.NextCursorHeader) == "" {
+		t.Errorf("Expected a page of 2 posts with a next cursor, but got %d", len(profile.Posts))
+	}
+
+	// STEP 5: Unknown authors are not found
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE username = \$1`).
+		WithArgs("nobody", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username"}))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/authors/nobody", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", w.Code)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"cms-backend/backup"
+	"cms-backend/utils"
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// backupTables are the tables a backup holds, in restore order
+var backupTables = []string{
+	"groups", "media", "external_assets", "users", "tags", "pages", "posts", "post_media", "post_tags", "post_relations",
+	"series", "series_posts", "playlists", "playlist_items", "collections", "redirects", "settings",
+	"forms", "form_fields", "environments", "environment_entries", "acl_entries",
+}
+
+// backupJoinTables have no id column, so no sequence is reset for them
+var backupJoinTables = map[string]bool{"post_media": true, "post_tags": true, "post_relations": true, "series_posts": true}
+
+func TestBackupRoundTrip(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	policy := &backup.Policy{Dir: t.TempDir(), Keep: 2}
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	noProgress := func(int) {}
+
+	// STEP 2: Backup; a subtag, a post and a setting
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(45, false))
+	for _, table := range backupTables {
+		rows := sqlmock.NewRows([]string{"id"})
+		switch table {
+		case "tags":
+			rows = sqlmock.NewRows([]string{"id", "name", "parent_id"}).AddRow(1, "go", nil).AddRow(2, "golang", 1)
+		case "posts":
+			rows = sqlmock.NewRows([]string{"id", "title"}).AddRow(5, "Hello")
+		case "settings":
+			rows = sqlmock.NewRows([]string{"id", "key", "value"}).AddRow(1, "title", []byte(`"My site"`))
+		}
+		mock.ExpectQuery(`SELECT \* FROM "` + table + `"`).WillReturnRows(rows)
+	}
+	info, err := policy.Create(context.Background(), db, now, noProgress)
+	if err != nil {
+		t.Fatalf("Expected the backup to be created, but got %v", err)
+	}
+	if info.Name != "backup-20261016T093000Z.jsonl.gz" || !info.CreatedAt.Equal(now) {
+		t.Fatalf("Unexpected backup %+v", info)
+	}
+
+	// STEP 3: Restore; rows go back in order with deferred references filled
+	// in last, and the seeded settings are replaced
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(45, false))
+	for _, table := range backupTables {
+		if table != "settings" {
+			mock.ExpectQuery(`SELECT count\(\*\) FROM "` + table + `"`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		}
+	}
+	mock.ExpectExec(`DELETE FROM settings`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "tags" \("id","name","parent_id"\) VALUES \(\$1,\$2,\$3\),\(\$4,\$5,\$6\)`).
+		WithArgs("1", "go", nil, "2", "golang", nil).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "posts" \("id","title"\) VALUES \(\$1,\$2\)`).
+		WithArgs("5", "Hello").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO "settings" \("id","key","value"\) VALUES \(\$1,\$2,\$3\)`).
+		WithArgs("1", "title", `"My site"`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, table := range backupTables {
+		if table == "tags" {
+			mock.ExpectExec(`UPDATE "tags" SET "parent_id"=\$1 WHERE id = \$2`).
+				WithArgs("1", "2").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		if !backupJoinTables[table] {
+			mock.ExpectExec(regexp.QuoteMeta("SELECT setval(pg_get_serial_sequence('" + table + "', 'id')")).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+	mock.ExpectCommit()
+	if err := policy.Restore(context.Background(), db, info.Name, noProgress); err != nil {
+		t.Fatalf("Expected the backup to be restored, but got %v", err)
+	}
+
+	// STEP 4: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRestoreBackupRejectsOtherSchemaVersion(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	policy := &backup.Policy{Dir: t.TempDir(), Keep: 2}
+	noProgress := func(int) {}
+
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(44, false))
+	for _, table := range backupTables {
+		mock.ExpectQuery(`SELECT \* FROM "` + table + `"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+	info, err := policy.Create(context.Background(), db, time.Now(), noProgress)
+	if err != nil {
+		t.Fatalf("Expected the backup to be created, but got %v", err)
+	}
+
+	// STEP 2: Database Expectations; the database was migrated since
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(45, false))
+	mock.ExpectRollback()
+
+	// STEP 3: Restore
+	err = policy.Restore(context.Background(), db, info.Name, noProgress)
+
+	// STEP 4: Result Validation
+	if err == nil {
+		t.Fatal("Expected the restore to be refused")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
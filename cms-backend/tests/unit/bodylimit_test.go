@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	// STEP 1: Test Setup; the handler echoes how much of the body it read
+	router := gin.New()
+	router.Use(middleware.BodyLimit(16))
+	router.POST("/posts", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			t.Errorf("Expected the body to be readable, but got %v", err)
+		}
+		c.JSON(http.StatusCreated, gin.H{"read": len(body)})
+	})
+
+	// STEP 2: Requests and expected statuses; a length of -1 sends the body
+	// without declaring its size
+	tests := []struct {
+		name   string
+		body   string
+		length int64
+		status int
+	}{
+		{"within the limit", `{"title":"Hi"}`, 14, http.StatusCreated},
+		{"declared over the limit", `{"title":"Hello, world"}`, 24, http.StatusRequestEntityTooLarge},
+		{"undeclared within the limit", `{"title":"Hi"}`, -1, http.StatusCreated},
+		{"undeclared over the limit", `{"title":"Hello, world"}`, -1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/posts", io.NopCloser(strings.NewReader(tt.body)))
+		req.ContentLength = tt.length
+		router.ServeHTTP(w, req)
+
+		// STEP 3: Response Validation
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, but got %d: %s", tt.name, tt.status, w.Code, w.Body.String())
+			continue
+		}
+		if tt.status != http.StatusRequestEntityTooLarge {
+			continue
+		}
+		var response utils.HTTPError
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Error unmarshaling response: %v", err)
+		}
+		if response.ErrorCode != utils.ErrorCodeBodyTooLarge {
+			t.Errorf("%s: expected error code %q, but got %q", tt.name, utils.ErrorCodeBodyTooLarge, response.ErrorCode)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"cms-backend/bucket"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDirListsFiles(t *testing.T) {
+	// STEP 1: Test Setup; hidden files and directories are left out
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"logo.png":          "png",
+		"docs/guide v2.pdf": "pdf!",
+		".DS_Store":         "x",
+		".cache/thumb.png":  "x",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte(content), 0o644)
+	}
+	dir := &bucket.Dir{Root: root, BaseURL: "https://assets.example.com/"}
+
+	// STEP 2: Listing
+	objects, err := dir.List(context.Background())
+
+	// STEP 3: Result Validation
+	if err != nil {
+		t.Fatalf("Expected the directory to be listed, but got %v", err)
+	}
+	expected := []bucket.Object{{Key: "docs/guide v2.pdf", Size: 4}, {Key: "logo.png", Size: 3}}
+	if !reflect.DeepEqual(objects, expected) {
+		t.Errorf("Expected %v, but got %v", expected, objects)
+	}
+	if url := dir.URL("docs/guide v2.pdf"); url != "https://assets.example.com/docs/guide%20v2.pdf" {
+		t.Errorf("Unexpected URL %q", url)
+	}
+}
+
+func TestS3ListsPagesOfObjects(t *testing.T) {
+	// STEP 1: Test Setup; a stand-in for S3 returning two pages
+	var authorization, query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		query = r.URL.RawQuery
+		if r.URL.Path != "/assets/" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("continuation-token") == "" {
+			w.Write([]byte(`<ListBucketResult><IsTruncated>true</IsTruncated><NextContinuationToken>page 2</NextContinuationToken>
+				<Contents><Key>library/</Key><Size>0</Size></Contents>
+				<Contents><Key>library/a.jpg</Key><Size>10</Size></Contents></ListBucketResult>`))
+			return
+		}
+		w.Write([]byte(`<ListBucketResult><IsTruncated>false</IsTruncated>
+			<Contents><Key>library/b.mp4</Key><Size>20</Size></Contents></ListBucketResult>`))
+	}))
+	defer server.Close()
+	s3 := &bucket.S3{
+		Bucket:          "assets",
+		Prefix:          "library/",
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+	}
+
+	// STEP 2: Listing
+	objects, err := s3.List(context.Background())
+
+	// STEP 3: Result Validation; folder markers are not files
+	if err != nil {
+		t.Fatalf("Expected the bucket to be listed, but got %v", err)
+	}
+	expected := []bucket.Object{{Key: "library/a.jpg", Size: 10}, {Key: "library/b.mp4", Size: 20}}
+	if !reflect.DeepEqual(objects, expected) {
+		t.Errorf("Expected %v, but got %v", expected, objects)
+	}
+	if query != "continuation-token=page%202&list-type=2&prefix=library%2F" {
+		t.Errorf("Expected a sorted, encoded query, but got %q", query)
+	}
+	if !strings.Contains(authorization, "/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Unexpected Authorization header %q", authorization)
+	}
+	if url := s3.URL("library/a.jpg"); url != server.URL+"/assets/library/a.jpg" {
+		t.Errorf("Unexpected URL %q", url)
+	}
+}
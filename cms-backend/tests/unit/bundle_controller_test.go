@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"cms-backend/bundle"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetBundleServesBuiltBundle(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	path := filepath.Join(t.TempDir(), "bundle-press-0-12-job-4.json.gz")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bundle.Write(file, bundle.New("press", 0, 12, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tags" WHERE name = \$1`).
+		WithArgs("press").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(seq\), 0\) FROM "changes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(12))
+	mock.ExpectQuery(`SELECT \* FROM "bundles" WHERE channel = \$1 AND since = \$2 AND version = \$3`).
+		WithArgs("press", 0, 12, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "channel", "since", "version", "job_id"}).
+			AddRow(1, "press", 0, 12, 4))
+	mock.ExpectQuery(`SELECT \* FROM "jobs" WHERE "jobs"\."id" = \$1`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "status", "result_path"}).
+			AddRow(4, controllers.JobTypeBundleBuild, "completed", path))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/bundles/:channel", controllers.GetBundle)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/bundles/press", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get(controllers.BundleVersionHeader); got != "12" {
+		t.Fatalf("Expected bundle version 12, but got %q", got)
+	}
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip-compressed bundle: %v", err)
+	}
+	var response bundle.Bundle
+	if err := json.NewDecoder(reader).Decode(&response); err != nil {
+		t.Fatalf("Error decoding bundle: %v", err)
+	}
+	if response.Channel != "press" || response.Version != 12 || response.Format != bundle.Format {
+		t.Fatalf("Expected the press bundle at version 12, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBundleRejectsFutureSince(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(seq\), 0\) FROM "changes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(12))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/bundles/:channel", controllers.GetBundle)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/bundles/all?since=40", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
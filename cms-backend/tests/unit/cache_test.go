@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"cms-backend/cache"
+	"cms-backend/settings"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCacheStaleWhileRevalidate(t *testing.T) {
+	// STEP 1: Test Setup
+	var calls int32
+	now := time.Now()
+	router := gin.New()
+	responseCache := cache.New(map[string]cache.Policy{
+		"posts": {TTL: time.Minute, StaleWhileRevalidate: 5 * time.Minute},
+	}, router)
+	responseCache.Now = func() time.Time { return now }
+	router.GET("/posts", responseCache.Handler("posts"), func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.String(http.StatusOK, fmt.Sprintf("version %d", n))
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: First request populates the cache
+	if w := get(); w.Header().Get("X-Cache") != cache.StatusMiss || w.Body.String() != "version 1" {
+		t.Fatalf("Expected MISS with version 1, but got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	// STEP 3: Fresh entries are served without calling the handler
+	if w := get(); w.Header().Get("X-Cache") != cache.StatusHit || w.Body.String() != "version 1" {
+		t.Fatalf("Expected HIT with version 1, but got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	// STEP 4: Stale entries are served immediately and refreshed in the background
+	now = now.Add(2 * time.Minute)
+	if w := get(); w.Header().Get("X-Cache") != cache.StatusStale || w.Body.String() != "version 1" {
+		t.Fatalf("Expected STALE with version 1, but got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	// STEP 5: Once the background refresh lands, the new response is served as a hit
+	var w *httptest.ResponseRecorder
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if w = get(); w.Body.String() == "version 2" {
+			break
+		}
+	}
+	if w.Header().Get("X-Cache") != cache.StatusHit || w.Body.String() != "version 2" {
+		t.Fatalf("Expected HIT with version 2, but got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+
+	// STEP 6: Past the stale window the handler runs synchronously
+	now = now.Add(10 * time.Minute)
+	if w := get(); w.Header().Get("X-Cache") != cache.StatusMiss || w.Body.String() != "version 3" {
+		t.Fatalf("Expected MISS with version 3, but got %s %q", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
+
+func TestCachePolicyFollowsSettings(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	responseCache := cache.New(map[string]cache.Policy{
+		"posts": {TTL: time.Minute, StaleWhileRevalidate: 5 * time.Minute},
+	}, router)
+	responseCache.Settings = settings.NewStore(time.Minute)
+	router.GET("/posts", responseCache.Handler("posts"), func(c *gin.Context) {
+		c.String(http.StatusOK, "posts")
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: Database Expectations; no override at first, then an admin sets cache/posts
+	columns := []string{"id", "namespace", "key", "type", "value"}
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).WillReturnRows(sqlmock.NewRows(columns))
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(1, "cache", "posts", "string", `"10s:30s"`))
+
+	// STEP 3: Without a setting the policy the cache was created with applies
+	if w := get(); w.Header().Get("Cache-Control") != "public, max-age=60, stale-while-revalidate=300" {
+		t.Fatalf("Expected the default policy, but got %q", w.Header().Get("Cache-Control"))
+	}
+
+	// STEP 4: Once the setting is reloaded, its windows apply without a restart
+	responseCache.Settings.Invalidate()
+	w := get()
+	if w.Header().Get("X-Cache") != cache.StatusHit {
+		t.Fatalf("Expected HIT, but got %s", w.Header().Get("X-Cache"))
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=10, stale-while-revalidate=30" {
+		t.Fatalf("Expected the policy from settings, but got %q", w.Header().Get("Cache-Control"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
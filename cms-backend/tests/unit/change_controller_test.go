@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetChanges(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+
+	// STEP 2: Database Expectations; nothing has been pruned past the cursor
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(pruned_through\), 0\) FROM "change_prunes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "changes" WHERE seq > \$1 ORDER BY seq LIMIT \$2`).
+		WithArgs(41, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "entity_type", "entity_id", "action", "changed_at"}).
+			AddRow(42, "post", 7, "update", now).
+			AddRow(43, "media", 2, "delete", now).
+			AddRow(45, "post", 8, "insert", now))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/changes", controllers.GetChanges)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/changes?since=41&limit=2", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response controllers.ChangesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response.Changes) != 2 || response.Changes[1].EntityType != "media" || response.Changes[1].Action != "delete" {
+		t.Fatalf("Unexpected changes: %+v", response.Changes)
+	}
+	if response.NextSince != 43 || !response.HasMore {
+		t.Fatalf("Expected next_since 43 with more to come, but got %d %v", response.NextSince, response.HasMore)
+	}
+}
+
+func TestGetChangesWithoutNewChanges(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; nothing has been pruned past the cursor
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(pruned_through\), 0\) FROM "change_prunes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(0))
+	mock.ExpectQuery(`SELECT \* FROM "changes" WHERE seq > \$1 ORDER BY seq LIMIT \$2`).
+		WithArgs(99, 101).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "entity_type", "entity_id", "action", "changed_at"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/changes", controllers.GetChanges)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/changes?since=99", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if body := w.Body.String(); body != `{"changes":[],"next_since":99,"has_more":false}` {
+		t.Fatalf("Unexpected response: %s", body)
+	}
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"cms-backend/chat"
+	"cms-backend/scheduler"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestChatBodies(t *testing.T) {
+	// STEP 1: Test Setup; titles are user input
+	event := chat.Event{Kind: chat.EventPublished, EntityType: "post", EntityID: 3, Title: "<!channel> Q&A @everyone", Actor: "alice"}
+
+	// STEP 2: Slack escapes its control characters
+	body, err := chat.Body(chat.ProviderSlack, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var slack map[string]interface{}
+	json.Unmarshal(body, &slack)
+	if slack["text"] != `alice published the post "&lt;!channel&gt; Q&amp;A @everyone"` {
+		t.Fatalf("Expected escaped Slack text, but got %v", slack["text"])
+	}
+
+	// STEP 3: Discord mentions are disabled
+	body, err = chat.Body(chat.ProviderDiscord, event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var discord struct {
+		Content         string `json:"content"`
+		AllowedMentions struct {
+			Parse []string `json:"parse"`
+		} `json:"allowed_mentions"`
+	}
+	json.Unmarshal(body, &discord)
+	if discord.Content != `alice published the post "<!channel> Q&A @everyone"` || discord.AllowedMentions.Parse == nil || len(discord.AllowedMentions.Parse) != 0 {
+		t.Fatalf("Expected Discord content without mentions, but got %s", body)
+	}
+
+	// STEP 4: Unknown event kinds are rejected
+	if _, err := chat.ParseEvents("published, comment_posted"); err == nil {
+		t.Fatalf("Expected an unknown event kind to be rejected")
+	}
+}
+
+func TestAnnounceChatRoutesEvents(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	var messages []string
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var message struct {
+			Text string `json:"text"`
+		}
+		json.Unmarshal(body, &message)
+		messages = append(messages, message.Text)
+		w.Write([]byte("ok"))
+	}))
+	defer slack.Close()
+
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+
+	// STEP 2: Database Expectations; #press follows published press releases only
+	mock.ExpectQuery(`SELECT \* FROM "chat_channels" WHERE active = \$1 ORDER BY id`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "provider", "webhook_url", "events", "filter", "active", "last_activity_id", "last_submission_id"}).
+			AddRow(1, "#press", "slack", slack.URL, "published", "tag=press", true, 10, 4))
+	mock.ExpectQuery(`SELECT \* FROM "activities" WHERE id > \$1 AND \(action = \$2 OR \(action = \$3 AND to_status = \$4\)\) ORDER BY id LIMIT \$5`).
+		WithArgs(10, "created", "status_changed", "published", 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "action", "actor_name", "to_status"}).
+			AddRow(11, "post", 7, "status_changed", "alice", "published").
+			AddRow(12, "post", 8, "status_changed", "bob", "published"))
+
+	// The first post is a press release
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).AddRow(7, "Launch", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}).AddRow(7, 2))
+	mock.ExpectQuery(`SELECT \* FROM "tags" WHERE "tags"\."id" = \$1`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "press"))
+
+	// The second post is not
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).AddRow(8, "Changelog", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(8).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// Review requests are skipped but still read past
+	mock.ExpectQuery(`SELECT "id","submitter","title","status" FROM "submissions" WHERE id > \$1 ORDER BY id LIMIT \$2`).
+		WithArgs(4, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "submitter", "title", "status"}).AddRow(5, "carol", "Guest post", "pending"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "chat_channels" SET "last_activity_id"=\$1,"last_attempt_at"=\$2,"last_error"=\$3,"last_status"=\$4,"last_submission_id"=\$5,"updated_at"=\$6 WHERE id = \$7`).
+		WithArgs(12, sqlmock.AnyArg(), "", 200, 5, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: Task Execution
+	if err := scheduler.AnnounceChat(context.Background(), db, now); err != nil {
+		t.Fatalf("Expected announcing to succeed, but got %v", err)
+	}
+
+	// STEP 4: Announcement Validation
+	if len(messages) != 1 || messages[0] != `alice published the post "Launch"` {
+		t.Fatalf("Expected only the press release to be announced, but got %q", messages)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateCollection(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/collections", controllers.CreateCollection)
+
+	// STEP 2: Invalid resources and saved cursors are refused
+	for _, body := range []string{
+		`{"name": "Stale drafts", "resource": "users", "query": "status=draft"}`,
+		`{"name": "Stale drafts", "resource": "posts", "query": "after=abc"}`,
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/collections", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, but got %d", body, w.Code)
+		}
+	}
+
+	// STEP 3: The query is stored in canonical form
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "collections"`).
+		WithArgs("Stale drafts", "posts", "older_than_days=30&status=draft", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/collections", bytes.NewBufferString(`{"name": "Stale drafts", "resource": "posts", "query": "?status=draft&older_than_days=30"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	var collection models.Collection
+	if err := json.Unmarshal(w.Body.Bytes(), &collection); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if collection.ID != 1 || collection.Query != "older_than_days=30&status=draft" {
+		t.Errorf("Expected the saved collection, but got %+v", collection)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCollectionItems(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/collections/:id/items", controllers.GetCollectionItems(map[string]gin.HandlerFunc{
+		"posts": controllers.NewPostHandler(controllers.Deps{}).List,
+	}))
+
+	// STEP 2: The saved filters are applied to the post listing, with the
+	// request's own parameters added
+	mock.ExpectQuery(`SELECT \* FROM "collections" WHERE "collections"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "resource", "query"}).
+			AddRow(1, "Stale drafts", "posts", "older_than_days=30&status=draft"))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 AND posts\.created_at < \$2 AND .* ORDER BY "posts"\."title","posts"\."id"`).
+		WithArgs("draft", sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(3, "Forgotten", "draft"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/collections/1/items?sort=title&include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var posts []models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &posts); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(posts) != 1 || posts[0].ID != 3 {
+		t.Errorf("Expected post 3, but got %+v", posts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
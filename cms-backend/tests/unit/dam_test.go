@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/dam"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCloudinaryAdapter(t *testing.T) {
+	// STEP 1: Test Setup; an upload notification signed with the API secret
+	adapter := &dam.Cloudinary{Secret: "cld-secret"}
+	body := []byte(`{"notification_type":"upload","public_id":"site/hero","secure_url":"https://res.cloudinary.com/demo/image/upload/site/hero.png",` +
+		`"resource_type":"image","format":"png","bytes":2048,"width":800,"height":600}`)
+	now := time.Unix(1760000000, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	sum := sha1.Sum([]byte(string(body) + timestamp + "cld-secret"))
+	header := http.Header{}
+	header.Set("X-Cld-Timestamp", timestamp)
+	header.Set("X-Cld-Signature", hex.EncodeToString(sum[:]))
+
+	// STEP 2: Signature Validation
+	if err := adapter.Verify(header, body, now.Add(time.Minute)); err != nil {
+		t.Fatalf("Expected the signature to be accepted, but got %v", err)
+	}
+	if err := adapter.Verify(header, body, now.Add(2*time.Hour)); err != dam.ErrSignature {
+		t.Fatalf("Expected a stale notification to be refused, but got %v", err)
+	}
+	if err := adapter.Verify(header, append(body, ' '), now); err != dam.ErrSignature {
+		t.Fatalf("Expected a modified body to be refused, but got %v", err)
+	}
+
+	// STEP 3: Payload Mapping
+	events, err := adapter.Events(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := dam.Asset{ID: "site/hero", URL: "https://res.cloudinary.com/demo/image/upload/site/hero.png", Type: "image/png", Size: 2048, Width: 800, Height: 600}
+	if len(events) != 1 || events[0].Deleted || events[0].Asset != want {
+		t.Fatalf("Unexpected upload events: %+v", events)
+	}
+	events, err = adapter.Events([]byte(`{"notification_type":"delete","resources":[{"public_id":"site/hero"},{"public_id":"site/logo"}]}`))
+	if err != nil || len(events) != 2 || !events[1].Deleted || events[1].Asset.ID != "site/logo" {
+		t.Fatalf("Unexpected delete events: %+v, %v", events, err)
+	}
+}
+
+func TestReceiveDAMWebhook(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	previous := dam.Default
+	dam.Default = map[string]dam.Adapter{"generic": &dam.Generic{Secret: "dam-secret"}}
+	t.Cleanup(func() { dam.Default = previous })
+	router.POST("/integrations/dam/:provider", controllers.NewDAMHandler(controllers.Deps{}).Receive)
+
+	post := func(provider, signature string, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/integrations/dam/"+provider, bytes.NewReader(body))
+		req.Header.Set(webhooks.SignatureHeader, signature)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	created := []byte(`{"event":"asset.created","asset":{"id":"a-1","url":"https://dam.example.com/a-1.jpg","type":"image/jpeg","size":512}}`)
+
+	// STEP 2: Unsigned webhooks and unconfigured asset managers are refused
+	if w := post("generic", "sha256=00", created); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, but got %d", w.Code)
+	}
+	if w := post("cloudinary", "", created); w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d", w.Code)
+	}
+
+	// STEP 3: Database Expectations; a new asset creates linked media
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "external_assets" WHERE source = \$1 AND external_id = \$2`).
+		WithArgs("generic", "a-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "media"`).
+		WithArgs("https://dam.example.com/a-1.jpg", "image/jpeg", 512, 0, 0, "", nil, nil, "{}", "unscanned", "", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(12))
+	mock.ExpectQuery(`INSERT INTO "external_assets" \("source","external_id","media_id","created_at","updated_at"\)`).
+		WithArgs("generic", "a-1", 12, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 4: Create Request
+	w := post("generic", webhooks.Sign("dam-secret", created), created)
+	var result controllers.DAMSyncResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusOK || result.Created != 1 {
+		t.Fatalf("Expected one media created, but got %d %s", w.Code, w.Body.String())
+	}
+
+	// STEP 5: Database Expectations; deleting the asset removes the media
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "external_assets" WHERE source = \$1 AND external_id = \$2`).
+		WithArgs("generic", "a-1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "source", "external_id", "media_id"}).AddRow(1, "generic", "a-1", 12))
+	mock.ExpectExec(`DELETE FROM "media" WHERE "media"."id" = \$1`).
+		WithArgs(12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 6: Delete Request
+	deleted := []byte(`{"event":"asset.deleted","asset":{"id":"a-1"}}`)
+	w = post("generic", webhooks.Sign("dam-secret", deleted), deleted)
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || w.Code != http.StatusOK || result.Deleted != 1 {
+		t.Fatalf("Expected one media deleted, but got %d %s", w.Code, w.Body.String())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
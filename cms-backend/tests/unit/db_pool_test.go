@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"testing"
+	"time"
+)
+
+func TestPoolConfigFromEnv(t *testing.T) {
+	// STEP 1: Defaults apply when nothing is configured
+	config, err := utils.PoolConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Expected default pool config, but got %v", err)
+	}
+	if config != utils.DefaultPoolConfig {
+		t.Fatalf("Expected %+v, but got %+v", utils.DefaultPoolConfig, config)
+	}
+
+	// STEP 2: Environment overrides
+	t.Setenv("DB_MAX_OPEN_CONNS", "50")
+	t.Setenv("DB_MAX_IDLE_CONNS", "0")
+	t.Setenv("DB_CONN_MAX_LIFETIME", "1h")
+	config, err = utils.PoolConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Expected pool config, but got %v", err)
+	}
+	if config.MaxOpenConns != 50 || config.MaxIdleConns != 0 || config.ConnMaxLifetime != time.Hour {
+		t.Fatalf("Expected overrides to apply, but got %+v", config)
+	}
+
+	// STEP 3: Invalid values are rejected
+	t.Setenv("DB_CONN_MAX_IDLE_TIME", "soon")
+	if _, err := utils.PoolConfigFromEnv(); err == nil {
+		t.Fatalf("Expected an invalid duration to be rejected")
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetDeliveryPostsPublishedOnly(t *testing.T) {
+	// STEP 1: Test Setup; the caller's key is ignored, so editors get the public view
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/delivery/posts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	}, controllers.GetDeliveryPosts)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$2\) AND posts\.visibility <> \$3 ORDER BY posts\.created_at DESC,posts\.id DESC LIMIT \$4`).
+		WithArgs("published", sqlmock.AnyArg(), "members", 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "notes", "created_at", "updated_at"}).
+			AddRow(1, "Launch", "published", "Internal note", now, now))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/delivery/posts?include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "Internal note") {
+		t.Errorf("Expected editorial notes to be redacted, but got %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetDeliveryPageBySlug(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/delivery/pages/:slug", controllers.GetDeliveryPage)
+
+	// STEP 2: Database Expectations; drafts are not found
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE pages\.slug = \$1 AND pages\.status = \$2 AND \(pages\.embargoed_until IS NULL OR pages\.embargoed_until <= \$3\) AND pages\.visibility <> \$4 ORDER BY "pages"\."id" LIMIT \$5`).
+		WithArgs("about", "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/delivery/pages/about", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetDeliveryPostsForMembers(t *testing.T) {
+	// STEP 1: Test Setup; member tokens are given the members audience
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/delivery/posts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Reader", Role: auth.RoleMember, KeyID: 9})
+	}, middleware.Audience(), controllers.GetDeliveryPosts)
+
+	// STEP 2: Database Expectations; members-only posts are not filtered out,
+	// embargoed ones still are
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$2\) ORDER BY`).
+		WithArgs("published", sqlmock.AnyArg(), 21).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "visibility"}).
+			AddRow(1, "Premium", "published", "members"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/delivery/posts?include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "Premium") {
+		t.Fatalf("Expected the members-only post, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetDeliveryPostCanonicalLink(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/delivery/posts/:slug", controllers.GetDeliveryPost)
+
+	// STEP 2: Database Expectations; the post is republished from a partner site
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.slug = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4 ORDER BY "posts"\."id" LIMIT \$5`).
+		WithArgs("launch", "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "slug", "status", "canonical_url", "syndication_source", "created_at", "updated_at"}).
+			AddRow(1, "Launch", "launch", "published", "https://partner.example/launch", "Partner Weekly", now, now))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/delivery/posts/launch?include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if link := w.Header().Get("Link"); link != `<https://partner.example/launch>; rel="canonical"` {
+		t.Errorf("Expected a canonical Link header, but got %q", link)
+	}
+	if !strings.Contains(w.Body.String(), `"syndication_source":"Partner Weekly"`) {
+		t.Errorf("Expected the syndication source in the response, but got %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
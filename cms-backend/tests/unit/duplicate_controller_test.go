@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDuplicatePost(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "title", "content", "author", "slug", "status", "created_at", "updated_at"}).
+		AddRow(1, "Launch Notes", "Template content", "Editor", "launch-notes", "published", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}).AddRow(1, 5))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE "media"\."id" = \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type", "created_at", "updated_at"}).
+			AddRow(5, "https://example.com/banner.png", "image", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "slug" FROM "posts" WHERE slug = \$1 OR slug LIKE \$2`).
+		WithArgs("launch-notes-copy", "launch-notes-copy-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectQuery(`INSERT INTO "posts" \("title","content","reading_time","author","author_email","slug","locale","canonical_url","syndication_source","status","expires_at","visibility","embargoed_until","notes","featured","position","owner_group_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19\) RETURNING "uuid","id"`).
+		WithArgs("Launch Notes (copy)", "Template content", 0, "Editor", "", "launch-notes-copy", "", "", "", "draft", nil, "", nil, "", false, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", 2))
+	mock.ExpectExec(`INSERT INTO "post_media" \("post_id","media_id"\) VALUES \(\$1,\$2\) ON CONFLICT DO NOTHING`).
+		WithArgs(2, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 2, "created", nil, "", "", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/posts/:id/duplicate", controllers.DuplicatePost)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/duplicate", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/api/v1/posts/2" {
+		t.Fatalf("Expected Location '/api/v1/posts/2', but got '%s'", location)
+	}
+
+	var response models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Status != models.StatusDraft {
+		t.Fatalf("Expected status 'draft', but got '%s'", response.Status)
+	}
+	if len(response.Media) != 1 || response.Media[0].ID != 5 {
+		t.Fatalf("Expected media to be copied, but got %v", response.Media)
+	}
+}
+
+func TestDuplicatePageNotFound(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1 ORDER BY "pages"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}))
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/pages/:id/duplicate", controllers.DuplicatePage)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/pages/1/duplicate", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d", w.Code)
+	}
+}
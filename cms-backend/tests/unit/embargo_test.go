@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/scheduler"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetEmbargoedPostRecordsAccess(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/embargoed/:token", controllers.GetEmbargoedPost)
+	token := "emb_0123456789abcdef"
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	publishAt := now.Add(24 * time.Hour)
+	mock.ExpectQuery(`SELECT \* FROM "embargo_links" WHERE token_hash = \$1`).
+		WithArgs(auth.HashKey(token), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "embargo_id", "member_id", "access_count"}).AddRow(7, 3, 5, 0))
+	mock.ExpectQuery(`SELECT \* FROM "embargoes" WHERE "embargoes"\."id" = \$1`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "publish_at"}).AddRow(3, 1, publishAt))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "status", "notes"}).
+			AddRow(1, "Quarterly results", "Content", "draft", "Hold until the call"))
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "embargo_links" SET "access_count"=access_count \+ 1,"first_accessed_at"=COALESCE\(first_accessed_at, \$1\),"last_accessed_at"=\$2 WHERE "id" = \$3`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/embargoed/"+token, nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation; press contacts never see editorial notes
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.EmbargoedPost
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Post.ID != 1 || response.Post.Notes != "" || !response.PublishAt.Equal(publishAt) {
+		t.Errorf("Expected post 1 without notes and its embargo time, but got %+v", response)
+	}
+	if w.Header().Get("Cache-Control") != "private, no-store" {
+		t.Errorf("Expected embargoed content to be uncacheable, but got %q", w.Header().Get("Cache-Control"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetEmbargoedPostUnknownToken(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/embargoed/:token", controllers.GetEmbargoedPost)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "embargo_links" WHERE token_hash = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/embargoed/emb_revoked", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDistributionListRejectsInvalidEmail(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/distribution-lists", controllers.CreateDistributionList)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"name": "Trade press", "members": [{"name": "Ada", "email": "not an address"}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/distribution-lists", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDistributionListsRequireAdminOrEditor(t *testing.T) {
+	// STEP 1: Test Setup; the caller's role comes from the X-Role header
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/distribution-lists", func(c *gin.Context) {
+		if role := c.GetHeader("X-Role"); role != "" {
+			auth.SetCaller(c, auth.Caller{Name: role, Role: role, KeyID: 5})
+		}
+	}, middleware.RequireRole(auth.RoleAdmin, auth.RoleEditor), controllers.GetDistributionLists)
+	request := func(role string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/distribution-lists", nil)
+		req.Header.Set("X-Role", role)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: Anonymous callers are refused with a 401
+	if w := request(""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for an anonymous caller, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	// STEP 3: Contributors are refused with a 403
+	if w := request(auth.RoleContributor); w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403 for a contributor, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	// STEP 4: Editors read the lists
+	mock.ExpectQuery(`SELECT \* FROM "distribution_lists" ORDER BY name`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	if w := request(auth.RoleEditor); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 for an editor, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPublishEmbargoed(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "embargoes" WHERE published_at IS NULL AND publish_at <= \$1`).
+		WithArgs(now).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "post_id", "publish_at"}).AddRow(3, 1, now.Add(-time.Minute)))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id" FROM "posts" WHERE id IN \(\$1\) AND status = \$2`).
+		WithArgs(1, "draft").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "posts" SET "status"=\$1,"updated_at"=\$2 WHERE id IN \(\$3\) AND status = \$4`).
+		WithArgs("published", now, 1, "draft").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The status change is recorded in the activity trail
+	mock.ExpectQuery(`INSERT INTO "activities" .* RETURNING "id"`).
+		WithArgs("post", 1, "status_changed", nil, "", "", "draft", "published", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "embargoes" SET "published_at"=\$1,"updated_at"=\$2 WHERE id IN \(\$3\)`).
+		WithArgs(now, sqlmock.AnyArg(), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: Task Execution
+	if err := scheduler.PublishEmbargoed(context.Background(), db, now); err != nil {
+		t.Fatalf("Expected publishing to succeed, but got %v", err)
+	}
+
+	// STEP 4: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestDiffEnvironmentAgainstProduction(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/environments/:name/diff", controllers.DiffEnvironment)
+
+	// STEP 2: Database Expectations; staging holds a new post, an edited page
+	// and a page production already matches
+	mock.ExpectQuery(`SELECT \* FROM "environments" WHERE name = \$1`).
+		WithArgs("staging", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "staging"))
+	mock.ExpectQuery(`SELECT \* FROM "environment_entries" WHERE environment_id = \$1 ORDER BY entity_type, slug`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "environment_id", "entity_type", "slug", "title", "content", "status"}).
+			AddRow(1, 2, "page", "about", "About us", "Who we are", "published").
+			AddRow(2, 2, "page", "contact", "Contact", "Write to us", "published").
+			AddRow(3, 2, "post", "launch", "Launch", "It is here", "published"))
+	mock.ExpectQuery(`SELECT "slug","title","content","status","author" FROM "posts" WHERE slug IN \(\$1\)`).
+		WithArgs("launch").
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "title", "content", "status", "author"}))
+	mock.ExpectQuery(`SELECT "slug","title","content","status",'' AS author FROM "pages" WHERE slug IN \(\$1,\$2\)`).
+		WithArgs("about", "contact").
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "title", "content", "status", "author"}).
+			AddRow("about", "About", "Who we are", "draft", "").
+			AddRow("contact", "Contact", "Write to us", "published", ""))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/environments/staging/diff", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var changes []controllers.EnvironmentChange
+	if err := json.Unmarshal(w.Body.Bytes(), &changes); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	expected := []controllers.EnvironmentChange{
+		{EntityType: "page", Slug: "about", Change: "changed", Fields: []string{"title", "status"}},
+		{EntityType: "page", Slug: "contact", Change: "unchanged"},
+		{EntityType: "post", Slug: "launch", Change: "added"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %+v, but got %+v", expected, changes)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPromoteEnvironmentCreatesProductionPost(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/environments/:name/promote", controllers.PromoteEnvironment)
+
+	// STEP 2: Database Expectations; the selected post is new to production
+	mock.ExpectQuery(`SELECT \* FROM "environments" WHERE name = \$1`).
+		WithArgs("staging", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "staging"))
+	mock.ExpectQuery(`SELECT \* FROM "environment_entries" WHERE environment_id = \$1 AND \(entity_type, slug\) IN \(\(\$2,\$3\)\) ORDER BY entity_type, slug`).
+		WithArgs(2, "post", "launch").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "environment_id", "entity_type", "slug", "title", "content", "author", "status"}).
+			AddRow(3, 2, "post", "launch", "Launch", "It is here", "Jane", "published"))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "slug","title","content","status","author" FROM "posts" WHERE slug IN \(\$1\)`).
+		WithArgs("launch").
+		WillReturnRows(sqlmock.NewRows([]string{"slug", "title", "content", "status", "author"}))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE slug = \$1`).
+		WithArgs("launch", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`INSERT INTO "posts"`).
+		WithArgs("Launch", "It is here", 1, "Jane", "", "launch", "", "", "", "published", nil, "public", nil, "", false, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 9, "created", nil, "", "", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"entries": [{"entity_type": "post", "slug": "launch"}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/environments/staging/promote", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.PromoteResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.To != "production" || len(response.Promoted) != 1 || response.Promoted[0].Change != "added" {
+		t.Errorf("Expected the post to be added to production, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
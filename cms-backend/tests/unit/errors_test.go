@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func TestHandleErrors(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	router.Use(utils.HandleErrors())
+	for version := 1; version <= 2; version++ {
+		group := router.Group(fmt.Sprintf("/v%d", version), middleware.APIVersion(version))
+		group.GET("/missing", func(c *gin.Context) {
+			utils.Fail(c, utils.NotFound("Post not found"))
+		})
+		group.GET("/record", func(c *gin.Context) {
+			utils.Fail(c, gorm.ErrRecordNotFound)
+		})
+		group.GET("/invalid", func(c *gin.Context) {
+			utils.Fail(c, utils.Invalid("title is required"))
+		})
+		group.GET("/taken", func(c *gin.Context) {
+			utils.Fail(c, fmt.Errorf("saving: %w", utils.Conflict("Slug already taken")))
+		})
+		group.GET("/broken", func(c *gin.Context) {
+			utils.Fail(c, errors.New(`pq: relation "posts" does not exist`))
+		})
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: Each kind of error has its status and error code
+	tests := []struct {
+		path    string
+		status  int
+		code    string
+		message string
+	}{
+		{"/v1/missing", http.StatusNotFound, utils.ErrorCodeNotFound, "Post not found"},
+		{"/v1/record", http.StatusNotFound, utils.ErrorCodeNotFound, "Record not found"},
+		{"/v1/invalid", http.StatusBadRequest, utils.ErrorCodeValidation, "title is required"},
+		{"/v1/taken", http.StatusConflict, utils.ErrorCodeConflict, "Slug already taken"},
+		{"/v1/broken", http.StatusInternalServerError, utils.ErrorCodeInternal, "Internal server error"},
+	}
+	for _, tt := range tests {
+		w := get(tt.path)
+		var response utils.HTTPError
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: error unmarshaling response: %v", tt.path, err)
+		}
+		if w.Code != tt.status || response.ErrorCode != tt.code || response.Message != tt.message {
+			t.Errorf("%s: expected %d %s %q, but got %d %s", tt.path, tt.status, tt.code, tt.message, w.Code, w.Body.String())
+		}
+	}
+
+	// STEP 3: Database errors do not reach clients
+	if w := get("/v1/broken"); strings.Contains(w.Body.String(), "pq:") {
+		t.Errorf("Expected the database error to be hidden, but got %s", w.Body.String())
+	}
+
+	// STEP 4: Version 2 errors are enveloped
+	w := get("/v2/missing")
+	var envelope utils.Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if w.Code != http.StatusNotFound || len(envelope.Errors) != 1 || envelope.Errors[0].ErrorCode != utils.ErrorCodeNotFound {
+		t.Errorf("Unexpected v2 error envelope: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExportPostPDF(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Press Kit", "Launch details (embargoed)", "Comms Team", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/posts/:id/export.pdf", controllers.ExportPostPDF)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/export.pdf", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if contentType := w.Header().Get("Content-Type"); contentType != "application/pdf" {
+		t.Fatalf("Expected Content-Type 'application/pdf', but got '%s'", contentType)
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-1.4")) {
+		t.Fatalf("Expected a PDF document")
+	}
+	if !bytes.Contains(w.Body.Bytes(), []byte(`(Launch details \(embargoed\)) Tj`)) {
+		t.Fatalf("Expected escaped post content in the PDF")
+	}
+}
+
+func TestExportPagePDFNotFound(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1 AND pages\.status = \$2 AND \(pages\.embargoed_until IS NULL OR pages\.embargoed_until <= \$3\) AND pages\.visibility <> \$4 ORDER BY "pages"\."id" LIMIT \$5`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/pages/:id/export.pdf", controllers.ExportPagePDF)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/pages/99/export.pdf", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestReorderFeaturedPosts(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "posts" SET "featured"=\$1,"position"=\$2,"updated_at"=\$3 WHERE featured = \$4 AND id NOT IN \(\$5,\$6\)`).
+		WithArgs(false, 0, sqlmock.AnyArg(), true, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "posts" SET "featured"=\$1,"position"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(true, 1, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "posts" SET "featured"=\$1,"position"=\$2,"updated_at"=\$3 WHERE id = \$4`).
+		WithArgs(true, 2, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "content", "featured", "position", "created_at", "updated_at"}).
+		AddRow(2, "Second", "Content", true, 1, now, now).
+		AddRow(1, "First", "Content", true, 2, now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE featured = \$1 ORDER BY position,id`).
+		WithArgs(true).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// STEP 3: HTTP Test Setup
+	router.PATCH("/posts/reorder", controllers.ReorderFeaturedPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, "/posts/reorder", bytes.NewBufferString(`{"post_ids":[2,1]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var response []models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 2 || response[0].ID != 2 || response[1].ID != 1 {
+		t.Fatalf("Expected posts in order [2 1], but got %v", response)
+	}
+}
+
+func TestReorderFeaturedPostsRejectsDuplicates(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: HTTP Test Setup
+	router.PATCH("/posts/reorder", controllers.ReorderFeaturedPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPatch, "/posts/reorder", bytes.NewBufferString(`{"post_ids":[3,3]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/flags"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestEvaluateFlag(t *testing.T) {
+	editor := auth.Caller{Name: "ed", Role: auth.RoleEditor, KeyID: 2}
+	cases := []struct {
+		name     string
+		flag     models.FeatureFlag
+		expected bool
+	}{
+		{"disabled", models.FeatureFlag{Name: "graphql", Percentage: 100}, false},
+		{"fully rolled out", models.FeatureFlag{Name: "graphql", Enabled: true, Percentage: 100}, true},
+		{"not rolled out", models.FeatureFlag{Name: "graphql", Enabled: true}, false},
+		{"role granted", models.FeatureFlag{Name: "graphql", Enabled: true, Roles: "admin, editor"}, true},
+	}
+	for _, tc := range cases {
+		if on := flags.Evaluate(tc.flag, editor, "key:2"); on != tc.expected {
+			t.Errorf("%s: expected %v, but got %v", tc.name, tc.expected, on)
+		}
+	}
+
+	// A partial rollout is stable per subject and covers about its percentage
+	flag := models.FeatureFlag{Name: "block_editor", Enabled: true, Percentage: 30}
+	on := 0
+	for i := 0; i < 1000; i++ {
+		subject := fmt.Sprintf("key:%d", i)
+		first := flags.Evaluate(flag, auth.Caller{}, subject)
+		if first != flags.Evaluate(flag, auth.Caller{}, subject) {
+			t.Fatalf("Expected a stable result for %s", subject)
+		}
+		if first {
+			on++
+		}
+	}
+	if on < 250 || on > 350 {
+		t.Errorf("Expected about 300 of 1000 subjects, but got %d", on)
+	}
+}
+
+func TestRequireFlag(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	flags.Default.Invalidate()
+	defer flags.Default.Invalidate()
+	router.GET("/graphql", flags.Require("graphql"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/blocks", flags.Require("block_editor"), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	// STEP 2: Database Expectations; loaded once and then cached
+	mock.ExpectQuery(`SELECT \* FROM "feature_flags"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "enabled", "percentage"}).
+			AddRow(1, "graphql", true, 100).
+			AddRow(2, "block_editor", false, 100))
+
+	// STEP 3: Requests and Response Validation
+	for path, expected := range map[string]int{"/graphql": http.StatusNoContent, "/blocks": http.StatusNotFound} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("Expected status %d for %s, but got %d", expected, path, w.Code)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
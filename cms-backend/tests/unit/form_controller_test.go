@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/captcha"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// stubCaptcha accepts only the token "human"
+type stubCaptcha struct{}
+
+func (stubCaptcha) Field() string { return "h-captcha-response" }
+
+func (stubCaptcha) Verify(ctx context.Context, token, remoteIP string) (bool, error) {
+	return token == "human", nil
+}
+
+// expectContactForm expects the active "contact" form with its fields to be loaded
+func expectContactForm(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT \* FROM "forms" WHERE slug = \$1 AND active = \$2`).
+		WithArgs("contact", true, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "honeypot", "active"}).
+			AddRow(1, "Contact", "contact", "website", true))
+	mock.ExpectQuery(`SELECT \* FROM "form_fields" WHERE "form_fields"\."form_id" = \$1 ORDER BY position`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "form_id", "position", "name", "type", "required", "max_length", "options"}).
+			AddRow(1, 1, 0, "email", "email", true, 0, "").
+			AddRow(2, 1, 1, "topic", "select", false, 0, "sales, support").
+			AddRow(3, 1, 2, "message", "textarea", true, 20, ""))
+}
+
+func submitForm(router http.Handler, contentType string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/forms/contact/submissions", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", contentType)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSubmitForm(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/forms/:slug/submissions", controllers.SubmitForm)
+
+	// STEP 2: Database Expectations; only defined fields are stored
+	expectContactForm(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "form_submissions" \("form_id","data","ip","user_agent","status","spam_score","created_at"\)`).
+		WithArgs(1, `{"email":"ada@example.com","message":"Hello","topic":"support"}`, sqlmock.AnyArg(), "", "accepted", nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: An HTML form post is accepted
+	form := url.Values{"email": {"ada@example.com"}, "topic": {"support"}, "message": {" Hello "}, "extra": {"dropped"}}
+	w := submitForm(router, "application/x-www-form-urlencoded", []byte(form.Encode()))
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSubmitFormRejectsInvalidValues(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/forms/:slug/submissions", controllers.SubmitForm)
+
+	// STEP 2: Database Expectations; nothing is stored
+	expectContactForm(mock)
+
+	// STEP 3: HTTP Test Setup
+	jsonData, _ := json.Marshal(map[string]interface{}{"email": "not an address", "topic": "billing", "message": strings.Repeat("x", 21)})
+	w := submitForm(router, "application/json", jsonData)
+
+	// STEP 4: Every invalid field is reported
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+	var response controllers.FormSubmissionError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ErrorCode != utils.ErrorCodeInvalidSubmission || len(response.Fields) != 3 {
+		t.Fatalf("Expected 3 invalid fields, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSubmitFormSpamProtection(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/forms/:slug/submissions", controllers.SubmitForm)
+	captcha.Default = stubCaptcha{}
+	defer func() { captcha.Default = captcha.Noop{} }()
+	valid := map[string]interface{}{"email": "ada@example.com", "message": "Hello"}
+
+	// STEP 2: A filled-in honeypot looks accepted but stores nothing
+	expectContactForm(mock)
+	jsonData, _ := json.Marshal(map[string]interface{}{"email": "ada@example.com", "message": "Hello", "website": "http://spam.example"})
+	if w := submitForm(router, "application/json", jsonData); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", w.Code)
+	}
+
+	// STEP 3: A missing captcha token is refused
+	expectContactForm(mock)
+	jsonData, _ = json.Marshal(valid)
+	w := submitForm(router, "application/json", jsonData)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), utils.ErrorCodeCaptchaFailed) {
+		t.Fatalf("Expected a captcha failure, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	// STEP 4: The provider's own token field is accepted
+	expectContactForm(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "form_submissions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	valid["h-captcha-response"] = "human"
+	jsonData, _ = json.Marshal(valid)
+	if w := submitForm(router, "application/json", jsonData); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateFormValidatesFields(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/forms", controllers.CreateForm)
+
+	// STEP 2: A select field without options is refused before any query
+	jsonData, _ := json.Marshal(map[string]interface{}{
+		"name":   "Contact",
+		"fields": []map[string]interface{}{{"name": "email", "type": "email"}, {"name": "topic", "type": "select"}},
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/forms", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "fields[1]") {
+		t.Fatalf("Expected a 400 naming fields[1], but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExportFormSubmissions(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/forms/:id/submissions/export.csv", controllers.ExportFormSubmissions)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "forms" WHERE "forms"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow(1, "Contact", "contact"))
+	mock.ExpectQuery(`SELECT \* FROM "form_fields" WHERE "form_fields"\."form_id" = \$1 ORDER BY position`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "form_id", "name", "type"}).
+			AddRow(1, 1, "email", "email").
+			AddRow(2, 1, "message", "textarea"))
+	submitted := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "form_submissions" WHERE form_id = \$1 AND status <> \$2 ORDER BY "form_submissions"\."id" LIMIT \$3`).
+		WithArgs(1, "spam", 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "form_id", "data", "ip", "user_agent", "created_at"}).
+			AddRow(7, 1, `{"email":"ada@example.com","message":"=HYPERLINK(\"x\")"}`, "203.0.113.9", "Firefox", submitted))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/forms/1/submissions/export.csv", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Formulas are neutralised so spreadsheets show them as text
+	expected := "id,submitted_at,email,message,ip,user_agent\n" +
+		"7,2024-03-01T12:00:00Z,ada@example.com,\"'=HYPERLINK(\"\"x\"\")\",203.0.113.9,Firefox\n"
+	if w.Code != http.StatusOK || w.Body.String() != expected {
+		t.Fatalf("Expected CSV %q, but got %d: %q", expected, w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), `attachment; filename="contact-submissions.csv"`) {
+		t.Errorf("Unexpected Content-Disposition %q", w.Header().Get("Content-Disposition"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
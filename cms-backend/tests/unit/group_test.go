@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateGroupRejectsDuplicateName(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/groups", controllers.CreateGroup)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "groups" WHERE name = \$1 AND id <> \$2`).
+		WithArgs("Marketing", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/groups", bytes.NewBufferString(`{"name": " Marketing "}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddGroupMemberRequiresExistingKey(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/groups/:id/members", controllers.AddGroupMember)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "groups" WHERE "groups"\."id" = \$1`).
+		WithArgs("2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+			AddRow(2, "Marketing", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE "api_keys"\."id" = \$1`).
+		WithArgs(40, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/groups/2/members", bytes.NewBufferString(`{"api_key_id": 40}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeletePostAllowedThroughGroupGrant(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.DELETE("/posts/:id", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	}, controllers.NewPostHandler(controllers.Deps{}).Delete)
+
+	// STEP 2: Database Expectations; the post is restricted to group 2, which key 7 belongs to
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Campaign", "draft", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "principal_type", "principal_id", "permission"}).
+			AddRow(1, "post", 1, "group", 2, "write"))
+	mock.ExpectQuery(`SELECT "group_id" FROM "group_members" WHERE api_key_id = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"group_id"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM "acl_entries" WHERE entity_type = \$1 AND entity_id = \$2`).
+		WithArgs("post", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 1, "deleted", 7, "Jane", "", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/posts/1", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
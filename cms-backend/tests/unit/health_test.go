@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"cms-backend/health"
+	"cms-backend/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadinessReportsDegradedMode(t *testing.T) {
+	// STEP 1: Test Setup
+	databaseStatus := health.StatusOK
+	probes := health.NewRegistry()
+	probes.Register("database", true, func(ctx context.Context) (string, string) {
+		return databaseStatus, ""
+	})
+	probes.Register("read_replicas", false, func(ctx context.Context) (string, string) {
+		return health.StatusDown, "serving reads from primary for eu-west"
+	})
+	probes.AddStats("database_pool", func() interface{} {
+		return utils.NewPoolStats(sql.DBStats{MaxOpenConnections: 25, InUse: 3})
+	})
+	router := gin.New()
+	router.GET("/readyz", probes.Readiness())
+
+	ready := func() (int, health.Report) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/readyz", nil)
+		router.ServeHTTP(w, req)
+		var report health.Report
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("Error unmarshaling response: %v", err)
+		}
+		return w.Code, report
+	}
+
+	// STEP 2: A non-critical backend being down only degrades the server
+	code, report := ready()
+	if code != http.StatusOK || report.Status != health.StatusDegraded {
+		t.Fatalf("Expected 200 degraded, but got %d %s", code, report.Status)
+	}
+	if report.Components["read_replicas"].Detail == "" {
+		t.Fatalf("Expected the degraded component to explain itself")
+	}
+	pool, ok := report.Stats["database_pool"].(map[string]interface{})
+	if !ok || pool["max_open"] != float64(25) || pool["in_use"] != float64(3) {
+		t.Fatalf("Expected pool stats in the report, but got %v", report.Stats)
+	}
+
+	// STEP 3: A critical backend being down makes the server unready
+	databaseStatus = health.StatusDown
+	code, report = ready()
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusDown {
+		t.Fatalf("Expected 503 down, but got %d %s", code, report.Status)
+	}
+}
@@ -0,0 +1,165 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateIDs(t *testing.T) {
+	// STEP 1: Test Setup; the handler must not run for malformed IDs
+	router := gin.New()
+	router.Use(utils.HandleErrors(), middleware.ValidateIDs())
+	reached := false
+	handler := func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/posts/:id", handler)
+	router.GET("/posts/:id/related/:relatedId", handler)
+	router.GET("/settings/:namespace/:key", handler)
+
+	// STEP 2: Requests and expected statuses
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/posts/12", http.StatusNoContent},
+		{"/posts/abc", http.StatusBadRequest},
+		{"/posts/0", http.StatusBadRequest},
+		{"/posts/-3", http.StatusBadRequest},
+		{"/posts/99999999999", http.StatusBadRequest},
+		{"/posts/12/related/x", http.StatusBadRequest},
+		{"/settings/site/title", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		reached = false
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, tt.path, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Errorf("%s: expected status %d, but got %d", tt.path, tt.status, w.Code)
+		}
+		if reached != (tt.status == http.StatusNoContent) {
+			t.Errorf("%s: expected the handler to run only for valid IDs", tt.path)
+		}
+	}
+}
+
+func TestValidateIDsResolvesUUIDs(t *testing.T) {
+	// STEP 1: Test Setup; posts are named by UUID, groups keep integer IDs
+	t.Setenv("ID_FORMAT", "uuid")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	var got string
+	handler := func(c *gin.Context) {
+		got = c.Param("id")
+		c.Status(http.StatusNoContent)
+	}
+	router.GET("/posts/:id", middleware.ValidateIDs(), handler)
+	router.GET("/groups/:id", middleware.ValidateIDs(), handler)
+	request := func(path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: A known UUID is swapped for the post's ID
+	const known = "1b4e28ba-2fa1-41d2-883f-0016d3cca427"
+	mock.ExpectQuery(`SELECT "id" FROM "posts" WHERE uuid = \$1 LIMIT \$2`).
+		WithArgs(known, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	if code := request("/posts/" + known); code != http.StatusNoContent || got != "7" {
+		t.Fatalf("Expected the post ID 7, but got %d with %q", code, got)
+	}
+
+	// STEP 3: An unknown UUID is not found and integers are refused
+	mock.ExpectQuery(`SELECT "id" FROM "posts"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if code := request("/posts/00000000-0000-4000-8000-000000000000"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", code)
+	}
+	if code := request("/posts/12"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, but got %d", code)
+	}
+
+	// STEP 4: Other records keep integer IDs
+	if code := request("/groups/12"); code != http.StatusNoContent || got != "12" {
+		t.Errorf("Expected group 12 to pass through, but got %d with %q", code, got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUUIDModeHidesIntegerIDs(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("ID_FORMAT", "uuid")
+	const known = "1b4e28ba-2fa1-41d2-883f-0016d3cca427"
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+	router.POST("/posts/:id/related", controllers.AddRelatedPosts)
+	request := func(method, path, body string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: Responses carry the UUID as the id
+	post := models.Post{ID: 7, UUID: known, Title: "Launch", Media: []models.Media{{ID: 3, UUID: known}}}
+	for _, value := range []interface{}{post, serializers.Post(auth.Caller{}, post)} {
+		body, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(body), `"id":"`+known+`"`) || strings.Contains(string(body), `"id":7`) || strings.Contains(string(body), `"id":3`) {
+			t.Errorf("Expected only UUIDs as ids, but got %s", body)
+		}
+	}
+
+	// STEP 3: Integer IDs are refused in ?ids= and request bodies
+	if code := request(http.MethodGet, "/posts?ids=3,4", ""); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for integer ids, but got %d", code)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(7, "Launch"))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+	if code := request(http.MethodPost, "/posts/7/related", `{"post_ids": [3]}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for integer post_ids, but got %d", code)
+	}
+
+	// STEP 4: UUIDs naming no post are reported
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(7, "Launch"))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+	mock.ExpectQuery(`SELECT "id","uuid" FROM "posts" WHERE uuid IN \(\$1\)`).
+		WithArgs("00000000-0000-4000-8000-000000000000").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "uuid"}))
+	if code := request(http.MethodPost, "/posts/7/related", `{"post_ids": ["00000000-0000-4000-8000-000000000000"]}`); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an unknown UUID, but got %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
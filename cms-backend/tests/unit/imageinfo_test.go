@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/imageinfo"
+	"cms-backend/utils"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// withOrientation inserts an EXIF segment carrying orientation into a JPEG
+func withOrientation(t *testing.T, jpg []byte, orientation byte) []byte {
+	t.Helper()
+	// Big-endian TIFF header, one IFD entry: tag 0x0112, type SHORT, count 1
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0, 0, 0, 0, 0, 0, 0}
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func TestDimensionsFollowOrientation(t *testing.T) {
+	// STEP 1: Test Setup; a 40x20 JPEG taken with the camera turned sideways
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+	rotated := withOrientation(t, buf.Bytes(), 6)
+
+	// STEP 2: Measuring
+	width, height, err := imageinfo.Dimensions(rotated)
+	if err != nil {
+		t.Fatalf("Expected the JPEG to be measured, but got %v", err)
+	}
+
+	// STEP 3: Result Validation
+	if imageinfo.Orientation(rotated) != 6 {
+		t.Errorf("Expected orientation 6, but got %d", imageinfo.Orientation(rotated))
+	}
+	if width != 20 || height != 40 {
+		t.Errorf("Expected 20x40, but got %dx%d", width, height)
+	}
+}
+
+func TestCreateMediaRecordsImageDimensions(t *testing.T) {
+	// STEP 1: Test Setup; the image is served locally, so private addresses are allowed
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/media", controllers.NewMediaHandler(controllers.Deps{}).Create)
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	t.Setenv("MEDIA_IMAGE_DIMENSIONS", "true")
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 64, 48)))
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(img.Bytes())
+	}))
+	defer files.Close()
+
+	// STEP 2: Database Expectations
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "media"`).
+		WithArgs(files.URL+"/photo.png", "image/png", 0, 64, 48, "", nil, nil, "{}", "unscanned", "", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/photo.png", "type": "image/png"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+// gpsIFD is a big-endian GPS IFD holding GPSLatitudeRef "N", placed at offset
+// 38 of the TIFF structure built by withGPS
+var gpsIFD = []byte{0, 1, 0, 1, 0, 2, 0, 0, 0, 2, 'N', 0, 0, 0, 0, 0, 0, 0}
+
+// withGPS inserts an EXIF segment carrying orientation and a GPS position
+// into a JPEG
+func withGPS(t *testing.T, jpg []byte, orientation byte) []byte {
+	t.Helper()
+	// IFD0 has two entries: the orientation and a pointer to the GPS IFD
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 2,
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, orientation, 0, 0,
+		0x88, 0x25, 0, 4, 0, 0, 0, 1, 0, 0, 0, 38,
+		0, 0, 0, 0}
+	tiff = append(tiff, gpsIFD...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := append([]byte{0xFF, 0xE1, byte((len(payload) + 2) >> 8), byte(len(payload) + 2)}, payload...)
+	return append(append(append([]byte{}, jpg[:2]...), segment...), jpg[2:]...)
+}
+
+func TestNormalizeRemovesGPSPosition(t *testing.T) {
+	// STEP 1: Test Setup; a 40x20 JPEG recording where it was taken
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatalf("Failed to encode JPEG: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name          string
+		orientation   byte
+		width, height int
+	}{
+		{"upright", 1, 40, 20},
+		{"sideways", 6, 20, 40},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tagged := withGPS(t, buf.Bytes(), tc.orientation)
+			if !bytes.Contains(tagged, gpsIFD) {
+				t.Fatalf("Expected the test image to carry a GPS position")
+			}
+
+			// STEP 2: Normalizing
+			normalized, err := imageinfo.Normalize(tagged)
+			if err != nil {
+				t.Fatalf("Expected the JPEG to be normalized, but got %v", err)
+			}
+
+			// STEP 3: Result Validation; no EXIF is left and the image is upright
+			if bytes.Contains(normalized, gpsIFD) || bytes.Contains(normalized, []byte("Exif\x00\x00")) {
+				t.Fatalf("Expected the EXIF and GPS tags to be removed")
+			}
+			config, err := jpeg.DecodeConfig(bytes.NewReader(normalized))
+			if err != nil {
+				t.Fatalf("Expected a valid JPEG, but got %v", err)
+			}
+			if config.Width != tc.width || config.Height != tc.height {
+				t.Errorf("Expected %dx%d, but got %dx%d", tc.width, tc.height, config.Width, config.Height)
+			}
+			if imageinfo.Orientation(normalized) != 1 {
+				t.Errorf("Expected no orientation, but got %d", imageinfo.Orientation(normalized))
+			}
+		})
+	}
+}
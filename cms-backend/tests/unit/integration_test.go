@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/integrations"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestIntegrationPresetsRender(t *testing.T) {
+	// STEP 1: Test Setup; a deleted entity has no fields to map
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	deleted := integrations.Data{
+		Payload: webhooks.NewPayload(
+			models.Change{Seq: 9, EntityType: "post", EntityID: 7, Action: models.ChangeDelete, ChangedAt: now},
+			webhooks.Event{Entity: "post", Action: models.ChangeDelete, ID: 7},
+		),
+		Entity: map[string]interface{}{},
+	}
+
+	// STEP 2: Every preset renders JSON for the sample and for deletes
+	for _, preset := range integrations.Presets {
+		if err := integrations.ValidateTemplate(preset.Template); err != nil {
+			t.Fatalf("Expected preset %s to validate, but got %v", preset.Name, err)
+		}
+		if _, err := webhooks.RenderTemplate(preset.Template, deleted); err != nil {
+			t.Fatalf("Expected preset %s to render a delete, but got %v", preset.Name, err)
+		}
+	}
+
+	// STEP 3: The zapier preset maps entity fields to flat keys
+	preset, _ := integrations.FindPreset("zapier")
+	body, err := webhooks.RenderTemplate(preset.Template, integrations.SampleData(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		t.Fatalf("Error unmarshaling rendered preset: %v", err)
+	}
+	if fields["title"] != "Hello world" || fields["event"] != webhooks.TestEvent || fields["entity_id"] != float64(1) {
+		t.Fatalf("Expected the sample mapped onto flat fields, but got %s", body)
+	}
+}
+
+func TestTestIntegrationSendsHeaders(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	var authorization string
+	var received map[string]interface{}
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer service.Close()
+
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "integrations" WHERE "integrations"\."id" = \$1`).
+		WithArgs("4", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "url", "filter", "template", "headers", "active"}).
+			AddRow(4, "IFTTT", service.URL, "", `{"value1": {{json (index .Entity "title")}}}`, `{"Authorization": "Bearer token"}`, true))
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/admin/integrations/:id/test", controllers.TestIntegration)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/admin/integrations/4/test", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	var result webhooks.Result
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if w.Code != http.StatusOK || !result.Success {
+		t.Fatalf("Expected a successful delivery, but got %d %+v", w.Code, result)
+	}
+	if authorization != "Bearer token" {
+		t.Fatalf("Expected the integration's headers to be sent, but got Authorization %q", authorization)
+	}
+	if received["value1"] != "Hello world" {
+		t.Fatalf("Expected the templated body, but got %v", received)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateIntegrationValidation(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/admin/integrations", controllers.CreateIntegration)
+
+	cases := []struct {
+		name string
+		body string
+	}{
+		{"unknown preset", `{"name": "Zap", "url": "http://127.0.0.1/hook", "preset": "nope"}`},
+		{"missing template", `{"name": "Zap", "url": "http://127.0.0.1/hook"}`},
+		{"invalid template", `{"name": "Zap", "url": "http://127.0.0.1/hook", "template": "{{.Missing}}"}`},
+		{"reserved header", `{"name": "Zap", "url": "http://127.0.0.1/hook", "preset": "zapier", "headers": {"Host": "example.com"}}`},
+		{"multi-line header", `{"name": "Zap", "url": "http://127.0.0.1/hook", "preset": "zapier", "headers": {"X-Token": "a\r\nX-Evil: 1"}}`},
+	}
+
+	// STEP 2: Each request is rejected before touching the database
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/admin/integrations", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
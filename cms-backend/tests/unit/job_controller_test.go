@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetJob(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "type", "status", "progress", "requested_by_key_id", "payload", "error", "result_path", "created_at", "updated_at", "started_at", "finished_at"}).
+		AddRow(7, "epub_export", "completed", 100, 3, `{"series_id":1}`, "", "exports/series-1-job-7.epub", now, now, now, now)
+	mock.ExpectQuery(`SELECT \* FROM "jobs" WHERE "jobs"\."id" = \$1 ORDER BY "jobs"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(row)
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/jobs/:id", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 3})
+	}, controllers.GetJob)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/jobs/7", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var response models.Job
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Status != models.JobCompleted {
+		t.Fatalf("Expected status 'completed', but got '%s'", response.Status)
+	}
+	if response.DownloadURL != "/api/v1/jobs/7/download" {
+		t.Fatalf("Expected download URL, but got '%s'", response.DownloadURL)
+	}
+}
+
+func TestDownloadJobResultRefusesOtherKeys(t *testing.T) {
+	// STEP 1: Test Setup; the job was started by key 3, the caller is key 4
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/jobs/:id/download", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Joe", Role: auth.RoleEditor, KeyID: 4})
+	}, controllers.DownloadJobResult)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "jobs" WHERE "jobs"\."id" = \$1 ORDER BY "jobs"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "type", "status", "progress", "requested_by_key_id", "result_path", "created_at", "updated_at"}).
+			AddRow(7, "epub_export", "completed", 100, 3, "exports/series-1-job-7.epub", now, now))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/jobs/7/download", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation; the job is reported as not found
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"cms-backend/jsonapi"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestJSONAPIOutput(t *testing.T) {
+	// STEP 1: Test Setup; two posts sharing a tag
+	router := gin.New()
+	group := router.Group("/api/v2", middleware.APIVersion(2), middleware.JSONAPI("/api/v2"))
+	group.GET("/posts", func(c *gin.Context) {
+		c.Header(utils.NextCursorHeader, "abc")
+		c.JSON(http.StatusOK, []gin.H{
+			{"id": 1, "title": "First", "owner_group_id": nil, "media": []gin.H{{"id": 4, "type": "image", "url": "/a.png"}}, "tags": []gin.H{{"id": 9, "name": "go", "parent_id": nil}}},
+			{"id": 2, "title": "Second", "owner_group_id": 3, "media": nil, "tags": []gin.H{{"id": 9, "name": "go", "parent_id": nil}}},
+		})
+	})
+	group.GET("/posts/:id", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, utils.HTTPError{Code: http.StatusNotFound, Message: "Post not found"})
+	})
+
+	get := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: Plain JSON clients keep the enveloped response
+	if w := get("/api/v2/posts", "application/json"); w.Header().Get("Content-Type") == jsonapi.MediaType || w.Header().Get("Vary") != "Accept" {
+		t.Fatalf("Expected a plain JSON response varying on Accept, but got %v", w.Header())
+	}
+
+	// STEP 3: JSON:API clients get a compound document
+	w := get("/api/v2/posts?limit=2", "application/vnd.api+json")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != jsonapi.MediaType {
+		t.Fatalf("Expected a JSON:API response, but got %d %v", w.Code, w.Header())
+	}
+	var doc jsonapi.Document
+	var data []jsonapi.Object
+	doc.Data = &data
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(data) != 2 || data[0].Type != "posts" || data[0].ID != "1" || string(data[0].Attributes["title"]) != `"First"` {
+		t.Fatalf("Unexpected primary data: %s", w.Body.String())
+	}
+	if _, ok := data[0].Attributes["media"]; ok || data[0].Links["self"] != "/api/v2/posts/1" {
+		t.Fatalf("Expected relations out of the attributes and a self link: %+v", data[0])
+	}
+	if _, ok := data[1].Relationships["media"]; ok {
+		t.Fatalf("Expected unloaded media to be left out: %+v", data[1].Relationships)
+	}
+	if group := data[1].Relationships["owner_group"].Data.(map[string]interface{}); group["type"] != "groups" || group["id"] != "3" {
+		t.Fatalf("Unexpected owner group linkage: %+v", data[1].Relationships)
+	}
+	if len(doc.Included) != 2 || doc.Included[0].Type != "media" || string(doc.Included[0].Attributes["media_type"]) != `"image"` ||
+		doc.Included[1].Type != "tags" || doc.Included[1].ID != "9" {
+		t.Fatalf("Expected the media and the shared tag included once: %+v", doc.Included)
+	}
+	if doc.Links["next"] != "/api/v2/posts?after=abc&limit=2" {
+		t.Fatalf("Unexpected links: %+v", doc.Links)
+	}
+
+	// STEP 4: Errors become error objects
+	w = get("/api/v2/posts/7", "application/vnd.api+json")
+	if w.Code != http.StatusNotFound || w.Body.String() != `{"errors":[{"status":"404","title":"Post not found"}]}` {
+		t.Fatalf("Unexpected error document: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/linkcheck"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExtractURLs(t *testing.T) {
+	// STEP 1: Links, images and repeats; relative and mailto links are not external
+	content := "See [docs](https://example.com/docs?a=1&b=2) and ![chart](http://img.example.com/c.png).\n\n" +
+		"Again [docs](https://example.com/docs?a=1&b=2), [about](/about), [mail](mailto:a@example.com)\n\n" +
+		"`[code](https://example.com/not-a-link)`"
+
+	// STEP 2: Extraction
+	urls := linkcheck.ExtractURLs(content)
+
+	// STEP 3: Validation
+	expected := []string{"https://example.com/docs?a=1&b=2", "http://img.example.com/c.png"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Fatalf("Expected %v, but got %v", expected, urls)
+	}
+}
+
+func TestCheckRecordsBrokenLinks(t *testing.T) {
+	// STEP 1: Test Setup; the server answers HEAD with 405 like some sites do
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	checker := &linkcheck.Checker{Client: server.Client(), Interval: time.Hour}
+	now := time.Now()
+
+	// STEP 2: Database Expectations; every link to the URL gets the result
+	mock.ExpectQuery(`SELECT "url" FROM "links" WHERE checked_at IS NULL OR checked_at < \$1 GROUP BY "url" ORDER BY MIN\(COALESCE\(checked_at, '-infinity'\)\) LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"url"}).AddRow(server.URL + "/gone"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "links" SET "broken"=\$1,"checked_at"=\$2,"error"=\$3,"status_code"=\$4 WHERE url = \$5`).
+		WithArgs(true, now, "", 404, server.URL+"/gone").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// STEP 3: Check
+	if err := checker.Check(context.Background(), db, now); err != nil {
+		t.Fatalf("Expected the check to succeed, but got %v", err)
+	}
+
+	// STEP 4: HEAD fell back to GET
+	if !reflect.DeepEqual(methods, []string{http.MethodHead, http.MethodGet}) {
+		t.Errorf("Expected HEAD then GET, but got %v", methods)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBrokenLinks(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/reports/broken-links", controllers.GetBrokenLinks)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "links" WHERE broken = \$1 AND entity_type = \$2 ORDER BY entity_type,entity_id,url`).
+		WithArgs(true, "post").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id", "url", "status_code", "broken"}).
+			AddRow(1, "post", 3, "https://example.com/gone", 404, true))
+	mock.ExpectQuery(`SELECT "id","title" FROM "posts" WHERE id IN \(\$1\)`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(3, "Launch"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/reports/broken-links?entity_type=post", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var response []controllers.BrokenLink
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 1 || response[0].Title != "Launch" || response[0].StatusCode != 404 {
+		t.Fatalf("Unexpected report %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/lockout"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLockoutBackoff(t *testing.T) {
+	// STEP 1: Test Setup; three failures lock a client out for a minute
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := lockout.New(lockout.Config{Threshold: 3, Window: 15 * time.Minute, Duration: time.Minute, MaxDuration: 3 * time.Minute})
+	tracker.Now = func() time.Time { return now }
+	fail := func(times int) (time.Duration, bool) {
+		var duration time.Duration
+		var locked bool
+		for i := 0; i < times; i++ {
+			duration, locked = tracker.Fail("203.0.113.9", "cms_mgt_guess")
+		}
+		return duration, locked
+	}
+
+	// STEP 2: The first lockout lasts a minute
+	if _, locked := fail(2); locked {
+		t.Fatalf("Expected two failures not to lock the client out")
+	}
+	if duration, locked := fail(1); !locked || duration != time.Minute {
+		t.Fatalf("Expected a one minute lockout, but got %s, %v", duration, locked)
+	}
+	if _, locked := tracker.Locked("203.0.113.9", ""); !locked {
+		t.Fatalf("Expected the client to be locked out")
+	}
+
+	// STEP 3: Each further lockout doubles, up to the maximum
+	now = now.Add(2 * time.Minute)
+	if duration, _ := fail(3); duration != 2*time.Minute {
+		t.Fatalf("Expected a two minute lockout, but got %s", duration)
+	}
+	now = now.Add(3 * time.Minute)
+	if duration, _ := fail(3); duration != 3*time.Minute {
+		t.Fatalf("Expected the lockout capped at three minutes, but got %s", duration)
+	}
+
+	// STEP 4: Admins see and lift the lockout
+	lockouts := tracker.Lockouts()
+	if len(lockouts) != 2 || lockouts[0].Lockouts != 3 || lockouts[0].LockedUntil == nil {
+		t.Fatalf("Unexpected lockouts %+v", lockouts)
+	}
+	if !tracker.Unlock("203.0.113.9") || !tracker.Unlock("cms_mgt_guess") {
+		t.Fatalf("Expected the client and key to be unlocked")
+	}
+	if _, locked := tracker.Locked("203.0.113.9", ""); locked {
+		t.Fatalf("Expected the lockout to be lifted")
+	}
+}
+
+func TestLockoutCannotBeReset(t *testing.T) {
+	// STEP 1: Test Setup; three failures lock a client out
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker := lockout.New(lockout.Config{Threshold: 3, Window: 15 * time.Minute, Duration: time.Minute, MaxDuration: time.Hour})
+	tracker.Now = func() time.Time { return now }
+
+	// STEP 2: A valid public key does not clear guesses at other keys
+	tracker.Fail("203.0.113.9", "cms_mgt_aaaaaaaa")
+	tracker.Fail("203.0.113.9", "cms_mgt_bbbbbbbb")
+	tracker.Succeed("203.0.113.9", "cms_dlv_public00")
+	if _, locked := tracker.Fail("203.0.113.9", "cms_mgt_cccccccc"); !locked {
+		t.Fatalf("Expected the guesses to lock the client out")
+	}
+
+	// STEP 3: A holder who mistyped their own key starts afresh
+	tracker.Fail("198.51.100.7", "cms_mgt_mine0000")
+	tracker.Fail("198.51.100.7", "cms_mgt_mine0000")
+	tracker.Succeed("198.51.100.7", "cms_mgt_mine0000")
+	if _, locked := tracker.Fail("198.51.100.7", "cms_mgt_other000"); locked {
+		t.Fatalf("Expected the mistyped key's failures to be forgotten")
+	}
+
+	// STEP 4: Guesses at one key from many addresses lock the key out
+	for i := 1; i <= 3; i++ {
+		tracker.Fail(fmt.Sprintf("192.0.2.%d", i), "cms_mgt_target00")
+	}
+	if _, locked := tracker.Locked("192.0.2.99", "cms_mgt_target00"); !locked {
+		t.Fatalf("Expected the key to be locked out from every address")
+	}
+
+	// STEP 5: Spoofed addresses cannot grow the tracker without bound
+	for i := 0; i < 12000; i++ {
+		tracker.Fail(fmt.Sprintf("10.%d.%d.%d", i>>16, (i>>8)&255, i&255), "cms_mgt_spoofed0")
+	}
+	if n := len(tracker.Lockouts()); n > 10000+2 {
+		t.Fatalf("Expected at most 10000 addresses to be tracked, but got %d", n)
+	}
+	if _, locked := tracker.Locked("203.0.113.9", ""); !locked {
+		t.Fatalf("Expected the lockout to survive eviction of older failures")
+	}
+}
+
+func TestAuthenticateLocksOutGuessing(t *testing.T) {
+	// STEP 1: Test Setup; one invalid key locks the client out
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	lockout.Default = lockout.New(lockout.Config{Threshold: 1, Window: time.Minute, Duration: time.Minute, MaxDuration: time.Hour})
+	defer func() { lockout.Default = nil }()
+	router.GET("/whoami", middleware.Authenticate(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, auth.CallerFrom(c))
+	})
+	request := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/whoami", nil)
+		req.Header.Set("Authorization", "Bearer cms_guess")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: The first guess is looked up and refused
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE key_hash = \$1`).
+		WithArgs(auth.HashKey("cms_guess"), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	if w := request(); w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, but got %d", w.Code)
+	}
+
+	// STEP 3: The next is turned away without a lookup
+	w := request()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "60" {
+		t.Fatalf("Expected status 429 with Retry-After 60, but got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"cms-backend/mailer"
+	"cms-backend/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderDigestEscapesContent(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("SITE_NAME", "Newsroom")
+	data := mailer.DigestData{
+		Name: "Jane",
+		Notifications: []models.Notification{
+			{Message: `freelancer submitted "<script>" for review`, CreatedAt: time.Now()},
+			{Message: "You were granted write access to post 3", CreatedAt: time.Now()},
+		},
+	}
+
+	// STEP 2: Rendering
+	msg, err := mailer.Render(mailer.TemplateDigest, data, "jane@example.com")
+	if err != nil {
+		t.Fatalf("Expected the digest to render, but got %v", err)
+	}
+
+	// STEP 3: Message Validation
+	if msg.Subject != "You have 2 unread notifications" {
+		t.Errorf("Unexpected subject %q", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<script>") {
+		t.Errorf("Expected notification messages to be escaped")
+	}
+	if !strings.Contains(msg.HTML, "Newsroom") {
+		t.Errorf("Expected the site name in the layout")
+	}
+	if len(msg.To) != 1 || msg.To[0] != "jane@example.com" {
+		t.Errorf("Unexpected recipients %v", msg.To)
+	}
+}
+
+func TestSESSendsSignedRequest(t *testing.T) {
+	// STEP 1: Test Setup; a stand-in for the SES endpoint
+	var payload map[string]interface{}
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&payload)
+		w.Write([]byte(`{"MessageId":"1"}`))
+	}))
+	defer server.Close()
+	ses := &mailer.SES{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		From:            "cms@example.com",
+		Endpoint:        server.URL,
+	}
+
+	// STEP 2: Sending
+	err := ses.Send(context.Background(), mailer.Message{To: []string{"jane@example.com"}, Subject: "Hello", HTML: "<p>Hi</p>"})
+	if err != nil {
+		t.Fatalf("Expected the email to be sent, but got %v", err)
+	}
+
+	// STEP 3: Request Validation
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(authorization, "/eu-west-1/ses/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=") {
+		t.Errorf("Unexpected Authorization header %q", authorization)
+	}
+	if payload["FromEmailAddress"] != "cms@example.com" {
+		t.Errorf("Unexpected payload %v", payload)
+	}
+}
+
+func TestMailerRejectsHeaderInjection(t *testing.T) {
+	// STEP 1: Sending; a subject with a line break could add headers
+	err := mailer.Log{}.Send(context.Background(), mailer.Message{
+		To:      []string{"jane@example.com"},
+		Subject: "Hello\r\nBcc: everyone@example.com",
+	})
+
+	// STEP 2: Result Validation
+	if err == nil {
+		t.Fatalf("Expected a subject with a line break to be rejected")
+	}
+}
@@ -1,17 +1,29 @@
 package controllers
 
 import (
+	"archive/zip"
 	"bytes"
+	"cms-backend/auth"
 	"cms-backend/controllers"
 	"cms-backend/models"
+	"cms-backend/storage"
 	"cms-backend/utils"
 	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
 )
 
 func TestGetMedia(t *testing.T) {
@@ -29,7 +41,7 @@ func TestGetMedia(t *testing.T) {
 	mock.ExpectQuery(`SELECT \* FROM "media"`).WillReturnRows(rows)
 
 	// HTTP Test Setup
-	router.GET("/media", controllers.GetMedia)
+	router.GET("/media", controllers.NewMediaHandler(controllers.Deps{}).List)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/media", nil)
 	router.ServeHTTP(w, req)
@@ -48,6 +60,38 @@ func TestGetMedia(t *testing.T) {
 	}
 }
 
+func TestGetMediaByIDs(t *testing.T) {
+	// Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE scan_status <> \$1 AND id IN \(\$2,\$3\)`).
+		WithArgs("quarantined", 2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type", "created_at", "updated_at"}).
+			AddRow(1, "https://example.com/image1.jpg", "image", now, now).
+			AddRow(2, "https://example.com/video1.mp4", "video", now, now))
+
+	// HTTP Test Setup
+	router.GET("/media", controllers.NewMediaHandler(controllers.Deps{}).List)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/media?ids=2,1", nil)
+	router.ServeHTTP(w, req)
+
+	// Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var response []models.Media
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 2 || response[0].ID != 2 || response[1].ID != 1 {
+		t.Fatalf("Expected media 2 and 1 in request order, but got %+v", response)
+	}
+}
+
 func TestGetMediaByID(t *testing.T) {
 	// Test Setup
 	router, _, mock := utils.SetupRouterAndMockDB(t)
@@ -59,12 +103,12 @@ func TestGetMediaByID(t *testing.T) {
 		AddRow(1, "https://example.com/test.jpg", "image", now, now)
 
 	// Database Expectations
-	mock.ExpectQuery(`SELECT \* FROM "media" WHERE "media"\."id" = \$1 ORDER BY "media"\."id" LIMIT \$2`).
-		WithArgs(sqlmock.AnyArg(), 1).
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE scan_status <> \$1 AND "media"\."id" = \$2 ORDER BY "media"\."id" LIMIT \$3`).
+		WithArgs("quarantined", sqlmock.AnyArg(), 1).
 		WillReturnRows(row)
 
 	// HTTP Test Setup
-	router.GET("/media/:id", controllers.GetMediaByID)
+	router.GET("/media/:id", controllers.NewMediaHandler(controllers.Deps{}).Get)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/media/1", nil)
 	router.ServeHTTP(w, req)
@@ -94,12 +138,13 @@ func TestCreateMedia(t *testing.T) {
 	// Test Setup
 	router, _, mock := utils.SetupRouterAndMockDB(t)
 	defer mock.ExpectClose()
+	stubLookupIP(t, "93.184.216.34")
 
 	// Database Expectations
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "media" \("url","type","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING "id"`).
-		WithArgs("https://example.com/new-image.jpg", "image", sqlmock.AnyArg(), sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "media" \("url","type","size","width","height","alt_text","focal_x","focal_y","crops","scan_status","scan_signature","scanned_at","uploaded_by_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15\) RETURNING "uuid","id"`).
+		WithArgs("https://example.com/new-image.jpg", "image", 0, 0, 0, "", nil, nil, "{}", "unscanned", "", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", 1))
 	mock.ExpectCommit()
 
 	// Request Preparation
@@ -110,7 +155,7 @@ func TestCreateMedia(t *testing.T) {
 	jsonData, _ := json.Marshal(media)
 
 	// HTTP Test Setup
-	router.POST("/media", controllers.CreateMedia)
+	router.POST("/media", controllers.NewMediaHandler(controllers.Deps{}).Create)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/media", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
@@ -134,6 +179,44 @@ func TestCreateMedia(t *testing.T) {
 	}
 }
 
+func TestCreateMediaIgnoresServerFields(t *testing.T) {
+	// Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	stubLookupIP(t, "93.184.216.34")
+
+	// Database Expectations; the row gets its own ID, no verdict, size or uploader
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "media" \("url","type","size","width","height","alt_text","focal_x","focal_y","crops","scan_status","scan_signature","scanned_at","uploaded_by_id","created_at","updated_at"\)`).
+		WithArgs("https://example.com/new-image.jpg", "image", 0, 0, 0, "", nil, nil, "{}", "unscanned", "", nil, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", 1))
+	mock.ExpectCommit()
+
+	// HTTP Test Setup; the client claims an ID, a clean scan and an uploader
+	router.POST("/media", controllers.NewMediaHandler(controllers.Deps{}).Create)
+	w := httptest.NewRecorder()
+	body := `{"id": 99, "url": "https://example.com/new-image.jpg", "type": "image", "width": 4000, "height": 3000,
+		"scan_status": "clean", "scan_signature": "none", "uploaded_by_id": 7}`
+	req, _ := http.NewRequest(http.MethodPost, "/media", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.Media
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ID != 1 || response.ScanStatus != models.ScanUnscanned || response.UploadedByID != nil {
+		t.Fatalf("Expected the server-set fields to be ignored, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestDeleteMedia(t *testing.T) {
 	// Test Setup
 	router, _, mock := utils.SetupRouterAndMockDB(t)
@@ -155,7 +238,7 @@ func TestDeleteMedia(t *testing.T) {
 	mock.ExpectCommit()
 
 	// HTTP Test Setup
-	router.DELETE("/media/:id", controllers.DeleteMedia)
+	router.DELETE("/media/:id", controllers.NewMediaHandler(controllers.Deps{}).Delete)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/media/1", nil)
 	router.ServeHTTP(w, req)
@@ -175,3 +258,321 @@ func TestDeleteMedia(t *testing.T) {
 		t.Fatalf("Expected message '%s', but got '%s'", expectedMessage, response["message"])
 	}
 }
+
+func TestCreateMediaRejectsUnsafeURL(t *testing.T) {
+	// Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	stubLookupIP(t, "10.0.0.5")
+	router.POST("/media", controllers.NewMediaHandler(controllers.Deps{}).Create)
+
+	cases := []struct {
+		name string
+		url  string
+		code string
+	}{
+		{"metadata address", "http://169.254.169.254/latest/meta-data", utils.ErrorCodeUnsafeURL},
+		{"host resolving to private range", "https://intranet.example.com/logo.png", utils.ErrorCodeUnsafeURL},
+		{"unsupported scheme", "file:///etc/passwd", utils.ErrorCodeUnsafeURL},
+		{"missing host", "https:///logo.png", utils.ErrorCodeInvalidURL},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// HTTP Test Setup
+			body, _ := json.Marshal(models.Media{URL: tc.url, Type: "image"})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/media", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// Response Validation
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("Expected status 422, but got %d", w.Code)
+			}
+			var response utils.HTTPError
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Error unmarshaling response: %v", err)
+			}
+			if response.ErrorCode != tc.code {
+				t.Fatalf("Expected error code '%s', but got '%s'", tc.code, response.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestImportMedia(t *testing.T) {
+	// Test Setup; the file is served locally, so private addresses are allowed
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	dir := t.TempDir()
+	store := &storage.Local{Dir: dir, BaseURL: "https://cdn.example.com"}
+	router.POST("/media/import", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "jane", Role: auth.RoleEditor, KeyID: 3})
+	}, controllers.NewMediaHandler(controllers.Deps{Storage: store}).Import)
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)))
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/notes.txt" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("hello"))
+			return
+		}
+		w.Write(img.Bytes())
+	}))
+	defer files.Close()
+
+	// Database Expectations; the record points at the stored copy and is
+	// counted against the caller, who has no quota
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE username = \$1`).
+		WithArgs("jane", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "storage_quota"}).AddRow(4, "jane", nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "media"`).
+		WithArgs(sqlmock.AnyArg(), "image/png", img.Len(), 0, 0, "", nil, nil, "{}", "unscanned", "", nil, 4, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/photo.png"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	var media models.Media
+	if err := json.Unmarshal(w.Body.Bytes(), &media); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if !strings.HasPrefix(media.URL, "https://cdn.example.com/") || !strings.HasSuffix(media.URL, ".png") {
+		t.Fatalf("Expected a stored copy URL, but got %s", media.URL)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, path.Base(media.URL)))
+	if err != nil || !bytes.Equal(stored, img.Bytes()) {
+		t.Fatalf("Expected the fetched file to be stored, but got %v", err)
+	}
+
+	// Types outside MEDIA_IMPORT_TYPES are refused before anything is stored
+	w = httptest.NewRecorder()
+	body = `{"url": "` + files.URL + `/notes.txt"}`
+	req, _ = http.NewRequest(http.MethodPost, "/media/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("Expected status 415, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportMediaStripsImageMetadata(t *testing.T) {
+	// Test Setup; the photo is served locally, so private addresses are allowed
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	t.Setenv("MEDIA_STRIP_METADATA", "true")
+	dir := t.TempDir()
+	store := &storage.Local{Dir: dir, BaseURL: "https://cdn.example.com"}
+	router.POST("/media/import", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "jane", Role: auth.RoleEditor, KeyID: 3})
+	}, controllers.NewMediaHandler(controllers.Deps{Storage: store}).Import)
+	var img bytes.Buffer
+	jpeg.Encode(&img, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil)
+	photo := withGPS(t, img.Bytes(), 1)
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Write(photo)
+	}))
+	defer files.Close()
+
+	// Database Expectations; the size recorded is that of the stripped copy
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE username = \$1`).
+		WithArgs("jane", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "storage_quota"}).AddRow(4, "jane", nil))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "media"`).
+		WithArgs(sqlmock.AnyArg(), "image/jpeg", img.Len(), 0, 0, "", nil, nil, "{}", "unscanned", "", nil, 4, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/photo.jpg"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation; the stored copy has lost its GPS position
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	var media models.Media
+	if err := json.Unmarshal(w.Body.Bytes(), &media); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, path.Base(media.URL)))
+	if err != nil {
+		t.Fatalf("Expected the fetched file to be stored, but got %v", err)
+	}
+	if bytes.Contains(stored, gpsIFD) || !bytes.Equal(stored, img.Bytes()) {
+		t.Fatalf("Expected the stored copy to carry no metadata")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportMediaOverQuota(t *testing.T) {
+	// Test Setup; the caller's imports already take up half of their quota
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	dir := t.TempDir()
+	store := &storage.Local{Dir: dir, BaseURL: "https://cdn.example.com"}
+	router.POST("/media/import", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "jane", Role: auth.RoleEditor, KeyID: 3})
+	}, controllers.NewMediaHandler(controllers.Deps{Storage: store}).Import)
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(bytes.Repeat([]byte("a"), 80))
+	}))
+	defer files.Close()
+
+	// Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE username = \$1`).
+		WithArgs("jane", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "storage_quota"}).AddRow(4, "jane", 100))
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(size\), 0\) FROM "media" WHERE uploaded_by_id = \$1`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow(50))
+
+	// HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/photo.png"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation; nothing is stored
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status 413, but got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), utils.ErrorCodeQuotaExceeded) {
+		t.Errorf("Expected a quota error, but got %s", w.Body.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing to be stored, but found %d files", len(entries))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestImportMediaRefusesAnonymousCallers(t *testing.T) {
+	// Test Setup; without an API key there is no quota to count the file against
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	dir := t.TempDir()
+	store := &storage.Local{Dir: dir, BaseURL: "https://cdn.example.com"}
+	router.POST("/media/import", controllers.NewMediaHandler(controllers.Deps{Storage: store}).Import)
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(bytes.Repeat([]byte("a"), 80))
+	}))
+	defer files.Close()
+
+	// HTTP Test Setup
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/photo.png"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media/import", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation; nothing is stored
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401, but got %d: %s", w.Code, w.Body.String())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Expected nothing to be stored, but found %d files", len(entries))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestExportMedia(t *testing.T) {
+	// Test Setup; one file is held in local storage, the other is fetched
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "logo.png"), []byte("stored"), 0o644)
+	store := &storage.Local{Dir: dir, BaseURL: "https://cdn.example.com"}
+	router.POST("/media/export", controllers.NewMediaHandler(controllers.Deps{Storage: store}).Export)
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/clip.mp4" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("remote"))
+	}))
+	defer files.Close()
+
+	// Database Expectations; media 3 no longer exists at its URL
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "media" WHERE scan_status <> \$1 AND id IN \(\$2,\$3,\$4\)`).
+		WithArgs(models.ScanQuarantined, 2, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE scan_status <> \$1 AND id IN \(\$2,\$3,\$4\)`).
+		WithArgs(models.ScanQuarantined, 2, 1, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type"}).
+			AddRow(1, "https://cdn.example.com/logo.png", "image/png").
+			AddRow(2, files.URL+"/clip.mp4", "video/mp4").
+			AddRow(3, files.URL+"/gone.pdf", "application/pdf"))
+
+	// HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/media/export", bytes.NewBufferString(`{"ids":[2,1,3]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// Response Validation; files follow the requested order
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("Expected a ZIP archive, but got %d: %s", w.Code, w.Body.String())
+	}
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatalf("Error reading archive: %v", err)
+	}
+	contents := make(map[string]string)
+	var names []string
+	for _, file := range archive.File {
+		reader, _ := file.Open()
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		names = append(names, file.Name)
+		contents[file.Name] = string(data)
+	}
+	if strings.Join(names, ",") != "files/2-clip.mp4,files/1-logo.png,manifest.json" {
+		t.Fatalf("Unexpected archive entries %v", names)
+	}
+	if contents["files/1-logo.png"] != "stored" || contents["files/2-clip.mp4"] != "remote" {
+		t.Errorf("Unexpected file contents %v", contents)
+	}
+	var manifest []controllers.MediaExportEntry
+	if err := json.Unmarshal([]byte(contents["manifest.json"]), &manifest); err != nil {
+		t.Fatalf("Error unmarshaling manifest: %v", err)
+	}
+	if len(manifest) != 3 || manifest[2].ID != 3 || manifest[2].File != "" || manifest[2].Error == "" {
+		t.Errorf("Expected the missing file to be reported, but got %+v", manifest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
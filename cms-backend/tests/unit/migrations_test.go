@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"cms-backend/migrations"
+	"cms-backend/utils"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestMigrationStatusListsPending(t *testing.T) {
+	// STEP 1: Test Setup; migration files are embedded, so the working
+	// directory does not matter
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	versions, err := migrations.Versions()
+	if err != nil || len(versions) < 3 {
+		t.Fatalf("Expected the shipped migrations, but got %v (%v)", versions, err)
+	}
+	latest := versions[len(versions)-1]
+
+	// STEP 2: Database Expectations; the schema is two migrations behind
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(versions[len(versions)-3], false))
+
+	// STEP 3: Status
+	status, err := migrations.StatusFromDB(db)
+	if err != nil {
+		t.Fatalf("Expected the status, but got %v", err)
+	}
+
+	// STEP 4: Validation
+	if status.Latest != latest || !reflect.DeepEqual(status.Pending, versions[len(versions)-2:]) {
+		t.Errorf("Unexpected status %+v", status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
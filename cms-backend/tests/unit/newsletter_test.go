@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/mailer"
+	"cms-backend/models"
+	"cms-backend/newsletter"
+	"cms-backend/utils"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// outbox records the messages sent through it
+type outbox struct {
+	sent []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func postJSON(router http.Handler, path string, body interface{}) *httptest.ResponseRecorder {
+	jsonData, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, path, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestSubscribeSendsConfirmation(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/newsletter/subscribe", controllers.Subscribe)
+	t.Setenv("NEWSLETTER_CONFIRM_URL", "https://example.com/confirm?t={token}")
+	mail := &outbox{}
+	newsletter.Mailer = mail
+	defer func() { newsletter.Mailer = nil }()
+
+	// STEP 2: Database Expectations; the address is stored lowercased
+	mock.ExpectQuery(`SELECT \* FROM "subscribers" WHERE email = \$1`).
+		WithArgs("ada@example.com", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "subscribers"`).
+		WithArgs("ada@example.com", "Ada", models.SubscriberPending, sqlmock.AnyArg(), sqlmock.AnyArg(),
+			nil, nil, "", "", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := postJSON(router, "/newsletter/subscribe", map[string]string{"email": " Ada@Example.com", "name": "Ada"})
+
+	// STEP 4: The confirmation email links to the frontend with the token
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, but got %d: %s", w.Code, w.Body.String())
+	}
+	if len(mail.sent) != 1 || mail.sent[0].To[0] != "ada@example.com" {
+		t.Fatalf("Expected one confirmation email, but got %+v", mail.sent)
+	}
+	if !regexp.MustCompile(`https://example.com/confirm\?t=[0-9a-f]{48}`).MatchString(mail.sent[0].HTML) {
+		t.Errorf("Expected a confirmation link in %s", mail.sent[0].HTML)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestConfirmSubscriptionExpires(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/newsletter/confirm", controllers.ConfirmSubscription)
+	columns := []string{"id", "email", "status", "confirm_sent_at"}
+
+	// STEP 2: A link older than the TTL has expired
+	mock.ExpectQuery(`SELECT \* FROM "subscribers" WHERE confirm_token_hash = \$1 AND status = \$2`).
+		WithArgs(sqlmock.AnyArg(), models.SubscriberPending, 1).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "ada@example.com", models.SubscriberPending, time.Now().Add(-newsletter.ConfirmTTL-time.Minute)))
+	if w := postJSON(router, "/newsletter/confirm", map[string]string{"token": "abc"}); w.Code != http.StatusGone {
+		t.Fatalf("Expected status 410, but got %d", w.Code)
+	}
+
+	// STEP 3: A fresh link confirms the subscription
+	mock.ExpectQuery(`SELECT \* FROM "subscribers" WHERE confirm_token_hash = \$1 AND status = \$2`).
+		WithArgs(sqlmock.AnyArg(), models.SubscriberPending, 1).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "ada@example.com", models.SubscriberPending, time.Now().Add(-time.Hour)))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "subscribers" SET "confirm_token_hash"=\$1,"confirmed_at"=\$2,"status"=\$3,"unsubscribed_at"=\$4,"updated_at"=\$5 WHERE "id" = \$6`).
+		WithArgs("", sqlmock.AnyArg(), models.SubscriberSubscribed, nil, sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	if w := postJSON(router, "/newsletter/confirm", map[string]string{"token": "abc"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUnsubscribeChecksToken(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/newsletter/unsubscribe", controllers.Unsubscribe)
+	t.Setenv("NEWSLETTER_SECRET", "s3cret")
+
+	// STEP 2: A token for another address is refused
+	other, _ := newsletter.UnsubscribeToken("eve@example.com")
+	if w := postJSON(router, "/newsletter/unsubscribe", map[string]string{"email": "ada@example.com", "token": other}); w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+
+	// STEP 3: The address's own token unsubscribes it, whatever its case
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "subscribers" SET .* WHERE email = \$5 AND status <> \$6`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	token, _ := newsletter.UnsubscribeToken("ada@example.com")
+	if w := postJSON(router, "/newsletter/unsubscribe", map[string]string{"email": "ADA@example.com", "token": token}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSyncPendingPushesToMailchimp(t *testing.T) {
+	// STEP 1: Test Setup; a stand-in for the Mailchimp API
+	var path, user string
+	var member map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, _, _ = r.BasicAuth()
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &member)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	sync := &newsletter.Mailchimp{BaseURL: server.URL + "/3.0", ListID: "list1", APIKey: "key-us1", Client: server.Client()}
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; the pushed status is recorded
+	mock.ExpectQuery(`SELECT \* FROM "subscribers" WHERE \(status = \$1 AND synced_status <> \$2\) OR \(status = \$3 AND synced_status = \$4\) ORDER BY id LIMIT \$5`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email", "name", "status"}).
+			AddRow(1, "ada@example.com", "Ada", models.SubscriberSubscribed))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "subscribers" SET "sync_error"=\$1,"synced_status"=\$2 WHERE id = \$3`).
+		WithArgs("", models.SubscriberSubscribed, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: Sync
+	if err := newsletter.SyncPending(context.Background(), db, sync); err != nil {
+		t.Fatalf("Expected the sync to succeed, but got %v", err)
+	}
+
+	// STEP 4: Members are addressed by the MD5 of their address
+	hash := md5.Sum([]byte("ada@example.com"))
+	if path != "/3.0/lists/list1/members/"+hex.EncodeToString(hash[:]) {
+		t.Errorf("Unexpected member path %s", path)
+	}
+	if user == "" || member["status"] != "subscribed" || member["email_address"] != "ada@example.com" {
+		t.Errorf("Unexpected member %v", member)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/notifications"
+	"cms-backend/utils"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateSubmissionNotifiesReviewers(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/submissions", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "freelancer", Role: auth.RoleSubmitter, KeyID: 9})
+	}, controllers.CreateSubmission)
+
+	// STEP 2: Database Expectations; keys 1 and 4 review submissions
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "submissions" WHERE api_key_id = \$1 AND status = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "submissions" WHERE api_key_id = \$1 AND created_at > \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "submissions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+	mock.ExpectQuery(`SELECT "id" FROM "api_keys" WHERE role IN \(\$1,\$2\) ORDER BY id`).
+		WithArgs("admin", "editor").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(4))
+	mock.ExpectQuery(`INSERT INTO "notifications"`).
+		WithArgs(1, "submission_pending", `freelancer submitted "Pitch" for review`, "submission", 3, nil, nil, sqlmock.AnyArg(),
+			4, "submission_pending", `freelancer submitted "Pitch" for review`, "submission", 3, nil, nil, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/submissions", bytes.NewBufferString(`{"title": "Pitch", "content": "Story"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMarkNotificationReadScopedToCaller(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/me/notifications/:id/read", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Jane", Role: auth.RoleEditor, KeyID: 7})
+	}, controllers.MarkNotificationRead)
+
+	// STEP 2: Database Expectations; notification 5 belongs to another key
+	mock.ExpectQuery(`SELECT \* FROM "notifications" WHERE api_key_id = \$1 AND "notifications"\."id" = \$2`).
+		WithArgs(7, "5", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/me/notifications/5/read", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSendDigestsGroupsByRecipient(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+	sent := make(map[string]int)
+	sender := notifications.SenderFunc(func(ctx context.Context, recipient models.APIKey, pending []models.Notification) error {
+		sent[recipient.Email] = len(pending)
+		return nil
+	})
+
+	// STEP 2: Database Expectations; key 4 has two unread notifications
+	mock.ExpectQuery(`SELECT \* FROM "notifications" WHERE \(read_at IS NULL AND emailed_at IS NULL AND created_at <= \$1\) AND api_key_id IN \(SELECT "id" FROM "api_keys" WHERE email <> ''\) ORDER BY api_key_id,id LIMIT \$2`).
+		WithArgs(now.Add(-time.Hour), 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "api_key_id", "kind"}).
+			AddRow(1, 4, "submission_pending").
+			AddRow(2, 4, "access_granted"))
+	mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE id IN \(\$1\) ORDER BY id`).
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(4, "jane@example.com"))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "notifications" SET "emailed_at"=\$1 WHERE id IN \(\$2,\$3\)`).
+		WithArgs(now, 1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// STEP 3: Task Execution
+	if err := notifications.SendDigests(context.Background(), db, sender, time.Hour, now); err != nil {
+		t.Fatalf("Expected digests to be sent, but got %v", err)
+	}
+
+	// STEP 4: Expectation Validation
+	if sent["jane@example.com"] != 2 {
+		t.Errorf("Expected one digest of 2 notifications, but got %v", sent)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/orphans"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetOrphanReport(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/admin/orphans", controllers.GetOrphanReport)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT post_id,media_id FROM "post_media" WHERE NOT EXISTS \(SELECT 1 FROM posts .*\) OR NOT EXISTS \(SELECT 1 FROM media .*\) ORDER BY post_id,media_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}).AddRow(4, 9))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE media\.created_at < \$1 AND NOT EXISTS \(SELECT 1 FROM post_media .*\) ORDER BY media\.id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url"}).AddRow(3, "https://cdn.example.com/old.png"))
+	mock.ExpectQuery(`SELECT "id","title","author" FROM "posts" WHERE posts\.author <> '' AND NOT EXISTS \(SELECT 1 FROM users WHERE users\.username = posts\.author\) ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author"}).AddRow(7, "Launch", "Departed"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/orphans", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var report orphans.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(report.PostMedia) != 1 || len(report.Media) != 1 || len(report.Posts) != 1 || report.Posts[0].Author != "Departed" {
+		t.Errorf("Expected one orphan of each kind, but got %+v", report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCleanOrphans(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; only the authorless post is found, and
+	// it is reassigned
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT post_id,media_id FROM "post_media"`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "media"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT "id","title","author" FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author"}).AddRow(7, "Launch", "Departed"))
+	mock.ExpectExec(`UPDATE "posts" SET "author"=\$1,"updated_at"=\$2 WHERE id IN \(\$3\)`).
+		WithArgs("Jane", sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: Clean
+	report, err := orphans.Clean(context.Background(), db, time.Now(), "Jane")
+
+	// STEP 4: Result Validation
+	if err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+	if len(report.Posts) != 1 || report.Posts[0].ID != 7 {
+		t.Errorf("Expected the authorless post to be reported, but got %+v", report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCleanOrphansUnknownAuthor(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/admin/orphans/clean", controllers.CleanOrphans)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE username = \$1`).
+		WithArgs("Nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/admin/orphans/clean", bytes.NewBufferString(`{"reassign_author":"Nobody"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -31,7 +31,7 @@ func TestGetPages(t *testing.T) {
 
 	mock.ExpectQuery(`SELECT \* FROM "pages"`).WillReturnRows(rows)
 
-	router.GET("/pages", controllers.GetPages)
+	router.GET("/pages", controllers.NewPageHandler(controllers.Deps{}).List)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/pages", nil)
 	router.ServeHTTP(w, req)
@@ -60,12 +60,12 @@ func TestGetPage(t *testing.T) {
 		AddRow(1, "Test Page", "Test Content", now, now)
 
 	// STEP 3: Database Expectations
-	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1 ORDER BY "pages"\."id" LIMIT \$2`).
-		WithArgs(sqlmock.AnyArg(), 1).
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1 AND pages\.status = \$2 AND \(pages\.embargoed_until IS NULL OR pages\.embargoed_until <= \$3\) AND pages\.visibility <> \$4 ORDER BY "pages"\."id" LIMIT \$5`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members", 1).
 		WillReturnRows(row)
 
 	// STEP 4: HTTP Test Setup
-	router.GET("/pages/:id", controllers.GetPage)
+	router.GET("/pages/:id", controllers.NewPageHandler(controllers.Deps{}).Get)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/pages/1", nil)
 	router.ServeHTTP(w, req)
@@ -95,8 +95,14 @@ func TestCreatePage(t *testing.T) {
 
 	// STEP 2: Database Expectations
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "pages" \("title","content","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING "id"`).
-		WithArgs("New Page", "New Content", sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectQuery(`SELECT "slug" FROM "pages" WHERE slug = \$1 OR slug LIKE \$2`).
+		WithArgs("new-page", "new-page-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}))
+	mock.ExpectQuery(`INSERT INTO "pages" \("title","content","slug","locale","parent_id","status","expires_at","visibility","embargoed_until","notes","owner_group_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13\) RETURNING "uuid","id"`).
+		WithArgs("New Page", "New Content", "new-page", "", nil, "published", nil, "public", nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("page", 1, "created", nil, "", "", "", "", sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
@@ -108,7 +114,7 @@ func TestCreatePage(t *testing.T) {
 	jsonData, _ := json.Marshal(page)
 
 	// STEP 4: HTTP Test Setup
-	router.POST("/pages", controllers.CreatePage)
+	router.POST("/pages", controllers.NewPageHandler(controllers.Deps{}).Create)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/pages", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
@@ -139,17 +145,24 @@ func TestUpdatePage(t *testing.T) {
 
 	// STEP 2: Database Expectations
 	now := time.Now()
-	existingRow := sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
-		AddRow(1, "Old Title", "Old Content", now, now)
+	existingRow := sqlmock.NewRows([]string{"id", "title", "content", "slug", "status", "created_at", "updated_at"}).
+		AddRow(1, "Old Title", "Old Content", "old-title", "published", now, now)
 
 	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1 ORDER BY "pages"\."id" LIMIT \$2`).
 		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnRows(existingRow)
 
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("page", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
 	mock.ExpectBegin()
-	mock.ExpectExec(`UPDATE "pages" SET "title"=\$1,"content"=\$2,"created_at"=\$3,"updated_at"=\$4 WHERE "id" = \$5`).
-		WithArgs("Updated Title", "Updated Content", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectExec(`UPDATE "pages" SET "title"=\$1,"content"=\$2,"slug"=\$3,"locale"=\$4,"parent_id"=\$5,"status"=\$6,"expires_at"=\$7,"visibility"=\$8,"embargoed_until"=\$9,"notes"=\$10,"owner_group_id"=\$11,"created_at"=\$12,"updated_at"=\$13 WHERE "id" = \$14`).
+		WithArgs("Updated Title", "Updated Content", "old-title", "", nil, "published", nil, "", nil, "", nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("page", 1, "edited", nil, "", "title,content", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
 	// STEP 3: Request Preparation
@@ -160,7 +173,7 @@ func TestUpdatePage(t *testing.T) {
 	jsonData, _ := json.Marshal(updateData)
 
 	// STEP 4: HTTP Test Setup
-	router.PUT("/pages/:id", controllers.UpdatePage)
+	router.PUT("/pages/:id", controllers.NewPageHandler(controllers.Deps{}).Update)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPut, "/pages/1", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
@@ -198,14 +211,24 @@ func TestDeletePage(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnRows(existingRow)
 
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("page", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
 	mock.ExpectBegin()
 	mock.ExpectExec(`DELETE FROM "pages" WHERE "pages"\."id" = \$1`).
 		WithArgs(sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM "acl_entries" WHERE entity_type = \$1 AND entity_id = \$2`).
+		WithArgs("page", 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("page", 1, "deleted", nil, "", "", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
 	// STEP 3: HTTP Test Setup
-	router.DELETE("/pages/:id", controllers.DeletePage)
+	router.DELETE("/pages/:id", controllers.NewPageHandler(controllers.Deps{}).Delete)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/pages/1", nil)
 	router.ServeHTTP(w, req)
@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// expectPlaylist mocks loading playlist 1 with a current media item, an expired
+// media item, a draft page and a media item that starts later
+func expectPlaylist(mock sqlmock.Sqlmock, now time.Time) {
+	past := now.Add(-2 * time.Hour)
+	expired := now.Add(-time.Hour)
+	later := now.Add(time.Hour)
+
+	mock.ExpectQuery(`SELECT \* FROM "playlists" WHERE "playlists"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "Lobby"))
+	mock.ExpectQuery(`SELECT \* FROM "playlist_items" WHERE playlist_id = \$1 ORDER BY position`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "playlist_id", "position", "media_id", "page_id", "duration_seconds", "starts_at", "ends_at"}).
+			AddRow(1, 1, 1, 10, nil, 15, nil, nil).
+			AddRow(2, 1, 2, 11, nil, 15, past, expired).
+			AddRow(3, 1, 3, nil, 20, 30, nil, nil).
+			AddRow(4, 1, 4, 12, nil, 10, later, nil))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE "media"\."id" IN \(\$1,\$2,\$3\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type", "size"}).
+			AddRow(10, "https://cdn.example.com/welcome.mp4", "video/mp4", 1024).
+			AddRow(11, "https://cdn.example.com/sale.png", "image/png", 512).
+			AddRow(12, "https://cdn.example.com/menu.png", "image/png", 256))
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).AddRow(20, "Draft notice", "draft"))
+}
+
+func TestPlayPlaylist(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/playlists/:id/play", controllers.PlayPlaylist)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	expectPlaylist(mock, now)
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/playlists/1/play", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation; only the current media item plays, and the
+	// playlist changes when the later item starts
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var playback controllers.PlaylistPlayback
+	if err := json.Unmarshal(w.Body.Bytes(), &playback); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(playback.Items) != 1 || playback.Items[0].Type != "media" || playback.Items[0].Media.ID != 10 {
+		t.Fatalf("Expected only media 10 to play, but got %+v", playback.Items)
+	}
+	if playback.ValidUntil == nil || !playback.ValidUntil.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected valid_until at the next item start, but got %v", playback.ValidUntil)
+	}
+	if playback.Hash == "" || w.Header().Get("ETag") != `"`+playback.Hash+`"` {
+		t.Errorf("Expected the hash as ETag, but got %q and %q", playback.Hash, w.Header().Get("ETag"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPlayPlaylistNotModified(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/playlists/:id/play", controllers.PlayPlaylist)
+
+	// STEP 2: First request to learn the hash
+	now := time.Now()
+	expectPlaylist(mock, now)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/playlists/1/play", nil)
+	router.ServeHTTP(w, req)
+	etag := w.Header().Get("ETag")
+
+	// STEP 3: A player polling with the same hash gets 304
+	expectPlaylist(mock, now)
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/playlists/1/play", nil)
+	req.Header.Set("If-None-Match", etag)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("Expected status 304, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreatePlaylistValidatesItems(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/playlists", controllers.CreatePlaylist)
+
+	// STEP 2: HTTP Test Setup; the item names both a media file and a page
+	w := httptest.NewRecorder()
+	body := `{"name": "Lobby", "items": [{"media_id": 1, "page_id": 2, "duration_seconds": 10}]}`
+	req, _ := http.NewRequest(http.MethodPost, "/playlists", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -34,8 +34,14 @@ func TestGetPosts(t *testing.T) {
 		WithArgs(1, 2).
 		WillReturnRows(mediaRows)
 
+	// Mock the tag preloading query
+	tagRows := sqlmock.NewRows([]string{"post_id", "tag_id"})
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" IN \(\$1,\$2\)`).
+		WithArgs(1, 2).
+		WillReturnRows(tagRows)
+
 	// STEP 4: HTTP Test Setup
-	router.GET("/posts", controllers.GetPosts)
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
 	router.ServeHTTP(w, req)
@@ -65,8 +71,8 @@ func TestGetPostsWithFilters(t *testing.T) {
 		AddRow(1, "Test Post", "Test Content", "TestAuthor", now, now)
 
 	// STEP 3: Database Expectations
-	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE title ILIKE \$1 AND author = \$2`).
-		WithArgs("%Test%", "TestAuthor").
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE title ILIKE \$1 AND author = \$2 AND posts\.status = \$3 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$4\) AND posts\.visibility <> \$5`).
+		WithArgs("%Test%", "TestAuthor", "published", sqlmock.AnyArg(), "members").
 		WillReturnRows(rows)
 		
 	// Mock the media preloading query
@@ -75,8 +81,14 @@ func TestGetPostsWithFilters(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(mediaRows)
 
+	// Mock the tag preloading query
+	tagRows := sqlmock.NewRows([]string{"post_id", "tag_id"})
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(tagRows)
+
 	// STEP 4: HTTP Test Setup
-	router.GET("/posts", controllers.GetPosts)
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/posts?title=Test&author=TestAuthor", nil)
 	router.ServeHTTP(w, req)
@@ -98,6 +110,169 @@ func TestGetPostsWithFilters(t *testing.T) {
 	}
 }
 
+func TestGetPostsWithFields(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Mock Data Creation
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "title", "slug", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "First Post", "first-post", "Long content", "Author 1", now, now)
+
+	// STEP 3: Database Expectations (relations left out of ?fields= are not preloaded)
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).WillReturnRows(rows)
+
+	// STEP 4: HTTP Test Setup
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?fields=id,title,slug", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 5: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if body := w.Body.String(); body != `[{"id":1,"slug":"first-post","title":"First Post"}]` {
+		t.Fatalf("Unexpected sparse response: %s", body)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetPostsRejectsUnknownFields(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?fields=id,password", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
+func TestGetPostsWithInclude(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Mock Data Creation
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
+		AddRow(1, "First Post", "Content", now, now)
+
+	// STEP 3: Database Expectations (only tags are preloaded)
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// STEP 4: HTTP Test Setup
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?include=tags", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 5: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+
+	// STEP 6: Unknown relations are rejected before querying
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/posts?include=author", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
+func TestGetPostsWithCursor(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+
+	// STEP 2: Mock Data Creation; the first page fetches one extra row
+	newest := time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)
+	older := newest.Add(-time.Hour)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$2\) AND posts\.visibility <> \$3 ORDER BY posts\.created_at DESC,posts\.id DESC LIMIT \$4`).
+		WithArgs("published", sqlmock.AnyArg(), "members", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
+			AddRow(9, "Newest", "Content", newest, newest).
+			AddRow(7, "Older", "Content", older, older).
+			AddRow(4, "Oldest", "Content", older, older))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	// STEP 3: First Page
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?limit=2&include=", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var page []models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(page) != 2 || page[1].ID != 7 {
+		t.Fatalf("Expected posts 9 and 7, but got %+v", page)
+	}
+	next := w.Header().Get(utils.NextCursorHeader)
+	if next == "" {
+		t.Fatalf("Expected a cursor for the next page")
+	}
+	if w.Header().Get(utils.TotalCountHeader) != "3" || w.Header().Get("Content-Range") != "posts 0-1/3" {
+		t.Fatalf("Expected 3 posts in total, but got %q and %q", w.Header().Get(utils.TotalCountHeader), w.Header().Get("Content-Range"))
+	}
+
+	// STEP 4: The cursor continues after the last post of the page
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE \(posts\.created_at, posts\.id\) < \(\$1, \$2\) AND posts\.status = \$3 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$4\) AND posts\.visibility <> \$5 ORDER BY posts\.created_at DESC,posts\.id DESC LIMIT \$6`).
+		WithArgs(older, 7, "published", sqlmock.AnyArg(), "members", 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
+			AddRow(4, "Oldest", "Content", older, older))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/posts?limit=2&include=&after="+next, nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if w.Header().Get(utils.NextCursorHeader) != "" {
+		t.Fatalf("Expected no cursor on the last page")
+	}
+	if w.Header().Get("Content-Range") != "posts */3" {
+		t.Fatalf("Expected an unknown range after a cursor, but got %q", w.Header().Get("Content-Range"))
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+
+	// STEP 5: Malformed cursors are rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/posts?after=not-a-cursor", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
 func TestGetPost(t *testing.T) {
 	// STEP 1: Test Setup
 	router, _, mock := utils.SetupRouterAndMockDB(t)
@@ -109,8 +284,8 @@ func TestGetPost(t *testing.T) {
 		AddRow(1, "Test Post", "Test Content", "Test Author", now, now)
 
 	// STEP 3: Database Expectations
-	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
-		WithArgs(sqlmock.AnyArg(), 1).
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4 ORDER BY "posts"\."id" LIMIT \$5`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members", 1).
 		WillReturnRows(row)
 		
 	// Mock the media preloading query
@@ -119,8 +294,14 @@ func TestGetPost(t *testing.T) {
 		WithArgs(1).
 		WillReturnRows(mediaRows)
 
+	// Mock the tag preloading query
+	tagRows := sqlmock.NewRows([]string{"post_id", "tag_id"})
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(tagRows)
+
 	// STEP 4: HTTP Test Setup
-	router.GET("/posts/:id", controllers.GetPost)
+	router.GET("/posts/:id", controllers.NewPostHandler(controllers.Deps{}).Get)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodGet, "/posts/1", nil)
 	router.ServeHTTP(w, req)
@@ -150,8 +331,14 @@ func TestCreatePost(t *testing.T) {
 
 	// STEP 2: Database Expectations
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "posts" \("title","content","author","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5\) RETURNING "id"`).
-		WithArgs("New Post", "New Content", "New Author", sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectQuery(`SELECT "slug" FROM "posts" WHERE slug = \$1 OR slug LIKE \$2`).
+		WithArgs("new-post", "new-post-%").
+		WillReturnRows(sqlmock.NewRows([]string{"slug"}).AddRow("new-post"))
+	mock.ExpectQuery(`INSERT INTO "posts" \("title","content","reading_time","author","author_email","slug","locale","canonical_url","syndication_source","status","expires_at","visibility","embargoed_until","notes","featured","position","owner_group_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12,\$13,\$14,\$15,\$16,\$17,\$18,\$19\) RETURNING "uuid","id"`).
+		WithArgs("New Post", "New Content", 1, "New Author", "", "new-post-2", "", "", "", "published", nil, "public", nil, "", false, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 1, "created", nil, "", "", "", "", sqlmock.AnyArg()).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
@@ -164,7 +351,7 @@ func TestCreatePost(t *testing.T) {
 	jsonData, _ := json.Marshal(post)
 
 	// STEP 4: HTTP Test Setup
-	router.POST("/posts", controllers.CreatePost)
+	router.POST("/posts", controllers.NewPostHandler(controllers.Deps{}).Create)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPost, "/posts", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
@@ -186,6 +373,40 @@ func TestCreatePost(t *testing.T) {
 	if response.Author != "New Author" {
 		t.Fatalf("Expected author 'New Author', but got '%s'", response.Author)
 	}
+	if response.Slug != "new-post-2" {
+		t.Fatalf("Expected slug 'new-post-2', but got '%s'", response.Slug)
+	}
+}
+
+func TestCreatePostChecksDuplicates(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts", controllers.NewPostHandler(controllers.Deps{}).Create)
+
+	// STEP 2: Database Expectations; a close title match stops the create
+	mock.ExpectQuery(`SELECT id, title, slug, status, GREATEST\(similarity\(title, \$1\), similarity\(left\(content, \$2\), \$3\)\) AS similarity FROM "posts" WHERE similarity\(title, \$4\) >= \$5 OR similarity\(left\(content, \$6\), \$7\) >= \$8 ORDER BY similarity DESC LIMIT \$9`).
+		WithArgs("Launch Day", 2000, "We launched.", "Launch Day", 0.5, 2000, "We launched.", 0.5, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "slug", "status", "similarity"}).
+			AddRow(4, "Launch day!", "launch-day", "published", 0.83))
+
+	// STEP 3: HTTP Test Setup
+	w := postJSON(router, "/posts?check_duplicates=true", map[string]string{"title": "Launch Day", "content": "We launched."})
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.DuplicatePostError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ErrorCode != utils.ErrorCodePossibleDuplicate || len(response.Candidates) != 1 || response.Candidates[0].Slug != "launch-day" {
+		t.Fatalf("Unexpected response %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
 }
 
 func TestUpdatePost(t *testing.T) {
@@ -195,17 +416,28 @@ func TestUpdatePost(t *testing.T) {
 
 	// STEP 2: Database Expectations
 	now := time.Now()
-	existingRow := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
-		AddRow(1, "Old Title", "Old Content", "Old Author", now, now)
+	existingRow := sqlmock.NewRows([]string{"id", "title", "content", "author", "slug", "status", "created_at", "updated_at"}).
+		AddRow(1, "Old Title", "Old Content", "Old Author", "old-title", "published", now, now)
 
 	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
 		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnRows(existingRow)
 
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
 	mock.ExpectBegin()
-	mock.ExpectExec(`UPDATE "posts" SET "title"=\$1,"content"=\$2,"author"=\$3,"created_at"=\$4,"updated_at"=\$5 WHERE "id" = \$6`).
-		WithArgs("Updated Title", "Updated Content", "Updated Author", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectExec(`UPDATE "posts" SET "title"=\$1,"content"=\$2,"reading_time"=\$3,"author"=\$4,"author_email"=\$5,"slug"=\$6,"locale"=\$7,"canonical_url"=\$8,"syndication_source"=\$9,"status"=\$10,"expires_at"=\$11,"visibility"=\$12,"embargoed_until"=\$13,"notes"=\$14,"featured"=\$15,"position"=\$16,"owner_group_id"=\$17,"created_at"=\$18,"updated_at"=\$19 WHERE "id" = \$20`).
+		WithArgs("Updated Title", "Updated Content", 1, "Updated Author", "", "old-title", "", "", "", "draft", nil, "", nil, "", false, 0, nil, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM "post_renders" WHERE post_id = \$1`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 1, "edited", nil, "", "title,content,author", "", "", sqlmock.AnyArg(),
+			"post", 1, "status_changed", nil, "", "", "published", "draft", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
 	mock.ExpectCommit()
 
 	// STEP 3: Request Preparation
@@ -213,11 +445,12 @@ func TestUpdatePost(t *testing.T) {
 		"title":   "Updated Title",
 		"content": "Updated Content",
 		"author":  "Updated Author",
+		"status":  "draft",
 	}
 	jsonData, _ := json.Marshal(updateData)
 
 	// STEP 4: HTTP Test Setup
-	router.PUT("/posts/:id", controllers.UpdatePost)
+	router.PUT("/posts/:id", controllers.NewPostHandler(controllers.Deps{}).Update)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodPut, "/posts/1", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
@@ -239,6 +472,9 @@ func TestUpdatePost(t *testing.T) {
 	if response.Author != "Updated Author" {
 		t.Fatalf("Expected author 'Updated Author', but got '%s'", response.Author)
 	}
+	if response.Status != "draft" {
+		t.Fatalf("Expected status 'draft', but got '%s'", response.Status)
+	}
 }
 
 func TestDeletePost(t *testing.T) {
@@ -255,14 +491,24 @@ func TestDeletePost(t *testing.T) {
 		WithArgs(sqlmock.AnyArg(), 1).
 		WillReturnRows(existingRow)
 
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
 	mock.ExpectBegin()
 	mock.ExpectExec(`DELETE FROM "posts" WHERE "posts"\."id" = \$1`).
 		WithArgs(sqlmock.AnyArg()).
 		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM "acl_entries" WHERE entity_type = \$1 AND entity_id = \$2`).
+		WithArgs("post", 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 1, "deleted", nil, "", "", "", "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 	mock.ExpectCommit()
 
 	// STEP 3: HTTP Test Setup
-	router.DELETE("/posts/:id", controllers.DeletePost)
+	router.DELETE("/posts/:id", controllers.NewPostHandler(controllers.Deps{}).Delete)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest(http.MethodDelete, "/posts/1", nil)
 	router.ServeHTTP(w, req)
@@ -282,3 +528,117 @@ func TestDeletePost(t *testing.T) {
 		t.Fatalf("Expected message '%s', but got '%s'", expectedMessage, response["message"])
 	}
 }
+
+func TestGetPostsByIDs(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+
+	// STEP 2: Database Expectations; the database returns rows in its own order
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.id IN \(\$1,\$2,\$3\) AND posts\.status = \$4 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$5\) AND posts\.visibility <> \$6`).
+		WithArgs(9, 1, 5, "published", sqlmock.AnyArg(), "members").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "created_at", "updated_at"}).
+			AddRow(1, "One", "Content", now, now).
+			AddRow(9, "Nine", "Content", now, now))
+
+	// STEP 3: HTTP Test Setup; post 5 does not exist and repeats are dropped
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?ids=9,1,5,9&include=", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var response []models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 2 || response[0].ID != 9 || response[1].ID != 1 {
+		t.Fatalf("Expected posts 9 and 1 in request order, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+
+	// STEP 5: Malformed ID lists are rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/posts?ids=1,abc", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
+func TestCountPosts(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/count", controllers.NewPostHandler(controllers.Deps{}).Count)
+	router.HEAD("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+
+	// STEP 2: The count applies the listing filters
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts" WHERE author = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4`).
+		WithArgs("Ada", "published", sqlmock.AnyArg(), "members").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/count?author=Ada", nil)
+	router.ServeHTTP(w, req)
+	var response controllers.CountResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if w.Code != http.StatusOK || response.Count != 12 {
+		t.Fatalf("Expected a count of 12, but got %d %s", w.Code, w.Body.String())
+	}
+
+	// STEP 3: HEAD reports the total without listing posts
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(40))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodHead, "/posts", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get(utils.TotalCountHeader) != "40" || w.Header().Get("Content-Range") != "posts */40" {
+		t.Fatalf("Expected a total of 40, but got %d %v", w.Code, w.Header())
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetPostsSorted(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts", controllers.NewPostHandler(controllers.Deps{}).List)
+
+	// STEP 2: Whitelisted columns are ordered as given, then by id
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE .* ORDER BY "posts"\."author","posts"\."created_at" DESC,"posts"\."id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "author"}).
+			AddRow(4, "First", "Ada").
+			AddRow(2, "Second", "Bob"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts?sort=author,-created_at&include=", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d %s", w.Code, w.Body.String())
+	}
+
+	// STEP 3: Columns off the whitelist, repeated columns and sorting a
+	// cursor page are refused
+	for _, query := range []string{"sort=password", "sort=title,-title", "sort=title,", "sort=title&limit=5"} {
+		w = httptest.NewRecorder()
+		req, _ = http.NewRequest(http.MethodGet, "/posts?"+query, nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, but got %d", query, w.Code)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
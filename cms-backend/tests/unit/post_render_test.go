@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/markdown"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetPostAsHTML(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Markdown Post", "# Heading\n\nSome **bold** text", "Author", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4 ORDER BY "posts"\."id" LIMIT \$5`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// No cached rendering yet, so it is rendered and stored
+	mock.ExpectQuery(`SELECT \* FROM "post_renders" WHERE post_id = \$1 ORDER BY "post_renders"\."post_id" LIMIT \$2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "content_hash", "html", "rendered_at"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "post_renders" .* ON CONFLICT \("post_id"\) DO UPDATE SET`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/posts/:id", controllers.NewPostHandler(controllers.Deps{}).Get)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1?format=html", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var response models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	expected := "<h1>Heading</h1>\n<p>Some <strong>bold</strong> text</p>\n"
+	if response.ContentHTML != expected {
+		t.Fatalf("Expected content_html %q, but got %q", expected, response.ContentHTML)
+	}
+}
+
+func TestMarkdownSanitizesHTML(t *testing.T) {
+	html := markdown.ToHTML("<script>alert(1)</script>\n\n[x](javascript:alert(1)) [ok](https://example.com)")
+
+	if strings.Contains(html, "<script>") {
+		t.Fatalf("Expected raw HTML to be escaped, got %q", html)
+	}
+	if !strings.Contains(html, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Fatalf("Expected raw HTML to be shown as typed, got %q", html)
+	}
+	if strings.Contains(html, "javascript:") {
+		t.Fatalf("Expected javascript: link to be dropped, got %q", html)
+	}
+	if !strings.Contains(html, `<a href="https://example.com">ok</a>`) {
+		t.Fatalf("Expected https link to be kept, got %q", html)
+	}
+}
+
+func TestMarkdownBlocksScriptInjection(t *testing.T) {
+	cases := []struct {
+		name   string
+		source string
+	}{
+		{"javascript link", `[x](javascript:alert(1))`},
+		{"mixed-case javascript link", `[x](JaVaScRiPt:alert(1))`},
+		{"entity-encoded javascript link", `[x](&#106;avascript:alert(1))`},
+		{"javascript link reference", "[x][ref]\n\n[ref]: javascript:alert(1)"},
+		{"autolink", `<javascript:alert(1)>`},
+		{"data URL", `[x](data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==)`},
+		{"javascript image", `![x](javascript:alert(1))`},
+		{"raw HTML block", `<img src=x onerror=alert(1)>`},
+		{"inline raw HTML", `hello <a href="javascript:alert(1)" onclick="alert(1)">there</a>`},
+		{"iframe", `<iframe src="https://evil.example.net"></iframe>`},
+		{"attribute breakout in link", `[x](https://example.com" onmouseover="alert(1))`},
+		{"attribute breakout in image alt", `![x" onerror="alert(1)](https://example.com/a.png)`},
+		{"attribute breakout in link title", `[x](https://example.com "a\" onclick=\"alert(1)")`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			html := markdown.ToHTML(tc.source)
+			for _, tag := range renderedTag.FindAllString(html, -1) {
+				parts := safeTag.FindStringSubmatch(tag)
+				if parts == nil {
+					t.Fatalf("Expected only formatting tags, got %q in %q", tag, html)
+				}
+				for _, attr := range renderedAttr.FindAllStringSubmatch(parts[1], -1) {
+					name, value := attr[1], strings.ToLower(attr[2])
+					if name != "href" && name != "src" && name != "alt" && name != "start" {
+						t.Fatalf("Expected no %s attribute, got %q", name, html)
+					}
+					if (name == "href" || name == "src") && !strings.HasPrefix(value, "http") && !strings.HasPrefix(value, "mailto:") && !strings.HasPrefix(value, "/") {
+						t.Fatalf("Expected a safe URL, got %q", html)
+					}
+				}
+			}
+		})
+	}
+}
+
+var (
+	// renderedTag matches the tags of rendered HTML; raw HTML from the source
+	// is escaped, so it is text and never matches
+	renderedTag = regexp.MustCompile(`<[^>]*>`)
+
+	// safeTag matches an opening or closing formatting tag whose attribute
+	// values are quoted, capturing the attributes
+	safeTag      = regexp.MustCompile(`^</?(?:p|br|hr|h[1-6]|em|strong|del|code|pre|blockquote|ul|ol|li|a|img)((?:\s+[a-z]+="[^"]*")*)\s*/?>$`)
+	renderedAttr = regexp.MustCompile(`([a-z]+)="([^"]*)"`)
+)
+
+func TestGetDeliveryPostAsText(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/delivery/posts/:slug", controllers.GetDeliveryPost)
+
+	// STEP 2: Database Expectations; the cached rendering matches the content
+	now := time.Now()
+	content := "# Heading\n\nSome **bold** text"
+	sum := sha256.Sum256([]byte(content))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.slug = \$1 .* LIMIT \$5`).
+		WithArgs("launch", "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "status", "created_at", "updated_at"}).
+			AddRow(1, "Launch", content, "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_renders" WHERE post_id = \$1 ORDER BY "post_renders"\."post_id" LIMIT \$2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "content_hash", "html", "text", "amp", "rendered_at"}).
+			AddRow(1, hex.EncodeToString(sum[:]), "<h1>Heading</h1>", "Heading\nSome bold text", "<h1>Heading</h1>", now))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/delivery/posts/launch?include=&format=text", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation; only the requested rendering is returned
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response models.Post
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ContentText != "Heading\nSome bold text" || response.ContentHTML != "" || response.ContentAMP != "" {
+		t.Fatalf("Expected only content_text, but got %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMarkdownToAMP(t *testing.T) {
+	amp := markdown.ToAMP(`![A "cat"](https://example.com/cat.png)`)
+
+	expected := `<p><amp-img src="https://example.com/cat.png" alt="A &#34;cat&#34;" width="16" height="9" layout="responsive"></amp-img></p>` + "\n"
+	if amp != expected {
+		t.Fatalf("Expected %q, got %q", expected, amp)
+	}
+}
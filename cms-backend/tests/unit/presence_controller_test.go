@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAcquirePostLock(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	postRow := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Locked Post", "Content", "Author", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(postRow)
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
+	// The post is free, so the insert takes it
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "post_locks" \("post_id","holder","holder_key_id","acquired_at","expires_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5\) ON CONFLICT \("post_id"\) DO NOTHING RETURNING "id"`).
+		WithArgs(1, "alice", 4, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/posts/:id/lock", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "alice", Role: auth.RoleEditor, KeyID: 4})
+	}, controllers.NewPresenceHandler(controllers.Deps{}).AcquireLock)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/lock", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var response models.PostLock
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Holder != "alice" {
+		t.Fatalf("Expected holder 'alice', but got '%s'", response.Holder)
+	}
+	if !response.ExpiresAt.After(now) {
+		t.Fatalf("Expected lock to expire in the future")
+	}
+}
+
+func TestAcquirePostLockConflict(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	postRow := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Locked Post", "Content", "Author", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(postRow)
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
+	// Bob's key holds the lock, so the insert is skipped and his row locked
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "post_locks" .* ON CONFLICT \("post_id"\) DO NOTHING RETURNING "id"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	lockRow := sqlmock.NewRows([]string{"id", "post_id", "holder", "holder_key_id", "acquired_at", "expires_at"}).
+		AddRow(1, 1, "bob", 5, now, now.Add(time.Minute))
+	mock.ExpectQuery(`SELECT \* FROM "post_locks" WHERE post_id = \$1 ORDER BY "post_locks"\."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(1, 1).
+		WillReturnRows(lockRow)
+	mock.ExpectRollback()
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/posts/:id/lock", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "alice", Role: auth.RoleEditor, KeyID: 4})
+	}, controllers.NewPresenceHandler(controllers.Deps{}).AcquireLock)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/lock", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, but got %d", w.Code)
+	}
+
+	var response models.PostLock
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Holder != "bob" {
+		t.Fatalf("Expected holder 'bob', but got '%s'", response.Holder)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPostPresenceRejectsForeignOrigin(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id/presence", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "alice", Role: auth.RoleEditor, KeyID: 4})
+	}, controllers.NewPresenceHandler(controllers.Deps{}).Connect)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status", "created_at", "updated_at"}).
+			AddRow(1, "Locked Post", "published", now, now))
+
+	// STEP 3: HTTP Test Setup; a page on another site opens the socket
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "http://cms.example.com/posts/1/presence", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Origin", "https://evil.example.net")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
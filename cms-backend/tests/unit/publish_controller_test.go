@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/checklist"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestBulkPublishPosts(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts/publish", controllers.BulkPublishPosts)
+
+	// STEP 2: Database Expectations; post 1 is a draft, post 2 already
+	// published and post 3 does not exist
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT "id","title","status" FROM "posts" WHERE id IN \(\$1,\$2,\$3\) AND id IN \(SELECT post_tags\.post_id FROM "post_tags" JOIN tags ON tags\.id = post_tags\.tag_id WHERE tags\.name = \$4\) ORDER BY id FOR UPDATE`).
+		WithArgs(1, 2, 3, "launch").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "status"}).
+			AddRow(1, "Launch day", "draft").
+			AddRow(2, "Teaser", "published"))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+	mock.ExpectExec(`UPDATE "posts" SET "expires_at"=CASE WHEN expires_at <= \$1 THEN NULL ELSE expires_at END,"status"=\$2,"updated_at"=\$3 WHERE id IN \(\$4\)`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WithArgs("post", 1, "status_changed", nil, "", "", "draft", "published", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/publish", bytes.NewBufferString(`{"ids":[1,2,3],"tag":"launch"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.BulkPublishResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Published != 1 || response.Skipped != 1 || len(response.Results) != 3 {
+		t.Fatalf("Expected one published and one skipped of three results, but got %+v", response)
+	}
+	expected := []string{controllers.PublishResultPublished, controllers.PublishResultSkipped, controllers.PublishResultNotFound}
+	for i, result := range response.Results {
+		if result.ID != uint(i+1) || result.Result != expected[i] {
+			t.Errorf("Expected post %d to be %s, but got %+v", i+1, expected[i], result)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestBulkPublishPostsRequiresFilter(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts/publish", controllers.BulkPublishPosts)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/publish", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetPublishReadiness(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/posts/:id/publish-readiness", controllers.GetPublishReadiness)
+	t.Setenv("PUBLISH_MIN_WORDS", "5")
+
+	// STEP 2: Database Expectations; the post has an image and enough words,
+	// but one of its links is broken
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4 ORDER BY "posts"\."id" LIMIT \$5`).
+		WithArgs(sqlmock.AnyArg(), "published", sqlmock.AnyArg(), "members", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "slug", "content", "status"}).
+			AddRow(1, "Launch day", "launch-day", "Read [the old notes](https://old.example.com/) and the new ones.", "draft"))
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}).AddRow(1, 5))
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE "media"\."id" = \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type", "scan_status"}).AddRow(5, "https://cdn.example.com/a.png", "image/png", "clean"))
+	mock.ExpectQuery(`SELECT DISTINCT "url" FROM "links" WHERE url IN \(\$1\) AND broken = \$2 ORDER BY url`).
+		WithArgs("https://old.example.com/", true).
+		WillReturnRows(sqlmock.NewRows([]string{"url"}).AddRow("https://old.example.com/"))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/1/publish-readiness", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var report checklist.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	passed := make(map[string]bool)
+	for _, item := range report.Items {
+		passed[item.Name] = item.Passed
+	}
+	expected := map[string]bool{"featured_image": true, "seo_fields": true, "minimum_length": true, "broken_links": false}
+	if report.Ready || !reflect.DeepEqual(passed, expected) {
+		t.Errorf("Expected only the broken link check to fail, but got %+v", report)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/querybudget"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestQueryBudgetCountsRequestQueries(t *testing.T) {
+	// STEP 1: Test Setup
+	router, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	if err := querybudget.Register(db); err != nil {
+		t.Fatalf("Error registering query counter: %v", err)
+	}
+	if err := querybudget.Register(db); err != nil {
+		t.Fatalf("Expected registering twice to be a no-op, but got %v", err)
+	}
+
+	// STEP 2: Database Expectations; preloads are batched, so a list costs
+	// one query per relation however many posts it returns
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+			AddRow(1, "First Post", "Content 1", "Author 1", now, now).
+			AddRow(2, "Second Post", "Content 2", "Author 2", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/posts", querybudget.Middleware(2), controllers.NewPostHandler(controllers.Deps{}).List)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	if got := w.Header().Get(querybudget.CountHeader); got != "3" {
+		t.Fatalf("Expected 3 queries to be counted, but got %q", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/ratelimit"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitTightensUnderDBLoad(t *testing.T) {
+	// STEP 1: Test Setup
+	now := time.Now()
+	limiter := ratelimit.New(ratelimit.Config{
+		Rate:          10,
+		Burst:         2,
+		MaxLatency:    100 * time.Millisecond,
+		MaxSaturation: 0.8,
+		MinFactor:     0.1,
+	})
+	limiter.Now = func() time.Time { return now }
+	router := gin.New()
+	router.GET("/posts", limiter.Handler(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// STEP 2: The burst is served, then requests are shed with Retry-After
+	for i := 0; i < 2; i++ {
+		if w := get(); w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, but got %d", http.StatusOK, w.Code)
+		}
+	}
+	w := get()
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Expected 503 with Retry-After 1, but got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	// STEP 3: A saturated pool halves the rate on each sample down to the floor
+	saturated := sql.DBStats{MaxOpenConnections: 10, InUse: 9}
+	limiter.Observe(saturated, 10*time.Millisecond)
+	limiter.Observe(saturated, 10*time.Millisecond)
+	if factor := limiter.Factor(); factor != 0.25 {
+		t.Fatalf("Expected factor 0.25, but got %v", factor)
+	}
+	for i := 0; i < 5; i++ {
+		limiter.Observe(sql.DBStats{}, time.Second)
+	}
+	if factor := limiter.Factor(); factor != 0.1 {
+		t.Fatalf("Expected factor 0.1, but got %v", factor)
+	}
+
+	// STEP 4: At one request per second, a token takes a full second to refill
+	now = now.Add(500 * time.Millisecond)
+	if w := get(); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, but got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	now = now.Add(600 * time.Millisecond)
+	if w := get(); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, but got %d", http.StatusOK, w.Code)
+	}
+
+	// STEP 5: A healthy database lets the rate recover
+	limiter.Observe(sql.DBStats{MaxOpenConnections: 10, InUse: 1}, 10*time.Millisecond)
+	if factor := limiter.Factor(); factor <= 0.1 {
+		t.Fatalf("Expected factor to recover above 0.1, but got %v", factor)
+	}
+}
+
+func TestRateLimitSkipsEditors(t *testing.T) {
+	// STEP 1: Test Setup
+	limiter := ratelimit.New(ratelimit.Config{Rate: 1, Burst: 1, MinFactor: 0.1})
+	router := gin.New()
+	router.GET("/posts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Editor", Role: auth.RoleEditor, KeyID: 1})
+	}, limiter.Handler(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// STEP 2: Editors are never shed, however fast they call
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, but got %d", http.StatusOK, w.Code)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/readability"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeReadability(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/analyze", controllers.Analyze)
+
+	// STEP 2: Headings start at h1 and skip a level; code is not counted
+	content := "# Intro\n\nThe cat sat on the mat. It was happy!\n\n" +
+		"#### Details\n\n```\nnot counted at all\n```\n\n- one more item"
+	w := postJSON(router, "/analyze", map[string]string{"content": content})
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var report readability.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if report.WordCount != 14 || report.SentenceCount != 5 || report.ReadingTime != 1 {
+		t.Errorf("Unexpected counts %+v", report)
+	}
+	if report.FleschReadingEase < 90 {
+		t.Errorf("Expected short words and sentences to read easily, but got %.1f", report.FleschReadingEase)
+	}
+	if len(report.Headings) != 2 || report.Headings[1].Level != 4 {
+		t.Errorf("Unexpected headings %+v", report.Headings)
+	}
+	if len(report.Warnings) != 2 || !strings.Contains(report.Warnings[1], "h1 to h4") {
+		t.Errorf("Unexpected warnings %v", report.Warnings)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	// STEP 1: Reading time rounds up to whole minutes
+	cases := map[string]int{
+		"":                           0,
+		"Short note.":                1,
+		strings.Repeat("word ", 200): 1,
+		strings.Repeat("word ", 201): 2,
+		"```\n" + strings.Repeat("x ", 500) + "\n```": 0,
+	}
+
+	// STEP 2: Validation
+	for content, expected := range cases {
+		if got := readability.ReadingTime(content); got != expected {
+			t.Errorf("Expected %d minutes for %.20q, but got %d", expected, content, got)
+		}
+	}
+}
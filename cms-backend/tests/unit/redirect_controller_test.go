@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateRedirectValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"relative from_path", map[string]interface{}{"from_path": "old", "to_path": "/new"}},
+		{"protocol-relative to_path", map[string]interface{}{"from_path": "/old", "to_path": "//evil.example"}},
+		{"non-http to_path", map[string]interface{}{"from_path": "/old", "to_path": "javascript:alert(1)"}},
+		{"redirect to itself", map[string]interface{}{"from_path": "/old/", "to_path": "/old"}},
+		{"unknown status", map[string]interface{}{"from_path": "/old", "to_path": "/new", "status_code": 200}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// STEP 1: Test Setup; invalid redirects never reach the database
+			router, _, mock := utils.SetupRouterAndMockDB(t)
+			defer mock.ExpectClose()
+			router.POST("/redirects", controllers.CreateRedirect)
+
+			// STEP 2: HTTP Test Setup
+			jsonData, _ := json.Marshal(tc.body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodPost, "/redirects", bytes.NewBuffer(jsonData))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			// STEP 3: Response Validation
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateRedirect(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/redirects", controllers.CreateRedirect)
+
+	// STEP 2: Database Expectations; the trailing slash is dropped and 301 is the default
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "redirects" WHERE from_path = \$1 AND id <> \$2`).
+		WithArgs("/old-path", 0).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "redirects" \("from_path","to_path","status_code","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5\) RETURNING "id"`).
+		WithArgs("/old-path", "https://example.com/new", 301, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	jsonData, _ := json.Marshal(map[string]string{"from_path": "/old-path/", "to_path": "https://example.com/new"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/redirects", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestResolveRedirect(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/redirects/resolve", controllers.ResolveRedirect)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "redirects" WHERE from_path = \$1 ORDER BY "redirects"\."id" LIMIT \$2`).
+		WithArgs("/posts/old", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_path", "to_path", "status_code", "created_at", "updated_at"}).
+			AddRow(1, "/posts/old", "/posts/new", 301, now, now))
+	mock.ExpectQuery(`SELECT \* FROM "redirects" WHERE from_path = \$1`).
+		WithArgs("/missing", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/redirects/resolve?path=/posts/old/", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response struct {
+		ToPath     string `json:"to_path"`
+		StatusCode int    `json:"status_code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ToPath != "/posts/new" || response.StatusCode != 301 {
+		t.Errorf("Expected a 301 to /posts/new, but got %+v", response)
+	}
+
+	// Paths without a redirect are not found
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/redirects/resolve?path=/missing", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdatePageSlugCreatesRedirect(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/pages/:id", controllers.NewPageHandler(controllers.Deps{}).Update)
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "slug", "status", "created_at", "updated_at"}).
+			AddRow(1, "About", "Content", "about", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "pages" WHERE slug = \$1 AND id <> \$2`).
+		WithArgs("about-us", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectExec(`DELETE FROM "redirects" WHERE from_path = \$1`).
+		WithArgs("/about-us").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "redirects" SET "to_path"=\$1,"updated_at"=\$2 WHERE to_path = \$3`).
+		WithArgs("/about-us", sqlmock.AnyArg(), "/about").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "redirects" .* ON CONFLICT \("from_path"\) DO UPDATE SET "to_path"="excluded"\."to_path","status_code"="excluded"\."status_code","updated_at"="excluded"\."updated_at" RETURNING "id"`).
+		WithArgs("/about", "/about-us", 301, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`UPDATE "pages" SET`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`INSERT INTO "activities"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	jsonData, _ := json.Marshal(map[string]string{"title": "About", "content": "Content", "slug": "about-us"})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/pages/1", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestAddRelatedPostsRejectsSelf(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Post", "Content", "Author", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2\)`).
+		WithArgs("post", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/posts/:id/related", controllers.AddRelatedPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/posts/1/related", bytes.NewBufferString(`{"post_ids":[1]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
+func TestRemoveRelatedPost(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	row := sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}).
+		AddRow(1, "Post", "Content", "Author", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1 ORDER BY "posts"\."id" LIMIT \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(row)
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries" WHERE entity_type = \$1 AND entity_id IN \(\$2,\$3\)`).
+		WithArgs("post", 1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity_type", "entity_id"}))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "post_relations" WHERE \(post_id = \$1 AND related_post_id = \$2\) OR \(post_id = \$3 AND related_post_id = \$4\)`).
+		WithArgs(1, 2, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.DELETE("/posts/:id/related/:relatedId", controllers.RemoveRelatedPost)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodDelete, "/posts/1/related/2", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/replica"
+	"cms-backend/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReplicaReadRouting(t *testing.T) {
+	// STEP 1: Test Setup
+	router, primary, _ := utils.SetupRouterAndMockDB(t)
+	_, euReplica, _ := utils.SetupRouterAndMockDB(t)
+	now := time.Now()
+	set := replica.New(primary, "eu-west", time.Second)
+	set.Now = func() time.Time { return now }
+	set.Add("eu-west", euReplica)
+
+	router.GET("/posts", set.Handler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/posts", set.Handler(), func(c *gin.Context) { c.Status(http.StatusCreated) })
+	router.GET("/drafts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "Editor", Role: auth.RoleEditor, KeyID: 1})
+	}, set.Handler(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	servedBy := func(method, path, region string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set(replica.RegionHeader, region)
+		router.ServeHTTP(w, req)
+		return w.Header().Get(replica.ServedByHeader)
+	}
+
+	// STEP 2: Replicas serve nothing until their lag is known
+	if got := servedBy(http.MethodGet, "/posts", ""); got != replica.Primary {
+		t.Fatalf("Expected primary before the first lag sample, but got %q", got)
+	}
+
+	// STEP 3: A caught-up replica serves its region, including the default one
+	set.Observe("eu-west", 0, now, nil)
+	if got := servedBy(http.MethodGet, "/posts", ""); got != "eu-west" {
+		t.Fatalf("Expected eu-west, but got %q", got)
+	}
+	if got := servedBy(http.MethodGet, "/posts", "ap-south"); got != replica.Primary {
+		t.Fatalf("Expected primary for an unknown region, but got %q", got)
+	}
+
+	// STEP 4: Reads right after a write go to the primary until the replica replays it
+	now = now.Add(time.Second)
+	servedBy(http.MethodPost, "/posts", "")
+	if got := servedBy(http.MethodGet, "/posts", "eu-west"); got != replica.Primary {
+		t.Fatalf("Expected primary right after a write, but got %q", got)
+	}
+	set.Observe("eu-west", 100*time.Millisecond, now.Add(500*time.Millisecond), nil)
+	if got := servedBy(http.MethodGet, "/posts", "eu-west"); got != "eu-west" {
+		t.Fatalf("Expected eu-west once the write is replayed, but got %q", got)
+	}
+
+	// STEP 5: Lagging or unreachable replicas fall back to the primary
+	set.Observe("eu-west", 2*time.Second, now.Add(5*time.Second), nil)
+	if got := servedBy(http.MethodGet, "/posts", "eu-west"); got != replica.Primary {
+		t.Fatalf("Expected primary for a lagging replica, but got %q", got)
+	}
+	set.Observe("eu-west", 0, now.Add(6*time.Second), errors.New("connection refused"))
+	if got := servedBy(http.MethodGet, "/posts", "eu-west"); got != replica.Primary {
+		t.Fatalf("Expected primary for an unreachable replica, but got %q", got)
+	}
+
+	// STEP 6: Editors always read from the primary
+	set.Observe("eu-west", 0, now.Add(7*time.Second), nil)
+	if got := servedBy(http.MethodGet, "/drafts", "eu-west"); got != "" {
+		t.Fatalf("Expected editor reads to stay on the primary, but got %q", got)
+	}
+}
@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/repository"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// memoryMedia is an in-memory MediaRepository
+type memoryMedia struct {
+	items map[uint]models.Media
+}
+
+func (m *memoryMedia) List(ctx context.Context, filter repository.MediaFilter) ([]models.Media, error) {
+	var media []models.Media
+	for _, item := range m.items {
+		if filter.IncludeQuarantined || item.ScanStatus != models.ScanQuarantined {
+			media = append(media, item)
+		}
+	}
+	return media, nil
+}
+
+func (m *memoryMedia) Count(ctx context.Context, filter repository.MediaFilter) (int64, error) {
+	media, err := m.List(ctx, filter)
+	return int64(len(media)), err
+}
+
+func (m *memoryMedia) Get(ctx context.Context, id uint, includeQuarantined bool) (*models.Media, error) {
+	item, ok := m.items[id]
+	if !ok || (!includeQuarantined && item.ScanStatus == models.ScanQuarantined) {
+		return nil, repository.ErrNotFound
+	}
+	return &item, nil
+}
+
+func (m *memoryMedia) Create(ctx context.Context, media *models.Media) error {
+	media.ID = uint(len(m.items) + 1)
+	m.items[media.ID] = *media
+	return nil
+}
+
+func (m *memoryMedia) Update(ctx context.Context, media *models.Media) error {
+	m.items[media.ID] = *media
+	return nil
+}
+
+func (m *memoryMedia) Delete(ctx context.Context, media *models.Media) error {
+	delete(m.items, media.ID)
+	return nil
+}
+
+func TestMediaHandlersWithRepository(t *testing.T) {
+	// STEP 1: Test Setup; no database is involved
+	media := &memoryMedia{items: map[uint]models.Media{
+		1: {ID: 1, URL: "https://example.com/a.png", Type: "image", ScanStatus: models.ScanClean},
+		2: {ID: 2, URL: "https://example.com/b.exe", Type: "file", ScanStatus: models.ScanQuarantined},
+	}}
+	role := auth.RoleEditor
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "tester", Role: role, KeyID: 1})
+	})
+	handler := controllers.NewMediaHandler(controllers.Deps{
+		Repositories: func(*gorm.DB) *repository.Repositories {
+			return &repository.Repositories{Media: media}
+		},
+	})
+	router.GET("/media/:id", handler.Get)
+	router.DELETE("/media/:id", handler.Delete)
+	request := func(method, path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: Quarantined media is hidden from editors but not from admins
+	if code := request(http.MethodGet, "/media/2"); code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an editor, but got %d", code)
+	}
+	role = auth.RoleAdmin
+	if code := request(http.MethodGet, "/media/2"); code != http.StatusOK {
+		t.Errorf("Expected status 200 for an admin, but got %d", code)
+	}
+
+	// STEP 3: Malformed IDs are refused
+	if code := request(http.MethodGet, "/media/abc"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, but got %d", code)
+	}
+
+	// STEP 4: Deleting goes through the repository
+	if code := request(http.MethodDelete, "/media/1"); code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	if _, ok := media.items[1]; ok {
+		t.Errorf("Expected media 1 to be deleted")
+	}
+}
+
+func TestUpdateMedia(t *testing.T) {
+	// STEP 1: Test Setup; a measured 800x600 image
+	media := &memoryMedia{items: map[uint]models.Media{
+		1: {ID: 1, URL: "https://example.com/a.png", Type: "image", Width: 800, Height: 600, ScanStatus: models.ScanClean},
+	}}
+	router := gin.New()
+	handler := controllers.NewMediaHandler(controllers.Deps{
+		Repositories: func(*gorm.DB) *repository.Repositories {
+			return &repository.Repositories{Media: media}
+		},
+	})
+	router.PUT("/media/:id", handler.Update)
+	request := func(body string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/media/1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: The alt text, focal point and crops are saved
+	if code := request(`{"alt_text": "A lighthouse at dusk", "focal_x": 0.25, "focal_y": 0.4, "crops": {"square": {"x": 100, "y": 0, "width": 600, "height": 600}}}`); code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	saved := media.items[1]
+	if saved.AltText != "A lighthouse at dusk" || saved.FocalX == nil || *saved.FocalX != 0.25 || saved.Crops["square"].Width != 600 {
+		t.Fatalf("Expected the update to be saved, but got %+v", saved)
+	}
+
+	// STEP 3: Invalid framing is refused
+	for _, body := range []string{
+		`{"focal_x": 0.5}`,
+		`{"focal_x": 1.5, "focal_y": 0.5}`,
+		`{"crops": {"banner": {"x": 0, "y": 0, "width": 900, "height": 300}}}`,
+		`{"crops": {"Bad Name": {"x": 0, "y": 0, "width": 10, "height": 10}}}`,
+	} {
+		if code := request(body); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, but got %d", body, code)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// resolution is the part of a Resolve response the tests check
+type resolution struct {
+	Type string `json:"type"`
+	Page *struct {
+		ID uint `json:"id"`
+	} `json:"page"`
+	Redirect *struct {
+		ToPath     string `json:"to_path"`
+		StatusCode int    `json:"status_code"`
+	} `json:"redirect"`
+}
+
+func resolvePath(t *testing.T, router http.Handler, path string) (int, resolution) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/resolve?path="+path, nil)
+	router.ServeHTTP(w, req)
+
+	var response resolution
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Error unmarshaling response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+func TestResolveNestedPage(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/resolve", controllers.Resolve)
+
+	// STEP 2: Database Expectations; the page is found by its own slug and
+	// its parents' slugs must match the rest of the path
+	now := time.Now()
+	pageRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "title", "slug", "parent_id", "status", "created_at", "updated_at"}).
+			AddRow(3, "Team", "team", 1, "published", now, now)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE pages\.status = \$1 AND pages\.slug = \$2`).
+		WithArgs("published", "team", 1).
+		WillReturnRows(pageRow())
+	mock.ExpectQuery(`SELECT "id","parent_id","slug" FROM "pages" WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "slug"}).AddRow(1, nil, "about"))
+
+	// STEP 3: The current path renders the page
+	code, response := resolvePath(t, router, "/about/team")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	if response.Type != controllers.ResolvedPage || response.Page == nil || response.Page.ID != 3 {
+		t.Fatalf("Expected page 3, but got %+v", response)
+	}
+
+	// STEP 4: An outdated parent path redirects to the current one
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE pages\.status = \$1 AND pages\.slug = \$2`).
+		WithArgs("published", "team", 1).
+		WillReturnRows(pageRow())
+	mock.ExpectQuery(`SELECT "id","parent_id","slug" FROM "pages" WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "slug"}).AddRow(1, nil, "about"))
+	mock.ExpectQuery(`SELECT \* FROM "redirects" WHERE from_path = \$1`).
+		WithArgs("/company/team", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	code, response = resolvePath(t, router, "/company/team/")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	if response.Type != controllers.ResolvedRedirect || response.Redirect.ToPath != "/about/team" || response.Redirect.StatusCode != 301 {
+		t.Fatalf("Expected a 301 to /about/team, but got %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestResolvePostAndRedirect(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/resolve", controllers.Resolve)
+
+	// STEP 2: Database Expectations; no post or page has the slug, so the
+	// redirects table answers
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE posts\.status = \$1 AND posts\.slug = \$2`).
+		WithArgs("published", "old-launch", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE pages\.status = \$1 AND pages\.slug = \$2`).
+		WithArgs("published", "old-launch", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "redirects" WHERE from_path = \$1`).
+		WithArgs("/posts/old-launch", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "from_path", "to_path", "status_code"}).
+			AddRow(1, "/posts/old-launch", "/posts/launch", 301))
+
+	// STEP 3: HTTP Test Setup
+	code, response := resolvePath(t, router, "/posts/old-launch?include=")
+	if code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", code)
+	}
+	if response.Type != controllers.ResolvedRedirect || response.Redirect.ToPath != "/posts/launch" {
+		t.Fatalf("Expected a redirect to /posts/launch, but got %+v", response)
+	}
+
+	// STEP 4: Paths must be absolute
+	if code, _ := resolvePath(t, router, "posts"); code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, but got %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdatePageRejectsParentCycle(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/pages/:id", controllers.NewPageHandler(controllers.Deps{}).Update)
+
+	// STEP 2: Database Expectations; page 2 is already under page 1
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE "pages"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "slug", "status", "created_at", "updated_at"}).
+			AddRow(1, "About", "Content", "about", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "acl_entries"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT "id","parent_id","slug" FROM "pages" WHERE id = \$1`).
+		WithArgs(2, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "slug"}).AddRow(2, 1, "team"))
+
+	// STEP 3: HTTP Test Setup
+	jsonData, _ := json.Marshal(map[string]interface{}{"title": "About", "content": "Content", "parent_id": 2})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/pages/1", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
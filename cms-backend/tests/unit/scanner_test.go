@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/scanner"
+	"cms-backend/utils"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClamd accepts one INSTREAM scan and flags streams containing "EICAR"
+func fakeClamd(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		if command, _ := reader.ReadString(0); command != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND ERROR\x00"))
+			return
+		}
+		var stream bytes.Buffer
+		for {
+			var size uint32
+			if err := binary.Read(reader, binary.BigEndian, &size); err != nil || size == 0 {
+				break
+			}
+			io.CopyN(&stream, reader, int64(size))
+		}
+		if strings.Contains(stream.String(), "EICAR") {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	}()
+	return listener.Addr().String()
+}
+
+func TestClamAVScan(t *testing.T) {
+	// STEP 1: Test Setup
+	clamav := &scanner.ClamAV{Addr: fakeClamd(t), Timeout: 5 * time.Second}
+
+	// STEP 2: Scanning
+	result, err := clamav.Scan(context.Background(), strings.NewReader("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if err != nil {
+		t.Fatalf("Expected the scan to succeed, but got %v", err)
+	}
+
+	// STEP 3: Result Validation
+	if !result.Infected || result.Signature != "Eicar-Test-Signature" {
+		t.Errorf("Expected the EICAR signature, but got %+v", result)
+	}
+}
+
+func TestCreateMediaRejectsInfectedFile(t *testing.T) {
+	// STEP 1: Test Setup; the file is served locally, so private addresses are allowed
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/media", controllers.NewMediaHandler(controllers.Deps{}).Create)
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	files := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("EICAR test file"))
+	}))
+	defer files.Close()
+	previous := scanner.Default
+	scanner.Default = &scanner.ClamAV{Addr: fakeClamd(t), Timeout: 5 * time.Second}
+	defer func() { scanner.Default = previous }()
+
+	// STEP 2: HTTP Test Setup; nothing is stored
+	w := httptest.NewRecorder()
+	body := `{"url": "` + files.URL + `/virus.jpg", "type": "image"}`
+	req, _ := http.NewRequest(http.MethodPost, "/media", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status 422, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response utils.HTTPError
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.ErrorCode != utils.ErrorCodeMediaInfected {
+		t.Errorf("Expected error code '%s', but got '%s'", utils.ErrorCodeMediaInfected, response.ErrorCode)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
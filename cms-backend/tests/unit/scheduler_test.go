@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"cms-backend/scheduler"
+	"cms-backend/utils"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestExpireContent(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+
+	// STEP 2: Database Expectations
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "posts" SET "status"=\$1,"updated_at"=\$2 WHERE status = \$3 AND expires_at <= \$4`).
+		WithArgs("archived", now, "published", now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "pages" SET "status"=\$1,"updated_at"=\$2 WHERE status = \$3 AND expires_at <= \$4`).
+		WithArgs("archived", now, "published", now).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	// STEP 3: Task Execution
+	if err := scheduler.ExpireContent(context.Background(), db, now); err != nil {
+		t.Fatalf("Expected expiry to succeed, but got %v", err)
+	}
+
+	// STEP 4: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetPostSchema(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	router.GET("/schema/:type", controllers.GetSchema)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/schema/post", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var schema struct {
+		Type       string                            `json:"type"`
+		Required   []string                          `json:"required"`
+		Properties map[string]map[string]interface{} `json:"properties"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &schema); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if schema.Type != "object" || len(schema.Required) != 2 || schema.Required[0] != "title" || schema.Required[1] != "content" {
+		t.Errorf("Expected an object requiring title and content, but got %s %v", schema.Type, schema.Required)
+	}
+	if schema.Properties["title"]["maxLength"] != 255.0 {
+		t.Errorf("Expected title to be limited to 255 characters, but got %v", schema.Properties["title"])
+	}
+	if enum, _ := schema.Properties["status"]["enum"].([]interface{}); len(enum) != 3 {
+		t.Errorf("Expected three status choices, but got %v", schema.Properties["status"])
+	}
+	if _, ok := schema.Properties["id"]; ok {
+		t.Errorf("Expected generated fields to be left out of the create body")
+	}
+	if schema.Properties["expires_at"]["format"] != "date-time" {
+		t.Errorf("Expected expires_at to be a timestamp, but got %v", schema.Properties["expires_at"])
+	}
+	if items, _ := schema.Properties["tags"]["items"].(map[string]interface{}); items["type"] != "object" {
+		t.Errorf("Expected tags to be described as objects, but got %v", schema.Properties["tags"])
+	}
+}
+
+func TestGetSchemaUnknownType(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	router.GET("/schema/:type", controllers.GetSchema)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/schema/widget", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireScope(t *testing.T) {
+	cases := []struct {
+		name     string
+		caller   auth.Caller
+		scope    string
+		expected int
+	}{
+		{"anonymous on delivery", auth.Caller{}, auth.ScopeDelivery, http.StatusNoContent},
+		{"anonymous on management", auth.Caller{}, auth.ScopeManagement, http.StatusNoContent},
+		{"delivery token on delivery", auth.Caller{Role: auth.RoleDelivery, KeyID: 1}, auth.ScopeDelivery, http.StatusNoContent},
+		{"delivery token on management", auth.Caller{Role: auth.RoleDelivery, KeyID: 1}, auth.ScopeManagement, http.StatusForbidden},
+		{"management token on management", auth.Caller{Role: auth.RoleEditor, KeyID: 2}, auth.ScopeManagement, http.StatusNoContent},
+		{"management token on delivery", auth.Caller{Role: auth.RoleEditor, KeyID: 2}, auth.ScopeDelivery, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// STEP 1: Test Setup
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				auth.SetCaller(c, tc.caller)
+			})
+			router.GET("/ping", middleware.RequireScope(tc.scope), func(c *gin.Context) {
+				c.Status(http.StatusNoContent)
+			})
+
+			// STEP 2: HTTP Test Setup
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+			router.ServeHTTP(w, req)
+
+			// STEP 3: Response Validation
+			if w.Code != tc.expected {
+				t.Fatalf("Expected status %d, but got %d", tc.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequireAuthRefusesAnonymousCallers(t *testing.T) {
+	// STEP 1: Test Setup; posts are created without an Authorization header
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	api := router.Group("/api/v1", middleware.Authenticate(), middleware.RequireAuth(), middleware.RequireScope(auth.ScopeManagement))
+	api.POST("/posts", controllers.NewPostHandler(controllers.Deps{}).Create)
+	api.GET("/posts", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	request := func(method string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, "/api/v1/posts", strings.NewReader(`{"title":"Spam","content":"Spam","author":"anyone"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: By default anonymous reads and writes are both refused
+	if code := request(http.MethodPost); code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for an anonymous POST, but got %d", code)
+	}
+	if code := request(http.MethodGet); code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for an anonymous GET, but got %d", code)
+	}
+
+	// STEP 3: REQUIRE_AUTH=writes lets anonymous reads through
+	t.Setenv("REQUIRE_AUTH", "writes")
+	if code := request(http.MethodGet); code != http.StatusNoContent {
+		t.Fatalf("Expected status 204 for an anonymous GET, but got %d", code)
+	}
+	if code := request(http.MethodPost); code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for an anonymous POST, but got %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
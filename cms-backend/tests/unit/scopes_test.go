@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseScopes(t *testing.T) {
+	// STEP 1: Valid lists are split and trimmed
+	scopes, err := auth.ParseScopes(" posts:read, media:write,,settings:admin ")
+	if err != nil || len(scopes) != 3 || scopes[1] != "media:write" {
+		t.Fatalf("Expected three scopes, but got %q (%v)", scopes, err)
+	}
+	if scopes, _ := auth.ParseScopes(""); scopes != nil {
+		t.Fatalf("Expected no scopes for an empty list, but got %q", scopes)
+	}
+
+	// STEP 2: Malformed scopes are rejected
+	for _, raw := range []string{"posts", "posts:delete", "Posts:read", ":read"} {
+		if _, err := auth.ParseScopes(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+
+	// STEP 3: Actions include the ones below them
+	scopes = []string{"posts:write", "*:read"}
+	cases := []struct {
+		resource, action string
+		want             bool
+	}{
+		{"posts", auth.ActionRead, true},
+		{"posts", auth.ActionWrite, true},
+		{"posts", auth.ActionAdmin, false},
+		{"media", auth.ActionRead, true},
+		{"media", auth.ActionWrite, false},
+	}
+	for _, tc := range cases {
+		if got := auth.Granted(scopes, tc.resource, tc.action); got != tc.want {
+			t.Errorf("Expected %s:%s granted to be %v, but got %v", tc.resource, tc.action, tc.want, got)
+		}
+	}
+}
+
+func TestRequireScopes(t *testing.T) {
+	// STEP 1: Test Setup; the caller's scopes are set per request
+	t.Setenv("ADMIN_TOKEN", "secret")
+	var scopes []string
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "bot", Role: auth.RoleEditor, KeyID: 1, Scopes: scopes})
+	})
+	api := router.Group("/api/v1", middleware.RequireScopes())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	api.GET("/posts/:id", ok)
+	api.PUT("/posts/:id", ok)
+	api.PUT("/settings/:namespace/:key", middleware.RequireAdminToken(), ok)
+	api.Group("/admin", middleware.RequireAdminToken()).GET("/api-keys", ok)
+	api.POST("/submissions", ok)
+	api.GET("/me/notifications", ok)
+	router.Group("/api/v1", middleware.RequireReadScopes()).POST("/posts/:id/view", ok)
+	request := func(method, path string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set(middleware.AdminTokenHeader, "secret")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: Keys without scopes keep everything their role allows
+	if code := request(http.MethodPut, "/api/v1/posts/1"); code != http.StatusOK {
+		t.Fatalf("Expected status 200 without scopes, but got %d", code)
+	}
+
+	// STEP 3: A read-only key may read posts but not change them or settings
+	scopes = []string{"posts:read", "settings:write"}
+	cases := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/posts/1", http.StatusOK},
+		{http.MethodPut, "/api/v1/posts/1", http.StatusForbidden},
+		{http.MethodPut, "/api/v1/settings/site/title", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/admin/api-keys", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/submissions", http.StatusForbidden},
+		{http.MethodGet, "/api/v1/me/notifications", http.StatusForbidden},
+		{http.MethodPost, "/api/v1/posts/1/view", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if code := request(tc.method, tc.path); code != tc.want {
+			t.Errorf("Expected status %d for %s %s, but got %d", tc.want, tc.method, tc.path, code)
+		}
+	}
+
+	// STEP 4: Admin scopes open the routes behind the admin token
+	scopes = []string{"settings:admin", "api-keys:admin"}
+	if code := request(http.MethodPut, "/api/v1/settings/site/title"); code != http.StatusOK {
+		t.Errorf("Expected status 200 with settings:admin, but got %d", code)
+	}
+	if code := request(http.MethodGet, "/api/v1/admin/api-keys"); code != http.StatusOK {
+		t.Errorf("Expected status 200 with api-keys:admin, but got %d", code)
+	}
+}
+
+func TestCreateAPIKeyWithScopes(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/admin/api-keys", controllers.CreateAPIKey)
+	request := func(body string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPost, "/admin/api-keys", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// STEP 2: Malformed scopes are refused before anything is stored
+	if code := request(`{"name":"ci","role":"editor","scopes":"posts:everything"}`); code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", code)
+	}
+
+	// STEP 3: Valid scopes are stored normalised
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "api_keys" \("name","role","email","scopes","prefix","key_hash","created_at"\)`).
+		WithArgs("ci", "editor", "", "posts:write,media:write", sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	if code := request(`{"name":"ci","role":"editor","scopes":"posts:write, media:write"}`); code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/search"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSearchConfigFor(t *testing.T) {
+	// STEP 1: Locale Mapping; regional variants share their language's stemmer
+	cases := map[string]string{
+		"en":    search.ConfigEnglish,
+		"en-GB": search.ConfigEnglish,
+		"de_AT": search.ConfigGerman,
+		"DE":    search.ConfigGerman,
+		"fr":    search.ConfigSimple,
+		"":      search.ConfigSimple,
+	}
+	for locale, want := range cases {
+		if got := search.ConfigFor(locale); got != want {
+			t.Errorf("Expected locale %q to use %s, but got %s", locale, want, got)
+		}
+	}
+}
+
+func TestGetPagesSearch(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/pages", controllers.NewPageHandler(controllers.Deps{}).List)
+	now := time.Now()
+
+	// STEP 2: Each page is stemmed in its own locale by default
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE .*to_tsvector\(content_search_config\(pages\.locale\), pages\.title \|\| ' ' \|\| pages\.content\) @@ websearch_to_tsquery\(content_search_config\(pages\.locale\), \$\d+\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "locale", "created_at", "updated_at"}).
+			AddRow(1, "Häuser", "Unsere Häuser", "de", now, now))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/pages?q=Haus", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	// STEP 3: ?search_config= overrides the locale for every page
+	mock.ExpectQuery(`SELECT \* FROM "pages" WHERE .*to_tsvector\(\$\d+::regconfig, pages\.title \|\| ' ' \|\| pages\.content\) @@ websearch_to_tsquery\(\$\d+::regconfig, \$\d+\)`).
+		WithArgs("german", "german", "Haus", "published", sqlmock.AnyArg(), "members").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/pages?q=Haus&search_config=german", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	// STEP 4: Unknown configurations are rejected
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/pages?q=Haus&search_config=klingon", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
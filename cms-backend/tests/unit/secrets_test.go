@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"cms-backend/secrets"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadSecrets(t *testing.T) {
+	// STEP 1: Test Setup; the password is mounted as a file and the rest is in Vault
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db_password")
+	os.WriteFile(path, []byte("from-file\n"), 0o600)
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/cms" || r.Header.Get("X-Vault-Token") != "root" {
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"DB_PASSWORD": "from-vault", "ADMIN_TOKEN": "from-vault", "SMTP_PASSWORD": "from-vault"}, "metadata": {"version": 3}}}`))
+	}))
+	defer vault.Close()
+
+	for _, name := range secrets.Names {
+		t.Setenv(name, "")
+	}
+	t.Setenv("DB_PASSWORD_FILE", path)
+	t.Setenv("SMTP_PASSWORD", "from-env")
+	t.Setenv("SECRETS_PROVIDER", "vault")
+	t.Setenv("VAULT_ADDR", vault.URL)
+	t.Setenv("VAULT_SECRET_PATH", "/secret/data/cms")
+	t.Setenv("VAULT_TOKEN", "root")
+
+	// STEP 2: Loading
+	providers, err := secrets.ProvidersFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := secrets.Load(context.Background(), providers); err != nil {
+		t.Fatalf("Expected loading to succeed, but got %v", err)
+	}
+
+	// STEP 3: The environment wins, then files, then Vault
+	want := map[string]string{
+		"SMTP_PASSWORD":     "from-env",
+		"DB_PASSWORD":       "from-file",
+		"ADMIN_TOKEN":       "from-vault",
+		"MAILCHIMP_API_KEY": "",
+	}
+	for name, value := range want {
+		if got := os.Getenv(name); got != value {
+			t.Errorf("Expected %s to be %q, but got %q", name, value, got)
+		}
+	}
+
+	// STEP 4: A rejected Vault token stops startup
+	for _, name := range secrets.Names {
+		t.Setenv(name, "")
+	}
+	t.Setenv("VAULT_TOKEN", "wrong")
+	providers, _ = secrets.ProvidersFromEnv()
+	if err := secrets.Load(context.Background(), providers); err == nil {
+		t.Fatalf("Expected a rejected Vault token to fail")
+	}
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"cms-backend/seed"
+	"cms-backend/utils"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// seedLookups are the tables seed.Run looks records up in, with how many it
+// looks up in each for count posts
+func seedLookups(count int) []struct {
+	table string
+	n     int
+} {
+	return []struct {
+		table string
+		n     int
+	}{
+		{"tags", 8},
+		{"media", (count + 1) / 2},
+		{"posts", count},
+		{"series", 1},
+		{"pages", 5},
+	}
+}
+
+// expectSeed sets the expectations of one seed.Run with count posts. On an
+// empty database every record is inserted; when seeded, every lookup finds
+// its record and only the idempotent junction rows are written.
+func expectSeed(mock sqlmock.Sqlmock, count int, seeded bool) {
+	now := time.Now()
+	found := func(table string) *sqlmock.Rows {
+		columns := []string{"id"}
+		switch table {
+		case "posts", "pages":
+			columns = append(columns, "title", "status", "created_at", "updated_at")
+			return sqlmock.NewRows(columns).AddRow(1, "Demo", "published", now, now)
+		}
+		return sqlmock.NewRows(columns).AddRow(1)
+	}
+
+	mock.ExpectBegin()
+	for _, lookup := range seedLookups(count) {
+		for i := 0; i < lookup.n; i++ {
+			query := mock.ExpectQuery(`SELECT \* FROM "` + lookup.table + `" WHERE`)
+			if seeded {
+				query.WillReturnRows(found(lookup.table))
+				continue
+			}
+			query.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectQuery(`INSERT INTO "` + lookup.table + `"`).
+				WillReturnRows(sqlmock.NewRows([]string{"uuid", "id"}).AddRow("5b0c9f3e-8d1a-4c2e-9f6b-2a7d3e1c4b5a", i+1))
+			if lookup.table == "posts" {
+				mock.ExpectExec(`INSERT INTO "post_tags" .* ON CONFLICT DO NOTHING`).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+		}
+		if lookup.table == "series" && count > 0 {
+			mock.ExpectExec(`INSERT INTO "series_posts" .* ON CONFLICT DO NOTHING`).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+
+	// The demo team and an API key per role
+	query := mock.ExpectQuery(`SELECT \* FROM "groups" WHERE`)
+	if seeded {
+		query.WillReturnRows(found("groups"))
+	} else {
+		query.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectQuery(`INSERT INTO "groups"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	}
+	for i, role := range []string{"admin", "editor", "contributor", "delivery"} {
+		query := mock.ExpectQuery(`SELECT \* FROM "api_keys" WHERE`)
+		if seeded {
+			query.WillReturnRows(found("api_keys"))
+		} else {
+			query.WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			mock.ExpectQuery(`INSERT INTO "api_keys"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(i + 1))
+		}
+		if role == "editor" || role == "contributor" {
+			mock.ExpectExec(`INSERT INTO "group_members" .* ON CONFLICT DO NOTHING`).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+		}
+	}
+	mock.ExpectCommit()
+}
+
+func TestSeedRunTwiceCreatesNoDuplicates(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; the second run finds every record, and
+	// any INSERT besides the junction rows would fail it
+	expectSeed(mock, 2, false)
+	expectSeed(mock, 2, true)
+
+	// STEP 3: Seeding twice
+	first, err := seed.Run(db, 2)
+	if err != nil {
+		t.Fatalf("Expected the first run to succeed, but got %v", err)
+	}
+	second, err := seed.Run(db, 2)
+	if err != nil {
+		t.Fatalf("Expected the second run to succeed, but got %v", err)
+	}
+
+	// STEP 4: Result Validation
+	if first.Posts != 2 || first.Media != 1 || first.Pages != 5 || first.Tags != 8 || len(first.APIKeys) != 4 {
+		t.Errorf("Expected the first run to create everything, but got %+v", first)
+	}
+	if second.Posts != 0 || second.Media != 0 || second.Pages != 0 || second.Tags != 0 ||
+		second.Series != 0 || second.Groups != 0 || len(second.APIKeys) != 0 {
+		t.Errorf("Expected the second run to create nothing, but got %+v", second)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSeedRunHonorsCount(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	count, err := seed.CountFromArgs([]string{"--count", "5"})
+	if err != nil || count != 5 {
+		t.Fatalf("Expected --count 5 to be parsed, but got %d, %v", count, err)
+	}
+
+	// STEP 2: Database Expectations; five posts and three media items
+	expectSeed(mock, count, false)
+
+	// STEP 3: Seeding
+	summary, err := seed.Run(db, count)
+	if err != nil {
+		t.Fatalf("Expected seeding to succeed, but got %v", err)
+	}
+
+	// STEP 4: Result Validation
+	if summary.Posts != 5 || summary.Media != 3 {
+		t.Errorf("Expected 5 posts and 3 media items, but got %+v", summary)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSeedCountFromArgs(t *testing.T) {
+	cases := []struct {
+		args  []string
+		count int
+		ok    bool
+	}{
+		{nil, seed.DefaultCount, true},
+		{[]string{"--count", "3"}, 3, true},
+		{[]string{"--count=0"}, 0, true},
+		{[]string{"--count", "-1"}, 0, false},
+		{[]string{"--count", "many"}, 0, false},
+		{[]string{"extra"}, 0, false},
+	}
+	for _, tc := range cases {
+		count, err := seed.CountFromArgs(tc.args)
+		if (err == nil) != tc.ok || (tc.ok && count != tc.count) {
+			t.Errorf("CountFromArgs(%q): expected %d (ok %t), but got %d, %v", tc.args, tc.count, tc.ok, count, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"cms-backend/auth"
+	"cms-backend/models"
+	"cms-backend/serializers"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPostRedaction(t *testing.T) {
+	// STEP 1: Test Setup
+	post := models.Post{
+		Title:       "Roadmap",
+		Author:      "alice",
+		AuthorEmail: "alice@example.com",
+		Notes:       "Waiting on legal review",
+	}
+
+	cases := []struct {
+		name      string
+		caller    auth.Caller
+		showEmail bool
+		showNotes bool
+	}{
+		{"anonymous", auth.Caller{}, false, false},
+		{"delivery key", auth.Caller{Name: "website", Role: auth.RoleDelivery, KeyID: 1}, false, false},
+		{"other contributor", auth.Caller{Name: "bob", Role: auth.RoleContributor, KeyID: 2}, false, true},
+		{"own contributor", auth.Caller{Name: "alice", Role: auth.RoleContributor, KeyID: 3}, true, true},
+		{"editor", auth.Caller{Name: "carol", Role: auth.RoleEditor, KeyID: 4}, true, true},
+		{"admin", auth.Caller{Name: "root", Role: auth.RoleAdmin, KeyID: 5}, true, true},
+	}
+
+	// STEP 2: Redaction Validation
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			redacted := serializers.Post(tc.caller, post)
+			if (redacted.AuthorEmail != "") != tc.showEmail {
+				t.Fatalf("Expected author email visible=%v, but got '%s'", tc.showEmail, redacted.AuthorEmail)
+			}
+			if (redacted.Notes != "") != tc.showNotes {
+				t.Fatalf("Expected notes visible=%v, but got '%s'", tc.showNotes, redacted.Notes)
+			}
+		})
+	}
+
+	// The original must not be modified
+	if post.Notes == "" || post.AuthorEmail == "" {
+		t.Fatalf("Expected redaction to leave the original post untouched")
+	}
+}
+
+func TestPostResponseKeepsJSONContract(t *testing.T) {
+	// STEP 1: Test Setup; every field the model serializes is set
+	expires := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	group := uint(3)
+	post := models.Post{
+		ID: 7, UUID: "8f14e45f-ceea-467f-a0e6-7b1f5c2e9a10", Title: "Roadmap", Content: "Next steps",
+		ContentHTML: "<p>Next steps</p>", ReadingTime: 1, Author: "alice", AuthorEmail: "alice@example.com",
+		Slug: "roadmap", Status: models.StatusPublished, ExpiresAt: &expires, Visibility: models.VisibilityPublic,
+		EmbargoedUntil: &expires, Notes: "Waiting on legal review", Featured: true, Position: 2, OwnerGroupID: &group,
+		CreatedAt: expires, UpdatedAt: expires,
+		Media: []models.Media{{ID: 1, URL: "https://cdn.example.com/a.png"}}, Tags: []models.Tag{{ID: 1, Name: "go"}},
+	}
+
+	// STEP 2: Encoding
+	fromModel, err := json.Marshal(post)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromResponse, err := json.Marshal(serializers.Post(auth.Caller{Role: auth.RoleAdmin, KeyID: 1}, post))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// STEP 3: Contract Validation
+	if string(fromModel) != string(fromResponse) {
+		t.Fatalf("Expected the response to encode like the model\nmodel:    %s\nresponse: %s", fromModel, fromResponse)
+	}
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestCreateSeries(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "series" \("title","description","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING "id"`).
+		WithArgs("Go Basics", "A beginner tutorial", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT .* FROM "posts" JOIN series_posts ON series_posts\.post_id = posts\.id WHERE series_posts\.series_id = \$1 AND posts\.status = \$2 AND \(posts\.embargoed_until IS NULL OR posts\.embargoed_until <= \$3\) AND posts\.visibility <> \$4 ORDER BY series_posts\.position`).
+		WithArgs(1, "published", sqlmock.AnyArg(), "members").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "author", "created_at", "updated_at"}))
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/series", controllers.CreateSeries)
+	w := httptest.NewRecorder()
+	body := `{"title":"Go Basics","description":"A beginner tutorial"}`
+	req, _ := http.NewRequest(http.MethodPost, "/series", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", w.Code)
+	}
+
+	var response models.Series
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ID != 1 || response.Title != "Go Basics" {
+		t.Fatalf("Unexpected series in response: %+v", response)
+	}
+}
+
+func TestCreateSeriesRequiresTitle(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: HTTP Test Setup
+	router.POST("/series", controllers.CreateSeries)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/series", bytes.NewBufferString(`{"description":"No title"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/settings"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestValidateSettingValue(t *testing.T) {
+	cases := []struct {
+		typ   string
+		value string
+		valid bool
+	}{
+		{settings.TypeString, `"My site"`, true},
+		{settings.TypeString, `3`, false},
+		{settings.TypeInt, `42`, true},
+		{settings.TypeInt, `4.2`, false},
+		{settings.TypeFloat, `4.2`, true},
+		{settings.TypeBool, `true`, true},
+		{settings.TypeBool, `"true"`, false},
+		{settings.TypeJSON, `{"locales": ["en", "de"]}`, true},
+		{settings.TypeJSON, `"text"`, false},
+	}
+	for _, tc := range cases {
+		err := settings.Validate(tc.typ, json.RawMessage(tc.value))
+		if (err == nil) != tc.valid {
+			t.Errorf("Validate(%s, %s) = %v, expected valid %v", tc.typ, tc.value, err, tc.valid)
+		}
+	}
+}
+
+func TestSettingsStoreCaches(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	store := settings.NewStore(time.Minute)
+	now := time.Now()
+	store.Now = func() time.Time { return now }
+
+	// STEP 2: Database Expectations; the table is read once until invalidated
+	rows := func(locale string) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "namespace", "key", "type", "value"}).
+			AddRow(1, "site", "default_locale", "string", locale).
+			AddRow(2, "features", "comments", "bool", `true`)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).WillReturnRows(rows(`"en"`))
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).WillReturnRows(rows(`"de"`))
+
+	// STEP 3: Reads
+	if locale := store.String(db, "site", "default_locale", "fr"); locale != "en" {
+		t.Errorf("Expected locale en, but got %q", locale)
+	}
+	if !store.Bool(db, "features", "comments", false) {
+		t.Errorf("Expected the cached comments flag to be on")
+	}
+	if store.Bool(db, "features", "missing", false) {
+		t.Errorf("Expected the fallback for a missing setting")
+	}
+	store.Invalidate()
+	if locale := store.String(db, "site", "default_locale", "fr"); locale != "de" {
+		t.Errorf("Expected the reloaded locale de, but got %q", locale)
+	}
+
+	// STEP 4: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPutSettingKeepsType(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/settings/:namespace/:key", controllers.PutSetting)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "settings" WHERE namespace = \$1 AND key = \$2`).
+		WithArgs("site", "title", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "namespace", "key", "type", "value"}).
+			AddRow(1, "site", "title", "string", `""`))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/settings/site/title", bytes.NewBufferString(`{"type": "int", "value": 3}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPutSettingRejectsMistypedValue(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/settings/:namespace/:key", controllers.PutSetting)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "settings" WHERE namespace = \$1 AND key = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/settings/features/comments", bytes.NewBufferString(`{"type": "bool", "value": "yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestPutSettingRejectsInvalidCachePolicy(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/settings/:namespace/:key", controllers.PutSetting)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT \* FROM "settings" WHERE namespace = \$1 AND key = \$2`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/settings/cache/posts", bytes.NewBufferString(`{"type": "string", "value": "30 seconds"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/settings"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSitemapListsPublishedContent(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("SITE_URL", "https://example.com/")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/sitemap.xml", controllers.GetSitemap)
+
+	// STEP 2: Database Expectations
+	updated := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS count, MAX\(updated_at\) AS latest FROM "posts" WHERE status = \$1`).
+		WithArgs("published").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "latest"}).AddRow(3, updated))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS count, MAX\(updated_at\) AS latest FROM "pages" WHERE status = \$1`).
+		WithArgs("published").
+		WillReturnRows(sqlmock.NewRows([]string{"count", "latest"}).AddRow(1, updated))
+	// Republished posts are listed only when their canonical URL is on this site
+	mock.ExpectQuery(`SELECT id,slug,updated_at,canonical_url FROM "posts" WHERE status = \$1 ORDER BY id LIMIT \$2`).
+		WithArgs("published", 50000).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at", "canonical_url"}).
+			AddRow(1, "launch", updated, "").
+			AddRow(2, "guest-column", updated, "https://news.example.org/columns/42").
+			AddRow(4, "launch-recap", updated, "https://example.com/posts/launch-recap-original"))
+	mock.ExpectQuery(`SELECT id,slug,updated_at,parent_id FROM "pages" WHERE status = \$1 ORDER BY id LIMIT \$2`).
+		WithArgs("published", 50000).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at", "parent_id"}).
+			AddRow(1, "about", updated, nil).
+			AddRow(3, "team", updated, 1))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sitemap.xml", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	for _, expected := range []string{
+		"<urlset",
+		"<loc>https://example.com/posts/launch</loc>",
+		"<loc>https://example.com/about</loc>",
+		"<loc>https://example.com/about/team</loc>",
+		"<loc>https://example.com/posts/launch-recap-original</loc>",
+		"<lastmod>2026-03-04T05:06:07Z</lastmod>",
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected sitemap to contain %s, but got %s", expected, w.Body.String())
+		}
+	}
+	for _, unexpected := range []string{"guest-column", "news.example.org", "/posts/launch-recap<"} {
+		if strings.Contains(w.Body.String(), unexpected) {
+			t.Errorf("Expected sitemap to leave out %s, but got %s", unexpected, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSitemapIndexForLargeSites(t *testing.T) {
+	// STEP 1: Test Setup; two URLs per sitemap
+	t.Setenv("SITEMAP_PAGE_SIZE", "2")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/sitemap.xml", controllers.GetSitemap)
+
+	// STEP 2: Database Expectations
+	updated := time.Now()
+	mock.ExpectQuery(`FROM "posts"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "latest"}).AddRow(3, updated))
+	mock.ExpectQuery(`FROM "pages"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "latest"}).AddRow(1, updated))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sitemap.xml", nil)
+	req.Host = "cms.example.com"
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	for _, expected := range []string{
+		"<sitemapindex",
+		"<loc>http://cms.example.com/sitemaps/posts-1.xml</loc>",
+		"<loc>http://cms.example.com/sitemaps/posts-2.xml</loc>",
+		"<loc>http://cms.example.com/sitemaps/pages-1.xml</loc>",
+	} {
+		if !strings.Contains(w.Body.String(), expected) {
+			t.Errorf("Expected sitemap index to contain %s, but got %s", expected, w.Body.String())
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSitemapPart(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("SITE_URL", "https://example.com")
+	t.Setenv("SITEMAP_PAGE_SIZE", "2")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/sitemaps/:name", controllers.GetSitemapPart)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT id,slug,updated_at,canonical_url FROM "posts" WHERE status = \$1 ORDER BY id LIMIT \$2 OFFSET \$3`).
+		WithArgs("published", 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "slug", "updated_at", "canonical_url"}).AddRow(3, "third", time.Now(), ""))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sitemaps/posts-2.xml", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "<loc>https://example.com/posts/third</loc>") {
+		t.Errorf("Expected the third post, but got %s", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+
+	// Unknown sitemaps are not found
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/sitemaps/media-1.xml", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, but got %d", w.Code)
+	}
+}
+
+func TestGetRobots(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/robots.txt", controllers.GetRobots)
+	settings.Default.Invalidate()
+	defer settings.Default.Invalidate()
+
+	// STEP 2: Database Expectations; without the setting the default is served
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "namespace", "key", "type", "value"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/robots.txt", nil)
+	req.Host = "cms.example.com"
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "Sitemap: http://cms.example.com/sitemap.xml") {
+		t.Errorf("Expected the sitemap to be advertised, but got %s", w.Body.String())
+	}
+
+	// STEP 5: A configured robots.txt replaces the default
+	settings.Default.Invalidate()
+	mock.ExpectQuery(`SELECT \* FROM "settings"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "namespace", "key", "type", "value"}).
+			AddRow(1, "seo", "robots_txt", "string", `"User-agent: *\nDisallow: /"`))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/robots.txt", nil)
+	router.ServeHTTP(w, req)
+	if w.Body.String() != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("Expected the configured robots.txt, but got %q", w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
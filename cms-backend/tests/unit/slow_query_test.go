@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSlowQueries(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/admin/slow-queries", controllers.GetSlowQueries)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM pg_extension WHERE extname = 'pg_stat_statements'`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT query, calls, total_exec_time AS total_time, mean_exec_time AS mean_time, rows\s+FROM pg_stat_statements .* ORDER BY mean_exec_time DESC\s+LIMIT \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"query", "calls", "total_time", "mean_time", "rows"}).
+			AddRow("SELECT * FROM posts WHERE title ILIKE $1", 12, 840.5, 70.04, 36))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/slow-queries?limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var queries []controllers.SlowQuery
+	if err := json.Unmarshal(w.Body.Bytes(), &queries); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(queries) != 1 || queries[0].Calls != 12 || queries[0].MeanTime != 70.04 {
+		t.Errorf("Expected the recorded statement, but got %+v", queries)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSlowQueriesWithoutExtension(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/admin/slow-queries", controllers.GetSlowQueries)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM pg_extension`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/admin/slow-queries", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("Expected status 501, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
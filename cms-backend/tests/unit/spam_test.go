@@ -0,0 +1,167 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/spam"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+// stubSpamChecker scores everything mentioning "casino" as spam and records
+// what it learns
+type stubSpamChecker struct {
+	learned []bool
+}
+
+func (s *stubSpamChecker) Score(ctx context.Context, db *gorm.DB, content spam.Content) (float64, error) {
+	if strings.Contains(content.Body, "casino") {
+		return 0.97, nil
+	}
+	return 0.1, nil
+}
+
+func (s *stubSpamChecker) Learn(ctx context.Context, db *gorm.DB, content spam.Content, isSpam bool) error {
+	s.learned = append(s.learned, isSpam)
+	return nil
+}
+
+func TestSubmitFormHoldsSpam(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/forms/:slug/submissions", controllers.SubmitForm)
+	spam.Default = &spam.Filter{Checker: &stubSpamChecker{}, HoldScore: 0.9}
+	defer func() { spam.Default = nil }()
+
+	// STEP 2: Database Expectations; the submission is stored held with its score
+	expectContactForm(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "form_submissions" \("form_id","data","ip","user_agent","status","spam_score","created_at"\)`).
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg(), "", "held", 0.97, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	jsonData, _ := json.Marshal(map[string]interface{}{"email": "bot@example.com", "message": "Best casino"})
+	w := submitForm(router, "application/json", jsonData)
+
+	// STEP 4: The sender cannot tell it was held
+	if w.Code != http.StatusCreated || !strings.Contains(w.Body.String(), "Submission received") {
+		t.Fatalf("Expected status 201, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestModerateFormSubmissionTeachesFilter(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/forms/:id/submissions/:submissionId/status", controllers.ModerateFormSubmission)
+	checker := &stubSpamChecker{}
+	spam.Default = &spam.Filter{Checker: checker, HoldScore: 0.9}
+	defer func() { spam.Default = nil }()
+
+	// STEP 2: Database Expectations; a held submission is released
+	mock.ExpectQuery(`SELECT \* FROM "forms" WHERE "forms"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug"}).AddRow(1, "Contact", "contact"))
+	mock.ExpectQuery(`SELECT \* FROM "form_fields" WHERE "form_fields"\."form_id" = \$1 ORDER BY position`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "form_id", "name", "type"}).
+			AddRow(1, 1, "email", "email").
+			AddRow(2, 1, "message", "textarea"))
+	mock.ExpectQuery(`SELECT \* FROM "form_submissions" WHERE id = \$1 AND form_id = \$2 ORDER BY "form_submissions"\."id" LIMIT \$3`).
+		WithArgs("7", 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "form_id", "data", "status", "spam_score"}).
+			AddRow(7, 1, `{"email":"ada@example.com","message":"Casino night fundraiser"}`, "held", 0.95))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "form_submissions" SET "status"=\$1 WHERE "id" = \$2`).
+		WithArgs("accepted", 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/forms/1/submissions/7/status", bytes.NewBufferString(`{"status": "accepted"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: The filter learns the submission was legitimate
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if len(checker.learned) != 1 || checker.learned[0] {
+		t.Fatalf("Expected the filter to learn one legitimate submission, but got %v", checker.learned)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAkismetScore(t *testing.T) {
+	// STEP 1: Test Setup; the service flags the viagra seller
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		if r.URL.Path != "/comment-check" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(strconv.FormatBool(strings.Contains(form.Get("comment_author"), "viagra"))))
+	}))
+	defer server.Close()
+	akismet := &spam.Akismet{URL: server.URL, Key: "key", Site: "https://example.com", Client: server.Client()}
+
+	// STEP 2: Spam scores 1 and the entry is described to the service
+	score, err := akismet.Score(context.Background(), nil, spam.Content{Type: "contact-form", Author: "viagra-test-123", IP: "203.0.113.9"})
+	if err != nil || score != 1 {
+		t.Fatalf("Expected a score of 1, but got %v, %v", score, err)
+	}
+	if form.Get("api_key") != "key" || form.Get("blog") != "https://example.com" || form.Get("user_ip") != "203.0.113.9" {
+		t.Fatalf("Unexpected request %v", form)
+	}
+
+	// STEP 3: Anything else scores 0
+	if score, err := akismet.Score(context.Background(), nil, spam.Content{Author: "Ada"}); err != nil || score != 0 {
+		t.Fatalf("Expected a score of 0, but got %v, %v", score, err)
+	}
+}
+
+func TestBayesScore(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; "casino" shows up in spam only
+	mock.ExpectQuery(`SELECT \* FROM "spam_tokens" WHERE token IN \(\$1,\$2,\$3\)`).
+		WithArgs("cheap", "casino", "").
+		WillReturnRows(sqlmock.NewRows([]string{"token", "spam_count", "ham_count"}).
+			AddRow("", 20, 40).
+			AddRow("casino", 18, 0).
+			AddRow("cheap", 6, 4))
+
+	// STEP 3: The entry scores as spam
+	score, err := spam.Bayes{}.Score(context.Background(), db, spam.Content{Body: "Cheap casino!"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score < 0.9 {
+		t.Fatalf("Expected a high spam score, but got %v", score)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetStats(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT status AS value, COUNT\(\*\) AS count FROM "posts" GROUP BY "status"`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("published", 3).AddRow("draft", 1))
+	mock.ExpectQuery(`SELECT status AS value, COUNT\(\*\) AS count FROM "pages" GROUP BY "status"`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("published", 2))
+	mock.ExpectQuery(`SELECT author, COUNT\(\*\) AS posts FROM "posts" GROUP BY "author" ORDER BY posts DESC,author`).
+		WillReturnRows(sqlmock.NewRows([]string{"author", "posts"}).AddRow("Ada", 3).AddRow("Grace", 1))
+	mock.ExpectQuery(`SELECT COUNT\(\*\) AS count, COALESCE\(SUM\(size\), 0\) AS total_bytes FROM "media"`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "total_bytes"}).AddRow(2, 3072))
+	mock.ExpectQuery(`SELECT type AS value, COUNT\(\*\) AS count FROM "media" GROUP BY "type"`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "count"}).AddRow("image", 2))
+	mock.ExpectQuery(`SELECT id, title AS title, created_at, updated_at FROM "posts" ORDER BY updated_at DESC LIMIT \$1`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "updated_at"}).
+			AddRow(1, "Older post", yesterday, today.Add(time.Hour)))
+	mock.ExpectQuery(`SELECT id, title AS title, created_at, updated_at FROM "pages" ORDER BY updated_at DESC LIMIT \$1`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "updated_at"}).
+			AddRow(4, "About", today.Add(2*time.Hour), today.Add(2*time.Hour)))
+	mock.ExpectQuery(`SELECT id, url AS title, created_at, updated_at FROM "media" ORDER BY updated_at DESC LIMIT \$1`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "created_at", "updated_at"}))
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, created_at AT TIME ZONE \$2\) AT TIME ZONE \$3 AS bucket, COUNT\(\*\) AS count FROM "posts" WHERE created_at >= \$4 GROUP BY "bucket"`).
+		WithArgs("day", "UTC", "UTC", yesterday).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(yesterday, 1))
+	mock.ExpectQuery(`SELECT date_trunc\(\$1, created_at AT TIME ZONE \$2\) AT TIME ZONE \$3 AS bucket, COUNT\(\*\) AS count FROM "pages" WHERE created_at >= \$4 GROUP BY "bucket"`).
+		WithArgs("day", "UTC", "UTC", yesterday).
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(today, 1))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/stats", controllers.GetStats)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/stats?since="+now.Add(-24*time.Hour).Format(time.RFC3339), nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	var stats controllers.Stats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if stats.Posts["published"] != 3 || stats.Posts["draft"] != 1 || stats.Pages["published"] != 2 {
+		t.Fatalf("Unexpected status counts: posts %v, pages %v", stats.Posts, stats.Pages)
+	}
+	if len(stats.PostsPerAuthor) != 2 || stats.PostsPerAuthor[0].Author != "Ada" || stats.PostsPerAuthor[0].Posts != 3 {
+		t.Fatalf("Unexpected posts per author: %+v", stats.PostsPerAuthor)
+	}
+	if stats.Media.Count != 2 || stats.Media.TotalBytes != 3072 || stats.Media.ByType["image"] != 2 {
+		t.Fatalf("Unexpected media stats: %+v", stats.Media)
+	}
+	if len(stats.RecentActivity) != 2 ||
+		stats.RecentActivity[0].Type != "page" || stats.RecentActivity[0].Action != "created" ||
+		stats.RecentActivity[1].Type != "post" || stats.RecentActivity[1].Action != "updated" {
+		t.Fatalf("Unexpected recent activity: %+v", stats.RecentActivity)
+	}
+	if len(stats.CreatedOverTime) != 2 ||
+		stats.CreatedOverTime[0].Posts != 1 || stats.CreatedOverTime[0].Pages != 0 ||
+		stats.CreatedOverTime[1].Posts != 0 || stats.CreatedOverTime[1].Pages != 1 {
+		t.Fatalf("Unexpected buckets: %+v", stats.CreatedOverTime)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetStatsRejectsUnknownInterval(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: HTTP Test Setup
+	router.GET("/stats", controllers.GetStats)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/stats?interval=hour", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
+
+func TestGetStatsLocalizesBuckets(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(tokyo)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, tokyo)
+
+	// STEP 2: Database Expectations; days start at midnight in Tokyo
+	for _, query := range []string{
+		`SELECT status AS value`, `SELECT status AS value`, `SELECT author`, `SELECT COUNT`, `SELECT type AS value`,
+		`SELECT id, title`, `SELECT id, title`, `SELECT id, url`,
+	} {
+		mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	}
+	for _, table := range []string{"posts", "pages"} {
+		mock.ExpectQuery(`AT TIME ZONE \$2\) AT TIME ZONE \$3 AS bucket, COUNT\(\*\) AS count FROM "`+table+`"`).
+			WithArgs("day", "Asia/Tokyo", "Asia/Tokyo", today).
+			WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(today.UTC(), 2))
+	}
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/stats", controllers.GetStats)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/stats?since="+url.QueryEscape(now.Format(time.RFC3339)), nil)
+	req.Header.Set("Accept-Timezone", "Asia/Tokyo")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		CreatedOverTime []struct {
+			Start string `json:"start"`
+			Posts int64  `json:"posts"`
+			Pages int64  `json:"pages"`
+		} `json:"created_over_time"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(body.CreatedOverTime) != 1 || body.CreatedOverTime[0].Start != today.Format(time.RFC3339) ||
+		body.CreatedOverTime[0].Posts != 2 || body.CreatedOverTime[0].Pages != 2 {
+		t.Fatalf("Unexpected buckets: %+v", body.CreatedOverTime)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetStatsRejectsUnknownTimeZone(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: HTTP Test Setup
+	router.GET("/stats", controllers.GetStats)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/stats?tz=Mars/Olympus", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/middleware"
+	"cms-backend/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCreateSubmissionEnforcesPendingLimit(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	t.Setenv("SUBMISSION_MAX_PENDING", "2")
+	router.POST("/submissions", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "freelancer", Role: auth.RoleSubmitter, KeyID: 9})
+	}, controllers.CreateSubmission)
+
+	// STEP 2: Database Expectations; two submissions already await review
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "submissions" WHERE api_key_id = \$1 AND status = \$2`).
+		WithArgs(9, "pending").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/submissions", bytes.NewBufferString(`{"title": "Pitch", "content": "Story"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSubmissionScopedToSubmitter(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/submissions/:id", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "freelancer", Role: auth.RoleSubmitter, KeyID: 9})
+	}, controllers.GetSubmission)
+
+	// STEP 2: Database Expectations; submission 4 belongs to another key
+	mock.ExpectQuery(`SELECT \* FROM "submissions" WHERE api_key_id = \$1 AND "submissions"\."id" = \$2`).
+		WithArgs(9, "4", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/submissions/4", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected status 404, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRejectSubmissionRequiresReviewer(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/submissions/:id/reject", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "freelancer", Role: auth.RoleSubmitter, KeyID: 9})
+	}, controllers.RejectSubmission)
+
+	// STEP 2: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/submissions/4/reject", bytes.NewBufferString(`{"note": "no"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("Expected status 403, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDenyRole(t *testing.T) {
+	// STEP 1: Test Setup
+	router := gin.New()
+	router.GET("/posts", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "key", Role: c.GetHeader("X-Role"), KeyID: 1})
+	}, middleware.DenyRole(auth.RoleSubmitter), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for role, expected := range map[string]int{
+		auth.RoleSubmitter: http.StatusForbidden,
+		auth.RoleEditor:    http.StatusNoContent,
+	} {
+		// STEP 2: HTTP Test Setup
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodGet, "/posts", nil)
+		req.Header.Set("X-Role", role)
+		router.ServeHTTP(w, req)
+
+		// STEP 3: Response Validation
+		if w.Code != expected {
+			t.Errorf("Expected status %d for %s, but got %d", expected, role, w.Code)
+		}
+	}
+}
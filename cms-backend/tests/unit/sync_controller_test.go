@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetSyncCollapsesDeltas(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/sync", controllers.GetSync)
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"seq":4}`))
+
+	// STEP 2: Database Expectations; post 1 is created then edited, post 2 is
+	// edited but no longer published and page 3 is deleted
+	now := time.Now()
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(pruned_through\), 0\) FROM "change_prunes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(2))
+	mock.ExpectQuery(`SELECT \* FROM "changes" WHERE seq > \$1 ORDER BY seq LIMIT \$2`).
+		WithArgs(4, 101).
+		WillReturnRows(sqlmock.NewRows([]string{"seq", "entity_type", "entity_id", "action", "changed_at"}).
+			AddRow(5, "post", 1, "insert", now).
+			AddRow(6, "post", 1, "update", now).
+			AddRow(7, "post", 2, "update", now).
+			AddRow(8, "page", 3, "delete", now))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE id IN \(\$1,\$2\) AND status = \$3 ORDER BY id`).
+		WithArgs(1, 2, "published").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "status", "created_at", "updated_at"}).
+			AddRow(1, "Opening hours", "Content", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sync?cursor="+cursor, nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response controllers.SyncResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response.Created) != 1 || response.Created[0].ID != 1 || len(response.Updated) != 0 {
+		t.Fatalf("Expected post 1 once as created, but got %+v / %+v", response.Created, response.Updated)
+	}
+	if len(response.Deleted) != 2 || response.Deleted[0].ID != 2 || response.Deleted[1].EntityType != "page" {
+		t.Fatalf("Expected tombstones for post 2 and page 3, but got %+v", response.Deleted)
+	}
+	if response.Cursor != base64.RawURLEncoding.EncodeToString([]byte(`{"seq":8}`)) || response.HasMore {
+		t.Fatalf("Expected the cursor to advance to 8, but got %q", response.Cursor)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSyncRejectsPrunedCursor(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/sync", controllers.GetSync)
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"seq":4}`))
+
+	// STEP 2: Database Expectations; retention pruned changes through 10
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(pruned_through\), 0\) FROM "change_prunes"`).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(10))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/sync?cursor="+cursor, nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusGone {
+		t.Fatalf("Expected status 410, but got %d", w.Code)
+	}
+	var response utils.HTTPError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ErrorCode != utils.ErrorCodeCursorExpired {
+		t.Fatalf("Expected error code %s, but got %q", utils.ErrorCodeCursorExpired, response.ErrorCode)
+	}
+}
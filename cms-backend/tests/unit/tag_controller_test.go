@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestGetTags(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).
+		AddRow(1, "golang", now, now).
+		AddRow(2, "press", now, now)
+	mock.ExpectQuery(`SELECT \* FROM "tags" ORDER BY name`).WillReturnRows(rows)
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/tags", controllers.GetTags)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/tags", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+
+	var response []models.Tag
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 2 {
+		t.Fatalf("Expected 2 tags, but got %d", len(response))
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "tags" WHERE name = \$1`).
+		WithArgs("golang").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "tags" \("name","parent_id","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING "id"`).
+		WithArgs("golang", nil, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/tags", controllers.CreateTag)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/tags", bytes.NewBufferString(`{"name":" golang "}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, but got %d", w.Code)
+	}
+
+	var response models.Tag
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.Name != "golang" {
+		t.Fatalf("Expected name 'golang', but got '%s'", response.Name)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/tags/:id/merge", controllers.MergeTags)
+
+	// STEP 2: Database Expectations; "golang" is merged into "go"
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "tags" WHERE "tags"\."id" = \$1 ORDER BY "tags"\."id" LIMIT \$2`).
+		WithArgs("2", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(2, "golang", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "tags" WHERE "tags"\."id" = \$1 ORDER BY "tags"\."id" LIMIT \$2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(1, "go", now, now))
+	mock.ExpectQuery(`SELECT "id","parent_id" FROM "tags" WHERE id = \$1 LIMIT \$2`).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(1, nil))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO post_tags \(post_id, tag_id\)\s+SELECT post_id, \$1 FROM post_tags WHERE tag_id = \$2\s+ON CONFLICT DO NOTHING`).
+		WithArgs(1, 2).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE "tags" SET "parent_id"=\$1,"updated_at"=\$2 WHERE parent_id = \$3`).
+		WithArgs(1, sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM "redirects" WHERE from_path = \$1`).
+		WithArgs("/tags/go").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "redirects" SET "to_path"=\$1,"updated_at"=\$2 WHERE to_path = \$3`).
+		WithArgs("/tags/go", sqlmock.AnyArg(), "/tags/golang").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`INSERT INTO "redirects" .* ON CONFLICT \("from_path"\) DO UPDATE`).
+		WithArgs("/tags/golang", "/tags/go", 301, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec(`DELETE FROM "tags" WHERE "tags"\."id" = \$1`).
+		WithArgs(2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/tags/2/merge", bytes.NewBufferString(`{"into":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateTagRejectsNestingUnderSubtag(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.PUT("/tags/:id", controllers.UpdateTag)
+
+	// STEP 2: Database Expectations; tag 3 is a subtag of tag 1
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM "tags" WHERE "tags"\."id" = \$1 ORDER BY "tags"\."id" LIMIT \$2`).
+		WithArgs("1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "created_at", "updated_at"}).AddRow(1, "languages", now, now))
+	mock.ExpectQuery(`SELECT "id","parent_id" FROM "tags" WHERE id = \$1 LIMIT \$2`).
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id"}).AddRow(3, 1))
+
+	// STEP 3: HTTP Test Setup
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPut, "/tags/1", bytes.NewBufferString(`{"parent_id":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d: %s", w.Code, w.Body.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSuggestTags(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/posts/:id/suggest-tags", controllers.SuggestTags)
+	content := "Kubernetes clusters run containers. Scaling kubernetes clusters needs care, " +
+		"and the deployment of containers is the everyday work."
+
+	// STEP 2: Database Expectations; "deployment" is common across the corpus
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE "posts"\."id" = \$1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content"}).AddRow(1, "Kubernetes in practice", content))
+	mock.ExpectQuery(`SELECT "id","title","content" FROM "posts" ORDER BY "posts"\."id" LIMIT \$1`).
+		WithArgs(200).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content"}).
+			AddRow(1, "Kubernetes in practice", content).
+			AddRow(2, "Release notes", "A new deployment process").
+			AddRow(3, "Deployment checklist", "Steps for every deployment"))
+	mock.ExpectQuery(`SELECT \* FROM "tags" ORDER BY name`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow(1, "Deployment").
+			AddRow(2, "Kubernetes").
+			AddRow(3, "Containers").
+			AddRow(4, "Databases"))
+	mock.ExpectQuery(`SELECT "tag_id" FROM "post_tags" WHERE post_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"tag_id"}).AddRow(3))
+
+	// STEP 3: HTTP Test Setup
+	w := postJSON(router, "/posts/1/suggest-tags", nil)
+
+	// STEP 4: Candidates rank by how distinctive they are; title words count twice
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var response []controllers.TagSuggestion
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	var names []string
+	for _, suggestion := range response {
+		names = append(names, suggestion.Name)
+	}
+	if len(response) != 4 || names[0] != "Kubernetes" || names[1] != "clusters" || names[2] != "practice" || names[3] != "Deployment" {
+		t.Fatalf("Unexpected suggestions %v", names)
+	}
+	if response[0].Score != 1 || response[0].TagID == nil || response[1].TagID != nil {
+		t.Errorf("Unexpected suggestions %+v", response)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"cms-backend/utils"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+func TestWithTransaction(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: A nil result commits
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error { return nil }); err != nil {
+		t.Fatalf("Expected no error, but got %v", err)
+	}
+
+	// STEP 3: A returned error rolls back and is passed on
+	failed := errors.New("failed")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	if err := utils.WithTransaction(db, func(tx *gorm.DB) error { return failed }); err != failed {
+		t.Fatalf("Expected the returned error, but got %v", err)
+	}
+
+	// STEP 4: A panic rolls back and carries on
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("Expected the panic to carry on, but recovered %v", r)
+			}
+		}()
+		utils.WithTransaction(db, func(tx *gorm.DB) error { panic("boom") })
+	}()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/trash"
+	"cms-backend/utils"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestPurgeTrash(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+	policy := &trash.Policy{RetentionDays: 30}
+
+	// STEP 2: Database Expectations; only media nothing refers to is deleted
+	mock.ExpectQuery(`SELECT "media"\."id" FROM "media" WHERE media\.created_at < \$1 AND NOT EXISTS \(SELECT 1 FROM post_media .*\) AND NOT EXISTS \(SELECT 1 FROM playlist_items .*\) AND NOT EXISTS \(SELECT 1 FROM users .*\) AND NOT EXISTS \(SELECT 1 FROM posts WHERE strpos\(posts\.content, media\.url\) > 0\) AND NOT EXISTS \(SELECT 1 FROM pages .*\) ORDER BY media\.id LIMIT \$2`).
+		WithArgs(now.AddDate(0, 0, -30), 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(8))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "media" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(3, 8).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	// STEP 3: Task Execution
+	if err := policy.Purge(context.Background(), db, now); err != nil {
+		t.Fatalf("Expected the purge to succeed, but got %v", err)
+	}
+
+	// STEP 4: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetTrashReport(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.GET("/reports/trash", controllers.GetTrashReport)
+	defer func(policy *trash.Policy) { trash.Default = policy }(trash.Default)
+	trash.Default = nil
+
+	// STEP 2: Without a policy, a retention period must be given
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/reports/trash", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+
+	// STEP 3: ?days= previews the purge without deleting anything
+	mock.ExpectQuery(`SELECT \* FROM "media" WHERE media\.created_at < \$1 AND .* ORDER BY media\.created_at,media\.id`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "type"}).AddRow(3, "https://cdn.example.com/old.png", "image/png"))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest(http.MethodGet, "/reports/trash?days=90", nil)
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+	var report trash.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if report.RetentionDays != 90 || len(report.Media) != 1 || report.Media[0].ID != 3 {
+		t.Errorf("Expected media 3 in a 90 day report, but got %+v", report)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
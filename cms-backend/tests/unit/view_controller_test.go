@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"cms-backend/controllers"
+	"cms-backend/utils"
+	"cms-backend/views"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestViewCounterDebouncesAndFlushes(t *testing.T) {
+	// STEP 1: Test Setup
+	_, db, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Date(2026, 3, 14, 15, 0, 0, 0, time.UTC)
+	counter := views.NewCounter()
+	counter.Now = func() time.Time { return now }
+
+	// STEP 2: Repeat views by one viewer count once within the debounce window
+	counter.Record(1, "10.0.0.1|browser")
+	counter.Record(1, "10.0.0.1|browser")
+	counter.Record(1, "10.0.0.2|browser")
+	now = now.Add(views.DebounceWindow)
+	counter.Record(1, "10.0.0.1|browser")
+
+	// STEP 3: Database Expectations
+	mock.ExpectQuery(`SELECT "id" FROM "posts" WHERE id IN \(\$1\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "post_views" \("post_id","day","views"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \("post_id","day"\) DO UPDATE SET "views"=post_views.views \+ EXCLUDED.views`).
+		WithArgs(1, time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC), 3).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// STEP 4: Flush writes the batch, and a second flush has nothing to write
+	if err := counter.Flush(context.Background(), db); err != nil {
+		t.Fatalf("Expected flush to succeed, but got %v", err)
+	}
+	if err := counter.Flush(context.Background(), db); err != nil {
+		t.Fatalf("Expected empty flush to succeed, but got %v", err)
+	}
+
+	// STEP 5: Expectation Validation
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetPopularPosts(t *testing.T) {
+	// STEP 1: Test Setup
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	now := time.Now()
+
+	// STEP 2: Database Expectations
+	mock.ExpectQuery(`SELECT post_views.post_id, SUM\(post_views.views\) AS views FROM "post_views" JOIN posts ON posts.id = post_views.post_id WHERE post_views.day >= \$1 AND posts.status = \$2 GROUP BY "post_views"."post_id" ORDER BY views DESC,post_views.post_id LIMIT \$3`).
+		WithArgs(sqlmock.AnyArg(), "published", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "views"}).AddRow(7, 120).AddRow(3, 45))
+	mock.ExpectQuery(`SELECT \* FROM "posts" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(7, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "content", "status", "created_at", "updated_at"}).
+			AddRow(3, "Less popular", "Content", "published", now, now).
+			AddRow(7, "Most popular", "Content", "published", now, now))
+	mock.ExpectQuery(`SELECT \* FROM "post_media" WHERE "post_media"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "media_id"}))
+	mock.ExpectQuery(`SELECT \* FROM "post_tags" WHERE "post_tags"\."post_id" IN \(\$1,\$2\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"post_id", "tag_id"}))
+
+	// STEP 3: HTTP Test Setup
+	router.GET("/posts/popular", controllers.GetPopularPosts)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/posts/popular?days=30&limit=2", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d: %s", w.Code, w.Body.String())
+	}
+
+	var response []controllers.PopularPost
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if len(response) != 2 || response[0].Post.ID != 7 || response[0].Views != 120 || response[1].Post.ID != 3 {
+		t.Fatalf("Expected posts ordered by views [7 3], but got %+v", response)
+	}
+}
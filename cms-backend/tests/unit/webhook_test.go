@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"bytes"
+	"cms-backend/auth"
+	"cms-backend/controllers"
+	"cms-backend/models"
+	"cms-backend/utils"
+	"cms-backend/webhooks"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookFilter(t *testing.T) {
+	// STEP 1: Test Setup
+	event := webhooks.Event{Entity: "post", Action: "update", ID: 7, Status: "published", Tags: []string{"press", "Product"}}
+
+	cases := []struct {
+		filter string
+		match  bool
+	}{
+		{"", true},
+		{"entity=post AND status=published AND tag=press", true},
+		{"entity=post and status=draft", false},
+		{"entity=page OR tag=product", true},
+		{"tag!=press", false},
+		{"NOT (entity=page OR status=archived) AND id=7", true},
+		{`tag="press" AND action != delete`, true},
+		{"entity=media OR entity=page AND status=published", false},
+	}
+
+	// STEP 2: Filter Evaluation
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			filter, err := webhooks.ParseFilter(tc.filter)
+			if err != nil {
+				t.Fatalf("Expected %q to parse, but got %v", tc.filter, err)
+			}
+			if got := filter.Match(event); got != tc.match {
+				t.Fatalf("Expected %q to match %v, but got %v", tc.filter, tc.match, got)
+			}
+		})
+	}
+
+	// STEP 3: Invalid filters are rejected
+	for _, expr := range []string{"entity", "colour=red", "entity=post AND", "(entity=post", `tag="press`, "entity=post status=draft"} {
+		if _, err := webhooks.ParseFilter(expr); err == nil {
+			t.Errorf("Expected %q to be rejected", expr)
+		}
+	}
+}
+
+func TestCreateWebhookRejectsInvalidFilter(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+	router.POST("/webhooks", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "search", Role: auth.RoleDelivery, KeyID: 3})
+	}, controllers.CreateWebhook)
+
+	// STEP 2: HTTP Test Setup
+	body, _ := json.Marshal(map[string]string{
+		"name":   "Search index",
+		"url":    "http://127.0.0.1:9000/hook",
+		"filter": "entity=post AND colour=red",
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/webhooks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	// STEP 3: Response Validation
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, but got %d", w.Code)
+	}
+	var response utils.HTTPError
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if response.ErrorCode != utils.ErrorCodeInvalidFilter {
+		t.Fatalf("Expected error code %s, but got %q", utils.ErrorCodeInvalidFilter, response.ErrorCode)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestTestWebhookReportsResponse(t *testing.T) {
+	// STEP 1: Test Setup
+	t.Setenv("EGRESS_ALLOW_PRIVATE", "true")
+	var signature string
+	var payload webhooks.Payload
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(webhooks.SignatureHeader)
+		if signature != webhooks.Sign("whsec_test", body) {
+			signature = "invalid"
+		}
+		json.Unmarshal(body, &payload)
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("queued"))
+	}))
+	defer endpoint.Close()
+
+	router, _, mock := utils.SetupRouterAndMockDB(t)
+	defer mock.ExpectClose()
+
+	// STEP 2: Database Expectations; the webhook is scoped to the caller's key
+	mock.ExpectQuery(`SELECT \* FROM "webhooks" WHERE api_key_id = \$1 AND "webhooks"\."id" = \$2`).
+		WithArgs(3, "5", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "api_key_id", "name", "url", "filter", "payload_version", "secret", "active"}).
+			AddRow(5, 3, "Search index", endpoint.URL, "entity=post", 1, "whsec_test", true))
+
+	// STEP 3: HTTP Test Setup
+	router.POST("/webhooks/:id/test", func(c *gin.Context) {
+		auth.SetCaller(c, auth.Caller{Name: "search", Role: auth.RoleDelivery, KeyID: 3})
+	}, controllers.TestWebhook)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/webhooks/5/test", nil)
+	router.ServeHTTP(w, req)
+
+	// STEP 4: Response Validation
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, but got %d", w.Code)
+	}
+	var result webhooks.Result
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("Error unmarshaling response: %v", err)
+	}
+	if !result.Success || result.StatusCode != http.StatusAccepted || result.Response != "queued" {
+		t.Fatalf("Expected a successful 202 delivery, but got %+v", result)
+	}
+	if signature == "invalid" || signature == "" {
+		t.Fatalf("Expected the sample payload to be signed with the webhook secret")
+	}
+	if payload.Event != webhooks.TestEvent {
+		t.Fatalf("Expected a %s event, but got %q", webhooks.TestEvent, payload.Event)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("There were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestWebhookPayloadRendering(t *testing.T) {
+	// STEP 1: Test Setup
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	payload := webhooks.NewPayload(
+		models.Change{Seq: 42, EntityType: "post", EntityID: 7, Action: models.ChangeUpdate, ChangedAt: now},
+		webhooks.Event{Entity: "post", Action: models.ChangeUpdate, ID: 7, Status: models.StatusPublished, Tags: []string{"press"}},
+	)
+	hook := models.Webhook{ID: 5, PayloadVersion: webhooks.PayloadV1}
+
+	// STEP 2: Version 1 is the flat payload
+	body, version, err := webhooks.Render(hook, payload)
+	if err != nil || version != "1" {
+		t.Fatalf("Expected a version 1 body, but got %q, %v", version, err)
+	}
+	var v1 webhooks.Payload
+	if err := json.Unmarshal(body, &v1); err != nil || v1.Event != "post.update" || v1.Seq != 42 {
+		t.Fatalf("Expected the flat payload, but got %s", body)
+	}
+
+	// STEP 3: Version 2 wraps the change in an envelope
+	hook.PayloadVersion = webhooks.PayloadV2
+	body, version, err = webhooks.Render(hook, payload)
+	if err != nil || version != "2" {
+		t.Fatalf("Expected a version 2 body, but got %q, %v", version, err)
+	}
+	var v2 webhooks.PayloadV2Body
+	if err := json.Unmarshal(body, &v2); err != nil {
+		t.Fatalf("Error unmarshaling version 2 body: %v", err)
+	}
+	if v2.ID != "5-42" || v2.Type != "post.update" || v2.Data.EntityID != 7 || !v2.OccurredAt.Equal(now) {
+		t.Fatalf("Expected the version 2 envelope, but got %s", body)
+	}
+
+	// STEP 4: A template takes precedence and values are JSON-escaped
+	hook.Template = `{"text": {{json (printf "%s #%d is now %s" .Event .EntityID .Status)}}}`
+	body, version, err = webhooks.Render(hook, payload)
+	if err != nil || version != "template" {
+		t.Fatalf("Expected a templated body, but got %q, %v", version, err)
+	}
+	if string(body) != `{"text": "post.update #7 is now published"}` {
+		t.Fatalf("Expected the Slack-shaped body, but got %s", body)
+	}
+
+	// STEP 5: Templates must parse, use known fields and render JSON
+	for _, tmpl := range []string{`{"text": {{.Event}`, `{"text": {{json .Missing}}}`, `text: {{.Event}}`} {
+		if err := webhooks.ValidateTemplate(tmpl); !errors.Is(err, webhooks.ErrInvalidTemplate) {
+			t.Errorf("Expected %q to be rejected, but got %v", tmpl, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"bufio"
+	"cms-backend/presence"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialWebSocket completes a WebSocket handshake with server and returns the
+// raw connection, leaving the test to write frames itself
+func dialWebSocket(t *testing.T, server *httptest.Server) net.Conn {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Error dialing server: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	handshake := "GET / HTTP/1.1\r\n" +
+		"Host: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatalf("Error writing handshake: %v", err)
+	}
+	response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("Error reading handshake response: %v", err)
+	}
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101, but got %d", response.StatusCode)
+	}
+	return conn
+}
+
+// readMessageServer upgrades each request and reports the result of reading
+// one message from it
+func readMessageServer(t *testing.T) (*httptest.Server, chan error) {
+	errs := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := presence.Upgrade(w, r)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+		_, err = conn.ReadMessage()
+		errs <- err
+	}))
+	t.Cleanup(server.Close)
+	return server, errs
+}
+
+func TestWebSocketDropsStalledClients(t *testing.T) {
+	// STEP 1: Test Setup; a connection may stay silent for 100ms
+	previous := presence.ReadTimeout
+	presence.ReadTimeout = 100 * time.Millisecond
+	defer func() { presence.ReadTimeout = previous }()
+	server, errs := readMessageServer(t)
+
+	// STEP 2: The client completes the handshake, then sends nothing
+	dialWebSocket(t, server)
+
+	// STEP 3: Response Validation; the read gives up instead of blocking
+	select {
+	case err := <-errs:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("Expected a read timeout, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the stalled connection to be dropped")
+	}
+}
+
+func TestWebSocketRejectsOversizedControlFrames(t *testing.T) {
+	// STEP 1: Test Setup
+	server, errs := readMessageServer(t)
+	conn := dialWebSocket(t, server)
+
+	// STEP 2: The client sends a masked ping with a 126-byte payload
+	frame := []byte{0x80 | 0x9, 0x80 | 126, 0, 126, 0, 0, 0, 0}
+	frame = append(frame, make([]byte, 126)...)
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatalf("Error writing frame: %v", err)
+	}
+
+	// STEP 3: Response Validation
+	select {
+	case err := <-errs:
+		if !errors.Is(err, presence.ErrInvalidControlFrame) {
+			t.Fatalf("Expected ErrInvalidControlFrame, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the oversized ping to be rejected")
+	}
+}
+
+func TestWebSocketRejectsFragmentedControlFrames(t *testing.T) {
+	// STEP 1: Test Setup
+	server, errs := readMessageServer(t)
+	conn := dialWebSocket(t, server)
+
+	// STEP 2: The client sends a masked, empty ping without the FIN bit
+	if _, err := conn.Write([]byte{0x9, 0x80, 0, 0, 0, 0}); err != nil {
+		t.Fatalf("Error writing frame: %v", err)
+	}
+
+	// STEP 3: Response Validation
+	select {
+	case err := <-errs:
+		if !errors.Is(err, presence.ErrInvalidControlFrame) {
+			t.Fatalf("Expected ErrInvalidControlFrame, but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected the fragmented ping to be rejected")
+	}
+}
@@ -0,0 +1,97 @@
+// Package trash permanently deletes content nothing uses any more once it has
+// been kept for a retention period. Posts and pages are deleted outright
+// rather than kept in a trash, so what is left to purge is media that no
+// post, page, playlist or author profile refers to.
+package trash
+
+import (
+	"cms-backend/models"
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// purgeBatchSize caps the media deleted per run, so a first run on a large
+// library does not hold one long transaction
+const purgeBatchSize = 500
+
+// Policy purges unused media older than RetentionDays
+type Policy struct {
+	RetentionDays int
+}
+
+// Report lists what a policy would purge at Cutoff
+type Report struct {
+	RetentionDays int            `json:"retention_days"`
+	Cutoff        time.Time      `json:"cutoff"`
+	Media         []models.Media `json:"media"`
+}
+
+// Default is the policy the scheduler runs; nil disables purging. It is set
+// once at startup.
+var Default *Policy
+
+// FromEnv returns a policy purging unused media after TRASH_RETENTION_DAYS,
+// or nil when it is not set, since purged media cannot be recovered
+func FromEnv() *Policy {
+	value := os.Getenv("TRASH_RETENTION_DAYS")
+	if value == "" {
+		return nil
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil || days < 1 {
+		log.Printf("Ignoring invalid TRASH_RETENTION_DAYS %q", value)
+		return nil
+	}
+	return &Policy{RetentionDays: days}
+}
+
+// Cutoff is the time media must have been created before to be purged at now
+func (p *Policy) Cutoff(now time.Time) time.Time {
+	return now.AddDate(0, 0, -p.RetentionDays)
+}
+
+// Unused returns a query for the media created before cutoff that nothing
+// refers to, by foreign key or by URL in the content of a post or page
+func Unused(db *gorm.DB, cutoff time.Time) *gorm.DB {
+	return db.Model(&models.Media{}).
+		Where("media.created_at < ?", cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM post_media WHERE post_media.media_id = media.id)").
+		Where("NOT EXISTS (SELECT 1 FROM playlist_items WHERE playlist_items.media_id = media.id)").
+		Where("NOT EXISTS (SELECT 1 FROM users WHERE users.avatar_id = media.id)").
+		Where("NOT EXISTS (SELECT 1 FROM posts WHERE strpos(posts.content, media.url) > 0)").
+		Where("NOT EXISTS (SELECT 1 FROM pages WHERE strpos(pages.content, media.url) > 0)")
+}
+
+// Report lists the media the policy would purge at now, oldest first,
+// without deleting anything
+func (p *Policy) Report(ctx context.Context, db *gorm.DB, now time.Time) (*Report, error) {
+	report := &Report{RetentionDays: p.RetentionDays, Cutoff: p.Cutoff(now), Media: []models.Media{}}
+	if err := Unused(db.WithContext(ctx), report.Cutoff).Order("media.created_at").Order("media.id").Find(&report.Media).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// Purge deletes up to one batch of the media the policy would purge at now
+func (p *Policy) Purge(ctx context.Context, db *gorm.DB, now time.Time) error {
+	db = db.WithContext(ctx)
+	var ids []uint
+	if err := Unused(db, p.Cutoff(now)).Order("media.id").Limit(purgeBatchSize).Pluck("media.id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	result := db.Where("id IN ?", ids).Delete(&models.Media{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Printf("Purged %d unused media older than %d days", result.RowsAffected, p.RetentionDays)
+	return nil
+}
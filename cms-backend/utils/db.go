@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"cms-backend/querybudget"
+	"database/sql"
 	"fmt"
 	"os"
+	"strconv"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -10,11 +14,15 @@ import (
 
 // ConnectDB initializes the database connection
 func ConnectDB() (*gorm.DB, error) {
+    return ConnectDBHost(os.Getenv("DB_HOST"), os.Getenv("DB_PORT"))
+}
+
+// ConnectDBHost connects to the configured database on another server, such as
+// a read replica, with the same credentials
+func ConnectDBHost(dbHost, dbPort string) (*gorm.DB, error) {
     dbUser := os.Getenv("DB_USER")
     dbPassword := os.Getenv("DB_PASSWORD")
     dbName := os.Getenv("DB_NAME")
-    dbHost := os.Getenv("DB_HOST")
-    dbPort := os.Getenv("DB_PORT")
 
     dsn := fmt.Sprintf(
         "host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
@@ -26,5 +34,105 @@ func ConnectDB() (*gorm.DB, error) {
         return nil, err
     }
 
+    // Count statements per request so handlers running too many are flagged
+    if err := querybudget.Register(db); err != nil {
+        return nil, err
+    }
+
+    sqlDB, err := db.DB()
+    if err != nil {
+        return nil, err
+    }
+    pool, err := PoolConfigFromEnv()
+    if err != nil {
+        return nil, err
+    }
+    pool.Apply(sqlDB)
+
     return db, nil
 }
+
+// PoolConfig sizes the database connection pool
+type PoolConfig struct {
+    MaxOpenConns    int
+    MaxIdleConns    int
+    ConnMaxLifetime time.Duration
+    ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolConfig bounds the pool so bursts queue for a connection instead of
+// exhausting the server's connection limit
+var DefaultPoolConfig = PoolConfig{
+    MaxOpenConns:    25,
+    MaxIdleConns:    10,
+    ConnMaxLifetime: 30 * time.Minute,
+    ConnMaxIdleTime: 5 * time.Minute,
+}
+
+// PoolConfigFromEnv reads DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,
+// DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME over DefaultPoolConfig.
+// Zero connection counts and durations mean unlimited.
+func PoolConfigFromEnv() (PoolConfig, error) {
+    config := DefaultPoolConfig
+    for name, target := range map[string]*int{
+        "DB_MAX_OPEN_CONNS": &config.MaxOpenConns,
+        "DB_MAX_IDLE_CONNS": &config.MaxIdleConns,
+    } {
+        if value := os.Getenv(name); value != "" {
+            parsed, err := strconv.Atoi(value)
+            if err != nil || parsed < 0 {
+                return config, fmt.Errorf("invalid %s %q", name, value)
+            }
+            *target = parsed
+        }
+    }
+    for name, target := range map[string]*time.Duration{
+        "DB_CONN_MAX_LIFETIME":  &config.ConnMaxLifetime,
+        "DB_CONN_MAX_IDLE_TIME": &config.ConnMaxIdleTime,
+    } {
+        if value := os.Getenv(name); value != "" {
+            parsed, err := time.ParseDuration(value)
+            if err != nil || parsed < 0 {
+                return config, fmt.Errorf("invalid %s %q", name, value)
+            }
+            *target = parsed
+        }
+    }
+    return config, nil
+}
+
+// Apply configures a connection pool
+func (p PoolConfig) Apply(db *sql.DB) {
+    db.SetMaxOpenConns(p.MaxOpenConns)
+    db.SetMaxIdleConns(p.MaxIdleConns)
+    db.SetConnMaxLifetime(p.ConnMaxLifetime)
+    db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// PoolStats is the connection pool usage reported by the readiness probe
+type PoolStats struct {
+    MaxOpen           int   `json:"max_open"`
+    Open              int   `json:"open"`
+    InUse             int   `json:"in_use"`
+    Idle              int   `json:"idle"`
+    WaitCount         int64 `json:"wait_count"`
+    WaitDurationMS    int64 `json:"wait_duration_ms"`
+    MaxIdleClosed     int64 `json:"max_idle_closed"`
+    MaxIdleTimeClosed int64 `json:"max_idle_time_closed"`
+    MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// NewPoolStats converts database/sql pool statistics for reporting
+func NewPoolStats(stats sql.DBStats) PoolStats {
+    return PoolStats{
+        MaxOpen:           stats.MaxOpenConnections,
+        Open:              stats.OpenConnections,
+        InUse:             stats.InUse,
+        Idle:              stats.Idle,
+        WaitCount:         stats.WaitCount,
+        WaitDurationMS:    stats.WaitDuration.Milliseconds(),
+        MaxIdleClosed:     stats.MaxIdleClosed,
+        MaxIdleTimeClosed: stats.MaxIdleTimeClosed,
+        MaxLifetimeClosed: stats.MaxLifetimeClosed,
+    }
+}
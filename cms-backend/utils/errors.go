@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Kinds of domain errors. Handlers report them with Fail and HandleErrors
+// answers each kind with its own status and error code.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrValidation = errors.New("validation failed")
+	ErrConflict   = errors.New("conflict")
+)
+
+// Error is a domain error of one kind with a message safe to show clients
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// NotFound returns an ErrNotFound error with message
+func NotFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+// Invalid returns an ErrValidation error with message
+func Invalid(message string) error {
+	return &Error{Kind: ErrValidation, Message: message}
+}
+
+// Conflict returns an ErrConflict error with message
+func Conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+// errorStatus returns the status and error code err is answered with
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, ErrorCodeNotFound
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest, ErrorCodeValidation
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict, ErrorCodeConflict
+	}
+	return http.StatusInternalServerError, ErrorCodeInternal
+}
+
+// ErrorResponse translates err into the response clients see. Errors that
+// are not domain errors are logged and reported without their details, so
+// database errors never reach clients.
+func ErrorResponse(err error) HTTPError {
+	status, code := errorStatus(err)
+	message := "Record not found"
+	var domain *Error
+	switch {
+	case errors.As(err, &domain):
+		message = domain.Message
+	case status == http.StatusInternalServerError:
+		log.Printf("Internal error: %v", err)
+		message = "Internal server error"
+	}
+	return HTTPError{Code: status, Message: message, ErrorCode: code}
+}
+
+// Fail records err for HandleErrors to answer once the handler returns. The
+// status is set at once so middleware inspecting it, such as the response
+// cache, sees the failure.
+func Fail(c *gin.Context, err error) {
+	status, _ := errorStatus(err)
+	c.Status(status)
+	c.Error(err)
+}
+
+// WriteError writes the response for err
+func WriteError(c *gin.Context, err error) {
+	response := ErrorResponse(err)
+	c.JSON(response.Code, response)
+}
+
+// HandleErrors is middleware answering the last error recorded with Fail
+// when the handler wrote no response of its own
+func HandleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) > 0 && !c.Writer.Written() {
+			WriteError(c, c.Errors.Last().Err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"regexp"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// uuidPattern matches a UUID in its canonical form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// ParseID parses a record ID. IDs are positive integers that fit the integer
+// primary keys; anything else is an ErrValidation error.
+func ParseID(value string) (uint, error) {
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || id == 0 {
+		return 0, Invalid("ID must be a positive integer")
+	}
+	return uint(id), nil
+}
+
+// IsUUID reports whether value is a UUID in its canonical form
+func IsUUID(value string) bool {
+	return uuidPattern.MatchString(value)
+}
+
+// ResolveUUIDs returns the IDs of the records in table with the given UUIDs,
+// keyed by UUID; UUIDs naming no record are left out
+func ResolveUUIDs(db *gorm.DB, table string, uuids []string) (map[string]uint, error) {
+	var rows []struct {
+		ID   uint
+		UUID string
+	}
+	if err := db.Table(table).Select("id", "uuid").Where("uuid IN ?", uuids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(rows))
+	for _, row := range rows {
+		ids[row.UUID] = row.ID
+	}
+	return ids, nil
+}
@@ -10,4 +10,54 @@ type MessageResponse struct {
 type HTTPError struct {
     Code    int    `json:"code" example:"400"`
     Message string `json:"message" example:"Invalid input"`
+    // ErrorCode is a stable machine-readable reason, set for errors clients may handle specifically
+    ErrorCode string `json:"error_code,omitempty" example:"unsafe_url"`
 }
+
+// Error codes reported in HTTPError.ErrorCode
+const (
+    ErrorCodeInvalidURL        = "invalid_url"
+    ErrorCodeUnsafeURL         = "unsafe_url"
+    ErrorCodeOverloaded        = "overloaded"
+    ErrorCodeInvalidFilter     = "invalid_filter"
+    ErrorCodeInvalidTemplate   = "invalid_template"
+    ErrorCodeCursorExpired     = "cursor_expired"
+    ErrorCodeSubmissionLimit   = "submission_limit"
+    ErrorCodeMediaInfected     = "media_infected"
+    ErrorCodeWrongTokenScope   = "wrong_token_scope"
+    ErrorCodeInvalidSubmission = "invalid_submission"
+    ErrorCodeCaptchaFailed     = "captcha_failed"
+    ErrorCodePossibleDuplicate = "possible_duplicate"
+    ErrorCodeNotFound          = "not_found"
+    ErrorCodeValidation        = "validation_failed"
+    ErrorCodeConflict          = "conflict"
+    ErrorCodeInternal          = "internal_error"
+    ErrorCodeBodyTooLarge      = "body_too_large"
+    ErrorCodeQuotaExceeded     = "quota_exceeded"
+    ErrorCodeLockedOut         = "locked_out"
+    ErrorCodeMissingScope      = "missing_scope"
+    ErrorCodeAuthRequired      = "auth_required"
+)
+
+// Envelope wraps every JSON response from API version 2 onwards
+type Envelope struct {
+    Data   interface{}  `json:"data"`
+    Meta   EnvelopeMeta `json:"meta"`
+    Errors []HTTPError  `json:"errors,omitempty"`
+}
+
+// EnvelopeMeta describes an enveloped response
+type EnvelopeMeta struct {
+    APIVersion int `json:"api_version" example:"2"`
+    // Count is the number of items when data is a list
+    Count *int `json:"count,omitempty" example:"10"`
+    // NextCursor continues a paginated list, taken from NextCursorHeader
+    NextCursor string `json:"next_cursor,omitempty" example:"eyJ0IjoiMjAyNC0wMy0wMVQxMjowMDowMFoiLCJpZCI6NDJ9"`
+}
+
+// NextCursorHeader carries the cursor for the next page of a paginated list;
+// it is absent on the last page
+const NextCursorHeader = "X-Next-Cursor"
+
+// TotalCountHeader carries the number of items a list has over all its pages
+const TotalCountHeader = "X-Total-Count"
@@ -0,0 +1,34 @@
+package utils
+
+import "strings"
+
+// maxSlugLength leaves room for a "-N" suffix within the 255 character column
+const maxSlugLength = 200
+
+// Slugify converts a title into a lowercase, hyphen-separated URL slug
+func Slugify(title string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(title) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+		if b.Len() >= maxSlugLength {
+			break
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if slug == "" {
+		return "untitled"
+	}
+	return slug
+}
+
+// IsValidSlug reports whether s is already in the form Slugify produces
+func IsValidSlug(s string) bool {
+	return s != "" && len(s) <= maxSlugLength && Slugify(s) == s
+}
@@ -30,7 +30,7 @@ func SetupRouterAndMockDB(t *testing.T) (*gin.Engine, *gorm.DB, sqlmock.Sqlmock)
 	}
 
 	router := gin.Default()
-	router.Use(func(c *gin.Context) {
+	router.Use(HandleErrors(), func(c *gin.Context) {
 		c.Set("db", db)
 	})
 	return router, db, mock
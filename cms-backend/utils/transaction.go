@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"gorm.io/gorm"
+)
+
+// WithTransaction runs fn in a transaction on db. The transaction is
+// committed when fn returns nil and rolled back when it returns an error or
+// panics; the error is returned and the panic carried on. An error
+// committing is returned too.
+func WithTransaction(db *gorm.DB, fn func(tx *gorm.DB) error) (err error) {
+	tx := db.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,133 @@
+// Package views counts post views in memory and writes them to Postgres in
+// batches, so a busy post costs one upsert per flush instead of one per hit.
+// Repeated hits from the same viewer within DebounceWindow count once.
+package views
+
+import (
+	"cms-backend/models"
+	"context"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DebounceWindow is how long repeated views from one viewer are ignored
+const DebounceWindow = 30 * time.Minute
+
+// maxViewers bounds the memory used for debouncing; when full, views count without it
+const maxViewers = 100000
+
+type dayKey struct {
+	postID uint
+	day    time.Time
+}
+
+type viewerKey struct {
+	postID uint
+	viewer [sha256.Size]byte
+}
+
+// Counter buffers view counts until they are flushed
+type Counter struct {
+	// Now is the clock used for days and debouncing; tests replace it
+	Now func() time.Time
+
+	mu       sync.Mutex
+	pending  map[dayKey]int64
+	lastSeen map[viewerKey]time.Time
+}
+
+// Default is the process-wide counter flushed by the scheduler
+var Default = NewCounter()
+
+// NewCounter creates an empty counter
+func NewCounter() *Counter {
+	return &Counter{
+		Now:      time.Now,
+		pending:  make(map[dayKey]int64),
+		lastSeen: make(map[viewerKey]time.Time),
+	}
+}
+
+// Record counts a view of a post by viewer, an opaque identifier such as the
+// client address and user agent. It reports whether the view was counted.
+func (c *Counter) Record(postID uint, viewer string) bool {
+	now := c.Now().UTC()
+	key := viewerKey{postID: postID, viewer: sha256.Sum256([]byte(viewer))}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if seen, ok := c.lastSeen[key]; ok && now.Sub(seen) < DebounceWindow {
+		return false
+	}
+	if len(c.lastSeen) < maxViewers {
+		c.lastSeen[key] = now
+	}
+
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	c.pending[dayKey{postID: postID, day: day}]++
+	return true
+}
+
+// Flush adds the buffered counts to post_views. On failure the counts are put
+// back so they are retried on the next flush.
+func (c *Counter) Flush(ctx context.Context, db *gorm.DB) error {
+	now := c.Now()
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[dayKey]int64)
+	for key, seen := range c.lastSeen {
+		if now.Sub(seen) >= DebounceWindow {
+			delete(c.lastSeen, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	err := c.write(ctx, db, pending)
+	if err != nil {
+		c.mu.Lock()
+		for key, count := range pending {
+			c.pending[key] += count
+		}
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *Counter) write(ctx context.Context, db *gorm.DB, pending map[dayKey]int64) error {
+	// Drop views of posts deleted since they were recorded
+	ids := make([]uint, 0, len(pending))
+	for key := range pending {
+		ids = append(ids, key.postID)
+	}
+	var existing []uint
+	if err := db.WithContext(ctx).Model(&models.Post{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	exists := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	rows := make([]models.PostView, 0, len(pending))
+	for key, count := range pending {
+		if exists[key.postID] {
+			rows = append(rows, models.PostView{PostID: key.postID, Day: key.day, Views: count})
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	return db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "post_id"}, {Name: "day"}},
+		DoUpdates: clause.Set{{Column: clause.Column{Name: "views"}, Value: gorm.Expr("post_views.views + EXCLUDED.views")}},
+	}).Create(&rows).Error
+}
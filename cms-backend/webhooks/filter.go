@@ -0,0 +1,278 @@
+package webhooks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Fields a filter can test
+var filterFields = map[string]bool{
+	"entity": true,
+	"action": true,
+	"status": true,
+	"tag":    true,
+	"id":     true,
+}
+
+// Event describes a content change in the terms filters test
+type Event struct {
+	// Entity is "post", "page", "media", "tag" or "series"
+	Entity string
+
+	// Action is "insert", "update" or "delete"
+	Action string
+
+	ID uint
+
+	// Status is the publishing status of posts and pages, empty otherwise
+	Status string
+
+	// Tags are the names of a post's tags
+	Tags []string
+}
+
+// values returns the values of an event field; tag is the only field with several
+func (e Event) values(field string) []string {
+	switch field {
+	case "entity":
+		return []string{e.Entity}
+	case "action":
+		return []string{e.Action}
+	case "status":
+		return []string{e.Status}
+	case "tag":
+		return e.Tags
+	case "id":
+		return []string{strconv.FormatUint(uint64(e.ID), 10)}
+	}
+	return nil
+}
+
+// Filter selects the events delivered to a webhook. Filters combine comparisons
+// such as `entity=post`, `status!=draft` or `tag="press release"` with AND, OR,
+// NOT and parentheses; AND binds tighter than OR. Keywords are case-insensitive.
+// `tag=x` matches when any of the post's tags is x and `tag!=x` when none is.
+// The empty filter matches every event.
+type Filter struct {
+	root node
+}
+
+// Match reports whether an event passes the filter
+func (f *Filter) Match(e Event) bool {
+	if f == nil || f.root == nil {
+		return true
+	}
+	return f.root.match(e)
+}
+
+type node interface {
+	match(e Event) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) match(e Event) bool { return n.left.match(e) || n.right.match(e) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) match(e Event) bool { return n.left.match(e) && n.right.match(e) }
+
+type notNode struct{ operand node }
+
+func (n notNode) match(e Event) bool { return !n.operand.match(e) }
+
+type comparison struct {
+	field  string
+	value  string
+	negate bool
+}
+
+func (n comparison) match(e Event) bool {
+	found := false
+	for _, value := range e.values(n.field) {
+		if strings.EqualFold(value, n.value) {
+			found = true
+			break
+		}
+	}
+	return found != n.negate
+}
+
+// ParseFilter parses a filter expression
+func ParseFilter(expr string) (*Filter, error) {
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return &Filter{}, nil
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos].text)
+	}
+	return &Filter{root: root}, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenEq
+	tokenNotEq
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func tokenize(expr string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")"})
+			i++
+		case r == '=':
+			tokens = append(tokens, token{tokenEq, "="})
+			i++
+		case r == '!':
+			if i+1 >= len(runes) || runes[i+1] != '=' {
+				return nil, fmt.Errorf("expected != at offset %d in filter", i)
+			}
+			tokens = append(tokens, token{tokenNotEq, "!="})
+			i += 2
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in filter")
+			}
+			tokens = append(tokens, token{tokenString, string(runes[i+1 : end])})
+			i = end + 1
+		case isWordRune(r):
+			end := i
+			for end < len(runes) && isWordRune(runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q in filter", r)
+		}
+	}
+	return tokens, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("AND") {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("filter ends unexpectedly")
+	}
+	if p.peekKeyword("NOT") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	}
+	if p.tokens[p.pos].kind == tokenLParen {
+		p.pos++
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenRParen {
+			return nil, fmt.Errorf("missing ) in filter")
+		}
+		p.pos++
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	if p.pos+3 > len(p.tokens) {
+		return nil, fmt.Errorf("filter ends unexpectedly")
+	}
+	field, op, value := p.tokens[p.pos], p.tokens[p.pos+1], p.tokens[p.pos+2]
+	if field.kind != tokenWord {
+		return nil, fmt.Errorf("expected a field name, got %q", field.text)
+	}
+	name := strings.ToLower(field.text)
+	if !filterFields[name] {
+		return nil, fmt.Errorf("unknown filter field %q", field.text)
+	}
+	if op.kind != tokenEq && op.kind != tokenNotEq {
+		return nil, fmt.Errorf("expected = or != after %s", field.text)
+	}
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("expected a value after %s%s", field.text, op.text)
+	}
+	p.pos += 3
+	return comparison{field: name, value: value.text, negate: op.kind == tokenNotEq}, nil
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"bytes"
+	"cms-backend/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Payload versions. Version 1 is the flat Payload; version 2 wraps the change
+// in an envelope with a delivery ID. Webhooks keep the version they registered
+// with, so receivers only change shape when they opt in.
+const (
+	PayloadV1            = 1
+	PayloadV2            = 2
+	LatestPayloadVersion = PayloadV2
+)
+
+// VersionHeader reports the payload version of a delivery; templated
+// deliveries report "template"
+const VersionHeader = "X-Webhook-Version"
+
+// maxTemplateOutput caps the size of a rendered template
+const maxTemplateOutput = 64 * 1024
+
+// ErrInvalidTemplate is returned when a payload template cannot be parsed or
+// does not render valid JSON
+var ErrInvalidTemplate = errors.New("invalid payload template")
+
+// PayloadV2Body is the version 2 delivery body
+type PayloadV2Body struct {
+	// ID identifies the delivery; retries of the same change reuse it
+	ID         string        `json:"id"`
+	Type       string        `json:"type"`
+	Version    int           `json:"version"`
+	OccurredAt time.Time     `json:"occurred_at"`
+	Data       PayloadV2Data `json:"data"`
+}
+
+// PayloadV2Data describes the changed entity in a version 2 body
+type PayloadV2Data struct {
+	Seq        int64    `json:"seq"`
+	EntityType string   `json:"entity_type"`
+	EntityID   uint     `json:"entity_id"`
+	Action     string   `json:"action"`
+	Status     string   `json:"status,omitempty"`
+	Tags       []string `json:"tags"`
+}
+
+// templateFuncs are available to payload templates. json encodes a value, so
+// `{"text": {{json .Event}}}` stays valid JSON whatever the value holds.
+var templateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// ValidVersion reports whether version is a supported payload version
+func ValidVersion(version int) bool {
+	return version >= PayloadV1 && version <= LatestPayloadVersion
+}
+
+// ValidateTemplate checks that a payload template parses and renders valid
+// JSON for the sample payload
+func ValidateTemplate(text string) error {
+	if text == "" {
+		return nil
+	}
+	_, err := RenderTemplate(text, SamplePayload(time.Now()))
+	return err
+}
+
+// Render returns the delivery body for a webhook and the value of VersionHeader.
+// A template, when set, takes precedence over the payload version.
+func Render(hook models.Webhook, payload Payload) ([]byte, string, error) {
+	if hook.Template != "" {
+		body, err := RenderTemplate(hook.Template, payload)
+		return body, "template", err
+	}
+
+	switch hook.PayloadVersion {
+	case PayloadV1:
+		body, err := json.Marshal(payload)
+		return body, "1", err
+	case PayloadV2:
+		tags := payload.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		body, err := json.Marshal(PayloadV2Body{
+			ID:         deliveryID(hook, payload),
+			Type:       payload.Event,
+			Version:    PayloadV2,
+			OccurredAt: payload.ChangedAt,
+			Data: PayloadV2Data{
+				Seq:        payload.Seq,
+				EntityType: payload.EntityType,
+				EntityID:   payload.EntityID,
+				Action:     payload.Action,
+				Status:     payload.Status,
+				Tags:       tags,
+			},
+		})
+		return body, "2", err
+	}
+	return nil, "", fmt.Errorf("unsupported payload version %d", hook.PayloadVersion)
+}
+
+// RenderTemplate executes a payload template with the given data and checks the
+// output is JSON of at most 64KB. Webhook templates get the version 1 payload
+// fields (.Event, .Seq, .EntityType, .EntityID, .Action, .Status, .Tags, .ChangedAt).
+func RenderTemplate(text string, data interface{}) ([]byte, error) {
+	tmpl, err := template.New("payload").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	if out.Len() > maxTemplateOutput {
+		return nil, fmt.Errorf("%w: output exceeds %d bytes", ErrInvalidTemplate, maxTemplateOutput)
+	}
+	if !json.Valid(out.Bytes()) {
+		return nil, fmt.Errorf("%w: output is not valid JSON", ErrInvalidTemplate)
+	}
+	return out.Bytes(), nil
+}
+
+// deliveryID identifies the delivery of a change to a webhook
+func deliveryID(hook models.Webhook, payload Payload) string {
+	return fmt.Sprintf("%d-%d", hook.ID, payload.Seq)
+}
@@ -0,0 +1,238 @@
+// Package webhooks delivers change feed events to integrator endpoints. Each
+// webhook walks the feed from its own position, filters events server-side and
+// signs every delivery with its secret.
+package webhooks
+
+import (
+	"bytes"
+	"cms-backend/egress"
+	"cms-backend/models"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Delivery headers
+const (
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+// SecretPrefix starts every webhook secret
+const SecretPrefix = "whsec_"
+
+// TestEvent names the sample event sent by test deliveries
+const TestEvent = "webhook.test"
+
+// batchSize caps the changes one webhook processes per dispatch
+const batchSize = 100
+
+// maxResponseBody caps how much of an endpoint's response is kept for reporting
+const maxResponseBody = 1024
+
+// Payload describes a delivered change. It is the version 1 body and the data
+// given to payload templates.
+type Payload struct {
+	// Event is "<entity>.<action>", e.g. "post.update", or TestEvent
+	Event      string    `json:"event"`
+	Seq        int64     `json:"seq"`
+	EntityType string    `json:"entity_type"`
+	EntityID   uint      `json:"entity_id"`
+	Action     string    `json:"action"`
+	Status     string    `json:"status,omitempty"`
+	Tags       []string  `json:"tags,omitempty"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// Result reports the outcome of one delivery
+type Result struct {
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Response   string `json:"response,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// GenerateSecret returns a new random signing secret
+func GenerateSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return SecretPrefix + hex.EncodeToString(buf), nil
+}
+
+// Sign returns the SignatureHeader value for a body: "sha256=" and the hex
+// HMAC-SHA256 of the body keyed with the secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewPayload describes a change and the event built from it
+func NewPayload(change models.Change, event Event) Payload {
+	return Payload{
+		Event:      change.EntityType + "." + change.Action,
+		Seq:        change.Seq,
+		EntityType: change.EntityType,
+		EntityID:   change.EntityID,
+		Action:     change.Action,
+		Status:     event.Status,
+		Tags:       event.Tags,
+		ChangedAt:  change.ChangedAt,
+	}
+}
+
+// SamplePayload is the payload sent by test deliveries
+func SamplePayload(now time.Time) Payload {
+	return Payload{
+		Event:      TestEvent,
+		EntityType: "post",
+		EntityID:   1,
+		Action:     models.ChangeUpdate,
+		Status:     models.StatusPublished,
+		Tags:       []string{"press"},
+		ChangedAt:  now.UTC(),
+	}
+}
+
+// Deliver posts a payload, rendered in the webhook's version or template, to
+// the webhook's URL through the egress policy. Any 2xx response counts as success.
+func Deliver(ctx context.Context, hook models.Webhook, payload Payload) Result {
+	body, version, err := Render(hook, payload)
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return Result{Error: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, payload.Event)
+	req.Header.Set(DeliveryHeader, deliveryID(hook, payload))
+	req.Header.Set(VersionHeader, version)
+	req.Header.Set(SignatureHeader, Sign(hook.Secret, body))
+
+	start := time.Now()
+	resp, err := egress.Default().Do(req)
+	result := Result{DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result.StatusCode = resp.StatusCode
+	result.Response = string(excerpt)
+	result.Success = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !result.Success {
+		result.Error = fmt.Sprintf("endpoint responded %d", resp.StatusCode)
+	}
+	return result
+}
+
+// Dispatch delivers the changes each active webhook has not yet processed, in
+// feed order. A webhook stops at its first failed delivery and retries from
+// there on the next dispatch.
+func Dispatch(ctx context.Context, db *gorm.DB) error {
+	var hooks []models.Webhook
+	if err := db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&hooks).Error; err != nil {
+		return err
+	}
+
+	// Events are shared between webhooks processing the same changes
+	events := make(map[int64]Event)
+	for _, hook := range hooks {
+		if err := dispatchHook(ctx, db, hook, events); err != nil {
+			log.Printf("Webhook %d dispatch failed: %v", hook.ID, err)
+		}
+	}
+	return nil
+}
+
+func dispatchHook(ctx context.Context, db *gorm.DB, hook models.Webhook, events map[int64]Event) error {
+	filter, err := ParseFilter(hook.Filter)
+	if err != nil {
+		return err
+	}
+
+	var changes []models.Change
+	if err := db.WithContext(ctx).Where("seq > ?", hook.LastSeq).Order("seq").Limit(batchSize).Find(&changes).Error; err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	updates := map[string]interface{}{}
+	for _, change := range changes {
+		event, ok := events[change.Seq]
+		if !ok {
+			if event, err = LoadEvent(ctx, db, change); err != nil {
+				return err
+			}
+			events[change.Seq] = event
+		}
+
+		if filter.Match(event) {
+			result := Deliver(ctx, hook, NewPayload(change, event))
+			now := time.Now()
+			updates["last_status"] = result.StatusCode
+			updates["last_error"] = result.Error
+			updates["last_attempt_at"] = &now
+			if !result.Success {
+				break
+			}
+		}
+		updates["last_seq"] = change.Seq
+	}
+	return db.WithContext(ctx).Model(&models.Webhook{}).Where("id = ?", hook.ID).Updates(updates).Error
+}
+
+// LoadEvent builds the filterable event for a change. Posts and pages carry
+// their current status, and posts their tags; deleted entities carry neither.
+func LoadEvent(ctx context.Context, db *gorm.DB, change models.Change) (Event, error) {
+	event := Event{Entity: change.EntityType, Action: change.Action, ID: change.EntityID}
+	if change.Action == models.ChangeDelete {
+		return event, nil
+	}
+
+	switch change.EntityType {
+	case "post":
+		var post models.Post
+		err := db.WithContext(ctx).Preload("Tags").First(&post, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return event, nil
+		}
+		if err != nil {
+			return event, err
+		}
+		event.Status = post.Status
+		for _, tag := range post.Tags {
+			event.Tags = append(event.Tags, tag.Name)
+		}
+	case "page":
+		var page models.Page
+		err := db.WithContext(ctx).First(&page, change.EntityID).Error
+		if err == gorm.ErrRecordNotFound {
+			return event, nil
+		}
+		if err != nil {
+			return event, err
+		}
+		event.Status = page.Status
+	}
+	return event, nil
+}